@@ -0,0 +1,112 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacondbpostgres
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/beacon_srv/internal/beacon"
+	"github.com/scionproto/scion/go/beacon_srv/internal/beacon/beacondbtest"
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/infra/modules/db/dbtest"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+var (
+	// connection is the connection string of the PostgreSQL database used in
+	// the tests. The tests only modify the testSchema schema of the database.
+	// If it is empty, the tests are skipped.
+	connection = flag.String("postgres", "", "PostgreSQL connection string")
+)
+
+const testSchema = "beacondb_test"
+
+var testIA = addr.IA{I: 1, A: 0xff0000000333}
+
+var _ beacondbtest.Testable = (*TestBackend)(nil)
+
+type TestBackend struct {
+	*Backend
+}
+
+func (b *TestBackend) Prepare(t *testing.T, _ context.Context) {
+	if b.Backend != nil {
+		b.Backend.Close()
+	}
+	b.Backend, _ = setupDB(t)
+}
+
+func TestBeaconDBSuite(t *testing.T) {
+	skipWithoutPostgres(t)
+	tdb := &TestBackend{}
+	Convey("BeaconDBSuite", t, func() {
+		beacondbtest.Test(t, tdb)
+	})
+}
+
+func TestOpenExisting(t *testing.T) {
+	skipWithoutPostgres(t)
+	Convey("New should not overwrite an existing database if versions match", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		db, conn := setupDB(t)
+		ctx, cancelF := context.WithTimeout(context.Background(), time.Second)
+		defer cancelF()
+		b := beacondbtest.InsertBeacon(t, ctrl, db, beacondbtest.Info1, 2, 10, beacon.UsageProp)
+		db.Close()
+		// Open existing database
+		db, err := New(conn, testIA)
+		xtest.FailOnErr(t, err)
+		defer db.Close()
+		res, err := db.CandidateBeacons(ctx, 10, beacon.UsageProp, addr.IA{})
+		SoMsg("err", err, ShouldBeNil)
+		beacondbtest.CheckResult(t, res, b)
+	})
+}
+
+func TestOpenNewer(t *testing.T) {
+	skipWithoutPostgres(t)
+	Convey("New should not overwrite an existing database if it's of a newer version", t, func() {
+		b, conn := setupDB(t)
+		// Write a newer version
+		_, err := b.db.Exec("UPDATE SchemaVersion SET Version = $1", SchemaVersion+1)
+		xtest.FailOnErr(t, err)
+		b.Close()
+		b, err = New(conn, testIA)
+		SoMsg("Backend nil", b, ShouldBeNil)
+		SoMsg("Err returned", err, ShouldNotBeNil)
+	})
+}
+
+func skipWithoutPostgres(t *testing.T) {
+	if *connection == "" {
+		t.Skip("No PostgreSQL database specified, use -postgres to run the test")
+	}
+}
+
+// setupDB drops the content of the test schema and returns a freshly set up
+// backend, and the connection string that uses the test schema.
+func setupDB(t *testing.T) (*Backend, string) {
+	conn := dbtest.PostgresSchema(t, *connection, testSchema)
+	b, err := New(conn, testIA)
+	xtest.FailOnErr(t, err, "Failed to open DB")
+	return b, conn
+}
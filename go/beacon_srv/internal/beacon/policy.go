@@ -44,6 +44,18 @@ const (
 	DefaultCandidateSetSize = 100
 	// DefaultMaxHopsLength is the default MaxHopsLength value.
 	DefaultMaxHopsLength = 10
+	// DefaultHopCountWeight is the default HopCount weight.
+	DefaultHopCountWeight = 1.0
+	// DefaultDisjointnessWeight is the default Disjointness weight.
+	DefaultDisjointnessWeight = 1.0
+	// DefaultExpirationWeight is the default Expiration weight.
+	DefaultExpirationWeight = 0.5
+	// DefaultMTUWeight is the default MTU weight.
+	DefaultMTUWeight = 0.25
+	// DefaultLatencyWeight is the default Latency weight.
+	DefaultLatencyWeight = 0.5
+	// DefaultBandwidthWeight is the default Bandwidth weight.
+	DefaultBandwidthWeight = 0.25
 )
 
 // Policies keeps track of all policies for a non-core beacon store.
@@ -63,7 +75,7 @@ func (p *Policies) InitDefaults() {
 	p.DownReg.initDefaults(DownRegPolicy)
 }
 
-// Validate checks that each policy is of the correct type.
+// Validate checks that each policy is of the correct type and valid.
 func (p *Policies) Validate() error {
 	if p.Prop.Type != PropPolicy {
 		return common.NewBasicError("Invalid policy type", nil,
@@ -77,6 +89,11 @@ func (p *Policies) Validate() error {
 		return common.NewBasicError("Invalid policy type", nil,
 			"expected", DownRegPolicy, "actual", p.DownReg.Type)
 	}
+	for _, policy := range []*Policy{&p.Prop, &p.UpReg, &p.DownReg} {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -129,7 +146,7 @@ func (p *CorePolicies) InitDefaults() {
 	p.CoreReg.initDefaults(CoreRegPolicy)
 }
 
-// Validate checks that each policy is of the correct type.
+// Validate checks that each policy is of the correct type and valid.
 func (p *CorePolicies) Validate() error {
 	if p.Prop.Type != PropPolicy {
 		return common.NewBasicError("Invalid policy type", nil,
@@ -139,6 +156,11 @@ func (p *CorePolicies) Validate() error {
 		return common.NewBasicError("Invalid policy type", nil,
 			"expected", CoreRegPolicy, "actual", p.CoreReg.Type)
 	}
+	for _, policy := range []*Policy{&p.Prop, &p.CoreReg} {
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
 	return nil
 }
 
@@ -182,6 +204,12 @@ type Policy struct {
 	Filter Filter `yaml:"Filter"`
 	// Type is the policy type.
 	Type PolicyType `yaml:"Type"`
+	// SelectionAlgorithm is the algorithm used to select the best set from
+	// the candidate set.
+	SelectionAlgorithm AlgorithmType `yaml:"SelectionAlgorithm"`
+	// Weights are the weights used by the weighted selection algorithm. They
+	// are ignored by all other algorithms.
+	Weights Weights `yaml:"Weights"`
 }
 
 // InitDefaults initializes the default values for unset fields.
@@ -192,7 +220,21 @@ func (p *Policy) InitDefaults() {
 	if p.CandidateSetSize == 0 {
 		p.CandidateSetSize = DefaultCandidateSetSize
 	}
+	if p.SelectionAlgorithm == "" {
+		p.SelectionAlgorithm = DefaultAlgorithm
+	}
 	p.Filter.InitDefaults()
+	p.Weights.InitDefaults()
+}
+
+// Validate checks that the selection algorithm is supported and the weights
+// are valid.
+func (p *Policy) Validate() error {
+	if _, ok := algorithms[p.SelectionAlgorithm]; !ok {
+		return common.NewBasicError("Unsupported selection algorithm", nil,
+			"type", p.Type, "algo", p.SelectionAlgorithm)
+	}
+	return p.Weights.Validate()
 }
 
 func (p *Policy) initDefaults(t PolicyType) {
@@ -216,6 +258,9 @@ func ParseYaml(b common.RawBytes, t PolicyType) (*Policy, error) {
 		return nil, common.NewBasicError("Specified policy type does not match", nil,
 			"expected", t, "actual", p.Type)
 	}
+	if err := p.Validate(); err != nil {
+		return nil, err
+	}
 	return p, nil
 }
 
@@ -229,6 +274,52 @@ func LoadFromYaml(path string, t PolicyType) (*Policy, error) {
 	return ParseYaml(b, t)
 }
 
+// Weights are the weights of the individual beacon properties that are
+// considered by the weighted selection algorithm. Each property is normalized
+// to the range [0, 1] before it is weighted.
+type Weights struct {
+	// HopCount is the weight of the segment length. Shorter segments score
+	// higher.
+	HopCount float64 `yaml:"HopCount"`
+	// Disjointness is the weight of the link disjointness to the beacons
+	// that are already selected.
+	Disjointness float64 `yaml:"Disjointness"`
+	// Expiration is the weight of the remaining lifetime of the segment.
+	Expiration float64 `yaml:"Expiration"`
+	// MTU is the weight of the minimum MTU along the segment.
+	MTU float64 `yaml:"MTU"`
+	// Latency is the weight of the latency along the segment, according to
+	// the static info extensions of the AS entries. Segments with lower
+	// latency score higher.
+	Latency float64 `yaml:"Latency"`
+	// Bandwidth is the weight of the minimum link bandwidth along the
+	// segment, according to the static info extensions of the AS entries.
+	Bandwidth float64 `yaml:"Bandwidth"`
+}
+
+// InitDefaults initializes the default weights if no weight is set.
+func (w *Weights) InitDefaults() {
+	if *w == (Weights{}) {
+		*w = Weights{
+			HopCount:     DefaultHopCountWeight,
+			Disjointness: DefaultDisjointnessWeight,
+			Expiration:   DefaultExpirationWeight,
+			MTU:          DefaultMTUWeight,
+			Latency:      DefaultLatencyWeight,
+			Bandwidth:    DefaultBandwidthWeight,
+		}
+	}
+}
+
+// Validate checks that no weight is negative.
+func (w Weights) Validate() error {
+	if w.HopCount < 0 || w.Disjointness < 0 || w.Expiration < 0 || w.MTU < 0 ||
+		w.Latency < 0 || w.Bandwidth < 0 {
+		return common.NewBasicError("Weights must not be negative", nil, "weights", w)
+	}
+	return nil
+}
+
 // Filter filters beacons.
 type Filter struct {
 	// MaxHopsLength is the maximum number of hops a segment can have.
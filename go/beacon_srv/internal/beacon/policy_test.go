@@ -74,6 +74,34 @@ func TestLoadFromYaml(t *testing.T) {
 	})
 }
 
+func TestLoadFromYamlSelectionAlgorithm(t *testing.T) {
+	Convey("Given a policy file with the weighted selection algorithm", t, func() {
+		p, err := beacon.LoadFromYaml("testdata/weightedPolicy.yml", beacon.PropPolicy)
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("SelectionAlgorithm", p.SelectionAlgorithm, ShouldEqual, beacon.WeightedAlgorithm)
+		SoMsg("Weights", p.Weights, ShouldResemble, beacon.Weights{
+			HopCount:     0.5,
+			Disjointness: 2,
+			Expiration:   0.1,
+			Latency:      1,
+			Bandwidth:    0.5,
+		})
+	})
+	Convey("Given a policy file without selection algorithm", t, func() {
+		p, err := beacon.LoadFromYaml("testdata/policy.yml", beacon.PropPolicy)
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("SelectionAlgorithm", p.SelectionAlgorithm, ShouldEqual, beacon.DefaultAlgorithm)
+	})
+	Convey("Given a policy with an unknown selection algorithm", t, func() {
+		_, err := beacon.ParseYaml([]byte("SelectionAlgorithm: Unknown"), beacon.PropPolicy)
+		SoMsg("err", err, ShouldNotBeNil)
+	})
+	Convey("Given a policy with negative weights", t, func() {
+		_, err := beacon.ParseYaml([]byte("Weights: {HopCount: -1}"), beacon.PropPolicy)
+		SoMsg("err", err, ShouldNotBeNil)
+	})
+}
+
 func TestFilterApply(t *testing.T) {
 	Convey("Given a filter", t, func() {
 		f := beacon.Filter{
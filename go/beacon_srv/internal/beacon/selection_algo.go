@@ -14,7 +14,35 @@
 
 package beacon
 
-import "math"
+import (
+	"math"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// AlgorithmType identifies a beacon selection algorithm.
+type AlgorithmType string
+
+const (
+	// BaseAlgorithm selects the shortest beacons and one diverse beacon.
+	BaseAlgorithm AlgorithmType = "Base"
+	// WeightedAlgorithm selects the beacons with the best weighted score
+	// based on hop count, link disjointness, expiration and link metadata.
+	WeightedAlgorithm AlgorithmType = "Weighted"
+)
+
+// DefaultAlgorithm is the default selection algorithm.
+const DefaultAlgorithm = BaseAlgorithm
+
+// algorithms contains the constructors of all supported selection algorithms.
+var algorithms = map[AlgorithmType]func(policy Policy) selectionAlgorithm{
+	BaseAlgorithm: func(_ Policy) selectionAlgorithm {
+		return baseAlgo{}
+	},
+	WeightedAlgorithm: func(policy Policy) selectionAlgorithm {
+		return weightedAlgo{weights: policy.Weights}
+	},
+}
 
 type selectionAlgorithm interface {
 	// SelectAndServe selects the n best beacons from the beacons channel and
@@ -22,6 +50,17 @@ type selectionAlgorithm interface {
 	SelectAndServe(beacons <-chan BeaconOrErr, results chan<- BeaconOrErr, resultSize int)
 }
 
+// newSelectionAlgorithm creates the selection algorithm configured in the
+// policy.
+func newSelectionAlgorithm(policy Policy) (selectionAlgorithm, error) {
+	newAlgo, ok := algorithms[policy.SelectionAlgorithm]
+	if !ok {
+		return nil, common.NewBasicError("Unsupported selection algorithm", nil,
+			"algo", policy.SelectionAlgorithm)
+	}
+	return newAlgo(policy), nil
+}
+
 // baseAlgo implements a very simple selection algorithm that optimizes for
 // short paths, but also tries to achieve some path diversity.
 type baseAlgo struct{}
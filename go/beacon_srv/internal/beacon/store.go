@@ -50,8 +50,7 @@ func NewBeaconStore(policies Policies, db DB) (*Store, error) {
 	}
 	s := &Store{
 		baseStore: baseStore{
-			db:   db,
-			algo: baseAlgo{},
+			db: db,
 		},
 		policies: policies,
 	}
@@ -85,6 +84,10 @@ func (s *Store) SegmentsToRegister(ctx context.Context, segType proto.PathSegTyp
 // getBeacons fetches the candidate beacons from the database and serves the
 // best beacons according to the policy.
 func (s *Store) getBeacons(ctx context.Context, policy *Policy) (<-chan BeaconOrErr, error) {
+	algo, err := newSelectionAlgorithm(*policy)
+	if err != nil {
+		return nil, err
+	}
 	beacons, err := s.db.CandidateBeacons(ctx, policy.CandidateSetSize,
 		UsageFromPolicyType(policy.Type), addr.IA{})
 	if err != nil {
@@ -94,7 +97,7 @@ func (s *Store) getBeacons(ctx context.Context, policy *Policy) (<-chan BeaconOr
 	go func() {
 		defer log.LogPanicAndExit()
 		defer close(results)
-		s.algo.SelectAndServe(beacons, results, policy.BestSetSize)
+		algo.SelectAndServe(beacons, results, policy.BestSetSize)
 	}()
 	return results, nil
 }
@@ -116,8 +119,7 @@ func NewCoreBeaconStore(policies CorePolicies, db DB) (*CoreStore, error) {
 	}
 	s := &CoreStore{
 		baseStore: baseStore{
-			db:   db,
-			algo: baseAlgo{},
+			db: db,
 		},
 		policies: policies,
 	}
@@ -147,6 +149,10 @@ func (s *CoreStore) SegmentsToRegister(ctx context.Context, segType proto.PathSe
 // getBeacons fetches the candidate beacons from the database and serves the
 // best beacons according to the policy.
 func (s *CoreStore) getBeacons(ctx context.Context, policy *Policy) (<-chan BeaconOrErr, error) {
+	algo, err := newSelectionAlgorithm(*policy)
+	if err != nil {
+		return nil, err
+	}
 	srcs, err := s.db.BeaconSources(ctx)
 	if err != nil {
 		return nil, err
@@ -168,7 +174,7 @@ func (s *CoreStore) getBeacons(ctx context.Context, policy *Policy) (<-chan Beac
 		go func() {
 			defer log.LogPanicAndExit()
 			defer wg.Done()
-			s.algo.SelectAndServe(beacons, results, policy.BestSetSize)
+			algo.SelectAndServe(beacons, results, policy.BestSetSize)
 		}()
 	}
 	go func() {
@@ -188,7 +194,6 @@ func (s *CoreStore) getBeacons(ctx context.Context, policy *Policy) (<-chan Beac
 type baseStore struct {
 	db     DB
 	usager usager
-	algo   selectionAlgorithm
 }
 
 // PreFilter indicates whether the beacon will be filtered on insert by
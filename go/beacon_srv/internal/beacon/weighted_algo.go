@@ -0,0 +1,272 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon
+
+import (
+	"math"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+)
+
+// weightedAlgo implements a selection algorithm that scores each beacon with a
+// weighted sum of its normalized properties. The beacons are selected
+// greedily. The disjointness is computed with regard to the beacons that are
+// already selected, such that the served set is diverse.
+type weightedAlgo struct {
+	weights Weights
+}
+
+// SelectAndServe reads all beacons from the beacons channel and serves the
+// resultSize best scoring beacons on the results channel. Errors are served
+// as they are encountered.
+func (a weightedAlgo) SelectAndServe(beacons <-chan BeaconOrErr, results chan<- BeaconOrErr,
+	resultSize int) {
+
+	var candidates []Beacon
+	for res := range beacons {
+		if res.Err != nil {
+			results <- res
+			continue
+		}
+		candidates = append(candidates, res.Beacon)
+	}
+	// All beacons are selected before the first one is served to avoid data
+	// races on the served segments.
+	for _, b := range a.selectBeacons(candidates, resultSize, time.Now()) {
+		results <- BeaconOrErr{Beacon: b}
+	}
+}
+
+// selectBeacons greedily selects the n best scoring beacons from the
+// candidates. On equal scores, the candidate that appears first is preferred.
+func (a weightedAlgo) selectBeacons(candidates []Beacon, n int, now time.Time) []Beacon {
+	norm := newNormalizer(candidates, now)
+	remaining := append([]Beacon(nil), candidates...)
+	selected := make([]Beacon, 0, n)
+	for len(selected) < n && len(remaining) > 0 {
+		bestIdx, bestScore := 0, math.Inf(-1)
+		for i, b := range remaining {
+			if score := a.score(b, selected, norm); score > bestScore {
+				bestIdx, bestScore = i, score
+			}
+		}
+		selected = append(selected, remaining[bestIdx])
+		remaining = append(remaining[:bestIdx], remaining[bestIdx+1:]...)
+	}
+	return selected
+}
+
+// score computes the weighted score of the beacon given the already selected
+// beacons.
+func (a weightedAlgo) score(b Beacon, selected []Beacon, norm normalizer) float64 {
+	return a.weights.HopCount*norm.hopCount(b) +
+		a.weights.Disjointness*disjointness(b, selected) +
+		a.weights.Expiration*norm.expiration(b) +
+		a.weights.MTU*norm.mtu(b) +
+		a.weights.Latency*norm.latency(b) +
+		a.weights.Bandwidth*norm.bandwidth(b)
+}
+
+// disjointness returns the fraction of links in the beacon that do not appear
+// in the most similar selected beacon. If no beacon is selected yet, the
+// beacon is fully disjoint.
+func disjointness(b Beacon, selected []Beacon) float64 {
+	l := len(b.Segment.ASEntries)
+	if l == 0 {
+		return 0
+	}
+	minDiversity := l
+	for _, other := range selected {
+		if d := b.Diversity(other); d < minDiversity {
+			minDiversity = d
+		}
+	}
+	return float64(minDiversity) / float64(l)
+}
+
+// normalizer normalizes the beacon properties to the range [0, 1] with
+// regard to the best value among all candidates.
+type normalizer struct {
+	now          time.Time
+	minHops      int
+	maxHeadroom  time.Duration
+	maxMTU       uint16
+	minLatency   uint64
+	maxBandwidth uint64
+	// static contains the static metrics of the candidates, such that the
+	// static info extensions are only aggregated once per candidate.
+	static map[*seg.PathSegment]staticMetrics
+}
+
+func newNormalizer(candidates []Beacon, now time.Time) normalizer {
+	n := normalizer{
+		now:     now,
+		minHops: math.MaxInt32,
+		static:  make(map[*seg.PathSegment]staticMetrics, len(candidates)),
+	}
+	for _, b := range candidates {
+		m := newStaticMetrics(b)
+		n.static[b.Segment] = m
+		if m.latency != 0 && (n.minLatency == 0 || m.latency < n.minLatency) {
+			n.minLatency = m.latency
+		}
+		if m.bandwidth > n.maxBandwidth {
+			n.maxBandwidth = m.bandwidth
+		}
+		if l := len(b.Segment.ASEntries); l < n.minHops {
+			n.minHops = l
+		}
+		if h := headroom(b, now); h > n.maxHeadroom {
+			n.maxHeadroom = h
+		}
+		if m := minMTU(b); m > n.maxMTU {
+			n.maxMTU = m
+		}
+	}
+	return n
+}
+
+func (n normalizer) hopCount(b Beacon) float64 {
+	l := len(b.Segment.ASEntries)
+	if l == 0 {
+		return 0
+	}
+	return float64(n.minHops) / float64(l)
+}
+
+func (n normalizer) expiration(b Beacon) float64 {
+	if n.maxHeadroom <= 0 {
+		return 0
+	}
+	return float64(headroom(b, n.now)) / float64(n.maxHeadroom)
+}
+
+func (n normalizer) mtu(b Beacon) float64 {
+	if n.maxMTU == 0 {
+		return 0
+	}
+	return float64(minMTU(b)) / float64(n.maxMTU)
+}
+
+// latency scores lower latencies higher. Beacons with unknown latency score
+// zero.
+func (n normalizer) latency(b Beacon) float64 {
+	l := n.static[b.Segment].latency
+	if l == 0 {
+		return 0
+	}
+	return float64(n.minLatency) / float64(l)
+}
+
+func (n normalizer) bandwidth(b Beacon) float64 {
+	if n.maxBandwidth == 0 {
+		return 0
+	}
+	return float64(n.static[b.Segment].bandwidth) / float64(n.maxBandwidth)
+}
+
+// headroom returns the time until the beacon expires. Expired beacons have a
+// headroom of zero.
+func headroom(b Beacon, now time.Time) time.Duration {
+	h := b.Segment.MinExpiry().Sub(now)
+	if h < 0 {
+		return 0
+	}
+	return h
+}
+
+// minMTU returns the minimum MTU of all AS entries and links in the beacon.
+// Unset values are ignored.
+func minMTU(b Beacon) uint16 {
+	var mtu uint16
+	update := func(m uint16) {
+		if m != 0 && (mtu == 0 || m < mtu) {
+			mtu = m
+		}
+	}
+	for _, asEntry := range b.Segment.ASEntries {
+		update(asEntry.MTU)
+		if len(asEntry.HopEntries) > 0 {
+			update(asEntry.HopEntries[0].InMTU)
+		}
+	}
+	return mtu
+}
+
+// staticMetrics are the beacon properties that are derived from the static
+// info extensions of the AS entries.
+type staticMetrics struct {
+	// latency is the sum of the known link and AS internal latencies in
+	// microseconds. Zero indicates that no latency is known.
+	latency uint64
+	// bandwidth is the minimum known link bandwidth in kbit/s. Zero indicates
+	// that no bandwidth is known.
+	bandwidth uint64
+}
+
+// newStaticMetrics aggregates the static info extensions of the AS entries in
+// the beacon. Each AS entry contributes the AS internal latency between its
+// ingress and egress interface, and the link from its egress interface to the
+// next AS. The link of the last AS entry is the one to the local AS. Unknown
+// values are ignored.
+func newStaticMetrics(b Beacon) staticMetrics {
+	var m staticMetrics
+	entries := b.Segment.ASEntries
+	for i, asEntry := range entries {
+		ext := asEntry.Exts.StaticInfo
+		in, eg := hopIfaces(asEntry)
+		if ext != nil && in != 0 && eg != 0 {
+			if intra := ext.IntraMetrics(in, eg); intra != nil {
+				m.latency += uint64(intra.Latency)
+			}
+		}
+		local := staticIface(ext, eg)
+		var remote *seg.StaticInfoIface
+		if i+1 < len(entries) {
+			nextIn, _ := hopIfaces(entries[i+1])
+			remote = staticIface(entries[i+1].Exts.StaticInfo, nextIn)
+		}
+		m.latency += uint64(seg.LinkLatency(local, remote))
+		bw := seg.LinkBandwidth(local, remote)
+		if bw != 0 && (m.bandwidth == 0 || bw < m.bandwidth) {
+			m.bandwidth = bw
+		}
+	}
+	return m
+}
+
+// hopIfaces returns the ingress and egress interface of the AS entry in
+// construction direction. If the hop field cannot be parsed, both are zero.
+func hopIfaces(asEntry *seg.ASEntry) (common.IFIDType, common.IFIDType) {
+	if len(asEntry.HopEntries) == 0 {
+		return 0, 0
+	}
+	hf, err := asEntry.HopEntries[0].HopField()
+	if err != nil {
+		return 0, 0
+	}
+	return hf.ConsIngress, hf.ConsEgress
+}
+
+// staticIface returns the static info of the interface, or nil if it is
+// unknown.
+func staticIface(ext *seg.StaticInfoExt, ifid common.IFIDType) *seg.StaticInfoIface {
+	if ext == nil || ifid == 0 {
+		return nil
+	}
+	return ext.Iface(ifid)
+}
@@ -0,0 +1,218 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beacon_test
+
+import (
+	"context"
+	"errors"
+	"testing"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/scionproto/scion/go/beacon_srv/internal/beacon"
+	"github.com/scionproto/scion/go/beacon_srv/internal/beacon/mock_beacon"
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/lib/xtest/graph"
+)
+
+func TestWeightedAlgoSelection(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	g := graph.NewDefaultGraph(mctrl)
+
+	stub := graph.If_210_X_220_X
+	beacons := []beacon.BeaconOrErr{
+		testBeaconOrErr(g, graph.If_130_A_110_X, graph.If_110_X_210_X, stub),
+		// Same beacon as the first beacon.
+		testBeaconOrErr(g, graph.If_130_A_110_X, graph.If_110_X_210_X, stub),
+		// Share the last link between 110 and 210.
+		testBeaconOrErr(g, graph.If_130_B_120_A, graph.If_120_A_110_X, graph.If_110_X_210_X, stub),
+		// Share the first link between 130 and 110.
+		testBeaconOrErr(g, graph.If_130_A_110_X, graph.If_110_X_120_A, graph.If_120_B_220_X,
+			graph.If_220_X_210_X, stub),
+		// Share no link.
+		testBeaconOrErr(g, graph.If_130_B_120_A, graph.If_120_B_220_X, graph.If_220_X_210_X, stub),
+		// Share no link.
+		testBeaconOrErr(g, graph.If_130_B_111_A, graph.If_111_B_120_X, graph.If_120_B_220_X,
+			graph.If_220_X_210_X, stub),
+	}
+	// Beacons with static info. The shorter beacon has the higher latency
+	// and the higher bandwidth.
+	fast := withStaticInfo(t, testBeaconOrErr(g, graph.If_130_B_120_A, graph.If_120_B_220_X,
+		graph.If_220_X_210_X, stub), 1000, 1000)
+	slow := withStaticInfo(t, testBeaconOrErr(g, graph.If_130_A_110_X, graph.If_110_X_210_X,
+		stub), 50000, 100000)
+	unknown := testBeaconOrErr(g, graph.If_130_B_111_A, graph.If_111_B_120_X,
+		graph.If_120_B_220_X, graph.If_220_X_210_X, stub)
+	staticBeacons := []beacon.BeaconOrErr{unknown, slow, fast}
+	beaconErr := beacon.BeaconOrErr{Err: errors.New("Fail")}
+	tests := []struct {
+		name      string
+		results   []beacon.BeaconOrErr
+		weights   beacon.Weights
+		bestSize  int
+		expected  map[beacon.BeaconOrErr]bool
+		expectErr bool
+	}{
+		{
+			name:      "Error only",
+			results:   []beacon.BeaconOrErr{beaconErr},
+			bestSize:  2,
+			expectErr: true,
+		},
+		{
+			name:     "Available beacons below best set size",
+			results:  beacons[:2],
+			bestSize: 5,
+			expected: map[beacon.BeaconOrErr]bool{
+				beacons[0]: true,
+				beacons[1]: true,
+			},
+		},
+		{
+			name:     "Balanced hop count and disjointness",
+			results:  beacons,
+			weights:  beacon.Weights{HopCount: 1, Disjointness: 1},
+			bestSize: 2,
+			expected: map[beacon.BeaconOrErr]bool{
+				beacons[0]: true,
+				beacons[4]: true,
+			},
+		},
+		{
+			name:     "Disjointness preferred over hop count",
+			results:  append(append([]beacon.BeaconOrErr{}, beacons...), beaconErr),
+			weights:  beacon.Weights{HopCount: 0.1, Disjointness: 1},
+			bestSize: 2,
+			expected: map[beacon.BeaconOrErr]bool{
+				beacons[0]: true,
+				beacons[5]: true,
+			},
+			expectErr: true,
+		},
+		{
+			name:     "Latency only",
+			results:  staticBeacons,
+			weights:  beacon.Weights{Latency: 1},
+			bestSize: 1,
+			expected: map[beacon.BeaconOrErr]bool{
+				fast: true,
+			},
+		},
+		{
+			name:     "Bandwidth only",
+			results:  staticBeacons,
+			weights:  beacon.Weights{Bandwidth: 1},
+			bestSize: 1,
+			expected: map[beacon.BeaconOrErr]bool{
+				slow: true,
+			},
+		},
+		{
+			name:     "Latency preferred over hop count",
+			results:  staticBeacons,
+			weights:  beacon.Weights{HopCount: 0.1, Latency: 1},
+			bestSize: 1,
+			expected: map[beacon.BeaconOrErr]bool{
+				fast: true,
+			},
+		},
+		{
+			name:     "Hop count only",
+			results:  beacons,
+			weights:  beacon.Weights{HopCount: 1},
+			bestSize: 2,
+			expected: map[beacon.BeaconOrErr]bool{
+				beacons[0]: true,
+				beacons[1]: true,
+			},
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			mctrl := gomock.NewController(t)
+			defer mctrl.Finish()
+			db := mock_beacon.NewMockDB(mctrl)
+			policy := beacon.Policy{
+				BestSetSize:        test.bestSize,
+				SelectionAlgorithm: beacon.WeightedAlgorithm,
+				Weights:            test.weights,
+			}
+			policies := beacon.Policies{Prop: policy, UpReg: policy, DownReg: policy}
+			store, err := beacon.NewBeaconStore(policies, db)
+			xtest.FailOnErr(t, err)
+			db.EXPECT().CandidateBeacons(gomock.Any(), gomock.Any(), gomock.Any(),
+				addr.IA{}).DoAndReturn(
+				func(_ ...interface{}) (<-chan beacon.BeaconOrErr, error) {
+					results := make(chan beacon.BeaconOrErr, len(test.results))
+					defer close(results)
+					for _, res := range test.results {
+						results <- res
+					}
+					return results, nil
+				},
+			)
+			res, err := store.BeaconsToPropagate(context.Background())
+			xtest.FailOnErr(t, err)
+			seen := make(map[beacon.BeaconOrErr]bool)
+			for bOrErr := range res {
+				if bOrErr.Err == nil {
+					if !test.expected[bOrErr] {
+						t.Errorf("Unexpected beacon %s", bOrErr.Beacon)
+					}
+					seen[bOrErr] = true
+				} else if !test.expectErr {
+					t.Errorf("Error not expected %s", bOrErr.Err)
+				}
+			}
+			for bOrErr := range test.expected {
+				if !seen[bOrErr] {
+					t.Errorf("Expected beacon not seen %s", bOrErr.Beacon)
+				}
+			}
+		})
+	}
+}
+
+// withStaticInfo adds a static info extension to all AS entries of the beacon
+// that reports the latency and bandwidth of the egress link.
+func withStaticInfo(t *testing.T, b beacon.BeaconOrErr, latency uint32,
+	bandwidth uint64) beacon.BeaconOrErr {
+
+	for _, asEntry := range b.Beacon.Segment.ASEntries {
+		hf, err := asEntry.HopEntries[0].HopField()
+		xtest.FailOnErr(t, err)
+		asEntry.Exts.StaticInfo = &seg.StaticInfoExt{
+			Set: true,
+			Ifaces: []*seg.StaticInfoIface{
+				{IfID: hf.ConsEgress, LinkLatency: latency, Bandwidth: bandwidth},
+			},
+		}
+	}
+	return b
+}
+
+func TestNewBeaconStoreInvalidAlgorithm(t *testing.T) {
+	mctrl := gomock.NewController(t)
+	defer mctrl.Finish()
+	policies := beacon.Policies{
+		Prop: beacon.Policy{SelectionAlgorithm: "Unknown"},
+	}
+	if _, err := beacon.NewBeaconStore(policies, mock_beacon.NewMockDB(mctrl)); err == nil {
+		t.Errorf("Expected error for unknown selection algorithm")
+	}
+}
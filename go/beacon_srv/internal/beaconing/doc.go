@@ -33,9 +33,11 @@
 //
 // The registrar is a periodic task to register segments with the appropriate
 // path server. Core and Up segments are registered with the local path server.
-// Down segments are registered with the originating core AS. In case the task
-// is run before a full period has passed, segments are only registered, if
-// there has not been a successful registration in the last period.
+// Down segments are registered with the originating core AS. If the local AS
+// is a writer of hidden path groups, down segments are instead registered with
+// the hidden path registries of these groups. In case the task is run before a
+// full period has passed, segments are only registered, if there has not been
+// a successful registration in the last period.
 //
 // Propagator
 //
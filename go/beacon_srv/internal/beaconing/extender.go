@@ -73,6 +73,7 @@ func (s *segExtender) extend(pseg *seg.PathSegment, inIfid, egIfid common.IFIDTy
 		MTU:        s.cfg.MTU,
 		HopEntries: hopEntries,
 	}
+	asEntry.Exts.StaticInfo = s.cfg.StaticInfo.generate(inIfid, egIfid, peers)
 	if err := pseg.AddASEntry(asEntry, s.cfg.Signer); err != nil {
 		return err
 	}
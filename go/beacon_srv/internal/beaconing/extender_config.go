@@ -42,6 +42,9 @@ type ExtenderConf struct {
 	IfidSize uint8
 	// MaxExpTime is the maximum relative expiration time.
 	MaxExpTime *spath.ExpTimeType
+	// StaticInfo is the static path metadata added to the AS entries. If
+	// nil, no static info extension is added.
+	StaticInfo *StaticInfoCfg
 	// maxExpTime is a copy of MaxExpTime to avoid using the captured
 	// reference from the calling code.
 	maxExpTime spath.ExpTimeType
@@ -26,6 +26,7 @@ import (
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
 	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/messenger"
 	"github.com/scionproto/scion/go/lib/log"
@@ -53,11 +54,16 @@ type RegistrarConf struct {
 	Period        time.Duration
 	SegType       proto.PathSegType
 	EnableMetrics bool
+	// HiddenPathGroups are the hidden path groups. Down segments are
+	// registered with the registries of the groups the local AS is a writer
+	// of, instead of the core.
+	HiddenPathGroups hiddenpath.Groups
 }
 
 // Registrar is used to periodically register path segments with the appropriate
 // path servers. Core and Up segments are registered with the local path server.
-// Down segments are registered at the core.
+// Down segments are registered at the core, or at the hidden path registries
+// if the local AS is a writer of a hidden path group.
 type Registrar struct {
 	*segExtender
 	msgr         infra.Messenger
@@ -65,6 +71,9 @@ type Registrar struct {
 	topoProvider topology.Provider
 	metrics      *metrics.Registrar
 	segType      proto.PathSegType
+	// hpRegistries maps the hidden path registries to the groups the down
+	// segments are registered for.
+	hpRegistries map[addr.IA][]hiddenpath.GroupId
 
 	// mutable fields
 	lastSucc time.Time
@@ -89,6 +98,21 @@ func (cfg RegistrarConf) New() (*Registrar, error) {
 	if cfg.EnableMetrics {
 		r.metrics = metrics.InitRegistrar()
 	}
+	if cfg.SegType == proto.PathSegType_down {
+		ia := cfg.TopoProvider.Get().ISD_AS
+		writable := cfg.HiddenPathGroups.Writable(ia)
+		if len(writable) > 0 {
+			r.hpRegistries = hiddenpath.RegistryMapping(writable)
+		}
+		// Down segments start at a core AS of the local ISD, so registries in
+		// other ISDs are never reachable (see chooseRegistry).
+		for registry := range r.hpRegistries {
+			if registry.I != ia.I {
+				return nil, common.NewBasicError("Hidden path registry not in local ISD", nil,
+					"registry", registry, "ia", ia)
+			}
+		}
+	}
 	return r, nil
 }
 
@@ -177,6 +201,10 @@ func (r *segmentRegistrar) start(ctx context.Context, wg *sync.WaitGroup) {
 		log.Error("[Registrar] Unable to create segment", "type", r.segType, "err", err)
 		return
 	}
+	if len(r.hpRegistries) > 0 {
+		r.startSendHPSegRegs(ctx, wg)
+		return
+	}
 	r.startSendSegReg(ctx, wg)
 }
 
@@ -197,6 +225,10 @@ func (r *segmentRegistrar) setSegToRegister() error {
 			},
 		},
 	}
+	if len(r.hpRegistries) > 0 {
+		// The registry addresses are chosen when sending.
+		return nil
+	}
 	var err error
 	r.addr, err = r.chooseServer(r.beacon.Segment)
 	if err != nil {
@@ -225,6 +257,54 @@ func (r *segmentRegistrar) startSendSegReg(ctx context.Context, wg *sync.WaitGro
 	}()
 }
 
+// startSendHPSegRegs adds to the wait group and starts a goroutine that sends
+// the hidden registration messages to the hidden path registries. The segment
+// is registered once per group. The registration is considered successful, if
+// at least one registry accepted the segment.
+func (r *segmentRegistrar) startSendHPSegRegs(ctx context.Context, wg *sync.WaitGroup) {
+	wg.Add(1)
+	go func() {
+		defer log.LogPanicAndExit()
+		defer wg.Done()
+		var registered, reachable int
+		for registry, ids := range r.hpRegistries {
+			a, err := r.chooseRegistry(registry, r.beacon.Segment)
+			if err != nil {
+				log.Debug("[Registrar] Skipping hidden path registry", "registry", registry,
+					"err", err)
+				continue
+			}
+			reachable++
+			for _, id := range ids {
+				reg := &path_mgmt.HPSegReg{
+					HPSegRecs: &path_mgmt.HPSegRecs{
+						GroupId: id.ToMsg(),
+						Recs:    r.reg.Recs,
+					},
+				}
+				if err := r.msgr.SendHPSegReg(ctx, reg, a, messenger.NextId()); err != nil {
+					log.Error("[Registrar] Unable to register hidden segment", "addr", a,
+						"group", id, "err", err)
+					continue
+				}
+				registered++
+			}
+		}
+		if registered == 0 {
+			if reachable == 0 {
+				log.Warn("[Registrar] No hidden path registry reachable with segment",
+					"segment_start", r.beacon.Segment.FirstIA(), "seg", r.beacon.Segment)
+			}
+			r.metrics.IncTotalBeacons(r.segType, r.beacon.Segment.FirstIA(), r.beacon.InIfId,
+				metrics.SendErr)
+			return
+		}
+		r.onSuccess()
+		log.Trace("[Registrar] Successfully registered hidden segment", "type", r.segType,
+			"registrations", registered, "seg", r.beacon.Segment)
+	}()
+}
+
 func (r *segmentRegistrar) onSuccess() {
 	r.summary.AddSrc(r.beacon.Segment.FirstIA())
 	r.summary.Inc()
@@ -239,3 +319,22 @@ func (r *segmentRegistrar) chooseServer(pseg *seg.PathSegment) (net.Addr, error)
 	}
 	return addrutil.GetPath(addr.SvcPS, pseg, r.topoProvider)
 }
+
+// chooseRegistry returns the address of the hidden path registry. Registries
+// in the local AS are reached directly, and registries in the core AS the
+// segment originates from are reached along the segment. Other registries are
+// not reachable by the beacon server.
+func (r *segmentRegistrar) chooseRegistry(registry addr.IA,
+	pseg *seg.PathSegment) (net.Addr, error) {
+
+	topo := r.topoProvider.Get()
+	switch {
+	case registry.Equal(topo.ISD_AS):
+		return &snet.Addr{IA: topo.ISD_AS, Host: addr.NewSVCUDPAppAddr(addr.SvcPS)}, nil
+	case registry.Equal(pseg.FirstIA()):
+		return addrutil.GetPath(addr.SvcPS, pseg, r.topoProvider)
+	default:
+		return nil, common.NewBasicError("Registry not reachable with segment", nil,
+			"registry", registry, "segment_start", pseg.FirstIA())
+	}
+}
@@ -33,6 +33,7 @@ import (
 	"github.com/scionproto/scion/go/lib/ctrl"
 	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
 	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/mock_infra"
 	"github.com/scionproto/scion/go/lib/infra/modules/trust"
@@ -253,6 +254,39 @@ func TestRegistrarRun(t *testing.T) {
 			r.Run(context.Background())
 		})
 	})
+	Convey("Hidden path registries outside the local ISD are rejected", t, func() {
+		mctrl := gomock.NewController(t)
+		defer mctrl.Finish()
+		topoProvider := xtest.TopoProviderFromFile(t, topoNonCore)
+		ia := topoProvider.Get().ISD_AS
+		id := hiddenpath.GroupId{OwnerAS: ia.A, Suffix: 1}
+		cfg := RegistrarConf{
+			Config: ExtenderConf{
+				Signer: testSigner(t, priv, ia),
+				Mac:    mac,
+				Intfs:  ifstate.NewInterfaces(topoProvider.Get().IFInfoMap, ifstate.Config{}),
+				MTU:    uint16(topoProvider.Get().MTU),
+			},
+			Msgr:         mock_infra.NewMockMessenger(mctrl),
+			SegProvider:  mock_beaconing.NewMockSegmentProvider(mctrl),
+			TopoProvider: topoProvider,
+			SegType:      proto.PathSegType_down,
+			HiddenPathGroups: hiddenpath.Groups{
+				id: {
+					Id:         id,
+					Owner:      ia,
+					Writers:    []addr.IA{ia},
+					Readers:    []addr.IA{ia},
+					Registries: []addr.IA{xtest.MustParseIA("2-ff00:0:210")},
+				},
+			},
+		}
+		_, err := cfg.New()
+		SoMsg("err", err, ShouldNotBeNil)
+		cfg.HiddenPathGroups[id].Registries = []addr.IA{ia}
+		_, err = cfg.New()
+		SoMsg("local err", err, ShouldBeNil)
+	})
 }
 
 func testBeaconOrErr(g *graph.Graph, desc []common.IFIDType) beacon.BeaconOrErr {
@@ -0,0 +1,203 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beaconing
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/util"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// StaticInfoCfg is the configuration of the static path metadata that is
+// added to the AS entries created by the beacon server.
+type StaticInfoCfg struct {
+	// Interfaces contains the static info of the local interfaces.
+	Interfaces map[common.IFIDType]InterfaceStaticInfo
+	// Note is a free-form note about the AS.
+	Note string
+}
+
+// InterfaceStaticInfo contains the static info of a local interface.
+type InterfaceStaticInfo struct {
+	// LinkLatency is the one-way latency of the inter-AS link.
+	LinkLatency util.DurWrap
+	// Bandwidth is the bandwidth of the inter-AS link in kbit/s.
+	Bandwidth uint64
+	// Geo is the geographic location of the interface.
+	Geo GeoInfo
+	// LinkType is the type of the inter-AS link. It is one of "direct",
+	// "multihop" or "opennet".
+	LinkType string
+	// Intra contains the AS internal metrics to the other local interfaces.
+	Intra map[common.IFIDType]IntraStaticInfo
+}
+
+// GeoInfo is the geographic location of an interface.
+type GeoInfo struct {
+	Latitude  float32
+	Longitude float32
+	Address   string
+}
+
+// IntraStaticInfo contains the AS internal metrics between two interfaces.
+type IntraStaticInfo struct {
+	// Latency is the one-way AS internal latency.
+	Latency util.DurWrap
+	// InternalHops is the number of AS internal hops.
+	InternalHops uint32
+}
+
+// LoadStaticInfoCfg loads the static info configuration from the json file.
+func LoadStaticInfoCfg(path string) (*StaticInfoCfg, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to read static info config", err,
+			"path", path)
+	}
+	cfg := &StaticInfoCfg{}
+	if err := json.Unmarshal(raw, cfg); err != nil {
+		return nil, common.NewBasicError("Unable to parse static info config", err,
+			"path", path)
+	}
+	if err := cfg.Validate(); err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Validate checks that the link types are valid and that no metrics are
+// configured from an interface to itself.
+func (cfg *StaticInfoCfg) Validate() error {
+	for ifid, info := range cfg.Interfaces {
+		if _, err := parseLinkType(info.LinkType); err != nil {
+			return common.NewBasicError("Invalid link type", err, "ifid", ifid)
+		}
+		if info.LinkLatency.Duration < 0 {
+			return common.NewBasicError("Negative link latency", nil, "ifid", ifid)
+		}
+		for other, intra := range info.Intra {
+			if other == ifid {
+				return common.NewBasicError("Intra metrics to the interface itself", nil,
+					"ifid", ifid)
+			}
+			if intra.Latency.Duration < 0 {
+				return common.NewBasicError("Negative intra latency", nil,
+					"ifid", ifid, "other", other)
+			}
+		}
+	}
+	return nil
+}
+
+// generate creates the static info extension for an AS entry with the
+// provided interfaces. The intra metrics are reported between the egress
+// interface and all other interfaces of the AS. For a terminating AS entry,
+// the ingress interface is used instead. A nil config results in no
+// extension.
+func (cfg *StaticInfoCfg) generate(inIfid, egIfid common.IFIDType,
+	peers []common.IFIDType) *seg.StaticInfoExt {
+
+	if cfg == nil {
+		return nil
+	}
+	ext := &seg.StaticInfoExt{
+		Set:  true,
+		Note: cfg.Note,
+	}
+	for _, ifid := range append([]common.IFIDType{inIfid, egIfid}, peers...) {
+		if iface := cfg.iface(ifid); iface != nil {
+			ext.Ifaces = append(ext.Ifaces, iface)
+		}
+	}
+	ref := egIfid
+	if ref == 0 {
+		ref = inIfid
+	}
+	ext.Intra = cfg.intra(ref)
+	if len(ext.Ifaces) == 0 && len(ext.Intra) == 0 && ext.Note == "" {
+		return nil
+	}
+	return ext
+}
+
+func (cfg *StaticInfoCfg) iface(ifid common.IFIDType) *seg.StaticInfoIface {
+	info, ok := cfg.Interfaces[ifid]
+	if ifid == 0 || !ok {
+		return nil
+	}
+	// The link type is validated when loading the config.
+	linkType, _ := parseLinkType(info.LinkType)
+	return &seg.StaticInfoIface{
+		IfID:        ifid,
+		LinkLatency: toMicros(info.LinkLatency.Duration),
+		Bandwidth:   info.Bandwidth,
+		Latitude:    info.Geo.Latitude,
+		Longitude:   info.Geo.Longitude,
+		Address:     info.Geo.Address,
+		LinkType:    linkType,
+	}
+}
+
+// intra returns the intra metrics between the reference interface and all
+// other interfaces. Metrics configured on the reference interface take
+// precedence over the ones configured on the other interface.
+func (cfg *StaticInfoCfg) intra(ref common.IFIDType) []*seg.StaticInfoIntra {
+	if ref == 0 {
+		return nil
+	}
+	metrics := make(map[common.IFIDType]IntraStaticInfo)
+	for ifid, info := range cfg.Interfaces {
+		if intra, ok := info.Intra[ref]; ok && ifid != ref {
+			metrics[ifid] = intra
+		}
+	}
+	for ifid, intra := range cfg.Interfaces[ref].Intra {
+		metrics[ifid] = intra
+	}
+	var result []*seg.StaticInfoIntra
+	for ifid, intra := range metrics {
+		result = append(result, &seg.StaticInfoIntra{
+			FromIfID:     ref,
+			ToIfID:       ifid,
+			Latency:      toMicros(intra.Latency.Duration),
+			InternalHops: intra.InternalHops,
+		})
+	}
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ToIfID < result[j].ToIfID
+	})
+	return result
+}
+
+func parseLinkType(s string) (proto.StaticInfoIface_LinkType, error) {
+	if s == "" {
+		return proto.StaticInfoIface_LinkType_unset, nil
+	}
+	linkType := proto.StaticInfoIface_LinkTypeFromString(s)
+	if linkType == proto.StaticInfoIface_LinkType_unset {
+		return linkType, common.NewBasicError("Unknown link type", nil, "type", s)
+	}
+	return linkType, nil
+}
+
+func toMicros(d time.Duration) uint32 {
+	return uint32(d / time.Microsecond)
+}
@@ -0,0 +1,154 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package beaconing
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/proto"
+)
+
+func TestLoadStaticInfoCfg(t *testing.T) {
+	cfg, err := LoadStaticInfoCfg("testdata/staticinfo.json")
+	xtest.FailOnErr(t, err)
+	if len(cfg.Interfaces) != 3 {
+		t.Fatalf("Expected 3 interfaces, actual %d", len(cfg.Interfaces))
+	}
+	if cfg.Note != "test AS" {
+		t.Errorf("Unexpected note %q", cfg.Note)
+	}
+	if _, err := LoadStaticInfoCfg("testdata/nonexisting.json"); err == nil {
+		t.Errorf("Expected error for non-existing file")
+	}
+}
+
+func TestStaticInfoCfgValidate(t *testing.T) {
+	tests := map[string]StaticInfoCfg{
+		"unknown link type": {
+			Interfaces: map[common.IFIDType]InterfaceStaticInfo{
+				1: {LinkType: "wormhole"},
+			},
+		},
+		"intra to itself": {
+			Interfaces: map[common.IFIDType]InterfaceStaticInfo{
+				1: {Intra: map[common.IFIDType]IntraStaticInfo{1: {}}},
+			},
+		},
+	}
+	for name, cfg := range tests {
+		t.Run(name, func(t *testing.T) {
+			if err := cfg.Validate(); err == nil {
+				t.Errorf("Expected error")
+			}
+		})
+	}
+}
+
+func TestStaticInfoCfgGenerate(t *testing.T) {
+	cfg, err := LoadStaticInfoCfg("testdata/staticinfo.json")
+	xtest.FailOnErr(t, err)
+	iface1 := &seg.StaticInfoIface{
+		IfID:        1,
+		LinkLatency: 10000,
+		Bandwidth:   1000000,
+		Latitude:    47.3769,
+		Longitude:   8.5417,
+		Address:     "Zurich",
+		LinkType:    proto.StaticInfoIface_LinkType_direct,
+	}
+	iface2 := &seg.StaticInfoIface{
+		IfID:        2,
+		LinkLatency: 500,
+		Bandwidth:   400000,
+		LinkType:    proto.StaticInfoIface_LinkType_opennet,
+	}
+	tests := []struct {
+		name     string
+		cfg      *StaticInfoCfg
+		inIfid   common.IFIDType
+		egIfid   common.IFIDType
+		peers    []common.IFIDType
+		expected *seg.StaticInfoExt
+	}{
+		{
+			name:   "nil config",
+			inIfid: 1,
+			egIfid: 2,
+		},
+		{
+			name:   "intra metrics from egress",
+			cfg:    cfg,
+			inIfid: 2,
+			egIfid: 1,
+			peers:  []common.IFIDType{4},
+			expected: &seg.StaticInfoExt{
+				Set:    true,
+				Ifaces: []*seg.StaticInfoIface{iface2, iface1},
+				Intra: []*seg.StaticInfoIntra{
+					{FromIfID: 1, ToIfID: 2, Latency: 1000, InternalHops: 2},
+					{FromIfID: 1, ToIfID: 3, Latency: 2000, InternalHops: 3},
+				},
+				Note: "test AS",
+			},
+		},
+		{
+			name:   "intra metrics configured on other interfaces",
+			cfg:    cfg,
+			inIfid: 1,
+			egIfid: 2,
+			expected: &seg.StaticInfoExt{
+				Set:    true,
+				Ifaces: []*seg.StaticInfoIface{iface1, iface2},
+				Intra: []*seg.StaticInfoIntra{
+					{FromIfID: 2, ToIfID: 1, Latency: 1000, InternalHops: 2},
+					{FromIfID: 2, ToIfID: 3, Latency: 4000, InternalHops: 1},
+				},
+				Note: "test AS",
+			},
+		},
+		{
+			name:   "terminating entry uses ingress",
+			cfg:    cfg,
+			inIfid: 2,
+			expected: &seg.StaticInfoExt{
+				Set:    true,
+				Ifaces: []*seg.StaticInfoIface{iface2},
+				Intra: []*seg.StaticInfoIntra{
+					{FromIfID: 2, ToIfID: 1, Latency: 1000, InternalHops: 2},
+					{FromIfID: 2, ToIfID: 3, Latency: 4000, InternalHops: 1},
+				},
+				Note: "test AS",
+			},
+		},
+		{
+			name:   "no info available",
+			cfg:    &StaticInfoCfg{},
+			inIfid: 1,
+			egIfid: 2,
+		},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			ext := test.cfg.generate(test.inIfid, test.egIfid, test.peers)
+			if !reflect.DeepEqual(ext, test.expected) {
+				t.Errorf("Unexpected extension. expected %v, actual %v", test.expected, ext)
+			}
+		})
+	}
+}
@@ -22,6 +22,7 @@ import (
 	"io"
 
 	"github.com/scionproto/scion/go/beacon_srv/internal/beacon"
+	"github.com/scionproto/scion/go/beacon_srv/internal/beacon/beacondbpostgres"
 	"github.com/scionproto/scion/go/beacon_srv/internal/beacon/beacondbsqlite"
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
@@ -39,6 +40,8 @@ const (
 	backendNone Backend = ""
 	// BackendSqlite indicates an sqlite backend.
 	BackendSqlite Backend = "sqlite"
+	// BackendPostgres indicates a PostgreSQL backend.
+	BackendPostgres Backend = "postgres"
 )
 
 const (
@@ -99,7 +102,7 @@ func (cfg *BeaconDBConf) Validate() error {
 
 func (cfg *BeaconDBConf) validateBackend() error {
 	switch cfg.Backend() {
-	case BackendSqlite:
+	case BackendSqlite, BackendPostgres:
 		return nil
 	case backendNone:
 		return common.NewBasicError("No backend set", nil)
@@ -126,6 +129,8 @@ func (cfg *BeaconDBConf) New(ia addr.IA) (beacon.DB, error) {
 	switch cfg.Backend() {
 	case BackendSqlite:
 		bdb, err = beacondbsqlite.New(cfg.Connection(), ia)
+	case BackendPostgres:
+		bdb, err = beacondbpostgres.New(cfg.Connection(), ia)
 	default:
 		return nil, common.NewBasicError("Unsupported backend", nil, "backend", cfg.Backend())
 	}
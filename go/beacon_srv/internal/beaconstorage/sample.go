@@ -15,10 +15,11 @@
 package beaconstorage
 
 const beaconDbSample = `
-# The type of trustdb backend. (default sqlite)
+# The type of beacondb backend, either "sqlite" or "postgres". (default sqlite)
 Backend = "sqlite"
 
-# Connection for the trust database.
+# Connection for the beacon database. This is the path to the database file for
+# the "sqlite" backend, or the connection string for the "postgres" backend.
 Connection = "/var/lib/scion/beacondb/%s.beacon.db"
 
 # The maximum number of open connections to the database. In case of the
@@ -56,6 +56,7 @@ type Config struct {
 	Metrics        env.Metrics
 	Tracing        env.Tracing
 	QUIC           env.QUIC `toml:"quic"`
+	Trust          env.Trust
 	TrustDB        truststorage.TrustDBConf
 	BeaconDB       beaconstorage.BeaconDBConf
 	Discovery      idiscovery.Config
@@ -83,6 +84,7 @@ func (cfg *Config) Validate() error {
 		&cfg.General,
 		&cfg.Logging,
 		&cfg.Metrics,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.BeaconDB,
 		&cfg.Discovery,
@@ -98,6 +100,7 @@ func (cfg *Config) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
 		&cfg.Metrics,
 		&cfg.Tracing,
 		&cfg.QUIC,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.BeaconDB,
 		&cfg.Discovery,
@@ -130,6 +133,15 @@ type BSConfig struct {
 	ExpiredCheckInterval util.DurWrap
 	// Policies contains the policy files.
 	Policies Policies
+	// StaticInfoConfig contains the file path for the static path metadata
+	// configuration. If this is the empty string, no static path metadata is
+	// added to the AS entries.
+	StaticInfoConfig string
+	// HiddenPathGroups contains the file path for the hidden path groups
+	// configuration. Down segments are registered with the registries of the
+	// groups the local AS is a writer of, instead of the core. If this is the
+	// empty string, all down segments are registered with the core.
+	HiddenPathGroups string
 }
 
 // InitDefaults the default values for the durations that are equal to zero.
@@ -43,6 +43,7 @@ func TestConfigSample(t *testing.T) {
 
 func InitTestConfig(cfg *Config) {
 	envtest.InitTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, nil)
+	envtest.InitTestTrust(&cfg.Trust)
 	truststoragetest.InitTestConfig(&cfg.TrustDB)
 	beaconstoragetest.InitTestBeaconDBConf(&cfg.BeaconDB)
 	idiscoverytest.InitTestConfig(&cfg.Discovery)
@@ -50,6 +51,8 @@ func InitTestConfig(cfg *Config) {
 }
 
 func InitTestBSConfig(cfg *BSConfig) {
+	cfg.StaticInfoConfig = "test"
+	cfg.HiddenPathGroups = "test"
 	InitTestPolicies(&cfg.Policies)
 }
 
@@ -62,6 +65,7 @@ func InitTestPolicies(cfg *Policies) {
 
 func CheckTestConfig(cfg *Config, id string) {
 	envtest.CheckTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, nil, id)
+	envtest.CheckTestTrust(&cfg.Trust)
 	truststoragetest.CheckTestConfig(&cfg.TrustDB, id)
 	beaconstoragetest.CheckTestBeaconDBConf(&cfg.BeaconDB, id)
 	idiscoverytest.CheckTestConfig(&cfg.Discovery)
@@ -80,6 +84,8 @@ func CheckTestBSConfig(cfg *BSConfig) {
 		DefaultRegistrationInterval)
 	SoMsg("ExpiredCheckInterval", cfg.ExpiredCheckInterval.Duration, ShouldEqual,
 		DefaultExpiredCheckInterval)
+	SoMsg("StaticInfoConfig", cfg.StaticInfoConfig, ShouldEqual, "")
+	SoMsg("HiddenPathGroups", cfg.HiddenPathGroups, ShouldEqual, "")
 	CheckTestPolicies(&cfg.Policies)
 }
 
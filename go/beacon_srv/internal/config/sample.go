@@ -35,6 +35,16 @@ RegistrationInterval = "5s"
 
 # The interval between checking for expired interfaces to revoke. (default 200ms)
 ExpiredCheckInterval = "200ms"
+
+# The file path for the static path metadata configuration. In case of the
+# empty string, no static path metadata is added to the AS entries. (default "")
+StaticInfoConfig = ""
+
+# The file path for the hidden path groups configuration. Down segments are
+# registered with the registries of the groups the local AS is a writer of,
+# instead of the core. In case of the empty string, all down segments are
+# registered with the core. (default "")
+HiddenPathGroups = ""
 `
 
 const policiesSample = `
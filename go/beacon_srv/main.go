@@ -46,6 +46,7 @@ import (
 	"github.com/scionproto/scion/go/lib/discovery"
 	"github.com/scionproto/scion/go/lib/env"
 	"github.com/scionproto/scion/go/lib/fatal"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/infraenv"
 	"github.com/scionproto/scion/go/lib/infra/messenger"
@@ -111,6 +112,9 @@ func realMain() int {
 	trustConf := &trust.Config{
 		MustHaveLocalChain: true,
 		ServiceType:        proto.ServiceType_bs,
+		CheckRevocation:    !cfg.Trust.DisableRevocationCheck,
+		RequireFreshCRL:    cfg.Trust.RequireFreshCRL,
+		MaxLeafLifetime:    cfg.Trust.MaxLeafLifetime.Duration,
 	}
 	trustStore := trust.NewStore(trustDB, topo.ISD_AS, trustConf, log.Root())
 	err = trustStore.LoadAuthoritativeCrypto(filepath.Join(cfg.General.ConfigDir, "certs"))
@@ -145,6 +149,14 @@ func realMain() int {
 		TrustStore:            trustStore,
 		SVCRouter:             messenger.NewSVCRouter(itopo.Provider()),
 	}
+	if cfg.QUIC.Address != "" && cfg.QUIC.PKI {
+		nc.QUICPKI, err = infraenv.NewQUICPKI(filepath.Join(cfg.General.ConfigDir, "keys"),
+			topo.ISD_AS, trustStore, trustDB)
+		if err != nil {
+			log.Crit("Unable to initialize QUIC PKI", "err", err)
+			return 1
+		}
+	}
 	msgr, err := nc.Messenger()
 	if err != nil {
 		log.Crit(infraenv.ErrAppUnableToInitMessenger, "err", err)
@@ -197,6 +209,16 @@ func realMain() int {
 		log.Crit("Unable to create SCION packet conn", "err", err)
 		return 1
 	}
+	staticInfo, err := loadStaticInfo(cfg.BS.StaticInfoConfig)
+	if err != nil {
+		log.Crit("Unable to load static info config", "err", err)
+		return 1
+	}
+	hpGroups, err := loadHiddenPathGroups(cfg.BS.HiddenPathGroups)
+	if err != nil {
+		log.Crit("Unable to load hidden path groups", "err", err)
+		return 1
+	}
 	tasks = &periodicTasks{
 		intfs:        intfs,
 		conn:         conn.(*snet.SCIONPacketConn),
@@ -211,6 +233,8 @@ func realMain() int {
 				},
 			},
 		),
+		staticInfo: staticInfo,
+		hpGroups:   hpGroups,
 	}
 	signer, err := tasks.createSigner(topo)
 	if err != nil {
@@ -267,6 +291,8 @@ type periodicTasks struct {
 	topoProvider    topology.Provider
 	allowIsdLoop    bool
 	addressRewriter *messenger.AddressRewriter
+	staticInfo      *beaconing.StaticInfoCfg
+	hpGroups        hiddenpath.Groups
 
 	keepalive  *periodic.Runner
 	originator *periodic.Runner
@@ -376,10 +402,11 @@ func (t *periodicTasks) startOriginator(a *topology.TopoAddr) (*periodic.Runner,
 			QUICBeaconSender: t.msgr,
 		},
 		Config: beaconing.ExtenderConf{
-			Intfs:  t.intfs,
-			Mac:    t.genMac(),
-			MTU:    uint16(topo.MTU),
-			Signer: signer,
+			Intfs:      t.intfs,
+			Mac:        t.genMac(),
+			MTU:        uint16(topo.MTU),
+			Signer:     signer,
+			StaticInfo: t.staticInfo,
 		},
 		Period: cfg.BS.OriginationInterval.Duration,
 	}.New()
@@ -412,10 +439,11 @@ func (t *periodicTasks) startPropagator(a *topology.TopoAddr) (*periodic.Runner,
 			QUICBeaconSender: t.msgr,
 		},
 		Config: beaconing.ExtenderConf{
-			Intfs:  t.intfs,
-			Mac:    t.genMac(),
-			MTU:    uint16(topo.MTU),
-			Signer: signer,
+			Intfs:      t.intfs,
+			Mac:        t.genMac(),
+			MTU:        uint16(topo.MTU),
+			Signer:     signer,
+			StaticInfo: t.staticInfo,
 		},
 		Period: cfg.BS.PropagationInterval.Duration,
 	}.New()
@@ -460,11 +488,13 @@ func (t *periodicTasks) startRegistrar(topo *topology.Topo,
 		Period:        cfg.BS.RegistrationInterval.Duration,
 		EnableMetrics: true,
 		Config: beaconing.ExtenderConf{
-			Intfs:  t.intfs,
-			Mac:    t.genMac(),
-			MTU:    uint16(topo.MTU),
-			Signer: signer,
+			Intfs:      t.intfs,
+			Mac:        t.genMac(),
+			MTU:        uint16(topo.MTU),
+			Signer:     signer,
+			StaticInfo: t.staticInfo,
 		},
+		HiddenPathGroups: t.hpGroups,
 	}.New()
 	if err != nil {
 		return nil, common.NewBasicError("Unable to start registrar", err, "type", segType)
@@ -617,6 +647,20 @@ func loadPolicy(fn string, t beacon.PolicyType) (beacon.Policy, error) {
 	return policy, nil
 }
 
+func loadStaticInfo(fn string) (*beaconing.StaticInfoCfg, error) {
+	if fn == "" {
+		return nil, nil
+	}
+	return beaconing.LoadStaticInfoCfg(fn)
+}
+
+func loadHiddenPathGroups(fn string) (hiddenpath.Groups, error) {
+	if fn == "" {
+		return nil, nil
+	}
+	return hiddenpath.LoadGroups(fn)
+}
+
 func checkFlags(cfg *config.Config) (int, bool) {
 	if helpPoliciy {
 		var sample beacon.Policy
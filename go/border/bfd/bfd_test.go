@@ -0,0 +1,126 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"sync/atomic"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/log"
+)
+
+func TestPacket(t *testing.T) {
+	Convey("Control packets", t, func() {
+		p := &Packet{
+			Diag:                  DiagTimeExpired,
+			State:                 StateInit,
+			DetectMult:            3,
+			MyDisc:                0x01020304,
+			YourDisc:              0x05060708,
+			DesiredMinTxInterval:  100 * time.Millisecond,
+			RequiredMinRxInterval: 50 * time.Millisecond,
+		}
+		raw := p.Pack()
+		Convey("are recognized", func() {
+			So(IsControl(raw), ShouldBeTrue)
+			// SCION common header with version 0.
+			So(IsControl(make(common.RawBytes, PacketLen)), ShouldBeFalse)
+			So(IsControl(raw[:PacketLen-1]), ShouldBeFalse)
+		})
+		Convey("round-trip", func() {
+			decoded, err := Decode(raw)
+			So(err, ShouldBeNil)
+			So(decoded, ShouldResemble, p)
+		})
+		Convey("with invalid length fail", func() {
+			raw[3] = PacketLen + 4
+			_, err := Decode(raw)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("with authentication fail", func() {
+			raw[1] |= 0x04
+			_, err := Decode(raw)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("with zero discriminator fail", func() {
+			p.MyDisc = 0
+			_, err := Decode(p.Pack())
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSession(t *testing.T) {
+	Convey("Two sessions", t, func() {
+		cfg := Config{
+			DetectMult:            3,
+			DesiredMinTxInterval:  5 * time.Millisecond,
+			RequiredMinRxInterval: 5 * time.Millisecond,
+		}
+		// linkUp controls whether packets are delivered between the sessions.
+		linkUp := int32(1)
+		var a, b *Session
+		sender := func(dst **Session) Sender {
+			return func(raw common.RawBytes) error {
+				if atomic.LoadInt32(&linkUp) == 1 {
+					(*dst).Receive(raw)
+				}
+				return nil
+			}
+		}
+		changes := make(chan State, 16)
+		a = NewSession(cfg, sender(&b), func(_, new State) { changes <- new }, log.Root())
+		b = NewSession(cfg, sender(&a), nil, log.Root())
+		stop := make(chan struct{})
+		defer close(stop)
+		go a.Run(stop)
+		go b.Run(stop)
+
+		Convey("come up and detect link failure", func() {
+			So(waitFor(changes, StateUp), ShouldBeTrue)
+			So(a.State(), ShouldEqual, StateUp)
+			atomic.StoreInt32(&linkUp, 0)
+			So(waitFor(changes, StateDown), ShouldBeTrue)
+			So(a.State(), ShouldEqual, StateDown)
+			Convey("and recover", func() {
+				atomic.StoreInt32(&linkUp, 1)
+				So(waitFor(changes, StateUp), ShouldBeTrue)
+			})
+		})
+		Convey("ignore packets for other sessions", func() {
+			p := &Packet{State: StateDown, DetectMult: 3, MyDisc: 1, YourDisc: 42}
+			So(a.Receive(p.Pack()), ShouldNotBeNil)
+		})
+	})
+}
+
+// waitFor waits until the state is reported on changes.
+func waitFor(changes <-chan State, state State) bool {
+	timeout := time.After(time.Second)
+	for {
+		select {
+		case s := <-changes:
+			if s == state {
+				return true
+			}
+		case <-timeout:
+			return false
+		}
+	}
+}
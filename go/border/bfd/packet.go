@@ -0,0 +1,145 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package bfd
+
+import (
+	"fmt"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+const (
+	// Version is the BFD protocol version.
+	Version = 1
+	// PacketLen is the length of a control packet without authentication.
+	PacketLen = 24
+)
+
+// State is the state of a BFD session.
+type State uint8
+
+const (
+	StateAdminDown State = 0
+	StateDown      State = 1
+	StateInit      State = 2
+	StateUp        State = 3
+)
+
+func (s State) String() string {
+	switch s {
+	case StateAdminDown:
+		return "AdminDown"
+	case StateDown:
+		return "Down"
+	case StateInit:
+		return "Init"
+	case StateUp:
+		return "Up"
+	}
+	return fmt.Sprintf("UNKNOWN (%d)", uint8(s))
+}
+
+// Diag is the diagnostic code indicating the reason for the last change of
+// the local session state.
+type Diag uint8
+
+const (
+	DiagNone         Diag = 0
+	DiagTimeExpired  Diag = 1
+	DiagNeighborDown Diag = 3
+)
+
+// Packet is a BFD control packet. Authentication, the poll sequence and
+// the echo function are not supported.
+type Packet struct {
+	Diag                  Diag
+	State                 State
+	DetectMult            uint8
+	MyDisc                uint32
+	YourDisc              uint32
+	DesiredMinTxInterval  time.Duration
+	RequiredMinRxInterval time.Duration
+}
+
+// IsControl returns whether raw starts like a BFD control packet. The version
+// field of BFD control packets makes them distinguishable from SCION packets,
+// such that both can be sent over the same overlay socket.
+func IsControl(raw common.RawBytes) bool {
+	return len(raw) >= PacketLen && raw[0]>>5 == Version
+}
+
+// Decode decodes a BFD control packet.
+func Decode(raw common.RawBytes) (*Packet, error) {
+	if len(raw) < PacketLen {
+		return nil, common.NewBasicError("Packet too short", nil,
+			"min", PacketLen, "actual", len(raw))
+	}
+	if v := raw[0] >> 5; v != Version {
+		return nil, common.NewBasicError("Unsupported version", nil, "version", v)
+	}
+	if raw[3] != PacketLen {
+		return nil, common.NewBasicError("Invalid length", nil, "length", raw[3])
+	}
+	if raw[1]&0x04 != 0 {
+		return nil, common.NewBasicError("Authentication not supported", nil)
+	}
+	p := &Packet{
+		Diag:                  Diag(raw[0] & 0x1f),
+		State:                 State(raw[1] >> 6),
+		DetectMult:            raw[2],
+		MyDisc:                common.Order.Uint32(raw[4:8]),
+		YourDisc:              common.Order.Uint32(raw[8:12]),
+		DesiredMinTxInterval:  usToDuration(common.Order.Uint32(raw[12:16])),
+		RequiredMinRxInterval: usToDuration(common.Order.Uint32(raw[16:20])),
+	}
+	if p.DetectMult == 0 {
+		return nil, common.NewBasicError("Invalid detection multiplier", nil)
+	}
+	if p.MyDisc == 0 {
+		return nil, common.NewBasicError("Invalid discriminator", nil)
+	}
+	return p, nil
+}
+
+// Pack packs the control packet.
+func (p *Packet) Pack() common.RawBytes {
+	raw := make(common.RawBytes, PacketLen)
+	raw[0] = Version<<5 | uint8(p.Diag)&0x1f
+	raw[1] = uint8(p.State) << 6
+	raw[2] = p.DetectMult
+	raw[3] = PacketLen
+	common.Order.PutUint32(raw[4:8], p.MyDisc)
+	common.Order.PutUint32(raw[8:12], p.YourDisc)
+	common.Order.PutUint32(raw[12:16], durationToUs(p.DesiredMinTxInterval))
+	common.Order.PutUint32(raw[16:20], durationToUs(p.RequiredMinRxInterval))
+	// The required min echo RX interval is left at 0, i.e., the echo
+	// function is not supported.
+	return raw
+}
+
+func (p *Packet) String() string {
+	return fmt.Sprintf("State: %s Diag: %d DetectMult: %d MyDisc: %d YourDisc: %d "+
+		"DesiredMinTx: %s RequiredMinRx: %s", p.State, p.Diag, p.DetectMult, p.MyDisc,
+		p.YourDisc, p.DesiredMinTxInterval, p.RequiredMinRxInterval)
+}
+
+func usToDuration(us uint32) time.Duration {
+	return time.Duration(us) * time.Microsecond
+}
+
+func durationToUs(d time.Duration) uint32 {
+	return uint32(d / time.Microsecond)
+}
@@ -0,0 +1,256 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package bfd implements link liveness detection between two border routers
+// based on the asynchronous mode of Bidirectional Forwarding Detection (BFD,
+// RFC 5880). Authentication, demand mode, the poll sequence and the echo
+// function are not supported.
+//
+// A session is in state Down until it has completed the three-way handshake
+// with its peer, after which it is Up. If no control packet is received within
+// the detection time, or if the peer signals that its session is down, the
+// session goes back to Down.
+package bfd
+
+import (
+	"math/rand"
+	"sync/atomic"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/log"
+)
+
+const (
+	// DefaultDetectMult is the default detection time multiplier.
+	DefaultDetectMult = 3
+	// DefaultDesiredMinTxInterval is the default minimum interval between
+	// sent control packets.
+	DefaultDesiredMinTxInterval = 200 * time.Millisecond
+	// DefaultRequiredMinRxInterval is the default minimum interval between
+	// received control packets.
+	DefaultRequiredMinRxInterval = 200 * time.Millisecond
+)
+
+// pktQueueLen is the number of received control packets that can be queued
+// for processing. Further packets are dropped.
+const pktQueueLen = 16
+
+// Config is the configuration of a session.
+type Config struct {
+	// DetectMult is the detection time multiplier sent to the peer.
+	DetectMult uint8
+	// DesiredMinTxInterval is the minimum interval between control packets
+	// sent by this router.
+	DesiredMinTxInterval time.Duration
+	// RequiredMinRxInterval is the minimum interval between control packets
+	// received from the peer that this router supports.
+	RequiredMinRxInterval time.Duration
+}
+
+// Sender sends a packed control packet to the peer.
+type Sender func(common.RawBytes) error
+
+// StateChangeHandler is called whenever the state of the session changes.
+type StateChangeHandler func(old, new State)
+
+// Session is a BFD session with a single peer.
+type Session struct {
+	cfg       Config
+	localDisc uint32
+	send      Sender
+	onChange  StateChangeHandler
+	pkts      chan *Packet
+	// curr is the current local state. It is only written by the Run
+	// goroutine, but can be read concurrently.
+	curr   uint32
+	logger log.Logger
+}
+
+// NewSession creates a new session. The session is started by calling Run.
+func NewSession(cfg Config, send Sender, onChange StateChangeHandler,
+	logger log.Logger) *Session {
+
+	localDisc := rand.Uint32()
+	for localDisc == 0 {
+		localDisc = rand.Uint32()
+	}
+	return &Session{
+		cfg:       cfg,
+		localDisc: localDisc,
+		send:      send,
+		onChange:  onChange,
+		pkts:      make(chan *Packet, pktQueueLen),
+		curr:      uint32(StateDown),
+		logger:    logger,
+	}
+}
+
+// State returns the current local state of the session.
+func (s *Session) State() State {
+	return State(atomic.LoadUint32(&s.curr))
+}
+
+// Receive decodes the control packet and queues it for processing by the
+// session. It does not block, packets are dropped if the queue is full. raw
+// is not referenced after Receive returns.
+func (s *Session) Receive(raw common.RawBytes) error {
+	p, err := Decode(raw)
+	if err != nil {
+		return err
+	}
+	if p.YourDisc != 0 && p.YourDisc != s.localDisc {
+		return common.NewBasicError("Discriminator mismatch", nil,
+			"expected", s.localDisc, "actual", p.YourDisc)
+	}
+	select {
+	case s.pkts <- p:
+	default:
+		s.logger.Debug("BFD: dropping control packet, queue full")
+	}
+	return nil
+}
+
+// Run runs the session until stop is closed.
+func (s *Session) Run(stop <-chan struct{}) {
+	r := &run{
+		Session:       s,
+		state:         StateDown,
+		remoteMinRx:   time.Microsecond,
+		detectTimer:   time.NewTimer(0),
+		transmitTimer: time.NewTimer(0),
+	}
+	// The detection timer is only armed once the first control packet is
+	// received.
+	if !r.detectTimer.Stop() {
+		<-r.detectTimer.C
+	}
+	defer r.detectTimer.Stop()
+	defer r.transmitTimer.Stop()
+	for {
+		select {
+		case <-stop:
+			return
+		case <-r.transmitTimer.C:
+			r.transmit()
+		case p := <-s.pkts:
+			r.process(p)
+		case <-r.detectTimer.C:
+			r.expire()
+		}
+	}
+}
+
+// run holds the session state that is only accessed by the Run goroutine.
+type run struct {
+	*Session
+	state         State
+	diag          Diag
+	remoteDisc    uint32
+	remoteMinRx   time.Duration
+	detectTimer   *time.Timer
+	transmitTimer *time.Timer
+}
+
+// transmit sends a control packet and schedules the next transmission.
+func (r *run) transmit() {
+	p := &Packet{
+		Diag:                  r.diag,
+		State:                 r.state,
+		DetectMult:            r.cfg.DetectMult,
+		MyDisc:                r.localDisc,
+		YourDisc:              r.remoteDisc,
+		DesiredMinTxInterval:  r.cfg.DesiredMinTxInterval,
+		RequiredMinRxInterval: r.cfg.RequiredMinRxInterval,
+	}
+	if err := r.send(p.Pack()); err != nil {
+		r.logger.Debug("BFD: unable to send control packet", "err", err)
+	}
+	r.transmitTimer.Reset(r.txInterval())
+}
+
+// txInterval returns the interval until the next transmission. It is jittered
+// between 75% and 100% of the negotiated interval, as required by RFC 5880.
+func (r *run) txInterval() time.Duration {
+	interval := r.cfg.DesiredMinTxInterval
+	if r.remoteMinRx > interval {
+		interval = r.remoteMinRx
+	}
+	return interval - time.Duration(rand.Int63n(int64(interval/4)+1))
+}
+
+// process processes a received control packet, following section 6.8.6 of
+// RFC 5880.
+func (r *run) process(p *Packet) {
+	if p.YourDisc == 0 && r.state != StateDown && r.state != StateAdminDown {
+		return
+	}
+	r.remoteDisc = p.MyDisc
+	r.remoteMinRx = p.RequiredMinRxInterval
+	resetTimer(r.detectTimer, r.detectionTime(p))
+	switch {
+	case p.State == StateAdminDown:
+		if r.state != StateDown {
+			r.setState(StateDown, DiagNeighborDown)
+		}
+	case r.state == StateDown && p.State == StateDown:
+		r.setState(StateInit, DiagNone)
+	case r.state == StateDown && p.State == StateInit:
+		r.setState(StateUp, DiagNone)
+	case r.state == StateInit && (p.State == StateInit || p.State == StateUp):
+		r.setState(StateUp, DiagNone)
+	case r.state == StateUp && p.State == StateDown:
+		r.setState(StateDown, DiagNeighborDown)
+	}
+}
+
+// detectionTime returns the time after which the session goes down, if no
+// further control packet is received from the peer.
+func (r *run) detectionTime(p *Packet) time.Duration {
+	interval := r.cfg.RequiredMinRxInterval
+	if p.DesiredMinTxInterval > interval {
+		interval = p.DesiredMinTxInterval
+	}
+	return time.Duration(p.DetectMult) * interval
+}
+
+// expire handles the expiry of the detection timer.
+func (r *run) expire() {
+	r.remoteDisc = 0
+	if r.state == StateInit || r.state == StateUp {
+		r.setState(StateDown, DiagTimeExpired)
+	}
+}
+
+func (r *run) setState(state State, diag Diag) {
+	old := r.state
+	r.state, r.diag = state, diag
+	atomic.StoreUint32(&r.curr, uint32(state))
+	r.logger.Info("BFD: session state changed", "old", old, "new", state, "diag", diag)
+	if r.onChange != nil {
+		r.onChange(old, state)
+	}
+}
+
+// resetTimer resets a timer that might have fired without its channel being
+// drained.
+func resetTimer(t *time.Timer, d time.Duration) {
+	if !t.Stop() {
+		select {
+		case <-t.C:
+		default:
+		}
+	}
+	t.Reset(d)
+}
@@ -0,0 +1,318 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brconf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"net"
+	"strings"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
+)
+
+// ACLAction is the action taken for packets matching an ACL rule.
+type ACLAction string
+
+const (
+	// ACLAccept forwards matching packets and stops the rule evaluation.
+	ACLAccept ACLAction = "accept"
+	// ACLDrop drops matching packets and stops the rule evaluation.
+	ACLDrop ACLAction = "drop"
+	// ACLCount counts matching packets and continues the rule evaluation.
+	ACLCount ACLAction = "count"
+)
+
+// ACL is an ordered list of packet filtering rules. The rules are evaluated in
+// order until an accept or drop rule matches. Packets that match no such rule
+// are forwarded.
+type ACL struct {
+	Rules []*ACLRule
+}
+
+// LoadACL loads the ACL from the JSON file at path.
+func LoadACL(path string) (*ACL, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to read ACL file", err, "path", path)
+	}
+	acl, err := ParseACL(raw)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to parse ACL file", err, "path", path)
+	}
+	return acl, nil
+}
+
+// ParseACL parses and validates the JSON encoded ACL.
+func ParseACL(raw []byte) (*ACL, error) {
+	acl := &ACL{}
+	if err := json.Unmarshal(raw, acl); err != nil {
+		return nil, err
+	}
+	for i, r := range acl.Rules {
+		if r == nil {
+			return nil, common.NewBasicError("Empty ACL rule", nil, "idx", i)
+		}
+		if err := r.init(); err != nil {
+			return nil, common.NewBasicError("Invalid ACL rule", err, "idx", i, "name", r.Name)
+		}
+	}
+	return acl, nil
+}
+
+// Empty returns whether the ACL contains no rules. A nil ACL is empty.
+func (acl *ACL) Empty() bool {
+	return acl == nil || len(acl.Rules) == 0
+}
+
+// Evaluate evaluates the rules against the packet and returns whether the
+// packet must be dropped. The matched callback, if not nil, is called for
+// every rule the packet matches.
+func (acl *ACL) Evaluate(p *ACLPacket, matched func(r *ACLRule)) bool {
+	if acl.Empty() {
+		return false
+	}
+	for _, r := range acl.Rules {
+		if !r.Match(p) {
+			continue
+		}
+		if matched != nil {
+			matched(r)
+		}
+		switch r.Action {
+		case ACLAccept:
+			return false
+		case ACLDrop:
+			return true
+		}
+	}
+	return false
+}
+
+// ACLPacket contains the packet fields ACL rules and traffic classes match on.
+type ACLPacket struct {
+	// IfID is the ingress interface. 0 denotes the internal interface.
+	IfID    common.IFIDType
+	SrcIA   addr.IA
+	DstIA   addr.IA
+	SrcHost addr.HostAddr
+	DstHost addr.HostAddr
+	// L4 is the L4 protocol, L4None if it is unknown.
+	L4 common.L4ProtocolType
+	// SrcPort and DstPort are the UDP ports, 0 for other L4 protocols.
+	SrcPort uint16
+	DstPort uint16
+	// Extns are the hop-by-hop and end-to-end extensions of the packet.
+	Extns []common.ExtnType
+}
+
+// ACLRule is a single packet filtering rule.
+type ACLRule struct {
+	// Name identifies the rule in logs and metrics.
+	Name string
+	// Action is the action taken for matching packets.
+	Action ACLAction
+	PacketMatch
+}
+
+// init validates the rule and initializes its match.
+func (r *ACLRule) init() error {
+	switch r.Action {
+	case ACLAccept, ACLDrop, ACLCount:
+	default:
+		return common.NewBasicError("Unknown ACL action", nil, "action", r.Action)
+	}
+	if r.Name == "" {
+		return common.NewBasicError("ACL rule name not set", nil)
+	}
+	return r.PacketMatch.init()
+}
+
+// PacketMatch matches packets based on their fields. A packet matches if it
+// matches all the set fields. Unset fields match any packet.
+type PacketMatch struct {
+	// Interfaces are the ingress interfaces to match. Interface 0 denotes the
+	// internal interface.
+	Interfaces []common.IFIDType
+	// SrcIA and DstIA match the source and destination ISD-AS. A 0 ISD or AS
+	// is a wildcard, e.g., "1-0" matches all ASes in ISD 1.
+	SrcIA addr.IA
+	DstIA addr.IA
+	// SrcHost and DstHost match the source and destination host address
+	// against a CIDR prefix, e.g., "192.0.2.0/24".
+	SrcHost string
+	DstHost string
+	// L4 matches the L4 protocol (SCMP | TCP | UDP).
+	L4 string
+	// SrcPorts and DstPorts match the UDP ports against a port, e.g., "53",
+	// or a port range, e.g., "40000-40999".
+	SrcPorts string
+	DstPorts string
+	// Extensions matches packets that contain any of the named extensions,
+	// e.g., "OneHopPath".
+	Extensions []string
+
+	srcNet   *net.IPNet
+	dstNet   *net.IPNet
+	l4       common.L4ProtocolType
+	srcPorts overlay.PortRange
+	dstPorts overlay.PortRange
+	extns    []common.ExtnType
+}
+
+// Match returns whether the packet matches.
+func (r *PacketMatch) Match(p *ACLPacket) bool {
+	if len(r.Interfaces) > 0 && !r.matchIfID(p.IfID) {
+		return false
+	}
+	if !matchIA(r.SrcIA, p.SrcIA) || !matchIA(r.DstIA, p.DstIA) {
+		return false
+	}
+	if !matchHost(r.srcNet, p.SrcHost) || !matchHost(r.dstNet, p.DstHost) {
+		return false
+	}
+	if r.l4 != common.L4None && r.l4 != p.L4 {
+		return false
+	}
+	if !r.srcPorts.IsEmpty() || !r.dstPorts.IsEmpty() {
+		if p.L4 != common.L4UDP {
+			return false
+		}
+		if !matchPort(r.srcPorts, p.SrcPort) || !matchPort(r.dstPorts, p.DstPort) {
+			return false
+		}
+	}
+	if len(r.extns) > 0 && !r.matchExtns(p.Extns) {
+		return false
+	}
+	return true
+}
+
+func (r *PacketMatch) matchIfID(ifid common.IFIDType) bool {
+	for _, id := range r.Interfaces {
+		if id == ifid {
+			return true
+		}
+	}
+	return false
+}
+
+func (r *PacketMatch) matchExtns(extns []common.ExtnType) bool {
+	for _, e := range extns {
+		for _, want := range r.extns {
+			if e == want {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// init validates the match and initializes the parsed representation of its
+// fields.
+func (r *PacketMatch) init() error {
+	var err error
+	if r.srcNet, err = parsePrefix(r.SrcHost); err != nil {
+		return err
+	}
+	if r.dstNet, err = parsePrefix(r.DstHost); err != nil {
+		return err
+	}
+	if r.l4, err = parseL4(r.L4); err != nil {
+		return err
+	}
+	if r.srcPorts, err = overlay.ParsePortRange(r.SrcPorts); err != nil {
+		return err
+	}
+	if r.dstPorts, err = overlay.ParsePortRange(r.DstPorts); err != nil {
+		return err
+	}
+	if (!r.srcPorts.IsEmpty() || !r.dstPorts.IsEmpty()) &&
+		r.l4 != common.L4None && r.l4 != common.L4UDP {
+		return common.NewBasicError("Ports require UDP", nil, "l4", r.L4)
+	}
+	r.extns = r.extns[:0]
+	for _, name := range r.Extensions {
+		extn, ok := extnTypes[name]
+		if !ok {
+			return common.NewBasicError("Unknown extension", nil, "extn", name)
+		}
+		r.extns = append(r.extns, extn)
+	}
+	return nil
+}
+
+// matchPort returns whether the port is in the range. The empty range matches
+// any port.
+func matchPort(r overlay.PortRange, port uint16) bool {
+	return r.IsEmpty() || r.Contains(port)
+}
+
+func matchIA(rule, ia addr.IA) bool {
+	return (rule.I == 0 || rule.I == ia.I) && (rule.A == 0 || rule.A == ia.A)
+}
+
+func matchHost(prefix *net.IPNet, host addr.HostAddr) bool {
+	if prefix == nil {
+		return true
+	}
+	if host == nil {
+		return false
+	}
+	// IP returns nil for SVC addresses, which never match a prefix.
+	ip := host.IP()
+	return ip != nil && prefix.Contains(ip)
+}
+
+func parsePrefix(s string) (*net.IPNet, error) {
+	if s == "" {
+		return nil, nil
+	}
+	_, prefix, err := net.ParseCIDR(s)
+	if err != nil {
+		return nil, common.NewBasicError("Invalid host prefix", err, "input", s)
+	}
+	return prefix, nil
+}
+
+func parseL4(s string) (common.L4ProtocolType, error) {
+	if s == "" {
+		return common.L4None, nil
+	}
+	for l4 := range common.L4Protocols {
+		if strings.EqualFold(s, l4.String()) {
+			return l4, nil
+		}
+	}
+	return common.L4None, common.NewBasicError("Unknown L4 protocol", nil, "input", s)
+}
+
+var extnTypes = map[string]common.ExtnType{}
+
+func init() {
+	for _, extn := range []common.ExtnType{
+		common.ExtnSCMPType,
+		common.ExtnOneHopPathType,
+		common.ExtnSIBRAType,
+		common.ExtnPathTransType,
+		common.ExtnPathProbeType,
+		common.ExtnSCIONPacketSecurityType,
+		common.ExtnE2EDebugType,
+	} {
+		extnTypes[extn.String()] = extn
+	}
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brconf
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+func TestParseACL(t *testing.T) {
+	Convey("ParseACL", t, func() {
+		Convey("parses a valid ACL", func() {
+			acl, err := ParseACL([]byte(`{"Rules": [{"Name": "r", "Action": "count",
+				"Interfaces": [0, 1], "SrcIA": "1-ff00:0:110", "DstHost": "10.0.0.0/8",
+				"L4": "udp", "SrcPorts": "53", "Extensions": ["OneHopPath"]}]}`))
+			So(err, ShouldBeNil)
+			So(len(acl.Rules), ShouldEqual, 1)
+			r := acl.Rules[0]
+			SoMsg("SrcIA", r.SrcIA, ShouldResemble, xtest.MustParseIA("1-ff00:0:110"))
+			SoMsg("l4", r.l4, ShouldEqual, common.L4UDP)
+			SoMsg("srcPorts", r.srcPorts, ShouldResemble, overlay.PortRange{Min: 53, Max: 53})
+			SoMsg("extns", r.extns, ShouldResemble,
+				[]common.ExtnType{common.ExtnOneHopPathType})
+		})
+		tests := map[string]string{
+			"unknown action":    `{"Name": "r", "Action": "reject"}`,
+			"missing name":      `{"Action": "drop"}`,
+			"invalid prefix":    `{"Name": "r", "Action": "drop", "SrcHost": "10.0.0.1"}`,
+			"unknown L4":        `{"Name": "r", "Action": "drop", "L4": "QUIC"}`,
+			"invalid ports":     `{"Name": "r", "Action": "drop", "DstPorts": "20-10"}`,
+			"ports without UDP": `{"Name": "r", "Action": "drop", "L4": "SCMP", "DstPorts": "53"}`,
+			"unknown extension": `{"Name": "r", "Action": "drop", "Extensions": ["Foo"]}`,
+			"invalid IA":        `{"Name": "r", "Action": "drop", "SrcIA": "1"}`,
+			"empty rule":        `null`,
+		}
+		for name, rule := range tests {
+			Convey("fails for "+name, func() {
+				_, err := ParseACL([]byte(`{"Rules": [` + rule + `]}`))
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestACLEvaluate(t *testing.T) {
+	Convey("Evaluate", t, func() {
+		acl, err := ParseACL([]byte(`{"Rules": [
+			{"Name": "count-all", "Action": "count"},
+			{"Name": "allow-local", "Action": "accept", "Interfaces": [0]},
+			{"Name": "block-isd", "Action": "drop", "SrcIA": "2-0"},
+			{"Name": "block-host", "Action": "drop", "SrcHost": "192.0.2.0/24"},
+			{"Name": "block-dns", "Action": "drop", "L4": "UDP", "DstPorts": "53"},
+			{"Name": "block-probe", "Action": "drop", "Extensions": ["PathProbe"]}
+		]}`))
+		So(err, ShouldBeNil)
+		p := &ACLPacket{
+			IfID:    1,
+			SrcIA:   xtest.MustParseIA("1-ff00:0:110"),
+			DstIA:   xtest.MustParseIA("1-ff00:0:111"),
+			SrcHost: addr.HostFromIP(net.IPv4(198, 51, 100, 1)),
+			DstHost: addr.SvcBS,
+			L4:      common.L4UDP,
+			SrcPort: 40000,
+			DstPort: 30041,
+		}
+		var matched []string
+		record := func(r *ACLRule) { matched = append(matched, r.Name) }
+		Convey("forwards packets matching no drop rule", func() {
+			So(acl.Evaluate(p, record), ShouldBeFalse)
+			So(matched, ShouldResemble, []string{"count-all"})
+		})
+		Convey("stops at accept rules", func() {
+			p.IfID = 0
+			p.SrcIA = xtest.MustParseIA("2-ff00:0:210")
+			So(acl.Evaluate(p, record), ShouldBeFalse)
+			So(matched, ShouldResemble, []string{"count-all", "allow-local"})
+		})
+		Convey("drops packets from wildcard IA", func() {
+			p.SrcIA = xtest.MustParseIA("2-ff00:0:210")
+			So(acl.Evaluate(p, record), ShouldBeTrue)
+			So(matched, ShouldResemble, []string{"count-all", "block-isd"})
+		})
+		Convey("drops packets from host prefix", func() {
+			p.SrcHost = addr.HostFromIP(net.IPv4(192, 0, 2, 10))
+			So(acl.Evaluate(p, record), ShouldBeTrue)
+			So(matched, ShouldResemble, []string{"count-all", "block-host"})
+		})
+		Convey("drops packets to port", func() {
+			p.DstPort = 53
+			So(acl.Evaluate(p, record), ShouldBeTrue)
+			Convey("but not for other L4 protocols", func() {
+				p.L4 = common.L4SCMP
+				So(acl.Evaluate(p, nil), ShouldBeFalse)
+			})
+		})
+		Convey("drops packets with extension", func() {
+			p.Extns = []common.ExtnType{common.ExtnPathTransType, common.ExtnPathProbeType}
+			So(acl.Evaluate(p, record), ShouldBeTrue)
+			So(matched, ShouldResemble, []string{"count-all", "block-probe"})
+		})
+		Convey("empty ACL drops nothing", func() {
+			var empty *ACL
+			So(empty.Evaluate(p, nil), ShouldBeFalse)
+		})
+	})
+}
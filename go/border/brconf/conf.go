@@ -19,6 +19,7 @@ package brconf
 
 import (
 	"crypto/sha256"
+	"os"
 	"path/filepath"
 	"sync"
 
@@ -28,9 +29,17 @@ import (
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/as_conf"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/lib/infra/modules/trust"
 	"github.com/scionproto/scion/go/lib/keyconf"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/overlay"
 	"github.com/scionproto/scion/go/lib/scrypto"
+	"github.com/scionproto/scion/go/lib/scrypto/cert"
+	"github.com/scionproto/scion/go/lib/scrypto/trc"
 	"github.com/scionproto/scion/go/lib/topology"
+	"github.com/scionproto/scion/go/lib/util"
 )
 
 // BRConf is the main config structure. It contains the dynamic
@@ -49,10 +58,20 @@ type BRConf struct {
 	MasterKeys keyconf.Master
 	// HFMacPool is the pool of Hop Field MAC generation instances.
 	HFMacPool *sync.Pool
+	// Signer signs the revocations issued by the router. It is nil if the
+	// config directory does not contain the AS signing key.
+	Signer infra.Signer
 	// Net is the network configuration of this router.
 	Net *netconf.NetConf
 	// Dir is the configuration directory.
 	Dir string
+	// DirectPorts is the range of end host ports that bypass the dispatcher.
+	DirectPorts overlay.PortRange
+	// ACL contains the packet filtering rules. It is nil if no ACL is set.
+	ACL *ACL
+	// QoS contains the traffic scheduling and policing configuration. It is
+	// nil if no QoS configuration is set.
+	QoS *QoS
 }
 
 // Load sets up the configuration, loading it from the supplied config directory.
@@ -72,6 +91,9 @@ func Load(id, confDir string) (*BRConf, error) {
 	if err := conf.initMacPool(); err != nil {
 		return nil, err
 	}
+	if err := conf.loadSigner(); err != nil {
+		return nil, err
+	}
 	if err := conf.initNet(); err != nil {
 		return nil, err
 	}
@@ -82,10 +104,14 @@ func Load(id, confDir string) (*BRConf, error) {
 // to topology with the oldConf.
 func WithNewTopo(id string, topo *topology.Topo, oldConf *BRConf) (*BRConf, error) {
 	conf := &BRConf{
-		Dir:        oldConf.Dir,
-		ASConf:     oldConf.ASConf,
-		MasterKeys: oldConf.MasterKeys,
-		HFMacPool:  oldConf.HFMacPool,
+		Dir:         oldConf.Dir,
+		ASConf:      oldConf.ASConf,
+		MasterKeys:  oldConf.MasterKeys,
+		HFMacPool:   oldConf.HFMacPool,
+		Signer:      oldConf.Signer,
+		DirectPorts: oldConf.DirectPorts,
+		ACL:         oldConf.ACL,
+		QoS:         oldConf.QoS,
 	}
 	if err := conf.initTopo(id, topo); err != nil {
 		return nil, common.NewBasicError("Unable to initialize topo", err)
@@ -144,6 +170,45 @@ func (cfg *BRConf) loadMasterKeys() error {
 	return nil
 }
 
+// loadSigner loads the AS signing key, and the newest certificate chain and TRC
+// from the config directory. The signer is not created if the config directory
+// does not contain the AS signing key.
+func (cfg *BRConf) loadSigner() error {
+	keyDir := filepath.Join(cfg.Dir, "keys")
+	if _, err := os.Stat(filepath.Join(keyDir, keyconf.SigKeyFile)); os.IsNotExist(err) {
+		return nil
+	}
+	keys, err := keyconf.Load(keyDir, false, false, false, false)
+	if err != nil {
+		return common.NewBasicError("Unable to load key config", err)
+	}
+	certDir := filepath.Join(cfg.Dir, "certs")
+	logErr := func(err error) {
+		log.Warn("Unable to read trust file", "err", err)
+	}
+	chain, err := cert.ChainFromDir(certDir, cfg.IA, logErr)
+	if err != nil || chain == nil {
+		return common.NewBasicError("Unable to load certificate chain", err, "dir", certDir)
+	}
+	t, err := trc.TRCFromDir(certDir, cfg.IA.I, logErr)
+	if err != nil || t == nil {
+		return common.NewBasicError("Unable to load TRC", err, "dir", certDir)
+	}
+	meta := infra.SignerMeta{
+		Algo: chain.Leaf.SignAlgorithm,
+		Src: ctrl.SignSrcDef{
+			IA:       cfg.IA,
+			ChainVer: chain.Leaf.Version,
+			TRCVer:   t.Version,
+		},
+		ExpTime: util.SecsToTime(chain.Leaf.ExpirationTime),
+	}
+	if cfg.Signer, err = trust.NewBasicSigner(keys.SignKey, meta); err != nil {
+		return common.NewBasicError("Unable to create signer", err)
+	}
+	return nil
+}
+
 // initMacPool initializes the hop field mac pool.
 func (cfg *BRConf) initMacPool() error {
 	// Generate keys
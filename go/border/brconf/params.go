@@ -16,11 +16,17 @@ package brconf
 
 import (
 	"io"
+	"strconv"
+	"time"
 
+	"github.com/scionproto/scion/go/border/bfd"
+	"github.com/scionproto/scion/go/border/xsk"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/config"
 	"github.com/scionproto/scion/go/lib/env"
 	"github.com/scionproto/scion/go/lib/infra/modules/idiscovery"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/util"
 )
 
 var _ config.Config = (*Config)(nil)
@@ -77,26 +83,227 @@ type BR struct {
 	// RollbackFailAction indicates the action that should be taken
 	// if the rollback fails.
 	RollbackFailAction FailAction
+	// DirectPorts is the range of end host ports that bypass the dispatcher.
+	// Packets for these ports are delivered directly to the port.
+	DirectPorts overlay.PortRange
+	// ACLFile is the path to the JSON file containing the packet filtering
+	// rules. The file is reloaded together with the topology.
+	ACLFile string
+	// QoSFile is the path to the JSON file containing the traffic scheduling
+	// and policing configuration. The file is reloaded together with the
+	// topology.
+	QoSFile string
+	// BFD configures the link liveness detection on the external interfaces.
+	BFD BFD
+	// IO configures the packet I/O of the sockets.
+	IO IO
 }
 
 func (cfg *BR) InitDefaults() {
 	if cfg.RollbackFailAction != FailActionContinue {
 		cfg.RollbackFailAction = FailActionFatal
 	}
+	cfg.BFD.InitDefaults()
+	cfg.IO.InitDefaults()
 }
 
 func (cfg *BR) Validate() error {
-	return cfg.RollbackFailAction.Validate()
+	if err := cfg.RollbackFailAction.Validate(); err != nil {
+		return err
+	}
+	if err := cfg.DirectPorts.ValidateDirect(); err != nil {
+		return err
+	}
+	if err := cfg.BFD.Validate(); err != nil {
+		return err
+	}
+	return cfg.IO.Validate()
 }
 
-func (cfg *BR) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
+func (cfg *BR) Sample(dst io.Writer, path config.Path, ctx config.CtxMap) {
 	config.WriteString(dst, brSample)
+	config.WriteSample(dst, path, ctx, &cfg.BFD, &cfg.IO)
 }
 
 func (cfg *BR) ConfigName() string {
 	return "br"
 }
 
+var _ config.Config = (*BFD)(nil)
+
+// BFD contains the configuration of the BFD sessions the router runs with the
+// peer router of each external interface.
+type BFD struct {
+	// Enable enables the BFD sessions. The peer routers must enable BFD as
+	// well.
+	Enable bool
+	// DetectMult is the detection time multiplier.
+	DetectMult uint8
+	// DesiredMinTxInterval is the minimum interval between control packets
+	// sent to the peer.
+	DesiredMinTxInterval util.DurWrap
+	// RequiredMinRxInterval is the minimum interval between control packets
+	// received from the peer.
+	RequiredMinRxInterval util.DurWrap
+}
+
+func (cfg *BFD) InitDefaults() {
+	if cfg.DetectMult == 0 {
+		cfg.DetectMult = bfd.DefaultDetectMult
+	}
+	if cfg.DesiredMinTxInterval.Duration == 0 {
+		cfg.DesiredMinTxInterval.Duration = bfd.DefaultDesiredMinTxInterval
+	}
+	if cfg.RequiredMinRxInterval.Duration == 0 {
+		cfg.RequiredMinRxInterval.Duration = bfd.DefaultRequiredMinRxInterval
+	}
+}
+
+func (cfg *BFD) Validate() error {
+	if cfg.DetectMult == 0 {
+		return common.NewBasicError("BFD DetectMult not set", nil)
+	}
+	if cfg.DesiredMinTxInterval.Duration < time.Millisecond {
+		return common.NewBasicError("BFD DesiredMinTxInterval too small", nil,
+			"interval", cfg.DesiredMinTxInterval)
+	}
+	if cfg.RequiredMinRxInterval.Duration < time.Millisecond {
+		return common.NewBasicError("BFD RequiredMinRxInterval too small", nil,
+			"interval", cfg.RequiredMinRxInterval)
+	}
+	return nil
+}
+
+func (cfg *BFD) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
+	config.WriteString(dst, bfdSample)
+}
+
+func (cfg *BFD) ConfigName() string {
+	return "bfd"
+}
+
+// SessionConfig returns the configuration of the BFD sessions.
+func (cfg *BFD) SessionConfig() bfd.Config {
+	return bfd.Config{
+		DetectMult:            cfg.DetectMult,
+		DesiredMinTxInterval:  cfg.DesiredMinTxInterval.Duration,
+		RequiredMinRxInterval: cfg.RequiredMinRxInterval.Duration,
+	}
+}
+
+var _ config.Config = (*IO)(nil)
+
+// IO contains the configuration of the packet I/O of the sockets.
+type IO struct {
+	// Sock is the type of the sockets, unless overridden by LocalSock or
+	// ExternalSocks.
+	Sock SockType
+	// LocalSock is the type of the local socket.
+	LocalSock SockType
+	// ExternalSocks maps interface IDs to the type of their sockets.
+	ExternalSocks map[string]SockType
+	// UDPOffload enables UDP generic segmentation and receive offload on the
+	// POSIX sockets, if supported by the kernel.
+	UDPOffload bool
+	// XDP configures the AF_XDP sockets.
+	XDP XDP
+}
+
+func (cfg *IO) InitDefaults() {
+	if cfg.Sock == "" {
+		cfg.Sock = DefaultSockType
+	}
+	cfg.XDP.InitDefaults()
+}
+
+func (cfg *IO) Validate() error {
+	if _, err := cfg.parseExternalSocks(); err != nil {
+		return err
+	}
+	return cfg.XDP.Validate()
+}
+
+func (cfg *IO) Sample(dst io.Writer, path config.Path, ctx config.CtxMap) {
+	config.WriteString(dst, ioSample)
+	config.WriteSample(dst, path, ctx, &cfg.XDP)
+}
+
+func (cfg *IO) ConfigName() string {
+	return "io"
+}
+
+// SockConf returns the socket types of the local and the external sockets.
+func (cfg *IO) SockConf() (SockConf, error) {
+	ext, err := cfg.parseExternalSocks()
+	if err != nil {
+		return SockConf{}, err
+	}
+	return SockConf{Default: cfg.Sock, LocalType: cfg.LocalSock, ExternalTypes: ext}, nil
+}
+
+func (cfg *IO) parseExternalSocks() (map[common.IFIDType]SockType, error) {
+	ext := make(map[common.IFIDType]SockType, len(cfg.ExternalSocks))
+	for raw, sockType := range cfg.ExternalSocks {
+		ifid, err := strconv.ParseUint(raw, 10, 64)
+		if err != nil {
+			return nil, common.NewBasicError("Invalid interface ID in ExternalSocks", err,
+				"ifid", raw)
+		}
+		ext[common.IFIDType(ifid)] = sockType
+	}
+	return ext, nil
+}
+
+var _ config.Config = (*XDP)(nil)
+
+// XDP contains the configuration of the AF_XDP sockets.
+type XDP struct {
+	// Queue is the receive queue of the network interfaces the sockets are
+	// bound to.
+	Queue int
+	// FrameSize is the size of the frames the packets are stored in. Larger
+	// packets are sent through the kernel.
+	FrameSize int
+	// NumFrames is the number of frames of each socket.
+	NumFrames int
+	// Generic forces generic mode XDP, even if the driver supports XDP.
+	Generic bool
+	// Copy forces copy mode, even if the driver supports zero-copy mode.
+	Copy bool
+}
+
+func (cfg *XDP) InitDefaults() {
+	if cfg.FrameSize == 0 {
+		cfg.FrameSize = xsk.DefaultFrameSize
+	}
+	if cfg.NumFrames == 0 {
+		cfg.NumFrames = xsk.DefaultNumFrames
+	}
+}
+
+func (cfg *XDP) Validate() error {
+	if cfg.Queue < 0 {
+		return common.NewBasicError("XDP Queue must not be negative", nil, "queue", cfg.Queue)
+	}
+	if cfg.FrameSize != 2048 && cfg.FrameSize != 4096 {
+		return common.NewBasicError("XDP FrameSize must be 2048 or 4096", nil,
+			"frameSize", cfg.FrameSize)
+	}
+	if cfg.NumFrames < 2 || cfg.NumFrames&(cfg.NumFrames-1) != 0 {
+		return common.NewBasicError("XDP NumFrames must be a power of two", nil,
+			"numFrames", cfg.NumFrames)
+	}
+	return nil
+}
+
+func (cfg *XDP) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
+	config.WriteString(dst, xdpSample)
+}
+
+func (cfg *XDP) ConfigName() string {
+	return "xdp"
+}
+
 var _ config.Config = (*Discovery)(nil)
 
 type Discovery struct {
@@ -21,8 +21,11 @@ import (
 	"github.com/BurntSushi/toml"
 	. "github.com/smartystreets/goconvey/convey"
 
+	"github.com/scionproto/scion/go/border/bfd"
+	"github.com/scionproto/scion/go/border/xsk"
 	"github.com/scionproto/scion/go/lib/env/envtest"
 	"github.com/scionproto/scion/go/lib/infra/modules/idiscovery/idiscoverytest"
+	"github.com/scionproto/scion/go/lib/overlay"
 )
 
 func TestConfigSample(t *testing.T) {
@@ -51,6 +54,15 @@ func InitTestDiscoveryConfig(cfg *Discovery) {
 
 func InitTestBRConfig(cfg *BR) {
 	cfg.Profile = true
+	cfg.DirectPorts = overlay.PortRange{Min: 40000, Max: 40999}
+	cfg.ACLFile = "acl.json"
+	cfg.QoSFile = "qos.json"
+	cfg.BFD.Enable = true
+	cfg.BFD.DetectMult = 5
+	cfg.IO.Sock = "xdp"
+	cfg.IO.UDPOffload = true
+	cfg.IO.XDP.Queue = 3
+	cfg.IO.XDP.Generic = true
 }
 
 func CheckTestConfig(cfg *Config, id string) {
@@ -67,4 +79,39 @@ func CheckTestDiscoveryConfig(cfg *Discovery) {
 func CheckTestBRConfig(cfg *BR) {
 	SoMsg("Profile correct", cfg.Profile, ShouldBeFalse)
 	SoMsg("RollbackFailAction correct", cfg.RollbackFailAction, ShouldEqual, FailActionFatal)
+	SoMsg("DirectPorts correct", cfg.DirectPorts, ShouldResemble, overlay.PortRange{})
+	SoMsg("ACLFile correct", cfg.ACLFile, ShouldBeEmpty)
+	SoMsg("QoSFile correct", cfg.QoSFile, ShouldBeEmpty)
+	SoMsg("BFD.Enable correct", cfg.BFD.Enable, ShouldBeFalse)
+	SoMsg("BFD.DetectMult correct", cfg.BFD.DetectMult, ShouldEqual,
+		uint8(bfd.DefaultDetectMult))
+	SoMsg("BFD.DesiredMinTxInterval correct", cfg.BFD.DesiredMinTxInterval.Duration,
+		ShouldEqual, bfd.DefaultDesiredMinTxInterval)
+	SoMsg("BFD.RequiredMinRxInterval correct", cfg.BFD.RequiredMinRxInterval.Duration,
+		ShouldEqual, bfd.DefaultRequiredMinRxInterval)
+	SoMsg("IO.Sock correct", cfg.IO.Sock, ShouldEqual, DefaultSockType)
+	SoMsg("IO.LocalSock correct", cfg.IO.LocalSock, ShouldBeEmpty)
+	SoMsg("IO.ExternalSocks correct", cfg.IO.ExternalSocks, ShouldBeEmpty)
+	SoMsg("IO.UDPOffload correct", cfg.IO.UDPOffload, ShouldBeFalse)
+	SoMsg("IO.XDP.Queue correct", cfg.IO.XDP.Queue, ShouldEqual, 0)
+	SoMsg("IO.XDP.FrameSize correct", cfg.IO.XDP.FrameSize, ShouldEqual, xsk.DefaultFrameSize)
+	SoMsg("IO.XDP.NumFrames correct", cfg.IO.XDP.NumFrames, ShouldEqual, xsk.DefaultNumFrames)
+	SoMsg("IO.XDP.Generic correct", cfg.IO.XDP.Generic, ShouldBeFalse)
+	SoMsg("IO.XDP.Copy correct", cfg.IO.XDP.Copy, ShouldBeFalse)
+}
+
+func TestIOSockConf(t *testing.T) {
+	Convey("SockConf", t, func() {
+		cfg := IO{Sock: "posix", ExternalSocks: map[string]SockType{"2": "xdp"}}
+		cfg.InitDefaults()
+		sockConf, err := cfg.SockConf()
+		So(err, ShouldBeNil)
+		So(sockConf.Loc(), ShouldEqual, DefaultSockType)
+		So(sockConf.Ext(1), ShouldEqual, DefaultSockType)
+		So(sockConf.Ext(2), ShouldEqual, SockType("xdp"))
+		Convey("fails for invalid interface IDs", func() {
+			cfg.ExternalSocks["x"] = "xdp"
+			So(cfg.Validate(), ShouldNotBeNil)
+		})
+	})
 }
@@ -0,0 +1,266 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brconf
+
+import (
+	"encoding/json"
+	"io/ioutil"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/tokenbucket"
+)
+
+const (
+	// ClassControl is the traffic class of control traffic, which has strict
+	// priority over all other classes and is policed by the control policers.
+	ClassControl = 0
+	// DefaultQueueLen is the default capacity of each output queue in packets.
+	DefaultQueueLen = 64
+)
+
+// QoS is the traffic scheduling and policing configuration of the router.
+//
+// The output traffic of each interface is scheduled in traffic classes. The
+// control class, which contains the one-hop path traffic such as beacons and
+// IFID keepalives, and the SCMP traffic, has strict priority. The control
+// traffic is policed by the control policers. The data traffic is assigned to
+// the first configured class it matches, or to the default class if it matches
+// none. The data classes share the capacity of the interface according to
+// their weights.
+type QoS struct {
+	// QueueLen is the capacity of each output queue in packets.
+	QueueLen int
+	// Interfaces are the interfaces with output scheduling. Interface 0
+	// denotes the internal interface. If empty, the output of all interfaces
+	// is scheduled.
+	Interfaces []common.IFIDType
+	// Classes are the data traffic classes, in order of matching.
+	Classes []*TrafficClass
+	// DefaultWeight is the weight of the default class.
+	DefaultWeight int
+	// DefaultDSCP is the DSCP value set on the packets of the default class.
+	DefaultDSCP uint8
+	// Policers limit the rate of the data traffic received on interfaces.
+	Policers []*Policer
+	// ControlPolicers limit the rate of the control traffic, i.e., one-hop
+	// path and SCMP traffic, received on interfaces.
+	ControlPolicers []*Policer
+
+	policers        map[common.IFIDType]*tokenbucket.Bucket
+	controlPolicers map[common.IFIDType]*tokenbucket.Bucket
+}
+
+// TrafficClass is a class of data traffic.
+type TrafficClass struct {
+	// Name identifies the class in metrics.
+	Name string
+	// Weight is the share of the interface capacity of the class, relative to
+	// the weights of the other classes.
+	Weight int
+	// DSCP is the DSCP value set in the overlay IP header of the packets of
+	// the class. 0 leaves the DSCP unset.
+	DSCP uint8
+	PacketMatch
+}
+
+// Policer is a token bucket policer for the traffic received on interfaces.
+// Each interface is policed independently.
+type Policer struct {
+	// Interfaces are the policed ingress interfaces. Interface 0 denotes the
+	// internal interface.
+	Interfaces []common.IFIDType
+	// Rate is the rate in bytes per second.
+	Rate uint64
+	// Burst is the burst size in bytes.
+	Burst uint64
+}
+
+// LoadQoS loads the QoS configuration from the JSON file at path.
+func LoadQoS(path string) (*QoS, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to read QoS file", err, "path", path)
+	}
+	q, err := ParseQoS(raw)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to parse QoS file", err, "path", path)
+	}
+	return q, nil
+}
+
+// ParseQoS parses and validates the JSON encoded QoS configuration.
+func ParseQoS(raw []byte) (*QoS, error) {
+	q := &QoS{}
+	if err := json.Unmarshal(raw, q); err != nil {
+		return nil, err
+	}
+	if q.QueueLen == 0 {
+		q.QueueLen = DefaultQueueLen
+	}
+	if q.DefaultWeight == 0 {
+		q.DefaultWeight = 1
+	}
+	if q.QueueLen < 0 || q.DefaultWeight < 0 {
+		return nil, common.NewBasicError("Invalid QoS config", nil,
+			"queueLen", q.QueueLen, "defaultWeight", q.DefaultWeight)
+	}
+	if err := validateDSCP(q.DefaultDSCP); err != nil {
+		return nil, err
+	}
+	for i, c := range q.Classes {
+		if c == nil {
+			return nil, common.NewBasicError("Empty traffic class", nil, "idx", i)
+		}
+		if err := c.init(); err != nil {
+			return nil, common.NewBasicError("Invalid traffic class", err,
+				"idx", i, "name", c.Name)
+		}
+	}
+	var err error
+	if q.policers, err = newPolicers(q.Policers); err != nil {
+		return nil, err
+	}
+	if q.controlPolicers, err = newPolicers(q.ControlPolicers); err != nil {
+		return nil, common.NewBasicError("Invalid control policers", err)
+	}
+	return q, nil
+}
+
+// newPolicers creates the token buckets of the policers, indexed by interface.
+func newPolicers(policers []*Policer) (map[common.IFIDType]*tokenbucket.Bucket, error) {
+	buckets := make(map[common.IFIDType]*tokenbucket.Bucket)
+	for i, p := range policers {
+		if p == nil || p.Rate == 0 || p.Burst < common.MaxMTU {
+			return nil, common.NewBasicError("Invalid policer", nil, "idx", i,
+				"minBurst", common.MaxMTU)
+		}
+		for _, ifid := range p.Interfaces {
+			if _, ok := buckets[ifid]; ok {
+				return nil, common.NewBasicError("Interface policed twice", nil, "ifid", ifid)
+			}
+			buckets[ifid] = tokenbucket.New(p.Rate, p.Burst)
+		}
+	}
+	return buckets, nil
+}
+
+func (c *TrafficClass) init() error {
+	if c.Name == "" {
+		return common.NewBasicError("Traffic class name not set", nil)
+	}
+	if c.Weight <= 0 {
+		return common.NewBasicError("Invalid traffic class weight", nil, "weight", c.Weight)
+	}
+	if err := validateDSCP(c.DSCP); err != nil {
+		return err
+	}
+	return c.PacketMatch.init()
+}
+
+func validateDSCP(dscp uint8) error {
+	if dscp > 63 {
+		return common.NewBasicError("Invalid DSCP", nil, "dscp", dscp)
+	}
+	return nil
+}
+
+// Scheduled returns whether the output of the interface is scheduled.
+func (q *QoS) Scheduled(ifid common.IFIDType) bool {
+	if q == nil {
+		return false
+	}
+	if len(q.Interfaces) == 0 {
+		return true
+	}
+	for _, id := range q.Interfaces {
+		if id == ifid {
+			return true
+		}
+	}
+	return false
+}
+
+// NumClasses returns the number of traffic classes, including the control
+// and the default class.
+func (q *QoS) NumClasses() int {
+	return len(q.Classes) + 2
+}
+
+// DefaultClass returns the default traffic class.
+func (q *QoS) DefaultClass() int {
+	return len(q.Classes) + 1
+}
+
+// Classify returns the traffic class of the data packet.
+func (q *QoS) Classify(p *ACLPacket) int {
+	for i, c := range q.Classes {
+		if c.Match(p) {
+			return i + 1
+		}
+	}
+	return q.DefaultClass()
+}
+
+// ClassName returns the name of the traffic class.
+func (q *QoS) ClassName(class int) string {
+	switch class {
+	case ClassControl:
+		return "control"
+	case q.DefaultClass():
+		return "default"
+	}
+	return q.Classes[class-1].Name
+}
+
+// Weights returns the weights of all traffic classes, indexed by class. The
+// weight of the control class is 0, as it has strict priority.
+func (q *QoS) Weights() []int {
+	weights := make([]int, q.NumClasses())
+	for i, c := range q.Classes {
+		weights[i+1] = c.Weight
+	}
+	weights[q.DefaultClass()] = q.DefaultWeight
+	return weights
+}
+
+// DSCP returns the DSCP value of the traffic class. 0 means unset.
+func (q *QoS) DSCP(class int) uint8 {
+	switch class {
+	case ClassControl:
+		return 0
+	case q.DefaultClass():
+		return q.DefaultDSCP
+	}
+	return q.Classes[class-1].DSCP
+}
+
+// Police returns whether a data packet of n bytes received on the interface
+// at time now conforms to the policer of the interface.
+func (q *QoS) Police(ifid common.IFIDType, n int, now time.Time) bool {
+	if q == nil {
+		return true
+	}
+	return q.policers[ifid].Allow(now, n)
+}
+
+// PoliceControl returns whether a control packet of n bytes received on the
+// interface at time now conforms to the control policer of the interface.
+func (q *QoS) PoliceControl(ifid common.IFIDType, n int, now time.Time) bool {
+	if q == nil {
+		return true
+	}
+	return q.controlPolicers[ifid].Allow(now, n)
+}
@@ -0,0 +1,113 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package brconf
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+func TestParseQoS(t *testing.T) {
+	Convey("ParseQoS", t, func() {
+		Convey("sets defaults", func() {
+			q, err := ParseQoS([]byte(`{}`))
+			So(err, ShouldBeNil)
+			So(q.QueueLen, ShouldEqual, DefaultQueueLen)
+			So(q.DefaultWeight, ShouldEqual, 1)
+			So(q.Scheduled(0), ShouldBeTrue)
+			So(q.Weights(), ShouldResemble, []int{0, 1})
+		})
+		tests := map[string]string{
+			"negative queue length": `{"QueueLen": -1}`,
+			"invalid default DSCP":  `{"DefaultDSCP": 64}`,
+			"missing class name":    `{"Classes": [{"Weight": 1}]}`,
+			"invalid class weight":  `{"Classes": [{"Name": "c"}]}`,
+			"invalid class match":   `{"Classes": [{"Name": "c", "Weight": 1, "L4": "QUIC"}]}`,
+			"empty class":           `{"Classes": [null]}`,
+			"policer without rate":  `{"Policers": [{"Interfaces": [1], "Burst": 65535}]}`,
+			"policer small burst": `{"Policers": [
+				{"Interfaces": [1], "Rate": 1000, "Burst": 100}]}`,
+			"interface policed twice": `{"Policers": [
+				{"Interfaces": [1], "Rate": 1000, "Burst": 65535},
+				{"Interfaces": [1], "Rate": 1000, "Burst": 65535}]}`,
+			"control policer small burst": `{"ControlPolicers": [
+				{"Interfaces": [1], "Rate": 1000, "Burst": 100}]}`,
+		}
+		for name, raw := range tests {
+			Convey("fails for "+name, func() {
+				_, err := ParseQoS([]byte(raw))
+				So(err, ShouldNotBeNil)
+			})
+		}
+	})
+}
+
+func TestQoS(t *testing.T) {
+	Convey("QoS", t, func() {
+		q, err := ParseQoS([]byte(`{
+			"Interfaces": [1, 2],
+			"Classes": [
+				{"Name": "bulk", "Weight": 1, "DSCP": 8, "DstPorts": "40000-40999"},
+				{"Name": "interactive", "Weight": 4, "DSCP": 46, "L4": "UDP"}
+			],
+			"DefaultWeight": 2,
+			"Policers": [{"Interfaces": [1], "Rate": 1000, "Burst": 65535}],
+			"ControlPolicers": [{"Interfaces": [1, 2], "Rate": 1000, "Burst": 3000}]
+		}`))
+		So(err, ShouldBeNil)
+		Convey("schedules the configured interfaces", func() {
+			So(q.Scheduled(1), ShouldBeTrue)
+			So(q.Scheduled(3), ShouldBeFalse)
+			So((*QoS)(nil).Scheduled(1), ShouldBeFalse)
+		})
+		Convey("classifies packets in order", func() {
+			p := &ACLPacket{L4: common.L4UDP, DstPort: 40001}
+			So(q.Classify(p), ShouldEqual, 1)
+			p.DstPort = 53
+			So(q.Classify(p), ShouldEqual, 2)
+			p.L4 = common.L4TCP
+			So(q.Classify(p), ShouldEqual, q.DefaultClass())
+		})
+		Convey("describes the classes", func() {
+			So(q.NumClasses(), ShouldEqual, 4)
+			So(q.Weights(), ShouldResemble, []int{0, 1, 4, 2})
+			So(q.ClassName(ClassControl), ShouldEqual, "control")
+			So(q.ClassName(2), ShouldEqual, "interactive")
+			So(q.ClassName(q.DefaultClass()), ShouldEqual, "default")
+			So(q.DSCP(ClassControl), ShouldEqual, uint8(0))
+			So(q.DSCP(2), ShouldEqual, uint8(46))
+		})
+		Convey("polices the configured interfaces", func() {
+			now := time.Now()
+			So(q.Police(1, 65535, now), ShouldBeTrue)
+			So(q.Police(1, 1500, now), ShouldBeFalse)
+			So(q.Police(1, 1500, now.Add(2*time.Second)), ShouldBeTrue)
+			So(q.Police(2, 65535, now), ShouldBeTrue)
+			So(q.Police(2, 65535, now), ShouldBeTrue)
+		})
+		Convey("polices control traffic separately", func() {
+			now := time.Now()
+			So(q.Police(1, 65535, now), ShouldBeTrue)
+			So(q.PoliceControl(1, 3000, now), ShouldBeTrue)
+			So(q.PoliceControl(1, 1500, now), ShouldBeFalse)
+			So(q.PoliceControl(2, 3000, now), ShouldBeTrue)
+			So(q.PoliceControl(3, 65535, now), ShouldBeTrue)
+		})
+	})
+}
@@ -23,6 +23,83 @@ Profile = false
 # Action that should be taken when an error occurs during a context rollback.
 # (Fatal | Continue) (default Fatal)
 RollbackFailAction = "Fatal"
+
+# Range of end host ports that bypass the dispatcher, e.g., "40000-40999".
+# Packets from remote ASes to these ports are delivered directly to the port
+# instead of to the dispatcher. (default "", i.e., none)
+DirectPorts = ""
+
+# Path to the JSON file containing the packet filtering rules (ACL). The file
+# is reloaded on SIGHUP. (default "", i.e., no filtering)
+ACLFile = ""
+
+# Path to the JSON file containing the traffic scheduling and policing (QoS)
+# configuration. The file is reloaded on SIGHUP. (default "", i.e., packets are
+# sent in FIFO order and not policed)
+QoSFile = ""
+`
+
+const bfdSample = `
+# Enable BFD link liveness detection with the peer router of each external
+# interface. The peer routers must enable BFD as well. If the session of an
+# interface goes down, the interface is considered revoked until the session is
+# up again. The revocations are signed with the AS signing key in the keys
+# directory, no revocations are issued without it. (default false)
+Enable = false
+
+# Detection time multiplier. A session goes down if no control packet is
+# received within DetectMult times the negotiated receive interval.
+# (default 3)
+DetectMult = 3
+
+# Minimum interval between control packets sent to the peer. (default 200ms)
+DesiredMinTxInterval = "200ms"
+
+# Minimum interval between control packets received from the peer.
+# (default 200ms)
+RequiredMinRxInterval = "200ms"
+`
+
+const ioSample = `
+# Type of the sockets, unless overridden by LocalSock or ExternalSocks.
+# posix uses the socket API of the kernel. xdp uses AF_XDP sockets, which
+# bypass the network stack of the kernel. Only one socket per network device
+# can use xdp; configurations with further xdp sockets on a network device are
+# rejected. Packets to destinations that are not on-link are still sent
+# through the kernel.
+# (posix | xdp) (default posix)
+Sock = "posix"
+
+# Type of the local socket. (default "", i.e., Sock)
+LocalSock = ""
+
+# Type of the sockets of the external interfaces, by interface ID, e.g.,
+# { "1" = "xdp" }. (default {}, i.e., Sock)
+ExternalSocks = {}
+
+# Enable UDP generic segmentation and receive offload (GSO/GRO) on the posix
+# sockets, if supported by the kernel. (default false)
+UDPOffload = false
+`
+
+const xdpSample = `
+# Receive queue of the network devices the AF_XDP sockets are bound to. Packets
+# received on other queues are processed through the kernel. (default 0)
+Queue = 0
+
+# Size of the frames the packets are stored in. Larger packets are sent through
+# the kernel. (2048 | 4096) (default 2048)
+FrameSize = 2048
+
+# Number of frames of each socket, half of which are used for receiving. Must
+# be a power of two. (default 4096)
+NumFrames = 4096
+
+# Force generic mode XDP, even if the driver supports XDP. (default false)
+Generic = false
+
+# Force copy mode, even if the driver supports zero-copy mode. (default false)
+Copy = false
 `
 
 const discoverySample = `
@@ -20,6 +20,9 @@ import (
 
 type SockType string
 
+// DefaultSockType is the type of the POSIX sockets.
+const DefaultSockType SockType = "posix"
+
 type SockConf struct {
 	Default       SockType
 	LocalType     SockType
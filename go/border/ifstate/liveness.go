@@ -0,0 +1,125 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file handles the link state detected locally by the router, e.g., by
+// BFD. While the beacon service needs several seconds to revoke an interface,
+// the router itself marks the link down immediately. Packets that would be
+// routed over such a link are answered with an SCMP revocation containing a
+// revocation issued by the router. The revocations are signed with the AS
+// signing key from the config directory of the router. Without a signing key,
+// the router does not issue revocations, and packets are routed over the link
+// until the beacon service revokes the interface.
+
+package ifstate
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/util"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// linkDown holds the interfaces whose link is down, keyed by interface ID.
+var linkDown sync.Map
+
+type downLink struct {
+	ia       addr.IA
+	ifID     common.IFIDType
+	linkType proto.LinkType
+	signer   infra.Signer
+	// rev holds the current *localRev.
+	rev atomic.Value
+}
+
+// localRev is a revocation issued by the router.
+type localRev struct {
+	raw common.RawBytes
+	// renew is the time after which a new revocation is issued.
+	renew time.Time
+}
+
+// SetLinkDown marks the link of the interface down. The local revocations are
+// signed with signer. If signer is nil, no local revocations are issued.
+func SetLinkDown(ia addr.IA, ifID common.IFIDType, linkType proto.LinkType,
+	signer infra.Signer) {
+
+	l := &downLink{ia: ia, ifID: ifID, linkType: linkType, signer: signer}
+	if signer == nil {
+		log.Warn("IFState: no signer, not issuing local revocation", "ifid", ifID)
+	} else if err := l.renew(time.Now()); err != nil {
+		log.Error("IFState: unable to create local revocation", "ifid", ifID, "err", err)
+	}
+	if _, loaded := linkDown.LoadOrStore(ifID, l); !loaded {
+		log.Info("IFState: link down", "ifid", ifID)
+	}
+}
+
+// SetLinkUp marks the link of the interface up.
+func SetLinkUp(ifID common.IFIDType) {
+	if _, ok := linkDown.Load(ifID); ok {
+		linkDown.Delete(ifID)
+		log.Info("IFState: link up", "ifid", ifID)
+	}
+}
+
+// LinkRevocation returns the packed local revocation of the interface if its
+// link is down and the router has a signer, and nil otherwise. The revocation
+// is renewed once half of its TTL has passed.
+func LinkRevocation(ifID common.IFIDType) common.RawBytes {
+	val, ok := linkDown.Load(ifID)
+	if !ok {
+		return nil
+	}
+	l := val.(*downLink)
+	if l.signer == nil {
+		return nil
+	}
+	now := time.Now()
+	rev, _ := l.rev.Load().(*localRev)
+	if rev == nil || now.After(rev.renew) {
+		if err := l.renew(now); err != nil {
+			log.Error("IFState: unable to renew local revocation", "ifid", ifID, "err", err)
+			return nil
+		}
+		rev = l.rev.Load().(*localRev)
+	}
+	return rev.raw
+}
+
+func (l *downLink) renew(now time.Time) error {
+	revInfo := &path_mgmt.RevInfo{
+		IfID:         l.ifID,
+		RawIsdas:     l.ia.IAInt(),
+		LinkType:     l.linkType,
+		RawTimestamp: util.TimeToSecs(now),
+		RawTTL:       uint32(path_mgmt.MinRevTTL.Seconds()),
+	}
+	sRevInfo, err := path_mgmt.NewSignedRevInfo(revInfo, l.signer)
+	if err != nil {
+		return err
+	}
+	raw, err := proto.PackRoot(sRevInfo)
+	if err != nil {
+		return err
+	}
+	l.rev.Store(&localRev{raw: raw, renew: now.Add(path_mgmt.MinRevTTL / 2)})
+	return nil
+}
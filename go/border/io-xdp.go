@@ -0,0 +1,255 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file handles IO using AF_XDP sockets.
+
+package main
+
+import (
+	"net"
+	"time"
+
+	"github.com/scionproto/scion/go/border/metrics"
+	"github.com/scionproto/scion/go/border/rctx"
+	"github.com/scionproto/scion/go/border/rpkt"
+	"github.com/scionproto/scion/go/border/xsk"
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/overlay/conn"
+	"github.com/scionproto/scion/go/lib/ringbuf"
+)
+
+// xdpPollTimeout is the maximum time xdpInput waits for packets before
+// checking whether it has to stop.
+const xdpPollTimeout = 100 * time.Millisecond
+
+func (r *Router) xdpInput(s *rctx.Sock, stop, stopped chan struct{}) {
+	c, ok := s.Conn.(*xdpConn)
+	if !ok {
+		// The socket fell back to POSIX (see newXDPConn).
+		r.posixInput(s, stop, stopped)
+		return
+	}
+	defer log.LogPanicAndExit()
+	defer close(stopped)
+	dst := c.LocalAddr()
+	log.Info("xdpInput starting", "addr", dst)
+	defer log.Info("xdpInput stopping", "addr", dst)
+	// The packets the XDP program passes to the kernel are read from the
+	// POSIX socket.
+	posixStopped := make(chan struct{})
+	go r.posixInput(s, stop, posixStopped)
+	defer func() { <-posixStopped }()
+	pkts := make(ringbuf.EntryList, 0, inputBufCnt)
+	frames := make([][]byte, inputBatchCnt)
+	var d xsk.Datagram
+	var sock = s.Labels["sock"]
+
+	// Pre-calculate metrics
+	inputPkts := metrics.InputPkts.With(s.Labels)
+	inputBytes := metrics.InputBytes.With(s.Labels)
+	inputPktSize := metrics.InputPktSize.With(s.Labels)
+	inputReads := metrics.InputReads.With(s.Labels)
+	inputReadErrs := metrics.InputReadErrors.With(s.Labels)
+	procPktTime := metrics.ProcessPktTime.With(s.Labels)
+	droppedPkts := metrics.XDPDroppedPkts.With(s.Labels)
+
+	// Called when the packet's reference count hits 0.
+	free := func(rp *rpkt.RtrPkt) {
+		procPktTime.Add(time.Since(rp.TimeIn).Seconds())
+		rp.Reset()
+		r.freePkts.Write(ringbuf.EntryList{rp}, true)
+	}
+
+Top:
+	for {
+		select {
+		case <-stop:
+			break Top
+		default:
+		}
+		var ok bool
+		if pkts, ok = r.refillInputPkts(pkts); !ok {
+			break
+		}
+		toRead := min(len(pkts), inputBatchCnt)
+		n, err := c.sock.Receive(frames[:toRead], xdpPollTimeout)
+		if err != nil {
+			inputReadErrs.Inc()
+			log.Error("Error reading from AF_XDP socket", "socket", dst, "err", err)
+			continue
+		}
+		if n == 0 {
+			continue
+		}
+		inputReads.Inc()
+		// Grab current router context to attach to this batch of packets.
+		ctx := rctx.Get()
+		now := time.Now()
+		var pktsRead int
+		// Loop over all received frames, and copy the valid packets.
+		for _, frame := range frames[:n] {
+			rp := pkts[pktsRead].(*rpkt.RtrPkt)
+			if d.Parse(frame) != nil || !c.accept(&d) || len(d.Payload) > cap(rp.Raw) {
+				droppedPkts.Inc()
+				continue
+			}
+			rp.Raw = rp.Raw[:copy(rp.Raw[:cap(rp.Raw)], d.Payload)]
+			rp.Ctx = ctx
+			rp.DirFrom = s.Dir
+			rp.Free = free // Set free callback.
+			rp.TimeIn = now
+			rp.Ingress.Dst = dst
+			rp.Ingress.Src = c.srcAddr(&d)
+			rp.Ingress.IfID = s.Ifid
+			rp.Ingress.Sock = sock
+			inputBytes.Add(float64(len(rp.Raw)))
+			inputPktSize.Observe(float64(len(rp.Raw)))
+			pktsRead++
+		}
+		inputPkts.Add(float64(pktsRead))
+		for written := 0; written < pktsRead; {
+			wn, _ := s.Ring.Write(pkts[written:pktsRead], true)
+			written += wn
+		}
+		// Move unused pkts to the start.
+		copied := copy(pkts, pkts[pktsRead:])
+		pkts = pkts[:copied]
+	}
+	// Return any unused buffers.
+	r.freePkts.Write(pkts, true)
+}
+
+// srcAddr returns the overlay source address of the datagram.
+func (c *xdpConn) srcAddr(d *xsk.Datagram) *overlay.OverlayAddr {
+	if remote := c.RemoteAddr(); remote != nil {
+		return remote
+	}
+	// Make a copy, as the frame will be overwritten.
+	ip := append(net.IP(nil), d.SrcIP...)
+	src, _ := overlay.NewOverlayAddr(addr.HostFromIP(ip), addr.NewL4UDPInfo(uint16(d.SrcPort)))
+	return src
+}
+
+// xdpOutput writes packets to the AF_XDP socket. Packets to destinations
+// without a neighbor entry, e.g., destinations behind a router, and packets
+// that do not fit into a frame are written to the POSIX socket instead.
+func (r *Router) xdpOutput(s *rctx.Sock, stop, stopped chan struct{}) {
+	c, ok := s.Conn.(*xdpConn)
+	if !ok {
+		// The socket fell back to POSIX (see newXDPConn).
+		r.posixOutput(s, stop, stopped)
+		return
+	}
+	defer log.LogPanicAndExit()
+	defer close(stopped)
+	src := c.LocalAddr()
+	remote := c.RemoteAddr()
+	log.Info("xdpOutput starting", "addr", src)
+	defer log.Info("xdpOutput stopping", "addr", src)
+	epkts := make(ringbuf.EntryList, 0, outputBufCnt)
+	msgs := conn.NewWriteMessages(outputBatchCnt)
+	sched := newOutputScheduler(s)
+	d := xsk.Datagram{
+		SrcMAC:  c.mac,
+		SrcIP:   src.L3().IP(),
+		SrcPort: int(src.L4().Port()),
+	}
+
+	// Pre-calculate metrics
+	outputPkts := metrics.OutputPkts.With(s.Labels)
+	outputBytes := metrics.OutputBytes.With(s.Labels)
+	outputPktSize := metrics.OutputPktSize.With(s.Labels)
+	outputWrites := metrics.OutputWrites.With(s.Labels)
+	outputWriteErrs := metrics.OutputWriteErrors.With(s.Labels)
+	outputWriteLatency := metrics.OutputWriteLatency.With(s.Labels)
+	slowPathPkts := metrics.XDPSlowPathPkts.With(s.Labels)
+
+	// This loop is exited when the ring is closed and fully drained. Packets
+	// that cannot be sent are dropped.
+	for {
+		var ok bool
+		if epkts, ok = r.posixPrepOutput(epkts, msgs, s.Ring, sched, remote != nil); !ok {
+			break
+		}
+		toWrite := min(len(epkts), outputBatchCnt)
+		start := time.Now()
+		var bytes, pktsWritten int
+		for i := 0; i < toWrite; i++ {
+			rp := epkts[i].(*rpkt.EgressRtrPkt).Rp
+			if remote != nil {
+				d.DstIP, d.DstPort = remote.L3().IP(), int(remote.L4().Port())
+			} else {
+				uaddr := msgs[i].Addr.(*net.UDPAddr)
+				d.DstIP, d.DstPort = uaddr.IP, uaddr.Port
+			}
+			d.TOS = sched.tos(rp)
+			d.Payload = rp.Raw
+			if !c.queue(&d) {
+				// Write to the POSIX socket, which also resolves the
+				// link-layer address of the destination.
+				slowPathPkts.Inc()
+				if _, err := c.Conn.WriteBatch(msgs[i : i+1]); err != nil {
+					outputWriteErrs.Inc()
+					rp.Error("Unable to write packet", "err", err)
+					rp.Release()
+					epkts[i] = nil
+					continue
+				}
+			}
+			bytes += len(rp.Raw)
+			pktsWritten++
+			outputPktSize.Observe(float64(len(rp.Raw)))
+			rp.Release()   // Release inner RtrPkt entry
+			epkts[i] = nil // Clear EgressRtrPkt reference
+		}
+		if err := c.sock.Flush(); err != nil {
+			outputWriteErrs.Inc()
+			log.Error("Error sending packet(s)", "src", src, "err", err)
+		}
+		outputWriteLatency.Add(time.Since(start).Seconds())
+		outputPkts.Add(float64(pktsWritten))
+		outputBytes.Add(float64(bytes))
+		outputWrites.Inc()
+		epkts = shiftUnwrittenPkts(epkts, toWrite)
+	}
+	// Release any remaining unsent pkts.
+	releasePkts(epkts)
+}
+
+// queue queues the datagram on the AF_XDP socket. It returns false, if the
+// link-layer address of the destination is unknown, or if the datagram does
+// not fit into a frame.
+func (c *xdpConn) queue(d *xsk.Datagram) bool {
+	mac, ok := c.neighbors.Lookup(d.DstIP)
+	if !ok {
+		return false
+	}
+	frame := c.sock.TxFrame()
+	if frame == nil {
+		// All frames are in flight, have the kernel complete some of them.
+		c.sock.Flush()
+		if frame = c.sock.TxFrame(); frame == nil {
+			return false
+		}
+	}
+	d.DstMAC = mac
+	n, err := d.Write(frame)
+	if err != nil {
+		return false
+	}
+	c.sock.Queue(n)
+	return true
+}
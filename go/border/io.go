@@ -149,6 +149,24 @@ Top:
 func (r *Router) posixPrepInput(pkts ringbuf.EntryList,
 	msgs []ipv4.Message) (ringbuf.EntryList, bool) {
 
+	var ok bool
+	if pkts, ok = r.refillInputPkts(pkts); !ok {
+		return pkts, false
+	}
+	// setup msg references
+	for i := range pkts {
+		if i == inputBatchCnt {
+			break
+		}
+		rp := pkts[i].(*rpkt.RtrPkt)
+		msgs[i].Buffers[0] = rp.Raw
+	}
+	return pkts, true
+}
+
+// refillInputPkts refills pkts if it's below inputLowBufCnt. The second
+// return value is false, if the free packets ring is closed.
+func (r *Router) refillInputPkts(pkts ringbuf.EntryList) (ringbuf.EntryList, bool) {
 	if len(pkts) < inputLowBufCnt {
 		before := len(pkts)
 		pkts = pkts[:cap(pkts)]
@@ -160,14 +178,6 @@ func (r *Router) posixPrepInput(pkts ringbuf.EntryList,
 		}
 		pkts = pkts[:before+n]
 	}
-	// setup msg references
-	for i := range pkts {
-		if i == inputBatchCnt {
-			break
-		}
-		rp := pkts[i].(*rpkt.RtrPkt)
-		msgs[i].Buffers[0] = rp.Raw
-	}
 	return pkts, true
 }
 
@@ -200,6 +210,7 @@ func (r *Router) posixOutput(s *rctx.Sock, _, stopped chan struct{}) {
 	defer log.Info("posixOutput stopping", "addr", src)
 	epkts := make(ringbuf.EntryList, 0, outputBufCnt)
 	msgs := conn.NewWriteMessages(outputBatchCnt)
+	sched := newOutputScheduler(s)
 
 	// Pre-calculate metrics
 	outputPkts := metrics.OutputPkts.With(s.Labels)
@@ -216,7 +227,7 @@ func (r *Router) posixOutput(s *rctx.Sock, _, stopped chan struct{}) {
 		var bytes int // Needs to be declared before goto
 		var t float64 // Needs to be declared before goto
 		var ok bool
-		if epkts, ok = r.posixPrepOutput(epkts, msgs, s.Ring, dst != nil); !ok {
+		if epkts, ok = r.posixPrepOutput(epkts, msgs, s.Ring, sched, dst != nil); !ok {
 			ringClosed = true
 			break
 		}
@@ -272,7 +283,7 @@ func (r *Router) posixOutput(s *rctx.Sock, _, stopped chan struct{}) {
 	if !ringClosed {
 		for {
 			var ok bool
-			if epkts, ok = r.posixPrepOutput(epkts, msgs, s.Ring, dst != nil); !ok {
+			if epkts, ok = r.posixPrepOutput(epkts, msgs, s.Ring, sched, dst != nil); !ok {
 				break
 			}
 			releasePkts(epkts)
@@ -282,18 +293,27 @@ func (r *Router) posixOutput(s *rctx.Sock, _, stopped chan struct{}) {
 }
 
 // posixPrepOutput fetches new packets if epkts is empty, and sets the msgs
-// Buffers and Addr based on the corresponding entries in epkts. The second return
-// value is false, if the underlying ring is closed and drained.
+// Buffers, Addr and OOB based on the corresponding entries in epkts. If the
+// output is scheduled, new packets are fetched from the scheduler. The second
+// return value is false, if the underlying ring is closed and drained.
 func (r *Router) posixPrepOutput(epkts ringbuf.EntryList, msgs []ipv4.Message,
-	ring *ringbuf.Ring, connected bool) (ringbuf.EntryList, bool) {
+	ring *ringbuf.Ring, sched *outputScheduler, connected bool) (ringbuf.EntryList, bool) {
 
 	if len(epkts) == 0 {
-		epkts = epkts[:cap(epkts)]
-		n, _ := ring.Read(epkts, true)
-		if n < 0 {
-			return epkts[:0], false
+		sched.update()
+		if sched.enabled() {
+			var ok bool
+			if epkts, ok = sched.fetch(epkts, ring); !ok {
+				return epkts, false
+			}
+		} else {
+			epkts = epkts[:cap(epkts)]
+			n, _ := ring.Read(epkts, true)
+			if n < 0 {
+				return epkts[:0], false
+			}
+			epkts = epkts[:n]
 		}
-		epkts = epkts[:n]
 	}
 	// setup msgs
 	for i := range epkts {
@@ -303,6 +323,7 @@ func (r *Router) posixPrepOutput(epkts ringbuf.EntryList, msgs []ipv4.Message,
 		erp := epkts[i].(*rpkt.EgressRtrPkt)
 		rp := erp.Rp
 		msgs[i].Buffers[0] = rp.Raw
+		msgs[i].OOB = sched.msgOOB(rp)
 		if !connected {
 			// Unconnected socket, use supplied address
 			uaddr := msgs[i].Addr.(*net.UDPAddr)
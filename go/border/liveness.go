@@ -0,0 +1,125 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file manages the BFD sessions with the peer routers of the external
+// interfaces. BFD control packets are exchanged over the interface overlay
+// sockets, alongside the SCION packets.
+
+package main
+
+import (
+	"sync"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scionproto/scion/go/border/bfd"
+	"github.com/scionproto/scion/go/border/ifstate"
+	"github.com/scionproto/scion/go/border/metrics"
+	"github.com/scionproto/scion/go/border/rctx"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/log"
+)
+
+// bfdSessions holds the BFD sessions of the external interfaces.
+type bfdSessions struct {
+	mtx      sync.Mutex
+	sessions map[common.IFIDType]*bfdSession
+}
+
+type bfdSession struct {
+	*bfd.Session
+	stop chan struct{}
+}
+
+// update starts a session for each new external interface of the context, and
+// stops the sessions of removed interfaces.
+func (s *bfdSessions) update(ctx *rctx.Ctx) {
+	if !cfg.BR.BFD.Enable {
+		return
+	}
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.sessions == nil {
+		s.sessions = make(map[common.IFIDType]*bfdSession)
+	}
+	for ifid, sess := range s.sessions {
+		if _, ok := ctx.ExtSockOut[ifid]; !ok {
+			close(sess.stop)
+			delete(s.sessions, ifid)
+			ifstate.SetLinkUp(ifid)
+		}
+	}
+	for ifid := range ctx.ExtSockOut {
+		if _, ok := s.sessions[ifid]; ok {
+			continue
+		}
+		sess := &bfdSession{
+			Session: bfd.NewSession(cfg.BR.BFD.SessionConfig(), bfdSender(ifid),
+				bfdStateHandler(ifid), log.New("bfd", ifid)),
+			stop: make(chan struct{}),
+		}
+		s.sessions[ifid] = sess
+		go func() {
+			defer log.LogPanicAndExit()
+			sess.Run(sess.stop)
+		}()
+	}
+}
+
+// receive hands a BFD control packet received on the interface to its
+// session.
+func (s *bfdSessions) receive(ifid common.IFIDType, raw common.RawBytes) error {
+	s.mtx.Lock()
+	sess, ok := s.sessions[ifid]
+	s.mtx.Unlock()
+	if !ok {
+		return common.NewBasicError("No BFD session for interface", nil, "ifid", ifid)
+	}
+	return sess.Receive(raw)
+}
+
+// bfdSender returns a sender writing control packets directly to the overlay
+// socket of the interface in the current context.
+func bfdSender(ifid common.IFIDType) bfd.Sender {
+	return func(raw common.RawBytes) error {
+		s, ok := rctx.Get().ExtSockOut[ifid]
+		if !ok {
+			return common.NewBasicError("No socket for interface", nil, "ifid", ifid)
+		}
+		_, err := s.Conn.Write(raw)
+		return err
+	}
+}
+
+// bfdStateHandler returns a handler that marks the link of the interface down
+// when its session goes down, and up again when the session is back up.
+func bfdStateHandler(ifid common.IFIDType) bfd.StateChangeHandler {
+	labels := extLabels(ifid)
+	up := metrics.BFDUp.With(labels)
+	up.Set(0)
+	return func(old, new bfd.State) {
+		metrics.BFDStateChanges.With(
+			prometheus.Labels{"sock": labels["sock"], "state": new.String()}).Inc()
+		switch {
+		case new == bfd.StateUp:
+			up.Set(1)
+			ifstate.SetLinkUp(ifid)
+		case old == bfd.StateUp:
+			up.Set(0)
+			conf := rctx.Get().Conf
+			ifstate.SetLinkDown(conf.IA, ifid, conf.Topo.IFInfoMap[ifid].LinkType,
+				conf.Signer)
+		}
+	}
+}
@@ -50,6 +50,19 @@ var (
 	// Processing metrics
 	ProcessPktTime    *prometheus.CounterVec
 	ProcessSockSrcDst *prometheus.CounterVec
+	ACLPkts           *prometheus.CounterVec
+
+	// Traffic scheduling and policing metrics
+	QueueDrops  *prometheus.CounterVec
+	PolicedPkts *prometheus.CounterVec
+
+	// AF_XDP socket metrics
+	XDPSlowPathPkts *prometheus.CounterVec
+	XDPDroppedPkts  *prometheus.CounterVec
+
+	// Link liveness (BFD) metrics
+	BFDUp           *prometheus.GaugeVec
+	BFDStateChanges *prometheus.CounterVec
 
 	// Misc
 	IFState *prometheus.GaugeVec
@@ -108,6 +121,19 @@ func Init(elem string) {
 		"Total processing time for input packets, in seconds.", sockLabels)
 	ProcessSockSrcDst = newCVec("process_pkts_src_dst_total",
 		"Total number of packets from one sock to another.", []string{"inSock", "outSock"})
+	ACLPkts = newCVec("acl_pkts_total",
+		"Total number of packets matching an ACL rule.", []string{"sock", "rule", "action"})
+
+	QueueDrops = newCVec("queue_drops_total",
+		"Total number of packets dropped because the output queue was full.",
+		[]string{"sock", "queue"})
+	PolicedPkts = newCVec("policed_pkts_total",
+		"Total number of received packets dropped by the policer.", sockLabels)
+
+	XDPSlowPathPkts = newCVec("xdp_slow_path_pkts_total",
+		"Total number of packets of AF_XDP sockets sent through the kernel.", sockLabels)
+	XDPDroppedPkts = newCVec("xdp_dropped_pkts_total",
+		"Total number of invalid packets dropped by AF_XDP sockets.", sockLabels)
 
 	// border_base_labels is a special metric that always has the value `1`,
 	// that is used to add labels to non-br metrics.
@@ -115,6 +141,10 @@ func Init(elem string) {
 	BRLabels.Set(1)
 	IFState = newGVec("interface_active", "Interface is active.", sockLabels)
 
+	BFDUp = newGVec("bfd_up", "Link liveness of the interface as detected by BFD.", sockLabels)
+	BFDStateChanges = newCVec("bfd_state_changes_total",
+		"Total number of BFD session state changes.", []string{"sock", "state"})
+
 	// Initialize ringbuf metrics.
 	ringbuf.InitMetrics("border", []string{"ringId"})
 }
@@ -0,0 +1,69 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package qos
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/ringbuf"
+)
+
+// entry is a test entry identifying the queue it was enqueued in.
+type entry struct {
+	queue int
+}
+
+func TestScheduler(t *testing.T) {
+	Convey("Scheduler", t, func() {
+		s := NewScheduler([]int{0, 3, 1}, 100)
+		Convey("serves the priority queue first", func() {
+			So(s.Enqueue(&entry{1}, 1, 100), ShouldBeTrue)
+			So(s.Enqueue(&entry{0}, 0, 100), ShouldBeTrue)
+			So(s.Len(), ShouldEqual, 2)
+			entries := s.Dequeue(nil, 1)
+			So(entries, ShouldResemble, ringbuf.EntryList{&entry{0}})
+			So(s.Len(), ShouldEqual, 1)
+		})
+		Convey("shares the capacity according to the weights", func() {
+			for i := 0; i < 100; i++ {
+				s.Enqueue(&entry{1}, 1, 1000)
+				s.Enqueue(&entry{2}, 2, 1000)
+			}
+			counts := make([]int, 3)
+			for _, e := range s.Dequeue(nil, 80) {
+				counts[e.(*entry).queue]++
+			}
+			So(counts[1], ShouldBeBetweenOrEqual, 58, 62)
+			So(counts[2], ShouldBeBetweenOrEqual, 18, 22)
+		})
+		Convey("serves a single queue at full capacity", func() {
+			for i := 0; i < 10; i++ {
+				s.Enqueue(&entry{2}, 2, 1500)
+			}
+			So(len(s.Dequeue(nil, 20)), ShouldEqual, 10)
+			So(s.Len(), ShouldEqual, 0)
+		})
+		Convey("rejects entries if the queue is full", func() {
+			for i := 0; i < 100; i++ {
+				So(s.Enqueue(&entry{1}, 1, 100), ShouldBeTrue)
+			}
+			So(s.Enqueue(&entry{1}, 1, 100), ShouldBeFalse)
+			So(s.Enqueue(&entry{2}, 2, 100), ShouldBeTrue)
+			So(s.Len(), ShouldEqual, 101)
+		})
+	})
+}
@@ -0,0 +1,159 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package qos provides the building blocks for the traffic scheduling and
+// policing of the border router.
+package qos
+
+import (
+	"github.com/scionproto/scion/go/lib/ringbuf"
+)
+
+// Quantum is the number of bytes a queue of weight 1 may send per round.
+const Quantum = 1500
+
+// Scheduler schedules packets from multiple bounded FIFO queues. Queue 0 has
+// strict priority over all other queues. The other queues share the remaining
+// capacity according to their weights, using deficit round robin. Scheduler
+// is not safe for concurrent use.
+type Scheduler struct {
+	queues  []queue
+	quantum []int
+	deficit []int
+	// curr is the queue currently served by deficit round robin.
+	curr int
+	// credited indicates whether curr has received its quantum in the
+	// current round.
+	credited bool
+	len      int
+}
+
+// NewScheduler creates a scheduler with one queue per weight. The weight of
+// queue 0 is ignored, as it has strict priority. Each queue holds up to
+// queueLen packets.
+func NewScheduler(weights []int, queueLen int) *Scheduler {
+	s := &Scheduler{
+		queues:  make([]queue, len(weights)),
+		quantum: make([]int, len(weights)),
+		deficit: make([]int, len(weights)),
+		curr:    1,
+	}
+	for i, w := range weights {
+		s.queues[i] = newQueue(queueLen)
+		s.quantum[i] = w * Quantum
+	}
+	return s
+}
+
+// Len returns the number of queued packets.
+func (s *Scheduler) Len() int {
+	return s.len
+}
+
+// Enqueue appends the entry of the given size in bytes to queue q. It returns
+// false if the queue is full, in which case the entry is not queued.
+func (s *Scheduler) Enqueue(e ringbuf.Entry, q, size int) bool {
+	if !s.queues[q].push(e, size) {
+		return false
+	}
+	s.len++
+	return true
+}
+
+// Dequeue appends up to n scheduled entries to entries.
+func (s *Scheduler) Dequeue(entries ringbuf.EntryList, n int) ringbuf.EntryList {
+	for ; n > 0 && s.len > 0; n-- {
+		entries = append(entries, s.next())
+	}
+	return entries
+}
+
+// next returns the next scheduled entry. There must be at least one queued
+// entry.
+func (s *Scheduler) next() ringbuf.Entry {
+	s.len--
+	if s.queues[0].len > 0 {
+		e, _ := s.queues[0].pop()
+		return e
+	}
+	for {
+		q := &s.queues[s.curr]
+		if q.len == 0 {
+			s.deficit[s.curr] = 0
+			s.advance()
+			continue
+		}
+		if !s.credited {
+			s.deficit[s.curr] += s.quantum[s.curr]
+			s.credited = true
+		}
+		if q.headSize() > s.deficit[s.curr] {
+			s.advance()
+			continue
+		}
+		e, size := q.pop()
+		s.deficit[s.curr] -= size
+		if q.len == 0 {
+			s.deficit[s.curr] = 0
+			s.advance()
+		}
+		return e
+	}
+}
+
+// advance moves deficit round robin to the next queue.
+func (s *Scheduler) advance() {
+	s.credited = false
+	s.curr++
+	if s.curr >= len(s.queues) {
+		s.curr = 1
+	}
+}
+
+// queue is a bounded FIFO queue of entries and their sizes.
+type queue struct {
+	entries ringbuf.EntryList
+	sizes   []int
+	head    int
+	len     int
+}
+
+func newQueue(capacity int) queue {
+	return queue{
+		entries: make(ringbuf.EntryList, capacity),
+		sizes:   make([]int, capacity),
+	}
+}
+
+func (q *queue) push(e ringbuf.Entry, size int) bool {
+	if q.len == len(q.entries) {
+		return false
+	}
+	idx := (q.head + q.len) % len(q.entries)
+	q.entries[idx], q.sizes[idx] = e, size
+	q.len++
+	return true
+}
+
+func (q *queue) pop() (ringbuf.Entry, int) {
+	e, size := q.entries[q.head], q.sizes[q.head]
+	q.entries[q.head] = nil
+	q.head = (q.head + 1) % len(q.entries)
+	q.len--
+	return e, size
+}
+
+func (q *queue) headSize() int {
+	return q.sizes[q.head]
+}
@@ -20,6 +20,7 @@ package main
 import (
 	"sync"
 
+	"github.com/scionproto/scion/go/border/bfd"
 	"github.com/scionproto/scion/go/border/brconf"
 	"github.com/scionproto/scion/go/border/metrics"
 	"github.com/scionproto/scion/go/border/rcmn"
@@ -52,6 +53,8 @@ type Router struct {
 	// static topology from the discovery service, or from dropping an expired
 	// dynamic topology.
 	setCtxMtx sync.Mutex
+	// bfd holds the BFD sessions of the external interfaces.
+	bfd bfdSessions
 }
 
 func NewRouter(id, confDir string) (*Router, error) {
@@ -132,6 +135,12 @@ func (r *Router) processPacket(rp *rpkt.RtrPkt) {
 	rp.Logger = log.New("rpkt", rp.Id)
 	// XXX(kormat): uncomment for debugging:
 	//rp.Debug("processPacket", "raw", rp.Raw)
+	if rp.DirFrom == rcmn.DirExternal && bfd.IsControl(rp.Raw) {
+		if err := r.bfd.receive(rp.Ingress.IfID, rp.Raw); err != nil {
+			rp.Debug("Dropping BFD control packet", "err", err)
+		}
+		return
+	}
 	if err := rp.Parse(); err != nil {
 		r.handlePktError(rp, err, "Error parsing packet")
 		return
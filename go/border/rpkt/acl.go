@@ -0,0 +1,137 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file handles the packet filtering rules (ACL) of the router config.
+
+package rpkt
+
+import (
+	"sync/atomic"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scionproto/scion/go/border/brconf"
+	"github.com/scionproto/scion/go/border/metrics"
+	"github.com/scionproto/scion/go/border/rctx"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/l4"
+)
+
+// filter evaluates the ACL of the router config against the packet, and
+// polices and classifies it according to the QoS config. It returns false if
+// the packet must be dropped.
+func (rp *RtrPkt) filter() bool {
+	if rp.Ctx == nil || rp.Ctx.Conf == nil {
+		return true
+	}
+	conf := rp.Ctx.Conf
+	if conf.ACL.Empty() && conf.QoS == nil {
+		return true
+	}
+	p := rp.aclPacket()
+	var counters *aclCounters
+	if !conf.ACL.Empty() {
+		counters = getACLCounters(rp.Ctx)
+	}
+	drop := conf.ACL.Evaluate(p, func(r *brconf.ACLRule) {
+		counters.inc(r, rp.Ingress.Sock)
+	})
+	if drop {
+		rp.Debug("Packet dropped by ACL", "ifid", p.IfID, "src", p.SrcIA, "dst", p.DstIA)
+		return false
+	}
+	return rp.police(conf.QoS, p)
+}
+
+// aclPacket extracts the fields ACL rules match on from the packet. Fields
+// that cannot be parsed are left unset, such that rules depending on them do
+// not match.
+func (rp *RtrPkt) aclPacket() *brconf.ACLPacket {
+	p := &brconf.ACLPacket{IfID: rp.Ingress.IfID}
+	p.SrcIA, _ = rp.SrcIA()
+	p.DstIA, _ = rp.DstIA()
+	p.SrcHost, _ = rp.SrcHost()
+	p.DstHost, _ = rp.DstHost()
+	// L4Type is set as soon as the L4 header is found, even if the router
+	// does not support parsing it.
+	l4h, _ := rp.L4Hdr(false)
+	p.L4 = rp.L4Type
+	if udp, ok := l4h.(*l4.UDP); ok {
+		p.SrcPort, p.DstPort = udp.SrcPort, udp.DstPort
+	}
+	p.Extns = make([]common.ExtnType, 0, len(rp.idxs.hbhExt)+len(rp.idxs.e2eExt))
+	for _, e := range rp.idxs.hbhExt {
+		p.Extns = append(p.Extns, e.Type)
+	}
+	for _, e := range rp.idxs.e2eExt {
+		p.Extns = append(p.Extns, e.Type)
+	}
+	return p
+}
+
+// aclCounters contains the packet counters of the ACL rules of a router
+// context, per rule and ingress socket. They are precomputed, such that
+// counting a packet does not allocate.
+type aclCounters struct {
+	ctx  *rctx.Ctx
+	pkts map[*brconf.ACLRule]map[string]prometheus.Counter
+}
+
+// aclCountersCache holds the *aclCounters of the most recent router context.
+var aclCountersCache atomic.Value
+
+// getACLCounters returns the ACL counters of ctx. They are recomputed when
+// the router context changes.
+func getACLCounters(ctx *rctx.Ctx) *aclCounters {
+	if c, _ := aclCountersCache.Load().(*aclCounters); c != nil && c.ctx == ctx {
+		return c
+	}
+	c := newACLCounters(ctx)
+	aclCountersCache.Store(c)
+	return c
+}
+
+func newACLCounters(ctx *rctx.Ctx) *aclCounters {
+	socks := make([]string, 0, len(ctx.ExtSockIn)+1)
+	if ctx.LocSockIn != nil {
+		socks = append(socks, ctx.LocSockIn.Labels["sock"])
+	}
+	for _, s := range ctx.ExtSockIn {
+		socks = append(socks, s.Labels["sock"])
+	}
+	c := &aclCounters{
+		ctx:  ctx,
+		pkts: make(map[*brconf.ACLRule]map[string]prometheus.Counter),
+	}
+	for _, r := range ctx.Conf.ACL.Rules {
+		c.pkts[r] = make(map[string]prometheus.Counter, len(socks))
+		for _, sock := range socks {
+			c.pkts[r][sock] = metrics.ACLPkts.With(prometheus.Labels{
+				"sock": sock, "rule": r.Name, "action": string(r.Action)})
+		}
+	}
+	return c
+}
+
+// inc counts a packet received on sock that matched r.
+func (c *aclCounters) inc(r *brconf.ACLRule, sock string) {
+	if counter, ok := c.pkts[r][sock]; ok {
+		counter.Inc()
+		return
+	}
+	// The socket is not part of the context, e.g., because the packet was
+	// received before a reload.
+	metrics.ACLPkts.With(prometheus.Labels{
+		"sock": sock, "rule": r.Name, "action": string(r.Action)}).Inc()
+}
@@ -100,34 +100,50 @@ func (rp *RtrPkt) validateLocalIF(ifid *common.IFIDType) error {
 	state, ok := ifstate.LoadState(*ifid)
 	if !ok || state.Active {
 		// Interface is not revoked
-		return nil
+		return rp.validateLink(*ifid)
 	}
 	// Interface is revoked.
 	sRevInfo := state.SRevInfo
 	if sRevInfo == nil {
 		rp.Warn("No SRevInfo for revoked interface", "ifid", *ifid)
-		return nil
+		return rp.validateLink(*ifid)
 	}
 	revInfo, err := sRevInfo.RevInfo()
 	if err != nil {
 		rp.Warn("Could not parse RevInfo for interface", "ifid", *ifid, "err", err)
-		return nil
+		return rp.validateLink(*ifid)
 	}
 	err = revInfo.Active()
 	if err != nil {
 		if !common.IsTimeoutErr(err) {
 			rp.Error("Error checking revocation", "err", err)
-			return nil
+			return rp.validateLink(*ifid)
 		}
 		// If the BR does not have a revocation for the current epoch, it considers
 		// the interface as active until it receives a new revocation.
 		newState := ifstate.NewInfo(*ifid, true, nil, nil)
 		ifstate.UpdateIfNew(*ifid, state, newState)
+		return rp.validateLink(*ifid)
+	}
+	return rp.revokedIF(*ifid, state.RawSRev)
+}
+
+// validateLink checks whether the link of the interface has been detected to
+// be down by the router. In that case, the router's local revocation is
+// returned in an SCMP revocation error.
+func (rp *RtrPkt) validateLink(ifid common.IFIDType) error {
+	rawSRev := ifstate.LinkRevocation(ifid)
+	if rawSRev == nil {
 		return nil
 	}
+	return rp.revokedIF(ifid, rawSRev)
+}
+
+// revokedIF creates the error for a packet routed over a revoked interface.
+func (rp *RtrPkt) revokedIF(ifid common.IFIDType, rawSRev common.RawBytes) error {
 	sinfo := scmp.NewInfoRevocation(
-		rp.CmnHdr.CurrInfoF, rp.CmnHdr.CurrHopF, *ifid,
-		rp.DirFrom == rcmn.DirExternal, state.RawSRev)
+		rp.CmnHdr.CurrInfoF, rp.CmnHdr.CurrHopF, ifid,
+		rp.DirFrom == rcmn.DirExternal, rawSRev)
 	return common.NewBasicError(
 		errIntfRevoked,
 		scmp.NewError(scmp.C_Path, scmp.T_P_RevokedIF, sinfo, nil),
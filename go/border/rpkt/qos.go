@@ -0,0 +1,69 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file handles the traffic classification and policing (QoS) of the
+// router config.
+
+package rpkt
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scionproto/scion/go/border/brconf"
+	"github.com/scionproto/scion/go/border/metrics"
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// police sets the traffic class of the packet, and polices it according to
+// its ingress interface. Control packets are policed by the control policers,
+// data packets by the data policers. It returns false if the packet must be
+// dropped.
+func (rp *RtrPkt) police(q *brconf.QoS, p *brconf.ACLPacket) bool {
+	if q == nil {
+		return true
+	}
+	police := q.Police
+	if isControl(p) {
+		rp.Class = brconf.ClassControl
+		police = q.PoliceControl
+	} else {
+		rp.Class = q.Classify(p)
+	}
+	if !police(p.IfID, len(rp.Raw), time.Now()) {
+		metrics.PolicedPkts.With(prometheus.Labels{"sock": rp.Ingress.Sock}).Inc()
+		rp.Debug("Packet dropped by policer", "ifid", p.IfID, "class", rp.Class)
+		return false
+	}
+	return true
+}
+
+// isControl returns whether the packet is control traffic, i.e., one-hop path
+// traffic such as beacons and IFID keepalives, or SCMP traffic such as
+// revocations. The control policers ensure that control traffic cannot starve
+// the data classes. Service address traffic is classified like data traffic.
+// BFD control packets are handled before classification, and are not
+// affected.
+func isControl(p *brconf.ACLPacket) bool {
+	if p.L4 == common.L4SCMP {
+		return true
+	}
+	for _, e := range p.Extns {
+		if e == common.ExtnOneHopPathType {
+			return true
+		}
+	}
+	return false
+}
@@ -26,6 +26,7 @@ import (
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/assert"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/l4"
 	"github.com/scionproto/scion/go/lib/overlay"
 	"github.com/scionproto/scion/go/lib/ringbuf"
 	"github.com/scionproto/scion/go/lib/scmp"
@@ -116,8 +117,8 @@ func (rp *RtrPkt) forwardFromExternal() (HookResult, error) {
 		rp.CmnHdr.HdrLenBytes()
 	if onLastSeg && rp.dstIA.Equal(rp.Ctx.Conf.IA) {
 		// Destination is a host in the local ISD-AS.
-		l4 := addr.NewL4UDPInfo(overlay.EndhostPort)
-		dst, err := overlay.NewOverlayAddr(rp.dstHost, l4)
+		l4i := addr.NewL4UDPInfo(rp.endhostPort())
+		dst, err := overlay.NewOverlayAddr(rp.dstHost, l4i)
 		if err != nil {
 			return HookError, err
 		}
@@ -145,6 +146,74 @@ func (rp *RtrPkt) forwardFromExternal() (HookResult, error) {
 	return HookContinue, nil
 }
 
+// endhostPort returns the overlay port of the local end host that the packet
+// is delivered to. Packets for ports in the direct port range bypass the
+// dispatcher and are delivered to the port itself, all other packets are
+// delivered to the dispatcher.
+func (rp *RtrPkt) endhostPort() uint16 {
+	ports := rp.Ctx.Conf.DirectPorts
+	if ports.IsEmpty() {
+		return overlay.EndhostPort
+	}
+	if port := rp.dstPort(); ports.Contains(port) {
+		return port
+	}
+	return overlay.EndhostPort
+}
+
+// dstPort returns the end host port of the application that the packet is
+// for, or 0 if it cannot be determined. For UDP, this is the destination
+// port. SCMP errors are for the application that sent the offending packet,
+// i.e., for the quoted UDP source port. SCMP General replies are for the port
+// encoded in the ID by overlay.DirectSCMPID.
+func (rp *RtrPkt) dstPort() uint16 {
+	l4h, err := rp.L4Hdr(false)
+	if err != nil || l4h == nil {
+		return 0
+	}
+	switch hdr := l4h.(type) {
+	case *l4.UDP:
+		return hdr.DstPort
+	case *scmp.Hdr:
+		pld, err := scmp.PldFromRaw(rp.Raw[rp.idxs.pld:],
+			scmp.ClassType{Class: hdr.Class, Type: hdr.Type})
+		if err != nil {
+			return 0
+		}
+		return scmpDstPort(hdr, pld)
+	}
+	return 0
+}
+
+func scmpDstPort(hdr *scmp.Hdr, pld *scmp.Payload) uint16 {
+	if hdr.Class == scmp.C_General {
+		// Requests are answered by the dispatcher.
+		switch info := pld.Info.(type) {
+		case *scmp.InfoEcho:
+			if hdr.Type == scmp.T_G_EchoReply {
+				return overlay.DirectSCMPPort(info.Id)
+			}
+		case *scmp.InfoTraceRoute:
+			if hdr.Type == scmp.T_G_TraceRouteReply {
+				return overlay.DirectSCMPPort(info.Id)
+			}
+		case *scmp.InfoRecordPath:
+			if hdr.Type == scmp.T_G_RecordPathReply {
+				return overlay.DirectSCMPPort(info.Id)
+			}
+		}
+		return 0
+	}
+	if pld.Meta.L4Proto != common.L4UDP {
+		return 0
+	}
+	quoted, err := l4.UDPFromRaw(pld.L4Hdr)
+	if err != nil {
+		return 0
+	}
+	return quoted.SrcPort
+}
+
 // xoverFromExternal handles XOVER hop fields at the ingress router, including
 // a lot of sanity/security checking.
 func (rp *RtrPkt) xoverFromExternal() error {
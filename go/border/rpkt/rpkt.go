@@ -117,6 +117,9 @@ type RtrPkt struct {
 	// SCMPError flags if the packet is an SCMP Error packet, in which case it should never trigger
 	// an error response packet. (PARSE, if SCMP extension header is present)
 	SCMPError bool
+	// Class is the traffic class of the packet, used for output scheduling. Packets created
+	// by the router are in the control class. (PARSE, only if QoS is configured)
+	Class int
 	// Logger is used to log messages associated with a packet. The Id field is automatically
 	// included in the output.
 	log.Logger
@@ -236,6 +239,7 @@ func (rp *RtrPkt) Reset() {
 	rp.pld = nil
 	rp.hooks = hooks{}
 	rp.SCMPError = false
+	rp.Class = 0
 	rp.Logger = nil
 	rp.Ctx = nil
 	rp.refCnt = 1
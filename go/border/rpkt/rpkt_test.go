@@ -29,6 +29,7 @@ import (
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/l4"
+	"github.com/scionproto/scion/go/lib/overlay"
 	"github.com/scionproto/scion/go/lib/spkt"
 )
 
@@ -100,3 +101,57 @@ func TestParse(t *testing.T) {
 		})
 	})
 }
+
+func TestEndhostPort(t *testing.T) {
+	Convey("Local delivery port", t, func() {
+		r := prepareRtrPacketSample()
+		r.Parse()
+		Convey("is the dispatcher port without direct ports", func() {
+			So(r.endhostPort(), ShouldEqual, overlay.EndhostPort)
+		})
+		Convey("is the UDP port if it is a direct port", func() {
+			r.Ctx.Conf.DirectPorts = overlay.PortRange{Min: 3000, Max: 3100}
+			So(r.endhostPort(), ShouldEqual, 3000)
+		})
+		Convey("is the dispatcher port for other ports", func() {
+			r.Ctx.Conf.DirectPorts = overlay.PortRange{Min: 4000, Max: 4100}
+			So(r.endhostPort(), ShouldEqual, overlay.EndhostPort)
+		})
+	})
+}
+
+func TestACLPacket(t *testing.T) {
+	Convey("ACL packet fields", t, func() {
+		r := prepareRtrPacketSample()
+		r.Parse()
+		p := r.aclPacket()
+		SoMsg("IfID", p.IfID, ShouldEqual, 5)
+		SoMsg("SrcIA", p.SrcIA, ShouldResemble, addr.IA{I: 1, A: 10})
+		SoMsg("DstIA", p.DstIA, ShouldResemble, addr.IA{I: 2, A: 25})
+		SoMsg("L4", p.L4, ShouldEqual, common.L4UDP)
+		SoMsg("SrcPort", p.SrcPort, ShouldEqual, 44887)
+		SoMsg("DstPort", p.DstPort, ShouldEqual, 3000)
+		SoMsg("Extns", p.Extns, ShouldBeEmpty)
+		Convey("match the ACL rules", func() {
+			acl, err := brconf.ParseACL([]byte(`{"Rules": [
+				{"Name": "other", "Action": "drop", "SrcIA": "1-11"},
+				{"Name": "block", "Action": "drop", "Interfaces": [5], "SrcIA": "1-0",
+					"SrcHost": "127.1.1.0/24", "L4": "UDP", "DstPorts": "3000-3100"}
+			]}`))
+			So(err, ShouldBeNil)
+			var matched []string
+			drop := acl.Evaluate(p, func(r *brconf.ACLRule) { matched = append(matched, r.Name) })
+			SoMsg("drop", drop, ShouldBeTrue)
+			SoMsg("matched", matched, ShouldResemble, []string{"block"})
+		})
+	})
+}
+
+func TestIsControl(t *testing.T) {
+	Convey("Control traffic is classified", t, func() {
+		SoMsg("UDP", isControl(&brconf.ACLPacket{L4: common.L4UDP}), ShouldBeFalse)
+		SoMsg("SCMP", isControl(&brconf.ACLPacket{L4: common.L4SCMP}), ShouldBeTrue)
+		SoMsg("one-hop path", isControl(&brconf.ACLPacket{L4: common.L4UDP,
+			Extns: []common.ExtnType{common.ExtnOneHopPathType}}), ShouldBeTrue)
+	})
+}
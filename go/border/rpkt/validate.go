@@ -70,6 +70,9 @@ func (rp *RtrPkt) Validate() (bool, error) {
 	if err := rp.validateExtns(); err != nil {
 		return false, err
 	}
+	if !rp.filter() {
+		return false, nil
+	}
 	for i, f := range rp.hooks.Validate {
 		ret, err := f()
 		switch {
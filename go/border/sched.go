@@ -0,0 +1,160 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file handles the output scheduling of the QoS config.
+
+package main
+
+import (
+	"syscall"
+	"unsafe"
+
+	"github.com/prometheus/client_golang/prometheus"
+
+	"github.com/scionproto/scion/go/border/brconf"
+	"github.com/scionproto/scion/go/border/metrics"
+	"github.com/scionproto/scion/go/border/qos"
+	"github.com/scionproto/scion/go/border/rctx"
+	"github.com/scionproto/scion/go/border/rpkt"
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ringbuf"
+)
+
+// outputScheduler schedules the output of a socket according to the traffic
+// classes of the QoS config. If the output of the socket is not scheduled, it
+// is disabled and packets are sent in FIFO order.
+type outputScheduler struct {
+	sock *rctx.Sock
+	ipv6 bool
+	// conf is the QoS config the scheduler is set up for.
+	conf  *brconf.QoS
+	sched *qos.Scheduler
+	// oob holds the control message setting the DSCP of each class, nil if the
+	// DSCP of the class is unset.
+	oob   [][]byte
+	drops []prometheus.Counter
+	buf   ringbuf.EntryList
+}
+
+func newOutputScheduler(s *rctx.Sock) *outputScheduler {
+	o := &outputScheduler{sock: s, buf: make(ringbuf.EntryList, outputBufCnt)}
+	if src := s.Conn.LocalAddr(); src != nil {
+		o.ipv6 = src.L3().Type() == addr.HostTypeIPv6
+	}
+	return o
+}
+
+// update switches to the QoS config of the current router context. The switch
+// is deferred while packets are queued, as their classes refer to the config
+// they were classified with.
+func (o *outputScheduler) update() {
+	if o.sched != nil && o.sched.Len() > 0 {
+		return
+	}
+	var conf *brconf.QoS
+	if ctx := rctx.Get(); ctx != nil && ctx.Conf != nil {
+		conf = ctx.Conf.QoS
+	}
+	if conf == o.conf {
+		return
+	}
+	o.conf = conf
+	o.sched, o.oob, o.drops = nil, nil, nil
+	if !conf.Scheduled(o.sock.Ifid) {
+		return
+	}
+	o.sched = qos.NewScheduler(conf.Weights(), conf.QueueLen)
+	o.oob = make([][]byte, conf.NumClasses())
+	o.drops = make([]prometheus.Counter, conf.NumClasses())
+	for class := range o.drops {
+		o.oob[class] = dscpOOB(conf.DSCP(class), o.ipv6)
+		o.drops[class] = metrics.QueueDrops.With(prometheus.Labels{
+			"sock": o.sock.Labels["sock"], "queue": conf.ClassName(class)})
+	}
+}
+
+// enabled returns whether the output of the socket is scheduled.
+func (o *outputScheduler) enabled() bool {
+	return o.sched != nil
+}
+
+// fetch moves the available packets from the ring to their queues, and then
+// returns up to outputBatchCnt scheduled packets in epkts. It only blocks if
+// no packets are queued. The second return value is false, if the ring is
+// closed and all queued packets have been fetched.
+func (o *outputScheduler) fetch(epkts ringbuf.EntryList,
+	ring *ringbuf.Ring) (ringbuf.EntryList, bool) {
+
+	n, _ := ring.Read(o.buf, o.sched.Len() == 0)
+	if n < 0 && o.sched.Len() == 0 {
+		return epkts[:0], false
+	}
+	for i := 0; i < n; i++ {
+		erp := o.buf[i].(*rpkt.EgressRtrPkt)
+		o.buf[i] = nil
+		class := o.class(erp.Rp)
+		if !o.sched.Enqueue(erp, class, len(erp.Rp.Raw)) {
+			o.drops[class].Inc()
+			erp.Rp.Release()
+		}
+	}
+	return o.sched.Dequeue(epkts[:0], outputBatchCnt), true
+}
+
+// class returns the traffic class of the packet. Packets classified with a
+// previous config that has more classes are put in the default class.
+func (o *outputScheduler) class(rp *rpkt.RtrPkt) int {
+	if rp.Class < 0 || rp.Class >= o.conf.NumClasses() {
+		return o.conf.DefaultClass()
+	}
+	return rp.Class
+}
+
+// msgOOB returns the control message to send with the packet.
+func (o *outputScheduler) msgOOB(rp *rpkt.RtrPkt) []byte {
+	if !o.enabled() {
+		return nil
+	}
+	return o.oob[o.class(rp)]
+}
+
+// tos returns the IPv4 type of service or IPv6 traffic class of the packet,
+// i.e., the DSCP of its class.
+func (o *outputScheduler) tos(rp *rpkt.RtrPkt) uint8 {
+	if !o.enabled() {
+		return 0
+	}
+	return o.conf.DSCP(o.class(rp)) << 2
+}
+
+// dscpOOB returns the control message setting the DSCP of an outgoing packet,
+// or nil if dscp is 0.
+func dscpOOB(dscp uint8, ipv6 bool) []byte {
+	if dscp == 0 {
+		return nil
+	}
+	level, typ := syscall.IPPROTO_IP, syscall.IP_TOS
+	if ipv6 {
+		level, typ = syscall.IPPROTO_IPV6, syscall.IPV6_TCLASS
+	}
+	oob := make([]byte, syscall.CmsgSpace(4))
+	hdr := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[0]))
+	hdr.Level = int32(level)
+	hdr.Type = int32(typ)
+	hdr.SetLen(syscall.CmsgLen(4))
+	// The DSCP is the upper 6 bits of the traffic class.
+	common.NativeOrder.PutUint32(oob[syscall.CmsgLen(0):], uint32(dscp)<<2)
+	return oob
+}
@@ -24,6 +24,7 @@ import (
 	"github.com/scionproto/scion/go/border/rctx"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/overlay"
 	"github.com/scionproto/scion/go/lib/overlay/conn"
 	"github.com/scionproto/scion/go/lib/prom"
 	"github.com/scionproto/scion/go/lib/ringbuf"
@@ -32,13 +33,39 @@ import (
 const PosixSock brconf.SockType = "posix"
 
 func init() {
-	registeredLocSockOps[PosixSock] = posixLoc{}
-	registeredExtSockOps[PosixSock] = posixExt{}
+	registeredLocSockOps[PosixSock] = posixLoc{posixIO}
+	registeredExtSockOps[PosixSock] = posixExt{posixIO}
+}
+
+// sockIO describes how the connections of a socket type are opened, and how
+// they are read from and written to. Socket types that are set up like POSIX
+// sockets use posixLoc and posixExt with their own sockIO.
+type sockIO struct {
+	sockType brconf.SockType
+	newConn  func(bind, remote *overlay.OverlayAddr) (conn.Conn, error)
+	input    func(r *Router) rctx.SockFunc
+	output   func(r *Router) rctx.SockFunc
+}
+
+var posixIO = sockIO{
+	sockType: PosixSock,
+	newConn: func(bind, remote *overlay.OverlayAddr) (conn.Conn, error) {
+		return conn.New(bind, remote, connConfig())
+	},
+	input:  func(r *Router) rctx.SockFunc { return r.posixInput },
+	output: func(r *Router) rctx.SockFunc { return r.posixOutput },
+}
+
+// connConfig returns the configuration of the POSIX connections.
+func connConfig() *conn.Config {
+	return &conn.Config{UDPOffload: cfg.BR.IO.UDPOffload}
 }
 
 var _ locSockOps = posixLoc{}
 
-type posixLoc struct{}
+type posixLoc struct {
+	io sockIO
+}
 
 // Setup configures a local POSIX(/BSD) socket.
 func (p posixLoc) Setup(r *Router, ctx *rctx.Ctx, labels prometheus.Labels,
@@ -88,15 +115,15 @@ func (p posixLoc) addSock(r *Router, ctx *rctx.Ctx, labels prometheus.Labels) er
 	bind := ctx.Conf.Net.LocAddr.BindOrPublicOverlay(ctx.Conf.Topo.Overlay)
 	log.Debug("Setting up new local socket.", "bind", bind)
 	// Listen on the socket.
-	over, err := conn.New(bind, nil, nil)
+	over, err := p.io.newConn(bind, nil)
 	if err != nil {
 		return common.NewBasicError("Unable to listen on local socket", err, "bind", bind)
 	}
 	// Setup input goroutine.
 	ctx.LocSockIn = rctx.NewSock(ringbuf.New(64, nil, "locIn", mkRingLabels(labels)),
-		over, rcmn.DirLocal, 0, labels, r.posixInput, r.handleSock, PosixSock)
+		over, rcmn.DirLocal, 0, labels, p.io.input(r), r.handleSock, p.io.sockType)
 	ctx.LocSockOut = rctx.NewSock(ringbuf.New(64, nil, "locOut", mkRingLabels(labels)),
-		over, rcmn.DirLocal, 0, labels, nil, r.posixOutput, PosixSock)
+		over, rcmn.DirLocal, 0, labels, nil, p.io.output(r), p.io.sockType)
 	log.Debug("Done setting up new local socket.", "conn", over.LocalAddr())
 	return nil
 }
@@ -160,15 +187,15 @@ func (p posixExt) addIntf(r *Router, ctx *rctx.Ctx, intf *netconf.Interface,
 	// Connect to remote address.
 	log.Debug("Setting up new external socket.", "intf", intf)
 	bind := intf.IFAddr.BindOrPublicOverlay(intf.IFAddr.Overlay)
-	c, err := conn.New(bind, intf.RemoteAddr, nil)
+	c, err := p.io.newConn(bind, intf.RemoteAddr)
 	if err != nil {
 		return common.NewBasicError("Unable to listen on external socket", err)
 	}
 	// Setup input goroutine.
 	ctx.ExtSockIn[intf.Id] = rctx.NewSock(ringbuf.New(64, nil, "extIn", mkRingLabels(labels)),
-		c, rcmn.DirExternal, intf.Id, labels, r.posixInput, r.handleSock, PosixSock)
+		c, rcmn.DirExternal, intf.Id, labels, p.io.input(r), r.handleSock, p.io.sockType)
 	ctx.ExtSockOut[intf.Id] = rctx.NewSock(ringbuf.New(64, nil, "extOut", mkRingLabels(labels)),
-		c, rcmn.DirExternal, intf.Id, labels, nil, r.posixOutput, PosixSock)
+		c, rcmn.DirExternal, intf.Id, labels, nil, p.io.output(r), p.io.sockType)
 	log.Debug("Done setting up new external socket.", "intf", intf)
 	return nil
 }
@@ -0,0 +1,226 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package main
+
+import (
+	"net"
+	"sync"
+	"time"
+
+	"github.com/scionproto/scion/go/border/brconf"
+	"github.com/scionproto/scion/go/border/rctx"
+	"github.com/scionproto/scion/go/border/xsk"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/overlay/conn"
+)
+
+// XDPSock is the type of the AF_XDP sockets. They are set up like POSIX
+// sockets, as each AF_XDP socket comes with a POSIX socket. The POSIX socket
+// reserves the port, and handles the packets the AF_XDP socket cannot, e.g.,
+// the packets received on other receive queues.
+const XDPSock brconf.SockType = "xdp"
+
+// neighborRefreshInterval is the minimum interval between refreshes of the
+// neighbor cache of an AF_XDP socket.
+const neighborRefreshInterval = time.Second
+
+var xdpIO = sockIO{
+	sockType: XDPSock,
+	newConn:  newXDPConn,
+	input:    func(r *Router) rctx.SockFunc { return r.xdpInput },
+	output:   func(r *Router) rctx.SockFunc { return r.xdpOutput },
+}
+
+func init() {
+	registeredLocSockOps[XDPSock] = posixLoc{xdpIO}
+	registeredExtSockOps[XDPSock] = posixExt{xdpIO}
+}
+
+var (
+	// xdpDevsMtx protects xdpDevs.
+	xdpDevsMtx sync.Mutex
+	// xdpDevs holds the indexes of the network devices with an AF_XDP socket.
+	// The XDP program of a network device only supports a single socket.
+	// validateXDP rejects contexts with further AF_XDP sockets on a device,
+	// such that the socket of a device is only taken while the sockets of the
+	// old context are replaced.
+	xdpDevs = make(map[int]bool)
+)
+
+// validateXDP ensures that at most one AF_XDP socket is bound to each network
+// device.
+func validateXDP(ctx *rctx.Ctx, sockConf brconf.SockConf) error {
+	var binds []*overlay.OverlayAddr
+	if sockConf.Loc() == XDPSock {
+		binds = append(binds, ctx.Conf.Net.LocAddr.BindOrPublicOverlay(ctx.Conf.Topo.Overlay))
+	}
+	for _, intf := range ctx.Conf.Net.IFs {
+		if sockConf.Ext(intf.Id) == XDPSock {
+			binds = append(binds, intf.IFAddr.BindOrPublicOverlay(intf.IFAddr.Overlay))
+		}
+	}
+	devs := make(map[int]*overlay.OverlayAddr, len(binds))
+	for _, bind := range binds {
+		ifi, err := interfaceByIP(bind.L3().IP())
+		if err != nil {
+			return common.NewBasicError("Unable to find network device of AF_XDP socket", err,
+				"bind", bind)
+		}
+		if other, ok := devs[ifi.Index]; ok {
+			return common.NewBasicError("Only one AF_XDP socket per network device is supported",
+				nil, "dev", ifi.Name, "bind", bind, "other", other)
+		}
+		devs[ifi.Index] = bind
+	}
+	return nil
+}
+
+var _ conn.Conn = (*xdpConn)(nil)
+
+// xdpConn is the connection of an AF_XDP socket. The input and the output
+// socket share the connection, which is closed when both have closed it.
+type xdpConn struct {
+	conn.Conn
+	sock      *xsk.Socket
+	prog      *xsk.Program
+	ifindex   int
+	mac       net.HardwareAddr
+	neighbors *xsk.Neighbors
+	mtx       sync.Mutex
+	refs      int
+}
+
+// newXDPConn creates the connection of an AF_XDP socket. If the network
+// device still has the AF_XDP socket of the old context, a POSIX connection is
+// returned instead, which xdpInput and xdpOutput handle like POSIX sockets.
+func newXDPConn(bind, remote *overlay.OverlayAddr) (conn.Conn, error) {
+	ifi, err := interfaceByIP(bind.L3().IP())
+	if err != nil {
+		return nil, err
+	}
+	xdpDevsMtx.Lock()
+	defer xdpDevsMtx.Unlock()
+	c, err := conn.New(bind, remote, connConfig())
+	if err != nil {
+		return nil, err
+	}
+	if xdpDevs[ifi.Index] {
+		log.Warn("Network device already has an AF_XDP socket, falling back to POSIX socket",
+			"dev", ifi.Name, "bind", bind)
+		return c, nil
+	}
+	xc := &xdpConn{
+		Conn:      c,
+		ifindex:   ifi.Index,
+		mac:       ifi.HardwareAddr,
+		neighbors: xsk.NewNeighbors(ifi.Index, neighborRefreshInterval),
+		refs:      2,
+	}
+	if err := xc.open(); err != nil {
+		xc.close()
+		return nil, common.NewBasicError("Unable to set up AF_XDP socket", err,
+			"dev", ifi.Name, "bind", bind)
+	}
+	xdpDevs[ifi.Index] = true
+	log.Debug("AF_XDP socket set up", "dev", ifi.Name, "bind", bind,
+		"genericXDP", xc.prog.Generic())
+	return xc, nil
+}
+
+func (c *xdpConn) open() error {
+	xdpCfg := cfg.BR.IO.XDP
+	var err error
+	if c.prog, err = xsk.LoadProgram(c.ifindex, xdpCfg.Generic); err != nil {
+		return err
+	}
+	c.sock, err = xsk.Open(xsk.Config{
+		Ifindex:   c.ifindex,
+		Queue:     xdpCfg.Queue,
+		FrameSize: xdpCfg.FrameSize,
+		NumFrames: xdpCfg.NumFrames,
+		Copy:      xdpCfg.Copy,
+	})
+	if err != nil {
+		return err
+	}
+	if err := c.prog.Register(c.sock); err != nil {
+		return err
+	}
+	return c.prog.AddPort(c.LocalAddr().L4().Port())
+}
+
+// Close closes the connection once both the input and the output socket have
+// closed it.
+func (c *xdpConn) Close() error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.refs--; c.refs > 0 {
+		return nil
+	}
+	xdpDevsMtx.Lock()
+	delete(xdpDevs, c.ifindex)
+	xdpDevsMtx.Unlock()
+	return c.close()
+}
+
+func (c *xdpConn) close() error {
+	if c.prog != nil {
+		if err := c.prog.Close(); err != nil {
+			log.Error("Unable to detach XDP program", "ifindex", c.ifindex, "err", err)
+		}
+	}
+	if c.sock != nil {
+		c.sock.Close()
+	}
+	return c.Conn.Close()
+}
+
+// accept returns whether the datagram received by the AF_XDP socket is for the
+// connection. Unlike the kernel, the XDP program only matches the destination
+// port.
+func (c *xdpConn) accept(d *xsk.Datagram) bool {
+	local := c.LocalAddr()
+	if d.DstPort != int(local.L4().Port()) || !d.DstIP.Equal(local.L3().IP()) {
+		return false
+	}
+	remote := c.RemoteAddr()
+	return remote == nil ||
+		(d.SrcPort == int(remote.L4().Port()) && d.SrcIP.Equal(remote.L3().IP()))
+}
+
+// interfaceByIP returns the network interface with the IP address.
+func interfaceByIP(ip net.IP) (*net.Interface, error) {
+	if ip.IsUnspecified() {
+		return nil, common.NewBasicError("AF_XDP sockets require a bind address", nil)
+	}
+	ifs, err := net.Interfaces()
+	if err != nil {
+		return nil, common.NewBasicError("Unable to list network interfaces", err)
+	}
+	for i := range ifs {
+		addrs, err := ifs[i].Addrs()
+		if err != nil {
+			continue
+		}
+		for _, a := range addrs {
+			if ipNet, ok := a.(*net.IPNet); ok && ipNet.IP.Equal(ip) {
+				return &ifs[i], nil
+			}
+		}
+	}
+	return nil, common.NewBasicError("No network interface with IP address", nil, "ip", ip)
+}
@@ -117,6 +117,20 @@ func (r *Router) loadNewConfig() (*brconf.BRConf, error) {
 	if config, err = brconf.Load(r.Id, r.confDir); err != nil {
 		return nil, common.NewBasicError("Failed to load topology config", err, "dir", r.confDir)
 	}
+	config.DirectPorts = cfg.BR.DirectPorts
+	if cfg.BR.ACLFile != "" {
+		if config.ACL, err = brconf.LoadACL(cfg.BR.ACLFile); err != nil {
+			return nil, common.NewBasicError("Failed to load ACL", err)
+		}
+		log.Debug("ACL loaded", "path", cfg.BR.ACLFile, "rules", len(config.ACL.Rules))
+	}
+	if cfg.BR.QoSFile != "" {
+		if config.QoS, err = brconf.LoadQoS(cfg.BR.QoSFile); err != nil {
+			return nil, common.NewBasicError("Failed to load QoS config", err)
+		}
+		log.Debug("QoS config loaded", "path", cfg.BR.QoSFile,
+			"classes", len(config.QoS.Classes))
+	}
 	log.Debug("Topology and AS config loaded", "IA", config.IA, "IfIDs", config.BR,
 		"dir", r.confDir)
 	return config, nil
@@ -199,14 +213,17 @@ func (r *Router) setupCtxOnClean() {
 // setupNewContext sets up a new router context.
 func (r *Router) setupNewContext(ctx *rctx.Ctx, tx *itopo.Transaction) error {
 	oldCtx := rctx.Get()
-	// TODO(roosd): Eventually, this will be configurable through brconfig.toml.
-	sockConf := brconf.SockConf{Default: PosixSock}
+	sockConf, err := cfg.BR.IO.SockConf()
+	if err != nil {
+		return err
+	}
 	if err := r.setupNetAndTopo(ctx, oldCtx, sockConf, tx); err != nil {
 		r.rollbackNet(ctx, oldCtx, sockConf, handleRollbackErr)
 		return err
 	}
 	rctx.Set(ctx)
 	startSocks(ctx)
+	r.bfd.update(ctx)
 	// Tear down sockets for removed interfaces
 	r.teardownNet(ctx, oldCtx, sockConf)
 	return nil
@@ -365,14 +382,24 @@ func extLabels(id common.IFIDType) prometheus.Labels {
 // validateCtx ensures that the socket type of existing sockets does not change
 // and that an address is not take over by one interfaces from another.
 func validateCtx(ctx, oldCtx *rctx.Ctx, sockConf brconf.SockConf) error {
-	if oldCtx == nil {
-		return nil
-	}
 	sockType := sockConf.Loc()
 	// Validate socket type is registered.
 	if _, ok := registeredLocSockOps[sockType]; !ok {
 		return common.NewBasicError("No LocSockOps found", nil, "sockType", sockType)
 	}
+	for _, intf := range ctx.Conf.Net.IFs {
+		sockType := sockConf.Ext(intf.Id)
+		if _, ok := registeredExtSockOps[sockType]; !ok {
+			return common.NewBasicError("No ExtSockOps found", nil,
+				"sockType", sockType, "ifid", intf.Id)
+		}
+	}
+	if err := validateXDP(ctx, sockConf); err != nil {
+		return err
+	}
+	if oldCtx == nil {
+		return nil
+	}
 	// Validate local sock of same type.
 	if oldCtx.LocSockIn.Type != sockType {
 		return common.NewBasicError("Unable to switch local socket type", nil,
@@ -381,11 +408,6 @@ func validateCtx(ctx, oldCtx *rctx.Ctx, sockConf brconf.SockConf) error {
 	// Validate interfaces.
 	for _, intf := range ctx.Conf.Net.IFs {
 		sockType := sockConf.Ext(intf.Id)
-		// Validate socket type is registered
-		if _, ok := registeredExtSockOps[sockType]; !ok {
-			return common.NewBasicError("No ExtSockOps found", nil,
-				"sockType", sockType, "ifid", intf.Id)
-		}
 		// Validate same socket type.
 		if oldCtx.ExtSockIn[intf.Id] != nil && oldCtx.ExtSockIn[intf.Id].Type != sockType {
 			return common.NewBasicError("Unable to switch external socket type", nil,
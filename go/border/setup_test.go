@@ -249,6 +249,32 @@ func TestTeardownNet(t *testing.T) {
 	})
 }
 
+func TestValidateXDP(t *testing.T) {
+	Convey("Given a context with all sockets on the loopback device", t, func() {
+		ctx := rctx.New(loadConfig(t))
+		setLoopback := func(a *overlay.OverlayAddr) {
+			ip := a.L3().IP()
+			copy(ip[len(ip)-4:], []byte{127, 0, 0, 1})
+		}
+		setLoopback(ctx.Conf.Net.LocAddr.BindOrPublicOverlay(ctx.Conf.Topo.Overlay))
+		for _, intf := range ctx.Conf.Net.IFs {
+			setLoopback(intf.IFAddr.BindOrPublicOverlay(intf.IFAddr.Overlay))
+		}
+		Convey("POSIX sockets are accepted", func() {
+			sockConf := brconf.SockConf{Default: brconf.DefaultSockType}
+			SoMsg("err", validateXDP(ctx, sockConf), ShouldBeNil)
+		})
+		Convey("a single AF_XDP socket is accepted", func() {
+			sockConf := brconf.SockConf{Default: brconf.DefaultSockType, LocalType: XDPSock}
+			SoMsg("err", validateXDP(ctx, sockConf), ShouldBeNil)
+		})
+		Convey("multiple AF_XDP sockets are rejected", func() {
+			sockConf := brconf.SockConf{Default: XDPSock}
+			SoMsg("err", validateXDP(ctx, sockConf), ShouldNotBeNil)
+		})
+	})
+}
+
 // checkLocSocksUnchanged compares that both contexts point to the same local socket.
 func checkLocSocksUnchanged(key string, ctx, oldCtx *rctx.Ctx) {
 	SoMsg(fmt.Sprintf("%s: LocSockIn unchanged", key), ctx.LocSockIn, ShouldEqual, oldCtx.LocSockIn)
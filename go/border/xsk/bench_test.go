@@ -0,0 +1,222 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// The benchmarks compare the throughput of AF_XDP sockets with the POSIX
+// sockets (see lib/overlay/conn) used by the border router by default, with
+// and without UDP offload. They run on a veth pair, and require root
+// privileges:
+//
+//   go test -run NONE -bench . ./go/border/xsk/
+//
+// On veth, AF_XDP sockets run in copy mode. With zero-copy drivers, the
+// difference is larger.
+
+package xsk
+
+import (
+	"net"
+	"testing"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/overlay/conn"
+)
+
+const (
+	benchPayloadLen = 1000
+	benchBatchSize  = 32
+	benchPort       = 30041
+)
+
+func BenchmarkTxPosix(b *testing.B) {
+	benchmarkTxPosix(b, false)
+}
+
+func BenchmarkTxPosixOffload(b *testing.B) {
+	benchmarkTxPosix(b, true)
+}
+
+func benchmarkTxPosix(b *testing.B, offload bool) {
+	v := newVeth(b)
+	defer v.close()
+	c := newBenchConn(b, 0, offload)
+	defer c.Close()
+	msgs := conn.NewWriteMessages(benchBatchSize)
+	for i := range msgs {
+		msgs[i].Buffers[0] = make([]byte, benchPayloadLen)
+		msgs[i].Addr = &net.UDPAddr{IP: vethPeerIP, Port: benchPort}
+	}
+	b.SetBytes(benchPayloadLen)
+	b.ResetTimer()
+	for sent := 0; sent < b.N; {
+		batch := msgs
+		if b.N-sent < len(batch) {
+			batch = batch[:b.N-sent]
+		}
+		n, err := c.WriteBatch(batch)
+		if err != nil {
+			b.Fatal(err)
+		}
+		sent += n
+	}
+}
+
+func BenchmarkTxXDP(b *testing.B) {
+	v := newVeth(b)
+	defer v.close()
+	s, err := Open(Config{Ifindex: v.ifindex})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer s.Close()
+	d := &Datagram{SrcMAC: v.mac, DstMAC: v.peerMAC, SrcIP: vethIP, DstIP: vethPeerIP,
+		SrcPort: benchPort, DstPort: benchPort, Payload: make([]byte, benchPayloadLen)}
+	b.SetBytes(benchPayloadLen)
+	b.ResetTimer()
+	for sent := 0; sent < b.N; {
+		for i := 0; i < benchBatchSize && sent < b.N; i++ {
+			frame := s.TxFrame()
+			if frame == nil {
+				break
+			}
+			// Write the whole frame, like the border router does for every
+			// packet.
+			n, err := d.Write(frame)
+			if err != nil {
+				b.Fatal(err)
+			}
+			s.Queue(n)
+			sent++
+		}
+		if err := s.Flush(); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
+
+func BenchmarkRxPosix(b *testing.B) {
+	benchmarkRxPosix(b, false)
+}
+
+func BenchmarkRxPosixOffload(b *testing.B) {
+	benchmarkRxPosix(b, true)
+}
+
+func benchmarkRxPosix(b *testing.B, offload bool) {
+	v := newVeth(b)
+	defer v.close()
+	c := newBenchConn(b, benchPort, offload)
+	defer c.Close()
+	msgs := conn.NewReadMessages(benchBatchSize)
+	for i := range msgs {
+		msgs[i].Buffers[0] = make([]byte, 1<<16)
+	}
+	metas := make([]conn.ReadMeta, benchBatchSize)
+	stop := startInjector(b, v)
+	defer close(stop)
+	c.SetReadDeadline(time.Now().Add(time.Minute))
+	b.SetBytes(benchPayloadLen)
+	b.ResetTimer()
+	for received := 0; received < b.N; {
+		n, err := c.ReadBatch(msgs, metas)
+		if err != nil {
+			b.Fatal(err)
+		}
+		received += n
+	}
+}
+
+func BenchmarkRxXDP(b *testing.B) {
+	v := newVeth(b)
+	defer v.close()
+	prog, err := LoadProgram(v.ifindex, false)
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer prog.Close()
+	s, err := Open(Config{Ifindex: v.ifindex})
+	if err != nil {
+		b.Fatal(err)
+	}
+	defer s.Close()
+	if err := prog.Register(s); err != nil {
+		b.Fatal(err)
+	}
+	if err := prog.AddPort(benchPort); err != nil {
+		b.Fatal(err)
+	}
+	frames := make([][]byte, benchBatchSize)
+	var d Datagram
+	stop := startInjector(b, v)
+	defer close(stop)
+	b.SetBytes(benchPayloadLen)
+	b.ResetTimer()
+	for received := 0; received < b.N; {
+		n, err := s.Receive(frames, time.Second)
+		if err != nil {
+			b.Fatal(err)
+		}
+		// Parse the frames, which the kernel does for POSIX sockets.
+		for _, frame := range frames[:n] {
+			if err := d.Parse(frame); err != nil {
+				b.Fatal(err)
+			}
+		}
+		received += n
+	}
+}
+
+func newBenchConn(b *testing.B, port uint16, offload bool) conn.Conn {
+	listen, err := overlay.NewOverlayAddr(addr.HostFromIP(vethIP), addr.NewL4UDPInfo(port))
+	if err != nil {
+		b.Fatal(err)
+	}
+	c, err := conn.New(listen, nil, &conn.Config{UDPOffload: offload})
+	if err != nil {
+		b.Fatal(err)
+	}
+	return c
+}
+
+// startInjector transmits frames to the benchmark port from the peer
+// interface until stop is closed. It uses an AF_XDP socket, such that the
+// injection rate exceeds the rate of the receiver.
+func startInjector(b *testing.B, v *veth) chan struct{} {
+	peer, err := net.InterfaceByName(v.name + "p")
+	if err != nil {
+		b.Fatal(err)
+	}
+	s, err := Open(Config{Ifindex: peer.Index})
+	if err != nil {
+		b.Fatal(err)
+	}
+	frame := v.frame(benchPort, make([]byte, benchPayloadLen))
+	stop := make(chan struct{})
+	go func() {
+		defer s.Close()
+		for {
+			select {
+			case <-stop:
+				return
+			default:
+			}
+			for buf := s.TxFrame(); buf != nil; buf = s.TxFrame() {
+				s.Queue(copy(buf, frame))
+			}
+			s.Flush()
+		}
+	}()
+	return stop
+}
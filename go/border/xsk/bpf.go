@@ -0,0 +1,332 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsk
+
+import (
+	"bytes"
+	"runtime"
+	"syscall"
+	"unsafe"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// Linux constants for BPF, see linux/bpf.h.
+const (
+	bpfMapCreate      = 0
+	bpfMapUpdateElem  = 2
+	bpfProgLoad       = 5
+	bpfMapTypeHash    = 1
+	bpfMapTypeXSKMap  = 17
+	bpfProgTypeXDP    = 6
+	bpfPseudoMapFD    = 1
+	bpfFuncLookup     = 1
+	bpfFuncRedirMap   = 51
+	xdpPass           = 2
+	xdpFlagsSKBMode   = 1 << 1
+	xdpFlagsDrvMode   = 1 << 2
+	maxQueues         = 64
+	maxPorts          = 64
+	verifierLogSize   = 1 << 16
+	xdpProgramName    = "scion_xsk"
+	xdpProgramLicense = "Apache-2.0"
+)
+
+// Program is the XDP program attached to a network interface. It redirects
+// the UDP packets to the registered ports to the AF_XDP socket bound to the
+// receive queue of the packet. All other packets are passed to the kernel.
+type Program struct {
+	ifindex int
+	flags   uint32
+	progFD  int
+	xsksFD  int
+	portsFD int
+}
+
+// LoadProgram loads the XDP program and attaches it to the network interface.
+// If generic is false, the program is attached in driver mode if the driver
+// supports it. Otherwise, it is attached in generic mode, which works with all
+// network interfaces but is slower.
+func LoadProgram(ifindex int, generic bool) (*Program, error) {
+	raiseMemlock()
+	p := &Program{ifindex: ifindex, progFD: -1, xsksFD: -1, portsFD: -1}
+	var err error
+	if p.xsksFD, err = createMap(bpfMapTypeXSKMap, 4, 4, maxQueues, "xsks"); err != nil {
+		p.close()
+		return nil, err
+	}
+	if p.portsFD, err = createMap(bpfMapTypeHash, 2, 1, maxPorts, "ports"); err != nil {
+		p.close()
+		return nil, err
+	}
+	if p.progFD, err = loadXDP(program(p.xsksFD, p.portsFD)); err != nil {
+		p.close()
+		return nil, err
+	}
+	modes := []uint32{xdpFlagsDrvMode, xdpFlagsSKBMode}
+	if generic {
+		modes = modes[1:]
+	}
+	for _, mode := range modes {
+		if err = attachXDP(ifindex, p.progFD, mode); err == nil {
+			p.flags = mode
+			return p, nil
+		}
+	}
+	p.close()
+	return nil, common.NewBasicError("Unable to attach XDP program", err, "ifindex", ifindex)
+}
+
+// Generic returns whether the program is attached in generic mode.
+func (p *Program) Generic() bool {
+	return p.flags == xdpFlagsSKBMode
+}
+
+// Register registers the socket for the receive queue it is bound to.
+func (p *Program) Register(s *Socket) error {
+	if s.QueueID() >= maxQueues {
+		return common.NewBasicError("Queue out of range", nil, "queue", s.QueueID(),
+			"max", maxQueues-1)
+	}
+	key, val := uint32(s.QueueID()), uint32(s.Fd())
+	if err := updateMap(p.xsksFD, unsafe.Pointer(&key), unsafe.Pointer(&val)); err != nil {
+		return common.NewBasicError("Unable to register AF_XDP socket", err,
+			"queue", s.QueueID())
+	}
+	return nil
+}
+
+// AddPort adds a UDP port whose packets are redirected to the sockets.
+func (p *Program) AddPort(port uint16) error {
+	key, val := [2]byte{byte(port >> 8), byte(port)}, uint8(1)
+	if err := updateMap(p.portsFD, unsafe.Pointer(&key), unsafe.Pointer(&val)); err != nil {
+		return common.NewBasicError("Unable to add port", err, "port", port)
+	}
+	return nil
+}
+
+// Close detaches the program from the network interface and releases it.
+func (p *Program) Close() error {
+	err := attachXDP(p.ifindex, -1, p.flags)
+	p.close()
+	return err
+}
+
+func (p *Program) close() {
+	for _, fd := range []*int{&p.progFD, &p.xsksFD, &p.portsFD} {
+		if *fd >= 0 {
+			syscall.Close(*fd)
+			*fd = -1
+		}
+	}
+}
+
+// insn corresponds to struct bpf_insn.
+type insn struct {
+	code uint8
+	regs uint8
+	off  int16
+	imm  int32
+}
+
+// BPF instruction opcodes.
+const (
+	opLdxB   = 0x71
+	opLdxH   = 0x69
+	opLdxW   = 0x61
+	opStxH   = 0x6b
+	opMovImm = 0xb7
+	opMovReg = 0xbf
+	opAddImm = 0x07
+	opAndImm = 0x57
+	opJeqImm = 0x15
+	opJneImm = 0x55
+	opJgtReg = 0x2d
+	opJa     = 0x05
+	opCall   = 0x85
+	opExit   = 0x95
+	opLdDW   = 0x18
+)
+
+// assembler assembles a BPF program with symbolic jump targets.
+type assembler struct {
+	insns  []insn
+	labels map[string]int
+	jumps  map[int]string
+}
+
+func (a *assembler) emit(code, dst, src uint8, off int16, imm int32) {
+	a.insns = append(a.insns, insn{code: code, regs: dst | src<<4, off: off, imm: imm})
+}
+
+func (a *assembler) jump(code, dst, src uint8, imm int32, label string) {
+	a.jumps[len(a.insns)] = label
+	a.emit(code, dst, src, 0, imm)
+}
+
+func (a *assembler) label(name string) {
+	a.labels[name] = len(a.insns)
+}
+
+func (a *assembler) loadMap(dst uint8, fd int) {
+	a.emit(opLdDW, dst, bpfPseudoMapFD, 0, int32(fd))
+	a.emit(0, 0, 0, 0, 0)
+}
+
+func (a *assembler) assemble() []insn {
+	for i, label := range a.jumps {
+		a.insns[i].off = int16(a.labels[label] - i - 1)
+	}
+	return a.insns
+}
+
+// program returns the XDP program. In pseudo code:
+//
+//   if the packet is IPv4 (without options, not a fragment) or IPv6, and UDP,
+//   and the destination port is in ports:
+//     redirect to xsks[rx_queue_index], or pass if there is no socket
+//   else:
+//     pass
+func program(xsksFD, portsFD int) []insn {
+	a := &assembler{labels: make(map[string]int), jumps: make(map[int]string)}
+	// r6 = ctx, r2 = data, r3 = data_end
+	a.emit(opMovReg, 6, 1, 0, 0)
+	a.emit(opLdxW, 2, 6, 0, 0)
+	a.emit(opLdxW, 3, 6, 4, 0)
+	// Ethernet
+	a.emit(opMovReg, 4, 2, 0, 0)
+	a.emit(opAddImm, 4, 0, 0, 14)
+	a.jump(opJgtReg, 4, 3, 0, "pass")
+	a.emit(opLdxH, 5, 2, 12, 0)
+	a.jump(opJeqImm, 5, 0, 0x0008, "ipv4")
+	a.jump(opJneImm, 5, 0, 0xdd86, "pass")
+	// IPv6
+	a.emit(opMovReg, 4, 2, 0, 0)
+	a.emit(opAddImm, 4, 0, 0, 14+40+8)
+	a.jump(opJgtReg, 4, 3, 0, "pass")
+	a.emit(opLdxB, 5, 2, 14+6, 0)
+	a.jump(opJneImm, 5, 0, int32(common.L4UDP), "pass")
+	a.emit(opLdxH, 5, 2, 14+40+2, 0)
+	a.jump(opJa, 0, 0, 0, "port")
+	// IPv4
+	a.label("ipv4")
+	a.emit(opMovReg, 4, 2, 0, 0)
+	a.emit(opAddImm, 4, 0, 0, 14+20+8)
+	a.jump(opJgtReg, 4, 3, 0, "pass")
+	a.emit(opLdxB, 5, 2, 14, 0)
+	a.jump(opJneImm, 5, 0, 0x45, "pass")
+	a.emit(opLdxB, 5, 2, 14+9, 0)
+	a.jump(opJneImm, 5, 0, int32(common.L4UDP), "pass")
+	// More fragments flag and fragment offset.
+	a.emit(opLdxH, 5, 2, 14+6, 0)
+	a.emit(opAndImm, 5, 0, 0, 0xff3f)
+	a.jump(opJneImm, 5, 0, 0, "pass")
+	a.emit(opLdxH, 5, 2, 14+20+2, 0)
+	// Port lookup
+	a.label("port")
+	a.emit(opStxH, 10, 5, -2, 0)
+	a.emit(opMovReg, 2, 10, 0, 0)
+	a.emit(opAddImm, 2, 0, 0, -2)
+	a.loadMap(1, portsFD)
+	a.emit(opCall, 0, 0, 0, bpfFuncLookup)
+	a.jump(opJeqImm, 0, 0, 0, "pass")
+	// Redirect
+	a.emit(opLdxW, 2, 6, 16, 0)
+	a.loadMap(1, xsksFD)
+	a.emit(opMovImm, 3, 0, 0, xdpPass)
+	a.emit(opCall, 0, 0, 0, bpfFuncRedirMap)
+	a.emit(opExit, 0, 0, 0, 0)
+	a.label("pass")
+	a.emit(opMovImm, 0, 0, 0, xdpPass)
+	a.emit(opExit, 0, 0, 0, 0)
+	return a.assemble()
+}
+
+func createMap(typ, keySize, valSize, maxEntries uint32, name string) (int, error) {
+	attr := struct {
+		mapType    uint32
+		keySize    uint32
+		valueSize  uint32
+		maxEntries uint32
+		mapFlags   uint32
+		innerMapFD uint32
+		numaNode   uint32
+		name       [16]byte
+	}{mapType: typ, keySize: keySize, valueSize: valSize, maxEntries: maxEntries}
+	copy(attr.name[:], name)
+	fd, err := bpf(bpfMapCreate, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	if err != nil {
+		return -1, common.NewBasicError("Unable to create BPF map", err, "name", name)
+	}
+	return fd, nil
+}
+
+func updateMap(fd int, key, val unsafe.Pointer) error {
+	attr := struct {
+		fd    uint32
+		_     uint32
+		key   uint64
+		value uint64
+		flags uint64
+	}{fd: uint32(fd), key: uint64(uintptr(key)), value: uint64(uintptr(val))}
+	_, err := bpf(bpfMapUpdateElem, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	return err
+}
+
+func loadXDP(insns []insn) (int, error) {
+	license := []byte(xdpProgramLicense + "\x00")
+	log := make([]byte, verifierLogSize)
+	attr := struct {
+		progType    uint32
+		insnCnt     uint32
+		insns       uint64
+		license     uint64
+		logLevel    uint32
+		logSize     uint32
+		logBuf      uint64
+		kernVersion uint32
+		progFlags   uint32
+		name        [16]byte
+	}{
+		progType: bpfProgTypeXDP,
+		insnCnt:  uint32(len(insns)),
+		insns:    uint64(uintptr(unsafe.Pointer(&insns[0]))),
+		license:  uint64(uintptr(unsafe.Pointer(&license[0]))),
+		logLevel: 1,
+		logSize:  uint32(len(log)),
+		logBuf:   uint64(uintptr(unsafe.Pointer(&log[0]))),
+	}
+	copy(attr.name[:], xdpProgramName)
+	fd, err := bpf(bpfProgLoad, unsafe.Pointer(&attr), unsafe.Sizeof(attr))
+	runtime.KeepAlive(insns)
+	runtime.KeepAlive(license)
+	runtime.KeepAlive(log)
+	if err != nil {
+		if end := bytes.IndexByte(log, 0); end >= 0 {
+			log = log[:end]
+		}
+		return -1, common.NewBasicError("Unable to load XDP program", err,
+			"verifier", string(log))
+	}
+	return fd, nil
+}
+
+func bpf(cmd int, attr unsafe.Pointer, size uintptr) (int, error) {
+	fd, _, errno := syscall.Syscall(sysBPF, uintptr(cmd), uintptr(attr), size)
+	if errno != 0 {
+		return -1, errno
+	}
+	return int(fd), nil
+}
@@ -0,0 +1,191 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsk
+
+import (
+	"encoding/binary"
+	"net"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/util"
+)
+
+const (
+	ethLen      = 14
+	ipv4Len     = 20
+	ipv6Len     = 40
+	udpLen      = 8
+	ethTypeIPv4 = 0x0800
+	ethTypeIPv6 = 0x86dd
+	defaultTTL  = 64
+	// MaxHdrLen is the maximum length of the headers in front of the UDP
+	// payload of a frame.
+	MaxHdrLen = ethLen + ipv6Len + udpLen
+)
+
+// Datagram is a UDP datagram in an Ethernet frame.
+type Datagram struct {
+	SrcMAC  net.HardwareAddr
+	DstMAC  net.HardwareAddr
+	SrcIP   net.IP
+	DstIP   net.IP
+	SrcPort int
+	DstPort int
+	// TOS is the IPv4 type of service or the IPv6 traffic class.
+	TOS     uint8
+	Payload []byte
+}
+
+// Parse parses the Ethernet frame into the datagram. The fields of the
+// datagram reference the frame. IPv4 fragments and datagrams with invalid
+// checksums are rejected.
+func (d *Datagram) Parse(frame []byte) error {
+	if len(frame) < ethLen {
+		return common.NewBasicError("Frame too short", nil, "len", len(frame))
+	}
+	d.DstMAC, d.SrcMAC = frame[0:6], frame[6:12]
+	ip := frame[ethLen:]
+	var l4 []byte
+	switch ethType := binary.BigEndian.Uint16(frame[12:]); ethType {
+	case ethTypeIPv4:
+		if len(ip) < ipv4Len || ip[0]>>4 != 4 {
+			return common.NewBasicError("Invalid IPv4 header", nil)
+		}
+		hdrLen, total := int(ip[0]&0x0f)*4, int(binary.BigEndian.Uint16(ip[2:]))
+		if hdrLen < ipv4Len || total < hdrLen || total > len(ip) {
+			return common.NewBasicError("Invalid IPv4 length", nil,
+				"hdrLen", hdrLen, "total", total, "available", len(ip))
+		}
+		if common.L4ProtocolType(ip[9]) != common.L4UDP {
+			return common.NewBasicError("Unsupported L4 protocol", nil, "proto", ip[9])
+		}
+		if binary.BigEndian.Uint16(ip[6:])&0x3fff != 0 {
+			return common.NewBasicError("Unsupported IPv4 fragment", nil)
+		}
+		if util.Checksum(ip[:hdrLen]) != 0 {
+			return common.NewBasicError("Invalid IPv4 header checksum", nil)
+		}
+		d.TOS, d.SrcIP, d.DstIP = ip[1], net.IP(ip[12:16]), net.IP(ip[16:20])
+		l4 = ip[hdrLen:total]
+	case ethTypeIPv6:
+		if len(ip) < ipv6Len || ip[0]>>4 != 6 {
+			return common.NewBasicError("Invalid IPv6 header", nil)
+		}
+		if common.L4ProtocolType(ip[6]) != common.L4UDP {
+			return common.NewBasicError("Unsupported L4 protocol", nil, "proto", ip[6])
+		}
+		plen := int(binary.BigEndian.Uint16(ip[4:]))
+		if ipv6Len+plen > len(ip) {
+			return common.NewBasicError("Invalid IPv6 length", nil,
+				"payloadLen", plen, "available", len(ip)-ipv6Len)
+		}
+		d.TOS = ip[0]<<4 | ip[1]>>4
+		d.SrcIP, d.DstIP = net.IP(ip[8:24]), net.IP(ip[24:40])
+		l4 = ip[ipv6Len : ipv6Len+plen]
+	default:
+		return common.NewBasicError("Unsupported ethernet type", nil, "type", ethType)
+	}
+	if len(l4) < udpLen {
+		return common.NewBasicError("UDP header too short", nil, "len", len(l4))
+	}
+	udpTotal := int(binary.BigEndian.Uint16(l4[4:]))
+	if udpTotal < udpLen || udpTotal > len(l4) {
+		return common.NewBasicError("Invalid UDP length", nil,
+			"len", udpTotal, "available", len(l4))
+	}
+	l4 = l4[:udpTotal]
+	// The UDP checksum is optional for IPv4 only.
+	if binary.BigEndian.Uint16(l4[6:]) != 0 || d.SrcIP.To4() == nil {
+		var pseudo [ipv6Len]byte
+		if util.Checksum(pseudoHeader(&pseudo, d.SrcIP, d.DstIP, len(l4)), l4) != 0 {
+			return common.NewBasicError("Invalid UDP checksum", nil)
+		}
+	}
+	d.SrcPort = int(binary.BigEndian.Uint16(l4[0:]))
+	d.DstPort = int(binary.BigEndian.Uint16(l4[2:]))
+	d.Payload = l4[udpLen:]
+	return nil
+}
+
+// Write writes the Ethernet frame of the datagram to b, and returns its
+// length. The IP version is determined by the source IP.
+func (d *Datagram) Write(b []byte) (int, error) {
+	v4 := d.SrcIP.To4() != nil
+	if v4 != (d.DstIP.To4() != nil) {
+		return 0, common.NewBasicError("IP version mismatch", nil,
+			"src", d.SrcIP, "dst", d.DstIP)
+	}
+	ipLen := ipv6Len
+	if v4 {
+		ipLen = ipv4Len
+	}
+	l4Len := udpLen + len(d.Payload)
+	n := ethLen + ipLen + l4Len
+	if n > len(b) {
+		return 0, common.NewBasicError("Frame too large", nil, "len", n, "max", len(b))
+	}
+	copy(b[0:6], d.DstMAC)
+	copy(b[6:12], d.SrcMAC)
+	ip := b[ethLen:]
+	if v4 {
+		binary.BigEndian.PutUint16(b[12:], ethTypeIPv4)
+		ip[0], ip[1] = 0x45, d.TOS
+		binary.BigEndian.PutUint16(ip[2:], uint16(ipv4Len+l4Len))
+		// Zero identification, don't fragment flag.
+		binary.BigEndian.PutUint32(ip[4:], 0x4000)
+		ip[8], ip[9] = defaultTTL, uint8(common.L4UDP)
+		ip[10], ip[11] = 0, 0
+		copy(ip[12:16], d.SrcIP.To4())
+		copy(ip[16:20], d.DstIP.To4())
+		binary.BigEndian.PutUint16(ip[10:], util.Checksum(ip[:ipv4Len]))
+	} else {
+		binary.BigEndian.PutUint16(b[12:], ethTypeIPv6)
+		binary.BigEndian.PutUint32(ip[0:], 6<<28|uint32(d.TOS)<<20)
+		binary.BigEndian.PutUint16(ip[4:], uint16(l4Len))
+		ip[6], ip[7] = uint8(common.L4UDP), defaultTTL
+		copy(ip[8:24], d.SrcIP.To16())
+		copy(ip[24:40], d.DstIP.To16())
+	}
+	l4 := ip[ipLen : ipLen+l4Len]
+	binary.BigEndian.PutUint16(l4[0:], uint16(d.SrcPort))
+	binary.BigEndian.PutUint16(l4[2:], uint16(d.DstPort))
+	binary.BigEndian.PutUint16(l4[4:], uint16(l4Len))
+	l4[6], l4[7] = 0, 0
+	copy(l4[udpLen:], d.Payload)
+	var pseudo [ipv6Len]byte
+	csum := util.Checksum(pseudoHeader(&pseudo, d.SrcIP, d.DstIP, l4Len), l4)
+	if csum == 0 {
+		csum = 0xffff
+	}
+	binary.BigEndian.PutUint16(l4[6:], csum)
+	return n, nil
+}
+
+// pseudoHeader writes the IPv4 or IPv6 pseudo header for the UDP checksum to
+// buf, and returns it.
+func pseudoHeader(buf *[ipv6Len]byte, src, dst net.IP, l4Len int) []byte {
+	if src4 := src.To4(); src4 != nil {
+		copy(buf[0:4], src4)
+		copy(buf[4:8], dst.To4())
+		buf[8], buf[9] = 0, uint8(common.L4UDP)
+		binary.BigEndian.PutUint16(buf[10:], uint16(l4Len))
+		return buf[:12]
+	}
+	copy(buf[0:16], src.To16())
+	copy(buf[16:32], dst.To16())
+	binary.BigEndian.PutUint32(buf[32:], uint32(l4Len))
+	buf[36], buf[37], buf[38], buf[39] = 0, 0, 0, uint8(common.L4UDP)
+	return buf[:40]
+}
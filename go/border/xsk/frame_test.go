@@ -0,0 +1,75 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsk
+
+import (
+	"net"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestDatagram(t *testing.T) {
+	Convey("Datagram", t, func() {
+		srcMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 1}
+		dstMAC := net.HardwareAddr{0x02, 0, 0, 0, 0, 2}
+		tests := map[string]struct {
+			src, dst net.IP
+			hdrLen   int
+		}{
+			"IPv4": {net.IP{192, 0, 2, 1}, net.IP{192, 0, 2, 2}, ethLen + ipv4Len + udpLen},
+			"IPv6": {net.ParseIP("2001:db8::1"), net.ParseIP("2001:db8::2"), MaxHdrLen},
+		}
+		for name, test := range tests {
+			d := &Datagram{SrcMAC: srcMAC, DstMAC: dstMAC, SrcIP: test.src, DstIP: test.dst,
+				SrcPort: 30041, DstPort: 30042, TOS: 46 << 2, Payload: []byte("scion")}
+			frame := make([]byte, 256)
+			n, err := d.Write(frame)
+			frame = frame[:n]
+			Convey(name+" frames are written", func() {
+				So(err, ShouldBeNil)
+				So(n, ShouldEqual, test.hdrLen+len("scion"))
+			})
+			Convey(name+" frames are parsed", func() {
+				var p Datagram
+				So(p.Parse(frame), ShouldBeNil)
+				So(p.SrcMAC, ShouldResemble, srcMAC)
+				So(p.DstMAC, ShouldResemble, dstMAC)
+				So(p.SrcIP.Equal(test.src), ShouldBeTrue)
+				So(p.DstIP.Equal(test.dst), ShouldBeTrue)
+				So(p.SrcPort, ShouldEqual, 30041)
+				So(p.DstPort, ShouldEqual, 30042)
+				So(p.TOS, ShouldEqual, 46<<2)
+				So(string(p.Payload), ShouldEqual, "scion")
+			})
+			Convey(name+" frames with invalid checksums are rejected", func() {
+				frame[len(frame)-1] ^= 0xff
+				var p Datagram
+				So(p.Parse(frame), ShouldNotBeNil)
+			})
+			Convey(name+" frames are truncated", func() {
+				var p Datagram
+				So(p.Parse(frame[:n-1]), ShouldNotBeNil)
+				_, err := d.Write(make([]byte, n-1))
+				So(err, ShouldNotBeNil)
+			})
+		}
+		Convey("Mixed IP versions are rejected", func() {
+			d := &Datagram{SrcIP: net.IP{192, 0, 2, 1}, DstIP: net.ParseIP("2001:db8::2")}
+			_, err := d.Write(make([]byte, 256))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
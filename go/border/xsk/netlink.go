@@ -0,0 +1,236 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsk
+
+import (
+	"net"
+	"sync"
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// Linux constants for netlink, see linux/if_link.h and linux/neighbour.h.
+const (
+	iflaXDP       = 43
+	iflaXDPFd     = 1
+	iflaXDPFlags  = 3
+	nlaFNested    = 1 << 15
+	ndaDst        = 1
+	ndaLLAddr     = 2
+	nudIncomplete = 0x01
+	nudFailed     = 0x20
+	ndMsgLen      = 12
+)
+
+// attachXDP attaches the XDP program to the network interface. If progFD is
+// -1, the attached program is detached.
+func attachXDP(ifindex, progFD int, flags uint32) error {
+	fd, err := syscall.Socket(syscall.AF_NETLINK, syscall.SOCK_RAW|syscall.SOCK_CLOEXEC,
+		syscall.NETLINK_ROUTE)
+	if err != nil {
+		return common.NewBasicError("Unable to create netlink socket", err)
+	}
+	defer syscall.Close(fd)
+	req := newNetlinkRequest(syscall.RTM_SETLINK, syscall.NLM_F_REQUEST|syscall.NLM_F_ACK)
+	info := syscall.IfInfomsg{Family: syscall.AF_UNSPEC, Index: int32(ifindex)}
+	req.append((*[syscall.SizeofIfInfomsg]byte)(unsafe.Pointer(&info))[:])
+	nested := req.beginAttr(iflaXDP | nlaFNested)
+	fdVal, flagsVal := int32(progFD), flags
+	req.appendAttr(iflaXDPFd, (*[4]byte)(unsafe.Pointer(&fdVal))[:])
+	req.appendAttr(iflaXDPFlags, (*[4]byte)(unsafe.Pointer(&flagsVal))[:])
+	req.endAttr(nested)
+	if err := syscall.Sendto(fd, req.bytes(), 0, &syscall.SockaddrNetlink{
+		Family: syscall.AF_NETLINK}); err != nil {
+		return common.NewBasicError("Unable to send netlink request", err)
+	}
+	buf := make([]byte, syscall.Getpagesize())
+	n, _, err := syscall.Recvfrom(fd, buf, 0)
+	if err != nil {
+		return common.NewBasicError("Unable to receive netlink response", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(buf[:n])
+	if err != nil {
+		return common.NewBasicError("Unable to parse netlink response", err)
+	}
+	for _, m := range msgs {
+		if m.Header.Type != syscall.NLMSG_ERROR || len(m.Data) < 4 {
+			continue
+		}
+		if errno := *(*int32)(unsafe.Pointer(&m.Data[0])); errno != 0 {
+			return common.NewBasicError("Unable to set XDP program", syscall.Errno(-errno),
+				"ifindex", ifindex)
+		}
+		return nil
+	}
+	return common.NewBasicError("Missing netlink acknowledgment", nil)
+}
+
+// netlinkRequest is a netlink message under construction.
+type netlinkRequest struct {
+	buf []byte
+}
+
+func newNetlinkRequest(typ, flags int) *netlinkRequest {
+	r := &netlinkRequest{buf: make([]byte, syscall.SizeofNlMsghdr)}
+	hdr := (*syscall.NlMsghdr)(unsafe.Pointer(&r.buf[0]))
+	hdr.Type = uint16(typ)
+	hdr.Flags = uint16(flags)
+	hdr.Seq = 1
+	return r
+}
+
+func (r *netlinkRequest) append(b []byte) {
+	r.buf = append(r.buf, b...)
+	for len(r.buf)%syscall.NLMSG_ALIGNTO != 0 {
+		r.buf = append(r.buf, 0)
+	}
+}
+
+// beginAttr starts an attribute with nested attributes, and returns its
+// offset, which must be passed to endAttr.
+func (r *netlinkRequest) beginAttr(typ int) int {
+	off := len(r.buf)
+	r.append(make([]byte, syscall.SizeofRtAttr))
+	attr := (*syscall.RtAttr)(unsafe.Pointer(&r.buf[off]))
+	attr.Type = uint16(typ)
+	return off
+}
+
+func (r *netlinkRequest) endAttr(off int) {
+	attr := (*syscall.RtAttr)(unsafe.Pointer(&r.buf[off]))
+	attr.Len = uint16(len(r.buf) - off)
+}
+
+func (r *netlinkRequest) appendAttr(typ int, val []byte) {
+	off := r.beginAttr(typ)
+	r.buf = append(r.buf, val...)
+	r.endAttr(off)
+	r.append(nil)
+}
+
+func (r *netlinkRequest) bytes() []byte {
+	hdr := (*syscall.NlMsghdr)(unsafe.Pointer(&r.buf[0]))
+	hdr.Len = uint32(len(r.buf))
+	return r.buf
+}
+
+// Neighbors caches the link-layer addresses of the neighbors of a network
+// interface, i.e., the ARP and NDP tables of the kernel. It is safe for
+// concurrent use.
+type Neighbors struct {
+	ifindex     int
+	minInterval time.Duration
+	mu          sync.RWMutex
+	entries     map[string]net.HardwareAddr
+	updated     time.Time
+}
+
+// NewNeighbors returns the neighbor cache of the network interface. The cache
+// is refreshed at most once per minInterval.
+func NewNeighbors(ifindex int, minInterval time.Duration) *Neighbors {
+	return &Neighbors{
+		ifindex:     ifindex,
+		minInterval: minInterval,
+		entries:     make(map[string]net.HardwareAddr),
+	}
+}
+
+// Lookup returns the link-layer address of the neighbor. If the neighbor is
+// not cached, the cache is refreshed, unless it was refreshed recently.
+func (n *Neighbors) Lookup(ip net.IP) (net.HardwareAddr, bool) {
+	key := string(ip.To16())
+	n.mu.RLock()
+	mac, ok := n.entries[key]
+	refresh := !ok && time.Since(n.updated) >= n.minInterval
+	n.mu.RUnlock()
+	if !refresh {
+		return mac, ok
+	}
+	if err := n.Refresh(); err != nil {
+		return nil, false
+	}
+	n.mu.RLock()
+	defer n.mu.RUnlock()
+	mac, ok = n.entries[key]
+	return mac, ok
+}
+
+// Refresh reads the neighbor tables of the kernel.
+func (n *Neighbors) Refresh() error {
+	n.mu.Lock()
+	n.updated = time.Now()
+	n.mu.Unlock()
+	rib, err := syscall.NetlinkRIB(syscall.RTM_GETNEIGH, syscall.AF_UNSPEC)
+	if err != nil {
+		return common.NewBasicError("Unable to dump neighbors", err)
+	}
+	msgs, err := syscall.ParseNetlinkMessage(rib)
+	if err != nil {
+		return common.NewBasicError("Unable to parse neighbors", err)
+	}
+	entries := make(map[string]net.HardwareAddr)
+	for _, m := range msgs {
+		if m.Header.Type != syscall.RTM_NEWNEIGH {
+			continue
+		}
+		if ip, mac := parseNeighbor(m.Data, n.ifindex); ip != nil {
+			entries[string(ip.To16())] = mac
+		}
+	}
+	n.mu.Lock()
+	n.entries = entries
+	n.mu.Unlock()
+	return nil
+}
+
+// parseNeighbor parses the neighbor message, and returns the IP and the
+// link-layer address of the neighbor, if it is reachable on the interface.
+func parseNeighbor(b []byte, ifindex int) (net.IP, net.HardwareAddr) {
+	if len(b) < ndMsgLen {
+		return nil, nil
+	}
+	msgIfindex := int(*(*int32)(unsafe.Pointer(&b[4])))
+	state := *(*uint16)(unsafe.Pointer(&b[8]))
+	if msgIfindex != ifindex || state&(nudIncomplete|nudFailed) != 0 {
+		return nil, nil
+	}
+	var ip net.IP
+	var mac net.HardwareAddr
+	for b = b[ndMsgLen:]; len(b) >= syscall.SizeofRtAttr; {
+		attr := (*syscall.RtAttr)(unsafe.Pointer(&b[0]))
+		if int(attr.Len) < syscall.SizeofRtAttr || int(attr.Len) > len(b) {
+			break
+		}
+		val := b[syscall.SizeofRtAttr:attr.Len]
+		switch attr.Type {
+		case ndaDst:
+			ip = append(net.IP(nil), val...)
+		case ndaLLAddr:
+			mac = append(net.HardwareAddr(nil), val...)
+		}
+		alen := (int(attr.Len) + syscall.RTA_ALIGNTO - 1) &^ (syscall.RTA_ALIGNTO - 1)
+		if alen > len(b) {
+			break
+		}
+		b = b[alen:]
+	}
+	if (len(ip) != net.IPv4len && len(ip) != net.IPv6len) || len(mac) != 6 {
+		return nil, nil
+	}
+	return ip, mac
+}
@@ -0,0 +1,124 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsk
+
+import (
+	"sync/atomic"
+	"unsafe"
+)
+
+// desc corresponds to struct xdp_desc, the entry of the RX and TX rings.
+type desc struct {
+	addr    uint64
+	len     uint32
+	options uint32
+}
+
+const descSize = int(unsafe.Sizeof(desc{}))
+
+// ringHead holds the producer and consumer indices of a ring, which are shared
+// with the kernel. The indices are free-running and masked on access.
+type ringHead struct {
+	producer *uint32
+	consumer *uint32
+	mask     uint32
+	size     uint32
+}
+
+func (h *ringHead) init(mem []byte, off ringOffsets, size int) {
+	h.producer = (*uint32)(unsafe.Pointer(&mem[off.producer]))
+	h.consumer = (*uint32)(unsafe.Pointer(&mem[off.consumer]))
+	h.size = uint32(size)
+	h.mask = h.size - 1
+}
+
+// available returns the number of entries that can be consumed.
+func (h *ringHead) available() uint32 {
+	return atomic.LoadUint32(h.producer) - atomic.LoadUint32(h.consumer)
+}
+
+// addrRing is a fill or completion ring, whose entries are UMEM addresses.
+type addrRing struct {
+	ringHead
+	addrs []uint64
+}
+
+func (r *addrRing) init(mem []byte, off ringOffsets, size int) {
+	r.ringHead.init(mem, off, size)
+	r.addrs = (*[1 << 28]uint64)(unsafe.Pointer(&mem[off.desc]))[:size:size]
+}
+
+// produce adds as many of the addresses as there is space for to the ring,
+// and returns their number.
+func (r *addrRing) produce(addrs []uint64) int {
+	prod := atomic.LoadUint32(r.producer)
+	free := int(r.size - (prod - atomic.LoadUint32(r.consumer)))
+	if len(addrs) > free {
+		addrs = addrs[:free]
+	}
+	for i, addr := range addrs {
+		r.addrs[(prod+uint32(i))&r.mask] = addr
+	}
+	atomic.StoreUint32(r.producer, prod+uint32(len(addrs)))
+	return len(addrs)
+}
+
+// consume appends all addresses in the ring to addrs.
+func (r *addrRing) consume(addrs []uint64) []uint64 {
+	cons := atomic.LoadUint32(r.consumer)
+	n := atomic.LoadUint32(r.producer) - cons
+	for i := uint32(0); i < n; i++ {
+		addrs = append(addrs, r.addrs[(cons+i)&r.mask])
+	}
+	atomic.StoreUint32(r.consumer, cons+n)
+	return addrs
+}
+
+// descRing is an RX or TX ring, whose entries are frame descriptors.
+type descRing struct {
+	ringHead
+	descs []desc
+}
+
+func (r *descRing) init(mem []byte, off ringOffsets, size int) {
+	r.ringHead.init(mem, off, size)
+	r.descs = (*[1 << 27]desc)(unsafe.Pointer(&mem[off.desc]))[:size:size]
+}
+
+// consume sets up to len(descs) descriptors from the ring in descs, and
+// returns their number.
+func (r *descRing) consume(descs []desc) int {
+	cons := atomic.LoadUint32(r.consumer)
+	n := int(atomic.LoadUint32(r.producer) - cons)
+	if len(descs) < n {
+		n = len(descs)
+	}
+	for i := 0; i < n; i++ {
+		descs[i] = r.descs[(cons+uint32(i))&r.mask]
+	}
+	atomic.StoreUint32(r.consumer, cons+uint32(n))
+	return n
+}
+
+// set sets the i-th descriptor after the producer index, without publishing
+// it to the kernel.
+func (r *descRing) set(i uint32, d desc) {
+	r.descs[(atomic.LoadUint32(r.producer)+i)&r.mask] = d
+}
+
+// publish advances the producer index by n.
+func (r *descRing) publish(n uint32) {
+	atomic.StoreUint32(r.producer, atomic.LoadUint32(r.producer)+n)
+}
@@ -0,0 +1,443 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package xsk implements packet I/O with AF_XDP sockets (XSK).
+//
+// An AF_XDP socket is bound to a single receive queue of a network interface.
+// Frames are exchanged with the kernel through a memory area shared with the
+// kernel (UMEM), which is split into equally sized frames, and four rings:
+// The fill and the RX ring for received frames, and the TX and the completion
+// ring for transmitted frames. The XDP program attached to the network
+// interface (see Program) redirects the UDP packets to the registered ports
+// to the socket. All other packets are passed to the kernel network stack.
+//
+// If the driver of the network interface supports it, the frames are
+// exchanged without copying (zero-copy mode). Otherwise, the kernel copies
+// them (copy mode), which also works with virtual interfaces such as veth.
+//
+// AF_XDP sockets require Linux 5.3 or later, and the CAP_NET_ADMIN and
+// CAP_NET_RAW capabilities.
+package xsk
+
+import (
+	"syscall"
+	"time"
+	"unsafe"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+const (
+	// DefaultFrameSize is the default size of the UMEM frames.
+	DefaultFrameSize = 2048
+	// DefaultNumFrames is the default number of UMEM frames.
+	DefaultNumFrames = 4096
+)
+
+// Linux constants for AF_XDP, see linux/if_xdp.h.
+const (
+	afXDP                 = 44
+	solXDP                = 283
+	xdpMmapOffsets        = 1
+	xdpRxRing             = 2
+	xdpTxRing             = 3
+	xdpUmemReg            = 4
+	xdpUmemFillRing       = 5
+	xdpUmemCompletionRing = 6
+	xdpCopy               = 1 << 1
+	pgoffRxRing           = 0
+	pgoffTxRing           = 0x80000000
+	pgoffFillRing         = 0x100000000
+	pgoffCompletionRing   = 0x180000000
+	pollIn                = 0x1
+)
+
+// Config is the configuration of an AF_XDP socket.
+type Config struct {
+	// Ifindex is the index of the network interface.
+	Ifindex int
+	// Queue is the receive queue of the network interface.
+	Queue int
+	// FrameSize is the size of the UMEM frames. It must be 2048 or 4096. If
+	// 0, DefaultFrameSize is used.
+	FrameSize int
+	// NumFrames is the number of UMEM frames, half of which are used for
+	// receiving. It must be a power of two. If 0, DefaultNumFrames is used.
+	NumFrames int
+	// Copy forces copy mode. Otherwise, zero-copy mode is used if the driver
+	// supports it.
+	Copy bool
+}
+
+func (cfg *Config) initDefaults() {
+	if cfg.FrameSize == 0 {
+		cfg.FrameSize = DefaultFrameSize
+	}
+	if cfg.NumFrames == 0 {
+		cfg.NumFrames = DefaultNumFrames
+	}
+}
+
+func (cfg *Config) validate() error {
+	if cfg.FrameSize != 2048 && cfg.FrameSize != 4096 {
+		return common.NewBasicError("Invalid frame size", nil, "frameSize", cfg.FrameSize)
+	}
+	if cfg.NumFrames < 2 || cfg.NumFrames&(cfg.NumFrames-1) != 0 {
+		return common.NewBasicError("Number of frames must be a power of two", nil,
+			"numFrames", cfg.NumFrames)
+	}
+	return nil
+}
+
+// Socket is an AF_XDP socket. Receive must only be called from a single
+// goroutine, and so must TxFrame, Queue and Flush. Receiving and transmitting
+// can happen concurrently.
+type Socket struct {
+	fd        int
+	cfg       Config
+	umem      []byte
+	fill      addrRing
+	comp      addrRing
+	rx        descRing
+	tx        descRing
+	mmaps     [][]byte
+	frameMask uint64
+	// rxDescs is the buffer for the descriptors of received frames.
+	rxDescs []desc
+	// rxAddrs are the frames returned by the last call to Receive.
+	rxAddrs []uint64
+	// txFree are the free transmit frames.
+	txFree []uint64
+	// txCurr is the frame returned by TxFrame that has not been queued yet,
+	// if txCurrSet is true.
+	txCurr    uint64
+	txCurrSet bool
+	// txQueued is the number of frames queued since the last Flush.
+	txQueued uint32
+}
+
+// Open opens an AF_XDP socket. The socket must be registered with the XDP
+// program of the network interface to receive packets.
+func Open(cfg Config) (*Socket, error) {
+	cfg.initDefaults()
+	if err := cfg.validate(); err != nil {
+		return nil, err
+	}
+	raiseMemlock()
+	fd, err := syscall.Socket(afXDP, syscall.SOCK_RAW, 0)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to create AF_XDP socket", err)
+	}
+	s := &Socket{fd: fd, cfg: cfg, frameMask: ^uint64(cfg.FrameSize - 1)}
+	if err := s.init(); err != nil {
+		s.Close()
+		return nil, err
+	}
+	return s, nil
+}
+
+func (s *Socket) init() error {
+	var err error
+	size := s.cfg.NumFrames * s.cfg.FrameSize
+	s.umem, err = syscall.Mmap(-1, 0, size, syscall.PROT_READ|syscall.PROT_WRITE,
+		syscall.MAP_PRIVATE|syscall.MAP_ANONYMOUS|syscall.MAP_POPULATE)
+	if err != nil {
+		return common.NewBasicError("Unable to allocate UMEM", err, "size", size)
+	}
+	reg := umemReg{
+		addr:      uint64(uintptr(unsafe.Pointer(&s.umem[0]))),
+		len:       uint64(size),
+		chunkSize: uint32(s.cfg.FrameSize),
+	}
+	if err := setsockopt(s.fd, xdpUmemReg, unsafe.Pointer(&reg), unsafe.Sizeof(reg)); err != nil {
+		return common.NewBasicError("Unable to register UMEM", err)
+	}
+	ringSize := s.cfg.NumFrames / 2
+	for _, opt := range []int{xdpUmemFillRing, xdpUmemCompletionRing, xdpRxRing, xdpTxRing} {
+		if err := syscall.SetsockoptInt(s.fd, solXDP, opt, ringSize); err != nil {
+			return common.NewBasicError("Unable to set ring size", err, "opt", opt)
+		}
+	}
+	off, err := getMmapOffsets(s.fd)
+	if err != nil {
+		return err
+	}
+	if err := s.mapRings(off, ringSize); err != nil {
+		return err
+	}
+	if err := s.bind(); err != nil {
+		return err
+	}
+	// The first half of the frames is used for receiving, the second half for
+	// transmitting.
+	rxAddrs := make([]uint64, ringSize)
+	s.txFree = make([]uint64, 0, ringSize)
+	for i := 0; i < ringSize; i++ {
+		rxAddrs[i] = uint64(i * s.cfg.FrameSize)
+		s.txFree = append(s.txFree, uint64((ringSize+i)*s.cfg.FrameSize))
+	}
+	s.fill.produce(rxAddrs)
+	s.rxAddrs = rxAddrs[:0]
+	s.rxDescs = make([]desc, ringSize)
+	return nil
+}
+
+func (s *Socket) mapRings(off *mmapOffsets, ringSize int) error {
+	mapRing := func(pgoff int64, ro ringOffsets, descSize int) ([]byte, error) {
+		mem, err := syscall.Mmap(s.fd, pgoff, int(ro.desc)+ringSize*descSize,
+			syscall.PROT_READ|syscall.PROT_WRITE, syscall.MAP_SHARED|syscall.MAP_POPULATE)
+		if err != nil {
+			return nil, common.NewBasicError("Unable to map ring", err, "pgoff", pgoff)
+		}
+		s.mmaps = append(s.mmaps, mem)
+		return mem, nil
+	}
+	mem, err := mapRing(pgoffFillRing, off.fill, 8)
+	if err != nil {
+		return err
+	}
+	s.fill.init(mem, off.fill, ringSize)
+	if mem, err = mapRing(pgoffCompletionRing, off.comp, 8); err != nil {
+		return err
+	}
+	s.comp.init(mem, off.comp, ringSize)
+	if mem, err = mapRing(pgoffRxRing, off.rx, descSize); err != nil {
+		return err
+	}
+	s.rx.init(mem, off.rx, ringSize)
+	if mem, err = mapRing(pgoffTxRing, off.tx, descSize); err != nil {
+		return err
+	}
+	s.tx.init(mem, off.tx, ringSize)
+	return nil
+}
+
+func (s *Socket) bind() error {
+	sa := sockaddrXDP{
+		family:  afXDP,
+		ifindex: uint32(s.cfg.Ifindex),
+		queueID: uint32(s.cfg.Queue),
+	}
+	if s.cfg.Copy {
+		sa.flags = xdpCopy
+	}
+	_, _, errno := syscall.Syscall(syscall.SYS_BIND, uintptr(s.fd),
+		uintptr(unsafe.Pointer(&sa)), unsafe.Sizeof(sa))
+	if errno != 0 {
+		return common.NewBasicError("Unable to bind AF_XDP socket", errno,
+			"ifindex", s.cfg.Ifindex, "queue", s.cfg.Queue)
+	}
+	return nil
+}
+
+// Fd returns the file descriptor of the socket.
+func (s *Socket) Fd() int {
+	return s.fd
+}
+
+// QueueID returns the receive queue the socket is bound to.
+func (s *Socket) QueueID() int {
+	return s.cfg.Queue
+}
+
+// FrameSize returns the size of the UMEM frames.
+func (s *Socket) FrameSize() int {
+	return s.cfg.FrameSize
+}
+
+// Receive waits up to timeout until frames are received, and sets up to
+// len(frames) received frames in frames. The frames are only valid until the
+// next call to Receive. It returns the number of received frames, which is 0
+// if the timeout expired.
+func (s *Socket) Receive(frames [][]byte, timeout time.Duration) (int, error) {
+	// Return the frames of the previous call to the kernel.
+	if len(s.rxAddrs) > 0 {
+		s.fill.produce(s.rxAddrs)
+		s.rxAddrs = s.rxAddrs[:0]
+	}
+	if s.rx.available() == 0 {
+		if err := poll(s.fd, pollIn, timeout); err != nil {
+			return 0, err
+		}
+	}
+	descs := s.rxDescs
+	if len(frames) < len(descs) {
+		descs = descs[:len(frames)]
+	}
+	n := s.rx.consume(descs)
+	for i, d := range descs[:n] {
+		frames[i] = s.umem[d.addr : d.addr+uint64(d.len)]
+		s.rxAddrs = append(s.rxAddrs, d.addr&s.frameMask)
+	}
+	return n, nil
+}
+
+// TxFrame returns a free frame for transmission, or nil if there is none. The
+// frame is transmitted by calling Queue and then Flush. If Queue is not
+// called, the next call to TxFrame returns the same frame.
+func (s *Socket) TxFrame() []byte {
+	if !s.txCurrSet {
+		if len(s.txFree) == 0 {
+			s.reapCompletions()
+			if len(s.txFree) == 0 {
+				return nil
+			}
+		}
+		s.txCurr = s.txFree[len(s.txFree)-1]
+		s.txFree = s.txFree[:len(s.txFree)-1]
+		s.txCurrSet = true
+	}
+	return s.umem[s.txCurr : s.txCurr+uint64(s.cfg.FrameSize)]
+}
+
+// Queue queues the first n bytes of the frame returned by TxFrame for
+// transmission.
+func (s *Socket) Queue(n int) {
+	s.tx.set(s.txQueued, desc{addr: s.txCurr, len: uint32(n)})
+	s.txQueued++
+	s.txCurrSet = false
+}
+
+// Flush transmits the queued frames.
+func (s *Socket) Flush() error {
+	if s.txQueued > 0 {
+		s.tx.publish(s.txQueued)
+		s.txQueued = 0
+	}
+	s.reapCompletions()
+	if len(s.txFree) == cap(s.txFree) {
+		return nil
+	}
+	_, _, errno := syscall.Syscall6(syscall.SYS_SENDTO, uintptr(s.fd), 0, 0,
+		syscall.MSG_DONTWAIT, 0, 0)
+	switch errno {
+	case 0, syscall.EAGAIN, syscall.EBUSY, syscall.ENOBUFS:
+		// The kernel processes the remaining frames on the next call.
+		return nil
+	}
+	return common.NewBasicError("Unable to transmit frames", errno)
+}
+
+// Pending returns the number of transmitted frames that have not been
+// completed by the kernel yet.
+func (s *Socket) Pending() int {
+	pending := cap(s.txFree) - len(s.txFree)
+	if s.txCurrSet {
+		pending--
+	}
+	return pending
+}
+
+func (s *Socket) reapCompletions() {
+	s.txFree = s.comp.consume(s.txFree)
+}
+
+// Close closes the socket and releases its memory. The socket must not be used
+// concurrently.
+func (s *Socket) Close() error {
+	for _, mem := range s.mmaps {
+		syscall.Munmap(mem)
+	}
+	s.mmaps = nil
+	if s.umem != nil {
+		syscall.Munmap(s.umem)
+		s.umem = nil
+	}
+	if s.fd < 0 {
+		return nil
+	}
+	err := syscall.Close(s.fd)
+	s.fd = -1
+	return err
+}
+
+// umemReg corresponds to struct xdp_umem_reg.
+type umemReg struct {
+	addr      uint64
+	len       uint64
+	chunkSize uint32
+	headroom  uint32
+}
+
+// sockaddrXDP corresponds to struct sockaddr_xdp.
+type sockaddrXDP struct {
+	family       uint16
+	flags        uint16
+	ifindex      uint32
+	queueID      uint32
+	sharedUmemFD uint32
+}
+
+// ringOffsets corresponds to struct xdp_ring_offset.
+type ringOffsets struct {
+	producer uint64
+	consumer uint64
+	desc     uint64
+	flags    uint64
+}
+
+// mmapOffsets corresponds to struct xdp_mmap_offsets.
+type mmapOffsets struct {
+	rx   ringOffsets
+	tx   ringOffsets
+	fill ringOffsets
+	comp ringOffsets
+}
+
+func getMmapOffsets(fd int) (*mmapOffsets, error) {
+	off := &mmapOffsets{}
+	size := uint32(unsafe.Sizeof(*off))
+	_, _, errno := syscall.Syscall6(syscall.SYS_GETSOCKOPT, uintptr(fd), solXDP,
+		xdpMmapOffsets, uintptr(unsafe.Pointer(off)), uintptr(unsafe.Pointer(&size)), 0)
+	if errno != 0 {
+		return nil, common.NewBasicError("Unable to get ring offsets", errno)
+	}
+	if size != uint32(unsafe.Sizeof(*off)) {
+		return nil, common.NewBasicError("Unsupported ring offsets", nil, "size", size)
+	}
+	return off, nil
+}
+
+func setsockopt(fd, opt int, val unsafe.Pointer, size uintptr) error {
+	_, _, errno := syscall.Syscall6(syscall.SYS_SETSOCKOPT, uintptr(fd), solXDP,
+		uintptr(opt), uintptr(val), size, 0)
+	if errno != 0 {
+		return errno
+	}
+	return nil
+}
+
+// poll waits up to timeout until the socket has one of the events.
+func poll(fd int, events int16, timeout time.Duration) error {
+	pfd := struct {
+		fd      int32
+		events  int16
+		revents int16
+	}{fd: int32(fd), events: events}
+	ts := syscall.NsecToTimespec(int64(timeout))
+	_, _, errno := syscall.Syscall6(syscall.SYS_PPOLL, uintptr(unsafe.Pointer(&pfd)), 1,
+		uintptr(unsafe.Pointer(&ts)), 0, 0, 0)
+	if errno != 0 && errno != syscall.EINTR {
+		return common.NewBasicError("Unable to poll AF_XDP socket", errno)
+	}
+	return nil
+}
+
+// raiseMemlock raises the limit of locked memory, which older kernels apply
+// to the UMEM and to BPF maps.
+func raiseMemlock() {
+	const rlimitMemlock = 8
+	lim := &syscall.Rlimit{Cur: ^uint64(0), Max: ^uint64(0)}
+	syscall.Setrlimit(rlimitMemlock, lim)
+}
@@ -0,0 +1,209 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package xsk
+
+import (
+	"fmt"
+	"net"
+	"os"
+	"os/exec"
+	"syscall"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+var (
+	vethIP     = net.IP{10, 199, 0, 1}
+	vethPeerIP = net.IP{10, 199, 0, 2}
+)
+
+// veth is a veth pair for tests. Since it requires root privileges, tests
+// using it are skipped otherwise.
+type veth struct {
+	name    string
+	ifindex int
+	mac     net.HardwareAddr
+	// peer is the AF_PACKET socket of the peer interface.
+	peer    int
+	peerMAC net.HardwareAddr
+}
+
+func newVeth(tb testing.TB) *veth {
+	if os.Geteuid() != 0 {
+		tb.Skip("Requires root privileges")
+	}
+	name := fmt.Sprintf("xskt%d", os.Getpid()%10000)
+	peer := name + "p"
+	if out, err := exec.Command("ip", "link", "add", name, "type", "veth",
+		"peer", "name", peer).CombinedOutput(); err != nil {
+		tb.Skipf("Unable to create veth pair: %s %s", err, out)
+	}
+	v := &veth{name: name, peer: -1}
+	cmds := [][]string{
+		{"link", "set", peer, "up"},
+		{"link", "set", name, "up"},
+		{"addr", "add", vethIP.String() + "/24", "dev", name},
+	}
+	for _, args := range cmds {
+		if out, err := exec.Command("ip", args...).CombinedOutput(); err != nil {
+			v.close()
+			tb.Fatalf("ip %v: %s %s", args, err, out)
+		}
+	}
+	ifi, err := net.InterfaceByName(name)
+	if err != nil {
+		v.close()
+		tb.Fatal(err)
+	}
+	peerIfi, err := net.InterfaceByName(peer)
+	if err != nil {
+		v.close()
+		tb.Fatal(err)
+	}
+	v.ifindex, v.mac, v.peerMAC = ifi.Index, ifi.HardwareAddr, peerIfi.HardwareAddr
+	out, err := exec.Command("ip", "neigh", "replace", vethPeerIP.String(), "lladdr",
+		v.peerMAC.String(), "dev", name).CombinedOutput()
+	if err != nil {
+		v.close()
+		tb.Fatalf("ip neigh: %s %s", err, out)
+	}
+	if v.peer, err = syscall.Socket(syscall.AF_PACKET, syscall.SOCK_RAW,
+		int(htons(syscall.ETH_P_ALL))); err != nil {
+		v.close()
+		tb.Fatal(err)
+	}
+	sa := &syscall.SockaddrLinklayer{Protocol: htons(syscall.ETH_P_ALL), Ifindex: peerIfi.Index}
+	if err := syscall.Bind(v.peer, sa); err != nil {
+		v.close()
+		tb.Fatal(err)
+	}
+	tv := syscall.NsecToTimeval(int64(10 * time.Millisecond))
+	syscall.SetsockoptTimeval(v.peer, syscall.SOL_SOCKET, syscall.SO_RCVTIMEO, &tv)
+	return v
+}
+
+// inject sends the frame from the peer interface.
+func (v *veth) inject(frame []byte) error {
+	_, err := syscall.Write(v.peer, frame)
+	return err
+}
+
+// capture returns the next UDP datagram to the port received on the peer
+// interface, or an error after the timeout.
+func (v *veth) capture(port int, timeout time.Duration) (*Datagram, error) {
+	buf := make([]byte, 1<<16)
+	for deadline := time.Now().Add(timeout); time.Now().Before(deadline); {
+		n, err := syscall.Read(v.peer, buf)
+		if err != nil {
+			continue
+		}
+		d := &Datagram{}
+		if d.Parse(buf[:n]) == nil && d.DstPort == port {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("no datagram received")
+}
+
+// frame returns a frame from the peer to the port.
+func (v *veth) frame(port int, payload []byte) []byte {
+	d := &Datagram{SrcMAC: v.peerMAC, DstMAC: v.mac, SrcIP: vethPeerIP, DstIP: vethIP,
+		SrcPort: 40000, DstPort: port, Payload: payload}
+	b := make([]byte, MaxHdrLen+len(payload))
+	n, _ := d.Write(b)
+	return b[:n]
+}
+
+func (v *veth) close() {
+	if v.peer >= 0 {
+		syscall.Close(v.peer)
+	}
+	exec.Command("ip", "link", "del", v.name).Run()
+}
+
+func htons(v uint16) uint16 {
+	return v<<8 | v>>8
+}
+
+func TestSocket(t *testing.T) {
+	v := newVeth(t)
+	defer v.close()
+	Convey("AF_XDP socket on a veth interface", t, func() {
+		prog, err := LoadProgram(v.ifindex, false)
+		So(err, ShouldBeNil)
+		defer prog.Close()
+		s, err := Open(Config{Ifindex: v.ifindex, NumFrames: 64})
+		So(err, ShouldBeNil)
+		defer s.Close()
+		So(prog.Register(s), ShouldBeNil)
+		So(prog.AddPort(30041), ShouldBeNil)
+		Convey("receives the datagrams to registered ports", func() {
+			So(v.inject(v.frame(30042, []byte("kernel"))), ShouldBeNil)
+			// The burst must fit into the fill ring.
+			for i := 0; i < 20; i++ {
+				So(v.inject(v.frame(30041, []byte{byte(i)})), ShouldBeNil)
+			}
+			frames := make([][]byte, 16)
+			var received []byte
+			for deadline := time.Now().Add(time.Second); time.Now().Before(deadline) &&
+				len(received) < 20; {
+				n, err := s.Receive(frames, 10*time.Millisecond)
+				So(err, ShouldBeNil)
+				for _, frame := range frames[:n] {
+					var d Datagram
+					So(d.Parse(frame), ShouldBeNil)
+					So(d.DstPort, ShouldEqual, 30041)
+					received = append(received, d.Payload...)
+				}
+			}
+			So(len(received), ShouldEqual, 20)
+			for i, b := range received {
+				So(b, ShouldEqual, byte(i))
+			}
+		})
+		Convey("transmits datagrams", func() {
+			for i := 0; i < 100; i++ {
+				frame := s.TxFrame()
+				So(frame, ShouldNotBeNil)
+				d := &Datagram{SrcMAC: v.mac, DstMAC: v.peerMAC, SrcIP: vethIP,
+					DstIP: vethPeerIP, SrcPort: 30041, DstPort: 40000 + i, Payload: []byte("tx")}
+				n, err := d.Write(frame)
+				So(err, ShouldBeNil)
+				s.Queue(n)
+				So(s.Flush(), ShouldBeNil)
+				d, err = v.capture(40000+i, time.Second)
+				So(err, ShouldBeNil)
+				So(string(d.Payload), ShouldEqual, "tx")
+			}
+			So(s.Flush(), ShouldBeNil)
+			So(s.Pending(), ShouldEqual, 0)
+		})
+	})
+}
+
+func TestNeighbors(t *testing.T) {
+	v := newVeth(t)
+	defer v.close()
+	Convey("Neighbors returns the kernel neighbor entries", t, func() {
+		n := NewNeighbors(v.ifindex, time.Second)
+		mac, ok := n.Lookup(vethPeerIP)
+		So(ok, ShouldBeTrue)
+		So(mac, ShouldResemble, v.peerMAC)
+		_, ok = n.Lookup(net.IP{10, 199, 0, 3})
+		So(ok, ShouldBeFalse)
+	})
+}
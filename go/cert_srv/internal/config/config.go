@@ -38,6 +38,9 @@ const (
 	ReissReqRate = 10 * time.Second
 	// ReissueReqTimeout is the default timeout of a reissue request.
 	ReissueReqTimeout = 5 * time.Second
+	// RevocationValidity is the default validity period of a published
+	// revocation list.
+	RevocationValidity = 24 * time.Hour
 
 	ErrorKeyConf   = "Unable to load KeyConf"
 	ErrorCustomers = "Unable to load Customers"
@@ -52,6 +55,7 @@ type Config struct {
 	Tracing   env.Tracing
 	QUIC      env.QUIC         `toml:"quic"`
 	Sciond    env.SciondClient `toml:"sd_client"`
+	Trust     env.Trust
 	TrustDB   truststorage.TrustDBConf
 	Discovery idiscovery.Config
 	CS        CSConfig
@@ -76,6 +80,7 @@ func (cfg *Config) Validate() error {
 		&cfg.Logging,
 		&cfg.Metrics,
 		&cfg.Sciond,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.Discovery,
 		&cfg.CS,
@@ -90,6 +95,7 @@ func (cfg *Config) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
 		&cfg.Metrics,
 		&cfg.Tracing,
 		&cfg.QUIC,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.Discovery,
 		&cfg.CS,
@@ -117,6 +123,11 @@ type CSConfig struct {
 	AutomaticRenewal bool
 	// DisableCorePush disables the core pusher task.
 	DisableCorePush bool
+	// RevocationFile is the file containing the certificates revoked by this
+	// AS. If set, a core AS periodically publishes a signed revocation list.
+	RevocationFile string
+	// RevocationValidity is the validity period of a published revocation list.
+	RevocationValidity util.DurWrap
 }
 
 func (cfg *CSConfig) InitDefaults() {
@@ -132,6 +143,9 @@ func (cfg *CSConfig) InitDefaults() {
 	if cfg.ReissueTimeout.Duration == 0 {
 		cfg.ReissueTimeout.Duration = ReissueReqTimeout
 	}
+	if cfg.RevocationValidity.Duration == 0 {
+		cfg.RevocationValidity.Duration = RevocationValidity
+	}
 }
 
 func (cfg *CSConfig) Validate() error {
@@ -147,6 +161,9 @@ func (cfg *CSConfig) Validate() error {
 	if cfg.ReissueTimeout.Duration == 0 {
 		return common.NewBasicError("ReissueTimeout must not be zero", nil)
 	}
+	if cfg.RevocationValidity.Duration == 0 {
+		return common.NewBasicError("RevocationValidity must not be zero", nil)
+	}
 	return nil
 }
 
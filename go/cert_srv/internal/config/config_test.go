@@ -56,6 +56,9 @@ func TestConfig_InitDefaults(t *testing.T) {
 			SoMsg("reissTimeout", cfg.CS.ReissueTimeout.Duration, ShouldEqual, 6*time.Second)
 			SoMsg("autoRenewal", cfg.CS.AutomaticRenewal, ShouldBeTrue)
 			SoMsg("disableCorePush", cfg.CS.DisableCorePush, ShouldBeTrue)
+			SoMsg("revocationFile", cfg.CS.RevocationFile, ShouldEqual, "revoked.json")
+			SoMsg("revocationValidity", cfg.CS.RevocationValidity.Duration, ShouldEqual,
+				12*time.Hour)
 		})
 	})
 
@@ -72,12 +75,16 @@ func TestConfig_InitDefaults(t *testing.T) {
 			SoMsg("reissTimeout", cfg.CS.ReissueTimeout.Duration, ShouldEqual, ReissueReqTimeout)
 			SoMsg("autoRenewal", cfg.CS.AutomaticRenewal, ShouldBeFalse)
 			SoMsg("disableCorePush", cfg.CS.DisableCorePush, ShouldBeFalse)
+			SoMsg("revocationFile", cfg.CS.RevocationFile, ShouldBeEmpty)
+			SoMsg("revocationValidity", cfg.CS.RevocationValidity.Duration, ShouldEqual,
+				RevocationValidity)
 		})
 	})
 }
 
 func InitTestConfig(cfg *Config) {
 	envtest.InitTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, &cfg.Sciond)
+	envtest.InitTestTrust(&cfg.Trust)
 	truststoragetest.InitTestConfig(&cfg.TrustDB)
 	idiscoverytest.InitTestConfig(&cfg.Discovery)
 	InitTestCSConfig(&cfg.CS)
@@ -90,6 +97,7 @@ func InitTestCSConfig(cfg *CSConfig) {
 
 func CheckTestConfig(cfg *Config, id string) {
 	envtest.CheckTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, &cfg.Sciond, id)
+	envtest.CheckTestTrust(&cfg.Trust)
 	truststoragetest.CheckTestConfig(&cfg.TrustDB, id)
 	idiscoverytest.CheckTestConfig(&cfg.Discovery)
 	CheckTestCSConfig(&cfg.CS)
@@ -104,4 +112,7 @@ func CheckTestCSConfig(cfg *CSConfig) {
 	SoMsg("IssuerReissLeadTime correct", cfg.IssuerReissueLeadTime.Duration, ShouldEqual,
 		IssuerReissTime)
 	SoMsg("DisableCorePush correct", cfg.DisableCorePush, ShouldBeFalse)
+	SoMsg("RevocationFile correct", cfg.RevocationFile, ShouldBeEmpty)
+	SoMsg("RevocationValidity correct", cfg.RevocationValidity.Duration, ShouldEqual,
+		RevocationValidity)
 }
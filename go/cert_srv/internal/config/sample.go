@@ -37,4 +37,12 @@ AutomaticRenewal = false
 
 # Disable the core pushing. (default false)
 DisableCorePush = false
+
+# File containing the JSON encoded list of certificates revoked by this AS. If
+# set, a core AS periodically publishes a signed revocation list. (default "")
+RevocationFile = ""
+
+# Validity period of a published revocation list. A new version is published
+# before half of the validity period has passed. (default 24h)
+RevocationValidity = "24h"
 `
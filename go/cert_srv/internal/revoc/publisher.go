@@ -0,0 +1,165 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package revoc contains the logic to publish the certificate revocation list
+// of a core AS.
+package revoc
+
+import (
+	"context"
+	"encoding/json"
+	"io/ioutil"
+	"sort"
+	"time"
+
+	"github.com/scionproto/scion/go/cert_srv/internal/config"
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/periodic"
+	"github.com/scionproto/scion/go/lib/scrypto/cert"
+	"github.com/scionproto/scion/go/lib/util"
+)
+
+var _ periodic.Task = (*Publisher)(nil)
+
+// Publisher periodically issues the certificate revocation list of a core AS.
+// A new version is issued if the set of revoked certificates in File changes,
+// if the issuer certificate is updated, or if half of the validity period of
+// the current version has passed. Issued lists are inserted into the trust
+// database, from where they are served to peers by the trust store.
+type Publisher struct {
+	State *config.State
+	IA    addr.IA
+	// File contains the JSON encoded list of revoked certificates.
+	File string
+	// Validity is the validity period of an issued revocation list.
+	Validity time.Duration
+}
+
+// Run issues a new revocation list for the local AS if necessary.
+func (p *Publisher) Run(ctx context.Context) {
+	if err := p.run(ctx); err != nil {
+		log.Error("[revoc.Publisher] Unable to publish revocation list", "err", err)
+	}
+}
+
+func (p *Publisher) run(ctx context.Context) error {
+	revoked, err := LoadRevocations(p.File)
+	if err != nil {
+		return err
+	}
+	issCrt, err := p.State.TrustDB.GetIssCertMaxVersion(ctx, p.IA)
+	if err != nil {
+		return common.NewBasicError("Unable to get issuer certificate", err)
+	}
+	if issCrt == nil {
+		return common.NewBasicError("Issuer certificate not found", nil, "ia", p.IA)
+	}
+	prev, err := p.State.TrustDB.GetCRLMaxVersion(ctx, p.IA)
+	if err != nil {
+		return common.NewBasicError("Unable to get current revocation list", err)
+	}
+	crl := nextRevocationList(prev, issCrt, revoked, time.Now(), p.Validity)
+	if crl == nil {
+		return nil
+	}
+	if err := crl.Sign(p.State.GetIssSigningKey(), issCrt.SignAlgorithm); err != nil {
+		return common.NewBasicError("Unable to sign revocation list", err, "crl", crl)
+	}
+	if err := crl.Verify(issCrt); err != nil {
+		return common.NewBasicError("Unable to verify revocation list", err, "crl", crl)
+	}
+	if _, err := p.State.TrustDB.InsertCRL(ctx, crl); err != nil {
+		return common.NewBasicError("Unable to write revocation list", err, "crl", crl)
+	}
+	log.Info("[revoc.Publisher] Published revocation list", "crl", crl,
+		"revoked", crl.Revoked)
+	return nil
+}
+
+// LoadRevocations reads the JSON encoded list of revoked certificates from
+// file. The list is sorted by subject and version.
+func LoadRevocations(file string) ([]cert.Revocation, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to read revocation file", err, "file", file)
+	}
+	var revoked []cert.Revocation
+	if err := json.Unmarshal(raw, &revoked); err != nil {
+		return nil, common.NewBasicError("Unable to parse revocation file", err, "file", file)
+	}
+	sort.Slice(revoked, func(i, j int) bool {
+		if !revoked[i].Subject.Equal(revoked[j].Subject) {
+			return revoked[i].Subject.IAInt() < revoked[j].Subject.IAInt()
+		}
+		return revoked[i].Version < revoked[j].Version
+	})
+	return revoked, nil
+}
+
+// nextRevocationList returns the unsigned successor of prev that revokes the
+// certificates in revoked. Nil is returned if prev is still up to date.
+// Revocation times of certificates that are already revoked by prev are
+// preserved. For newly revoked certificates without a revocation time, now is
+// used.
+func nextRevocationList(prev *cert.RevocationList, issCrt *cert.Certificate,
+	revoked []cert.Revocation, now time.Time, validity time.Duration) *cert.RevocationList {
+
+	if prev != nil && prev.IssuerVersion == issCrt.Version &&
+		sameRevocations(prev.Revoked, revoked) &&
+		now.Add(validity/2).Before(util.SecsToTime(prev.NextUpdate)) {
+		return nil
+	}
+	crl := &cert.RevocationList{
+		Issuer:        issCrt.Subject,
+		IssuerVersion: issCrt.Version,
+		IssuingTime:   util.TimeToSecs(now),
+		NextUpdate:    util.TimeToSecs(now.Add(validity)),
+		Revoked:       make([]cert.Revocation, 0, len(revoked)),
+		Version:       1,
+	}
+	if prev != nil {
+		crl.Version = prev.Version + 1
+	}
+	for _, r := range revoked {
+		if prev != nil {
+			for _, old := range prev.Revoked {
+				if old.Subject.Equal(r.Subject) && old.Version == r.Version {
+					r.RevocationTime = old.RevocationTime
+					break
+				}
+			}
+		}
+		if r.RevocationTime == 0 {
+			r.RevocationTime = crl.IssuingTime
+		}
+		crl.Revoked = append(crl.Revoked, r)
+	}
+	return crl
+}
+
+// sameRevocations checks whether a and b revoke the same certificates. Both
+// lists are expected to be sorted.
+func sameRevocations(a, b []cert.Revocation) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if !a[i].Subject.Equal(b[i].Subject) || a[i].Version != b[i].Version {
+			return false
+		}
+	}
+	return true
+}
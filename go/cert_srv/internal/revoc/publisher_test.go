@@ -0,0 +1,90 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package revoc
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/scrypto/cert"
+	"github.com/scionproto/scion/go/lib/util"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+func TestLoadRevocations(t *testing.T) {
+	Convey("Revocations are loaded and sorted", t, func() {
+		revoked, err := LoadRevocations("testdata/revoked.json")
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("revoked", revoked, ShouldResemble, []cert.Revocation{
+			{Subject: xtest.MustParseIA("1-ff00:0:311"), Version: 1},
+			{Subject: xtest.MustParseIA("1-ff00:0:311"), Version: 3, RevocationTime: 1560000000},
+			{Subject: xtest.MustParseIA("1-ff00:0:312"), Version: 2},
+		})
+	})
+	Convey("Missing file throws error", t, func() {
+		_, err := LoadRevocations("testdata/missing.json")
+		SoMsg("err", err, ShouldNotBeNil)
+	})
+}
+
+func TestNextRevocationList(t *testing.T) {
+	Convey("Next revocation list", t, func() {
+		issCrt := &cert.Certificate{Subject: xtest.MustParseIA("1-ff00:0:310"), Version: 2}
+		revoked := []cert.Revocation{
+			{Subject: xtest.MustParseIA("1-ff00:0:311"), Version: 1},
+		}
+		now := util.SecsToTime(util.TimeToSecs(time.Now()))
+		validity := 24 * time.Hour
+		prev := nextRevocationList(nil, issCrt, revoked, now, validity)
+
+		Convey("First version revokes certificates at the current time", func() {
+			SoMsg("version", prev.Version, ShouldEqual, 1)
+			SoMsg("issuer", prev.Issuer, ShouldResemble, issCrt.Subject)
+			SoMsg("issuerVersion", prev.IssuerVersion, ShouldEqual, issCrt.Version)
+			SoMsg("nextUpdate", prev.NextUpdate, ShouldEqual, util.TimeToSecs(now.Add(validity)))
+			SoMsg("revoked", prev.Revoked, ShouldResemble, []cert.Revocation{
+				{Subject: revoked[0].Subject, Version: 1, RevocationTime: util.TimeToSecs(now)},
+			})
+		})
+		Convey("Up to date list is not reissued", func() {
+			crl := nextRevocationList(prev, issCrt, revoked, now.Add(time.Hour), validity)
+			SoMsg("crl", crl, ShouldBeNil)
+		})
+		Convey("List is reissued after half of the validity period", func() {
+			crl := nextRevocationList(prev, issCrt, revoked, now.Add(validity/2), validity)
+			SoMsg("version", crl.Version, ShouldEqual, 2)
+			SoMsg("revoked", crl.Revoked, ShouldResemble, prev.Revoked)
+		})
+		Convey("List is reissued for a new issuer certificate", func() {
+			newCrt := &cert.Certificate{Subject: issCrt.Subject, Version: 3}
+			crl := nextRevocationList(prev, newCrt, revoked, now, validity)
+			SoMsg("version", crl.Version, ShouldEqual, 2)
+			SoMsg("issuerVersion", crl.IssuerVersion, ShouldEqual, 3)
+		})
+		Convey("List is reissued for new revocations", func() {
+			more := append(revoked, cert.Revocation{
+				Subject: xtest.MustParseIA("1-ff00:0:312"), Version: 4, RevocationTime: 1,
+			})
+			crl := nextRevocationList(prev, issCrt, more, now.Add(time.Hour), validity)
+			SoMsg("version", crl.Version, ShouldEqual, 2)
+			SoMsg("revoked", crl.Revoked, ShouldResemble, []cert.Revocation{
+				prev.Revoked[0],
+				{Subject: xtest.MustParseIA("1-ff00:0:312"), Version: 4, RevocationTime: 1},
+			})
+		})
+	})
+}
@@ -26,6 +26,7 @@ import (
 
 	"github.com/scionproto/scion/go/cert_srv/internal/config"
 	"github.com/scionproto/scion/go/cert_srv/internal/reiss"
+	"github.com/scionproto/scion/go/cert_srv/internal/revoc"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/discovery"
 	"github.com/scionproto/scion/go/lib/env"
@@ -44,6 +45,7 @@ var (
 	reissRunner *periodic.Runner
 	discRunners idiscovery.Runners
 	corePusher  *periodic.Runner
+	publisher   *periodic.Runner
 	msgr        infra.Messenger
 	trustDB     trustdb.TrustDB
 )
@@ -85,6 +87,8 @@ func realMain() int {
 	opentracing.SetGlobalTracer(tracer)
 	// Start the periodic reissuance task.
 	startReissRunner()
+	// Start the periodic revocation list publishing task.
+	startPublisher()
 	// Start the periodic fetching from discovery service.
 	startDiscovery()
 	// Start the messenger.
@@ -153,6 +157,31 @@ func startReissRunner() {
 	)
 }
 
+// startPublisher starts the periodic revocation list publishing task on a core
+// AS that has a revocation file configured.
+func startPublisher() {
+	if !itopo.Get().Core || cfg.CS.RevocationFile == "" {
+		return
+	}
+	log.Info("Starting periodic revoc.Publisher task")
+	publisher = periodic.StartPeriodicTask(
+		&revoc.Publisher{
+			State:    state,
+			IA:       itopo.Get().ISD_AS,
+			File:     cfg.CS.RevocationFile,
+			Validity: cfg.CS.RevocationValidity.Duration,
+		},
+		periodic.NewTicker(time.Minute),
+		10*time.Second,
+	)
+}
+
+func stopPublisher() {
+	if publisher != nil {
+		publisher.Stop()
+	}
+}
+
 func startDiscovery() {
 	var err error
 	discRunners, err = idiscovery.StartRunners(cfg.Discovery, discovery.Full,
@@ -173,6 +202,7 @@ func stopReissRunner() {
 
 func stop() {
 	stopReissRunner()
+	stopPublisher()
 	discRunners.Kill()
 	msgr.CloseServer()
 	trustDB.Close()
@@ -108,9 +108,11 @@ func reload() error {
 		return common.NewBasicError("Unable to validate new config", err)
 	}
 	cfg.CS = newConf.CS
-	// Restart the periodic reissue task to respect the fresh parameters.
+	// Restart the periodic tasks to respect the fresh parameters.
 	stopReissRunner()
 	startReissRunner()
+	stopPublisher()
+	startPublisher()
 	return nil
 }
 
@@ -126,6 +128,9 @@ func initState(cfg *config.Config, router snet.Router) error {
 		MustHaveLocalChain: true,
 		ServiceType:        proto.ServiceType_cs,
 		Router:             router,
+		CheckRevocation:    !cfg.Trust.DisableRevocationCheck,
+		RequireFreshCRL:    cfg.Trust.RequireFreshCRL,
+		MaxLeafLifetime:    cfg.Trust.MaxLeafLifetime.Duration,
 	}
 	trustStore := trust.NewStore(trustDB, topo.ISD_AS, trustConf, log.Root())
 	err = trustStore.LoadAuthoritativeCrypto(filepath.Join(cfg.General.ConfigDir, "certs"))
@@ -185,6 +190,13 @@ func setMessenger(cfg *config.Config, router snet.Router) error {
 		SVCRouter:             messenger.NewSVCRouter(itopo.Provider()),
 	}
 	var err error
+	if cfg.QUIC.Address != "" && cfg.QUIC.PKI {
+		nc.QUICPKI, err = infraenv.NewQUICPKI(filepath.Join(cfg.General.ConfigDir, "keys"),
+			topo.ISD_AS, state.Store, trustDB)
+		if err != nil {
+			return common.NewBasicError("Unable to initialize QUIC PKI", err)
+		}
+	}
 	msgr, err = nc.Messenger()
 	if err != nil {
 		return common.NewBasicError("Unable to initialize SCION Messenger", err)
@@ -193,6 +205,7 @@ func setMessenger(cfg *config.Config, router snet.Router) error {
 	msgr.AddHandler(infra.TRCRequest, state.Store.NewTRCReqHandler(true))
 	msgr.AddHandler(infra.Chain, state.Store.NewChainPushHandler())
 	msgr.AddHandler(infra.TRC, state.Store.NewTRCPushHandler())
+	msgr.AddHandler(infra.CRLRequest, state.Store.NewCRLReqHandler(true))
 	msgr.UpdateSigner(state.GetSigner(), []infra.MessageType{infra.ChainIssueRequest})
 	msgr.UpdateVerifier(state.GetVerifier())
 	// Only core CS handles certificate reissuance requests.
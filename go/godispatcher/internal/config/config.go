@@ -19,6 +19,9 @@ import (
 	"fmt"
 	"io"
 
+	"github.com/scionproto/scion/go/godispatcher/internal/limits"
+	"github.com/scionproto/scion/go/godispatcher/internal/registration"
+	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/config"
 	"github.com/scionproto/scion/go/lib/env"
@@ -39,11 +42,24 @@ type Config struct {
 		// OverlayPort is the native port opened by the dispatcher (default 30041)
 		OverlayPort int
 		// PerfData starts the pprof HTTP server on the specified address. If not set,
-		// the server is not started.
+		// the server is not started. The server, like the prometheus server,
+		// also serves the status of the registrations at /registrations.
 		PerfData string
 		// DeleteSocket specifies whether the dispatcher should delete the
 		// socket file prior to attempting to create a new one.
 		DeleteSocket bool
+		// DirectPorts is the range of end host ports that bypass the
+		// dispatcher at the border routers (the DirectPorts of the border
+		// routers). The dispatcher does not allocate or register these ports.
+		DirectPorts overlay.PortRange
+		// SVCPolicies maps anycast SVC addresses (e.g., CS) to the policy that
+		// selects the registrations that receive the packets for the address.
+		// SVC addresses without policy use round-robin.
+		SVCPolicies map[string]registration.SVCPolicy
+		// Limits are the rules that limit the traffic of registered
+		// applications. Each registration is limited by the first rule that
+		// matches it. Registrations that match no rule are not limited.
+		Limits limits.Rules
 	}
 }
 
@@ -66,6 +82,21 @@ func (cfg *Config) Validate() error {
 	if cfg.Dispatcher.ID == "" {
 		return common.NewBasicError("ID must be set", nil)
 	}
+	if err := cfg.Dispatcher.DirectPorts.ValidateDirect(); err != nil {
+		return err
+	}
+	for svc, policy := range cfg.Dispatcher.SVCPolicies {
+		if a := addr.HostSVCFromString(svc); a == addr.SvcNone || a.IsMulticast() {
+			return common.NewBasicError("SVCPolicies requires anycast SVC addresses", nil,
+				"svc", svc)
+		}
+		if err := policy.Validate(); err != nil {
+			return err
+		}
+	}
+	if err := cfg.Dispatcher.Limits.Validate(); err != nil {
+		return err
+	}
 	return config.ValidateAll(&cfg.Logging, &cfg.Metrics)
 }
 
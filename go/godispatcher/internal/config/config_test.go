@@ -55,4 +55,6 @@ func CheckTestConfig(cfg *Config, id string) {
 	SoMsg("OverlayPort", cfg.Dispatcher.OverlayPort, ShouldEqual, overlay.EndhostPort)
 	SoMsg("PerfData", cfg.Dispatcher.PerfData, ShouldBeEmpty)
 	SoMsg("DeleteSocket", cfg.Dispatcher.DeleteSocket, ShouldBeFalse)
+	SoMsg("SVCPolicies", cfg.Dispatcher.SVCPolicies, ShouldBeEmpty)
+	SoMsg("Limits", cfg.Dispatcher.Limits, ShouldBeEmpty)
 }
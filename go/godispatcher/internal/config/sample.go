@@ -28,9 +28,39 @@ OverlayPort = 30041
 
 # PerfData starts the pprof HTTP server on the specified address.
 # (host:port or ip:port or :port) If not set, the server is not started.
+# The server, like the prometheus server, also serves the status of the
+# registrations at /registrations.
 PerfData = ""
 
 # Set DeleteSock to true to have the Dispatcher remove the socket file (if it
 # exists) on start. (default false)
 DeleteSocket = false
+
+# Range of end host ports that bypass the dispatcher at the border routers,
+# e.g., "40000-40999". This must match the DirectPorts of the border routers.
+# The dispatcher does not allocate these ports, and rejects registrations for
+# them. (default "", i.e., none)
+DirectPorts = ""
+
+# SVCPolicies maps anycast SVC addresses (BS, PS, CS, SB, SIG) to the policy
+# that selects the registrations that receive the packets for the address.
+# Supported policies are round_robin, least_recently_used, source_hash, and
+# broadcast. SVC addresses without policy use round_robin. For example:
+# SVCPolicies = { CS = "source_hash", BS = "broadcast" }
+SVCPolicies = {}
+
+# Limits are the rules that limit the traffic of registered applications. Each
+# registration is limited by the first rule that matches it, registrations
+# that match no rule are not limited. A rule matches on the public port range
+# (Ports), the anycast SVC address (SVC), and the user and group of the
+# application process (UID, GID); omitted conditions match everything.
+# EgressRate and IngressRate are in bytes per second, and default to
+# unlimited. EgressBurst and IngressBurst are in bytes, and default to the
+# rate. Queue is the number of packets that can be queued for the
+# application. (default 128) For example:
+# Limits = [
+#     { SVC = "CS", Queue = 64 },
+#     { Ports = "40000-40999", UID = 1000, EgressRate = 1000000 },
+# ]
+Limits = []
 `
@@ -0,0 +1,163 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package limits contains the per-registration traffic limits of the
+// dispatcher.
+//
+// Limits are configured as a list of rules. Each registration is limited by
+// the first rule that matches it. A rule matches a registration if all its
+// match conditions hold; a rule without conditions matches all registrations.
+// Registrations that match no rule are not limited.
+package limits
+
+import (
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
+)
+
+// MaxQueue is the maximum number of packets queued for an application, and
+// the default queue quota.
+const MaxQueue = 128
+
+// Rule limits the traffic of the registrations it matches.
+type Rule struct {
+	// Ports matches registrations with a public port in the range.
+	Ports overlay.PortRange
+	// SVC matches registrations for the anycast SVC address (e.g., CS).
+	SVC string
+	// UID matches applications whose socket peer has the user ID.
+	UID *uint32
+	// GID matches applications whose socket peer has the group ID.
+	GID *uint32
+	// EgressRate is the rate in bytes per second of the packets the
+	// application can send. Zero means unlimited.
+	EgressRate uint64
+	// EgressBurst is the number of bytes the application can send at once. If
+	// zero, it is EgressRate, i.e., one second of traffic.
+	EgressBurst uint64
+	// IngressRate is the rate in bytes per second of the packets delivered to
+	// the application. Zero means unlimited.
+	IngressRate uint64
+	// IngressBurst is the number of bytes delivered at once. If zero, it is
+	// IngressRate.
+	IngressBurst uint64
+	// Queue is the number of packets that can be queued for the application,
+	// i.e., the quota of packet buffers the application can hold. If zero,
+	// it is MaxQueue.
+	Queue int
+}
+
+// Validate checks that the rule is well formed.
+func (r *Rule) Validate() error {
+	if err := r.Ports.Validate(); err != nil {
+		return err
+	}
+	if r.SVC != "" {
+		if svc := addr.HostSVCFromString(r.SVC); svc == addr.SvcNone || svc.IsMulticast() {
+			return common.NewBasicError("Rule requires anycast SVC address", nil, "svc", r.SVC)
+		}
+	}
+	if err := validateBurst(r.EgressRate, r.EgressBurst); err != nil {
+		return common.NewBasicError("Invalid egress limit", err)
+	}
+	if err := validateBurst(r.IngressRate, r.IngressBurst); err != nil {
+		return common.NewBasicError("Invalid ingress limit", err)
+	}
+	if r.Queue < 0 || r.Queue > MaxQueue {
+		return common.NewBasicError("Invalid queue quota", nil, "queue", r.Queue,
+			"max", MaxQueue)
+	}
+	return nil
+}
+
+// validateBurst checks that packets of the maximum size fit in the bucket, as
+// they could never be sent otherwise.
+func validateBurst(rate, burst uint64) error {
+	if burst == 0 {
+		burst = rate
+	}
+	if rate != 0 && burst < common.MaxMTU {
+		return common.NewBasicError("Burst must be at least the MTU", nil, "burst", burst,
+			"mtu", common.MaxMTU)
+	}
+	return nil
+}
+
+// Matches returns whether the rule matches the registration.
+func (r *Rule) Matches(reg Registration) bool {
+	if !r.Ports.IsEmpty() && !r.Ports.Contains(reg.Port) {
+		return false
+	}
+	if r.SVC != "" && addr.HostSVCFromString(r.SVC) != reg.SVC {
+		return false
+	}
+	if r.UID != nil && (reg.Cred == nil || reg.Cred.UID != *r.UID) {
+		return false
+	}
+	if r.GID != nil && (reg.Cred == nil || reg.Cred.GID != *r.GID) {
+		return false
+	}
+	return true
+}
+
+// QueueQuota returns the queue quota of the rule.
+func (r *Rule) QueueQuota() int {
+	if r.Queue == 0 {
+		return MaxQueue
+	}
+	return r.Queue
+}
+
+// Rules is an ordered list of rules.
+type Rules []Rule
+
+// Validate checks that all rules are well formed.
+func (rs Rules) Validate() error {
+	for i := range rs {
+		if err := rs[i].Validate(); err != nil {
+			return common.NewBasicError("Invalid limit rule", err, "index", i)
+		}
+	}
+	return nil
+}
+
+// Match returns the first rule that matches the registration, or nil if no
+// rule matches.
+func (rs Rules) Match(reg Registration) *Rule {
+	for i := range rs {
+		if rs[i].Matches(reg) {
+			return &rs[i]
+		}
+	}
+	return nil
+}
+
+// Registration contains the properties of a registration that rules match on.
+type Registration struct {
+	// Port is the public port of the registration.
+	Port uint16
+	// SVC is the SVC address of the registration, or SvcNone.
+	SVC addr.HostSVC
+	// Cred are the credentials of the application, or nil if they are
+	// unknown.
+	Cred *Cred
+}
+
+// Cred are the credentials of the process at the other end of a UNIX socket.
+type Cred struct {
+	PID int32
+	UID uint32
+	GID uint32
+}
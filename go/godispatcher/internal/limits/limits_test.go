@@ -0,0 +1,97 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+package limits
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
+)
+
+func TestRuleValidate(t *testing.T) {
+	tests := map[string]struct {
+		Rule  Rule
+		Valid bool
+	}{
+		"empty rule":      {Rule: Rule{}, Valid: true},
+		"port range":      {Rule: Rule{Ports: overlay.PortRange{Min: 1, Max: 2}}, Valid: true},
+		"reverse range":   {Rule: Rule{Ports: overlay.PortRange{Min: 2, Max: 1}}, Valid: false},
+		"anycast SVC":     {Rule: Rule{SVC: "CS"}, Valid: true},
+		"multicast SVC":   {Rule: Rule{SVC: "CS_M"}, Valid: false},
+		"unknown SVC":     {Rule: Rule{SVC: "foo"}, Valid: false},
+		"rate as burst":   {Rule: Rule{EgressRate: common.MaxMTU}, Valid: true},
+		"rate below MTU":  {Rule: Rule{EgressRate: 1000}, Valid: false},
+		"burst above MTU": {Rule: Rule{IngressRate: 1000, IngressBurst: 1 << 20}, Valid: true},
+		"burst below MTU": {Rule: Rule{IngressRate: 1 << 20, IngressBurst: 1000}, Valid: false},
+		"queue quota":     {Rule: Rule{Queue: MaxQueue}, Valid: true},
+		"queue above max": {Rule: Rule{Queue: MaxQueue + 1}, Valid: false},
+		"negative queue":  {Rule: Rule{Queue: -1}, Valid: false},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			err := test.Rule.Validate()
+			if test.Valid {
+				assert.NoError(t, err)
+			} else {
+				assert.Error(t, err)
+			}
+		})
+	}
+}
+
+func TestRulesMatch(t *testing.T) {
+	uid := uint32(1000)
+	rules := Rules{
+		{SVC: "CS", Queue: 1},
+		{Ports: overlay.PortRange{Min: 40000, Max: 40999}, Queue: 2},
+		{UID: &uid, Queue: 3},
+	}
+	tests := map[string]struct {
+		Registration Registration
+		Queue        int
+	}{
+		"SVC": {
+			Registration: Registration{Port: 40000, SVC: addr.SvcCS},
+			Queue:        1,
+		},
+		"port in range": {
+			Registration: Registration{Port: 40999, SVC: addr.SvcNone},
+			Queue:        2,
+		},
+		"UID": {
+			Registration: Registration{Port: 50000, SVC: addr.SvcNone, Cred: &Cred{UID: 1000}},
+			Queue:        3,
+		},
+		"other UID": {
+			Registration: Registration{Port: 50000, SVC: addr.SvcNone, Cred: &Cred{UID: 0}},
+		},
+		"unknown credentials": {
+			Registration: Registration{Port: 50000, SVC: addr.SvcNone},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			rule := rules.Match(test.Registration)
+			if test.Queue == 0 {
+				assert.Nil(t, rule)
+			} else if assert.NotNil(t, rule) {
+				assert.Equal(t, test.Queue, rule.QueueQuota())
+			}
+		})
+	}
+}
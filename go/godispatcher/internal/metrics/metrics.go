@@ -32,6 +32,8 @@ const (
 const (
 	IncomingPacketOutcome = "incoming_packet_outcome"
 	OpenConnectionType    = "open_connection_type"
+	LimitDirection        = "direction"
+	LimitReason           = "reason"
 )
 
 // Packet outcome labels
@@ -41,12 +43,22 @@ const (
 	PacketOutcomeOk            = "ok"
 )
 
+// Limit labels
+const (
+	LimitDirectionIngress = "ingress"
+	LimitDirectionEgress  = "egress"
+	LimitReasonRate       = "rate"
+	LimitReasonQueue      = "queue"
+)
+
 var (
 	OutgoingPacketsTotal prometheus.Counter
 	IncomingBytesTotal   prometheus.Counter
 	OutgoingBytesTotal   prometheus.Counter
 	IncomingPackets      *prometheus.CounterVec
 	OpenSockets          *prometheus.GaugeVec
+	LimitedPackets       *prometheus.CounterVec
+	LimitedBytes         *prometheus.CounterVec
 )
 
 // GetOpenConnectionLabel returns an SVC address string representation for sockets
@@ -80,4 +92,10 @@ func initMetrics(elem string) {
 		"Total packets received from the network.", []string{IncomingPacketOutcome})
 	OpenSockets = prom.NewGaugeVec(namespace, "", "open_application_connections",
 		"Number of sockets currently opened by applications.", []string{OpenConnectionType})
+	LimitedPackets = prom.NewCounterVec(namespace, "", "limited_packets_total",
+		"Total packets dropped because they exceeded the limits of the application.",
+		[]string{LimitDirection, LimitReason})
+	LimitedBytes = prom.NewCounterVec(namespace, "", "limited_bytes_total",
+		"Total bytes dropped because they exceeded the limits of the application.",
+		[]string{LimitDirection, LimitReason})
 }
@@ -102,6 +102,6 @@ func BenchmarkLookupServiceIPv4(b *testing.B) {
 	lookupData := generateLookupServiceArgs(b.N)
 	b.ResetTimer()
 	for n := 0; n < b.N; n++ {
-		table.LookupService(addr.IA{I: 1, A: 1}, lookupData[n].svc, lookupData[n].bind)
+		table.LookupService(addr.IA{I: 1, A: 1}, lookupData[n].svc, lookupData[n].bind, nil)
 	}
 }
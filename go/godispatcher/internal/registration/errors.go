@@ -24,4 +24,7 @@ const (
 	ErrNilAddress         = "nil address"
 	ErrSvcNone            = "svc none"
 	ErrNoPorts            = "no free ports"
+	ErrReservedPort       = "reserved port"
+	ErrBadSVCPolicy       = "unknown svc policy"
+	ErrBadSVCPolicySVC    = "svc policy requires anycast svc"
 )
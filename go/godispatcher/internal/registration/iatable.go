@@ -15,11 +15,14 @@
 package registration
 
 import (
+	"bytes"
 	"net"
+	"sort"
 	"sync"
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
 )
 
 const (
@@ -79,20 +82,49 @@ type IATable interface {
 	//
 	// If SVC is an anycast address, at most one entry is returned. The bind
 	// address is used to narrow down the set of possible entries. If multiple
-	// entries exist, one is selected arbitrarily.
+	// entries exist, one is selected according to the SVC policy, see
+	// SetSVCPolicy. The flow key identifies the source of the packet, and is
+	// used by SVCPolicySourceHash.
 	//
 	// Note that nil bind addresses are supported for anycasts (the address is
 	// in this case ignored), but support for this might be dropped in the
 	// future.
 	//
-	// If SVC is a multicast address, more than one entry can be returned. The
-	// bind address is ignored in this case.
-	LookupService(ia addr.IA, svc addr.HostSVC, bind net.IP) []interface{}
+	// If SVC is a multicast address, or the SVC policy is SVCPolicyBroadcast,
+	// more than one entry can be returned. The bind address is ignored in this
+	// case.
+	LookupService(ia addr.IA, svc addr.HostSVC, bind net.IP, flow []byte) []interface{}
+	// SetSVCPolicy sets the policy that selects the entries for anycasts to
+	// svc, in all ASes. The default policy is SVCPolicyRoundRobin.
+	SetSVCPolicy(svc addr.HostSVC, policy SVCPolicy) error
+	// SetReservedPorts reserves ports in all ASes, e.g., the ports that
+	// bypass the dispatcher at the border routers. Reserved ports are not
+	// allocated, and registrations for reserved ports return an error.
+	SetReservedPorts(ports overlay.PortRange) error
 	// LookupID returns the entry associated with the SCMP General class ID id.
 	// The ID is used for SCMP Echo, TraceRoute, and RecordPath functionality.
 	// If an entry is found, the returned boolean is set to true. Otherwise, it
 	// is set to false.
 	LookupID(ia addr.IA, id uint64) (interface{}, bool)
+	// Registrations returns a snapshot of all registrations in the table,
+	// sorted by IA and public address.
+	Registrations() []Registration
+}
+
+// Registration describes an entry of an IATable.
+type Registration struct {
+	IA addr.IA
+	// Public is the public address, including the allocated port.
+	Public *net.UDPAddr
+	// Bind is the bind address of the SVC registration, or nil if the entry
+	// is not registered for a service.
+	Bind net.IP
+	// SVC is the registered service address, or SvcNone.
+	SVC addr.HostSVC
+	// IDs are the SCMP General IDs registered for the entry.
+	IDs []uint64
+	// Value is the value associated with the entry.
+	Value interface{}
 }
 
 // NewIATable creates a new UDP/IP port registration table.
@@ -108,17 +140,20 @@ func NewIATable(minPort, maxPort int) IATable {
 var _ IATable = (*iaTable)(nil)
 
 type iaTable struct {
-	mtx     sync.RWMutex
-	ia      map[addr.IA]*Table
-	minPort int
-	maxPort int
+	mtx      sync.RWMutex
+	ia       map[addr.IA]*Table
+	policies map[addr.HostSVC]SVCPolicy
+	reserved overlay.PortRange
+	minPort  int
+	maxPort  int
 }
 
 func newIATable(minPort, maxPort int) *iaTable {
 	return &iaTable{
-		ia:      make(map[addr.IA]*Table),
-		minPort: minPort,
-		maxPort: maxPort,
+		ia:       make(map[addr.IA]*Table),
+		policies: make(map[addr.HostSVC]SVCPolicy),
+		minPort:  minPort,
+		maxPort:  maxPort,
 	}
 }
 
@@ -136,6 +171,11 @@ func (t *iaTable) Register(ia addr.IA, public *net.UDPAddr, bind net.IP, svc add
 	table, ok := t.ia[ia]
 	if !ok {
 		table = NewTable(t.minPort, t.maxPort)
+		for svc, policy := range t.policies {
+			// The policies were validated when they were set.
+			table.SetSVCPolicy(svc, policy)
+		}
+		table.SetReservedPorts(t.reserved)
 		t.ia[ia] = table
 	}
 	reference, err := table.Register(public, bind, svc, value)
@@ -160,12 +200,53 @@ func (t *iaTable) LookupPublic(ia addr.IA, public *net.UDPAddr) (interface{}, bo
 	return nil, false
 }
 
-func (t *iaTable) LookupService(ia addr.IA, svc addr.HostSVC, bind net.IP) []interface{} {
+func (t *iaTable) LookupService(ia addr.IA, svc addr.HostSVC, bind net.IP,
+	flow []byte) []interface{} {
+
+	// Least recently used lookups update the use counters of the entries, so
+	// they need the write lock. All other lookups only need the read lock.
+	if t.isLeastRecentlyUsed(svc) {
+		t.mtx.Lock()
+		defer t.mtx.Unlock()
+	} else {
+		t.mtx.RLock()
+		defer t.mtx.RUnlock()
+	}
+	if table, ok := t.ia[ia]; ok {
+		return table.LookupService(svc, bind, flow)
+	}
+	return nil
+}
+
+func (t *iaTable) isLeastRecentlyUsed(svc addr.HostSVC) bool {
 	t.mtx.RLock()
 	defer t.mtx.RUnlock()
-	if table, ok := t.ia[ia]; ok {
-		return table.LookupService(svc, bind)
+	return t.policies[svc] == SVCPolicyLeastRecentlyUsed
+}
+
+func (t *iaTable) SetSVCPolicy(svc addr.HostSVC, policy SVCPolicy) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if err := validateSVCPolicy(svc, policy); err != nil {
+		return err
 	}
+	for _, table := range t.ia {
+		table.SetSVCPolicy(svc, policy)
+	}
+	t.policies[svc] = policy
+	return nil
+}
+
+func (t *iaTable) SetReservedPorts(ports overlay.PortRange) error {
+	t.mtx.Lock()
+	defer t.mtx.Unlock()
+	if err := ports.Validate(); err != nil {
+		return err
+	}
+	for _, table := range t.ia {
+		table.SetReservedPorts(ports)
+	}
+	t.reserved = ports
 	return nil
 }
 
@@ -178,6 +259,29 @@ func (t *iaTable) LookupID(ia addr.IA, id uint64) (interface{}, bool) {
 	return nil, false
 }
 
+func (t *iaTable) Registrations() []Registration {
+	t.mtx.RLock()
+	defer t.mtx.RUnlock()
+	var regs []Registration
+	for ia, table := range t.ia {
+		for _, reg := range table.Registrations() {
+			reg.IA = ia
+			regs = append(regs, reg)
+		}
+	}
+	sort.Slice(regs, func(i, j int) bool {
+		a, b := regs[i], regs[j]
+		if a.IA != b.IA {
+			return a.IA.IAInt() < b.IA.IAInt()
+		}
+		if c := bytes.Compare(a.Public.IP.To16(), b.Public.IP.To16()); c != 0 {
+			return c < 0
+		}
+		return a.Public.Port < b.Public.Port
+	})
+	return regs
+}
+
 var _ RegReference = (*iaTableReference)(nil)
 
 type iaTableReference struct {
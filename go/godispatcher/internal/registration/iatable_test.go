@@ -21,6 +21,7 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/overlay"
 	"github.com/scionproto/scion/go/lib/xtest"
 )
 
@@ -41,7 +42,7 @@ func TestIATable(t *testing.T) {
 					SoMsg("value", retValue, ShouldEqual, value)
 				})
 				Convey("work correctly for SVC", func() {
-					retValues := table.LookupService(ia, addr.SvcCS, net.IP{192, 0, 2, 1})
+					retValues := table.LookupService(ia, addr.SvcCS, net.IP{192, 0, 2, 1}, nil)
 					So(retValues, ShouldBeEmpty)
 				})
 			})
@@ -53,7 +54,7 @@ func TestIATable(t *testing.T) {
 					SoMsg("value", retValue, ShouldBeNil)
 				})
 				Convey("work correctly for SVC", func() {
-					retValues := table.LookupService(otherIA, addr.SvcCS, net.IP{192, 0, 2, 1})
+					retValues := table.LookupService(otherIA, addr.SvcCS, net.IP{192, 0, 2, 1}, nil)
 					So(retValues, ShouldBeEmpty)
 				})
 			})
@@ -75,7 +76,7 @@ func TestIATable(t *testing.T) {
 					SoMsg("value", retValue, ShouldEqual, value)
 				})
 				Convey("work correctly for SVC", func() {
-					retValues := table.LookupService(ia, addr.SvcCS, net.IP{192, 0, 2, 1})
+					retValues := table.LookupService(ia, addr.SvcCS, net.IP{192, 0, 2, 1}, nil)
 					So(retValues, ShouldResemble, []interface{}{value})
 				})
 			})
@@ -93,6 +94,10 @@ func TestIATableRegister(t *testing.T) {
 			xtest.SoMsgErrorStr("err", err, ErrBadISD)
 			SoMsg("ref", ref, ShouldBeNil)
 		})
+		Convey("invalid reserved ports are error", func() {
+			err := table.SetReservedPorts(overlay.PortRange{Min: 2, Max: 1})
+			SoMsg("err", err, ShouldNotBeNil)
+		})
 		Convey("AS zero is error", func() {
 			ref, err := table.Register(addr.IA{I: 1, A: 0}, public, nil, addr.SvcNone, value)
 			xtest.SoMsgErrorStr("err", err, ErrBadAS)
@@ -112,6 +117,24 @@ func TestIATableRegister(t *testing.T) {
 				SoMsg("err", err, ShouldBeNil)
 				SoMsg("ref", ref, ShouldNotBeNil)
 			})
+			Convey("reserved ports will cause error", func() {
+				err := table.SetReservedPorts(overlay.PortRange{Min: 80, Max: 80})
+				xtest.FailOnErr(t, err)
+				ref, err := table.Register(ia, public, nil, addr.SvcNone, value)
+				xtest.SoMsgErrorStr("err", err, ErrReservedPort)
+				SoMsg("ref", ref, ShouldBeNil)
+			})
+			Convey("reserved ports apply to existing ASes", func() {
+				_, err := table.Register(ia, public, nil, addr.SvcNone, value)
+				xtest.FailOnErr(t, err)
+				reserved := overlay.PortRange{Min: uint16(minPort), Max: uint16(minPort)}
+				err = table.SetReservedPorts(reserved)
+				xtest.FailOnErr(t, err)
+				ref, err := table.Register(ia, &net.UDPAddr{IP: public.IP}, nil,
+					addr.SvcNone, value)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("port", ref.UDPAddr().Port, ShouldEqual, minPort+1)
+			})
 		})
 	})
 }
@@ -174,3 +197,57 @@ func TestIATableSCMPExistingRegistration(t *testing.T) {
 		})
 	})
 }
+
+func TestIATableRegistrations(t *testing.T) {
+	Convey("Given a table with registrations in two ASes", t, func() {
+		table := NewIATable(minPort, maxPort)
+		ia1 := xtest.MustParseIA("1-ff00:0:1")
+		ia2 := xtest.MustParseIA("1-ff00:0:2")
+		public := &net.UDPAddr{IP: net.IP{192, 0, 2, 1}, Port: 80}
+		otherPublic := &net.UDPAddr{IP: net.IP{192, 0, 2, 1}, Port: 81}
+		ref, err := table.Register(ia2, public, nil, addr.SvcNone, "a")
+		xtest.FailOnErr(t, err)
+		xtest.FailOnErr(t, ref.RegisterID(42))
+		_, err = table.Register(ia1, otherPublic, net.IP{192, 0, 2, 2}, addr.SvcCS, "b")
+		xtest.FailOnErr(t, err)
+		_, err = table.Register(ia1, public, nil, addr.SvcNone, "c")
+		xtest.FailOnErr(t, err)
+		Convey("the registrations are sorted by IA and public address", func() {
+			So(table.Registrations(), ShouldResemble, []Registration{
+				{IA: ia1, Public: public, SVC: addr.SvcNone, Value: "c"},
+				{IA: ia1, Public: otherPublic, Bind: net.IP{192, 0, 2, 2}, SVC: addr.SvcCS,
+					Value: "b"},
+				{IA: ia2, Public: public, SVC: addr.SvcNone, IDs: []uint64{42}, Value: "a"},
+			})
+		})
+		Convey("freed registrations are removed", func() {
+			ref.Free()
+			regs := table.Registrations()
+			So(regs, ShouldHaveLength, 2)
+			So(regs[0].IA, ShouldResemble, ia1)
+			So(regs[1].IA, ShouldResemble, ia1)
+		})
+	})
+}
+
+func TestIATableSVCPolicy(t *testing.T) {
+	Convey("Given a table with an SVC policy", t, func() {
+		table := NewIATable(minPort, maxPort)
+		err := table.SetSVCPolicy(addr.SvcCS, SVCPolicyBroadcast)
+		So(err, ShouldBeNil)
+		Convey("the policy applies to ASes registered later", func() {
+			ia := xtest.MustParseIA("1-ff00:0:1")
+			bind := net.IP{192, 0, 2, 1}
+			for _, port := range []int{80, 81} {
+				_, err := table.Register(ia, &net.UDPAddr{IP: bind, Port: port}, nil,
+					addr.SvcCS, port)
+				xtest.FailOnErr(t, err)
+			}
+			So(table.LookupService(ia, addr.SvcCS, bind, nil), ShouldHaveLength, 2)
+		})
+		Convey("invalid policies are rejected", func() {
+			err := table.SetSVCPolicy(addr.SvcNone, SVCPolicyBroadcast)
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
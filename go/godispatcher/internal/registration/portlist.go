@@ -52,6 +52,15 @@ func (l *portList) Get() interface{} {
 	return v.(*listItem).value
 }
 
+// Do calls f for each item in the list.
+func (l *portList) Do(f func(item *listItem)) {
+	l.list.Do(
+		func(p interface{}) {
+			f(p.(*listItem))
+		},
+	)
+}
+
 func (l *portList) Find(port int) bool {
 	var found bool
 	l.list.Do(
@@ -84,4 +93,7 @@ func (l *portList) Len() int {
 type listItem struct {
 	port  int
 	value interface{}
+	// lastUse is the lookup sequence number of the last time the item was
+	// selected by the least recently used policy. Zero means never.
+	lastUse uint64
 }
@@ -0,0 +1,63 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package registration
+
+import (
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// SVCPolicy determines which registrations receive a packet for an anycast
+// SVC address.
+type SVCPolicy string
+
+const (
+	// SVCPolicyRoundRobin delivers each packet to a single registration,
+	// selecting the registrations in turn. This is the default policy.
+	SVCPolicyRoundRobin SVCPolicy = "round_robin"
+	// SVCPolicyLeastRecentlyUsed delivers each packet to the registration
+	// that has not received a packet for the longest time. New registrations
+	// are selected first.
+	SVCPolicyLeastRecentlyUsed SVCPolicy = "least_recently_used"
+	// SVCPolicySourceHash delivers all packets from the same source to the
+	// same registration, using rendezvous hashing on the source address. If a
+	// registration is freed, only the sources that were mapped to it are
+	// remapped.
+	SVCPolicySourceHash SVCPolicy = "source_hash"
+	// SVCPolicyBroadcast delivers each packet to all registrations, like a
+	// multicast SVC address.
+	SVCPolicyBroadcast SVCPolicy = "broadcast"
+)
+
+// Validate returns an error if p is not a known policy. The empty policy is
+// valid, and is equivalent to SVCPolicyRoundRobin.
+func (p SVCPolicy) Validate() error {
+	switch p {
+	case "", SVCPolicyRoundRobin, SVCPolicyLeastRecentlyUsed, SVCPolicySourceHash,
+		SVCPolicyBroadcast:
+		return nil
+	default:
+		return common.NewBasicError(ErrBadSVCPolicy, nil, "policy", string(p))
+	}
+}
+
+// validateSVCPolicy returns an error if policy is not valid, or if svc is not
+// an anycast SVC address.
+func validateSVCPolicy(svc addr.HostSVC, policy SVCPolicy) error {
+	if svc == addr.SvcNone || svc.IsMulticast() {
+		return common.NewBasicError(ErrBadSVCPolicySVC, nil, "svc", svc)
+	}
+	return policy.Validate()
+}
@@ -16,11 +16,14 @@ package registration
 
 import (
 	"container/ring"
+	"encoding/binary"
 	"fmt"
+	"hash/fnv"
 	"net"
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/util"
 )
 
 // SVCTable tracks SVC registrations.
@@ -49,9 +52,10 @@ import (
 // router. The local dispatcher then anycasts between all local ports listening on that IP.
 //
 // For example, in the table above, anycasting to CS-10.2.3.4 can either go to
-// entry 10.2.3.4:10080 or 10.2.3.4:10081. Anycasts are chosen in round-robin
-// fashion; the round-robin distribution is not strict, and can get skewed due
-// to registrations and frees.
+// entry 10.2.3.4:10080 or 10.2.3.4:10081. By default, anycasts are chosen in
+// round-robin fashion; the round-robin distribution is not strict, and can get
+// skewed due to registrations and frees. Other selections can be configured
+// per SVC address with SetPolicy, see SVCPolicy.
 type SVCTable interface {
 	// Register adds a new entry for the select svc, IP address and port. Both
 	// IPv4 and IPv6 are supported. IP addresses 0.0.0.0 and :: are not
@@ -67,14 +71,21 @@ type SVCTable interface {
 	//
 	// If SVC is an anycast address, at most one entry is returned. The ip
 	// address is used in case to narrow down the set of possible entries. If
-	// multiple entries exist, one is selected arbitrarily.
+	// multiple entries exist, one is selected according to the policy of the
+	// SVC address. The flow key identifies the source of the packet, and is
+	// used by SVCPolicySourceHash; if it is nil, the entry is selected
+	// round-robin.
 	//
 	// Note that nil addresses are supported for anycasts (the address is then
 	// ignored), but support for this might be dropped in the future.
 	//
-	// If SVC is a multicast address, more than one entry can be returned. The
-	// ip address is ignored in this case.
-	Lookup(svc addr.HostSVC, ip net.IP) []interface{}
+	// If SVC is a multicast address, or the policy of the SVC address is
+	// SVCPolicyBroadcast, more than one entry can be returned. The ip address
+	// is ignored in this case.
+	Lookup(svc addr.HostSVC, ip net.IP, flow []byte) []interface{}
+	// SetPolicy sets the policy for anycasts to svc. The policy of multicast
+	// addresses cannot be set.
+	SetPolicy(svc addr.HostSVC, policy SVCPolicy) error
 	String() string
 }
 
@@ -85,12 +96,17 @@ func NewSVCTable() SVCTable {
 var _ SVCTable = (*svcTable)(nil)
 
 type svcTable struct {
-	m map[addr.HostSVC]unicastIpTable
+	m        map[addr.HostSVC]unicastIpTable
+	policies map[addr.HostSVC]SVCPolicy
+	// uses is the sequence number of the last lookup with the least recently
+	// used policy.
+	uses uint64
 }
 
 func newSvcTable() *svcTable {
 	return &svcTable{
-		m: make(map[addr.HostSVC]unicastIpTable),
+		m:        make(map[addr.HostSVC]unicastIpTable),
+		policies: make(map[addr.HostSVC]SVCPolicy),
 	}
 }
 
@@ -120,18 +136,30 @@ func (t *svcTable) Register(svc addr.HostSVC, address *net.UDPAddr,
 	}, nil
 }
 
-func (t *svcTable) Lookup(svc addr.HostSVC, ip net.IP) []interface{} {
+func (t *svcTable) Lookup(svc addr.HostSVC, ip net.IP, flow []byte) []interface{} {
 	var values []interface{}
-	if svc.IsMulticast() {
+	if svc.IsMulticast() || t.policies[svc] == SVCPolicyBroadcast {
 		values = t.multicast(svc)
 	} else {
-		if v, ok := t.anycast(svc, ip); ok {
+		if v, ok := t.anycast(svc, ip, flow); ok {
 			values = []interface{}{v}
 		}
 	}
 	return values
 }
 
+func (t *svcTable) SetPolicy(svc addr.HostSVC, policy SVCPolicy) error {
+	if err := validateSVCPolicy(svc, policy); err != nil {
+		return err
+	}
+	if policy == "" || policy == SVCPolicyRoundRobin {
+		delete(t.policies, svc)
+	} else {
+		t.policies[svc] = policy
+	}
+	return nil
+}
+
 func (t *svcTable) multicast(svc addr.HostSVC) []interface{} {
 	var values []interface{}
 	ipTable, ok := t.m[svc.Base()]
@@ -146,11 +174,27 @@ func (t *svcTable) multicast(svc addr.HostSVC) []interface{} {
 	return values
 }
 
-func (t *svcTable) anycast(svc addr.HostSVC, ip net.IP) (interface{}, bool) {
+func (t *svcTable) anycast(svc addr.HostSVC, ip net.IP, flow []byte) (interface{}, bool) {
 	ipTable, ok := t.m[svc]
 	if !ok {
 		return nil, false
 	}
+	switch t.policies[svc] {
+	case SVCPolicyLeastRecentlyUsed:
+		if candidates, ok := ipTable.candidates(ip); ok {
+			t.uses++
+			return candidates.leastRecentlyUsed(t.uses), true
+		}
+		return nil, false
+	case SVCPolicySourceHash:
+		if flow == nil {
+			break
+		}
+		if candidates, ok := ipTable.candidates(ip); ok {
+			return candidates.highestHash(flow), true
+		}
+		return nil, false
+	}
 	// XXX(scrye): This is a workaround s.t. a simple overlay socket
 	// that does not return IP-header information can still be used to
 	// deliver to SVC addresses. Once IP-header information is passed
@@ -230,6 +274,63 @@ func (t unicastIpTable) any() (*portList, bool) {
 	return nil, false
 }
 
+// candidates returns the subset of the table that contains the entries for
+// ip. If ip is nil, the whole table is returned. The boolean return value is
+// false if there are no entries for ip.
+func (t unicastIpTable) candidates(ip net.IP) (unicastIpTable, bool) {
+	if ip == nil {
+		return t, len(t) > 0
+	}
+	list, ok := t[ip.String()]
+	if !ok {
+		return nil, false
+	}
+	return unicastIpTable{ip.String(): list}, true
+}
+
+// leastRecentlyUsed returns the value of the entry with the oldest use, and
+// sets the use of the entry to use.
+func (t unicastIpTable) leastRecentlyUsed(use uint64) interface{} {
+	var lru *listItem
+	for _, list := range t {
+		list.Do(func(item *listItem) {
+			if lru == nil || item.lastUse < lru.lastUse {
+				lru = item
+			}
+		})
+	}
+	lru.lastUse = use
+	return lru.value
+}
+
+// highestHash returns the value of the entry with the highest rendezvous hash
+// for the flow key.
+func (t unicastIpTable) highestHash(flow []byte) interface{} {
+	var best *listItem
+	var bestHash uint64
+	for ip, list := range t {
+		list.Do(func(item *listItem) {
+			if h := rendezvousHash(flow, ip, item.port); best == nil || h > bestHash {
+				best, bestHash = item, h
+			}
+		})
+	}
+	return best.value
+}
+
+func rendezvousHash(flow []byte, ip string, port int) uint64 {
+	h := fnv.New64a()
+	h.Write(flow)
+	h.Write([]byte(ip))
+	var b [2]byte
+	binary.BigEndian.PutUint16(b[:], uint16(port))
+	h.Write(b[:])
+	// FNV-64a does not mix the last bytes into the high bits well, which
+	// skews the distribution of the rendezvous hashes of entries that only
+	// differ in the port.
+	return util.Mix64(h.Sum64())
+}
+
 var _ Reference = (*svcTableReference)(nil)
 
 type svcTableReference struct {
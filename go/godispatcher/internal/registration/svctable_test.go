@@ -32,15 +32,15 @@ func TestSVCTableEmpty(t *testing.T) {
 	Convey("Given an empty SVCTable", t, func() {
 		table := NewSVCTable()
 		Convey("Anycast to nil address, not found", func() {
-			retValues := table.Lookup(addr.SvcCS, nil)
+			retValues := table.Lookup(addr.SvcCS, nil, nil)
 			So(retValues, ShouldBeEmpty)
 		})
 		Convey("Anycast to some IPv4 address, not found", func() {
-			retValues := table.Lookup(addr.SvcCS, net.IP{10, 2, 3, 4})
+			retValues := table.Lookup(addr.SvcCS, net.IP{10, 2, 3, 4}, nil)
 			So(retValues, ShouldBeEmpty)
 		})
 		Convey("Multicast to some IPv4 address, not found", func() {
-			retValues := table.Lookup(addr.SvcCS.Multicast(), nil)
+			retValues := table.Lookup(addr.SvcCS.Multicast(), nil, nil)
 			So(retValues, ShouldBeEmpty)
 		})
 		Convey("Registering nil address fails", func() {
@@ -102,28 +102,28 @@ func TestSVCTableOneItem(t *testing.T) {
 			// that does not return IP-header information can still be used to
 			// deliver to SVC addresses. Once IP-header information is passed
 			// into the app, searching for nil should not return an entry.
-			retValues := table.Lookup(addr.SvcCS, nil)
+			retValues := table.Lookup(addr.SvcCS, nil, nil)
 			SoMsg("len", len(retValues), ShouldEqual, 1)
 		})
 		Convey("multicasting to nil finds the entry", func() {
 			// XXX(scrye): this is the same workaround as above
-			retValues := table.Lookup(addr.SvcCS.Multicast(), nil)
+			retValues := table.Lookup(addr.SvcCS.Multicast(), nil, nil)
 			SoMsg("values", retValues, ShouldResemble, []interface{}{value})
 		})
 		Convey("anycasting to a different IP does not find the entry", func() {
-			retValues := table.Lookup(addr.SvcCS, diffIpSamePortAddress.IP)
+			retValues := table.Lookup(addr.SvcCS, diffIpSamePortAddress.IP, nil)
 			So(retValues, ShouldBeEmpty)
 		})
 		Convey("anycasting to a different SVC does not find the entry", func() {
-			retValues := table.Lookup(addr.SvcPS, address.IP)
+			retValues := table.Lookup(addr.SvcPS, address.IP, nil)
 			So(retValues, ShouldBeEmpty)
 		})
 		Convey("anycasting to the same SVC and IP finds the entry", func() {
-			retValues := table.Lookup(addr.SvcCS, address.IP)
+			retValues := table.Lookup(addr.SvcCS, address.IP, nil)
 			SoMsg("values", retValues, ShouldResemble, []interface{}{value})
 		})
 		Convey("multicasting to the same SVC and IP finds the entry", func() {
-			retValues := table.Lookup(addr.SvcCS.Multicast(), nil)
+			retValues := table.Lookup(addr.SvcCS.Multicast(), nil, nil)
 			SoMsg("values", retValues, ShouldResemble, []interface{}{value})
 		})
 		Convey("Registering the same address and different port succeeds", func() {
@@ -138,7 +138,7 @@ func TestSVCTableOneItem(t *testing.T) {
 		})
 		Convey("Freeing the reference yields nil on anycast", func() {
 			reference.Free()
-			retValues := table.Lookup(addr.SvcCS, nil)
+			retValues := table.Lookup(addr.SvcCS, nil, nil)
 			So(retValues, ShouldBeEmpty)
 			Convey("And double free panics", func() {
 				So(reference.Free, ShouldPanic)
@@ -151,7 +151,7 @@ func TestSVCTableOneItem(t *testing.T) {
 		Convey("Adding a second address, anycasting to first one returns correct value", func() {
 			_, err := table.Register(addr.SvcCS, diffIpSamePortAddress, otherValue)
 			SoMsg("err", err, ShouldBeNil)
-			retValues := table.Lookup(addr.SvcCS, address.IP)
+			retValues := table.Lookup(addr.SvcCS, address.IP, nil)
 			SoMsg("values", retValues, ShouldResemble, []interface{}{value})
 		})
 	})
@@ -170,13 +170,13 @@ func TestSVCTableTwoItems(t *testing.T) {
 		_, err = table.Register(addr.SvcCS, sameIpDiffPortAddress, otherValue)
 		xtest.FailOnErr(t, err)
 		Convey("The anycasts will cycle between the values", func() {
-			retValues := table.Lookup(addr.SvcCS, address.IP)
+			retValues := table.Lookup(addr.SvcCS, address.IP, nil)
 			SoMsg("values", retValues, ShouldResemble, []interface{}{value})
-			otherRetValue := table.Lookup(addr.SvcCS, address.IP)
+			otherRetValue := table.Lookup(addr.SvcCS, address.IP, nil)
 			SoMsg("second values", otherRetValue, ShouldResemble, []interface{}{otherValue})
 		})
 		Convey("A multicast will return both values", func() {
-			retValues := table.Lookup(addr.SvcCS.Multicast(), address.IP)
+			retValues := table.Lookup(addr.SvcCS.Multicast(), address.IP, nil)
 			SoMsg("len", len(retValues), ShouldEqual, 2)
 		})
 	})
@@ -194,7 +194,7 @@ func TestSVCTableMulticastTwoAddresses(t *testing.T) {
 		_, err = table.Register(addr.SvcCS, diffAddress, otherValue)
 		xtest.FailOnErr(t, err)
 		Convey("A multicast will return both values", func() {
-			retValues := table.Lookup(addr.SvcCS.Multicast(), address.IP)
+			retValues := table.Lookup(addr.SvcCS.Multicast(), address.IP, nil)
 			sort.Slice(retValues, func(i, j int) bool {
 				return retValues[i].(string) < retValues[j].(string)
 			})
@@ -240,52 +240,52 @@ func TestSVCTableFree(t *testing.T) {
 			xtest.FailOnErr(t, err)
 			Convey("if the second address is removed, 1 and 3 should stay", func() {
 				refTwo.Free()
-				retValues := table.Lookup(addr.SvcCS.Multicast(), ip)
+				retValues := table.Lookup(addr.SvcCS.Multicast(), ip, nil)
 				sort.Slice(retValues, func(i, j int) bool {
 					return retValues[i].(string) < retValues[j].(string)
 				})
 				So(retValues, ShouldResemble, []interface{}{"1", "3"})
 				Convey("anycasting cycles between addresses one and three", func() {
 					checkAnyCastCycles(t,
-						func() []interface{} { return table.Lookup(addr.SvcCS, ip) },
+						func() []interface{} { return table.Lookup(addr.SvcCS, ip, nil) },
 						[]string{"1", "3"})
 				})
 			})
 			Convey("if the first address is removed, 2 and 3 should stay", func() {
 				refOne.Free()
-				retValues := table.Lookup(addr.SvcCS.Multicast(), ip)
+				retValues := table.Lookup(addr.SvcCS.Multicast(), ip, nil)
 				sort.Slice(retValues, func(i, j int) bool {
 					return retValues[i].(string) < retValues[j].(string)
 				})
 				So(retValues, ShouldResemble, []interface{}{"2", "3"})
 				Convey("anycasting cycles between addresses two and three", func() {
 					checkAnyCastCycles(t,
-						func() []interface{} { return table.Lookup(addr.SvcCS, ip) },
+						func() []interface{} { return table.Lookup(addr.SvcCS, ip, nil) },
 						[]string{"2", "3"})
 				})
 			})
 			Convey("if the third address is removed, 1 and 2 should stay", func() {
 				refThree.Free()
-				retValues := table.Lookup(addr.SvcCS.Multicast(), ip)
+				retValues := table.Lookup(addr.SvcCS.Multicast(), ip, nil)
 				sort.Slice(retValues, func(i, j int) bool {
 					return retValues[i].(string) < retValues[j].(string)
 				})
 				So(retValues, ShouldResemble, []interface{}{"1", "2"})
 				Convey("anycasting cycles between addresses one and two", func() {
 					checkAnyCastCycles(t,
-						func() []interface{} { return table.Lookup(addr.SvcCS, ip) },
+						func() []interface{} { return table.Lookup(addr.SvcCS, ip, nil) },
 						[]string{"1", "2"})
 				})
 				Convey("removing the 1st as well should only leave 2", func() {
 					refOne.Free()
-					retValues := table.Lookup(addr.SvcCS.Multicast(), ip)
+					retValues := table.Lookup(addr.SvcCS.Multicast(), ip, nil)
 					sort.Slice(retValues, func(i, j int) bool {
 						return retValues[i].(string) < retValues[j].(string)
 					})
 					So(retValues, ShouldResemble, []interface{}{"2"})
 					Convey("anycasting cycles between addresses two", func() {
 						checkAnyCastCycles(t,
-							func() []interface{} { return table.Lookup(addr.SvcCS, ip) },
+							func() []interface{} { return table.Lookup(addr.SvcCS, ip, nil) },
 							[]string{"2"})
 					})
 				})
@@ -294,6 +294,113 @@ func TestSVCTableFree(t *testing.T) {
 	})
 }
 
+func TestSVCTablePolicies(t *testing.T) {
+	Convey("Given a table with three entries on two IPs", t, func() {
+		ip := net.IP{10, 2, 3, 4}
+		table := NewSVCTable()
+		refs := make(map[interface{}]Reference)
+		for value, address := range map[string]*net.UDPAddr{
+			"1": {IP: ip, Port: 10080},
+			"2": {IP: ip, Port: 10081},
+			"3": {IP: net.IP{10, 2, 3, 5}, Port: 10080},
+		} {
+			ref, err := table.Register(addr.SvcCS, address, value)
+			xtest.FailOnErr(t, err)
+			refs[value] = ref
+		}
+		Convey("least recently used visits all entries before repeating", func() {
+			xtest.FailOnErr(t, table.SetPolicy(addr.SvcCS, SVCPolicyLeastRecentlyUsed))
+			var retValues []interface{}
+			for i := 0; i < 3; i++ {
+				retValues = append(retValues, table.Lookup(addr.SvcCS, nil, nil)...)
+			}
+			sortStrings(retValues)
+			So(retValues, ShouldResemble, []interface{}{"1", "2", "3"})
+			Convey("and is restricted to the IP", func() {
+				for i := 0; i < 3; i++ {
+					So(table.Lookup(addr.SvcCS, ip, nil)[0], ShouldBeIn, []interface{}{"1", "2"})
+				}
+			})
+		})
+		Convey("source hash selects the same entry for the same flow", func() {
+			xtest.FailOnErr(t, table.SetPolicy(addr.SvcCS, SVCPolicySourceHash))
+			flow := []byte("flow")
+			retValues := table.Lookup(addr.SvcCS, nil, flow)
+			So(retValues, ShouldHaveLength, 1)
+			for i := 0; i < 5; i++ {
+				So(table.Lookup(addr.SvcCS, nil, flow), ShouldResemble, retValues)
+			}
+			Convey("freeing other entries does not remap the flow", func() {
+				for value, ref := range refs {
+					if value != retValues[0] {
+						ref.Free()
+					}
+				}
+				So(table.Lookup(addr.SvcCS, nil, flow), ShouldResemble, retValues)
+			})
+			Convey("freeing the entry remaps the flow", func() {
+				refs[retValues[0]].Free()
+				retValue := table.Lookup(addr.SvcCS, nil, flow)[0]
+				So(retValue, ShouldNotEqual, retValues[0])
+				So(table.Lookup(addr.SvcCS, nil, flow)[0], ShouldEqual, retValue)
+			})
+			Convey("and selects an entry without flow", func() {
+				So(table.Lookup(addr.SvcCS, nil, nil), ShouldHaveLength, 1)
+			})
+		})
+		Convey("broadcast selects all entries", func() {
+			xtest.FailOnErr(t, table.SetPolicy(addr.SvcCS, SVCPolicyBroadcast))
+			retValues := table.Lookup(addr.SvcCS, ip, nil)
+			sortStrings(retValues)
+			So(retValues, ShouldResemble, []interface{}{"1", "2", "3"})
+		})
+		Convey("round robin cycles between the entries of the IP", func() {
+			xtest.FailOnErr(t, table.SetPolicy(addr.SvcCS, SVCPolicyRoundRobin))
+			checkAnyCastCycles(t,
+				func() []interface{} { return table.Lookup(addr.SvcCS, ip, nil) },
+				[]string{"1", "2"})
+		})
+		Convey("policies for multicast addresses are rejected", func() {
+			err := table.SetPolicy(addr.SvcCS.Multicast(), SVCPolicyBroadcast)
+			So(err, ShouldNotBeNil)
+		})
+		Convey("unknown policies are rejected", func() {
+			err := table.SetPolicy(addr.SvcCS, SVCPolicy("random"))
+			So(err, ShouldNotBeNil)
+		})
+	})
+}
+
+func TestSVCTableSourceHashDistribution(t *testing.T) {
+	Convey("Given a table with four entries that only differ in the port", t, func() {
+		ip := net.IP{10, 2, 3, 4}
+		table := NewSVCTable()
+		for i := 0; i < 4; i++ {
+			_, err := table.Register(addr.SvcCS, &net.UDPAddr{IP: ip, Port: 10080 + i}, i)
+			xtest.FailOnErr(t, err)
+		}
+		xtest.FailOnErr(t, table.SetPolicy(addr.SvcCS, SVCPolicySourceHash))
+		Convey("source hash spreads the flows evenly over the entries", func() {
+			flows := 10000
+			counts := make(map[interface{}]int)
+			for i := 0; i < flows; i++ {
+				flow := []byte{10, 0, byte(i >> 8), byte(i), 0x75, 0x30}
+				counts[table.Lookup(addr.SvcCS, nil, flow)[0]]++
+			}
+			So(counts, ShouldHaveLength, 4)
+			for _, count := range counts {
+				So(count, ShouldBeBetween, flows/4*9/10, flows/4*11/10)
+			}
+		})
+	})
+}
+
+func sortStrings(values []interface{}) {
+	sort.Slice(values, func(i, j int) bool {
+		return values[i].(string) < values[j].(string)
+	})
+}
+
 func checkAnyCastCycles(t *testing.T, lookup func() []interface{}, expected []string) {
 	t.Helper()
 	firstRes := lookup()[0].(string)
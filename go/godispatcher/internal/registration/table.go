@@ -19,6 +19,7 @@ import (
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
 )
 
 // Table manages the UDP/IP port registrations for a single AS.
@@ -36,6 +37,8 @@ type Table struct {
 	// e.g., if apps start with an ID of 1 and increment from there). We should
 	// revisit if SCMP General IDs should be scoped to IPs.
 	scmpTable *SCMPTable
+	// refs contains the references of all registrations in the table.
+	refs map[*TableReference]struct{}
 }
 
 func NewTable(minPort, maxPort int) *Table {
@@ -43,6 +46,7 @@ func NewTable(minPort, maxPort int) *Table {
 		udpPortTable: NewUDPPortTable(minPort, maxPort),
 		svcTable:     NewSVCTable(),
 		scmpTable:    NewSCMPTable(),
+		refs:         make(map[*TableReference]struct{}),
 	}
 }
 
@@ -68,7 +72,12 @@ func (t *Table) Register(public *net.UDPAddr, bind net.IP, svc addr.HostSVC,
 		return nil, err
 	}
 	t.size++
-	return &TableReference{table: t, address: address, svcRef: svcRef}, nil
+	ref := &TableReference{table: t, address: address, svcRef: svcRef, svc: svc, value: value}
+	if svc != addr.SvcNone {
+		ref.bind = copyIPAddr(bind)
+	}
+	t.refs[ref] = struct{}{}
+	return ref, nil
 }
 
 func (t *Table) insertSVCIfRequested(svc addr.HostSVC, bind net.IP, port int,
@@ -88,14 +97,33 @@ func (t *Table) LookupPublic(address *net.UDPAddr) (interface{}, bool) {
 	return t.udpPortTable.Lookup(address)
 }
 
-func (t *Table) LookupService(svc addr.HostSVC, bind net.IP) []interface{} {
-	return t.svcTable.Lookup(svc, bind)
+func (t *Table) LookupService(svc addr.HostSVC, bind net.IP, flow []byte) []interface{} {
+	return t.svcTable.Lookup(svc, bind, flow)
+}
+
+func (t *Table) SetSVCPolicy(svc addr.HostSVC, policy SVCPolicy) error {
+	return t.svcTable.SetPolicy(svc, policy)
+}
+
+// SetReservedPorts reserves ports, see UDPPortTable.SetReservedPorts.
+func (t *Table) SetReservedPorts(ports overlay.PortRange) {
+	t.udpPortTable.SetReservedPorts(ports)
 }
 
 func (t *Table) Size() int {
 	return t.size
 }
 
+// Registrations returns a snapshot of the registrations in the table. The IA
+// of the returned registrations is not set.
+func (t *Table) Registrations() []Registration {
+	regs := make([]Registration, 0, len(t.refs))
+	for ref := range t.refs {
+		regs = append(regs, ref.registration())
+	}
+	return regs
+}
+
 func (t *Table) LookupID(id uint64) (interface{}, bool) {
 	return t.scmpTable.Lookup(id)
 }
@@ -114,6 +142,9 @@ type TableReference struct {
 	address *net.UDPAddr
 	svcRef  Reference
 	ids     []uint64
+	bind    net.IP
+	svc     addr.HostSVC
+	value   interface{}
 }
 
 func (r *TableReference) Free() {
@@ -126,6 +157,7 @@ func (r *TableReference) Free() {
 		r.svcRef.Free()
 	}
 	r.table.size--
+	delete(r.table.refs, r)
 	for _, id := range r.ids {
 		r.table.removeID(id)
 	}
@@ -142,3 +174,16 @@ func (r *TableReference) RegisterID(id uint64, value interface{}) error {
 	r.ids = append(r.ids, id)
 	return nil
 }
+
+func (r *TableReference) registration() Registration {
+	reg := Registration{
+		Public: &net.UDPAddr{IP: r.address.IP, Port: r.address.Port},
+		Bind:   r.bind,
+		SVC:    r.svc,
+		Value:  r.value,
+	}
+	if len(r.ids) > 0 {
+		reg.IDs = append([]uint64(nil), r.ids...)
+	}
+	return reg
+}
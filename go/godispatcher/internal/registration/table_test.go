@@ -160,7 +160,7 @@ func TestRegisterPublicAndSVC(t *testing.T) {
 			SoMsg("value", retValue, ShouldEqual, value)
 		})
 		Convey("SVC lookup is successful (bind inherits from public)", func() {
-			retValues := table.LookupService(addr.SvcCS, public.IP)
+			retValues := table.LookupService(addr.SvcCS, public.IP, nil)
 			So(retValues, ShouldResemble, []interface{}{value})
 		})
 	})
@@ -183,11 +183,11 @@ func TestRegisterWithBind(t *testing.T) {
 			SoMsg("value", retValue, ShouldEqual, value)
 		})
 		Convey("SVC lookup is successful", func() {
-			retValues := table.LookupService(addr.SvcCS, bind)
+			retValues := table.LookupService(addr.SvcCS, bind, nil)
 			So(retValues, ShouldResemble, []interface{}{value})
 		})
 		Convey("Bind lookup on different svc fails", func() {
-			retValues := table.LookupService(addr.SvcBS, bind)
+			retValues := table.LookupService(addr.SvcBS, bind, nil)
 			So(retValues, ShouldBeEmpty)
 		})
 		Convey("Colliding binds return error, and public port is released", func() {
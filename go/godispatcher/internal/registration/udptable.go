@@ -19,6 +19,7 @@ import (
 	"net"
 
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
 )
 
 // UDPPortTable stores port allocations for UDP/IPv4 and UDP/IPv6 sockets.
@@ -28,6 +29,8 @@ type UDPPortTable struct {
 	v4PortTable map[int]IPTable
 	v6PortTable map[int]IPTable
 	allocator   *UDPPortAllocator
+	// reserved are the ports that are neither allocated nor inserted.
+	reserved overlay.PortRange
 }
 
 func NewUDPPortTable(minPort, maxPort int) *UDPPortTable {
@@ -70,9 +73,19 @@ func (t *UDPPortTable) overlapsWith(address *net.UDPAddr) bool {
 	return ipTable.OverlapsWith(address.IP)
 }
 
+// SetReservedPorts reserves ports. Reserved ports are not allocated, and
+// inserting an address with a reserved port returns an error.
+func (t *UDPPortTable) SetReservedPorts(ports overlay.PortRange) {
+	t.reserved = ports
+}
+
 // Insert adds address into the allocation table. It will return an error if an
-// entry overlaps, or if the value is nil.
+// entry overlaps, if the port is reserved, or if the value is nil.
 func (t *UDPPortTable) Insert(address *net.UDPAddr, value interface{}) (*net.UDPAddr, error) {
+	if address.Port != 0 && t.reserved.Contains(uint16(address.Port)) {
+		return nil, common.NewBasicError(ErrReservedPort, nil, "address", address,
+			"reserved", t.reserved)
+	}
 	if t.overlapsWith(address) {
 		return nil, common.NewBasicError(ErrOverlappingAddress, nil, "address", address)
 	}
@@ -193,8 +206,8 @@ func NewUDPPortAllocator(min, max int) *UDPPortAllocator {
 	}
 }
 
-// Allocate returns the next available port for the IP address. It will panic
-// if it runs out of ports.
+// Allocate returns the next available port for the IP address. Ports reserved
+// in t are skipped. It will panic if it runs out of ports.
 func (a *UDPPortAllocator) Allocate(ip net.IP, t *UDPPortTable) (int, error) {
 	for i := a.minPort; i < a.maxPort+1; i++ {
 		candidate := &net.UDPAddr{
@@ -205,7 +218,7 @@ func (a *UDPPortAllocator) Allocate(ip net.IP, t *UDPPortTable) (int, error) {
 		if a.nextPort == a.maxPort+1 {
 			a.nextPort = a.minPort
 		}
-		if !t.overlapsWith(candidate) {
+		if !t.reserved.Contains(uint16(candidate.Port)) && !t.overlapsWith(candidate) {
 			return candidate.Port, nil
 		}
 	}
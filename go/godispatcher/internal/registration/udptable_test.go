@@ -20,6 +20,9 @@ import (
 	"testing"
 
 	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/xtest"
 )
 
 var docIPv6AddressStr = "2001:db8::1"
@@ -132,6 +135,13 @@ func TestUDPPortTableInsert(t *testing.T) {
 				SoMsg("err", err, ShouldBeNil)
 				SoMsg("address", retAddress, ShouldResemble, expectedAddress)
 			})
+			Convey("Inserting an address with a reserved port is not permitted", func() {
+				table.SetReservedPorts(overlay.PortRange{Min: 10000, Max: 10999})
+				address := &net.UDPAddr{IP: net.IP{10, 2, 3, 4}, Port: 10080}
+				retAddress, err := table.Insert(address, value)
+				xtest.SoMsgErrorStr("err", err, ErrReservedPort)
+				SoMsg("address", retAddress, ShouldBeNil)
+			})
 			Convey("Inserting an address without a value is not permitted", func() {
 				address := &net.UDPAddr{IP: net.IP{10, 2, 3, 4}, Port: 10080}
 				retAddress, err := table.Insert(address, nil)
@@ -222,6 +232,12 @@ func TestUDPPortAllocator(t *testing.T) {
 				SoMsg("port", port, ShouldEqual, 1001)
 				SoMsg("err", err, ShouldBeNil)
 			})
+			Convey("if table reserves the first ports, first allocation skips them", func() {
+				table.SetReservedPorts(overlay.PortRange{Min: 1000, Max: 1009})
+				port, err := allocator.Allocate(address, table)
+				SoMsg("port", port, ShouldEqual, 1010)
+				SoMsg("err", err, ShouldBeNil)
+			})
 		})
 		Convey("Given an allocator with few ports", func() {
 			allocator := NewUDPPortAllocator(1, 3)
@@ -245,6 +261,16 @@ func TestUDPPortAllocator(t *testing.T) {
 					SoMsg("err", err, ShouldBeNil)
 				})
 			})
+			Convey("if all free ports are reserved, error", func() {
+				table := testUDPTableWithPorts(
+					map[int]IPTable{
+						1: {"0.0.0.0": value},
+					}, nil)
+				table.SetReservedPorts(overlay.PortRange{Min: 2, Max: 3})
+				port, err := allocator.Allocate(address, table)
+				SoMsg("port", port, ShouldEqual, 0)
+				xtest.SoMsgErrorStr("err", err, ErrNoPorts)
+			})
 			Convey("if all ports are taken, error", func() {
 				table := testUDPTableWithPorts(
 					map[int]IPTable{
@@ -133,6 +133,16 @@ func (pkt *Packet) SendOnConn(conn net.PacketConn, address net.Addr) (int, error
 	return conn.WriteTo(pkt.buffer, address)
 }
 
+// Raw returns the raw packet. Callers must not modify it.
+func (pkt *Packet) Raw() common.RawBytes {
+	return pkt.buffer
+}
+
+// Len returns the length of the raw packet.
+func (pkt *Packet) Len() int {
+	return len(pkt.buffer)
+}
+
 func (pkt *Packet) reset() {
 	pkt.buffer = pkt.buffer[:cap(pkt.buffer)]
 	pkt.Info = spkt.ScnPkt{}
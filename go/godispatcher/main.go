@@ -27,6 +27,7 @@ import (
 	"github.com/scionproto/scion/go/godispatcher/internal/config"
 	"github.com/scionproto/scion/go/godispatcher/internal/metrics"
 	"github.com/scionproto/scion/go/godispatcher/network"
+	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/env"
 	"github.com/scionproto/scion/go/lib/fatal"
 	"github.com/scionproto/scion/go/lib/log"
@@ -65,12 +66,26 @@ func realMain() int {
 		return 1
 	}
 
+	routingTable := network.NewIATable(1024, 65535)
+	if err := routingTable.SetReservedPorts(cfg.Dispatcher.DirectPorts); err != nil {
+		log.Crit("Unable to reserve direct ports", "err", err)
+		return 1
+	}
+	for svc, policy := range cfg.Dispatcher.SVCPolicies {
+		if err := routingTable.SetSVCPolicy(addr.HostSVCFromString(svc), policy); err != nil {
+			log.Crit("Unable to set SVC policy", "svc", svc, "err", err)
+			return 1
+		}
+	}
+	// The status is served by the pprof and prometheus HTTP servers.
+	http.HandleFunc("/registrations", routingTable.ServeRegistrations)
 	go func() {
 		defer log.LogPanicAndExit()
 		err := RunDispatcher(
 			cfg.Dispatcher.DeleteSocket,
 			cfg.Dispatcher.ApplicationSocket,
 			cfg.Dispatcher.OverlayPort,
+			routingTable,
 		)
 		if err != nil {
 			fatal.Fatal(err)
@@ -120,16 +135,19 @@ func setupBasic() error {
 	return env.LogAppStarted("Dispatcher", cfg.Dispatcher.ID)
 }
 
-func RunDispatcher(deleteSocketFlag bool, applicationSocket string, overlayPort int) error {
+func RunDispatcher(deleteSocketFlag bool, applicationSocket string, overlayPort int,
+	routingTable *network.IATable) error {
+
 	if deleteSocketFlag {
 		if err := deleteSocket(cfg.Dispatcher.ApplicationSocket); err != nil {
 			return err
 		}
 	}
 	dispatcher := &network.Dispatcher{
-		RoutingTable:      network.NewIATable(1024, 65535),
+		RoutingTable:      routingTable,
 		OverlaySocket:     fmt.Sprintf(":%d", overlayPort),
 		ApplicationSocket: applicationSocket,
+		Limits:            cfg.Dispatcher.Limits,
 	}
 	log.Debug("Dispatcher starting", "appSocket", applicationSocket, "overlayPort", overlayPort)
 	return dispatcher.ListenAndServe()
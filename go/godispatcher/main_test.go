@@ -25,6 +25,7 @@ import (
 	"time"
 
 	"github.com/scionproto/scion/go/godispatcher/internal/metrics"
+	"github.com/scionproto/scion/go/godispatcher/network"
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/hpkt"
@@ -423,21 +424,39 @@ func TestDataplaneIntegration(t *testing.T) {
 	settings := InitTestSettings(t)
 
 	go func() {
-		err := RunDispatcher(false, settings.ApplicationSocket, settings.OverlayPort)
+		err := RunDispatcher(false, settings.ApplicationSocket, settings.OverlayPort,
+			network.NewIATable(1024, 65535))
 		xtest.FailOnErr(t, err, "dispatcher error")
 	}()
 	time.Sleep(defaultWaitDuration)
 
 	for _, tc := range testCases {
 		t.Run(tc.Name, func(t *testing.T) {
-			RunTestCase(t, tc, settings)
+			RunTestCase(t, tc, settings, 0)
+		})
+		time.Sleep(defaultWaitDuration)
+	}
+	for _, tc := range testCases {
+		t.Run(tc.Name+" (v2 SEQPACKET)", func(t *testing.T) {
+			RunTestCase(t, tc, settings, reliable.V2SeqPacket)
 		})
 		time.Sleep(defaultWaitDuration)
 	}
 }
 
-func RunTestCase(t *testing.T, tc *TestCase, settings *TestSettings) {
-	conn, _, err := reliable.RegisterTimeout(
+// RunTestCase runs tc. If flags is not zero, the client registers with
+// protocol version 2 and the flags.
+func RunTestCase(t *testing.T, tc *TestCase, settings *TestSettings, flags reliable.V2Flags) {
+	register := reliable.RegisterTimeout
+	if flags != 0 {
+		register = func(dispatcher string, ia addr.IA, public *addr.AppAddr,
+			bind *overlay.OverlayAddr, svc addr.HostSVC,
+			timeout time.Duration) (*reliable.Conn, uint16, error) {
+
+			return reliable.RegisterV2(dispatcher, ia, public, bind, svc, timeout, flags)
+		}
+	}
+	conn, _, err := register(
 		settings.ApplicationSocket,
 		tc.ClientAddress.IA,
 		&addr.AppAddr{L3: tc.ClientAddress.PublicAddress, L4: tc.ClientAddress.PublicPort},
@@ -446,6 +465,9 @@ func RunTestCase(t *testing.T, tc *TestCase, settings *TestSettings) {
 		defaultTimeout,
 	)
 	xtest.FailOnErr(t, err, "unable to open socket")
+	if (flags&reliable.V2SeqPacket) != 0 && !conn.SeqPacket() {
+		t.Fatalf("SEQPACKET socket not negotiated")
+	}
 	// Always destroy the connection s.t. future tests aren't compromised by a
 	// fatal in this subtest
 	defer conn.Close()
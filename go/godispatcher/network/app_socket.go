@@ -18,7 +18,10 @@ import (
 	"fmt"
 	"io"
 	"net"
+	"sync/atomic"
+	"syscall"
 
+	"github.com/scionproto/scion/go/godispatcher/internal/limits"
 	"github.com/scionproto/scion/go/godispatcher/internal/metrics"
 	"github.com/scionproto/scion/go/godispatcher/internal/registration"
 	"github.com/scionproto/scion/go/godispatcher/internal/respool"
@@ -32,6 +35,10 @@ import (
 	"github.com/scionproto/scion/go/lib/spkt"
 )
 
+// appBatchSize is the maximum number of packets written to an application
+// socket at once.
+const appBatchSize = 32
+
 // AppSocketServer accepts new connections coming from SCION apps, and
 // hands them off to the registration + dataplane handler.
 type AppSocketServer struct {
@@ -59,6 +66,8 @@ type AppConnManager struct {
 	// IPv6OverlayConn is the network connection to which IPv6 egress traffic
 	// is sent.
 	IPv6OverlayConn net.PacketConn
+	// Limits are the rules that limit the traffic of registered applications.
+	Limits limits.Rules
 }
 
 // Handle passes conn off to a per-connection state handler.
@@ -68,6 +77,7 @@ func (h *AppConnManager) Handle(conn net.PacketConn) {
 		RoutingTable:    h.RoutingTable,
 		IPv4OverlayConn: h.IPv4OverlayConn,
 		IPv6OverlayConn: h.IPv6OverlayConn,
+		Limits:          h.Limits,
 		Logger:          log.Root().New("clientID", fmt.Sprintf("%p", conn)),
 	}
 	go func() {
@@ -87,13 +97,16 @@ type AppConnHandler struct {
 	// IPv6OverlayConn is the network connection to which egress IPv6 traffic
 	// is sent.
 	IPv6OverlayConn net.PacketConn
-	Logger          log.Logger
+	// Limits are the rules that limit the traffic of the application.
+	Limits limits.Rules
+	Logger log.Logger
 }
 
 func (h *AppConnHandler) Handle() {
 	h.Logger.Info("Accepted new client")
 	defer h.Logger.Info("Closed client socket")
-	defer h.Conn.Close()
+	// The registration exchange can replace the connection, see confirm.
+	defer func() { h.Conn.Close() }()
 
 	ref, tableEntry, useIPv6, err := h.doRegExchange()
 	if err != nil {
@@ -107,14 +120,14 @@ func (h *AppConnHandler) Handle() {
 	defer tableEntry.appIngressRing.Close()
 	go func() {
 		defer log.LogPanicAndExit()
-		h.RunRingToAppDataplane(tableEntry.appIngressRing)
+		h.RunRingToAppDataplane(tableEntry)
 	}()
 
 	conn := h.IPv4OverlayConn
 	if useIPv6 {
 		conn = h.IPv6OverlayConn
 	}
-	h.RunAppToNetDataplane(ref, conn)
+	h.RunAppToNetDataplane(ref, tableEntry, conn)
 }
 
 // doRegExchange manages an application's registration request, and returns a
@@ -144,19 +157,61 @@ func (h *AppConnHandler) doRegExchange() (registration.RegReference, *TableEntry
 
 	udpRef := ref.(registration.RegReference)
 	port := uint16(udpRef.UDPAddr().Port)
-	if err := h.sendConfirmation(b, &reliable.Confirmation{Port: port}); err != nil {
+	h.setLimits(tableEntry, port, regInfo.SVCAddress)
+	confirmation := &reliable.Confirmation{Port: port}
+	if regInfo.Version >= reliable.ProtocolV2 {
+		confirmation.Version = reliable.ProtocolV2
+		confirmation.Flags = regInfo.Flags & reliable.V2SupportedFlags
+	}
+	if err := h.confirm(b, confirmation); err != nil {
 		// Need to release stale state from the table
 		ref.Free()
 		return nil, nil, false, common.NewBasicError("confirmation message error", nil, "err", err)
 	}
 	h.logRegistration(regInfo.IA, udpRef.UDPAddr(), getBindIP(regInfo.BindAddress),
-		regInfo.SVCAddress)
+		regInfo.SVCAddress, confirmation)
 	isIPv6 := regInfo.PublicAddress.IP.To4() == nil
 	return udpRef, tableEntry, isIPv6, nil
 }
 
+// setLimits limits the traffic of the entry according to the first rule that
+// matches the registration.
+func (h *AppConnHandler) setLimits(entry *TableEntry, port uint16, svc addr.HostSVC) {
+	if len(h.Limits) == 0 {
+		entry.setLimiter(nil)
+		return
+	}
+	reg := limits.Registration{Port: port, SVC: svc, Cred: peerCred(h.Conn)}
+	rule := h.Limits.Match(reg)
+	entry.setLimiter(rule)
+	if rule != nil {
+		h.Logger.Debug("Client traffic limited", "rule", rule)
+	}
+}
+
+// peerCred returns the credentials of the process connected to conn, or nil
+// if they cannot be read.
+func peerCred(conn net.PacketConn) *limits.Cred {
+	sc, ok := conn.(syscall.Conn)
+	if !ok {
+		return nil
+	}
+	raw, err := sc.SyscallConn()
+	if err != nil {
+		return nil
+	}
+	var ucred *syscall.Ucred
+	err = raw.Control(func(fd uintptr) {
+		ucred, err = syscall.GetsockoptUcred(int(fd), syscall.SOL_SOCKET, syscall.SO_PEERCRED)
+	})
+	if err != nil || ucred == nil {
+		return nil
+	}
+	return &limits.Cred{PID: ucred.Pid, UID: ucred.Uid, GID: ucred.Gid}
+}
+
 func (h *AppConnHandler) logRegistration(ia addr.IA, public *net.UDPAddr, bind net.IP,
-	svc addr.HostSVC) {
+	svc addr.HostSVC, c *reliable.Confirmation) {
 
 	items := []interface{}{"ia", ia, "public", public}
 	if bind != nil {
@@ -165,6 +220,9 @@ func (h *AppConnHandler) logRegistration(ia addr.IA, public *net.UDPAddr, bind n
 	if svc != addr.SvcNone {
 		items = append(items, "svc", svc)
 	}
+	if c.Version >= reliable.ProtocolV2 {
+		items = append(items, "version", c.Version, "flags", c.Flags)
+	}
 	h.Logger.Info("Client registered address", items...)
 }
 
@@ -182,6 +240,27 @@ func (h *AppConnHandler) recvRegistration(b common.RawBytes) (*reliable.Registra
 	return &rm, nil
 }
 
+// confirm sends the confirmation c to the application. If c grants a
+// SEQPACKET data socket, the connection of the handler is replaced by the data
+// socket, and the registration socket is closed.
+func (h *AppConnHandler) confirm(b common.RawBytes, c *reliable.Confirmation) error {
+	conn, ok := h.Conn.(*reliable.Conn)
+	if !ok {
+		// Data sockets can only be passed on UNIX sockets.
+		c.Flags &^= reliable.V2SeqPacket
+	}
+	if (c.Flags & reliable.V2SeqPacket) == 0 {
+		return h.sendConfirmation(b, c)
+	}
+	dataConn, err := conn.Confirm(c)
+	if err != nil {
+		return err
+	}
+	conn.Close()
+	h.Conn = dataConn
+	return nil
+}
+
 func (h *AppConnHandler) sendConfirmation(b common.RawBytes, c *reliable.Confirmation) error {
 	n, err := c.SerializeTo(b)
 	if err != nil {
@@ -198,7 +277,7 @@ func (h *AppConnHandler) sendConfirmation(b common.RawBytes, c *reliable.Confirm
 // RunAppToNetDataplane moves packets from the application's socket to the
 // overlay socket.
 func (h *AppConnHandler) RunAppToNetDataplane(ref registration.RegReference,
-	ovConn net.PacketConn) {
+	entry *TableEntry, ovConn net.PacketConn) {
 
 	for {
 		pkt := respool.GetPacket()
@@ -215,6 +294,11 @@ func (h *AppConnHandler) RunAppToNetDataplane(ref registration.RegReference,
 			return
 		}
 
+		if !entry.allowEgress(pkt.Len()) {
+			pkt.Free()
+			continue
+		}
+
 		if err := registerIfSCMPRequest(ref, &pkt.Info); err != nil {
 			log.Warn("SCMP Request ID error, packet still sent", "err", err)
 		}
@@ -225,6 +309,7 @@ func (h *AppConnHandler) RunAppToNetDataplane(ref registration.RegReference,
 		} else {
 			metrics.OutgoingBytesTotal.Add(float64(n))
 			metrics.OutgoingPacketsTotal.Inc()
+			entry.stats.addEgress(n)
 		}
 		pkt.Free()
 	}
@@ -243,17 +328,21 @@ func registerIfSCMPRequest(ref registration.RegReference, packet *spkt.ScnPkt) e
 }
 
 // RunRingToAppDataplane moves packets from the application's ingress ring to
-// the application's socket.
-func (h *AppConnHandler) RunRingToAppDataplane(r *ringbuf.Ring) {
-	entries := make(ringbuf.EntryList, 1)
+// the application's socket. All packets that are read from the ring at once
+// are written to the socket in a batch.
+func (h *AppConnHandler) RunRingToAppDataplane(entry *TableEntry) {
+	entries := make(ringbuf.EntryList, appBatchSize)
+	msgs := make([]reliable.Message, 0, appBatchSize)
 	for {
-		n, _ := r.Read(entries, true)
+		n, _ := entry.appIngressRing.Read(entries, true)
 		if n < 0 {
 			// Ring was closed because app shut down its data socket
 			return
 		}
-		if n > 0 {
-			pkt := entries[0].(*respool.Packet)
+		atomic.AddInt32(&entry.queued, -int32(n))
+		msgs = msgs[:0]
+		for i := 0; i < n; i++ {
+			pkt := entries[i].(*respool.Packet)
 			overlayAddr, err := overlay.NewOverlayAddr(
 				addr.HostFromIP(pkt.OverlayRemote.IP),
 				addr.NewL4UDPInfo(uint16(pkt.OverlayRemote.Port)),
@@ -262,12 +351,38 @@ func (h *AppConnHandler) RunRingToAppDataplane(r *ringbuf.Ring) {
 				h.Logger.Warn("[network->app] Unable to encode overlay address.", "err", err)
 				continue
 			}
-			if _, err := pkt.SendOnConn(h.Conn, overlayAddr); err != nil {
-				h.Logger.Error("[network->app] App connection error.", "err", err)
-				h.Conn.Close()
-				return
-			}
-			pkt.Free()
+			msgs = append(msgs, reliable.Message{Buffer: pkt.Raw(), Addr: overlayAddr})
+		}
+		err := h.writeBatch(msgs)
+		for i := 0; i < n; i++ {
+			entries[i].(*respool.Packet).Free()
+			entries[i] = nil
+		}
+		if err != nil {
+			h.Logger.Error("[network->app] App connection error.", "err", err)
+			h.Conn.Close()
+			return
 		}
 	}
 }
+
+// writeBatch writes msgs to the application's socket, with a single batch
+// write if the socket supports it.
+func (h *AppConnHandler) writeBatch(msgs []reliable.Message) error {
+	if conn, ok := h.Conn.(batchWriter); ok {
+		_, err := conn.WriteBatch(msgs)
+		return err
+	}
+	for _, msg := range msgs {
+		if _, err := h.Conn.WriteTo(msg.Buffer, msg.Addr); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// batchWriter is implemented by connections that write multiple packets at
+// once, e.g., *reliable.Conn.
+type batchWriter interface {
+	WriteBatch(msgs []reliable.Message) (int, error)
+}
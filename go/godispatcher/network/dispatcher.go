@@ -19,6 +19,7 @@ import (
 	"sync"
 	"time"
 
+	"github.com/scionproto/scion/go/godispatcher/internal/limits"
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/log"
@@ -38,6 +39,8 @@ type Dispatcher struct {
 	RoutingTable      *IATable
 	OverlaySocket     string
 	ApplicationSocket string
+	// Limits are the rules that limit the traffic of registered applications.
+	Limits limits.Rules
 }
 
 func (d *Dispatcher) ListenAndServe() error {
@@ -89,6 +92,7 @@ func (d *Dispatcher) ListenAndServe() error {
 				RoutingTable:    d.RoutingTable,
 				IPv4OverlayConn: ipv4Conn,
 				IPv6OverlayConn: ipv6Conn,
+				Limits:          d.Limits,
 			},
 		}
 		errChan <- appServer.Serve()
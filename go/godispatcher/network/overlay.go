@@ -16,6 +16,7 @@ package network
 
 import (
 	"net"
+	"sync/atomic"
 
 	"github.com/scionproto/scion/go/godispatcher/internal/metrics"
 	"github.com/scionproto/scion/go/godispatcher/internal/respool"
@@ -173,7 +174,8 @@ type SVCDestination addr.HostSVC
 func (d SVCDestination) Send(dp *NetToRingDataplane, pkt *respool.Packet) {
 	// FIXME(scrye): This should deliver to the correct IP address, based on
 	// information found in the overlay IP header.
-	routingEntries := dp.RoutingTable.LookupService(pkt.Info.DstIA, addr.HostSVC(d), nil)
+	routingEntries := dp.RoutingTable.LookupService(pkt.Info.DstIA, addr.HostSVC(d), nil,
+		svcFlowKey(&pkt.Info))
 	if len(routingEntries) == 0 {
 		log.Warn("destination address not found", "ia", pkt.Info.DstIA, "svc", addr.HostSVC(d))
 		return
@@ -187,6 +189,17 @@ func (d SVCDestination) Send(dp *NetToRingDataplane, pkt *respool.Packet) {
 	}
 }
 
+// svcFlowKey returns the key that identifies the source of pkt for the SVC
+// policies, i.e., the source IA and host address.
+func svcFlowKey(pkt *spkt.ScnPkt) []byte {
+	if pkt.SrcHost == nil {
+		return nil
+	}
+	key := make(common.RawBytes, addr.IABytes, addr.IABytes+pkt.SrcHost.Size())
+	pkt.SrcIA.Write(key)
+	return append(key, pkt.SrcHost.Pack()...)
+}
+
 var _ Destination = (*SCMPAppDestination)(nil)
 
 type SCMPAppDestination struct {
@@ -206,11 +219,22 @@ func (d *SCMPAppDestination) Send(dp *NetToRingDataplane, pkt *respool.Packet) {
 // reference to pkt.
 func sendPacket(routingEntry *TableEntry, pkt *respool.Packet) {
 	// Move packet reference to other goroutine.
+	// Read the length before the other goroutine takes ownership.
+	n := pkt.Len()
+	if !routingEntry.allowIngress(n) {
+		pkt.Free()
+		return
+	}
+	atomic.AddInt32(&routingEntry.queued, 1)
 	count, _ := routingEntry.appIngressRing.Write(ringbuf.EntryList{pkt}, false)
 	if count <= 0 {
+		atomic.AddInt32(&routingEntry.queued, -1)
+		routingEntry.stats.addIngressDrop()
 		// Release buffer if we couldn't transmit it to the other goroutine.
 		pkt.Free()
+		return
 	}
+	routingEntry.stats.addIngress(n)
 }
 
 var _ Destination = (*SCMPHandlerDestination)(nil)
@@ -0,0 +1,99 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/log"
+)
+
+// RegistrationStatus describes a registration of the routing table and the
+// traffic of the registered socket.
+type RegistrationStatus struct {
+	IA     string
+	Public string
+	Bind   string   `json:",omitempty"`
+	SVC    string   `json:",omitempty"`
+	IDs    []uint64 `json:",omitempty"`
+	// Socket identifies the application socket that owns the registration.
+	// It matches the clientID in the dispatcher logs.
+	Socket  string
+	Created time.Time
+	Age     string
+	// IngressPackets and IngressBytes count the packets delivered to the
+	// socket's ingress ring.
+	IngressPackets uint64
+	IngressBytes   uint64
+	// IngressDrops counts the packets dropped because the ingress ring was
+	// full, i.e., because the application did not read fast enough.
+	IngressDrops uint64
+	// EgressPackets and EgressBytes count the packets sent by the socket.
+	EgressPackets uint64
+	EgressBytes   uint64
+	// IngressLimited and EgressLimited count the packets dropped because
+	// they exceeded the limits of the registration.
+	IngressLimited uint64
+	EgressLimited  uint64
+}
+
+// Status returns the status of all registrations in the table.
+func (t *IATable) Status() []RegistrationStatus {
+	regs := t.IATable.Registrations()
+	status := make([]RegistrationStatus, 0, len(regs))
+	now := time.Now()
+	for _, reg := range regs {
+		entry := reg.Value.(*TableEntry)
+		s := RegistrationStatus{
+			IA:             reg.IA.String(),
+			Public:         reg.Public.String(),
+			IDs:            reg.IDs,
+			Socket:         fmt.Sprintf("%p", entry.conn),
+			Created:        entry.created,
+			Age:            now.Sub(entry.created).Round(time.Second).String(),
+			IngressPackets: atomic.LoadUint64(&entry.stats.ingressPkts),
+			IngressBytes:   atomic.LoadUint64(&entry.stats.ingressBytes),
+			IngressDrops:   atomic.LoadUint64(&entry.stats.ingressDrops),
+			EgressPackets:  atomic.LoadUint64(&entry.stats.egressPkts),
+			EgressBytes:    atomic.LoadUint64(&entry.stats.egressBytes),
+			IngressLimited: atomic.LoadUint64(&entry.stats.ingressLimited),
+			EgressLimited:  atomic.LoadUint64(&entry.stats.egressLimited),
+		}
+		if reg.Bind != nil {
+			s.Bind = reg.Bind.String()
+		}
+		if reg.SVC != addr.SvcNone {
+			s.SVC = reg.SVC.String()
+		}
+		status = append(status, s)
+	}
+	return status
+}
+
+// ServeRegistrations is an HTTP handler that writes the status of all
+// registrations in the table as JSON list.
+func (t *IATable) ServeRegistrations(w http.ResponseWriter, _ *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(t.Status()); err != nil {
+		log.Warn("Unable to write registration status", "err", err)
+	}
+}
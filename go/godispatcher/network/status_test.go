@@ -0,0 +1,105 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package network
+
+import (
+	"encoding/json"
+	"net"
+	"net/http/httptest"
+	"os"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/godispatcher/internal/limits"
+	"github.com/scionproto/scion/go/godispatcher/internal/metrics"
+	"github.com/scionproto/scion/go/godispatcher/internal/respool"
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+func TestStatus(t *testing.T) {
+	Convey("Given a table with an SVC registration", t, func() {
+		table := NewIATable(1024, 65535)
+		ia := xtest.MustParseIA("1-ff00:0:1")
+		public := &net.UDPAddr{IP: net.IP{192, 0, 2, 1}, Port: 40000}
+		entry := newTableEntry(nil)
+		ref, err := table.Register(ia, public, nil, addr.SvcPS, entry)
+		xtest.FailOnErr(t, err)
+		xtest.FailOnErr(t, ref.RegisterID(42))
+		Convey("the status contains the registration", func() {
+			status := table.Status()
+			So(status, ShouldHaveLength, 1)
+			So(status[0].IA, ShouldEqual, "1-ff00:0:1")
+			So(status[0].Public, ShouldEqual, "192.0.2.1:40000")
+			So(status[0].Bind, ShouldEqual, "192.0.2.1")
+			So(status[0].SVC, ShouldEqual, addr.SvcPS.String())
+			So(status[0].IDs, ShouldResemble, []uint64{42})
+		})
+		Convey("packets are dropped until the limits are set", func() {
+			sendPacket(entry, respool.GetPacket())
+			status := table.Status()
+			So(status[0].IngressPackets, ShouldEqual, 0)
+			So(status[0].IngressDrops, ShouldEqual, 1)
+		})
+		Convey("packets that do not fit in the ring are counted as drops", func() {
+			entry.setLimiter(nil)
+			var n int
+			for i := 0; i < 129; i++ {
+				pkt := respool.GetPacket()
+				n = pkt.Len()
+				sendPacket(entry, pkt)
+			}
+			status := table.Status()
+			So(status[0].IngressPackets, ShouldEqual, 128)
+			So(status[0].IngressBytes, ShouldEqual, 128*n)
+			So(status[0].IngressDrops, ShouldEqual, 1)
+		})
+		Convey("packets that exceed the queue quota are counted as limited", func() {
+			entry.setLimiter(&limits.Rule{Queue: 2})
+			for i := 0; i < 3; i++ {
+				sendPacket(entry, respool.GetPacket())
+			}
+			status := table.Status()
+			So(status[0].IngressPackets, ShouldEqual, 2)
+			So(status[0].IngressLimited, ShouldEqual, 1)
+			So(status[0].IngressDrops, ShouldEqual, 0)
+		})
+		Convey("packets that exceed the egress rate are counted as limited", func() {
+			entry.setLimiter(&limits.Rule{EgressRate: 1000, EgressBurst: 2000})
+			So(entry.allowEgress(1500), ShouldBeTrue)
+			So(entry.allowEgress(1500), ShouldBeFalse)
+			So(table.Status()[0].EgressLimited, ShouldEqual, 1)
+		})
+		Convey("the HTTP handler serves the status as JSON", func() {
+			w := httptest.NewRecorder()
+			table.ServeRegistrations(w, httptest.NewRequest("GET", "/registrations", nil))
+			var status []RegistrationStatus
+			So(json.Unmarshal(w.Body.Bytes(), &status), ShouldBeNil)
+			So(status, ShouldHaveLength, 1)
+			So(status[0].Public, ShouldEqual, "192.0.2.1:40000")
+		})
+		Convey("freed registrations are not listed", func() {
+			ref.Free()
+			So(table.Status(), ShouldBeEmpty)
+		})
+	})
+}
+
+func TestMain(m *testing.M) {
+	// The ring buffers of the table entries require the metrics.
+	metrics.Init("dispatcher")
+	os.Exit(m.Run())
+}
@@ -16,24 +16,144 @@ package network
 
 import (
 	"net"
+	"sync/atomic"
+	"time"
 
+	"github.com/scionproto/scion/go/godispatcher/internal/limits"
+	"github.com/scionproto/scion/go/godispatcher/internal/metrics"
 	"github.com/scionproto/scion/go/godispatcher/internal/registration"
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/ringbuf"
+	"github.com/scionproto/scion/go/lib/tokenbucket"
 )
 
 type TableEntry struct {
 	conn           net.PacketConn
 	appIngressRing *ringbuf.Ring
+	// created is the time the entry was created.
+	created time.Time
+	stats   entryStats
+	// limiter contains the *appLimiter of the entry, or nil if the entry is
+	// not limited. The limits are only known once the registration assigned
+	// the public port. Until they are set, the entry holds pendingLimiter and
+	// ingress packets are dropped, such that no packet bypasses the limits.
+	limiter atomic.Value
+	// queued is the number of packets on the ingress ring. It is updated
+	// atomically.
+	queued int32
+}
+
+// pendingLimiter is the limiter of entries whose limits are not set yet.
+var pendingLimiter = &appLimiter{}
+
+// setLimiter limits the traffic of the entry according to rule. If rule is
+// nil, the entry is not limited.
+func (e *TableEntry) setLimiter(rule *limits.Rule) {
+	if rule == nil {
+		e.limiter.Store((*appLimiter)(nil))
+		return
+	}
+	e.limiter.Store(&appLimiter{
+		egress:  tokenbucket.New(rule.EgressRate, rule.EgressBurst),
+		ingress: tokenbucket.New(rule.IngressRate, rule.IngressBurst),
+		queue:   int32(rule.QueueQuota()),
+	})
+}
+
+// allowIngress returns whether a packet of n bytes can be queued on the
+// ingress ring. If not, the drop is counted.
+func (e *TableEntry) allowIngress(n int) bool {
+	l, _ := e.limiter.Load().(*appLimiter)
+	if l == nil {
+		return true
+	}
+	if l == pendingLimiter {
+		e.stats.addIngressDrop()
+		return false
+	}
+	if atomic.LoadInt32(&e.queued) >= l.queue {
+		e.stats.addLimited(metrics.LimitDirectionIngress, metrics.LimitReasonQueue, n)
+		return false
+	}
+	if !l.ingress.Allow(time.Now(), n) {
+		e.stats.addLimited(metrics.LimitDirectionIngress, metrics.LimitReasonRate, n)
+		return false
+	}
+	return true
+}
+
+// allowEgress returns whether the application can send a packet of n bytes.
+// If not, the drop is counted.
+func (e *TableEntry) allowEgress(n int) bool {
+	l, _ := e.limiter.Load().(*appLimiter)
+	if l == nil || l == pendingLimiter || l.egress.Allow(time.Now(), n) {
+		return true
+	}
+	e.stats.addLimited(metrics.LimitDirectionEgress, metrics.LimitReasonRate, n)
+	return false
+}
+
+// appLimiter contains the limits of a table entry.
+type appLimiter struct {
+	egress  *tokenbucket.Bucket
+	ingress *tokenbucket.Bucket
+	// queue is the maximum number of packets on the ingress ring.
+	queue int32
 }
 
 func newTableEntry(conn net.PacketConn) *TableEntry {
 	// Construct application ingress ring buffer
 	appIngressRing := ringbuf.New(128, nil, "", nil)
-	return &TableEntry{
+	e := &TableEntry{
 		conn:           conn,
 		appIngressRing: appIngressRing,
+		created:        time.Now(),
 	}
+	e.limiter.Store(pendingLimiter)
+	return e
+}
+
+// entryStats contains the packet counters of a table entry. The counters are
+// updated atomically.
+type entryStats struct {
+	// ingressPkts and ingressBytes count the packets enqueued on the
+	// application's ingress ring.
+	ingressPkts  uint64
+	ingressBytes uint64
+	// ingressDrops counts the packets that were dropped because the ingress
+	// ring was full or closed.
+	ingressDrops uint64
+	// egressPkts and egressBytes count the packets sent by the application.
+	egressPkts  uint64
+	egressBytes uint64
+	// ingressLimited and egressLimited count the packets that were dropped
+	// because they exceeded the limits of the entry.
+	ingressLimited uint64
+	egressLimited  uint64
+}
+
+func (s *entryStats) addIngress(bytes int) {
+	atomic.AddUint64(&s.ingressPkts, 1)
+	atomic.AddUint64(&s.ingressBytes, uint64(bytes))
+}
+
+func (s *entryStats) addIngressDrop() {
+	atomic.AddUint64(&s.ingressDrops, 1)
+}
+
+func (s *entryStats) addLimited(direction, reason string, bytes int) {
+	if direction == metrics.LimitDirectionIngress {
+		atomic.AddUint64(&s.ingressLimited, 1)
+	} else {
+		atomic.AddUint64(&s.egressLimited, 1)
+	}
+	metrics.LimitedPackets.WithLabelValues(direction, reason).Inc()
+	metrics.LimitedBytes.WithLabelValues(direction, reason).Add(float64(bytes))
+}
+
+func (s *entryStats) addEgress(bytes int) {
+	atomic.AddUint64(&s.egressPkts, 1)
+	atomic.AddUint64(&s.egressBytes, uint64(bytes))
 }
 
 func getBindIP(address *net.UDPAddr) net.IP {
@@ -62,8 +182,10 @@ func (t *IATable) LookupPublic(ia addr.IA, public *net.UDPAddr) (*TableEntry, bo
 	return e.(*TableEntry), true
 }
 
-func (t *IATable) LookupService(ia addr.IA, svc addr.HostSVC, bind net.IP) []*TableEntry {
-	ifaces := t.IATable.LookupService(ia, svc, bind)
+func (t *IATable) LookupService(ia addr.IA, svc addr.HostSVC, bind net.IP,
+	flow []byte) []*TableEntry {
+
+	ifaces := t.IATable.LookupService(ia, svc, bind, flow)
 	entries := make([]*TableEntry, len(ifaces))
 	for i := range ifaces {
 		entries[i] = ifaces[i].(*TableEntry)
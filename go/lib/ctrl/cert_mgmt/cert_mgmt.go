@@ -30,6 +30,8 @@ type union struct {
 	ChainIssRep *ChainIssRep `capnp:"certChainIssRep"`
 	TRCReq      *TRCReq      `capnp:"trcReq"`
 	TRCRep      *TRC         `capnp:"trc"`
+	CRLReq      *CRLReq      `capnp:"crlReq"`
+	CRLRep      *CRL         `capnp:"crl"`
 }
 
 func (u *union) set(c proto.Cerealizable) error {
@@ -52,6 +54,12 @@ func (u *union) set(c proto.Cerealizable) error {
 	case *TRC:
 		u.Which = proto.CertMgmt_Which_trc
 		u.TRCRep = p
+	case *CRLReq:
+		u.Which = proto.CertMgmt_Which_crlReq
+		u.CRLReq = p
+	case *CRL:
+		u.Which = proto.CertMgmt_Which_crl
+		u.CRLRep = p
 	default:
 		return common.NewBasicError("Unsupported cert mgmt union type (set)", nil,
 			"type", common.TypeOf(c))
@@ -73,6 +81,10 @@ func (u *union) get() (proto.Cerealizable, error) {
 		return u.TRCReq, nil
 	case proto.CertMgmt_Which_trc:
 		return u.TRCRep, nil
+	case proto.CertMgmt_Which_crlReq:
+		return u.CRLReq, nil
+	case proto.CertMgmt_Which_crl:
+		return u.CRLRep, nil
 	}
 	return nil, common.NewBasicError("Unsupported cert mgmt union type (get)", nil, "type", u.Which)
 }
@@ -0,0 +1,51 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert_mgmt
+
+import (
+	"fmt"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/scrypto/cert"
+	"github.com/scionproto/scion/go/proto"
+)
+
+var _ proto.Cerealizable = (*CRL)(nil)
+
+type CRL struct {
+	RawCRL common.RawBytes `capnp:"crl"`
+}
+
+func (c *CRL) CRL() (*cert.RevocationList, error) {
+	if c.RawCRL == nil {
+		return nil, nil
+	}
+	return cert.RevocationListFromRaw(c.RawCRL)
+}
+
+func (c *CRL) ProtoId() proto.ProtoIdType {
+	return proto.CRL_TypeID
+}
+
+func (c *CRL) String() string {
+	crl, err := c.CRL()
+	if err != nil {
+		return fmt.Sprintf("Invalid CRL: %v", err)
+	}
+	if crl == nil {
+		return "CRL: <nil>"
+	}
+	return crl.String()
+}
@@ -0,0 +1,45 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file contains the Go representation of certificate revocation list requests.
+
+package cert_mgmt
+
+import (
+	"fmt"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/proto"
+)
+
+var _ proto.Cerealizable = (*CRLReq)(nil)
+
+type CRLReq struct {
+	RawIssuer addr.IAInt `capnp:"issuer"`
+	Version   uint64
+	CacheOnly bool
+}
+
+func (c *CRLReq) Issuer() addr.IA {
+	return c.RawIssuer.IA()
+}
+
+func (c *CRLReq) ProtoId() proto.ProtoIdType {
+	return proto.CRLReq_TypeID
+}
+
+func (c *CRLReq) String() string {
+	return fmt.Sprintf("Issuer: %s Version: %v CacheOnly: %v", c.Issuer(), c.Version,
+		c.CacheOnly)
+}
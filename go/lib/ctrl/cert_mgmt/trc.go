@@ -20,6 +20,7 @@ import (
 
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 	"github.com/scionproto/scion/go/proto"
 )
 
@@ -36,14 +37,32 @@ func (t *TRC) TRC() (*trc.TRC, error) {
 	return trc.TRCFromRaw(t.RawTRC, true)
 }
 
+// SignedTRCv2 parses the raw TRC as a signed TRC v2. It is only set in
+// replies to requests with V2 set.
+func (t *TRC) SignedTRCv2() (*trcv2.Signed, error) {
+	if t.RawTRC == nil {
+		return nil, nil
+	}
+	signed, err := trcv2.ParseSigned(t.RawTRC)
+	if err != nil {
+		return nil, err
+	}
+	return &signed, nil
+}
+
 func (t *TRC) ProtoId() proto.ProtoIdType {
 	return proto.TRC_TypeID
 }
 
 func (t *TRC) String() string {
 	u, err := t.TRC()
-	if err != nil {
-		return fmt.Sprintf("Invalid TRC: %v", err)
+	if err == nil {
+		return u.String()
+	}
+	if signed, errV2 := t.SignedTRCv2(); errV2 == nil {
+		if v2, errV2 := signed.EncodedTRC.Decode(); errV2 == nil {
+			return fmt.Sprintf("TRC v2 %dv%d", v2.ISD, v2.Version)
+		}
 	}
-	return u.String()
+	return fmt.Sprintf("Invalid TRC: %v", err)
 }
@@ -29,6 +29,8 @@ type TRCReq struct {
 	ISD       addr.ISD `capnp:"isd"`
 	Version   uint64
 	CacheOnly bool
+	// V2 requests the signed TRC in the format of package scrypto/trc/v2.
+	V2 bool `capnp:"v2"`
 }
 
 func (t *TRCReq) IA() addr.IA {
@@ -40,5 +42,6 @@ func (t *TRCReq) ProtoId() proto.ProtoIdType {
 }
 
 func (t *TRCReq) String() string {
-	return fmt.Sprintf("ISD: %d Version: %d CacheOnly: %v", t.ISD, t.Version, t.CacheOnly)
+	return fmt.Sprintf("ISD: %d Version: %d CacheOnly: %v V2: %v", t.ISD, t.Version,
+		t.CacheOnly, t.V2)
 }
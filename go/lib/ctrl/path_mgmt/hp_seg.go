@@ -0,0 +1,130 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file contains the Go representation of hidden path segment messages.
+
+package path_mgmt
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/proto"
+)
+
+var _ proto.Cerealizable = (*HPGroupId)(nil)
+
+// HPGroupId identifies a hidden path group.
+type HPGroupId struct {
+	OwnerAS addr.AS `capnp:"ownerAS"`
+	GroupId uint16  `capnp:"groupID"`
+}
+
+func (h *HPGroupId) ProtoId() proto.ProtoIdType {
+	return proto.HPGroupId_TypeID
+}
+
+func (h *HPGroupId) String() string {
+	return fmt.Sprintf("%s-%x", h.OwnerAS, h.GroupId)
+}
+
+var _ proto.Cerealizable = (*HPSegReq)(nil)
+
+// HPSegReq is a request for hidden down segments to the destination, that are
+// registered for one of the groups.
+type HPSegReq struct {
+	RawDstIA addr.IAInt `capnp:"dstIA"`
+	GroupIds []*HPGroupId
+}
+
+func (s *HPSegReq) DstIA() addr.IA {
+	return s.RawDstIA.IA()
+}
+
+func (s *HPSegReq) ProtoId() proto.ProtoIdType {
+	return proto.HPSegReq_TypeID
+}
+
+func (s *HPSegReq) String() string {
+	return fmt.Sprintf("Dst: %s, GroupIds: %v", s.DstIA(), s.GroupIds)
+}
+
+var _ proto.Cerealizable = (*HPSegRecs)(nil)
+
+// HPSegRecs contains the hidden segments of a single group.
+type HPSegRecs struct {
+	GroupId *HPGroupId
+	Recs    []*seg.Meta
+}
+
+func (s *HPSegRecs) ProtoId() proto.ProtoIdType {
+	return proto.HPSegRecs_TypeID
+}
+
+func (s *HPSegRecs) String() string {
+	desc := []string{fmt.Sprintf("group: %s segments:", s.GroupId)}
+	for _, m := range s.Recs {
+		desc = append(desc, "  "+m.String())
+	}
+	return strings.Join(desc, "\n")
+}
+
+func (s *HPSegRecs) ParseRaw() error {
+	for i, segMeta := range s.Recs {
+		if err := segMeta.Segment.ParseRaw(false); err != nil {
+			return common.NewBasicError("Unable to parse segment", err, "seg_index", i,
+				"segment", segMeta.Segment)
+		}
+	}
+	return nil
+}
+
+var _ proto.Cerealizable = (*HPSegReg)(nil)
+
+// HPSegReg registers hidden segments for a group with a hidden path registry.
+type HPSegReg struct {
+	*HPSegRecs
+}
+
+var _ proto.Cerealizable = (*HPSegReply)(nil)
+
+// HPSegReply is the reply to a HPSegReq. It contains the hidden segments per
+// group.
+type HPSegReply struct {
+	Recs []*HPSegRecs
+}
+
+func (s *HPSegReply) ProtoId() proto.ProtoIdType {
+	return proto.HPSegReply_TypeID
+}
+
+func (s *HPSegReply) String() string {
+	desc := make([]string, 0, len(s.Recs))
+	for _, recs := range s.Recs {
+		desc = append(desc, recs.String())
+	}
+	return strings.Join(desc, "\n")
+}
+
+func (s *HPSegReply) ParseRaw() error {
+	for _, recs := range s.Recs {
+		if err := recs.ParseRaw(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
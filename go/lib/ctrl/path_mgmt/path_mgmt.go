@@ -37,6 +37,9 @@ type union struct {
 	SegChangesIdReply *SegChangesIdReply
 	SegChangesReq     *SegChangesReq
 	SegChangesReply   *SegChangesReply
+	HPSegReq          *HPSegReq   `capnp:"hpSegReq"`
+	HPSegReply        *HPSegReply `capnp:"hpSegReply"`
+	HPSegReg          *HPSegReg   `capnp:"hpSegReg"`
 }
 
 func (u *union) set(c proto.Cerealizable) error {
@@ -74,6 +77,15 @@ func (u *union) set(c proto.Cerealizable) error {
 	case *SegChangesReply:
 		u.Which = proto.PathMgmt_Which_segChangesReply
 		u.SegChangesReply = p
+	case *HPSegReq:
+		u.Which = proto.PathMgmt_Which_hpSegReq
+		u.HPSegReq = p
+	case *HPSegReply:
+		u.Which = proto.PathMgmt_Which_hpSegReply
+		u.HPSegReply = p
+	case *HPSegReg:
+		u.Which = proto.PathMgmt_Which_hpSegReg
+		u.HPSegReg = p
 	default:
 		return common.NewBasicError("Unsupported path mgmt union type (set)", nil,
 			"type", common.TypeOf(c))
@@ -105,6 +117,12 @@ func (u *union) get() (proto.Cerealizable, error) {
 		return u.SegChangesReq, nil
 	case proto.PathMgmt_Which_segChangesReply:
 		return u.SegChangesReply, nil
+	case proto.PathMgmt_Which_hpSegReq:
+		return u.HPSegReq, nil
+	case proto.PathMgmt_Which_hpSegReply:
+		return u.HPSegReply, nil
+	case proto.PathMgmt_Which_hpSegReg:
+		return u.HPSegReg, nil
 	}
 	return nil, common.NewBasicError("Unsupported path mgmt union type (get)", nil, "type", u.Which)
 }
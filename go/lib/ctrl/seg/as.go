@@ -36,6 +36,7 @@ type ASEntry struct {
 	Exts       struct {
 		RoutingPolicy common.RawBytes `capnp:"-"` // Not supported yet
 		Sibra         common.RawBytes `capnp:"-"` // Not supported yet
+		StaticInfo    *StaticInfoExt  `capnp:"staticInfo"`
 	}
 }
 
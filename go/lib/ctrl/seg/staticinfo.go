@@ -0,0 +1,128 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seg
+
+import (
+	"fmt"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// StaticInfoExt is the static path metadata extension of an AS entry. It
+// describes static properties of the interfaces referenced by the hop
+// entries, and the AS internal connections between them. As part of the AS
+// entry, the extension is covered by the AS entry signature.
+type StaticInfoExt struct {
+	// Set indicates that the extension is present.
+	Set bool
+	// Ifaces contains the static info of the interfaces referenced by the hop
+	// entries.
+	Ifaces []*StaticInfoIface
+	// Intra contains the AS internal metrics between the egress interface and
+	// the other interfaces of the AS.
+	Intra []*StaticInfoIntra
+	// Note is a free-form note about the AS.
+	Note string
+}
+
+// Iface returns the static info of the interface, or nil if it is not part of
+// the extension.
+func (ext *StaticInfoExt) Iface(ifid common.IFIDType) *StaticInfoIface {
+	for _, iface := range ext.Ifaces {
+		if iface.IfID == ifid {
+			return iface
+		}
+	}
+	return nil
+}
+
+// IntraMetrics returns the AS internal metrics between the two interfaces, or
+// nil if they are not part of the extension. The metrics are assumed to be
+// symmetric.
+func (ext *StaticInfoExt) IntraMetrics(a, b common.IFIDType) *StaticInfoIntra {
+	for _, intra := range ext.Intra {
+		if (intra.FromIfID == a && intra.ToIfID == b) ||
+			(intra.FromIfID == b && intra.ToIfID == a) {
+			return intra
+		}
+	}
+	return nil
+}
+
+func (ext *StaticInfoExt) String() string {
+	return fmt.Sprintf("Ifaces: %d Intra: %d Note: %q", len(ext.Ifaces), len(ext.Intra),
+		ext.Note)
+}
+
+// StaticInfoIface contains the static info of an interface and its
+// inter-AS link.
+type StaticInfoIface struct {
+	// IfID is the local interface ID.
+	IfID common.IFIDType `capnp:"ifID"`
+	// LinkLatency is the one-way latency of the inter-AS link in microseconds.
+	// Zero indicates that the latency is unknown.
+	LinkLatency uint32
+	// Bandwidth is the bandwidth of the inter-AS link in kbit/s. Zero
+	// indicates that the bandwidth is unknown.
+	Bandwidth uint64
+	// Latitude is the geographic latitude of the interface.
+	Latitude float32
+	// Longitude is the geographic longitude of the interface.
+	Longitude float32
+	// Address is the civic address of the interface location.
+	Address string
+	// LinkType is the type of the inter-AS link.
+	LinkType proto.StaticInfoIface_LinkType
+}
+
+// LinkLatency returns the latency of the link between the two interfaces, or
+// zero if it is unknown. The value reported by the first interface takes
+// precedence. Both interfaces can be nil.
+func LinkLatency(a, b *StaticInfoIface) uint32 {
+	if a != nil && a.LinkLatency != 0 {
+		return a.LinkLatency
+	}
+	if b != nil {
+		return b.LinkLatency
+	}
+	return 0
+}
+
+// LinkBandwidth returns the bandwidth of the link between the two interfaces,
+// or zero if it is unknown. The value reported by the first interface takes
+// precedence. Both interfaces can be nil.
+func LinkBandwidth(a, b *StaticInfoIface) uint64 {
+	if a != nil && a.Bandwidth != 0 {
+		return a.Bandwidth
+	}
+	if b != nil {
+		return b.Bandwidth
+	}
+	return 0
+}
+
+// StaticInfoIntra contains the AS internal metrics between two interfaces.
+type StaticInfoIntra struct {
+	// FromIfID is the interface the metrics are measured from.
+	FromIfID common.IFIDType `capnp:"fromIfID"`
+	// ToIfID is the interface the metrics are measured to.
+	ToIfID common.IFIDType `capnp:"toIfID"`
+	// Latency is the one-way AS internal latency in microseconds. Zero
+	// indicates that the latency is unknown.
+	Latency uint32
+	// InternalHops is the number of AS internal hops.
+	InternalHops uint32
+}
@@ -0,0 +1,102 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package seg
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/spath"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/proto"
+)
+
+func TestASEntryStaticInfoRoundTrip(t *testing.T) {
+	asEntry := &ASEntry{
+		RawIA:    as110.IAInt(),
+		TrcVer:   1,
+		CertVer:  3,
+		MTU:      1500,
+		IfIDSize: 12,
+		HopEntries: []*HopEntry{
+			{
+				RemoteOutIF: 23,
+				RawOutIA:    as111.IAInt(),
+				RawHopField: make(common.RawBytes, spath.HopFieldLength),
+			},
+		},
+	}
+	asEntry.Exts.StaticInfo = testStaticInfo()
+	raw, err := asEntry.Pack()
+	xtest.FailOnErr(t, err)
+	parsed, err := NewASEntryFromRaw(raw)
+	xtest.FailOnErr(t, err)
+	if !reflect.DeepEqual(parsed.Exts.StaticInfo, asEntry.Exts.StaticInfo) {
+		t.Errorf("Static info mismatch, expected %v, actual %v",
+			asEntry.Exts.StaticInfo, parsed.Exts.StaticInfo)
+	}
+}
+
+func TestStaticInfoExtLookup(t *testing.T) {
+	ext := testStaticInfo()
+	if iface := ext.Iface(1); iface == nil || iface.LinkLatency != 1500 {
+		t.Errorf("Unexpected iface %v", iface)
+	}
+	if iface := ext.Iface(42); iface != nil {
+		t.Errorf("Expected nil iface, actual %v", iface)
+	}
+	if intra := ext.IntraMetrics(2, 1); intra == nil || intra.Latency != 300 {
+		t.Errorf("Unexpected intra metrics %v", intra)
+	}
+	if intra := ext.IntraMetrics(2, 3); intra != nil {
+		t.Errorf("Expected nil intra metrics, actual %v", intra)
+	}
+}
+
+func TestLinkMetrics(t *testing.T) {
+	a := &StaticInfoIface{LinkLatency: 1500}
+	b := &StaticInfoIface{LinkLatency: 1000, Bandwidth: 2000}
+	if l := LinkLatency(a, b); l != 1500 {
+		t.Errorf("Expected latency of first iface, actual %d", l)
+	}
+	if bw := LinkBandwidth(a, b); bw != 2000 {
+		t.Errorf("Expected bandwidth of second iface, actual %d", bw)
+	}
+	if l, bw := LinkLatency(nil, nil), LinkBandwidth(nil, a); l != 0 || bw != 0 {
+		t.Errorf("Expected unknown metrics, actual latency %d bandwidth %d", l, bw)
+	}
+}
+
+func testStaticInfo() *StaticInfoExt {
+	return &StaticInfoExt{
+		Set: true,
+		Ifaces: []*StaticInfoIface{
+			{
+				IfID:        1,
+				LinkLatency: 1500,
+				Bandwidth:   1000000,
+				Latitude:    47.3769,
+				Longitude:   8.5417,
+				Address:     "Zurich",
+				LinkType:    proto.StaticInfoIface_LinkType_direct,
+			},
+		},
+		Intra: []*StaticInfoIntra{
+			{FromIfID: 1, ToIfID: 2, Latency: 300, InternalHops: 2},
+		},
+		Note: "test",
+	}
+}
@@ -315,6 +315,9 @@ type QUIC struct {
 	Address            string
 	CertFile           string
 	KeyFile            string
+	// PKI authenticates QUIC sessions with the control-plane PKI instead of
+	// the certificate in CertFile.
+	PKI bool
 }
 
 func (cfg *QUIC) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
@@ -324,3 +327,38 @@ func (cfg *QUIC) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
 func (cfg *QUIC) ConfigName() string {
 	return "quic"
 }
+
+var _ config.Config = (*Trust)(nil)
+
+// Trust contains the certificate chain validation policy of the trust store.
+type Trust struct {
+	config.NoDefaulter
+	// DisableRevocationCheck disables checking certificate chains against the
+	// CRL of the issuer.
+	DisableRevocationCheck bool
+	// RequireFreshCRL rejects certificate chains if no fresh CRL of the issuer
+	// is available. If not set, the revocation check fails open.
+	RequireFreshCRL bool
+	// MaxLeafLifetime is the maximum validity period of leaf certificates. If
+	// zero, the lifetime of leaf certificates is not restricted.
+	MaxLeafLifetime util.DurWrap
+}
+
+func (cfg *Trust) Validate() error {
+	if cfg.DisableRevocationCheck && cfg.RequireFreshCRL {
+		return common.NewBasicError("RequireFreshCRL requires the revocation check", nil)
+	}
+	if cfg.MaxLeafLifetime.Duration < 0 {
+		return common.NewBasicError("MaxLeafLifetime must not be negative", nil,
+			"value", cfg.MaxLeafLifetime.Duration)
+	}
+	return nil
+}
+
+func (cfg *Trust) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
+	config.WriteString(dst, trustSample)
+}
+
+func (cfg *Trust) ConfigName() string {
+	return "trust"
+}
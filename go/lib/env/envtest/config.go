@@ -17,6 +17,7 @@ package envtest
 import (
 	"fmt"
 	"path/filepath"
+	"time"
 
 	. "github.com/smartystreets/goconvey/convey"
 	"github.com/uber/jaeger-client-go"
@@ -60,6 +61,12 @@ func InitTestTracing(cfg *env.Tracing) {
 
 func InitTestSciond(cfg *env.SciondClient) {}
 
+func InitTestTrust(cfg *env.Trust) {
+	cfg.DisableRevocationCheck = true
+	cfg.RequireFreshCRL = true
+	cfg.MaxLeafLifetime.Duration = time.Hour
+}
+
 func CheckTest(general *env.General, logging *env.Logging,
 	metrics *env.Metrics, tracing *env.Tracing, sciond *env.SciondClient, id string) {
 	if general != nil {
@@ -114,3 +121,9 @@ func CheckTestSciond(cfg *env.SciondClient, id string) {
 	SoMsg("InitialConnectPeriod correct", cfg.InitialConnectPeriod.Duration, ShouldEqual,
 		env.SciondInitConnectPeriod)
 }
+
+func CheckTestTrust(cfg *env.Trust) {
+	SoMsg("DisableRevocationCheck correct", cfg.DisableRevocationCheck, ShouldBeFalse)
+	SoMsg("RequireFreshCRL correct", cfg.RequireFreshCRL, ShouldBeFalse)
+	SoMsg("MaxLeafLifetime correct", cfg.MaxLeafLifetime.Duration, ShouldBeZeroValue)
+}
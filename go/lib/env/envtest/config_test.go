@@ -89,3 +89,16 @@ func TestSciondClientSample(t *testing.T) {
 		InitTestSciond(&cfg)
 	})
 }
+
+func TestTrustSample(t *testing.T) {
+	Convey("Sample correct", t, func() {
+		var sample bytes.Buffer
+		var cfg env.Trust
+		cfg.Sample(&sample, nil, nil)
+		InitTestTrust(&cfg)
+		meta, err := toml.Decode(sample.String(), &cfg)
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("unparsed", meta.Undecoded(), ShouldBeEmpty)
+		CheckTestTrust(&cfg)
+	})
+}
@@ -91,6 +91,14 @@ CertFile = "/etc/scion/quic/tls.pem"
 # Key file to use for authenticating QUIC connections.
 KeyFile = "/etc/scion/quic/tls.key"
 
+# Authenticate QUIC connections with the control-plane PKI instead of the
+# certificate in CertFile. Control-plane servers sign their certificates with
+# the AS signing key, and reject peers that present certificates without such a
+# signature. SCIOND only verifies the servers it connects to, and requires no
+# signing key. All control-plane services of the AS must be updated before it
+# is enabled. (default false)
+PKI = false
+
 # SVCResolutionFraction enables SVC resolution for traffic to SVC
 # destinations in a way that is also compatible with control plane servers
 # that do not implement the SVC Resolution Mechanism. The value represents
@@ -105,3 +113,17 @@ KeyFile = "/etc/scion/quic/tls.key"
 # resolution step is successful.
 ResolutionFraction = 0.0
 `
+
+const trustSample = `
+# Disable checking certificate chains against the CRL of the issuer.
+# (default false)
+DisableRevocationCheck = false
+
+# Reject certificate chains if no fresh CRL of the issuer is available. If not
+# set, chains are accepted when the CRL cannot be fetched. (default false)
+RequireFreshCRL = false
+
+# Maximum validity period of leaf certificates, e.g. "72h". If 0, the lifetime
+# of leaf certificates is not restricted. (default 0)
+MaxLeafLifetime = "0s"
+`
@@ -0,0 +1,243 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package hiddenpath contains the configuration of hidden path groups.
+//
+// A hidden path group is identified by the owner AS and a group number. The
+// writers of a group register their down segments with the registries of the
+// group, instead of the core. The registries only hand out the segments to
+// the readers of the group.
+//
+// The groups are loaded from a json file containing a list of groups:
+//  [
+//    {
+//      "Id": "ff00:0:110-69b5",
+//      "Version": 1,
+//      "Owner": "1-ff00:0:110",
+//      "Writers": ["1-ff00:0:111"],
+//      "Readers": ["1-ff00:0:112"],
+//      "Registries": ["1-ff00:0:110"]
+//    }
+//  ]
+package hiddenpath
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"sort"
+	"strconv"
+	"strings"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+)
+
+// GroupId identifies a hidden path group.
+type GroupId struct {
+	// OwnerAS is the AS number of the owner of the group.
+	OwnerAS addr.AS
+	// Suffix is the group number, unique per owner.
+	Suffix uint16
+}
+
+// IdFromMsg creates a group id from the control message representation.
+func IdFromMsg(id *path_mgmt.HPGroupId) GroupId {
+	return GroupId{OwnerAS: id.OwnerAS, Suffix: id.GroupId}
+}
+
+// ParseGroupId parses a group id of the form <owner AS>-<hex suffix>.
+func ParseGroupId(s string) (GroupId, error) {
+	idx := strings.LastIndex(s, "-")
+	if idx < 0 {
+		return GroupId{}, common.NewBasicError("Invalid group id, missing separator", nil,
+			"id", s)
+	}
+	as, err := addr.ASFromString(s[:idx])
+	if err != nil {
+		return GroupId{}, common.NewBasicError("Invalid group id owner", err, "id", s)
+	}
+	suffix, err := strconv.ParseUint(s[idx+1:], 16, 16)
+	if err != nil {
+		return GroupId{}, common.NewBasicError("Invalid group id suffix", err, "id", s)
+	}
+	return GroupId{OwnerAS: as, Suffix: uint16(suffix)}, nil
+}
+
+// ToMsg returns the control message representation of the group id.
+func (id GroupId) ToMsg() *path_mgmt.HPGroupId {
+	return &path_mgmt.HPGroupId{OwnerAS: id.OwnerAS, GroupId: id.Suffix}
+}
+
+// HPCfgID returns the path database representation of the group id.
+func (id GroupId) HPCfgID() *query.HPCfgID {
+	return &query.HPCfgID{IA: addr.IA{A: id.OwnerAS}, ID: uint64(id.Suffix)}
+}
+
+func (id GroupId) String() string {
+	return fmt.Sprintf("%s-%x", id.OwnerAS, id.Suffix)
+}
+
+func (id GroupId) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+func (id *GroupId) UnmarshalText(text []byte) error {
+	parsed, err := ParseGroupId(string(text))
+	if err != nil {
+		return err
+	}
+	*id = parsed
+	return nil
+}
+
+// Group is a hidden path group.
+type Group struct {
+	// Id identifies the group.
+	Id GroupId
+	// Version is the version of the group configuration.
+	Version uint32
+	// Owner is the AS that owns the group.
+	Owner addr.IA
+	// Writers are the ASes that register their down segments with the
+	// registries of the group.
+	Writers []addr.IA
+	// Readers are the ASes that are allowed to get the hidden segments of the
+	// group.
+	Readers []addr.IA
+	// Registries are the ASes that store the hidden segments of the group.
+	Registries []addr.IA
+}
+
+// Validate checks that the group has a valid id that matches the owner, and
+// that at least one writer, reader and registry is configured.
+func (g *Group) Validate() error {
+	if g.Id.OwnerAS == 0 {
+		return common.NewBasicError("Missing group id owner", nil)
+	}
+	if g.Owner.A != g.Id.OwnerAS {
+		return common.NewBasicError("Owner does not match group id", nil,
+			"owner", g.Owner, "id", g.Id)
+	}
+	if len(g.Writers) == 0 {
+		return common.NewBasicError("Group has no writers", nil, "id", g.Id)
+	}
+	if len(g.Readers) == 0 {
+		return common.NewBasicError("Group has no readers", nil, "id", g.Id)
+	}
+	if len(g.Registries) == 0 {
+		return common.NewBasicError("Group has no registries", nil, "id", g.Id)
+	}
+	return nil
+}
+
+// HasWriter returns whether the AS is a writer of the group.
+func (g *Group) HasWriter(ia addr.IA) bool {
+	return contains(g.Writers, ia)
+}
+
+// HasReader returns whether the AS is a reader of the group.
+func (g *Group) HasReader(ia addr.IA) bool {
+	return contains(g.Readers, ia)
+}
+
+// HasRegistry returns whether the AS is a registry of the group.
+func (g *Group) HasRegistry(ia addr.IA) bool {
+	return contains(g.Registries, ia)
+}
+
+func contains(ias []addr.IA, ia addr.IA) bool {
+	for _, other := range ias {
+		if other.Equal(ia) {
+			return true
+		}
+	}
+	return false
+}
+
+// Groups is a set of hidden path groups, indexed by their id.
+type Groups map[GroupId]*Group
+
+// LoadGroups loads the hidden path groups from the json file.
+func LoadGroups(path string) (Groups, error) {
+	raw, err := ioutil.ReadFile(path)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to read hidden path groups", err,
+			"path", path)
+	}
+	return ParseGroups(raw)
+}
+
+// ParseGroups parses the hidden path groups from the raw json.
+func ParseGroups(raw []byte) (Groups, error) {
+	var list []*Group
+	if err := json.Unmarshal(raw, &list); err != nil {
+		return nil, common.NewBasicError("Unable to parse hidden path groups", err)
+	}
+	groups := make(Groups, len(list))
+	for _, g := range list {
+		if err := g.Validate(); err != nil {
+			return nil, err
+		}
+		if _, ok := groups[g.Id]; ok {
+			return nil, common.NewBasicError("Duplicate group id", nil, "id", g.Id)
+		}
+		groups[g.Id] = g
+	}
+	return groups, nil
+}
+
+// Writable returns the groups the AS is a writer of.
+func (g Groups) Writable(ia addr.IA) []*Group {
+	return g.filter(func(group *Group) bool { return group.HasWriter(ia) })
+}
+
+// Readable returns the groups the AS is a reader of.
+func (g Groups) Readable(ia addr.IA) []*Group {
+	return g.filter(func(group *Group) bool { return group.HasReader(ia) })
+}
+
+// Registered returns the groups the AS is a registry of.
+func (g Groups) Registered(ia addr.IA) []*Group {
+	return g.filter(func(group *Group) bool { return group.HasRegistry(ia) })
+}
+
+// filter returns the groups that match the predicate, sorted by id.
+func (g Groups) filter(pred func(*Group) bool) []*Group {
+	var result []*Group
+	for _, group := range g {
+		if pred(group) {
+			result = append(result, group)
+		}
+	}
+	sort.Slice(result, func(i, j int) bool {
+		a, b := result[i].Id, result[j].Id
+		return a.OwnerAS < b.OwnerAS || (a.OwnerAS == b.OwnerAS && a.Suffix < b.Suffix)
+	})
+	return result
+}
+
+// RegistryMapping maps the registries to the ids of the provided groups they
+// serve.
+func RegistryMapping(groups []*Group) map[addr.IA][]GroupId {
+	mapping := make(map[addr.IA][]GroupId)
+	for _, group := range groups {
+		for _, registry := range group.Registries {
+			mapping[registry] = append(mapping[registry], group.Id)
+		}
+	}
+	return mapping
+}
@@ -0,0 +1,131 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package hiddenpath_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+var (
+	as110 = xtest.MustParseIA("1-ff00:0:110")
+	as111 = xtest.MustParseIA("1-ff00:0:111")
+	as112 = xtest.MustParseIA("1-ff00:0:112")
+	as113 = xtest.MustParseIA("1-ff00:0:113")
+	as120 = xtest.MustParseIA("1-ff00:0:120")
+
+	group1    = hiddenpath.GroupId{OwnerAS: as110.A, Suffix: 0x1}
+	group69b5 = hiddenpath.GroupId{OwnerAS: as110.A, Suffix: 0x69b5}
+)
+
+func TestParseGroupId(t *testing.T) {
+	tests := map[string]struct {
+		Input     string
+		Expected  hiddenpath.GroupId
+		Assertion assert.ErrorAssertionFunc
+	}{
+		"valid": {
+			Input:     "ff00:0:110-69b5",
+			Expected:  group69b5,
+			Assertion: assert.NoError,
+		},
+		"missing separator": {
+			Input:     "ff00:0:110",
+			Assertion: assert.Error,
+		},
+		"invalid owner": {
+			Input:     "1-ff00:0:110:1-1",
+			Assertion: assert.Error,
+		},
+		"suffix too large": {
+			Input:     "ff00:0:110-10000",
+			Assertion: assert.Error,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			id, err := hiddenpath.ParseGroupId(test.Input)
+			test.Assertion(t, err)
+			assert.Equal(t, test.Expected, id)
+		})
+	}
+}
+
+func TestGroupIdConversion(t *testing.T) {
+	assert.Equal(t, group69b5, hiddenpath.IdFromMsg(group69b5.ToMsg()))
+	cfgID := group69b5.HPCfgID()
+	assert.Equal(t, as110.A, cfgID.IA.A)
+	assert.Equal(t, uint64(0x69b5), cfgID.ID)
+	assert.Equal(t, "ff00:0:110-69b5", group69b5.String())
+}
+
+func TestLoadGroups(t *testing.T) {
+	groups, err := hiddenpath.LoadGroups("testdata/groups.json")
+	require.NoError(t, err)
+	require.Len(t, groups, 2)
+	g := groups[group69b5]
+	require.NotNil(t, g)
+	assert.Equal(t, uint32(1), g.Version)
+	assert.Equal(t, as110, g.Owner)
+	assert.True(t, g.HasWriter(as111))
+	assert.False(t, g.HasWriter(as113))
+	assert.True(t, g.HasReader(as113))
+	assert.False(t, g.HasReader(as111))
+	assert.True(t, g.HasRegistry(as110))
+
+	assert.Equal(t, []*hiddenpath.Group{groups[group1], groups[group69b5]},
+		groups.Writable(as111))
+	assert.Equal(t, []*hiddenpath.Group{groups[group69b5]}, groups.Writable(as112))
+	assert.Equal(t, []*hiddenpath.Group{groups[group1]}, groups.Readable(as112))
+	assert.Empty(t, groups.Readable(as111))
+	assert.Equal(t, []*hiddenpath.Group{groups[group1]}, groups.Registered(as120))
+
+	mapping := hiddenpath.RegistryMapping(groups.Readable(as113))
+	assert.Equal(t, map[addr.IA][]hiddenpath.GroupId{
+		as110: {group1, group69b5},
+		as120: {group1},
+	}, mapping)
+}
+
+func TestParseGroupsInvalid(t *testing.T) {
+	tests := map[string]string{
+		"owner mismatch": `[{"Id": "ff00:0:110-1", "Owner": "1-ff00:0:111",
+			"Writers": ["1-ff00:0:111"], "Readers": ["1-ff00:0:112"],
+			"Registries": ["1-ff00:0:110"]}]`,
+		"no registries": `[{"Id": "ff00:0:110-1", "Owner": "1-ff00:0:110",
+			"Writers": ["1-ff00:0:111"], "Readers": ["1-ff00:0:112"]}]`,
+		"duplicate id": `[{"Id": "ff00:0:110-1", "Owner": "1-ff00:0:110",
+			"Writers": ["1-ff00:0:111"], "Readers": ["1-ff00:0:112"],
+			"Registries": ["1-ff00:0:110"]},
+			{"Id": "ff00:0:110-1", "Owner": "1-ff00:0:110",
+			"Writers": ["1-ff00:0:111"], "Readers": ["1-ff00:0:112"],
+			"Registries": ["1-ff00:0:110"]}]`,
+		"invalid id": `[{"Id": "ff00:0:110", "Owner": "1-ff00:0:110",
+			"Writers": ["1-ff00:0:111"], "Readers": ["1-ff00:0:112"],
+			"Registries": ["1-ff00:0:110"]}]`,
+	}
+	for name, raw := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := hiddenpath.ParseGroups([]byte(raw))
+			assert.Error(t, err)
+		})
+	}
+}
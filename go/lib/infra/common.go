@@ -146,6 +146,11 @@ const (
 	ChainIssueRequest
 	ChainIssueReply
 	Ack
+	HPSegReg
+	HPSegRequest
+	HPSegReply
+	CRL
+	CRLRequest
 )
 
 func (mt MessageType) String() string {
@@ -192,6 +197,16 @@ func (mt MessageType) String() string {
 		return "ChainIssueReply"
 	case Ack:
 		return "Ack"
+	case HPSegReg:
+		return "HPSegReg"
+	case HPSegRequest:
+		return "HPSegRequest"
+	case HPSegReply:
+		return "HPSegReply"
+	case CRL:
+		return "CRL"
+	case CRLRequest:
+		return "CRLRequest"
 	default:
 		return fmt.Sprintf("Unknown (%d)", mt)
 	}
@@ -243,6 +258,16 @@ func (mt MessageType) MetricLabel() string {
 		return "chain_issue_push"
 	case Ack:
 		return "ack_push"
+	case HPSegReg:
+		return "hp_seg_reg_push"
+	case HPSegRequest:
+		return "hp_seg_req"
+	case HPSegReply:
+		return "hp_seg_push"
+	case CRL:
+		return "crl_push"
+	case CRLRequest:
+		return "crl_req"
 	default:
 		return "unknown_mt"
 	}
@@ -299,6 +324,12 @@ type Messenger interface {
 		id uint64) (*cert_mgmt.Chain, error)
 	// SendCertChain sends a reliable cert_mgmt.Chain to address a.
 	SendCertChain(ctx context.Context, msg *cert_mgmt.Chain, a net.Addr, id uint64) error
+	// GetCRL sends a cert_mgmt.CRLReq to address a, blocks until it receives a
+	// reply and returns the reply.
+	GetCRL(ctx context.Context, msg *cert_mgmt.CRLReq, a net.Addr,
+		id uint64) (*cert_mgmt.CRL, error)
+	// SendCRL sends a reliable cert_mgmt.CRL to address a.
+	SendCRL(ctx context.Context, msg *cert_mgmt.CRL, a net.Addr, id uint64) error
 	// SendIfId sends a reliable ifid.IFID to address a.
 	SendIfId(ctx context.Context, msg *ifid.IFID, a net.Addr, id uint64) error
 	// SendIfStateInfos sends a reliable path_mgmt.IfStateInfos to address a.
@@ -313,6 +344,14 @@ type Messenger interface {
 		id uint64) (*path_mgmt.SegReply, error)
 	// SendSegReply sends a reliable path_mgmt.SegReply to address a.
 	SendSegReply(ctx context.Context, msg *path_mgmt.SegReply, a net.Addr, id uint64) error
+	// SendHPSegReg sends a reliable path_mgmt.HPSegReg to a.
+	SendHPSegReg(ctx context.Context, msg *path_mgmt.HPSegReg, a net.Addr, id uint64) error
+	// GetHPSegs asks the hidden path registry at the remote address for the
+	// hidden path segments that satisfy msg, and returns the reply.
+	GetHPSegs(ctx context.Context, msg *path_mgmt.HPSegReq, a net.Addr,
+		id uint64) (*path_mgmt.HPSegReply, error)
+	// SendHPSegReply sends a reliable path_mgmt.HPSegReply to address a.
+	SendHPSegReply(ctx context.Context, msg *path_mgmt.HPSegReply, a net.Addr, id uint64) error
 	// SendSegSync sends a reliable path_mgmt.SegSync to address a.
 	SendSegSync(ctx context.Context, msg *path_mgmt.SegSync, a net.Addr, id uint64) error
 	GetSegChangesIds(ctx context.Context, msg *path_mgmt.SegChangesIdReq,
@@ -339,8 +378,10 @@ type ResponseWriter interface {
 	SendAckReply(ctx context.Context, msg *ack.Ack) error
 	SendTRCReply(ctx context.Context, msg *cert_mgmt.TRC) error
 	SendCertChainReply(ctx context.Context, msg *cert_mgmt.Chain) error
+	SendCRLReply(ctx context.Context, msg *cert_mgmt.CRL) error
 	SendChainIssueReply(ctx context.Context, msg *cert_mgmt.ChainIssRep) error
 	SendSegReply(ctx context.Context, msg *path_mgmt.SegReply) error
+	SendHPSegReply(ctx context.Context, msg *path_mgmt.HPSegReply) error
 	SendIfStateInfoReply(ctx context.Context, msg *path_mgmt.IFStateInfos) error
 }
 
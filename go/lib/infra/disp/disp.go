@@ -167,6 +167,15 @@ func (d *Dispatcher) RecvFrom(ctx context.Context) (proto.Cerealizable, net.Addr
 	case <-d.closedChan:
 		// Some other goroutine closed the dispatcher
 		return nil, nil, common.NewBasicError(infra.StrClosedError, nil)
+	case <-d.stoppedChan:
+		// The transport was closed by the remote end. Messages that were
+		// read before are still returned.
+		select {
+		case event := <-d.readEvents:
+			return event.msg, event.address, nil
+		default:
+			return nil, nil, common.NewBasicError(infra.StrClosedError, nil)
+		}
 	}
 }
 
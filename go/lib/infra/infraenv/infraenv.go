@@ -18,6 +18,7 @@ package infraenv
 
 import (
 	"bytes"
+	"context"
 	"crypto/tls"
 	"net"
 	"time"
@@ -28,10 +29,15 @@ import (
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/disp"
 	"github.com/scionproto/scion/go/lib/infra/messenger"
+	"github.com/scionproto/scion/go/lib/infra/modules/trust"
+	"github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb"
+	"github.com/scionproto/scion/go/lib/keyconf"
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/pathmgr"
 	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/scrypto"
 	"github.com/scionproto/scion/go/lib/snet"
+	"github.com/scionproto/scion/go/lib/snet/squic"
 	"github.com/scionproto/scion/go/lib/sock/reliable"
 	"github.com/scionproto/scion/go/lib/sock/reliable/reconnect"
 	"github.com/scionproto/scion/go/lib/svc"
@@ -41,15 +47,21 @@ const (
 	ErrAppUnableToInitMessenger = "Unable to initialize SCION Infra Messenger"
 )
 
+// quicPKISignerTimeout bounds the time to obtain the local certificate chain
+// when creating a new QUIC certificate.
+const quicPKISignerTimeout = 5 * time.Second
+
 var resolutionRequestPayload = []byte{0x00, 0x00, 0x00, 0x00}
 
 // QUIC contains the QUIC configuration for control-plane speakers.
 type QUIC struct {
 	// Address is the UDP address to start the QUIC server on.
 	Address string
-	// CertFile is the certificate to use for QUIC authentication.
+	// CertFile is the certificate to use for QUIC authentication. It is only
+	// used if NetworkConfig.QUICPKI is not set or verify-only.
 	CertFile string
-	// KeyFile is the private key to use for QUIC authentication.
+	// KeyFile is the private key to use for QUIC authentication. It is only
+	// used if NetworkConfig.QUICPKI is not set or verify-only.
 	KeyFile string
 }
 
@@ -75,6 +87,11 @@ type NetworkConfig struct {
 	// QUIC contains configuration details for QUIC servers. If the listening
 	// address is the empty string, then no QUIC socket is opened.
 	QUIC QUIC
+	// QUICPKI, if not nil, authenticates QUIC sessions with the control-plane
+	// PKI. The certificate and key files in QUIC are then not used. A
+	// verify-only PKI only authenticates the servers this endpoint connects
+	// to, and the QUIC server keeps using the certificate and key files.
+	QUICPKI *squic.PKI
 	// SVCResolutionFraction can be used to customize whether SVC resolution is
 	// enabled.
 	SVCResolutionFraction float64
@@ -180,7 +197,7 @@ func (nc *NetworkConfig) initUDPSocket(quicAddress string) (net.PacketConn, erro
 		return nil, common.NewBasicError("Unable to build SVC resolution reply", err)
 	}
 
-	dispatcherService := reliable.NewDispatcherService("")
+	dispatcherService := reliable.NewDispatcherServiceV2("", reliable.V2SeqPacket)
 	if nc.ReconnectToDispatcher {
 		dispatcherService = reconnect.NewDispatcherService(dispatcherService)
 	}
@@ -207,7 +224,7 @@ func (nc *NetworkConfig) initUDPSocket(quicAddress string) (net.PacketConn, erro
 }
 
 func (nc *NetworkConfig) initQUICSocket() (net.PacketConn, error) {
-	dispatcherService := reliable.NewDispatcherService("")
+	dispatcherService := reliable.NewDispatcherServiceV2("", reliable.V2SeqPacket)
 	if nc.ReconnectToDispatcher {
 		dispatcherService = reconnect.NewDispatcherService(dispatcherService)
 	}
@@ -241,17 +258,63 @@ func (nc *NetworkConfig) initQUICSocket() (net.PacketConn, error) {
 }
 
 func (nc *NetworkConfig) buildQUICConfig(conn net.PacketConn) (*messenger.QUICConfig, error) {
+	if nc.QUICPKI != nil && !nc.QUICPKI.VerifyOnly() {
+		return &messenger.QUICConfig{
+			Conn:          conn,
+			TLSConfig:     nc.QUICPKI.TLSConfig(),
+			PeerTLSConfig: nc.QUICPKI.TLSConfigFor,
+		}, nil
+	}
 	cert, err := tls.LoadX509KeyPair(nc.QUIC.CertFile, nc.QUIC.KeyFile)
 	if err != nil {
 		return nil, err
 	}
-	return &messenger.QUICConfig{
+	quicCfg := &messenger.QUICConfig{
 		Conn: conn,
 		TLSConfig: &tls.Config{
 			Certificates:       []tls.Certificate{cert},
 			InsecureSkipVerify: true,
 		},
-	}, nil
+	}
+	if nc.QUICPKI != nil {
+		quicCfg.PeerTLSConfig = nc.QUICPKI.TLSConfigFor
+	}
+	return quicCfg, nil
+}
+
+// NewQUICPKI returns a PKI that authenticates QUIC sessions with the
+// control-plane PKI. Certificates are signed with the AS signing key in keyDir
+// on behalf of the newest certificate chain of ia, such that reissued chains
+// are picked up automatically. Peers are verified with the trust store.
+func NewQUICPKI(keyDir string, ia addr.IA, store *trust.Store,
+	db trustdb.TrustDB) (*squic.PKI, error) {
+
+	keys, err := keyconf.Load(keyDir, false, false, false, false)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to load key config", err)
+	}
+	signerF := func() (infra.Signer, error) {
+		ctx, cancelF := context.WithTimeout(context.Background(), quicPKISignerTimeout)
+		defer cancelF()
+		// Make sure the newest local chain is in the database. Services that
+		// do not load the chain from disk fetch it from the local CS.
+		if _, err := store.GetChain(ctx, ia, scrypto.LatestVer); err != nil {
+			return nil, common.NewBasicError("Unable to get local certificate chain", err)
+		}
+		meta, err := trust.CreateSignMeta(ctx, ia, db)
+		if err != nil {
+			return nil, common.NewBasicError("Unable to create sign meta", err)
+		}
+		return trust.NewBasicSigner(keys.SignKey, meta)
+	}
+	return squic.NewPKIWithSignerFunc(signerF, store.NewVerifier()), nil
+}
+
+// NewVerifyOnlyQUICPKI returns a PKI for endpoints that only connect to
+// control-plane servers, such as SCIOND. It verifies the servers with the
+// trust store, and requires no signing key.
+func NewVerifyOnlyQUICPKI(store *trust.Store) *squic.PKI {
+	return squic.NewVerifyOnlyPKI(store.NewVerifier())
 }
 
 func buildLocalMachine(bind, public *snet.Addr) snet.LocalMachine {
@@ -34,6 +34,11 @@
 //  infra.SegSync             -> ctrl.SignedPld/ctrl.Pld/path_mgmt.SegSync
 //  infra.ChainIssueRequest   -> ctrl.SignedPld/ctrl.Pld/cert_mgmt.ChainIssReq
 //  infra.ChainIssueReply     -> ctrl.SignedPld/ctrl.Pld/cert_mgmt.ChainIssRep
+//  infra.HPSegReg            -> ctrl.SignedPld/ctrl.Pld/path_mgmt.HPSegReg
+//  infra.HPSegRequest        -> ctrl.SignedPld/ctrl.Pld/path_mgmt.HPSegReq
+//  infra.HPSegReply          -> ctrl.SignedPld/ctrl.Pld/path_mgmt.HPSegReply
+//  infra.CRLRequest          -> ctrl.SignedPld/ctrl.Pld/cert_mgmt.CRLReq
+//  infra.CRL                 -> ctrl.SignedPld/ctrl.Pld/cert_mgmt.CRL
 //
 // To start processing messages received via the Messenger, call
 // ListenAndServe. The method runs in the current goroutine, and spawns new
@@ -132,9 +137,12 @@ type Config struct {
 }
 
 type QUICConfig struct {
-	Conn       net.PacketConn
-	TLSConfig  *tls.Config
-	QUICConfig *quic.Config
+	Conn      net.PacketConn
+	TLSConfig *tls.Config
+	// PeerTLSConfig, if not nil, returns the TLS configuration for requests
+	// to the AS ia. If nil, TLSConfig is used for all requests.
+	PeerTLSConfig func(ia addr.IA) *tls.Config
+	QUICConfig    *quic.Config
 }
 
 func (c *Config) InitDefaults() {
@@ -202,9 +210,10 @@ func New(config *Config) *Messenger {
 
 	if config.QUIC != nil {
 		quicClient = &rpc.Client{
-			Conn:       config.QUIC.Conn,
-			TLSConfig:  config.QUIC.TLSConfig,
-			QUICConfig: config.QUIC.QUICConfig,
+			Conn:          config.QUIC.Conn,
+			TLSConfig:     config.QUIC.TLSConfig,
+			PeerTLSConfig: config.QUIC.PeerTLSConfig,
+			QUICConfig:    config.QUIC.QUICConfig,
 		}
 		quicHandler = &QUICHandler{
 			handlers:     make(map[infra.MessageType]infra.Handler),
@@ -332,6 +341,44 @@ func (m *Messenger) SendCertChain(ctx context.Context, msg *cert_mgmt.Chain, a n
 	return m.sendMessage(ctx, pld, a, id, infra.Chain)
 }
 
+func (m *Messenger) GetCRL(ctx context.Context, msg *cert_mgmt.CRLReq,
+	a net.Addr, id uint64) (*cert_mgmt.CRL, error) {
+
+	pld, err := ctrl.NewCertMgmtPld(msg, nil, &ctrl.Data{ReqId: id, TraceId: traceId(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	logger := log.FromCtx(ctx)
+	logger.Trace("[Messenger] Sending request", "req_type", infra.CRLRequest,
+		"msg_id", id, "request", msg, "peer", a)
+	replyCtrlPld, err := m.getFallbackRequester(infra.CRLRequest).Request(ctx, pld, a, false)
+	if err != nil {
+		return nil, common.NewBasicError("[Messenger] Request error", err)
+	}
+	_, replyMsg, err := validate(replyCtrlPld)
+	if err != nil {
+		return nil, common.NewBasicError("[Messenger] Reply validation failed", err)
+	}
+	switch reply := replyMsg.(type) {
+	case *cert_mgmt.CRL:
+		logger.Trace("[Messenger] Received reply", "req_id", id, "reply", reply)
+		return reply, nil
+	case *ack.Ack:
+		return nil, &infra.Error{Message: reply}
+	default:
+		err := newTypeAssertErr("*cert_mgmt.CRL", replyMsg)
+		return nil, common.NewBasicError("[Messenger] Type assertion failed", err)
+	}
+}
+
+func (m *Messenger) SendCRL(ctx context.Context, msg *cert_mgmt.CRL, a net.Addr, id uint64) error {
+	pld, err := cert_mgmt.NewPld(msg, nil)
+	if err != nil {
+		return err
+	}
+	return m.sendMessage(ctx, pld, a, id, infra.CRL)
+}
+
 func (m *Messenger) SendIfId(ctx context.Context, msg *ifid.IFID, a net.Addr, id uint64) error {
 	return m.sendMessage(ctx, msg, a, id, infra.IfId)
 }
@@ -428,6 +475,61 @@ func (m *Messenger) SendSegReply(ctx context.Context, msg *path_mgmt.SegReply,
 	return m.getFallbackRequester(infra.SegReply).Notify(ctx, pld, a)
 }
 
+func (m *Messenger) SendHPSegReg(ctx context.Context, msg *path_mgmt.HPSegReg,
+	a net.Addr, id uint64) error {
+
+	pld, err := path_mgmt.NewPld(msg, nil)
+	if err != nil {
+		return err
+	}
+	return m.sendMessage(ctx, pld, a, id, infra.HPSegReg)
+}
+
+func (m *Messenger) GetHPSegs(ctx context.Context, msg *path_mgmt.HPSegReq,
+	a net.Addr, id uint64) (*path_mgmt.HPSegReply, error) {
+
+	logger := log.FromCtx(ctx)
+	pld, err := ctrl.NewPathMgmtPld(msg, nil, &ctrl.Data{ReqId: id, TraceId: traceId(ctx)})
+	if err != nil {
+		return nil, err
+	}
+	logger.Trace("[Messenger] Sending request", "req_type", infra.HPSegRequest,
+		"msg_id", id, "request", msg, "peer", a)
+	replyCtrlPld, err := m.getFallbackRequester(infra.HPSegRequest).Request(ctx, pld, a, false)
+	if err != nil {
+		return nil, common.NewBasicError("[Messenger] Request error", err)
+	}
+	_, replyMsg, err := validate(replyCtrlPld)
+	if err != nil {
+		return nil, common.NewBasicError("[Messenger] Reply validation failed", err)
+	}
+	switch reply := replyMsg.(type) {
+	case *path_mgmt.HPSegReply:
+		if err := reply.ParseRaw(); err != nil {
+			return nil, common.NewBasicError("[Messenger] Failed to parse reply", err)
+		}
+		logger.Trace("[Messenger] Received reply", "req_id", id)
+		return reply, nil
+	case *ack.Ack:
+		return nil, &infra.Error{Message: reply}
+	default:
+		err := newTypeAssertErr("*path_mgmt.HPSegReply", replyMsg)
+		return nil, common.NewBasicError("[Messenger] Type assertion failed", err)
+	}
+}
+
+func (m *Messenger) SendHPSegReply(ctx context.Context, msg *path_mgmt.HPSegReply,
+	a net.Addr, id uint64) error {
+
+	pld, err := ctrl.NewPathMgmtPld(msg, nil, &ctrl.Data{ReqId: id})
+	if err != nil {
+		return err
+	}
+	logger := log.FromCtx(ctx)
+	logger.Trace("[Messenger] Sending Notify", "type", infra.HPSegReply, "to", a, "id", id)
+	return m.getFallbackRequester(infra.HPSegReply).Notify(ctx, pld, a)
+}
+
 func (m *Messenger) SendSegSync(ctx context.Context, msg *path_mgmt.SegSync,
 	a net.Addr, id uint64) error {
 
@@ -969,6 +1071,10 @@ func validate(pld *ctrl.Pld) (infra.MessageType, proto.Cerealizable, error) {
 			return infra.ChainIssueRequest, pld.CertMgmt.ChainIssReq, nil
 		case proto.CertMgmt_Which_certChainIssRep:
 			return infra.ChainIssueReply, pld.CertMgmt.ChainIssRep, nil
+		case proto.CertMgmt_Which_crlReq:
+			return infra.CRLRequest, pld.CertMgmt.CRLReq, nil
+		case proto.CertMgmt_Which_crl:
+			return infra.CRL, pld.CertMgmt.CRLRep, nil
 		default:
 			return infra.None, nil,
 				common.NewBasicError("Unsupported SignedPld.CtrlPld.CertMgmt.Xxx message type",
@@ -998,6 +1104,12 @@ func validate(pld *ctrl.Pld) (infra.MessageType, proto.Cerealizable, error) {
 			return infra.SegChangesReq, pld.PathMgmt.SegChangesReq, nil
 		case proto.PathMgmt_Which_segChangesReply:
 			return infra.SegChangesReply, pld.PathMgmt.SegChangesReply, nil
+		case proto.PathMgmt_Which_hpSegReq:
+			return infra.HPSegRequest, pld.PathMgmt.HPSegReq, nil
+		case proto.PathMgmt_Which_hpSegReply:
+			return infra.HPSegReply, pld.PathMgmt.HPSegReply, nil
+		case proto.PathMgmt_Which_hpSegReg:
+			return infra.HPSegReg, pld.PathMgmt.HPSegReg, nil
 		default:
 			return infra.None, nil,
 				common.NewBasicError("Unsupported SignedPld.CtrlPld.PathMgmt.Xxx message type",
@@ -93,6 +93,25 @@ func (m *MessengerWithMetrics) SendCertChain(ctx context.Context, msg *cert_mgmt
 	})
 }
 
+func (m *MessengerWithMetrics) GetCRL(ctx context.Context, msg *cert_mgmt.CRLReq,
+	a net.Addr, id uint64) (*cert_mgmt.CRL, error) {
+
+	var crl *cert_mgmt.CRL
+	return crl, observe(ctx, infra.CRLRequest, func(ctx context.Context) error {
+		var err error
+		crl, err = m.messenger.GetCRL(ctx, msg, a, id)
+		return err
+	})
+}
+
+func (m *MessengerWithMetrics) SendCRL(ctx context.Context, msg *cert_mgmt.CRL, a net.Addr,
+	id uint64) error {
+
+	return observe(ctx, infra.CRL, func(ctx context.Context) error {
+		return m.messenger.SendCRL(ctx, msg, a, id)
+	})
+}
+
 func (m *MessengerWithMetrics) SendIfId(ctx context.Context, msg *ifid.IFID, a net.Addr,
 	id uint64) error {
 
@@ -144,6 +163,34 @@ func (m *MessengerWithMetrics) SendSegReply(ctx context.Context, msg *path_mgmt.
 	})
 }
 
+func (m *MessengerWithMetrics) SendHPSegReg(ctx context.Context, msg *path_mgmt.HPSegReg,
+	a net.Addr, id uint64) error {
+
+	return observe(ctx, infra.HPSegReg, func(ctx context.Context) error {
+		return m.messenger.SendHPSegReg(ctx, msg, a, id)
+	})
+}
+
+func (m *MessengerWithMetrics) GetHPSegs(ctx context.Context, msg *path_mgmt.HPSegReq,
+	a net.Addr, id uint64) (*path_mgmt.HPSegReply, error) {
+
+	var segs *path_mgmt.HPSegReply
+	err := observe(ctx, infra.HPSegRequest, func(ctx context.Context) error {
+		var err error
+		segs, err = m.messenger.GetHPSegs(ctx, msg, a, id)
+		return err
+	})
+	return segs, err
+}
+
+func (m *MessengerWithMetrics) SendHPSegReply(ctx context.Context, msg *path_mgmt.HPSegReply,
+	a net.Addr, id uint64) error {
+
+	return observe(ctx, infra.HPSegReply, func(ctx context.Context) error {
+		return m.messenger.SendHPSegReply(ctx, msg, a, id)
+	})
+}
+
 func (m *MessengerWithMetrics) SendSegSync(ctx context.Context, msg *path_mgmt.SegSync,
 	a net.Addr, id uint64) error {
 
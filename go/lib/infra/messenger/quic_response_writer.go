@@ -74,6 +74,19 @@ func (rw *QUICResponseWriter) SendCertChainReply(ctx context.Context, msg *cert_
 	return rw.sendMessage(ctrlPld)
 }
 
+func (rw *QUICResponseWriter) SendCRLReply(ctx context.Context, msg *cert_mgmt.CRL) error {
+	go func() {
+		defer log.LogPanicAndExit()
+		<-ctx.Done()
+		rw.ReplyWriter.Close()
+	}()
+	ctrlPld, err := ctrl.NewCertMgmtPld(msg, nil, &ctrl.Data{ReqId: rw.ID})
+	if err != nil {
+		return err
+	}
+	return rw.sendMessage(ctrlPld)
+}
+
 func (rw *QUICResponseWriter) SendChainIssueReply(ctx context.Context,
 	msg *cert_mgmt.ChainIssRep) error {
 
@@ -102,6 +115,21 @@ func (rw *QUICResponseWriter) SendSegReply(ctx context.Context, msg *path_mgmt.S
 	return rw.sendMessage(ctrlPld)
 }
 
+func (rw *QUICResponseWriter) SendHPSegReply(ctx context.Context,
+	msg *path_mgmt.HPSegReply) error {
+
+	go func() {
+		defer log.LogPanicAndExit()
+		<-ctx.Done()
+		rw.ReplyWriter.Close()
+	}()
+	ctrlPld, err := ctrl.NewPathMgmtPld(msg, nil, &ctrl.Data{ReqId: rw.ID})
+	if err != nil {
+		return err
+	}
+	return rw.sendMessage(ctrlPld)
+}
+
 func (rw *QUICResponseWriter) SendIfStateInfoReply(ctx context.Context,
 	msg *path_mgmt.IFStateInfos) error {
 
@@ -44,6 +44,10 @@ func (rw *UDPResponseWriter) SendCertChainReply(ctx context.Context, msg *cert_m
 	return rw.Messenger.SendCertChain(ctx, msg, rw.Remote, rw.ID)
 }
 
+func (rw *UDPResponseWriter) SendCRLReply(ctx context.Context, msg *cert_mgmt.CRL) error {
+	return rw.Messenger.SendCRL(ctx, msg, rw.Remote, rw.ID)
+}
+
 func (rw *UDPResponseWriter) SendChainIssueReply(ctx context.Context,
 	msg *cert_mgmt.ChainIssRep) error {
 
@@ -54,6 +58,12 @@ func (rw *UDPResponseWriter) SendSegReply(ctx context.Context, msg *path_mgmt.Se
 	return rw.Messenger.SendSegReply(ctx, msg, rw.Remote, rw.ID)
 }
 
+func (rw *UDPResponseWriter) SendHPSegReply(ctx context.Context,
+	msg *path_mgmt.HPSegReply) error {
+
+	return rw.Messenger.SendHPSegReply(ctx, msg, rw.Remote, rw.ID)
+}
+
 func (rw *UDPResponseWriter) SendIfStateInfoReply(ctx context.Context,
 	msg *path_mgmt.IFStateInfos) error {
 
@@ -239,6 +239,21 @@ func (mr *MockMessengerMockRecorder) CloseServer() *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "CloseServer", reflect.TypeOf((*MockMessenger)(nil).CloseServer))
 }
 
+// GetCRL mocks base method
+func (m *MockMessenger) GetCRL(arg0 context.Context, arg1 *cert_mgmt.CRLReq, arg2 net.Addr, arg3 uint64) (*cert_mgmt.CRL, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCRL", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*cert_mgmt.CRL)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCRL indicates an expected call of GetCRL
+func (mr *MockMessengerMockRecorder) GetCRL(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCRL", reflect.TypeOf((*MockMessenger)(nil).GetCRL), arg0, arg1, arg2, arg3)
+}
+
 // GetCertChain mocks base method
 func (m *MockMessenger) GetCertChain(arg0 context.Context, arg1 *cert_mgmt.ChainReq, arg2 net.Addr, arg3 uint64) (*cert_mgmt.Chain, error) {
 	m.ctrl.T.Helper()
@@ -254,6 +269,21 @@ func (mr *MockMessengerMockRecorder) GetCertChain(arg0, arg1, arg2, arg3 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCertChain", reflect.TypeOf((*MockMessenger)(nil).GetCertChain), arg0, arg1, arg2, arg3)
 }
 
+// GetHPSegs mocks base method
+func (m *MockMessenger) GetHPSegs(arg0 context.Context, arg1 *path_mgmt.HPSegReq, arg2 net.Addr, arg3 uint64) (*path_mgmt.HPSegReply, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetHPSegs", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(*path_mgmt.HPSegReply)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetHPSegs indicates an expected call of GetHPSegs
+func (mr *MockMessengerMockRecorder) GetHPSegs(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetHPSegs", reflect.TypeOf((*MockMessenger)(nil).GetHPSegs), arg0, arg1, arg2, arg3)
+}
+
 // GetSegChanges mocks base method
 func (m *MockMessenger) GetSegChanges(arg0 context.Context, arg1 *path_mgmt.SegChangesReq, arg2 net.Addr, arg3 uint64) (*path_mgmt.SegChangesReply, error) {
 	m.ctrl.T.Helper()
@@ -369,6 +399,20 @@ func (mr *MockMessengerMockRecorder) SendBeacon(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendBeacon", reflect.TypeOf((*MockMessenger)(nil).SendBeacon), arg0, arg1, arg2, arg3)
 }
 
+// SendCRL mocks base method
+func (m *MockMessenger) SendCRL(arg0 context.Context, arg1 *cert_mgmt.CRL, arg2 net.Addr, arg3 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendCRL", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendCRL indicates an expected call of SendCRL
+func (mr *MockMessengerMockRecorder) SendCRL(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCRL", reflect.TypeOf((*MockMessenger)(nil).SendCRL), arg0, arg1, arg2, arg3)
+}
+
 // SendCertChain mocks base method
 func (m *MockMessenger) SendCertChain(arg0 context.Context, arg1 *cert_mgmt.Chain, arg2 net.Addr, arg3 uint64) error {
 	m.ctrl.T.Helper()
@@ -397,6 +441,34 @@ func (mr *MockMessengerMockRecorder) SendChainIssueReply(arg0, arg1, arg2, arg3
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendChainIssueReply", reflect.TypeOf((*MockMessenger)(nil).SendChainIssueReply), arg0, arg1, arg2, arg3)
 }
 
+// SendHPSegReg mocks base method
+func (m *MockMessenger) SendHPSegReg(arg0 context.Context, arg1 *path_mgmt.HPSegReg, arg2 net.Addr, arg3 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHPSegReg", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHPSegReg indicates an expected call of SendHPSegReg
+func (mr *MockMessengerMockRecorder) SendHPSegReg(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHPSegReg", reflect.TypeOf((*MockMessenger)(nil).SendHPSegReg), arg0, arg1, arg2, arg3)
+}
+
+// SendHPSegReply mocks base method
+func (m *MockMessenger) SendHPSegReply(arg0 context.Context, arg1 *path_mgmt.HPSegReply, arg2 net.Addr, arg3 uint64) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHPSegReply", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHPSegReply indicates an expected call of SendHPSegReply
+func (mr *MockMessengerMockRecorder) SendHPSegReply(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHPSegReply", reflect.TypeOf((*MockMessenger)(nil).SendHPSegReply), arg0, arg1, arg2, arg3)
+}
+
 // SendIfId mocks base method
 func (m *MockMessenger) SendIfId(arg0 context.Context, arg1 *ifid.IFID, arg2 net.Addr, arg3 uint64) error {
 	m.ctrl.T.Helper()
@@ -584,6 +656,20 @@ func (mr *MockResponseWriterMockRecorder) SendAckReply(arg0, arg1 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendAckReply", reflect.TypeOf((*MockResponseWriter)(nil).SendAckReply), arg0, arg1)
 }
 
+// SendCRLReply mocks base method
+func (m *MockResponseWriter) SendCRLReply(arg0 context.Context, arg1 *cert_mgmt.CRL) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendCRLReply", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendCRLReply indicates an expected call of SendCRLReply
+func (mr *MockResponseWriterMockRecorder) SendCRLReply(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendCRLReply", reflect.TypeOf((*MockResponseWriter)(nil).SendCRLReply), arg0, arg1)
+}
+
 // SendCertChainReply mocks base method
 func (m *MockResponseWriter) SendCertChainReply(arg0 context.Context, arg1 *cert_mgmt.Chain) error {
 	m.ctrl.T.Helper()
@@ -612,6 +698,20 @@ func (mr *MockResponseWriterMockRecorder) SendChainIssueReply(arg0, arg1 interfa
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendChainIssueReply", reflect.TypeOf((*MockResponseWriter)(nil).SendChainIssueReply), arg0, arg1)
 }
 
+// SendHPSegReply mocks base method
+func (m *MockResponseWriter) SendHPSegReply(arg0 context.Context, arg1 *path_mgmt.HPSegReply) error {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "SendHPSegReply", arg0, arg1)
+	ret0, _ := ret[0].(error)
+	return ret0
+}
+
+// SendHPSegReply indicates an expected call of SendHPSegReply
+func (mr *MockResponseWriterMockRecorder) SendHPSegReply(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "SendHPSegReply", reflect.TypeOf((*MockResponseWriter)(nil).SendHPSegReply), arg0, arg1)
+}
+
 // SendIfStateInfoReply mocks base method
 func (m *MockResponseWriter) SendIfStateInfoReply(arg0 context.Context, arg1 *path_mgmt.IFStateInfos) error {
 	m.ctrl.T.Helper()
@@ -361,6 +361,11 @@ func (solution *PathSolution) GetFwdPathMetadata() *Path {
 	}
 	path.reverseDownSegment()
 	path.aggregateInterfaces()
+	var segments []*InputSegment
+	for _, solEdge := range solution.edges {
+		segments = append(segments, solEdge.segment)
+	}
+	path.StaticInfo = aggregateStaticInfo(segments, path.Interfaces)
 	return path
 }
 
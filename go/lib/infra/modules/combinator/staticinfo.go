@@ -0,0 +1,124 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package combinator
+
+import (
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/sciond"
+)
+
+type ifaceKey struct {
+	ia   addr.IA
+	ifid common.IFIDType
+}
+
+func keyOf(iface sciond.PathInterface) ifaceKey {
+	return ifaceKey{ia: iface.ISD_AS(), ifid: iface.IfID}
+}
+
+// aggregateStaticInfo aggregates the static info extensions of the AS entries
+// in the segments for the path with the provided interfaces. The interfaces
+// are expected in path order, i.e., consecutive interfaces 2k and 2k+1 are the
+// two ends of an inter-AS link, and consecutive interfaces 2k+1 and 2k+2 are
+// the ingress and egress interface of an AS. If no AS entry carries a static
+// info extension, nil is returned.
+func aggregateStaticInfo(segments []*InputSegment,
+	ifaces []sciond.PathInterface) *sciond.PathStaticInfo {
+
+	infos := make(map[ifaceKey]*seg.StaticInfoIface)
+	exts := make(map[addr.IA][]*seg.StaticInfoExt)
+	for _, segment := range segments {
+		for _, asEntry := range segment.ASEntries {
+			ext := asEntry.Exts.StaticInfo
+			if ext == nil {
+				continue
+			}
+			ia := asEntry.IA()
+			for _, iface := range ext.Ifaces {
+				infos[ifaceKey{ia: ia, ifid: iface.IfID}] = iface
+			}
+			exts[ia] = append(exts[ia], ext)
+		}
+	}
+	if len(exts) == 0 {
+		return nil
+	}
+	info := &sciond.PathStaticInfo{
+		Ifaces: make([]sciond.PathIfaceStaticInfo, len(ifaces)),
+	}
+	for i, iface := range ifaces {
+		if s := infos[keyOf(iface)]; s != nil {
+			info.Ifaces[i] = sciond.PathIfaceStaticInfo{
+				Latitude:  s.Latitude,
+				Longitude: s.Longitude,
+				Address:   s.Address,
+				LinkType:  s.LinkType,
+			}
+		}
+	}
+	// Inter-AS links.
+	for i := 0; i+1 < len(ifaces); i += 2 {
+		if ifaces[i].ISD_AS().Equal(ifaces[i+1].ISD_AS()) {
+			continue
+		}
+		a, b := infos[keyOf(ifaces[i])], infos[keyOf(ifaces[i+1])]
+		info.Latency += seg.LinkLatency(a, b)
+		bw := seg.LinkBandwidth(a, b)
+		if bw != 0 && (info.Bandwidth == 0 || bw < info.Bandwidth) {
+			info.Bandwidth = bw
+		}
+	}
+	// AS internal connections.
+	for i := 1; i+1 < len(ifaces); i += 2 {
+		ia := ifaces[i].ISD_AS()
+		if !ia.Equal(ifaces[i+1].ISD_AS()) {
+			continue
+		}
+		if intra := intraMetrics(exts[ia], ifaces[i].IfID, ifaces[i+1].IfID); intra != nil {
+			info.Latency += intra.Latency
+			info.InternalHops += intra.InternalHops
+		}
+	}
+	// Notes in path order, without duplicates.
+	seen := make(map[addr.IA]bool)
+	for _, iface := range ifaces {
+		ia := iface.ISD_AS()
+		if seen[ia] {
+			continue
+		}
+		seen[ia] = true
+		for _, ext := range exts[ia] {
+			if ext.Note != "" {
+				info.Notes = append(info.Notes, sciond.PathASNote{
+					RawIsdas: ia.IAInt(),
+					Note:     ext.Note,
+				})
+				break
+			}
+		}
+	}
+	return info
+}
+
+func intraMetrics(exts []*seg.StaticInfoExt, a, b common.IFIDType) *seg.StaticInfoIntra {
+	for _, ext := range exts {
+		if intra := ext.IntraMetrics(a, b); intra != nil {
+			return intra
+		}
+	}
+	return nil
+}
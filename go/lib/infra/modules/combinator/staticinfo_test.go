@@ -0,0 +1,104 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package combinator
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/proto"
+)
+
+func TestAggregateStaticInfo(t *testing.T) {
+	ia110 := xtest.MustParseIA("1-ff00:0:110")
+	ia111 := xtest.MustParseIA("1-ff00:0:111")
+	ia112 := xtest.MustParseIA("1-ff00:0:112")
+	asEntry := func(ia addr.IA, ext *seg.StaticInfoExt) *seg.ASEntry {
+		entry := &seg.ASEntry{RawIA: ia.IAInt()}
+		entry.Exts.StaticInfo = ext
+		return entry
+	}
+	ifaces := []sciond.PathInterface{
+		{RawIsdas: ia110.IAInt(), IfID: 1},
+		{RawIsdas: ia111.IAInt(), IfID: 2},
+		{RawIsdas: ia111.IAInt(), IfID: 3},
+		{RawIsdas: ia112.IAInt(), IfID: 4},
+	}
+	t.Run("no static info", func(t *testing.T) {
+		segments := []*InputSegment{
+			{
+				PathSegment: &seg.PathSegment{
+					ASEntries: []*seg.ASEntry{asEntry(ia110, nil), asEntry(ia111, nil)},
+				},
+			},
+		}
+		if info := aggregateStaticInfo(segments, ifaces); info != nil {
+			t.Errorf("Expected nil, actual %v", info)
+		}
+	})
+	t.Run("partial static info", func(t *testing.T) {
+		segments := []*InputSegment{
+			{
+				PathSegment: &seg.PathSegment{
+					ASEntries: []*seg.ASEntry{
+						asEntry(ia110, &seg.StaticInfoExt{
+							Set: true,
+							Ifaces: []*seg.StaticInfoIface{
+								{IfID: 1, LinkLatency: 1000, Bandwidth: 100},
+							},
+						}),
+						asEntry(ia111, &seg.StaticInfoExt{
+							Set: true,
+							Ifaces: []*seg.StaticInfoIface{
+								{IfID: 2, Bandwidth: 50, Address: "Bern"},
+								{
+									IfID:        3,
+									LinkLatency: 2000,
+									Bandwidth:   40,
+									LinkType:    proto.StaticInfoIface_LinkType_opennet,
+								},
+							},
+							Intra: []*seg.StaticInfoIntra{
+								{FromIfID: 3, ToIfID: 2, Latency: 500, InternalHops: 2},
+							},
+							Note: "transit",
+						}),
+						asEntry(ia112, nil),
+					},
+				},
+			},
+		}
+		expected := &sciond.PathStaticInfo{
+			Latency:      3500,
+			Bandwidth:    40,
+			InternalHops: 2,
+			Ifaces: []sciond.PathIfaceStaticInfo{
+				{},
+				{Address: "Bern"},
+				{LinkType: proto.StaticInfoIface_LinkType_opennet},
+				{},
+			},
+			Notes: []sciond.PathASNote{{RawIsdas: ia111.IAInt(), Note: "transit"}},
+		}
+		info := aggregateStaticInfo(segments, ifaces)
+		if !reflect.DeepEqual(info, expected) {
+			t.Errorf("Unexpected static info. expected %v, actual %v", expected, info)
+		}
+	})
+}
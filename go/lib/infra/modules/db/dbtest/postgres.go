@@ -0,0 +1,60 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package dbtest contains helpers for the tests of the database backends.
+package dbtest
+
+import (
+	"database/sql"
+	"fmt"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/lib/pq"
+
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+// PostgresSchema drops and recreates schema in the PostgreSQL database of
+// connection, and returns a connection string that uses schema as search path.
+// Other schemas of the database are not modified, such that the tests can run
+// against a database that is used by other applications.
+func PostgresSchema(t *testing.T, connection, schema string) string {
+	t.Helper()
+	db, err := sql.Open("postgres", connection)
+	xtest.FailOnErr(t, err, "Failed to open DB")
+	defer db.Close()
+	ident := pq.QuoteIdentifier(schema)
+	_, err = db.Exec(fmt.Sprintf("DROP SCHEMA IF EXISTS %s CASCADE; CREATE SCHEMA %s",
+		ident, ident))
+	xtest.FailOnErr(t, err, "Failed to reset schema")
+	return withSearchPath(t, connection, schema)
+}
+
+// withSearchPath adds the search path to connection, which is either a URL or
+// a list of key/value pairs.
+func withSearchPath(t *testing.T, connection, schema string) string {
+	if !strings.HasPrefix(connection, "postgres://") &&
+		!strings.HasPrefix(connection, "postgresql://") {
+
+		return fmt.Sprintf("%s search_path=%s", connection, schema)
+	}
+	u, err := url.Parse(connection)
+	xtest.FailOnErr(t, err, "Failed to parse connection URL")
+	q := u.Query()
+	q.Set("search_path", schema)
+	u.RawQuery = q.Encode()
+	return u.String()
+}
@@ -0,0 +1,108 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package db
+
+import (
+	"context"
+	"database/sql"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+const (
+	// postgresSchemaLock is the key of the advisory lock that is held while
+	// the schema is set up. It prevents that multiple instances sharing the
+	// database set up the schema concurrently.
+	postgresSchemaLock = 0x5c10
+	// postgresVersionTable is the table that stores the schema version.
+	postgresVersionTable = "SchemaVersion"
+)
+
+// NewPostgres returns a new PostgreSQL backed database using the given
+// connection string. If the database does not contain the schema yet, it is
+// set up. If the schema version of the stored database is different from
+// schemaVersion, an error is returned.
+//
+// The schema version is stored per database. Each backend must therefore use
+// its own database, i.e., the path and trust database can not share one.
+func NewPostgres(connection string, schema string, schemaVersion int) (*sql.DB, error) {
+	var err error
+	if connection == "" {
+		return nil, common.NewBasicError("Empty connection not allowed for postgres", nil)
+	}
+	db, err := sql.Open("postgres", connection)
+	if err != nil {
+		return nil, common.NewBasicError("Couldn't open PostgreSQL database", err)
+	}
+	// On future errors, close the sql database before exiting
+	defer func() {
+		if err != nil {
+			db.Close()
+		}
+	}()
+	// Make sure DB is reachable
+	if err = db.Ping(); err != nil {
+		return nil, common.NewBasicError("Initial DB ping failed, connection broken?", err)
+	}
+	err = DoInTx(context.Background(), db, func(ctx context.Context, tx *sql.Tx) error {
+		return setupPostgres(ctx, tx, schema, schemaVersion)
+	})
+	if err != nil {
+		return nil, err
+	}
+	return db, nil
+}
+
+func setupPostgres(ctx context.Context, tx *sql.Tx, schema string, schemaVersion int) error {
+	// The lock is released at the end of the transaction.
+	if _, err := tx.ExecContext(ctx, "SELECT pg_advisory_xact_lock($1)",
+		postgresSchemaLock); err != nil {
+
+		return common.NewBasicError("Failed to acquire schema lock", err)
+	}
+	var exists bool
+	err := tx.QueryRowContext(ctx, "SELECT to_regclass($1) IS NOT NULL",
+		postgresVersionTable).Scan(&exists)
+	if err != nil {
+		return common.NewBasicError("Failed to check schema version", err)
+	}
+	if !exists {
+		if _, err := tx.ExecContext(ctx, schema); err != nil {
+			return common.NewBasicError("Failed to set up PostgreSQL database", err)
+		}
+		_, err := tx.ExecContext(ctx, "CREATE TABLE "+postgresVersionTable+
+			"(Version INTEGER NOT NULL)")
+		if err != nil {
+			return common.NewBasicError("Failed to create schema version table", err)
+		}
+		_, err = tx.ExecContext(ctx, "INSERT INTO "+postgresVersionTable+
+			"(Version) VALUES ($1)", schemaVersion)
+		if err != nil {
+			return common.NewBasicError("Failed to write schema version", err)
+		}
+		return nil
+	}
+	var existingVersion int
+	err = tx.QueryRowContext(ctx, "SELECT Version FROM "+postgresVersionTable).
+		Scan(&existingVersion)
+	if err != nil {
+		return common.NewBasicError("Failed to check schema version", err)
+	}
+	if existingVersion != schemaVersion {
+		return common.NewBasicError("Database schema version mismatch", nil,
+			"expected", schemaVersion, "have", existingVersion)
+	}
+	return nil
+}
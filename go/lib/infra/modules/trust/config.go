@@ -15,6 +15,8 @@
 package trust
 
 import (
+	"time"
+
 	"github.com/scionproto/scion/go/lib/snet"
 	"github.com/scionproto/scion/go/proto"
 )
@@ -32,4 +34,16 @@ type Config struct {
 	ServiceType proto.ServiceType
 	// Router is used to determine paths to other ASes.
 	Router snet.Router
+	// CheckRevocation states that certificate chains are checked against the
+	// revocation list of the issuer before they are considered valid. Missing
+	// or stale revocation lists are fetched from the issuer.
+	CheckRevocation bool
+	// RequireFreshCRL states that certificate chains are rejected if no
+	// revocation list of the issuer is available that is valid at the current
+	// time. Only used if CheckRevocation is set.
+	RequireFreshCRL bool
+	// MaxLeafLifetime is the maximum validity period of leaf certificates.
+	// Certificate chains with a leaf certificate that is valid for a longer
+	// period are rejected. If zero, the validity period is not restricted.
+	MaxLeafLifetime time.Duration
 }
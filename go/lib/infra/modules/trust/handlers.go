@@ -25,6 +25,7 @@ import (
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 	"github.com/scionproto/scion/go/lib/snet"
 	"github.com/scionproto/scion/go/proto"
 )
@@ -74,6 +75,9 @@ func (h *trcReqHandler) Handle() *infra.HandlerResult {
 	subCtx, cancelF := context.WithTimeout(h.request.Context(), HandlerTimeout)
 	defer cancelF()
 
+	if trcReq.V2 {
+		return h.handleV2(subCtx, trcReq, rw)
+	}
 	var trcObj *trc.TRC
 	var err error
 	// Only allow network traffic to be sent out if recursion is enabled and
@@ -116,6 +120,50 @@ func (h *trcReqHandler) Handle() *infra.HandlerResult {
 	return infra.MetricsResultOk
 }
 
+// handleV2 replies with the signed v2 TRC.
+func (h *trcReqHandler) handleV2(ctx context.Context, trcReq *cert_mgmt.TRCReq,
+	rw infra.ResponseWriter) *infra.HandlerResult {
+
+	logger := log.FromCtx(h.request.Context())
+	var signed *trcv2.Signed
+	var err error
+	// Only allow network traffic to be sent out if recursion is enabled and
+	// CacheOnly is not requested.
+	if trcReq.CacheOnly {
+		signed, err = h.store.trustdb.GetTRCv2Version(h.request.Context(), trcReq.ISD,
+			trcReq.Version)
+		if err != nil {
+			logger.Error("[TrustStore:trcReqHandler] Unable to retrieve TRC v2", "err", err)
+			return infra.MetricsErrTrustDB(err)
+		}
+	} else {
+		signed, err = h.store.getTRCv2(h.request.Context(), trcReq.ISD, trcReq.Version,
+			h.recurse, h.request.Peer, nil)
+		if err != nil {
+			logger.Error("[TrustStore:trcReqHandler] Unable to retrieve TRC v2", "err", err)
+			return infra.MetricsErrTrustStore(err)
+		}
+	}
+	var rawTRC common.RawBytes
+	if signed != nil {
+		rawTRC, err = signed.Encode()
+		if err != nil {
+			logger.Warn("[TrustStore:trcReqHandler] Unable to encode TRC v2", "err", err)
+			return infra.MetricsErrInternal
+		}
+	}
+	trcMessage := &cert_mgmt.TRC{
+		RawTRC: rawTRC,
+	}
+	if err := rw.SendTRCReply(ctx, trcMessage); err != nil {
+		logger.Error("[TrustStore:trcReqHandler] Messenger error", "err", err)
+		return infra.MetricsErrMsger(err)
+	}
+	logger.Debug("[TrustStore:trcReqHandler] Replied with TRC v2",
+		"trc", trcMessage, "peer", h.request.Peer)
+	return infra.MetricsResultOk
+}
+
 // chainReqHandler contains the state of a handler for a specific Certificate
 // Chain Request message, received via the Messenger's ListenAndServe method.
 type chainReqHandler struct {
@@ -187,6 +235,72 @@ func (h *chainReqHandler) Handle() *infra.HandlerResult {
 	return infra.MetricsResultOk
 }
 
+// crlReqHandler contains the state of a handler for a specific certificate
+// revocation list request message, received via the Messenger's
+// ListenAndServe method.
+type crlReqHandler struct {
+	request *infra.Request
+	store   *Store
+	// set to true if this handler is allowed to issue new requests over the
+	// network
+	recurse bool
+}
+
+func (h *crlReqHandler) Handle() *infra.HandlerResult {
+	logger := log.FromCtx(h.request.Context())
+	crlReq, ok := h.request.Message.(*cert_mgmt.CRLReq)
+	if !ok {
+		logger.Error("[TrustStore:crlReqHandler] wrong message type, expected cert_mgmt.CRLReq",
+			"msg", h.request.Message, "type", common.TypeOf(h.request.Message))
+		return infra.MetricsErrInternal
+	}
+	logger.Debug("[TrustStore:crlReqHandler] Received request", "crlReq", crlReq,
+		"peer", h.request.Peer)
+	rw, ok := infra.ResponseWriterFromContext(h.request.Context())
+	if !ok {
+		logger.Warn("[TrustStore:crlReqHandler] Unable to service request, no Messenger found")
+		return infra.MetricsErrInternal
+	}
+	subCtx, cancelF := context.WithTimeout(h.request.Context(), HandlerTimeout)
+	defer cancelF()
+
+	var crl *cert.RevocationList
+	var err error
+	// Only allow network traffic to be sent out if recursion is enabled and
+	// CacheOnly is not requested.
+	if crlReq.CacheOnly {
+		crl, err = h.store.trustdb.GetCRLVersion(h.request.Context(),
+			crlReq.Issuer(), crlReq.Version)
+		if err != nil {
+			logger.Error("[TrustStore:crlReqHandler] Unable to retrieve CRL", "err", err)
+			return infra.MetricsErrTrustDB(err)
+		}
+	} else {
+		crl, err = h.store.getCRL(h.request.Context(), crlReq.Issuer(), crlReq.Version,
+			h.recurse, h.request.Peer, false)
+		if err != nil {
+			logger.Error("[TrustStore:crlReqHandler] Unable to retrieve CRL", "err", err)
+			return infra.MetricsErrTrustStore(err)
+		}
+	}
+	var rawCRL common.RawBytes
+	if crl != nil {
+		rawCRL, err = crl.JSON(false)
+		if err != nil {
+			logger.Error("[TrustStore:crlReqHandler] Unable to marshal CRL", "err", err)
+			return infra.MetricsErrInternal
+		}
+	}
+	err = rw.SendCRLReply(subCtx, &cert_mgmt.CRL{RawCRL: rawCRL})
+	if err != nil {
+		logger.Error("[TrustStore:crlReqHandler] Messenger API error", "err", err)
+		return infra.MetricsErrMsger(err)
+	}
+	logger.Debug("[TrustStore:crlReqHandler] Replied with CRL",
+		"crl", crl, "peer", h.request.Peer)
+	return infra.MetricsResultOk
+}
+
 type trcPushHandler struct {
 	request *infra.Request
 	store   *Store
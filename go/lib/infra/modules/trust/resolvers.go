@@ -23,6 +23,7 @@ import (
 	"github.com/scionproto/scion/go/lib/infra/dedupe"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 )
 
 var _ dedupe.Request = (*trcRequest)(nil)
@@ -53,6 +54,29 @@ func (req *trcRequest) BroadcastKey() string {
 	return fmt.Sprintf("%dv%d", req.isd, req.version)
 }
 
+var _ dedupe.Request = (*trcv2Request)(nil)
+
+// trcv2Request objects describe a single request for a signed v2 TRC and are
+// passed from the trust store to the background resolvers.
+type trcv2Request struct {
+	isd       addr.ISD
+	version   uint64
+	cacheOnly bool
+	id        uint64
+	server    net.Addr
+	// If postHook is set, run the callback to verify the downloaded object and
+	// insert into the database.
+	postHook ValidateTRCv2Func
+}
+
+func (req *trcv2Request) DedupeKey() string {
+	return fmt.Sprintf("%dv%d v2 %t %s", req.isd, req.version, req.postHook != nil, req.server)
+}
+
+func (req *trcv2Request) BroadcastKey() string {
+	return fmt.Sprintf("%dv%d v2", req.isd, req.version)
+}
+
 var _ dedupe.Request = (*chainRequest)(nil)
 
 // chainRequest objects describe a single request and are passed from the trust
@@ -81,6 +105,34 @@ func (req *chainRequest) BroadcastKey() string {
 	return fmt.Sprintf("%sv%d", req.ia, req.version)
 }
 
+var _ dedupe.Request = (*crlRequest)(nil)
+
+// crlRequest objects describe a single request for a certificate revocation
+// list and are passed from the trust store to the background resolvers.
+type crlRequest struct {
+	issuer    addr.IA
+	version   uint64
+	cacheOnly bool
+	id        uint64
+	server    net.Addr
+	// If postHook is set, run the callback to verify the downloaded object and
+	// insert into the database.
+	postHook ValidateCRLFunc
+}
+
+func (req *crlRequest) DedupeKey() string {
+	return fmt.Sprintf("%sv%d crl %t %s", req.issuer, req.version, req.postHook != nil,
+		req.server)
+}
+
+func (req *crlRequest) BroadcastKey() string {
+	return fmt.Sprintf("%sv%d crl", req.issuer, req.version)
+}
+
 type ValidateTRCFunc func(ctx context.Context, trcObj *trc.TRC) error
 
+type ValidateTRCv2Func func(ctx context.Context, signed *trcv2.Signed) error
+
 type ValidateChainFunc func(ctx context.Context, chain *cert.Chain) error
+
+type ValidateCRLFunc func(ctx context.Context, crl *cert.RevocationList) error
@@ -85,8 +85,8 @@ func (b *BasicSigner) Meta() infra.SignerMeta {
 
 var _ infra.Verifier = (*BasicVerifier)(nil)
 
-// BasicVerifier is a verifier that ignores signatures on cert_mgmt.TRC
-// and cert_mgmt.Chain messages, to avoid dependency cycles.
+// BasicVerifier is a verifier that ignores signatures on cert_mgmt.TRC,
+// cert_mgmt.Chain and cert_mgmt.CRL messages, to avoid dependency cycles.
 type BasicVerifier struct {
 	store  *Store
 	ia     addr.IA
@@ -160,9 +160,9 @@ func (v *BasicVerifier) ignoreSign(p *ctrl.Pld, sign *proto.SignS) bool {
 	}
 	u1, _ := outer.Union()
 	switch u1.(type) {
-	case *cert_mgmt.Chain, *cert_mgmt.TRC:
+	case *cert_mgmt.Chain, *cert_mgmt.TRC, *cert_mgmt.CRL:
 		return true
-	case *cert_mgmt.ChainReq, *cert_mgmt.TRCReq:
+	case *cert_mgmt.ChainReq, *cert_mgmt.TRCReq, *cert_mgmt.CRLReq:
 		if sign == nil || sign.Type == proto.SignType_none {
 			return true
 		}
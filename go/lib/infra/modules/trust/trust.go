@@ -18,8 +18,13 @@
 package trust
 
 import (
+	"bytes"
 	"context"
+	"fmt"
+	"io/ioutil"
 	"net"
+	"path/filepath"
+	"sort"
 	"sync"
 	"time"
 
@@ -37,21 +42,33 @@ import (
 	"github.com/scionproto/scion/go/lib/scrypto"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 	"github.com/scionproto/scion/go/lib/snet"
 	"github.com/scionproto/scion/go/lib/topology"
+	"github.com/scionproto/scion/go/lib/util"
 	"github.com/scionproto/scion/go/proto"
 )
 
 const (
 	// Handler lifetime
 	HandlerTimeout = 3 * time.Second
+	// CRLFetchBackoff is the time after a failed CRL fetch during which no
+	// further fetches of the same CRL are attempted.
+	CRLFetchBackoff = 30 * time.Second
+	// CRLFetchTimeout is the maximum time a CRL fetch can delay the
+	// verification of a certificate chain. Stale CRLs are refreshed in the
+	// background with the same timeout.
+	CRLFetchTimeout = time.Second
 )
 
 var (
 	ErrNotFoundLocally      = "Chain/TRC not found locally"
 	ErrMissingAuthoritative = "Trust store is authoritative for requested object," +
 		" and object was not found"
-	ErrNotFound = "Chain/TRC not found"
+	ErrNotFound     = "Chain/TRC not found"
+	ErrRevoked      = "Certificate revoked by issuer"
+	ErrLeafLifetime = "Leaf certificate validity period exceeds maximum"
+	ErrBaseTRC      = "Base TRC must be loaded from the local configuration"
 )
 
 var _ infra.TrustStore = (*Store)(nil)
@@ -73,8 +90,16 @@ type Store struct {
 	mu           sync.Mutex
 	trustdb      trustdb.TrustDB
 	trcDeduper   dedupe.Deduper
+	trcv2Deduper dedupe.Deduper
 	chainDeduper dedupe.Deduper
+	crlDeduper   dedupe.Deduper
 	config       *Config
+	// crlMu protects crlFailures and crlRefreshes.
+	crlMu sync.Mutex
+	// crlFailures contains the time of the last failed fetch per CRL.
+	crlFailures map[crlKey]time.Time
+	// crlRefreshes contains the CRLs that are refreshed in the background.
+	crlRefreshes map[crlKey]struct{}
 	// local AS
 	ia    addr.IA
 	log   log.Logger
@@ -108,7 +133,9 @@ func (store *Store) SetMessenger(msger infra.Messenger) {
 	}
 	store.msger = msger
 	store.trcDeduper = dedupe.New(store.trcRequestFunc, 0, 0)
+	store.trcv2Deduper = dedupe.New(store.trcv2RequestFunc, 0, 0)
 	store.chainDeduper = dedupe.New(store.chainRequestFunc, 0, 0)
+	store.crlDeduper = dedupe.New(store.crlRequestFunc, 0, 0)
 }
 
 // trcRequestFunc is the dedupe.RequestFunc for TRC requests.
@@ -142,6 +169,44 @@ func (store *Store) trcRequestFunc(ctx context.Context, request dedupe.Request)
 	return dedupe.Response{Data: trcObj}
 }
 
+// trcv2RequestFunc is the dedupe.RequestFunc for signed v2 TRC requests.
+func (store *Store) trcv2RequestFunc(ctx context.Context, request dedupe.Request) dedupe.Response {
+	req := request.(*trcv2Request)
+	trcReqMsg := &cert_mgmt.TRCReq{
+		ISD:       req.isd,
+		Version:   req.version,
+		CacheOnly: req.cacheOnly,
+		V2:        true,
+	}
+	trcMsg, err := store.msger.GetTRC(ctx, trcReqMsg, req.server, req.id)
+	if err != nil {
+		return wrapErr(err)
+	}
+	signed, err := trcMsg.SignedTRCv2()
+	if err != nil {
+		return wrapErr(common.NewBasicError("Unable to parse TRC message", err, "msg", trcMsg))
+	}
+	if signed == nil {
+		return dedupe.Response{Data: nil}
+	}
+	trcObj, err := signed.EncodedTRC.Decode()
+	if err != nil {
+		return wrapErr(common.NewBasicError("Unable to decode TRC payload", err))
+	}
+	if trcObj.ISD != req.isd {
+		return wrapErr(common.NewBasicError("Remote server responded with bad ISD", nil,
+			"got", trcObj.ISD, "expected", req.isd))
+	}
+	if req.version != scrypto.LatestVer && uint64(trcObj.Version) != req.version {
+		return wrapErr(common.NewBasicError("Remote server responded with bad version", nil,
+			"got", trcObj.Version, "expected", req.version))
+	}
+	if req.postHook != nil {
+		return dedupe.Response{Data: signed, Error: req.postHook(ctx, signed)}
+	}
+	return dedupe.Response{Data: signed}
+}
+
 // chainRequestFunc is the dedupe.RequestFunc for Chain requests.
 func (store *Store) chainRequestFunc(ctx context.Context, request dedupe.Request) dedupe.Response {
 	req := request.(*chainRequest)
@@ -171,6 +236,39 @@ func (store *Store) chainRequestFunc(ctx context.Context, request dedupe.Request
 	return dedupe.Response{Data: chain}
 }
 
+// crlRequestFunc is the dedupe.RequestFunc for CRL requests.
+func (store *Store) crlRequestFunc(ctx context.Context, request dedupe.Request) dedupe.Response {
+	req := request.(*crlRequest)
+	crlReqMsg := &cert_mgmt.CRLReq{
+		RawIssuer: req.issuer.IAInt(),
+		Version:   req.version,
+		CacheOnly: req.cacheOnly,
+	}
+	crlMsg, err := store.msger.GetCRL(ctx, crlReqMsg, req.server, req.id)
+	if err != nil {
+		return wrapErr(common.NewBasicError("Unable to get CRL from peer", err))
+	}
+	crl, err := crlMsg.CRL()
+	if err != nil {
+		return wrapErr(common.NewBasicError("Unable to parse CRL message", err))
+	}
+	if crl == nil {
+		return dedupe.Response{Data: nil}
+	}
+	if !crl.Issuer.Equal(req.issuer) {
+		return wrapErr(common.NewBasicError("Remote server responded with bad issuer", nil,
+			"got", crl.Issuer, "expected", req.issuer))
+	}
+	if req.version != scrypto.LatestVer && crl.Version != req.version {
+		return wrapErr(common.NewBasicError("Remote server responded with bad version", nil,
+			"got", crl.Version, "expected", req.version))
+	}
+	if req.postHook != nil {
+		return dedupe.Response{Data: crl, Error: req.postHook(ctx, crl)}
+	}
+	return dedupe.Response{Data: crl}
+}
+
 // GetValidTRC asks the trust store to return a valid TRC for isd. Server is
 // queried over the network if the TRC is not available locally. Otherwise, the
 // default server is queried.
@@ -295,17 +393,156 @@ func (store *Store) insertTRCHookForwarding(ctx context.Context, trcObj *trc.TRC
 	return nil
 }
 
+// GetTRCv2 asks the trust store to return the signed v2 TRC of the requested
+// version. If the TRC is not available locally, it is requested from the
+// authoritative CS and verified before it is inserted into the database. A
+// TRC update is verified against the previous version, which is resolved
+// recursively if necessary. Base TRCs are not accepted from the network, they
+// must be loaded with LoadAuthoritativeTRCv2.
+func (store *Store) GetTRCv2(ctx context.Context,
+	isd addr.ISD, version uint64) (*trcv2.Signed, error) {
+
+	return store.getTRCv2(ctx, isd, version, true, nil, nil)
+}
+
+// getTRCv2 attempts to grab the signed v2 TRC from the database; if the TRC is
+// not found, it follows up with a network request (if allowed). The
+// parameters have the same semantics as for getTRC.
+func (store *Store) getTRCv2(ctx context.Context, isd addr.ISD, version uint64,
+	recurse bool, client, server net.Addr) (*trcv2.Signed, error) {
+
+	signed, err := store.trustdb.GetTRCv2Version(ctx, isd, version)
+	if err != nil || signed != nil {
+		return signed, err
+	}
+	if !recurse {
+		return nil, common.NewBasicError(ErrNotFoundLocally, nil, "isd", isd, "version", version,
+			"client", client)
+	}
+	if err := store.isLocal(client); err != nil {
+		return nil, err
+	}
+	if server == nil {
+		server, err = store.ChooseServer(ctx, addr.IA{I: isd})
+		if err != nil {
+			return nil, common.NewBasicError("Error determining server to query", err,
+				"isd", isd, "version", version)
+		}
+	}
+	return store.getTRCv2FromNetwork(ctx, &trcv2Request{
+		isd:      isd,
+		version:  version,
+		id:       messenger.NextId(),
+		server:   server,
+		postHook: store.newTRCv2Verifier(client, server),
+	})
+}
+
+func (store *Store) getTRCv2FromNetwork(ctx context.Context,
+	req *trcv2Request) (*trcv2.Signed, error) {
+
+	var span opentracing.Span
+	span, ctx = opentracing.StartSpanFromContext(ctx, "getTRCv2FromNet")
+	defer span.Finish()
+	responseC, cancelF, span := store.trcv2Deduper.Request(ctx, req)
+	defer cancelF()
+	defer span.Finish()
+	select {
+	case response := <-responseC:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		if response.Data == nil {
+			return nil, common.NewBasicError(ErrNotFound, nil)
+		}
+		return response.Data.(*trcv2.Signed), nil
+	case <-ctx.Done():
+		return nil, common.NewBasicError("Context done while waiting for TRC",
+			ctx.Err(), "isd", req.isd, "version", req.version)
+	}
+}
+
+// newTRCv2Verifier returns a hook that verifies the signed v2 TRC and inserts
+// it into the database. Base TRCs are trust anchors and are only loaded from
+// the local configuration, see LoadAuthoritativeTRCv2, they are rejected if
+// received from the network. An update is verified against the previous
+// version, which is fetched from the same server if it is not available
+// locally.
+func (store *Store) newTRCv2Verifier(client, server net.Addr) ValidateTRCv2Func {
+	return func(ctx context.Context, signed *trcv2.Signed) error {
+		trcObj, err := signed.EncodedTRC.Decode()
+		if err != nil {
+			return common.NewBasicError("Unable to decode TRC payload", err)
+		}
+		if trcObj.Base() {
+			return common.NewBasicError(ErrBaseTRC, nil,
+				"isd", trcObj.ISD, "version", trcObj.Version)
+		}
+		prevSigned, err := store.getTRCv2(ctx, trcObj.ISD, uint64(trcObj.Version)-1,
+			true, client, server)
+		if err != nil {
+			return common.NewBasicError("Unable to fetch previous TRC", err,
+				"isd", trcObj.ISD, "version", trcObj.Version-1)
+		}
+		if err := verifyTRCv2Update(prevSigned, signed, trcObj); err != nil {
+			return err
+		}
+		if _, err := store.trustdb.InsertTRCv2(ctx, signed); err != nil {
+			return common.NewBasicError("Unable to store TRC in database", err)
+		}
+		return nil
+	}
+}
+
+// verifyTRCv2Update verifies the TRC update against the previous TRC.
+func verifyTRCv2Update(prevSigned, signed *trcv2.Signed, trcObj *trcv2.TRC) error {
+	prev, err := prevSigned.EncodedTRC.Decode()
+	if err != nil {
+		return common.NewBasicError("Unable to decode previous TRC payload", err)
+	}
+	v := trcv2.UpdateVerifier{
+		Prev:        prev,
+		Next:        trcObj,
+		NextEncoded: signed.EncodedTRC,
+		Signatures:  signed.Signatures,
+	}
+	if _, err := v.Verify(); err != nil {
+		return common.NewBasicError("Unable to verify TRC update", err,
+			"isd", trcObj.ISD, "version", trcObj.Version)
+	}
+	return nil
+}
+
 // GetValidChain asks the trust store to return a valid certificate chain for ia.
 // Server is queried over the network if the chain is not available locally.
+// If configured, the chain is rejected if its leaf certificate is revoked by
+// the issuer or exceeds the maximum leaf lifetime.
 func (store *Store) GetValidChain(ctx context.Context, ia addr.IA, ver uint64,
 	server net.Addr) (*cert.Chain, error) {
 
 	return store.getValidChain(ctx, ia, ver, true, nil, server)
 }
 
+// getValidChain returns a chain that is verified against the TRC and that
+// conforms to the revocation and lifetime policy of the store.
 func (store *Store) getValidChain(ctx context.Context, ia addr.IA, ver uint64,
 	recurse bool, client, server net.Addr) (*cert.Chain, error) {
 
+	chain, err := store.getVerifiedChain(ctx, ia, ver, recurse, client, server)
+	if err != nil {
+		return nil, err
+	}
+	if err := store.checkChain(ctx, chain, recurse, client); err != nil {
+		return nil, err
+	}
+	return chain, nil
+}
+
+// getVerifiedChain returns a chain that is verified against the TRC. The
+// revocation and lifetime policy of the store is not enforced.
+func (store *Store) getVerifiedChain(ctx context.Context, ia addr.IA, ver uint64,
+	recurse bool, client, server net.Addr) (*cert.Chain, error) {
+
 	chain, err := store.trustdb.GetChainVersion(ctx, ia, ver)
 	if err != nil || chain != nil {
 		return chain, err
@@ -477,11 +714,280 @@ func (store *Store) getChainFromNetwork(ctx context.Context,
 	}
 }
 
-// LoadAuthoritativeCrypto loads the authoritative TRC and chain.
+// checkChain enforces the lifetime and revocation policy of the store on the
+// chain. If the revocation list of the issuer is not available locally, it is
+// fetched from the network if recurse is set, for at most CRLFetchTimeout. A
+// stale revocation list is refreshed in the background, unless fresh lists
+// are required.
+func (store *Store) checkChain(ctx context.Context, chain *cert.Chain, recurse bool,
+	client net.Addr) error {
+
+	if max := store.config.MaxLeafLifetime; max != 0 {
+		validity := chain.Leaf.ExpirationTime - chain.Leaf.IssuingTime
+		if lifetime := time.Duration(validity) * time.Second; lifetime > max {
+			return common.NewBasicError(ErrLeafLifetime, nil, "chain", chain,
+				"lifetime", lifetime, "max", max)
+		}
+	}
+	if !store.config.CheckRevocation {
+		return nil
+	}
+	crlCtx, cancelF := context.WithTimeout(ctx, CRLFetchTimeout)
+	defer cancelF()
+	crl, err := store.getCRL(crlCtx, chain.Leaf.Issuer, scrypto.LatestVer, recurse, client,
+		!store.config.RequireFreshCRL)
+	if err != nil {
+		if store.config.RequireFreshCRL {
+			return common.NewBasicError("Unable to get revocation list", err,
+				"issuer", chain.Leaf.Issuer)
+		}
+		log.FromCtx(ctx).Debug("[TrustStore] Unable to get revocation list, "+
+			"accepting chain", "chain", chain, "err", err)
+		return nil
+	}
+	if crl.Revokes(chain.Leaf.Subject, chain.Leaf.Version) {
+		return common.NewBasicError(ErrRevoked, nil, "chain", chain, "crl", crl)
+	}
+	if store.config.RequireFreshCRL {
+		if err := crl.VerifyTime(util.TimeToSecs(time.Now())); err != nil {
+			return common.NewBasicError("Revocation list not fresh", err, "crl", crl)
+		}
+	}
+	return nil
+}
+
+// GetCRL asks the trust store to return the certificate revocation list of the
+// issuer. If the requested version is not available locally, or the newest
+// version is requested and the local one is stale, the list is requested from
+// the issuer. Lists fetched from the network are verified against the issuer
+// certificate before they are inserted into the database.
+func (store *Store) GetCRL(ctx context.Context, issuer addr.IA,
+	version uint64) (*cert.RevocationList, error) {
+
+	return store.getCRL(ctx, issuer, version, true, nil, false)
+}
+
+// getCRL attempts to grab the CRL from the database; if the CRL is not found,
+// or is stale, it follows up with a network request (if allowed). If the
+// network request fails, a stale CRL is returned instead, and no further
+// network requests for the CRL are made for CRLFetchBackoff. If async is set
+// and a stale CRL is available, the stale CRL is returned immediately and the
+// network request runs in the background. The other parameters have the same
+// semantics as for getTRC.
+func (store *Store) getCRL(ctx context.Context, issuer addr.IA, version uint64,
+	recurse bool, client net.Addr, async bool) (*cert.RevocationList, error) {
+
+	crl, err := store.trustdb.GetCRLVersion(ctx, issuer, version)
+	if err != nil {
+		return nil, err
+	}
+	if crl != nil && (version != scrypto.LatestVer ||
+		crl.VerifyTime(util.TimeToSecs(time.Now())) == nil) {
+		return crl, nil
+	}
+	// The CS of the issuer publishes the CRL, so it is never fetched from the network.
+	if store.config.ServiceType == proto.ServiceType_cs && store.ia.Equal(issuer) {
+		if crl != nil {
+			return crl, nil
+		}
+		return nil, common.NewBasicError(ErrMissingAuthoritative, nil, "issuer", issuer,
+			"version", version)
+	}
+	if !recurse || store.isLocal(client) != nil {
+		if crl != nil {
+			return crl, nil
+		}
+		return nil, common.NewBasicError(ErrNotFoundLocally, nil, "issuer", issuer,
+			"version", version, "client", client)
+	}
+	key := crlKey{issuer: issuer, version: version}
+	if store.crlBackedOff(key) {
+		if crl != nil {
+			return crl, nil
+		}
+		return nil, common.NewBasicError("Backing off from fetching CRL", nil,
+			"issuer", issuer, "version", version)
+	}
+	if async && crl != nil {
+		store.refreshCRL(log.FromCtx(ctx), key)
+		return crl, nil
+	}
+	fetched, err := store.fetchCRL(ctx, issuer, version)
+	store.setCRLFailed(key, err != nil)
+	if err != nil {
+		if crl != nil {
+			log.FromCtx(ctx).Debug("[TrustStore] Unable to fetch CRL, using stale CRL",
+				"crl", crl, "err", err)
+			return crl, nil
+		}
+		return nil, err
+	}
+	if crl != nil && crl.Version > fetched.Version {
+		return crl, nil
+	}
+	return fetched, nil
+}
+
+// fetchCRL requests the CRL from a server of the issuer.
+func (store *Store) fetchCRL(ctx context.Context, issuer addr.IA,
+	version uint64) (*cert.RevocationList, error) {
+
+	server, err := store.chooseCRLServer(ctx, issuer)
+	if err != nil {
+		return nil, common.NewBasicError("Error determining server to query", err,
+			"issuer", issuer, "version", version)
+	}
+	return store.getCRLFromNetwork(ctx, &crlRequest{
+		issuer:   issuer,
+		version:  version,
+		id:       messenger.NextId(),
+		server:   server,
+		postHook: store.newCRLVerifier(),
+	})
+}
+
+// refreshCRL fetches the CRL in the background, unless it is already being
+// refreshed. The fetch is bounded by CRLFetchTimeout.
+func (store *Store) refreshCRL(logger log.Logger, key crlKey) {
+	store.crlMu.Lock()
+	defer store.crlMu.Unlock()
+	if _, ok := store.crlRefreshes[key]; ok {
+		return
+	}
+	if store.crlRefreshes == nil {
+		store.crlRefreshes = make(map[crlKey]struct{})
+	}
+	store.crlRefreshes[key] = struct{}{}
+	go func() {
+		defer log.LogPanicAndExit()
+		defer func() {
+			store.crlMu.Lock()
+			defer store.crlMu.Unlock()
+			delete(store.crlRefreshes, key)
+		}()
+		ctx, cancelF := context.WithTimeout(context.Background(), CRLFetchTimeout)
+		defer cancelF()
+		_, err := store.fetchCRL(log.CtxWith(ctx, logger), key.issuer, key.version)
+		store.setCRLFailed(key, err != nil)
+		if err != nil {
+			logger.Debug("[TrustStore] Unable to refresh stale CRL", "issuer", key.issuer,
+				"err", err)
+		}
+	}()
+}
+
+// crlKey identifies a CRL in the negative cache of failed fetches.
+type crlKey struct {
+	issuer  addr.IA
+	version uint64
+}
+
+// crlBackedOff returns whether the last fetch of the CRL failed less than
+// CRLFetchBackoff ago.
+func (store *Store) crlBackedOff(key crlKey) bool {
+	store.crlMu.Lock()
+	defer store.crlMu.Unlock()
+	failed, ok := store.crlFailures[key]
+	return ok && time.Since(failed) < CRLFetchBackoff
+}
+
+// setCRLFailed records the outcome of a CRL fetch in the negative cache.
+func (store *Store) setCRLFailed(key crlKey, failed bool) {
+	store.crlMu.Lock()
+	defer store.crlMu.Unlock()
+	if !failed {
+		delete(store.crlFailures, key)
+		return
+	}
+	if store.crlFailures == nil {
+		store.crlFailures = make(map[crlKey]time.Time)
+	}
+	store.crlFailures[key] = time.Now()
+}
+
+func (store *Store) getCRLFromNetwork(ctx context.Context,
+	req *crlRequest) (*cert.RevocationList, error) {
+
+	var span opentracing.Span
+	span, ctx = opentracing.StartSpanFromContext(ctx, "getCRLFromNetwork")
+	defer span.Finish()
+	responseC, cancelF, span := store.crlDeduper.Request(ctx, req)
+	defer cancelF()
+	defer span.Finish()
+	select {
+	case response := <-responseC:
+		if response.Error != nil {
+			return nil, response.Error
+		}
+		if response.Data == nil {
+			return nil, common.NewBasicError(ErrNotFound, nil)
+		}
+		return response.Data.(*cert.RevocationList), nil
+	case <-ctx.Done():
+		return nil, common.NewBasicError("Context done while waiting for CRL",
+			ctx.Err(), "issuer", req.issuer, "version", req.version)
+	}
+}
+
+// newCRLVerifier returns a hook that verifies the CRL against the issuer
+// certificate and inserts it into the database. If the issuer certificate is
+// not available locally, it is taken from the newest verified chain of the
+// issuer.
+func (store *Store) newCRLVerifier() ValidateCRLFunc {
+	return func(ctx context.Context, crl *cert.RevocationList) error {
+		issCert, err := store.trustdb.GetIssCertVersion(ctx, crl.Issuer, crl.IssuerVersion)
+		if err != nil {
+			return common.NewBasicError("Unable to get issuer certificate", err,
+				"issuer", crl.Issuer, "version", crl.IssuerVersion)
+		}
+		if issCert == nil {
+			chain, err := store.getVerifiedChain(ctx, crl.Issuer, scrypto.LatestVer,
+				true, nil, nil)
+			if err != nil {
+				return common.NewBasicError("Unable to get issuer chain", err,
+					"issuer", crl.Issuer)
+			}
+			issCert = chain.Issuer
+		}
+		if err := crl.Verify(issCert); err != nil {
+			return common.NewBasicError("Unable to verify CRL", err, "crl", crl)
+		}
+		if _, err := store.trustdb.InsertCRL(ctx, crl); err != nil {
+			return common.NewBasicError("Unable to store CRL in database", err)
+		}
+		return nil
+	}
+}
+
+// chooseCRLServer builds the address of the CS of the issuer, which publishes
+// the CRL. Services other than the CS query the AS-local CS.
+func (store *Store) chooseCRLServer(ctx context.Context, issuer addr.IA) (net.Addr, error) {
+	if store.config.ServiceType != proto.ServiceType_cs {
+		return &snet.Addr{IA: store.ia, Host: addr.NewSVCUDPAppAddr(addr.SvcCS)}, nil
+	}
+	path, err := store.config.Router.Route(ctx, issuer)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to find path to issuer", err,
+			"issuer", issuer)
+	}
+	a := &snet.Addr{
+		IA:      issuer,
+		Host:    addr.NewSVCUDPAppAddr(addr.SvcCS),
+		Path:    path.Path(),
+		NextHop: path.OverlayNextHop(),
+	}
+	return a, nil
+}
+
+// LoadAuthoritativeCrypto loads the authoritative TRC, the signed v2 TRCs and
+// the chain.
 func (store *Store) LoadAuthoritativeCrypto(dir string) error {
 	if err := store.LoadAuthoritativeTRC(dir); err != nil {
 		return err
 	}
+	if err := store.LoadAuthoritativeTRCv2(dir); err != nil {
+		return err
+	}
 	return store.LoadAuthoritativeChain(dir)
 }
 
@@ -539,6 +1045,95 @@ func (store *Store) LoadAuthoritativeTRC(dir string) error {
 	}
 }
 
+// LoadAuthoritativeTRCv2 loads the signed v2 TRCs in the ISD{isd}-V{version}.signed.trc
+// files of dir into the database. Base TRCs are the trust anchors of their
+// ISD, and are verified on their own. Updates are verified against the
+// previous version, which must be in dir or in the database.
+func (store *Store) LoadAuthoritativeTRCv2(dir string) error {
+	files, err := filepath.Glob(fmt.Sprintf("%s/ISD*-V*.signed.trc", dir))
+	if err != nil {
+		return common.NewBasicError("Unable to list TRC files", err, "dir", dir)
+	}
+	type loadedTRC struct {
+		signed *trcv2.Signed
+		trc    *trcv2.TRC
+	}
+	var loaded []loadedTRC
+	for _, file := range files {
+		raw, err := ioutil.ReadFile(file)
+		if err != nil {
+			return common.NewBasicError("Unable to read TRC file", err, "file", file)
+		}
+		signed, err := trcv2.ParseSigned(raw)
+		if err != nil {
+			return common.NewBasicError("Unable to parse TRC file", err, "file", file)
+		}
+		trcObj, err := signed.EncodedTRC.Decode()
+		if err != nil {
+			return common.NewBasicError("Unable to decode TRC payload", err, "file", file)
+		}
+		loaded = append(loaded, loadedTRC{signed: &signed, trc: trcObj})
+	}
+	// Updates are verified against the previous version, which must be loaded
+	// first.
+	sort.Slice(loaded, func(i, j int) bool {
+		a, b := loaded[i].trc, loaded[j].trc
+		if a.ISD != b.ISD {
+			return a.ISD < b.ISD
+		}
+		return a.Version < b.Version
+	})
+	ctx, cancelF := context.WithTimeout(context.Background(), time.Second)
+	defer cancelF()
+	for _, l := range loaded {
+		if err := store.loadTRCv2(ctx, l.signed, l.trc); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (store *Store) loadTRCv2(ctx context.Context, signed *trcv2.Signed,
+	trcObj *trcv2.TRC) error {
+
+	dbSigned, err := store.trustdb.GetTRCv2Version(ctx, trcObj.ISD, uint64(trcObj.Version))
+	if err != nil {
+		return common.NewBasicError("Failed to get TRC from trust db", err)
+	}
+	if dbSigned != nil {
+		if !bytes.Equal(dbSigned.EncodedTRC, signed.EncodedTRC) {
+			return common.NewBasicError("Conflicting TRCs found for same version", nil,
+				"isd", trcObj.ISD, "version", trcObj.Version)
+		}
+		return nil
+	}
+	if trcObj.Base() {
+		v := trcv2.BaseVerifier{
+			TRC:        trcObj,
+			Encoded:    signed.EncodedTRC,
+			Signatures: signed.Signatures,
+		}
+		if err := v.Verify(); err != nil {
+			return common.NewBasicError("Unable to verify base TRC", err,
+				"isd", trcObj.ISD, "version", trcObj.Version)
+		}
+	} else {
+		prevSigned, err := store.getTRCv2(ctx, trcObj.ISD, uint64(trcObj.Version)-1,
+			false, nil, nil)
+		if err != nil {
+			return common.NewBasicError("Unable to get previous TRC", err,
+				"isd", trcObj.ISD, "version", trcObj.Version-1)
+		}
+		if err := verifyTRCv2Update(prevSigned, signed, trcObj); err != nil {
+			return err
+		}
+	}
+	if _, err := store.trustdb.InsertTRCv2(ctx, signed); err != nil {
+		return common.NewBasicError("Failed to insert TRC in trust db", err)
+	}
+	return nil
+}
+
 func (store *Store) LoadAuthoritativeChain(dir string) error {
 	fileChain, err := cert.ChainFromDir(
 		dir,
@@ -552,7 +1147,7 @@ func (store *Store) LoadAuthoritativeChain(dir string) error {
 
 	ctx, cancelF := context.WithTimeout(context.Background(), time.Second)
 	defer cancelF()
-	dbChain, err := store.getValidChain(ctx, store.ia, scrypto.LatestVer, false, nil, nil)
+	dbChain, err := store.getVerifiedChain(ctx, store.ia, scrypto.LatestVer, false, nil, nil)
 	switch {
 	case err != nil && common.GetErrorMsg(err) != ErrMissingAuthoritative:
 		// Unexpected error in trust store
@@ -618,6 +1213,23 @@ func (store *Store) NewChainReqHandler(recurse bool) infra.Handler {
 	return infra.HandlerFunc(f)
 }
 
+// NewCRLReqHandler returns an infra.Handler for certificate revocation list
+// requests coming from a peer, backed by the trust store. If recurse is set to
+// true, the handler is allowed to issue new CRL requests over the network.
+// This method should only be used when servicing requests coming from remote
+// nodes.
+func (store *Store) NewCRLReqHandler(recurse bool) infra.Handler {
+	f := func(r *infra.Request) *infra.HandlerResult {
+		handler := crlReqHandler{
+			request: r,
+			store:   store,
+			recurse: recurse,
+		}
+		return handler.Handle()
+	}
+	return infra.HandlerFunc(f)
+}
+
 // NewTRCPushHandler returns an infra.Handler for TRC pushes coming from a
 // peer, backed by the trust store. TRCs are pushed by local BSes during
 // beaconing. Pushes are allowed from all local AS sources.
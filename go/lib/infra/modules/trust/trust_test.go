@@ -17,6 +17,7 @@ package trust
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"net"
 	"os"
 	"os/exec"
@@ -26,9 +27,11 @@ import (
 
 	"github.com/golang/mock/gomock"
 	. "github.com/smartystreets/goconvey/convey"
+	capnp "zombiezen.com/go/capnproto2"
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl"
 	"github.com/scionproto/scion/go/lib/ctrl/cert_mgmt"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/disp"
@@ -36,13 +39,18 @@ import (
 	"github.com/scionproto/scion/go/lib/infra/mock_infra"
 	"github.com/scionproto/scion/go/lib/infra/modules/itopo"
 	"github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb/trustdbsqlite"
+	"github.com/scionproto/scion/go/lib/infra/rpc"
+	"github.com/scionproto/scion/go/lib/keyconf"
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/scrypto"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 	"github.com/scionproto/scion/go/lib/snet"
+	"github.com/scionproto/scion/go/lib/snet/squic"
 	"github.com/scionproto/scion/go/lib/topology"
 	"github.com/scionproto/scion/go/lib/topology/topotestutil"
+	"github.com/scionproto/scion/go/lib/util"
 	"github.com/scionproto/scion/go/lib/xtest"
 	"github.com/scionproto/scion/go/lib/xtest/p2p"
 	"github.com/scionproto/scion/go/proto"
@@ -269,6 +277,196 @@ func TestGetTRC(t *testing.T) {
 
 }
 
+func TestGetTRCv2(t *testing.T) {
+	v1 := loadSignedTRC(t, 1, 1)
+	v2 := loadSignedTRC(t, 1, 2)
+	tampered := *v2
+	tampered.Signatures = []trcv2.Signature{v2.Signatures[0]}
+	tampered.Signatures[0].Signature = append(common.RawBytes{}, v2.Signatures[0].Signature...)
+	tampered.Signatures[0].Signature[0] ^= 0xff
+
+	testCases := []struct {
+		Name     string
+		Remote   []*trcv2.Signed
+		Local    []*trcv2.Signed
+		Version  uint64
+		ExpData  *trcv2.Signed
+		ExpError bool
+	}{
+		{
+			Name:    "local version 1",
+			Local:   []*trcv2.Signed{v1},
+			Version: 1,
+			ExpData: v1,
+		},
+		{
+			Name:     "remote base TRC",
+			Remote:   []*trcv2.Signed{v1},
+			Version:  1,
+			ExpError: true,
+		},
+		{
+			Name:    "remote update with local previous TRC",
+			Remote:  []*trcv2.Signed{v1, v2},
+			Local:   []*trcv2.Signed{v1},
+			Version: 2,
+			ExpData: v2,
+		},
+		{
+			Name:     "remote update with remote base TRC",
+			Remote:   []*trcv2.Signed{v1, v2},
+			Version:  2,
+			ExpError: true,
+		},
+		{
+			Name:    "local max version",
+			Remote:  []*trcv2.Signed{v1, v2},
+			Local:   []*trcv2.Signed{v1},
+			Version: scrypto.LatestVer,
+			ExpData: v1,
+		},
+		{
+			Name:     "remote max version without local base TRC",
+			Remote:   []*trcv2.Signed{v1, v2},
+			Version:  scrypto.LatestVer,
+			ExpError: true,
+		},
+		{
+			Name:     "remote update with invalid signature",
+			Remote:   []*trcv2.Signed{v1, &tampered},
+			Local:    []*trcv2.Signed{v1},
+			Version:  2,
+			ExpError: true,
+		},
+		{
+			Name:     "unknown version",
+			Remote:   []*trcv2.Signed{v1, v2},
+			Version:  3,
+			ExpError: true,
+		},
+	}
+
+	Convey("Get signed v2 TRCs", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				msger := newTRCv2MessengerMock(ctrl, tc.Remote)
+				store, cleanF := initStore(t, ctrl, xtest.MustParseIA("1-ff00:0:1"), msger)
+				defer cleanF()
+				for _, signed := range tc.Local {
+					_, err := store.trustdb.InsertTRCv2(context.Background(), signed)
+					xtest.FailOnErr(t, err)
+				}
+
+				ctx, cancelF := context.WithTimeout(context.Background(), testCtxTimeout)
+				defer cancelF()
+				signed, err := store.GetTRCv2(ctx, 1, tc.Version)
+				xtest.SoMsgError("err", err, tc.ExpError)
+				SoMsg("trc", signed, ShouldResemble, tc.ExpData)
+				if !tc.ExpError {
+					stored, err := store.trustdb.GetTRCv2Version(ctx, 1, tc.Version)
+					SoMsg("db err", err, ShouldBeNil)
+					SoMsg("db trc", stored, ShouldResemble, tc.ExpData)
+				}
+			})
+		}
+	})
+}
+
+func TestLoadAuthoritativeTRCv2(t *testing.T) {
+	v1 := loadSignedTRC(t, 1, 1)
+	v2 := loadSignedTRC(t, 1, 2)
+	tampered := *v1
+	tampered.Signatures = append([]trcv2.Signature{}, v1.Signatures...)
+	tampered.Signatures[0].Signature = append(common.RawBytes{}, v1.Signatures[0].Signature...)
+	tampered.Signatures[0].Signature[0] ^= 0xff
+
+	testCases := []struct {
+		Name     string
+		Files    []*trcv2.Signed
+		ExpData  []*trcv2.Signed
+		ExpError bool
+	}{
+		{
+			Name:    "base TRC and update",
+			Files:   []*trcv2.Signed{v1, v2},
+			ExpData: []*trcv2.Signed{v1, v2},
+		},
+		{
+			Name:     "update without base TRC",
+			Files:    []*trcv2.Signed{v2},
+			ExpError: true,
+		},
+		{
+			Name:     "base TRC with invalid signature",
+			Files:    []*trcv2.Signed{&tampered},
+			ExpError: true,
+		},
+	}
+
+	Convey("Load signed v2 TRCs from the local configuration", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				ctrl := gomock.NewController(t)
+				defer ctrl.Finish()
+				// The TRCs must not be fetched from the network.
+				msger := mock_infra.NewMockMessenger(ctrl)
+				store, cleanF := initStore(t, ctrl, xtest.MustParseIA("1-ff00:0:1"), msger)
+				defer cleanF()
+				dir, cleanDirF := xtest.MustTempDir("", "trcv2")
+				defer cleanDirF()
+				for _, signed := range tc.Files {
+					raw, err := signed.Encode()
+					xtest.FailOnErr(t, err)
+					trcObj, err := signed.EncodedTRC.Decode()
+					xtest.FailOnErr(t, err)
+					file := fmt.Sprintf("%s/ISD1-V%d.signed.trc", dir, trcObj.Version)
+					xtest.FailOnErr(t, ioutil.WriteFile(file, raw, 0644))
+				}
+
+				err := store.LoadAuthoritativeTRCv2(dir)
+				xtest.SoMsgError("err", err, tc.ExpError)
+				for i, expected := range tc.ExpData {
+					ctx, cancelF := context.WithTimeout(context.Background(), testCtxTimeout)
+					defer cancelF()
+					stored, err := store.trustdb.GetTRCv2Version(ctx, 1, uint64(i+1))
+					SoMsg("db err", err, ShouldBeNil)
+					SoMsg("db trc", stored, ShouldResemble, expected)
+				}
+			})
+		}
+	})
+}
+
+// newTRCv2MessengerMock returns a messenger that serves the signed v2 TRCs of
+// ISD 1. The TRCs must be sorted by version, starting with version 1.
+func newTRCv2MessengerMock(ctrl *gomock.Controller, trcs []*trcv2.Signed) infra.Messenger {
+	msger := mock_infra.NewMockMessenger(ctrl)
+	msger.EXPECT().GetTRC(gomock.Any(), gomock.Any(), gomock.Any(), gomock.Any()).DoAndReturn(
+		func(_ context.Context, msg *cert_mgmt.TRCReq,
+			a net.Addr, id uint64) (*cert_mgmt.TRC, error) {
+
+			if !msg.V2 || msg.ISD != 1 || len(trcs) == 0 {
+				return nil, common.NewBasicError("TRC not found", nil)
+			}
+			version := msg.Version
+			if version == scrypto.LatestVer {
+				version = uint64(len(trcs))
+			}
+			if version == 0 || version > uint64(len(trcs)) {
+				return nil, common.NewBasicError("TRC not found", nil)
+			}
+			raw, err := trcs[version-1].Encode()
+			if err != nil {
+				return nil, common.NewBasicError("Unable to encode TRC", err)
+			}
+			return &cert_mgmt.TRC{RawTRC: raw}, nil
+		},
+	).AnyTimes()
+	return msger
+}
+
 func TestGetValidChain(t *testing.T) {
 	trcs, chains := loadCrypto(t, isds, ias)
 
@@ -689,6 +887,271 @@ func TestChainReqHandler(t *testing.T) {
 	})
 }
 
+func TestGetValidChainRevocation(t *testing.T) {
+	trcs, chains := loadCrypto(t, isds, ias)
+	issuer := xtest.MustParseIA("1-ff00:0:1")
+	revoked := chains[xtest.MustParseIA("1-ff00:0:2")]
+	crl := newTestCRL(t, chains[issuer].Issuer, revoked.Leaf)
+
+	Convey("Get revoked chains", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msger := newMessengerMock(ctrl, trcs, chains)
+		store, cleanF := initStore(t, ctrl, issuer, msger)
+		defer cleanF()
+		store.config.CheckRevocation = true
+		insertTRC(t, store, trcs[1])
+		insertChain(t, store, chains[xtest.MustParseIA("1-ff00:0:2")])
+		insertChain(t, store, chains[xtest.MustParseIA("1-ff00:0:3")])
+		ctx, cancelF := context.WithTimeout(context.Background(), testCtxTimeout)
+		defer cancelF()
+
+		Convey("Chain without revocation list is accepted", func() {
+			msger.(*mock_infra.MockMessenger).EXPECT().GetCRL(gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).Return(nil, common.NewBasicError("no CRL", nil))
+			chain, err := store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("chain", chain, ShouldResemble, revoked)
+		})
+		Convey("Chain without revocation list is rejected if fresh list is required",
+			func() {
+				store.config.RequireFreshCRL = true
+				msger.(*mock_infra.MockMessenger).EXPECT().GetCRL(gomock.Any(), gomock.Any(),
+					gomock.Any(), gomock.Any()).Return(nil, common.NewBasicError("no CRL", nil))
+				_, err := store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+				SoMsg("err", err, ShouldNotBeNil)
+			})
+		Convey("Failed revocation list fetches are not repeated during backoff", func() {
+			msger.(*mock_infra.MockMessenger).EXPECT().GetCRL(gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).Return(nil, common.NewBasicError("no CRL", nil))
+			for i := 0; i < 2; i++ {
+				_, err := store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+				SoMsg("err", err, ShouldBeNil)
+			}
+		})
+		Convey("Revocation list is fetched from the issuer and stored", func() {
+			rawCRL, err := crl.JSON(false)
+			xtest.FailOnErr(t, err)
+			msger.(*mock_infra.MockMessenger).EXPECT().GetCRL(gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).Return(&cert_mgmt.CRL{RawCRL: rawCRL}, nil)
+			_, err = store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+			SoMsg("err", common.GetErrorMsg(err), ShouldEqual, ErrRevoked)
+			get, err := store.trustdb.GetCRLVersion(ctx, issuer, crl.Version)
+			SoMsg("db err", err, ShouldBeNil)
+			SoMsg("db crl", get, ShouldResemble, crl)
+		})
+		Convey("Revocation list in the database is used", func() {
+			_, err := store.trustdb.InsertCRL(ctx, crl)
+			xtest.FailOnErr(t, err)
+			_, err = store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+			SoMsg("revoked err", common.GetErrorMsg(err), ShouldEqual, ErrRevoked)
+			valid := chains[xtest.MustParseIA("1-ff00:0:3")]
+			chain, err := store.GetValidChain(ctx, valid.Leaf.Subject, scrypto.LatestVer, nil)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("chain", chain, ShouldResemble, valid)
+		})
+		Convey("Stale revocation list is used and refreshed in the background", func() {
+			stale := newTestCRL(t, chains[issuer].Issuer, revoked.Leaf)
+			stale.IssuingTime -= 7200
+			stale.NextUpdate -= 7200
+			_, err := store.trustdb.InsertCRL(ctx, stale)
+			xtest.FailOnErr(t, err)
+			started, release, returned := make(chan struct{}), make(chan struct{}),
+				make(chan struct{})
+			msger.(*mock_infra.MockMessenger).EXPECT().GetCRL(gomock.Any(), gomock.Any(),
+				gomock.Any(), gomock.Any()).DoAndReturn(
+				func(ctx context.Context, _ *cert_mgmt.CRLReq, _ net.Addr,
+					_ uint64) (*cert_mgmt.CRL, error) {
+
+					defer close(returned)
+					close(started)
+					select {
+					case <-release:
+					case <-ctx.Done():
+					}
+					return nil, common.NewBasicError("no CRL", nil)
+				},
+			)
+			_, err = store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+			SoMsg("err", common.GetErrorMsg(err), ShouldEqual, ErrRevoked)
+			<-started
+			select {
+			case <-returned:
+				t.Fatalf("Verification waited for the CRL fetch")
+			default:
+			}
+			close(release)
+			<-returned
+		})
+		Convey("Leaf lifetime exceeding the maximum is rejected", func() {
+			store.config.CheckRevocation = false
+			store.config.MaxLeafLifetime = time.Hour
+			_, err := store.GetValidChain(ctx, revoked.Leaf.Subject, scrypto.LatestVer, nil)
+			SoMsg("err", common.GetErrorMsg(err), ShouldEqual, ErrLeafLifetime)
+		})
+	})
+}
+
+func TestCRLReqHandler(t *testing.T) {
+	trcs, chains := loadCrypto(t, isds, ias)
+	issuer := xtest.MustParseIA("1-ff00:0:1")
+	crl := newTestCRL(t, chains[issuer].Issuer, chains[xtest.MustParseIA("1-ff00:0:2")].Leaf)
+
+	testCases := []struct {
+		Name     string
+		Issuer   addr.IA
+		Version  uint64
+		ExpData  *cert.RevocationList
+		ExpError bool
+	}{
+		{
+			Name:    "ask for known CRL=1-1, version=max",
+			Issuer:  issuer,
+			Version: scrypto.LatestVer,
+			ExpData: crl, ExpError: false,
+		},
+		{
+			Name:    "ask for known CRL=1-1, version=1",
+			Issuer:  issuer,
+			Version: 1,
+			ExpData: crl, ExpError: false,
+		},
+		{
+			Name:    "ask for unknown CRL=1-1, version=2",
+			Issuer:  issuer,
+			Version: 2,
+			ExpData: nil, ExpError: false,
+		},
+		{
+			Name:    "ask for unknown CRL=1-3, version=max",
+			Issuer:  xtest.MustParseIA("1-ff00:0:3"),
+			Version: scrypto.LatestVer,
+			ExpData: nil, ExpError: false,
+		},
+	}
+
+	// See TestTRCReqHandler for info about the testing setup.
+	Convey("Test CRLReq Handler", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msger := newMessengerMock(ctrl, trcs, chains)
+		store, cleanF := initStore(t, ctrl, issuer, msger)
+		defer cleanF()
+		_, err := store.trustdb.InsertCRL(context.Background(), crl)
+		xtest.FailOnErr(t, err)
+
+		c2s, s2c := p2p.NewPacketConns()
+		clientMessenger := setupMessenger(xtest.MustParseIA("2-ff00:0:1"), c2s, nil, "client")
+		serverMessenger := setupMessenger(issuer, s2c, store, "server")
+
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				serverMessenger.AddHandler(infra.CRLRequest, store.NewCRLReqHandler(false))
+				go func() {
+					defer log.LogPanicAndExit()
+					serverMessenger.ListenAndServe()
+				}()
+				defer serverMessenger.CloseServer()
+
+				ctx, cancelF := context.WithTimeout(context.Background(), testCtxTimeout)
+				defer cancelF()
+
+				msg := &cert_mgmt.CRLReq{
+					RawIssuer: tc.Issuer.IAInt(),
+					Version:   tc.Version,
+					CacheOnly: true,
+				}
+				reply, err := clientMessenger.GetCRL(ctx, msg, nil, 73)
+				xtest.SoMsgError("err", err, tc.ExpError)
+				if reply != nil {
+					crl, err := reply.CRL()
+					SoMsg("crl err", err, ShouldBeNil)
+					SoMsg("crl", crl, ShouldResemble, tc.ExpData)
+				}
+			})
+		}
+	})
+}
+
+// echoHandler replies to RPC requests with the request message.
+type echoHandler struct{}
+
+func (echoHandler) ServeRPC(rw rpc.ReplyWriter, request *rpc.Request) {
+	rw.WriteReply(&rpc.Reply{Message: request.Message})
+}
+
+func TestQUICPKI(t *testing.T) {
+	trcs, chains := loadCrypto(t, isds, ias)
+	serverIA := xtest.MustParseIA("1-ff00:0:1")
+	clientIA := xtest.MustParseIA("1-ff00:0:2")
+
+	Convey("Given a QUIC server authenticated with the control-plane PKI", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		msger := newMessengerMock(ctrl, trcs, chains)
+		store, cleanF := initStore(t, ctrl, serverIA, msger)
+		defer cleanF()
+		insertTRC(t, store, trcs[1])
+		insertChain(t, store, chains[serverIA])
+
+		srvConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+		xtest.FailOnErr(t, err)
+		defer srvConn.Close()
+		cliConn, err := net.ListenUDP("udp4", &net.UDPAddr{IP: net.IP{127, 0, 0, 1}})
+		xtest.FailOnErr(t, err)
+		defer cliConn.Close()
+		serverPKI := squic.NewPKI(newPKISigner(t, chains[serverIA], loadSignKey(t, serverIA)),
+			store.NewVerifier())
+		server := &rpc.Server{
+			Conn:      srvConn,
+			TLSConfig: serverPKI.TLSConfig(),
+			Handler:   echoHandler{},
+		}
+		go func() {
+			defer log.LogPanicAndExit()
+			server.ListenAndServe()
+		}()
+		defer server.Close()
+
+		request := func(clientPKI *squic.PKI, ia addr.IA) error {
+			ctx, cancelF := context.WithTimeout(context.Background(), time.Second)
+			defer cancelF()
+			client := &rpc.Client{
+				Conn:      cliConn,
+				TLSConfig: clientPKI.TLSConfigFor(ia),
+			}
+			_, err := client.Request(ctx, &rpc.Request{Message: newTestMessage(t)},
+				srvConn.LocalAddr())
+			return err
+		}
+		Convey("a client with a trusted certificate is accepted", func() {
+			clientPKI := squic.NewPKI(newPKISigner(t, chains[clientIA],
+				loadSignKey(t, clientIA)), store.NewVerifier())
+			SoMsg("err", request(clientPKI, serverIA), ShouldBeNil)
+		})
+		Convey("a client with an untrusted certificate is rejected", func() {
+			_, key, err := scrypto.GenKeyPair(scrypto.Ed25519)
+			xtest.FailOnErr(t, err)
+			clientPKI := squic.NewPKI(newPKISigner(t, chains[clientIA], key),
+				store.NewVerifier())
+			SoMsg("err", request(clientPKI, serverIA), ShouldNotBeNil)
+		})
+		Convey("a client rejects a server of a different AS", func() {
+			clientPKI := squic.NewPKI(newPKISigner(t, chains[clientIA],
+				loadSignKey(t, clientIA)), store.NewVerifier())
+			SoMsg("err", request(clientPKI, clientIA), ShouldNotBeNil)
+		})
+		Convey("a verify-only client is accepted", func() {
+			clientPKI := squic.NewVerifyOnlyPKI(store.NewVerifier())
+			SoMsg("err", request(clientPKI, serverIA), ShouldBeNil)
+		})
+		Convey("a verify-only client rejects a server of a different AS", func() {
+			clientPKI := squic.NewVerifyOnlyPKI(store.NewVerifier())
+			SoMsg("err", request(clientPKI, clientIA), ShouldNotBeNil)
+		})
+	})
+}
+
 func setupMessenger(ia addr.IA, conn net.PacketConn, store *Store, name string) infra.Messenger {
 	config := &messenger.Config{
 		IA: ia,
@@ -729,6 +1192,15 @@ func loadCrypto(t *testing.T, isds []addr.ISD,
 	return trcMap, chainMap
 }
 
+func loadSignedTRC(t *testing.T, isd addr.ISD, version uint64) *trcv2.Signed {
+	t.Helper()
+	raw, err := ioutil.ReadFile(fmt.Sprintf("testdata/trcv2/ISD%d-V%d.signed.trc", isd, version))
+	xtest.FailOnErr(t, err)
+	signed, err := trcv2.ParseSigned(raw)
+	xtest.FailOnErr(t, err)
+	return &signed
+}
+
 func getTRCFileName(isd addr.ISD, version uint64) string {
 	return fmt.Sprintf("%s/ISD%d/trcs/ISD%d-V%d.trc", tmpDir, isd, isd, version)
 }
@@ -771,3 +1243,61 @@ func insertChain(t *testing.T, store *Store, chain *cert.Chain) {
 	_, err := store.trustdb.InsertChain(context.Background(), chain)
 	xtest.FailOnErr(t, err)
 }
+
+// newTestCRL creates a fresh revocation list that revokes the leaf
+// certificate, signed with the signing key of the issuer certificate.
+func newTestCRL(t *testing.T, issCert, leaf *cert.Certificate) *cert.RevocationList {
+	t.Helper()
+	now := util.TimeToSecs(time.Now())
+	crl := &cert.RevocationList{
+		Issuer:        issCert.Subject,
+		IssuerVersion: issCert.Version,
+		IssuingTime:   now,
+		NextUpdate:    now + 3600,
+		Revoked: []cert.Revocation{
+			{Subject: leaf.Subject, Version: leaf.Version, RevocationTime: now},
+		},
+		Version: 1,
+	}
+	file := fmt.Sprintf("%s/ISD%d/AS%s/keys/%s", tmpDir, issCert.Subject.I,
+		issCert.Subject.A.FileFmt(), keyconf.IssSigKeyFile)
+	key, err := keyconf.LoadKey(file, issCert.SignAlgorithm)
+	xtest.FailOnErr(t, err)
+	xtest.FailOnErr(t, crl.Sign(key, issCert.SignAlgorithm))
+	return crl
+}
+
+// newPKISigner creates a signer on behalf of the leaf certificate of chain.
+func newPKISigner(t *testing.T, chain *cert.Chain, key common.RawBytes) infra.Signer {
+	t.Helper()
+	signer, err := NewBasicSigner(key, infra.SignerMeta{
+		Src: ctrl.SignSrcDef{
+			IA:       chain.Leaf.Subject,
+			ChainVer: chain.Leaf.Version,
+			TRCVer:   chain.Issuer.TRCVersion,
+		},
+		Algo:    chain.Leaf.SignAlgorithm,
+		ExpTime: util.SecsToTime(chain.Leaf.ExpirationTime),
+	})
+	xtest.FailOnErr(t, err)
+	return signer
+}
+
+// loadSignKey loads the AS signing key of ia.
+func loadSignKey(t *testing.T, ia addr.IA) common.RawBytes {
+	t.Helper()
+	file := fmt.Sprintf("%s/ISD%d/AS%s/keys/%s", tmpDir, ia.I, ia.A.FileFmt(),
+		keyconf.SigKeyFile)
+	key, err := keyconf.LoadKey(file, scrypto.Ed25519)
+	xtest.FailOnErr(t, err)
+	return key
+}
+
+func newTestMessage(t *testing.T) *capnp.Message {
+	t.Helper()
+	msg, seg, err := capnp.NewMessage(capnp.SingleSegment(nil))
+	xtest.FailOnErr(t, err)
+	_, err = proto.NewRootSignedCtrlPld(seg)
+	xtest.FailOnErr(t, err)
+	return msg
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/scionproto/scion/go/lib/prom"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 )
 
 const (
@@ -48,12 +49,18 @@ const (
 	promOpGetTRC         promOp = "get_trc"
 	promOpGetTRCMV       promOp = "get_trc_mv"
 	promOpGetAllTRCs     promOp = "get_all_trcs"
+	promOpGetTRCv2       promOp = "get_trc_v2"
+	promOpGetTRCv2MV     promOp = "get_trc_v2_mv"
+	promOpGetCRL         promOp = "get_crl"
+	promOpGetCRLMV       promOp = "get_crl_mv"
 	promOpGetCustKey     promOp = "get_cust_key"
 	promOpGetAllCustKeys promOp = "get_all_cust_keys"
 
 	promOpInsertIssCert promOp = "insert_iss_cert"
 	promOpInsertChain   promOp = "insert_chain"
 	promOpInsertTRC     promOp = "insert_trc"
+	promOpInsertTRCv2   promOp = "insert_trc_v2"
+	promOpInsertCRL     promOp = "insert_crl"
 	promOpInsertCustKey promOp = "insert_cust_key"
 
 	promOpBeginTx    promOp = "tx_begin"
@@ -70,10 +77,10 @@ var (
 
 func initMetrics() {
 	initMetricsOnce.Do(func() {
-		// Cardinality: X (dbName) * 18 (len(all ops))
+		// Cardinality: X (dbName) * 24 (len(all ops))
 		queriesTotal = prom.NewCounterVec(promNamespace, "", "queries_total",
 			"Total queries to the database.", []string{promDBName, prom.LabelOperation})
-		// Cardinality: X (dbName) * 18 (len(all ops)) * Y (len(all results))
+		// Cardinality: X (dbName) * 24 (len(all ops)) * Y (len(all results))
 		resultsTotal = prom.NewCounterVec(promNamespace, "", "results_total",
 			"Results of trustdb operations.",
 			[]string{promDBName, prom.LabelOperation, prom.LabelResult})
@@ -224,6 +231,30 @@ func (db *metricsExecutor) InsertTRC(ctx context.Context, trcobj *trc.TRC) (int6
 	return cnt, err
 }
 
+func (db *metricsExecutor) InsertTRCv2(ctx context.Context,
+	signed *trcv2.Signed) (int64, error) {
+
+	var cnt int64
+	var err error
+	db.metrics.Observe(ctx, promOpInsertTRCv2, func(ctx context.Context) error {
+		cnt, err = db.rwDB.InsertTRCv2(ctx, signed)
+		return err
+	})
+	return cnt, err
+}
+
+func (db *metricsExecutor) InsertCRL(ctx context.Context,
+	crl *cert.RevocationList) (int64, error) {
+
+	var cnt int64
+	var err error
+	db.metrics.Observe(ctx, promOpInsertCRL, func(ctx context.Context) error {
+		cnt, err = db.rwDB.InsertCRL(ctx, crl)
+		return err
+	})
+	return cnt, err
+}
+
 func (db *metricsExecutor) InsertCustKey(ctx context.Context, key *CustKey,
 	oldVersion uint64) error {
 
@@ -335,6 +366,54 @@ func (db *metricsExecutor) GetAllTRCs(ctx context.Context) (<-chan TrcOrErr, err
 	return res, err
 }
 
+func (db *metricsExecutor) GetTRCv2Version(ctx context.Context, isd addr.ISD,
+	version uint64) (*trcv2.Signed, error) {
+
+	var res *trcv2.Signed
+	var err error
+	db.metrics.Observe(ctx, promOpGetTRCv2, func(ctx context.Context) error {
+		res, err = db.rwDB.GetTRCv2Version(ctx, isd, version)
+		return err
+	})
+	return res, err
+}
+
+func (db *metricsExecutor) GetTRCv2MaxVersion(ctx context.Context,
+	isd addr.ISD) (*trcv2.Signed, error) {
+
+	var res *trcv2.Signed
+	var err error
+	db.metrics.Observe(ctx, promOpGetTRCv2MV, func(ctx context.Context) error {
+		res, err = db.rwDB.GetTRCv2MaxVersion(ctx, isd)
+		return err
+	})
+	return res, err
+}
+
+func (db *metricsExecutor) GetCRLVersion(ctx context.Context, issuer addr.IA,
+	version uint64) (*cert.RevocationList, error) {
+
+	var res *cert.RevocationList
+	var err error
+	db.metrics.Observe(ctx, promOpGetCRL, func(ctx context.Context) error {
+		res, err = db.rwDB.GetCRLVersion(ctx, issuer, version)
+		return err
+	})
+	return res, err
+}
+
+func (db *metricsExecutor) GetCRLMaxVersion(ctx context.Context,
+	issuer addr.IA) (*cert.RevocationList, error) {
+
+	var res *cert.RevocationList
+	var err error
+	db.metrics.Observe(ctx, promOpGetCRLMV, func(ctx context.Context) error {
+		res, err = db.rwDB.GetCRLMaxVersion(ctx, issuer)
+		return err
+	})
+	return res, err
+}
+
 func (db *metricsExecutor) GetCustKey(ctx context.Context, ia addr.IA) (*CustKey, error) {
 	var res *CustKey
 	var err error
@@ -12,6 +12,7 @@ import (
 	trustdb "github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb"
 	cert "github.com/scionproto/scion/go/lib/scrypto/cert"
 	trc "github.com/scionproto/scion/go/lib/scrypto/trc"
+	v2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 	reflect "reflect"
 )
 
@@ -127,6 +128,36 @@ func (mr *MockTrustDBMockRecorder) GetAllTRCs(arg0 interface{}) *gomock.Call {
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetAllTRCs", reflect.TypeOf((*MockTrustDB)(nil).GetAllTRCs), arg0)
 }
 
+// GetCRLMaxVersion mocks base method
+func (m *MockTrustDB) GetCRLMaxVersion(arg0 context.Context, arg1 addr.IA) (*cert.RevocationList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCRLMaxVersion", arg0, arg1)
+	ret0, _ := ret[0].(*cert.RevocationList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCRLMaxVersion indicates an expected call of GetCRLMaxVersion
+func (mr *MockTrustDBMockRecorder) GetCRLMaxVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCRLMaxVersion", reflect.TypeOf((*MockTrustDB)(nil).GetCRLMaxVersion), arg0, arg1)
+}
+
+// GetCRLVersion mocks base method
+func (m *MockTrustDB) GetCRLVersion(arg0 context.Context, arg1 addr.IA, arg2 uint64) (*cert.RevocationList, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetCRLVersion", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*cert.RevocationList)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetCRLVersion indicates an expected call of GetCRLVersion
+func (mr *MockTrustDBMockRecorder) GetCRLVersion(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetCRLVersion", reflect.TypeOf((*MockTrustDB)(nil).GetCRLVersion), arg0, arg1, arg2)
+}
+
 // GetChainMaxVersion mocks base method
 func (m *MockTrustDB) GetChainMaxVersion(arg0 context.Context, arg1 addr.IA) (*cert.Chain, error) {
 	m.ctrl.T.Helper()
@@ -232,6 +263,51 @@ func (mr *MockTrustDBMockRecorder) GetTRCVersion(arg0, arg1, arg2 interface{}) *
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTRCVersion", reflect.TypeOf((*MockTrustDB)(nil).GetTRCVersion), arg0, arg1, arg2)
 }
 
+// GetTRCv2MaxVersion mocks base method
+func (m *MockTrustDB) GetTRCv2MaxVersion(arg0 context.Context, arg1 addr.ISD) (*v2.Signed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTRCv2MaxVersion", arg0, arg1)
+	ret0, _ := ret[0].(*v2.Signed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTRCv2MaxVersion indicates an expected call of GetTRCv2MaxVersion
+func (mr *MockTrustDBMockRecorder) GetTRCv2MaxVersion(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTRCv2MaxVersion", reflect.TypeOf((*MockTrustDB)(nil).GetTRCv2MaxVersion), arg0, arg1)
+}
+
+// GetTRCv2Version mocks base method
+func (m *MockTrustDB) GetTRCv2Version(arg0 context.Context, arg1 addr.ISD, arg2 uint64) (*v2.Signed, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "GetTRCv2Version", arg0, arg1, arg2)
+	ret0, _ := ret[0].(*v2.Signed)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// GetTRCv2Version indicates an expected call of GetTRCv2Version
+func (mr *MockTrustDBMockRecorder) GetTRCv2Version(arg0, arg1, arg2 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "GetTRCv2Version", reflect.TypeOf((*MockTrustDB)(nil).GetTRCv2Version), arg0, arg1, arg2)
+}
+
+// InsertCRL mocks base method
+func (m *MockTrustDB) InsertCRL(arg0 context.Context, arg1 *cert.RevocationList) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertCRL", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertCRL indicates an expected call of InsertCRL
+func (mr *MockTrustDBMockRecorder) InsertCRL(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertCRL", reflect.TypeOf((*MockTrustDB)(nil).InsertCRL), arg0, arg1)
+}
+
 // InsertChain mocks base method
 func (m *MockTrustDB) InsertChain(arg0 context.Context, arg1 *cert.Chain) (int64, error) {
 	m.ctrl.T.Helper()
@@ -291,6 +367,21 @@ func (mr *MockTrustDBMockRecorder) InsertTRC(arg0, arg1 interface{}) *gomock.Cal
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertTRC", reflect.TypeOf((*MockTrustDB)(nil).InsertTRC), arg0, arg1)
 }
 
+// InsertTRCv2 mocks base method
+func (m *MockTrustDB) InsertTRCv2(arg0 context.Context, arg1 *v2.Signed) (int64, error) {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "InsertTRCv2", arg0, arg1)
+	ret0, _ := ret[0].(int64)
+	ret1, _ := ret[1].(error)
+	return ret0, ret1
+}
+
+// InsertTRCv2 indicates an expected call of InsertTRCv2
+func (mr *MockTrustDBMockRecorder) InsertTRCv2(arg0, arg1 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "InsertTRCv2", reflect.TypeOf((*MockTrustDB)(nil).InsertTRCv2), arg0, arg1)
+}
+
 // SetMaxIdleConns mocks base method
 func (m *MockTrustDB) SetMaxIdleConns(arg0 int) {
 	m.ctrl.T.Helper()
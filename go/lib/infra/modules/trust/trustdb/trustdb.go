@@ -26,6 +26,7 @@ import (
 	"github.com/scionproto/scion/go/lib/infra/modules/db"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 )
 
 // TrustDB is a database containing Certificates, Chains and TRCs, stored in JSON format.
@@ -111,6 +112,19 @@ type Read interface {
 	// be incomplete. Note that the implementation can spawn a goroutine to fill the channel,
 	// therefore the channel must be fully drained to guarantee destruction of the goroutine.
 	GetAllTRCs(ctx context.Context) (<-chan TrcOrErr, error)
+	// GetTRCv2Version returns the specified version of the signed v2 TRC for
+	// isd. If version is scrypto.LatestVer, this is equivalent to GetTRCv2MaxVersion.
+	GetTRCv2Version(ctx context.Context, isd addr.ISD, version uint64) (*trcv2.Signed, error)
+	// GetTRCv2MaxVersion returns the max version of the signed v2 TRC for isd.
+	GetTRCv2MaxVersion(ctx context.Context, isd addr.ISD) (*trcv2.Signed, error)
+	// GetCRLVersion returns the specified version of the certificate revocation
+	// list of the issuer. If version is scrypto.LatestVer, this is equivalent
+	// to GetCRLMaxVersion.
+	GetCRLVersion(ctx context.Context, issuer addr.IA,
+		version uint64) (*cert.RevocationList, error)
+	// GetCRLMaxVersion returns the max version of the certificate revocation
+	// list of the issuer.
+	GetCRLMaxVersion(ctx context.Context, issuer addr.IA) (*cert.RevocationList, error)
 	// GetCustKey gets the latest signing key and version for the specified customer AS.
 	GetCustKey(ctx context.Context, ia addr.IA) (*CustKey, error)
 	// GetAllCustKeys returns a channel that will provide all customer keys in the trust db. If the
@@ -133,6 +147,12 @@ type Write interface {
 	// InsertTRC inserts trcobj into the database. The first return value is the
 	// number of rows affected.
 	InsertTRC(ctx context.Context, trcobj *trc.TRC) (int64, error)
+	// InsertTRCv2 inserts the signed v2 TRC into the database. The first
+	// return value is the number of rows affected.
+	InsertTRCv2(ctx context.Context, signed *trcv2.Signed) (int64, error)
+	// InsertCRL inserts the certificate revocation list into the database. The
+	// first return value is the number of rows affected.
+	InsertCRL(ctx context.Context, crl *cert.RevocationList) (int64, error)
 	// InsertCustKey inserts or updates the given customer key.
 	// If there has been a concurrent insert, i.e. the version in the DB is no longer oldVersion
 	// this operation should return an error.
@@ -0,0 +1,67 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trustdbpostgres
+
+import (
+	"context"
+	"flag"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/infra/modules/db/dbtest"
+	"github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb/trustdbtest"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+var (
+	// connection is the connection string of the PostgreSQL database used in
+	// the tests. The tests only modify the testSchema schema of the database.
+	// If it is empty, the tests are skipped.
+	connection = flag.String("postgres", "", "PostgreSQL connection string")
+)
+
+const testSchema = "trustdb_test"
+
+var _ trustdbtest.TestableTrustDB = (*TestTrustDB)(nil)
+
+type TestTrustDB struct {
+	*Backend
+}
+
+func (b *TestTrustDB) Prepare(t *testing.T, _ context.Context) {
+	if b.Backend != nil {
+		b.Backend.Close()
+	}
+	b.Backend = newDatabase(t)
+}
+
+func TestTrustDBSuite(t *testing.T) {
+	if *connection == "" {
+		t.Skip("No PostgreSQL database specified, use -postgres to run the test")
+	}
+	tdb := &TestTrustDB{}
+	Convey("TrustDBTestSuite", t, func() {
+		trustdbtest.TestTrustDB(t, tdb)
+	})
+}
+
+// newDatabase drops the content of the test schema and returns a freshly set
+// up backend.
+func newDatabase(t *testing.T) *Backend {
+	db, err := New(dbtest.PostgresSchema(t, *connection, testSchema))
+	xtest.FailOnErr(t, err)
+	return db
+}
@@ -33,11 +33,12 @@ import (
 	"github.com/scionproto/scion/go/lib/scrypto"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 )
 
 const (
 	Path          = "trustDB.sqlite3"
-	SchemaVersion = 2
+	SchemaVersion = 4
 	Schema        = `
 	CREATE TABLE TRCs (
 		IsdID INTEGER NOT NULL,
@@ -46,6 +47,21 @@ const (
 		PRIMARY KEY (IsdID, Version)
 	);
 
+	CREATE TABLE TRCsV2 (
+		IsdID INTEGER NOT NULL,
+		Version INTEGER NOT NULL,
+		Data TEXT NOT NULL,
+		PRIMARY KEY (IsdID, Version)
+	);
+
+	CREATE TABLE CRLs (
+		IsdID INTEGER NOT NULL,
+		AsID INTEGER NOT NULL,
+		Version INTEGER NOT NULL,
+		Data TEXT NOT NULL,
+		PRIMARY KEY (IsdID, AsID, Version)
+	);
+
 	CREATE TABLE Chains (
 		IsdID INTEGER NOT NULL,
 		AsID INTEGER NOT NULL,
@@ -91,6 +107,8 @@ const (
 	`
 
 	TRCsTable        = "TRCs"
+	TRCsV2Table      = "TRCsV2"
+	CRLsTable        = "CRLs"
 	ChainsTable      = "Chains"
 	IssuerCertsTable = "IssuerCerts"
 	LeafCertsTable   = "LeafCerts"
@@ -171,6 +189,26 @@ const (
 	getAllTRCsStr = `
 			SELECT Data FROM TRCs
 	`
+	getTRCv2VersionStr = `
+			SELECT Data FROM TRCsV2 WHERE IsdID=? AND Version=?
+		`
+	getTRCv2MaxVersionStr = `
+			SELECT Data FROM (SELECT *, MAX(Version) FROM TRCsV2 WHERE IsdID=?)
+			WHERE Data IS NOT NULL
+		`
+	insertTRCv2Str = `
+			INSERT OR IGNORE INTO TRCsV2 (IsdID, Version, Data) VALUES (?, ?, ?)
+		`
+	getCRLVersionStr = `
+			SELECT Data FROM CRLs WHERE IsdID=? AND AsID=? AND Version=?
+		`
+	getCRLMaxVersionStr = `
+			SELECT Data FROM (SELECT *, MAX(Version) FROM CRLs WHERE IsdID=? AND AsID=?)
+			WHERE Data IS NOT NULL
+		`
+	insertCRLStr = `
+			INSERT OR IGNORE INTO CRLs (IsdID, AsID, Version, Data) VALUES (?, ?, ?, ?)
+		`
 	getCustKeyStr = `
 			SELECT Key, Version FROM CustKeys WHERE IsdID=? AND AsID=?
 	`
@@ -545,6 +583,98 @@ func (db *executor) GetAllTRCs(ctx context.Context) (<-chan trustdb.TrcOrErr, er
 	return trcChan, nil
 }
 
+// GetTRCv2Version returns the specified version of the signed v2 TRC for
+// isd. If version is scrypto.LatestVer, this is equivalent to GetTRCv2MaxVersion.
+func (db *executor) GetTRCv2Version(ctx context.Context,
+	isd addr.ISD, version uint64) (*trcv2.Signed, error) {
+
+	if version == scrypto.LatestVer {
+		return db.GetTRCv2MaxVersion(ctx, isd)
+	}
+	db.RLock()
+	defer db.RUnlock()
+	var raw common.RawBytes
+	err := db.db.QueryRowContext(ctx, getTRCv2VersionStr, isd, version).Scan(&raw)
+	return parseSignedTRC(raw, isd, version, err)
+}
+
+// GetTRCv2MaxVersion returns the max version of the signed v2 TRC for isd.
+func (db *executor) GetTRCv2MaxVersion(ctx context.Context,
+	isd addr.ISD) (*trcv2.Signed, error) {
+
+	db.RLock()
+	defer db.RUnlock()
+	var raw common.RawBytes
+	err := db.db.QueryRowContext(ctx, getTRCv2MaxVersionStr, isd).Scan(&raw)
+	return parseSignedTRC(raw, isd, scrypto.LatestVer, err)
+}
+
+// InsertTRCv2 inserts the signed v2 TRC into the database. The first return
+// value is the number of rows affected.
+func (db *executor) InsertTRCv2(ctx context.Context, signed *trcv2.Signed) (int64, error) {
+	t, err := signed.EncodedTRC.Decode()
+	if err != nil {
+		return 0, common.NewBasicError("Unable to decode TRC payload", err)
+	}
+	raw, err := signed.Encode()
+	if err != nil {
+		return 0, common.NewBasicError("Unable to convert to JSON", err)
+	}
+	db.Lock()
+	defer db.Unlock()
+	res, err := db.db.ExecContext(ctx, insertTRCv2Str, t.ISD, t.Version, raw)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
+// GetCRLVersion returns the specified version of the certificate revocation
+// list of the issuer. If version is scrypto.LatestVer, this is equivalent to
+// GetCRLMaxVersion.
+func (db *executor) GetCRLVersion(ctx context.Context, issuer addr.IA,
+	version uint64) (*cert.RevocationList, error) {
+
+	if version == scrypto.LatestVer {
+		return db.GetCRLMaxVersion(ctx, issuer)
+	}
+	db.RLock()
+	defer db.RUnlock()
+	var raw common.RawBytes
+	err := db.db.QueryRowContext(ctx, getCRLVersionStr, issuer.I, issuer.A,
+		version).Scan(&raw)
+	return parseCRL(raw, issuer, version, err)
+}
+
+// GetCRLMaxVersion returns the max version of the certificate revocation list
+// of the issuer.
+func (db *executor) GetCRLMaxVersion(ctx context.Context,
+	issuer addr.IA) (*cert.RevocationList, error) {
+
+	db.RLock()
+	defer db.RUnlock()
+	var raw common.RawBytes
+	err := db.db.QueryRowContext(ctx, getCRLMaxVersionStr, issuer.I, issuer.A).Scan(&raw)
+	return parseCRL(raw, issuer, scrypto.LatestVer, err)
+}
+
+// InsertCRL inserts the certificate revocation list into the database. The
+// first return value is the number of rows affected.
+func (db *executor) InsertCRL(ctx context.Context, crl *cert.RevocationList) (int64, error) {
+	raw, err := crl.JSON(false)
+	if err != nil {
+		return 0, common.NewBasicError("Unable to convert to JSON", err)
+	}
+	db.Lock()
+	defer db.Unlock()
+	res, err := db.db.ExecContext(ctx, insertCRLStr, crl.Issuer.I, crl.Issuer.A,
+		crl.Version, raw)
+	if err != nil {
+		return 0, err
+	}
+	return res.RowsAffected()
+}
+
 // GetCustKey gets the latest signing key and version for the specified customer AS.
 func (db *executor) GetCustKey(ctx context.Context, ia addr.IA) (*trustdb.CustKey, error) {
 	db.RLock()
@@ -744,3 +874,42 @@ func getIssCertRowIDCtx(ctx context.Context, db db.Sqler,
 	}
 	return rowId, nil
 }
+
+func parseSignedTRC(raw common.RawBytes, isd addr.ISD, v uint64,
+	err error) (*trcv2.Signed, error) {
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewBasicError("Database access error", err)
+	}
+	signed, err := trcv2.ParseSigned(raw)
+	if err != nil {
+		if v == scrypto.LatestVer {
+			return nil, common.NewBasicError("TRC parse error", err, "isd", isd, "version", "max")
+		}
+		return nil, common.NewBasicError("TRC parse error", err, "isd", isd, "version", v)
+	}
+	return &signed, nil
+}
+
+func parseCRL(raw common.RawBytes, issuer addr.IA, v uint64,
+	err error) (*cert.RevocationList, error) {
+
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewBasicError("Database access error", err)
+	}
+	crl, err := cert.RevocationListFromRaw(raw)
+	if err != nil {
+		if v == scrypto.LatestVer {
+			return nil, common.NewBasicError("CRL parse error", err, "issuer", issuer,
+				"version", "max")
+		}
+		return nil, common.NewBasicError("CRL parse error", err, "issuer", issuer, "version", v)
+	}
+	return crl, nil
+}
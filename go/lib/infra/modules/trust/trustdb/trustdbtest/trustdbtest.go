@@ -17,6 +17,7 @@ package trustdbtest
 import (
 	"context"
 	"fmt"
+	"io/ioutil"
 	"strings"
 	"testing"
 	"time"
@@ -29,6 +30,7 @@ import (
 	"github.com/scionproto/scion/go/lib/scrypto"
 	"github.com/scionproto/scion/go/lib/scrypto/cert"
 	"github.com/scionproto/scion/go/lib/scrypto/trc"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
 	"github.com/scionproto/scion/go/lib/xtest"
 )
 
@@ -61,6 +63,8 @@ func TestTrustDB(t *testing.T, db TestableTrustDB) {
 	}
 	Convey("TestTRC", testWrapper(testTRC))
 	Convey("TestTRCGetAll", testWrapper(testTRCGetAll))
+	Convey("TestTRCv2", testWrapper(testTRCv2))
+	Convey("TestCRL", testWrapper(testCRL))
 	Convey("TestIssCert", testWrapper(testIssCert))
 	Convey("TestGetAllIssCerts", testWrapper(testGetAllIssCerts))
 	Convey("TestChain", testWrapper(testChain))
@@ -91,6 +95,8 @@ func TestTrustDB(t *testing.T, db TestableTrustDB) {
 	Convey("WithTransaction", func() {
 		Convey("TestTRC", txTestWrapper(testTRC))
 		Convey("TestTRCGetAll", txTestWrapper(testTRCGetAll))
+		Convey("TestTRCv2", txTestWrapper(testTRCv2))
+		Convey("TestCRL", txTestWrapper(testCRL))
 		Convey("TestIssCert", txTestWrapper(testIssCert))
 		Convey("TestGetAllIssCerts", txTestWrapper(testGetAllIssCerts))
 		Convey("TestChain", txTestWrapper(testChain))
@@ -195,6 +201,127 @@ func insertTRCFromFile(t *testing.T, ctx context.Context,
 	return trcobj
 }
 
+func testTRCv2(t *testing.T, db trustdb.ReadWrite) {
+	Convey("Initialize DB and load signed v2 TRCs", func() {
+		ctx, cancelF := context.WithTimeout(context.Background(), Timeout)
+		defer cancelF()
+
+		v1 := loadSignedTRC(t, "ISD1-V1.signed.trc")
+		v2 := loadSignedTRC(t, "ISD1-V2.signed.trc")
+		Convey("Insert into database", func() {
+			rows, err := db.InsertTRCv2(ctx, v1)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("rows", rows, ShouldNotEqual, 0)
+			rows, err = db.InsertTRCv2(ctx, v1)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("rows", rows, ShouldEqual, 0)
+			Convey("Get TRC from database", func() {
+				signed, err := db.GetTRCv2Version(ctx, 1, 1)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", signed, ShouldResemble, v1)
+			})
+			Convey("Get Max TRC from database", func() {
+				_, err := db.InsertTRCv2(ctx, v2)
+				SoMsg("err", err, ShouldBeNil)
+				signed, err := db.GetTRCv2MaxVersion(ctx, 1)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", signed, ShouldResemble, v2)
+				signed, err = db.GetTRCv2Version(ctx, 1, scrypto.LatestVer)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", signed, ShouldResemble, v2)
+			})
+			Convey("Legacy TRCs are stored separately", func() {
+				trcobj, err := db.GetTRCVersion(ctx, 1, 1)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", trcobj, ShouldBeNil)
+			})
+			Convey("Get missing TRC from database", func() {
+				signed, err := db.GetTRCv2Version(ctx, 2, 10)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", signed, ShouldBeNil)
+			})
+			Convey("Get missing Max TRC from database", func() {
+				signed, err := db.GetTRCv2Version(ctx, 2, scrypto.LatestVer)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", signed, ShouldBeNil)
+				signed, err = db.GetTRCv2MaxVersion(ctx, 2)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("trc", signed, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func testCRL(t *testing.T, db trustdb.ReadWrite) {
+	Convey("Initialize DB and create CRLs", func() {
+		ctx, cancelF := context.WithTimeout(context.Background(), Timeout)
+		defer cancelF()
+
+		issuer := addr.IA{I: 1, A: 0xff0000000310}
+		v1 := newCRL(issuer, 1)
+		v2 := newCRL(issuer, 2)
+		Convey("Insert into database", func() {
+			rows, err := db.InsertCRL(ctx, v1)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("rows", rows, ShouldNotEqual, 0)
+			rows, err = db.InsertCRL(ctx, v1)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("rows", rows, ShouldEqual, 0)
+			Convey("Get CRL from database", func() {
+				crl, err := db.GetCRLVersion(ctx, issuer, 1)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("crl", crl, ShouldResemble, v1)
+			})
+			Convey("Get Max CRL from database", func() {
+				_, err := db.InsertCRL(ctx, v2)
+				SoMsg("err", err, ShouldBeNil)
+				crl, err := db.GetCRLMaxVersion(ctx, issuer)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("crl", crl, ShouldResemble, v2)
+				crl, err = db.GetCRLVersion(ctx, issuer, scrypto.LatestVer)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("crl", crl, ShouldResemble, v2)
+			})
+			Convey("Get missing CRL from database", func() {
+				crl, err := db.GetCRLVersion(ctx, issuer, 10)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("crl", crl, ShouldBeNil)
+			})
+			Convey("Get missing Max CRL from database", func() {
+				other := addr.IA{I: 1, A: 0xff0000000320}
+				crl, err := db.GetCRLVersion(ctx, other, scrypto.LatestVer)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("crl", crl, ShouldBeNil)
+				crl, err = db.GetCRLMaxVersion(ctx, other)
+				SoMsg("err", err, ShouldBeNil)
+				SoMsg("crl", crl, ShouldBeNil)
+			})
+		})
+	})
+}
+
+func newCRL(issuer addr.IA, version uint64) *cert.RevocationList {
+	return &cert.RevocationList{
+		Issuer:        issuer,
+		IssuerVersion: 1,
+		IssuingTime:   1,
+		NextUpdate:    2,
+		Revoked: []cert.Revocation{
+			{Subject: addr.IA{I: 1, A: 0xff0000000311}, Version: version, RevocationTime: 1},
+		},
+		Signature: common.RawBytes{0x01, 0x02, 0x03},
+		Version:   version,
+	}
+}
+
+func loadSignedTRC(t *testing.T, fName string) *trcv2.Signed {
+	raw, err := ioutil.ReadFile(filePath(fName))
+	xtest.FailOnErr(t, err)
+	signed, err := trcv2.ParseSigned(raw)
+	xtest.FailOnErr(t, err)
+	return &signed
+}
+
 func testIssCert(t *testing.T, db trustdb.ReadWrite) {
 	Convey("Initialize DB and load issuer Cert", func() {
 		ctx, cancelF := context.WithTimeout(context.Background(), Timeout)
@@ -383,7 +510,7 @@ func testCustKey(t *testing.T, db trustdb.ReadWrite) {
 		ctx, cancelF := context.WithTimeout(context.Background(), Timeout)
 		defer cancelF()
 
-		ia1_110 := xtest.MustParseIA("1-ff00:0:110")
+		ia1_110 := addr.IA{I: 1, A: 0xff0000000310}
 		key_110_1 := common.RawBytes("dddddddd")
 		key_110_2 := common.RawBytes("ddddddaa")
 
@@ -436,12 +563,12 @@ func testCustKey(t *testing.T, db trustdb.ReadWrite) {
 
 func testGetAllCustKeys(t *testing.T, db trustdb.ReadWrite) {
 	key110 := &trustdb.CustKey{
-		IA:      xtest.MustParseIA("1-ff00:0:110"),
+		IA:      addr.IA{I: 1, A: 0xff0000000310},
 		Key:     common.RawBytes("dddddddd"),
 		Version: 1,
 	}
 	key111 := &trustdb.CustKey{
-		IA:      xtest.MustParseIA("1-ff00:0:111"),
+		IA:      addr.IA{I: 1, A: 0xff0000000311},
 		Key:     common.RawBytes("ddddddaa"),
 		Version: 1,
 	}
@@ -27,6 +27,7 @@ import (
 	quic "github.com/lucas-clemente/quic-go"
 	capnp "zombiezen.com/go/capnproto2"
 
+	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/snet"
@@ -131,6 +132,10 @@ type Client struct {
 	Conn net.PacketConn
 	// TLSConfig is the client's TLS configuration for starting QUIC connections.
 	TLSConfig *tls.Config
+	// PeerTLSConfig, if not nil, returns the TLS configuration for starting
+	// QUIC connections to the AS ia. It is used instead of TLSConfig for SCION
+	// addresses, such that the identity of the server can be verified.
+	PeerTLSConfig func(ia addr.IA) *tls.Config
 	// QUICConfig is the client's QUIC configuration.
 	QUICConfig *quic.Config
 }
@@ -142,7 +147,7 @@ func (c *Client) Request(ctx context.Context, request *Request, address net.Addr
 	addressStr := computeAddressStr(address)
 
 	session, err := quic.DialContext(ctx, c.Conn, address, addressStr,
-		c.TLSConfig, c.QUICConfig)
+		c.tlsConfig(address), c.QUICConfig)
 	if err != nil {
 		return nil, err
 	}
@@ -176,6 +181,13 @@ func (c *Client) Request(ctx context.Context, request *Request, address net.Addr
 	return &Reply{Message: msg}, nil
 }
 
+func (c *Client) tlsConfig(address net.Addr) *tls.Config {
+	if snetAddr, ok := address.(*snet.Addr); ok && c.PeerTLSConfig != nil {
+		return c.PeerTLSConfig(snetAddr.IA)
+	}
+	return c.TLSConfig
+}
+
 func (c *Client) sendRequest() error {
 	return nil
 }
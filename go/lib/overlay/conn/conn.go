@@ -39,7 +39,7 @@ import (
 // opened sockets.
 const ReceiveBufferSize = 1 << 20
 
-var oobSize = syscall.CmsgSpace(SizeOfInt) + syscall.CmsgSpace(SizeOfTimespec)
+var oobSize = 2*syscall.CmsgSpace(SizeOfInt) + syscall.CmsgSpace(SizeOfTimespec)
 var sizeIgnore = flag.Bool("overlay.conn.sizeIgnore", true,
 	"Ignore failing to set the receive buffer size on a socket.")
 
@@ -68,6 +68,10 @@ type Config struct {
 	// ReceiveBufferSize is the size of the operating system receive buffer, in
 	// bytes. If 0, the package constant is used instead.
 	ReceiveBufferSize int
+	// UDPOffload enables UDP generic segmentation offload (GSO) and generic
+	// receive offload (GRO), if supported by the kernel. Both are transparent
+	// to the user of the socket.
+	UDPOffload bool
 }
 
 func (c *Config) getReceiveBufferSize() int {
@@ -94,9 +98,17 @@ func New(listen, remote *overlay.OverlayAddr, cfg *Config) (Conn, error) {
 	}
 	switch a.Type() {
 	case overlay.UDPIPv6:
-		return newConnUDPIPv6(listen, remote, cfg)
+		c, err := newConnUDPIPv6(listen, remote, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return withOffload(c, c.conn, cfg), nil
 	case overlay.UDPIPv4:
-		return newConnUDPIPv4(listen, remote, cfg)
+		c, err := newConnUDPIPv4(listen, remote, cfg)
+		if err != nil {
+			return nil, err
+		}
+		return withOffload(c, c.conn, cfg), nil
 	}
 	return nil, common.NewBasicError("Unsupported overlay type", nil, "overlay", a.Type())
 }
@@ -277,7 +289,7 @@ func (cc *connUDPBase) initConnUDP(network string, listen, remote *overlay.Overl
 		}
 		log.Warn(msg, ctx...)
 	}
-	oob := make(common.RawBytes, syscall.CmsgSpace(SizeOfInt)+syscall.CmsgSpace(SizeOfTimespec))
+	oob := make(common.RawBytes, oobSize)
 	cc.conn = c
 	cc.Listen = listen
 	cc.Remote = remote
@@ -329,6 +341,8 @@ func (c *connUDPBase) handleCmsg(oob common.RawBytes, meta *ReadMeta, readTime t
 			if meta.ReadDelay < 0 {
 				meta.ReadDelay = 0
 			}
+		case hdr.Level == solUDP && hdr.Type == udpGRO:
+			meta.segSize = *(*int)(unsafe.Pointer(&oob[sizeofCmsgHdr]))
 		}
 		// What we actually want is the padded length of the cmsg, but CmsgLen
 		// adds a CmsgHdr length to the result, so we subtract that.
@@ -383,6 +397,9 @@ type ReadMeta struct {
 	// socket's receive buffer, and the application reading it from the Go
 	// network stack (i.e., kernel to application latency).
 	ReadDelay time.Duration
+	// segSize is the size of the datagrams coalesced by GRO, 0 if the read
+	// returned a single datagram.
+	segSize int
 }
 
 func (m *ReadMeta) reset() {
@@ -390,6 +407,7 @@ func (m *ReadMeta) reset() {
 	m.RcvOvfl = 0
 	m.Recvd = time.Unix(0, 0)
 	m.ReadDelay = 0
+	m.segSize = 0
 }
 
 func (m *ReadMeta) setSrc(a *overlay.OverlayAddr, raddr *net.UDPAddr, ot overlay.Type) {
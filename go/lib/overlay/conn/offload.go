@@ -0,0 +1,320 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.9,linux
+
+// This file implements UDP generic segmentation offload (GSO) and generic
+// receive offload (GRO). With GSO, consecutive messages of the same size to
+// the same destination are passed to the kernel as a single large datagram,
+// which is split into the individual datagrams by the kernel or the NIC. With
+// GRO, the kernel coalesces received datagrams of the same flow into a single
+// large datagram, which is split into the individual datagrams here.
+
+package conn
+
+import (
+	"bytes"
+	"net"
+	"os"
+	"syscall"
+	"unsafe"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/sockctrl"
+)
+
+const (
+	// solUDP is the socket option level of UDP.
+	solUDP = syscall.IPPROTO_UDP
+	// udpSegment is the socket option and control message type of GSO.
+	udpSegment = 103
+	// udpGRO is the socket option and control message type of GRO.
+	udpGRO = 104
+	// gsoMaxSegments is the maximum number of datagrams sent with GSO at once.
+	gsoMaxSegments = 64
+	// gsoMaxSize is the maximum size of the datagrams sent with GSO at once,
+	// i.e., the maximum UDP payload size over IPv4.
+	gsoMaxSize = 65507
+	// groBufSize is the size of the buffers coalesced datagrams are read into.
+	groBufSize = 1 << 16
+	// groBatchSize is the maximum number of coalesced datagrams read at once.
+	groBatchSize = 16
+)
+
+// gsoOOBSize is the size of the GSO control message.
+var gsoOOBSize = syscall.CmsgSpace(2)
+
+// offloadConn is a Conn with GSO and GRO. Either of them is nil, if it is not
+// supported by the kernel.
+type offloadConn struct {
+	Conn
+	gso *gsoWriter
+	gro *groReader
+}
+
+// withOffload enables GSO and GRO on the socket, if configured and supported
+// by the kernel.
+func withOffload(c Conn, udp *net.UDPConn, cfg *Config) Conn {
+	if !cfg.UDPOffload {
+		return c
+	}
+	oc := &offloadConn{Conn: c}
+	if _, err := sockctrl.GetsockoptInt(udp, solUDP, udpSegment); err == nil {
+		oc.gso = &gsoWriter{}
+	} else {
+		log.Info("UDP GSO not supported", "addr", c.LocalAddr(), "err", err)
+	}
+	if err := sockctrl.SetsockoptInt(udp, solUDP, udpGRO, 1); err == nil {
+		oc.gro = newGROReader()
+	} else {
+		log.Info("UDP GRO not supported", "addr", c.LocalAddr(), "err", err)
+	}
+	if oc.gso == nil && oc.gro == nil {
+		return c
+	}
+	return oc
+}
+
+func (c *offloadConn) Read(b common.RawBytes) (int, *ReadMeta, error) {
+	if c.gro == nil {
+		return c.Conn.Read(b)
+	}
+	msgs := Messages{{Buffers: [][]byte{b}}}
+	metas := make([]ReadMeta, 1)
+	n, err := c.gro.readBatch(c.Conn, msgs, metas)
+	if n <= 0 {
+		return 0, &metas[0], err
+	}
+	return msgs[0].N, &metas[0], err
+}
+
+func (c *offloadConn) ReadBatch(msgs Messages, metas []ReadMeta) (int, error) {
+	if c.gro == nil {
+		return c.Conn.ReadBatch(msgs, metas)
+	}
+	return c.gro.readBatch(c.Conn, msgs, metas)
+}
+
+func (c *offloadConn) WriteBatch(msgs Messages) (int, error) {
+	if c.gso == nil {
+		return c.Conn.WriteBatch(msgs)
+	}
+	return c.gso.writeBatch(c.Conn, msgs)
+}
+
+// groReader reads coalesced datagrams into large buffers, and returns the
+// individual datagrams. It is not safe for concurrent use.
+type groReader struct {
+	msgs  Messages
+	metas []ReadMeta
+	// segs are the datagrams of the last read. The ones starting from next
+	// have not been returned yet.
+	segs []segment
+	next int
+}
+
+// segment is a datagram in the buffer of msgs[msg].
+type segment struct {
+	msg  int
+	data []byte
+}
+
+func newGROReader() *groReader {
+	r := &groReader{
+		msgs:  NewReadMessages(groBatchSize),
+		metas: make([]ReadMeta, groBatchSize),
+	}
+	for i := range r.msgs {
+		r.msgs[i].Buffers[0] = make([]byte, groBufSize)
+	}
+	return r
+}
+
+// readBatch reads up to len(msgs) datagrams. The underlying socket is only
+// read once all datagrams of the previous read have been returned.
+func (r *groReader) readBatch(c Conn, msgs Messages, metas []ReadMeta) (int, error) {
+	if r.next == len(r.segs) {
+		toRead := len(r.msgs)
+		if len(msgs) < toRead {
+			toRead = len(msgs)
+		}
+		n, err := c.ReadBatch(r.msgs[:toRead], r.metas[:toRead])
+		if n <= 0 {
+			return n, err
+		}
+		r.split(n)
+	}
+	var n int
+	for ; n < len(msgs) && r.next < len(r.segs); n++ {
+		seg := r.segs[r.next]
+		msgs[n].N = copy(msgs[n].Buffers[0], seg.data)
+		msgs[n].NN = 0
+		msgs[n].Addr = r.msgs[seg.msg].Addr
+		metas[n] = r.metas[seg.msg]
+		metas[n].segSize = 0
+		r.next++
+	}
+	return n, nil
+}
+
+// split splits the first n read messages into their datagrams. All but the
+// last datagram of a message are of the GRO segment size.
+func (r *groReader) split(n int) {
+	r.segs, r.next = r.segs[:0], 0
+	for i := 0; i < n; i++ {
+		data := r.msgs[i].Buffers[0][:r.msgs[i].N]
+		segSize := r.metas[i].segSize
+		if segSize <= 0 {
+			segSize = len(data)
+		}
+		for len(data) > segSize {
+			r.segs = append(r.segs, segment{msg: i, data: data[:segSize]})
+			data = data[segSize:]
+		}
+		r.segs = append(r.segs, segment{msg: i, data: data})
+	}
+}
+
+// gsoWriter coalesces consecutive messages of the same size to the same
+// destination into a single message. It is not safe for concurrent use.
+type gsoWriter struct {
+	msgs Messages
+	// counts holds the number of coalesced messages of each message in msgs.
+	counts []int
+	// disabled is set if the kernel or the NIC failed to send with GSO.
+	disabled bool
+}
+
+func (w *gsoWriter) writeBatch(c Conn, msgs Messages) (int, error) {
+	if w.disabled {
+		return c.WriteBatch(msgs)
+	}
+	w.coalesce(msgs)
+	if len(w.msgs) == len(msgs) {
+		return c.WriteBatch(msgs)
+	}
+	n, err := c.WriteBatch(w.msgs)
+	if n <= 0 && isGSOError(err) {
+		log.Info("Disabling UDP GSO", "addr", c.LocalAddr(), "err", err)
+		w.disabled = true
+		return c.WriteBatch(msgs)
+	}
+	var written int
+	for i := 0; i < n; i++ {
+		if w.counts[i] == 1 {
+			msgs[written].N = w.msgs[i].N
+			written++
+			continue
+		}
+		for j := 0; j < w.counts[i]; j++ {
+			msgs[written].N = len(msgs[written].Buffers[0])
+			written++
+		}
+	}
+	return written, err
+}
+
+// coalesce sets w.msgs to the coalesced msgs. The buffers of the coalesced
+// messages reference the buffers in msgs.
+func (w *gsoWriter) coalesce(msgs Messages) {
+	w.msgs, w.counts = w.msgs[:0], w.counts[:0]
+	for i := 0; i < len(msgs); {
+		cnt := gsoGroupLen(msgs[i:])
+		if len(w.msgs) < cap(w.msgs) {
+			w.msgs = w.msgs[:len(w.msgs)+1]
+		} else {
+			w.msgs = append(w.msgs, Messages{{}}...)
+		}
+		m := &w.msgs[len(w.msgs)-1]
+		m.Buffers = m.Buffers[:0]
+		for _, msg := range msgs[i : i+cnt] {
+			m.Buffers = append(m.Buffers, msg.Buffers...)
+		}
+		m.Addr = msgs[i].Addr
+		m.OOB = append(m.OOB[:0], msgs[i].OOB...)
+		if cnt > 1 {
+			m.OOB = appendGSOCmsg(m.OOB, len(msgs[i].Buffers[0]))
+		}
+		m.N, m.NN, m.Flags = 0, 0, 0
+		w.counts = append(w.counts, cnt)
+		i += cnt
+	}
+}
+
+// gsoGroupLen returns the number of messages at the start of msgs that can be
+// sent with GSO at once. All but the last message must be of the same size,
+// the last one can be smaller.
+func gsoGroupLen(msgs Messages) int {
+	first := msgs[0]
+	if len(first.Buffers) != 1 {
+		return 1
+	}
+	segSize := len(first.Buffers[0])
+	total := segSize
+	n := 1
+	for n < len(msgs) && n < gsoMaxSegments {
+		m := msgs[n]
+		size := len(m.Buffers[0])
+		if len(m.Buffers) != 1 || size == 0 || size > segSize || total+size > gsoMaxSize {
+			break
+		}
+		if !sameDst(first.Addr, m.Addr) || !bytes.Equal(first.OOB, m.OOB) {
+			break
+		}
+		n++
+		total += size
+		if size < segSize {
+			break
+		}
+	}
+	return n
+}
+
+func sameDst(a, b net.Addr) bool {
+	ua, okA := a.(*net.UDPAddr)
+	ub, okB := b.(*net.UDPAddr)
+	if !okA || !okB {
+		return a == b
+	}
+	return ua.Port == ub.Port && ua.IP.Equal(ub.IP)
+}
+
+// appendGSOCmsg appends the GSO control message with the segment size to oob.
+func appendGSOCmsg(oob []byte, segSize int) []byte {
+	start := len(oob)
+	for i := 0; i < gsoOOBSize; i++ {
+		oob = append(oob, 0)
+	}
+	hdr := (*syscall.Cmsghdr)(unsafe.Pointer(&oob[start]))
+	hdr.Level = solUDP
+	hdr.Type = udpSegment
+	hdr.SetLen(syscall.CmsgLen(2))
+	common.NativeOrder.PutUint16(oob[start+syscall.CmsgLen(0):], uint16(segSize))
+	return oob
+}
+
+// isGSOError returns whether the error is caused by GSO not being usable,
+// e.g., because the NIC does not support checksum offload.
+func isGSOError(err error) bool {
+	netErr, ok := err.(*net.OpError)
+	if !ok {
+		return false
+	}
+	osErr, ok := netErr.Err.(*os.SyscallError)
+	if !ok {
+		return false
+	}
+	return osErr.Err == syscall.EIO || osErr.Err == syscall.EINVAL
+}
@@ -0,0 +1,138 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// +build go1.9,linux
+
+package conn
+
+import (
+	"bytes"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/net/ipv4"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/overlay"
+)
+
+func TestGSOCoalesce(t *testing.T) {
+	Convey("Coalescing messages", t, func() {
+		dstA := &net.UDPAddr{IP: net.IP{127, 0, 0, 1}, Port: 1}
+		dstB := &net.UDPAddr{IP: net.IP{127, 0, 0, 1}, Port: 2}
+		msgs := Messages{
+			testMsg(100, dstA), testMsg(100, dstA), testMsg(50, dstA),
+			testMsg(100, dstA), testMsg(100, dstB), testMsg(200, dstB),
+		}
+		w := &gsoWriter{}
+		w.coalesce(msgs)
+		Convey("groups messages of the same size to the same destination", func() {
+			So(w.counts, ShouldResemble, []int{3, 1, 1, 1})
+			So(len(w.msgs[0].Buffers), ShouldEqual, 3)
+			So(len(w.msgs[0].OOB), ShouldEqual, gsoOOBSize)
+			So(len(w.msgs[1].OOB), ShouldEqual, 0)
+		})
+		Convey("does not modify the messages", func() {
+			w.coalesce(msgs[1:])
+			for _, m := range msgs {
+				So(len(m.Buffers), ShouldEqual, 1)
+			}
+		})
+		Convey("limits the number of segments", func() {
+			many := make(Messages, gsoMaxSegments+1)
+			for i := range many {
+				many[i] = testMsg(10, dstA)
+			}
+			So(gsoGroupLen(many), ShouldEqual, gsoMaxSegments)
+		})
+	})
+}
+
+func TestGROSplit(t *testing.T) {
+	Convey("Splitting coalesced datagrams", t, func() {
+		r := newGROReader()
+		r.msgs[0].N = 250
+		r.metas[0].segSize = 100
+		r.msgs[1].N = 80
+		r.split(2)
+		So(len(r.segs), ShouldEqual, 4)
+		So(len(r.segs[2].data), ShouldEqual, 50)
+		So(r.segs[3].msg, ShouldEqual, 1)
+		So(len(r.segs[3].data), ShouldEqual, 80)
+	})
+}
+
+func TestOffloadLoopback(t *testing.T) {
+	Convey("Datagrams sent and received with offload", t, func() {
+		cfg := &Config{UDPOffload: true}
+		rcv, err := New(mustOverlayAddr(t, 0), nil, cfg)
+		So(err, ShouldBeNil)
+		defer rcv.Close()
+		snd, err := New(mustOverlayAddr(t, 0), nil, cfg)
+		So(err, ShouldBeNil)
+		defer snd.Close()
+		udpDst := &net.UDPAddr{IP: net.IP{127, 0, 0, 1}, Port: localPort(rcv)}
+		sent := make(Messages, 10)
+		for i := range sent {
+			sent[i] = testMsg(100, udpDst)
+			if i == len(sent)-1 {
+				sent[i].Buffers[0] = sent[i].Buffers[0][:30]
+			}
+			sent[i].Buffers[0][0] = byte(i)
+		}
+		n, err := snd.WriteBatch(sent)
+		So(err, ShouldBeNil)
+		So(n, ShouldEqual, len(sent))
+		So(sent[0].N, ShouldEqual, 100)
+		rcv.SetReadDeadline(time.Now().Add(time.Second))
+		var received [][]byte
+		for len(received) < len(sent) {
+			msgs := NewReadMessages(4)
+			for i := range msgs {
+				msgs[i].Buffers[0] = make([]byte, 1500)
+			}
+			n, err := rcv.ReadBatch(msgs, make([]ReadMeta, len(msgs)))
+			So(err, ShouldBeNil)
+			for _, m := range msgs[:n] {
+				received = append(received, m.Buffers[0][:m.N])
+			}
+		}
+		for i := range sent {
+			So(bytes.Equal(received[i], sent[i].Buffers[0]), ShouldBeTrue)
+		}
+	})
+}
+
+func testMsg(size int, dst net.Addr) ipv4.Message {
+	return ipv4.Message{Buffers: [][]byte{make([]byte, size)}, Addr: dst}
+}
+
+func mustOverlayAddr(t *testing.T, port uint16) *overlay.OverlayAddr {
+	a, err := overlay.NewOverlayAddr(addr.HostFromIP(net.IP{127, 0, 0, 1}),
+		addr.NewL4UDPInfo(port))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return a
+}
+
+// localPort returns the port the kernel assigned to the socket.
+func localPort(c Conn) int {
+	if oc, ok := c.(*offloadConn); ok {
+		c = oc.Conn
+	}
+	return c.(*connUDPIPv4).conn.LocalAddr().(*net.UDPAddr).Port
+}
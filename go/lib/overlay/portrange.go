@@ -0,0 +1,131 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package overlay
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// PortRange is an inclusive range of ports. The zero value is the empty range.
+//
+// A range of end host ports that are not served by the dispatcher must be
+// validated with ValidateDirect. Applications bind their overlay socket
+// directly to a port in such a range, and routers deliver packets for such
+// ports to the port itself instead of the EndhostPort.
+type PortRange struct {
+	Min uint16
+	Max uint16
+}
+
+// ParsePortRange parses a port range of the form "min-max", or a single port.
+// The empty string is the empty range.
+func ParsePortRange(s string) (PortRange, error) {
+	if s == "" {
+		return PortRange{}, nil
+	}
+	parts := strings.Split(s, "-")
+	switch len(parts) {
+	case 1:
+		parts = append(parts, parts[0])
+	case 2:
+	default:
+		return PortRange{}, common.NewBasicError("Invalid port range", nil, "input", s)
+	}
+	min, err := strconv.ParseUint(parts[0], 10, 16)
+	if err != nil {
+		return PortRange{}, common.NewBasicError("Invalid port range minimum", err, "input", s)
+	}
+	max, err := strconv.ParseUint(parts[1], 10, 16)
+	if err != nil {
+		return PortRange{}, common.NewBasicError("Invalid port range maximum", err, "input", s)
+	}
+	r := PortRange{Min: uint16(min), Max: uint16(max)}
+	if err := r.Validate(); err != nil {
+		return PortRange{}, err
+	}
+	return r, nil
+}
+
+// Validate checks that the range is either empty or a valid range.
+func (r PortRange) Validate() error {
+	if r.IsEmpty() {
+		return nil
+	}
+	if r.Min == 0 || r.Min > r.Max {
+		return common.NewBasicError("Invalid port range", nil, "min", r.Min, "max", r.Max)
+	}
+	return nil
+}
+
+// ValidateDirect checks that the range is valid and does not contain the
+// EndhostPort, such that the ports in the range can bypass the dispatcher.
+func (r PortRange) ValidateDirect() error {
+	if err := r.Validate(); err != nil {
+		return err
+	}
+	if r.Contains(EndhostPort) {
+		return common.NewBasicError("Port range contains the end host port", nil,
+			"range", r, "port", EndhostPort)
+	}
+	return nil
+}
+
+// IsEmpty returns whether the range contains no port.
+func (r PortRange) IsEmpty() bool {
+	return r.Min == 0 && r.Max == 0
+}
+
+// Contains returns whether port is in the range.
+func (r PortRange) Contains(port uint16) bool {
+	return !r.IsEmpty() && port >= r.Min && port <= r.Max
+}
+
+func (r PortRange) String() string {
+	if r.IsEmpty() {
+		return ""
+	}
+	if r.Min == r.Max {
+		return fmt.Sprintf("%d", r.Min)
+	}
+	return fmt.Sprintf("%d-%d", r.Min, r.Max)
+}
+
+func (r *PortRange) UnmarshalText(text []byte) error {
+	var err error
+	*r, err = ParsePortRange(string(text))
+	return err
+}
+
+func (r PortRange) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// DirectSCMPID returns an SCMP General ID for applications that are bound to
+// port in a PortRange. The port is encoded in the most significant 16 bits,
+// which lets routers deliver the replies to the port. The remaining bits are
+// taken from id.
+func DirectSCMPID(port uint16, id uint64) uint64 {
+	return uint64(port)<<48 | id&(1<<48-1)
+}
+
+// DirectSCMPPort returns the port encoded in an SCMP General ID by
+// DirectSCMPID.
+func DirectSCMPPort(id uint64) uint16 {
+	return uint16(id >> 48)
+}
@@ -0,0 +1,67 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package overlay
+
+import (
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestParsePortRange(t *testing.T) {
+	Convey("ParsePortRange", t, func() {
+		tests := []struct {
+			input string
+			r     PortRange
+			ok    bool
+			str   string
+		}{
+			{"", PortRange{}, true, ""},
+			{"40000-40100", PortRange{Min: 40000, Max: 40100}, true, "40000-40100"},
+			{"40000-40000", PortRange{Min: 40000, Max: 40000}, true, "40000"},
+			{"40000", PortRange{Min: 40000, Max: 40000}, true, "40000"},
+			{"30000-31000", PortRange{Min: 30000, Max: 31000}, true, "30000-31000"},
+			{"40100-40000", PortRange{}, false, ""},
+			{"0-100", PortRange{}, false, ""},
+			{"40000-70000", PortRange{}, false, ""},
+			{"1-2-3", PortRange{}, false, ""},
+			{"a-b", PortRange{}, false, ""},
+		}
+		for _, test := range tests {
+			r, err := ParsePortRange(test.input)
+			SoMsg(test.input+" err", err == nil, ShouldEqual, test.ok)
+			SoMsg(test.input+" range", r, ShouldResemble, test.r)
+			SoMsg(test.input+" string", r.String(), ShouldEqual, test.str)
+		}
+	})
+	Convey("ValidateDirect rejects ranges with the end host port", t, func() {
+		So(PortRange{}.ValidateDirect(), ShouldBeNil)
+		So(PortRange{Min: 40000, Max: 40100}.ValidateDirect(), ShouldBeNil)
+		So(PortRange{Min: 30000, Max: 31000}.ValidateDirect(), ShouldNotBeNil)
+		So(PortRange{Min: 40100, Max: 40000}.ValidateDirect(), ShouldNotBeNil)
+	})
+	Convey("Contains", t, func() {
+		r := PortRange{Min: 40000, Max: 40100}
+		So(r.Contains(40000), ShouldBeTrue)
+		So(r.Contains(40100), ShouldBeTrue)
+		So(r.Contains(40101), ShouldBeFalse)
+		So(PortRange{}.Contains(0), ShouldBeFalse)
+	})
+	Convey("DirectSCMPID encodes the port", t, func() {
+		id := DirectSCMPID(40001, 0xffffffffffffffff)
+		So(DirectSCMPPort(id), ShouldEqual, 40001)
+		So(id&(1<<48-1), ShouldEqual, uint64(1<<48-1))
+	})
+}
@@ -0,0 +1,655 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package postgres contains a PostgreSQL backend for the PathDB. Contrary to
+// the SQLite backend, the database can be shared by multiple path servers.
+package postgres
+
+import (
+	"bytes"
+	"context"
+	"database/sql"
+	"fmt"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/lib/pq"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/infra/modules/db"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathdb"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+	"github.com/scionproto/scion/go/proto"
+)
+
+type segMeta struct {
+	RowID       int64
+	SegID       common.RawBytes
+	FullID      common.RawBytes
+	LastUpdated time.Time
+	Seg         *seg.PathSegment
+}
+
+var _ pathdb.PathDB = (*Backend)(nil)
+
+type Backend struct {
+	db *sql.DB
+	*executor
+}
+
+// New returns a new PostgreSQL backend using the given connection string. If
+// the database is empty, the schema is set up. If the schema version of the
+// stored database is different from the one in schema.go, an error is returned.
+func New(connection string) (*Backend, error) {
+	db, err := db.NewPostgres(connection, Schema, SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{
+		executor: &executor{
+			db: db,
+		},
+		db: db,
+	}, nil
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+func (b *Backend) SetMaxOpenConns(maxOpenConns int) {
+	b.db.SetMaxOpenConns(maxOpenConns)
+}
+func (b *Backend) SetMaxIdleConns(maxIdleConns int) {
+	b.db.SetMaxIdleConns(maxIdleConns)
+}
+
+func (b *Backend) BeginTransaction(ctx context.Context,
+	opts *sql.TxOptions) (pathdb.Transaction, error) {
+
+	b.Lock()
+	defer b.Unlock()
+	tx, err := b.db.BeginTx(ctx, opts)
+	if err != nil {
+		return nil, common.NewBasicError("Failed to create transaction", err)
+	}
+	return &transaction{
+		executor: &executor{
+			db: tx,
+		},
+		tx: tx,
+	}, nil
+}
+
+var _ (pathdb.Transaction) = (*transaction)(nil)
+
+type transaction struct {
+	*executor
+	tx *sql.Tx
+}
+
+func (tx *transaction) Commit() error {
+	tx.Lock()
+	defer tx.Unlock()
+	return tx.tx.Commit()
+}
+
+func (tx *transaction) Rollback() error {
+	tx.Lock()
+	defer tx.Unlock()
+	return tx.tx.Rollback()
+}
+
+var _ (pathdb.ReadWrite) = (*executor)(nil)
+
+type executor struct {
+	sync.RWMutex
+	db db.Sqler
+}
+
+func (e *executor) Insert(ctx context.Context, segMeta *seg.Meta) (int, error) {
+	return e.InsertWithHPCfgIDs(ctx, segMeta, []*query.HPCfgID{&query.NullHpCfgID})
+}
+
+func (e *executor) InsertWithHPCfgIDs(ctx context.Context, segMeta *seg.Meta,
+	hpCfgIDs []*query.HPCfgID) (int, error) {
+
+	e.Lock()
+	defer e.Unlock()
+	if e.db == nil {
+		return 0, common.NewBasicError("No database open", nil)
+	}
+	pseg := segMeta.Segment
+	// Check if we already have a path segment.
+	segID, err := pseg.ID()
+	if err != nil {
+		return 0, err
+	}
+	newFullId, err := pseg.FullId()
+	if err != nil {
+		return 0, err
+	}
+	newInfo, err := pseg.InfoF()
+	if err != nil {
+		return 0, err
+	}
+	// Other instances might modify the same segment concurrently, the lookup
+	// and the modification are therefore done in the same transaction.
+	var inserted int
+	err = db.DoInTx(ctx, e.db, func(ctx context.Context, tx *sql.Tx) error {
+		meta, err := get(ctx, tx, segID)
+		if err != nil {
+			return err
+		}
+		if meta == nil {
+			inserted, err = insertFull(ctx, tx, segMeta, hpCfgIDs)
+			return err
+		}
+		// Check if the new segment is more recent.
+		curInfo, _ := meta.Seg.InfoF()
+		if !newInfo.Timestamp().After(curInfo.Timestamp()) {
+			return nil
+		}
+		// Update existing path segment.
+		meta.Seg = pseg
+		meta.LastUpdated = time.Now()
+		if err := updateExisting(ctx, tx, meta, segMeta.Type, newFullId, hpCfgIDs); err != nil {
+			return err
+		}
+		inserted = 1
+		return nil
+	})
+	if err != nil {
+		return 0, err
+	}
+	return inserted, nil
+}
+
+func get(ctx context.Context, tx *sql.Tx, segID common.RawBytes) (*segMeta, error) {
+	query := `SELECT RowID, SegID, FullID, LastUpdated, Segment FROM Segments WHERE SegID=$1
+		FOR UPDATE`
+	var meta segMeta
+	var lastUpdated int64
+	var rawSeg common.RawBytes
+	err := tx.QueryRowContext(ctx, query, segID).Scan(
+		&meta.RowID, &meta.SegID, &meta.FullID, &lastUpdated, &rawSeg)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, common.NewBasicError("Failed to lookup segment", err)
+	}
+	meta.LastUpdated = time.Unix(0, lastUpdated)
+	meta.Seg, err = seg.NewSegFromRaw(rawSeg)
+	if err != nil {
+		return nil, err
+	}
+	return &meta, nil
+}
+
+func updateExisting(ctx context.Context, tx *sql.Tx, meta *segMeta,
+	segType proto.PathSegType, newFullId common.RawBytes, hpCfgIDs []*query.HPCfgID) error {
+
+	// Update segment.
+	if err := updateSeg(ctx, tx, meta); err != nil {
+		return err
+	}
+	// Make sure the existing segment is registered as the given type.
+	if err := insertType(ctx, tx, meta.RowID, segType); err != nil {
+		return err
+	}
+	// Check if the existing segment is registered with the given hpCfgIDs.
+	for _, hpCfgID := range hpCfgIDs {
+		if err := insertHPCfgID(ctx, tx, meta.RowID, hpCfgID); err != nil {
+			return err
+		}
+	}
+	// Update the IntfToSeg table
+	if !bytes.Equal(newFullId, meta.FullID) {
+		// Delete all old interfaces and then insert the new ones.
+		// Calculating the actual diffset would be better, but this is way easier to implement.
+		_, err := tx.ExecContext(ctx, `DELETE FROM IntfToSeg WHERE SegRowID=$1`, meta.RowID)
+		if err != nil {
+			return err
+		}
+		if err := insertInterfaces(ctx, tx, meta.Seg.ASEntries, meta.RowID); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func updateSeg(ctx context.Context, tx *sql.Tx, meta *segMeta) error {
+	packedSeg, err := meta.Seg.Pack()
+	if err != nil {
+		return err
+	}
+	info, err := meta.Seg.InfoF()
+	if err != nil {
+		return err
+	}
+	exp := meta.Seg.MaxExpiry().Unix()
+	fullID, err := meta.Seg.FullId()
+	if err != nil {
+		return err
+	}
+	stmtStr := `UPDATE Segments SET FullID=$1, LastUpdated=$2, InfoTs=$3, Segment=$4,
+				MaxExpiry=$5 WHERE RowID=$6`
+	_, err = tx.ExecContext(ctx, stmtStr, fullID, meta.LastUpdated.UnixNano(),
+		info.Timestamp().UnixNano(), packedSeg, exp, meta.RowID)
+	if err != nil {
+		return common.NewBasicError("Failed to update segment", err)
+	}
+	return nil
+}
+
+func insertType(ctx context.Context, tx *sql.Tx, segRowID int64,
+	segType proto.PathSegType) error {
+
+	_, err := tx.ExecContext(ctx, `INSERT INTO SegTypes (SegRowID, Type) VALUES ($1, $2)
+		ON CONFLICT DO NOTHING`, segRowID, segType)
+	if err != nil {
+		return common.NewBasicError("Failed to insert type", err)
+	}
+	return nil
+}
+
+func insertHPCfgID(ctx context.Context, tx *sql.Tx, segRowID int64,
+	hpCfgID *query.HPCfgID) error {
+
+	_, err := tx.ExecContext(ctx,
+		`INSERT INTO HpCfgIds (SegRowID, IsdID, AsID, CfgID) VALUES ($1, $2, $3, $4)
+		ON CONFLICT DO NOTHING`,
+		segRowID, hpCfgID.IA.I, hpCfgID.IA.A, hpCfgID.ID)
+	if err != nil {
+		return common.NewBasicError("Failed to insert hpCfgID", err)
+	}
+	return nil
+}
+
+// insertFull inserts the segment and returns the number of inserted segments.
+// If another instance inserted the same segment in the meantime, the stored
+// segment is only replaced if it has an older timestamp. Otherwise, 0 is
+// returned.
+func insertFull(ctx context.Context, tx *sql.Tx, segMeta *seg.Meta,
+	hpCfgIDs []*query.HPCfgID) (int, error) {
+
+	pseg := segMeta.Segment
+	segID, err := pseg.ID()
+	if err != nil {
+		return 0, err
+	}
+	fullID, err := pseg.FullId()
+	if err != nil {
+		return 0, err
+	}
+	packedSeg, err := pseg.Pack()
+	if err != nil {
+		return 0, err
+	}
+	info, err := pseg.InfoF()
+	if err != nil {
+		return 0, err
+	}
+	st := pseg.FirstIA()
+	end := pseg.LastIA()
+	exp := pseg.MaxExpiry().Unix()
+	// Insert path segment.
+	inst := `INSERT INTO Segments (SegID, FullID, LastUpdated, InfoTs, Segment, MaxExpiry,
+			StartIsdID, StartAsID, EndIsdID, EndAsID)
+			VALUES ($1, $2, $3, $4, $5, $6, $7, $8, $9, $10)
+			ON CONFLICT (SegID) DO UPDATE SET FullID=EXCLUDED.FullID,
+				LastUpdated=EXCLUDED.LastUpdated, InfoTs=EXCLUDED.InfoTs,
+				Segment=EXCLUDED.Segment, MaxExpiry=EXCLUDED.MaxExpiry
+			WHERE Segments.InfoTs < EXCLUDED.InfoTs
+			RETURNING RowID`
+	var segRowID int64
+	err = tx.QueryRowContext(ctx, inst, segID, fullID, time.Now().UnixNano(),
+		info.Timestamp().UnixNano(), packedSeg, exp, st.I, st.A, end.I, end.A).Scan(&segRowID)
+	if err == sql.ErrNoRows {
+		return 0, nil
+	}
+	if err != nil {
+		return 0, common.NewBasicError("Failed to insert path segment", err)
+	}
+	// Drop the interfaces of a replaced segment.
+	_, err = tx.ExecContext(ctx, `DELETE FROM IntfToSeg WHERE SegRowID=$1`, segRowID)
+	if err != nil {
+		return 0, common.NewBasicError("Failed to delete interfaces", err)
+	}
+	// Insert all interfaces.
+	if err = insertInterfaces(ctx, tx, pseg.ASEntries, segRowID); err != nil {
+		return 0, err
+	}
+	// Insert segType information.
+	if err = insertType(ctx, tx, segRowID, segMeta.Type); err != nil {
+		return 0, err
+	}
+	// Insert hpCfgID information.
+	for _, hpCfgID := range hpCfgIDs {
+		if err = insertHPCfgID(ctx, tx, segRowID, hpCfgID); err != nil {
+			return 0, err
+		}
+	}
+	return 1, nil
+}
+
+func insertInterfaces(ctx context.Context, tx *sql.Tx,
+	ases []*seg.ASEntry, segRowID int64) error {
+
+	stmtStr := `INSERT INTO IntfToSeg (IsdID, AsID, IntfID, SegRowID) VALUES ($1, $2, $3, $4)`
+	stmt, err := tx.PrepareContext(ctx, stmtStr)
+	if err != nil {
+		return common.NewBasicError("Failed to prepare insert into IntfToSeg", err)
+	}
+	defer stmt.Close()
+	for _, as := range ases {
+		ia := as.IA()
+		for idx, hop := range as.HopEntries {
+			hof, err := hop.HopField()
+			if err != nil {
+				return common.NewBasicError("Failed to extract hop field", err)
+			}
+			if hof.ConsIngress != 0 {
+				_, err = stmt.ExecContext(ctx, ia.I, ia.A, hof.ConsIngress, segRowID)
+				if err != nil {
+					return common.NewBasicError("Failed to insert Ingress into IntfToSeg", err)
+				}
+			}
+			// Only insert the Egress interface for the first hop entry in an AS entry.
+			if idx == 0 && hof.ConsEgress != 0 {
+				_, err := stmt.ExecContext(ctx, ia.I, ia.A, hof.ConsEgress, segRowID)
+				if err != nil {
+					return common.NewBasicError("Failed to insert Egress into IntfToSeg", err)
+				}
+			}
+		}
+	}
+	return nil
+}
+
+func (e *executor) Delete(ctx context.Context, params *query.Params) (int, error) {
+	q, args := e.buildQuery(params)
+	query := fmt.Sprintf("DELETE FROM Segments WHERE RowID IN(SELECT RowID FROM (%s) AS q)", q)
+	return e.deleteInTx(ctx, func(tx *sql.Tx) (sql.Result, error) {
+		return tx.ExecContext(ctx, query, args...)
+	})
+}
+
+func (e *executor) DeleteExpired(ctx context.Context, now time.Time) (int, error) {
+	return e.deleteInTx(ctx, func(tx *sql.Tx) (sql.Result, error) {
+		delStmt := `DELETE FROM Segments WHERE MaxExpiry < $1`
+		return tx.ExecContext(ctx, delStmt, now.Unix())
+	})
+}
+
+func (e *executor) deleteInTx(ctx context.Context,
+	delFunc func(tx *sql.Tx) (sql.Result, error)) (int, error) {
+
+	e.Lock()
+	defer e.Unlock()
+	if e.db == nil {
+		return 0, common.NewBasicError("No database open", nil)
+	}
+	return db.DeleteInTx(ctx, e.db, delFunc)
+}
+
+func (e *executor) Get(ctx context.Context, params *query.Params) (query.Results, error) {
+	e.RLock()
+	defer e.RUnlock()
+	if e.db == nil {
+		return nil, common.NewBasicError("No database open", nil)
+	}
+	stmt, args := e.buildQuery(params)
+	rows, err := e.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, common.NewBasicError("Error looking up path segment", err, "q", stmt)
+	}
+	defer rows.Close()
+	var res query.Results
+	var prevID int64 = -1
+	var curRes *query.Result
+	for rows.Next() {
+		var segRowID int64
+		var rawSeg common.RawBytes
+		var lastUpdated int64
+		hpCfgID := &query.HPCfgID{IA: addr.IA{}}
+		err = rows.Scan(&segRowID, &rawSeg, &lastUpdated, &hpCfgID.IA.I, &hpCfgID.IA.A, &hpCfgID.ID)
+		if err != nil {
+			return nil, common.NewBasicError("Error reading DB response", err)
+		}
+		// Check if we have a new segment.
+		if segRowID != prevID {
+			if curRes != nil {
+				res = append(res, curRes)
+			}
+			curRes = &query.Result{
+				LastUpdate: time.Unix(0, lastUpdated),
+			}
+			var err error
+			curRes.Seg, err = seg.NewSegFromRaw(rawSeg)
+			if err != nil {
+				return nil, common.NewBasicError("Error unmarshalling segment", err)
+			}
+		}
+		// Append hpCfgID to result
+		curRes.HpCfgIDs = append(curRes.HpCfgIDs, hpCfgID)
+		prevID = segRowID
+	}
+	if curRes != nil {
+		res = append(res, curRes)
+	}
+	return res, nil
+}
+
+// queryArgs collects the arguments of a query.
+type queryArgs []interface{}
+
+// add adds the argument and returns its placeholder.
+func (a *queryArgs) add(arg interface{}) string {
+	*a = append(*a, arg)
+	return fmt.Sprintf("$%d", len(*a))
+}
+
+func (e *executor) buildQuery(params *query.Params) (string, []interface{}) {
+	var args queryArgs
+	query := []string{
+		"SELECT DISTINCT s.RowID, s.Segment, s.LastUpdated," +
+			" h.IsdID, h.AsID, h.CfgID FROM Segments s",
+		"JOIN HpCfgIds h ON h.SegRowID=s.RowID",
+	}
+	// Order by RowID as well, so that the rows of a segment are adjacent.
+	order := " ORDER BY s.LastUpdated, s.RowID"
+	if params == nil {
+		return strings.Join(append(query, order), "\n"), args
+	}
+	joins := []string{}
+	where := []string{}
+	if len(params.SegIDs) > 0 {
+		subQ := make([]string, 0, len(params.SegIDs))
+		for _, segID := range params.SegIDs {
+			subQ = append(subQ, "s.SegID="+args.add(segID))
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(subQ, " OR ")))
+	}
+	if len(params.SegTypes) > 0 {
+		joins = append(joins, "JOIN SegTypes t ON t.SegRowID=s.RowID")
+		subQ := []string{}
+		for _, segType := range params.SegTypes {
+			subQ = append(subQ, "t.Type="+args.add(segType))
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(subQ, " OR ")))
+	}
+	if len(params.HpCfgIDs) > 0 {
+		subQ := []string{}
+		for _, hpCfgID := range params.HpCfgIDs {
+			subQ = append(subQ, fmt.Sprintf("(h.IsdID=%s AND h.AsID=%s AND h.CfgID=%s)",
+				args.add(hpCfgID.IA.I), args.add(hpCfgID.IA.A), args.add(hpCfgID.ID)))
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(subQ, " OR ")))
+	}
+	if len(params.Intfs) > 0 {
+		joins = append(joins, "JOIN IntfToSeg i ON i.SegRowID=s.RowID")
+		subQ := []string{}
+		for _, spec := range params.Intfs {
+			subQ = append(subQ, fmt.Sprintf("(i.IsdID=%s AND i.AsID=%s AND i.IntfID=%s)",
+				args.add(spec.IA.I), args.add(spec.IA.A), args.add(spec.IfID)))
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(subQ, " OR ")))
+	}
+	if len(params.StartsAt) > 0 {
+		subQ := []string{}
+		for _, as := range params.StartsAt {
+			if as.A == 0 {
+				subQ = append(subQ, fmt.Sprintf("(s.StartIsdID=%s)", args.add(as.I)))
+			} else {
+				subQ = append(subQ, fmt.Sprintf("(s.StartIsdID=%s AND s.StartAsID=%s)",
+					args.add(as.I), args.add(as.A)))
+			}
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(subQ, " OR ")))
+	}
+	if len(params.EndsAt) > 0 {
+		subQ := []string{}
+		for _, as := range params.EndsAt {
+			if as.A == 0 {
+				subQ = append(subQ, fmt.Sprintf("(s.EndIsdID=%s)", args.add(as.I)))
+			} else {
+				subQ = append(subQ, fmt.Sprintf("(s.EndIsdID=%s AND s.EndAsID=%s)",
+					args.add(as.I), args.add(as.A)))
+			}
+		}
+		where = append(where, fmt.Sprintf("(%s)", strings.Join(subQ, " OR ")))
+	}
+	if params.MinLastUpdate != nil {
+		where = append(where, fmt.Sprintf("(s.LastUpdated>%s)",
+			args.add(params.MinLastUpdate.UnixNano())))
+	}
+	// Assemble the query.
+	if len(joins) > 0 {
+		query = append(query, strings.Join(joins, "\n"))
+	}
+	if len(where) > 0 {
+		query = append(query, fmt.Sprintf("WHERE %s", strings.Join(where, " AND\n")))
+	}
+	query = append(query, order)
+	return strings.Join(query, "\n"), args
+}
+
+func (e *executor) GetAll(ctx context.Context) (<-chan query.ResultOrErr, error) {
+	e.RLock()
+	defer e.RUnlock()
+	if e.db == nil {
+		return nil, common.NewBasicError("No database open", nil)
+	}
+	stmt, args := e.buildQuery(nil)
+	rows, err := e.db.QueryContext(ctx, stmt, args...)
+	if err != nil {
+		return nil, common.NewBasicError("Error looking up path segment", err, "q", stmt)
+	}
+	resCh := make(chan query.ResultOrErr)
+	go func() {
+		defer log.LogPanicAndExit()
+		defer close(resCh)
+		defer rows.Close()
+		var prevID int64 = -1
+		var curRes *query.Result
+		for rows.Next() {
+			var segRowID int64
+			var rawSeg common.RawBytes
+			var lastUpdated int64
+			hpCfgID := &query.HPCfgID{IA: addr.IA{}}
+			err = rows.Scan(&segRowID, &rawSeg, &lastUpdated,
+				&hpCfgID.IA.I, &hpCfgID.IA.A, &hpCfgID.ID)
+			if err != nil {
+				resCh <- query.ResultOrErr{
+					Err: common.NewBasicError("Error reading DB response", err)}
+				return
+			}
+			// Check if we have a new segment.
+			if segRowID != prevID {
+				if curRes != nil {
+					resCh <- query.ResultOrErr{Result: curRes}
+				}
+				curRes = &query.Result{
+					LastUpdate: time.Unix(0, lastUpdated),
+				}
+				var err error
+				curRes.Seg, err = seg.NewSegFromRaw(rawSeg)
+				if err != nil {
+					resCh <- query.ResultOrErr{
+						Err: common.NewBasicError("Error unmarshalling segment", err)}
+					return
+				}
+			}
+			// Append hpCfgID to result
+			curRes.HpCfgIDs = append(curRes.HpCfgIDs, hpCfgID)
+			prevID = segRowID
+		}
+		if curRes != nil {
+			resCh <- query.ResultOrErr{Result: curRes}
+		}
+	}()
+	return resCh, nil
+}
+
+func (e *executor) InsertNextQuery(ctx context.Context, dst addr.IA,
+	nextQuery time.Time) (bool, error) {
+
+	e.Lock()
+	defer e.Unlock()
+	if e.db == nil {
+		return false, common.NewBasicError("No database open", nil)
+	}
+	// Only update the existing entry if the new NextQuery is larger.
+	query := `
+		INSERT INTO NextQuery (IsdID, AsID, NextQuery) VALUES ($1, $2, $3)
+		ON CONFLICT (IsdID, AsID) DO UPDATE SET NextQuery=EXCLUDED.NextQuery
+		WHERE NextQuery.NextQuery < EXCLUDED.NextQuery
+	`
+	var r sql.Result
+	err := db.DoInTx(ctx, e.db, func(ctx context.Context, tx *sql.Tx) error {
+		var err error
+		r, err = tx.ExecContext(ctx, query, dst.I, dst.A, nextQuery.UnixNano())
+		return err
+	})
+	if err != nil {
+		return false, common.NewBasicError("Failed to execute statement", err)
+	}
+	n, err := r.RowsAffected()
+	return n > 0, err
+}
+
+func (e *executor) GetNextQuery(ctx context.Context, dst addr.IA) (*time.Time, error) {
+	e.RLock()
+	defer e.RUnlock()
+	if e.db == nil {
+		return nil, common.NewBasicError("No database open", nil)
+	}
+	query := "SELECT NextQuery from NextQuery WHERE IsdID = $1 AND AsID = $2"
+	var nanos int64
+	err := e.db.QueryRowContext(ctx, query, dst.I, dst.A).Scan(&nanos)
+	if err == sql.ErrNoRows {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	t := time.Unix(0, nanos)
+	return &t, nil
+}
@@ -0,0 +1,126 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package postgres
+
+import (
+	"context"
+	"flag"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/infra/modules/db/dbtest"
+	"github.com/scionproto/scion/go/lib/pathdb/pathdbtest"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+var (
+	// connection is the connection string of the PostgreSQL database used in
+	// the tests. The tests only modify the testSchema schema of the database.
+	// If it is empty, the tests are skipped.
+	connection = flag.String("postgres", "", "PostgreSQL connection string")
+)
+
+var (
+	ia330    = addr.IA{I: 1, A: 0xff0000000330}
+	ifs1     = []uint64{0, 5, 2, 3, 6, 3, 1, 0}
+	hpCfgIDs = []*query.HPCfgID{
+		&query.NullHpCfgID,
+		{IA: ia330, ID: 0xdeadbeef},
+	}
+	timeout = time.Second
+)
+
+const testSchema = "pathdb_test"
+
+var _ pathdbtest.TestablePathDB = (*TestPathDB)(nil)
+
+type TestPathDB struct {
+	*Backend
+}
+
+func (b *TestPathDB) Prepare(t *testing.T, _ context.Context) {
+	if b.Backend != nil {
+		b.Backend.Close()
+	}
+	b.Backend, _ = setupDB(t)
+}
+
+func TestPathDBSuite(t *testing.T) {
+	skipWithoutPostgres(t)
+	tdb := &TestPathDB{}
+	Convey("PathDBSuite", t, func() {
+		pathdbtest.TestPathDB(t, tdb)
+	})
+}
+
+func TestOpenExisting(t *testing.T) {
+	skipWithoutPostgres(t)
+	Convey("New should not overwrite an existing database if versions match", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		b, conn := setupDB(t)
+		TS := uint32(10)
+		ctx, cancelF := context.WithTimeout(context.Background(), timeout)
+		defer cancelF()
+		pseg1, _ := pathdbtest.AllocPathSegment(t, ctrl, ifs1, TS)
+		pathdbtest.InsertSeg(t, ctx, b, pseg1, hpCfgIDs)
+		b.Close()
+		// Call
+		b, err := New(conn)
+		xtest.FailOnErr(t, err)
+		defer b.Close()
+		// Test
+		// Check that path segment is still there.
+		res, err := b.Get(ctx, nil)
+		xtest.FailOnErr(t, err)
+		SoMsg("Segment still exists", len(res), ShouldEqual, 1)
+	})
+}
+
+func TestOpenNewer(t *testing.T) {
+	skipWithoutPostgres(t)
+	Convey("New should not overwrite an existing database if it's of a newer version", t, func() {
+		b, conn := setupDB(t)
+		// Write a newer version
+		_, err := b.db.Exec("UPDATE SchemaVersion SET Version = $1", SchemaVersion+1)
+		xtest.FailOnErr(t, err)
+		b.Close()
+		// Call
+		b, err = New(conn)
+		// Test
+		SoMsg("Backend nil", b, ShouldBeNil)
+		SoMsg("Err returned", err, ShouldNotBeNil)
+	})
+}
+
+func skipWithoutPostgres(t *testing.T) {
+	if *connection == "" {
+		t.Skip("No PostgreSQL database specified, use -postgres to run the test")
+	}
+}
+
+// setupDB drops the content of the test schema and returns a freshly set up
+// backend, and the connection string that uses the test schema.
+func setupDB(t *testing.T) (*Backend, string) {
+	conn := dbtest.PostgresSchema(t, *connection, testSchema)
+	b, err := New(conn)
+	xtest.FailOnErr(t, err, "Failed to open DB")
+	return b, conn
+}
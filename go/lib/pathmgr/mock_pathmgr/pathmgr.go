@@ -105,6 +105,20 @@ func (mr *MockResolverMockRecorder) QueryFilter(arg0, arg1, arg2, arg3 interface
 	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryFilter", reflect.TypeOf((*MockResolver)(nil).QueryFilter), arg0, arg1, arg2, arg3)
 }
 
+// QueryRank mocks base method
+func (m *MockResolver) QueryRank(arg0 context.Context, arg1, arg2 addr.IA, arg3 *pathpol.Policy) []*spathmeta.AppPath {
+	m.ctrl.T.Helper()
+	ret := m.ctrl.Call(m, "QueryRank", arg0, arg1, arg2, arg3)
+	ret0, _ := ret[0].([]*spathmeta.AppPath)
+	return ret0
+}
+
+// QueryRank indicates an expected call of QueryRank
+func (mr *MockResolverMockRecorder) QueryRank(arg0, arg1, arg2, arg3 interface{}) *gomock.Call {
+	mr.mock.ctrl.T.Helper()
+	return mr.mock.ctrl.RecordCallWithMethodType(mr.mock, "QueryRank", reflect.TypeOf((*MockResolver)(nil).QueryRank), arg0, arg1, arg2, arg3)
+}
+
 // Revoke mocks base method
 func (m *MockResolver) Revoke(arg0 context.Context, arg1 *path_mgmt.SignedRevInfo) {
 	m.ctrl.T.Helper()
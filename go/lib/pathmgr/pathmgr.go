@@ -27,6 +27,10 @@
 // paths, the resolver will atomically change the value within the SyncPaths
 // object. The data can be accessed by calling Load again.
 //
+// Watches subscribe to the paths in SCIOND, which pushes path changes and
+// revocations as they happen. If SCIOND does not support subscriptions, or
+// the subscription ends, the watch polls SCIOND periodically instead.
+//
 // An example of how this package can be used can be found in the associated
 // infra test file.
 package pathmgr
@@ -75,6 +79,10 @@ const (
 	DefaultErrorRefire = time.Second
 	// DefaultQueryTimeout is the time allocated for a query to SCIOND
 	DefaultQueryTimeout = 5 * time.Second
+	// SubscribeTimeout is the maximum time allocated for a path subscription
+	// to SCIOND. SCIOND versions without subscriptions do not reply, so the
+	// timeout is short to leave time for polling.
+	SubscribeTimeout = time.Second
 )
 
 type Querier interface {
@@ -87,8 +95,13 @@ type Resolver interface {
 	// QueryFilter returns a set of paths between src and dst that satisfy
 	// policy. A nil policy will not delete any paths.
 	QueryFilter(ctx context.Context, src, dst addr.IA, policy *pathpol.Policy) spathmeta.AppPathSet
-	// Watch returns an object that periodically polls for paths between src
-	// and dst.
+	// QueryRank returns the paths between src and dst that satisfy policy,
+	// ranked by the ordering of the policy, the best path first. A nil policy
+	// will not delete any paths, and sorts them by their key.
+	QueryRank(ctx context.Context, src, dst addr.IA, policy *pathpol.Policy) []*spathmeta.AppPath
+	// Watch returns an object that keeps the paths between src and dst up to
+	// date. The paths are updated by a SCIOND path subscription, or by
+	// periodically polling SCIOND if subscribing is not possible.
 	//
 	// The function blocks until the first answer from SCIOND is received. The
 	// amount of time is dictated by ctx. Note that the resolver might
@@ -165,15 +178,18 @@ func (r *resolver) QueryFilter(ctx context.Context, src, dst addr.IA,
 	return policy.Act(aps).(spathmeta.AppPathSet)
 }
 
-func (r *resolver) WatchFilter(ctx context.Context, src, dst addr.IA,
-	filter *pathpol.Policy) (*SyncPaths, error) {
+func (r *resolver) QueryRank(ctx context.Context, src, dst addr.IA,
+	policy *pathpol.Policy) []*spathmeta.AppPath {
 
 	aps := r.Query(ctx, src, dst, sciond.PathReqFlags{})
-	if filter != nil {
-		aps = filter.Act(aps).(spathmeta.AppPathSet)
+	if policy == nil {
+		return (*pathpol.Ordering)(nil).Sort(aps)
 	}
-	sp := NewSyncPaths()
-	sp.update(aps)
+	return policy.Rank(aps)
+}
+
+func (r *resolver) WatchFilter(ctx context.Context, src, dst addr.IA,
+	filter *pathpol.Policy) (*SyncPaths, error) {
 
 	query := &queryConfig{
 		querier: Querier(r),
@@ -181,8 +197,20 @@ func (r *resolver) WatchFilter(ctx context.Context, src, dst addr.IA,
 		dst:     dst,
 		filter:  filter,
 	}
+	sp := NewSyncPaths()
+	subCtx, cancelF := subscribeContext(ctx)
+	sub, err := r.sciondConn.SubscribePaths(subCtx, dst, src, numReqPaths)
+	cancelF()
+	if err != nil {
+		// The remaining time of ctx is used for polling.
+		r.logger.Debug("Unable to subscribe to paths, polling instead",
+			"src", src, "dst", dst, "err", err)
+		sp.update(query.Do(ctx, sciond.PathReqFlags{}))
+	} else {
+		sp.update(query.filterPaths(subscriptionPaths(sub)))
+	}
 	pp := NewPollingPolicy(filter != nil, r.timers)
-	w := r.watchFactory.New(sp, query, pp)
+	w := r.watchFactory.New(sp, query, pp, sub)
 	sp.setDestructor(w.Destroy)
 
 	go func() {
@@ -192,6 +220,19 @@ func (r *resolver) WatchFilter(ctx context.Context, src, dst addr.IA,
 	return sp, nil
 }
 
+// subscribeContext returns the context for a path subscription. The
+// subscription is allocated at most half of the remaining time of ctx, and at
+// most SubscribeTimeout.
+func subscribeContext(ctx context.Context) (context.Context, context.CancelFunc) {
+	timeout := SubscribeTimeout
+	if deadline, ok := ctx.Deadline(); ok {
+		if remaining := time.Until(deadline) / 2; remaining < timeout {
+			timeout = remaining
+		}
+	}
+	return context.WithTimeout(ctx, timeout)
+}
+
 func (r *resolver) Watch(ctx context.Context, src, dst addr.IA) (*SyncPaths, error) {
 	return r.WatchFilter(ctx, src, dst, nil)
 }
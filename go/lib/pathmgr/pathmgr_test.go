@@ -24,6 +24,7 @@ import (
 	"github.com/golang/mock/gomock"
 	. "github.com/smartystreets/goconvey/convey"
 
+	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/log"
@@ -120,6 +121,41 @@ func TestQueryFilter(t *testing.T) {
 		aps := pm.QueryFilter(context.Background(), srcIA, dstIA, policy)
 		SoMsg("aps len", len(aps), ShouldEqual, 0)
 	})
+	Convey("Query with constraints, path must be short and long-lived", t, func() {
+		policy := &pathpol.Policy{Constraints: pathpol.Constraints{
+			{Attribute: pathpol.AttrHops, Op: pathpol.OpLessEqual, Value: 2},
+			{Attribute: pathpol.AttrExpiry, Op: pathpol.OpGreaterEqual,
+				Value: int64(time.Hour)},
+		}}
+		aps := pm.QueryFilter(context.Background(), srcIA, dstIA, policy)
+		SoMsg("aps len", len(aps), ShouldEqual, 1)
+		policy.Constraints[0].Value = 1
+		aps = pm.QueryFilter(context.Background(), srcIA, dstIA, policy)
+		SoMsg("aps len", len(aps), ShouldEqual, 0)
+	})
+}
+
+func TestQueryRank(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	g := graph.NewDefaultGraph(ctrl)
+	g.AddLink("1-ff00:0:133", 101902, "1-ff00:0:132", 191002, false)
+	pm := NewPR(t, g, 0, 0)
+	srcIA := xtest.MustParseIA("1-ff00:0:133")
+	dstIA := xtest.MustParseIA("1-ff00:0:131")
+	Convey("Query with ordering, paths are ranked and ties are broken by key", t, func() {
+		policy := &pathpol.Policy{Ordering: &pathpol.Ordering{
+			Keys: []*pathpol.OrderKey{{Attribute: pathpol.AttrHops}},
+		}}
+		paths := pm.QueryRank(context.Background(), srcIA, dstIA, policy)
+		SoMsg("paths len", len(paths), ShouldEqual, 2)
+		SoMsg("order", paths[0].Key(), ShouldBeLessThan, paths[1].Key())
+		Convey("and the limit keeps the best path", func() {
+			policy.Ordering.Limit = 1
+			limited := pm.QueryRank(context.Background(), srcIA, dstIA, policy)
+			SoMsg("paths", limited, ShouldResemble, paths[:1])
+		})
+	})
 }
 
 func TestACLPolicyFilter(t *testing.T) {
@@ -170,6 +206,7 @@ func TestWatchCount(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 		sd := mock_sciond.NewMockConnector(ctrl)
+		expectNoSubscription(sd)
 		pr := New(sd, Timers{}, nil)
 		Convey("the count is initially 0", func() {
 			So(pr.WatchCount(), ShouldEqual, 0)
@@ -200,6 +237,7 @@ func TestWatchPolling(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 		sd := mock_sciond.NewMockConnector(ctrl)
+		expectNoSubscription(sd)
 		gomock.InOrder(
 			sd.EXPECT().Paths(gomock.Any(), dst, src, gomock.Any(), gomock.Any()).Return(
 				buildSDAnswer(), nil,
@@ -232,6 +270,7 @@ func TestWatchFilter(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 		sd := mock_sciond.NewMockConnector(ctrl)
+		expectNoSubscription(sd)
 		gomock.InOrder(
 			sd.EXPECT().Paths(gomock.Any(), dst, src, gomock.Any(), gomock.Any()).Return(
 				buildSDAnswer(
@@ -264,6 +303,90 @@ func TestWatchFilter(t *testing.T) {
 	})
 }
 
+func TestWatchSubscriptionNoReply(t *testing.T) {
+	src := xtest.MustParseIA("1-ff00:0:111")
+	dst := xtest.MustParseIA("1-ff00:0:110")
+	path := "1-ff00:0:111#105 1-ff00:0:130#1002 1-ff00:0:130#1004 1-ff00:0:110#2"
+	Convey("Given a path manager and a SCIOND that does not reply to subscriptions", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		sd := mock_sciond.NewMockConnector(ctrl)
+		sd.EXPECT().SubscribePaths(gomock.Any(), dst, src, gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _, _ addr.IA, _ uint16) (*sciond.PathSubscription, error) {
+				<-ctx.Done()
+				return nil, ctx.Err()
+			},
+		)
+		sd.EXPECT().Paths(gomock.Any(), dst, src, gomock.Any(), gomock.Any()).DoAndReturn(
+			func(ctx context.Context, _, _ addr.IA, _ uint16,
+				_ sciond.PathReqFlags) (*sciond.PathReply, error) {
+
+				if err := ctx.Err(); err != nil {
+					return nil, err
+				}
+				return buildSDAnswer(path), nil
+			},
+		).MinTimes(1)
+		pr := New(sd, Timers{NormalRefire: time.Hour}, nil)
+		Convey("the watch polls SCIOND before ctx expires", func() {
+			ctx, cancelF := context.WithTimeout(context.Background(), getDuration(10))
+			defer cancelF()
+			sp, err := pr.Watch(ctx, src, dst)
+			xtest.FailOnErr(t, err)
+			defer sp.Destroy()
+			So(getPathStrings(sp.Load().APS), ShouldResemble, []string{"[" + path + "]"})
+		})
+	})
+}
+
+func TestWatchSubscription(t *testing.T) {
+	src := xtest.MustParseIA("1-ff00:0:111")
+	dst := xtest.MustParseIA("1-ff00:0:110")
+	path1 := "1-ff00:0:111#105 1-ff00:0:130#1002 1-ff00:0:130#1004 1-ff00:0:110#2"
+	path2 := "1-ff00:0:111#104 1-ff00:0:120#5 1-ff00:0:120#6 1-ff00:0:110#1"
+	Convey("Given a path manager and a SCIOND that supports subscriptions", t, func() {
+		ctrl := gomock.NewController(t)
+		defer ctrl.Finish()
+		sd := mock_sciond.NewMockConnector(ctrl)
+		closed := 0
+		sub := sciond.NewPathSubscription(
+			&sciond.PathSubscribeReply{
+				ErrorCode: sciond.ErrorOk,
+				Entries:   buildSDAnswer(path1).Entries,
+			},
+			func(_ context.Context) error {
+				closed++
+				return nil
+			},
+		)
+		sd.EXPECT().SubscribePaths(gomock.Any(), dst, src, gomock.Any()).Return(sub, nil)
+		pr := New(sd, Timers{ErrorRefire: getDuration(1)}, nil)
+		sp, err := pr.Watch(context.Background(), src, dst)
+		xtest.FailOnErr(t, err)
+		So(getPathStrings(sp.Load().APS), ShouldResemble, []string{"[" + path1 + "]"})
+		Convey("pushed updates change the paths", func() {
+			sub.Push(&sciond.PathUpdate{Added: buildSDAnswer(path2).Entries})
+			time.Sleep(getDuration(2))
+			So(len(sp.Load().APS), ShouldEqual, 2)
+			sub.Push(&sciond.PathUpdate{Removed: buildSDAnswer(path1).Entries})
+			time.Sleep(getDuration(2))
+			So(getPathStrings(sp.Load().APS), ShouldResemble, []string{"[" + path2 + "]"})
+		})
+		Convey("if the subscription ends, SCIOND is polled", func() {
+			sd.EXPECT().Paths(gomock.Any(), dst, src, gomock.Any(), gomock.Any()).Return(
+				buildSDAnswer(path2), nil,
+			).MinTimes(1)
+			sub.End(fmt.Errorf("connection closed"))
+			time.Sleep(getDuration(4))
+			So(getPathStrings(sp.Load().APS), ShouldResemble, []string{"[" + path2 + "]"})
+		})
+		Convey("destroying the watch closes the subscription", func() {
+			sp.Destroy()
+			So(closed, ShouldEqual, 1)
+		})
+	})
+}
+
 func TestRevokeFastRecovery(t *testing.T) {
 	src := xtest.MustParseIA("1-ff00:0:111")
 	dst := xtest.MustParseIA("1-ff00:0:110")
@@ -272,6 +395,7 @@ func TestRevokeFastRecovery(t *testing.T) {
 		defer ctrl.Finish()
 
 		sd := mock_sciond.NewMockConnector(ctrl)
+		expectNoSubscription(sd)
 		// First SCIOND query populates the watch
 		sd.EXPECT().Paths(gomock.Any(), dst, src, gomock.Any(), gomock.Any()).Return(
 			buildSDAnswer(
@@ -309,6 +433,7 @@ func TestRevoke(t *testing.T) {
 		ctrl := gomock.NewController(t)
 		defer ctrl.Finish()
 		sd := mock_sciond.NewMockConnector(ctrl)
+		expectNoSubscription(sd)
 		pr := New(sd, Timers{}, nil)
 		Convey("and a watch that retrieves one path", func() {
 			sd.EXPECT().Paths(gomock.Any(), dst, src, gomock.Any(), gomock.Any()).Return(
@@ -399,6 +524,13 @@ func TestRevoke(t *testing.T) {
 	})
 }
 
+// expectNoSubscription makes path subscriptions fail, such that watches
+// poll SCIOND.
+func expectNoSubscription(sd *mock_sciond.MockConnector) {
+	sd.EXPECT().SubscribePaths(gomock.Any(), gomock.Any(), gomock.Any(),
+		gomock.Any()).Return(nil, fmt.Errorf("not supported")).AnyTimes()
+}
+
 func newTestRev(t *testing.T, rev string) *path_mgmt.SignedRevInfo {
 	pi := mustParsePI(rev)
 	signedRevInfo, err := path_mgmt.NewSignedRevInfo(
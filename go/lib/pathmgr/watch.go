@@ -19,12 +19,14 @@ import (
 	"sync"
 
 	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/pathpol"
 	"github.com/scionproto/scion/go/lib/sciond"
 	"github.com/scionproto/scion/go/lib/spath/spathmeta"
 )
 
-// WatchFactory creates and tracks path watches, i.e., path polling goroutines.
+// WatchFactory creates and tracks path watches, i.e., goroutines that keep
+// paths up to date.
 type WatchFactory struct {
 	timers Timers
 	// mtx protects the map operations below
@@ -39,14 +41,24 @@ func NewWatchFactory(timers Timers) *WatchFactory {
 	}
 }
 
-func (factory *WatchFactory) New(sp *SyncPaths, bq *queryConfig, pp PollingPolicy) *WatchReference {
+// New creates a watch that updates sp. If sub is not nil, the paths are
+// updated from the subscription, and the polling policy is only used once the
+// subscription ends.
+func (factory *WatchFactory) New(sp *SyncPaths, bq *queryConfig, pp PollingPolicy,
+	sub *sciond.PathSubscription) *WatchReference {
+
 	ref := &WatchReference{parent: factory}
-	factory.instances[ref] = &WatchRunner{
+	w := &WatchRunner{
 		sp:      sp,
 		querier: bq,
 		pp:      pp,
+		sub:     sub,
 		closeC:  make(chan struct{}),
 	}
+	if sub != nil {
+		w.subPaths = subscriptionPaths(sub)
+	}
+	factory.instances[ref] = w
 	return ref
 }
 
@@ -106,8 +118,9 @@ func (ref *WatchReference) Destroy() {
 	ref.parent.destroy(ref)
 }
 
-// WatchRunner polls SCIOND in accordance to a polling policy, updating a
-// concurrency-safe store of paths after every poll.
+// WatchRunner applies the updates of a SCIOND path subscription, or polls
+// SCIOND in accordance to a polling policy, updating a concurrency-safe store
+// of paths after every change.
 //
 // Call Stop to shut down the running goroutine. It is safe to call Stop
 // multiple times from different goroutines.
@@ -116,16 +129,40 @@ type WatchRunner struct {
 	sp      *SyncPaths
 	querier *queryConfig
 	closeC  chan struct{}
+	// sub is the path subscription, or nil if the paths are polled.
+	sub *sciond.PathSubscription
+	// subPaths are the unfiltered paths of the subscription.
+	subPaths spathmeta.AppPathSet
 }
 
 func (w *WatchRunner) Run() {
+	var updates <-chan *sciond.PathUpdate
+	pollC := w.pp.PollC()
+	if w.sub != nil {
+		// Polling is only needed once the subscription ends.
+		updates, pollC = w.sub.Updates(), nil
+	}
 	for {
 		w.pp.UpdateState(w.sp.Load().APS)
 		select {
 		case <-w.closeC:
 			w.pp.Destroy()
 			return
-		case flags := <-w.pp.PollC():
+		case update, ok := <-updates:
+			if !ok {
+				updates = nil
+				if err := w.sub.Err(); err != nil {
+					// The subscription was not closed by Stop.
+					log.Info("Path subscription ended, polling SCIOND instead",
+						"src", w.querier.src, "dst", w.querier.dst, "err", err)
+					pollC = w.pp.PollC()
+					w.pp.PollNow()
+				}
+				continue
+			}
+			applyUpdate(w.subPaths, update)
+			w.sp.update(w.querier.filterPaths(w.subPaths.Copy()))
+		case flags := <-pollC:
 			ctx, cancelF := context.WithTimeout(context.Background(), DefaultQueryTimeout)
 			w.sp.update(w.querier.Do(ctx, flags))
 			cancelF()
@@ -138,6 +175,13 @@ func (w *WatchRunner) Stop() {
 	case <-w.closeC:
 	default:
 		close(w.closeC)
+		if w.sub != nil {
+			ctx, cancelF := context.WithTimeout(context.Background(), DefaultQueryTimeout)
+			defer cancelF()
+			if err := w.sub.Close(ctx); err != nil {
+				log.Warn("Unable to close path subscription", "err", err)
+			}
+		}
 	}
 }
 
@@ -151,9 +195,31 @@ type queryConfig struct {
 }
 
 func (bq *queryConfig) Do(ctx context.Context, flags sciond.PathReqFlags) spathmeta.AppPathSet {
-	aps := bq.querier.Query(ctx, bq.src, bq.dst, flags)
+	return bq.filterPaths(bq.querier.Query(ctx, bq.src, bq.dst, flags))
+}
+
+func (bq *queryConfig) filterPaths(aps spathmeta.AppPathSet) spathmeta.AppPathSet {
 	if bq.filter != nil {
 		aps = bq.filter.Act(aps).(spathmeta.AppPathSet)
 	}
 	return aps
 }
+
+// subscriptionPaths returns the initial paths of sub.
+func subscriptionPaths(sub *sciond.PathSubscription) spathmeta.AppPathSet {
+	if sub.Reply.ErrorCode != sciond.ErrorOk {
+		return make(spathmeta.AppPathSet)
+	}
+	return spathmeta.NewAppPathSet(&sciond.PathReply{Entries: sub.Reply.Entries})
+}
+
+// applyUpdate removes and adds the paths of update to aps. Added paths replace
+// the paths with the same key, e.g., if their metadata changed.
+func applyUpdate(aps spathmeta.AppPathSet, update *sciond.PathUpdate) {
+	for i := range update.Removed {
+		delete(aps, (&spathmeta.AppPath{Entry: &update.Removed[i]}).Key())
+	}
+	for i := range update.Added {
+		aps.Add(&update.Added[i])
+	}
+}
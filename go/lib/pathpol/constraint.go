@@ -0,0 +1,297 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathpol
+
+import (
+	"encoding/json"
+	"fmt"
+	"regexp"
+	"strconv"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+)
+
+// Attribute is a numeric property of a path that can be used in constraints
+// and orderings.
+type Attribute string
+
+const (
+	// AttrMTU is the MTU of the path in bytes.
+	AttrMTU Attribute = "mtu"
+	// AttrHops is the number of inter-AS links on the path.
+	AttrHops Attribute = "hops"
+	// AttrExpiry is the remaining lifetime of the path.
+	AttrExpiry Attribute = "expiry"
+	// AttrLatency is the latency of the path, based on the static info of the
+	// path. It is unknown for paths without static info.
+	AttrLatency Attribute = "latency"
+	// AttrBandwidth is the bandwidth of the path in kbit/s, based on the
+	// static info of the path. It is unknown for paths without static info.
+	AttrBandwidth Attribute = "bandwidth"
+	// AttrISDCrossings is the number of inter-ISD links on the path.
+	AttrISDCrossings Attribute = "isd_crossings"
+)
+
+// attrKind is the type of the values of an attribute.
+type attrKind int
+
+const (
+	// kindCount values are non-negative integers.
+	kindCount attrKind = iota
+	// kindDuration values are durations, e.g., "30m".
+	kindDuration
+)
+
+type attrSpec struct {
+	kind attrKind
+	// value returns the value of the attribute for the path. The second return
+	// value is false if the value is unknown.
+	value func(path *spathmeta.AppPath, now time.Time) (int64, bool)
+}
+
+var attributes = map[Attribute]attrSpec{
+	AttrMTU: {
+		kind: kindCount,
+		value: func(path *spathmeta.AppPath, _ time.Time) (int64, bool) {
+			return int64(path.Entry.Path.Mtu), true
+		},
+	},
+	AttrHops: {
+		kind: kindCount,
+		value: func(path *spathmeta.AppPath, _ time.Time) (int64, bool) {
+			return int64(len(path.Entry.Path.Interfaces) / 2), true
+		},
+	},
+	AttrExpiry: {
+		kind: kindDuration,
+		value: func(path *spathmeta.AppPath, now time.Time) (int64, bool) {
+			return int64(path.Entry.Path.Expiry().Sub(now)), true
+		},
+	},
+	AttrLatency: {
+		kind: kindDuration,
+		value: func(path *spathmeta.AppPath, _ time.Time) (int64, bool) {
+			info := path.Entry.Path.StaticInfo
+			if info == nil || info.Latency == 0 {
+				return 0, false
+			}
+			return int64(info.LatencyDuration()), true
+		},
+	},
+	AttrBandwidth: {
+		kind: kindCount,
+		value: func(path *spathmeta.AppPath, _ time.Time) (int64, bool) {
+			info := path.Entry.Path.StaticInfo
+			if info == nil || info.Bandwidth == 0 {
+				return 0, false
+			}
+			return int64(info.Bandwidth), true
+		},
+	},
+	AttrISDCrossings: {
+		kind: kindCount,
+		value: func(path *spathmeta.AppPath, _ time.Time) (int64, bool) {
+			ifaces := path.Entry.Path.Interfaces
+			var crossings int64
+			// Interfaces come in pairs, one pair per inter-AS link.
+			for i := 0; i+1 < len(ifaces); i += 2 {
+				if ifaces[i].ISD_AS().I != ifaces[i+1].ISD_AS().I {
+					crossings++
+				}
+			}
+			return crossings, true
+		},
+	},
+}
+
+func (a Attribute) spec() (attrSpec, error) {
+	spec, ok := attributes[a]
+	if !ok {
+		return attrSpec{}, common.NewBasicError("Unknown attribute", nil, "attribute", a)
+	}
+	return spec, nil
+}
+
+func (a Attribute) parseValue(str string) (int64, error) {
+	spec, err := a.spec()
+	if err != nil {
+		return 0, err
+	}
+	switch spec.kind {
+	case kindDuration:
+		d, err := time.ParseDuration(str)
+		if err != nil {
+			return 0, common.NewBasicError("Failed to parse duration", err,
+				"attribute", a, "value", str)
+		}
+		return int64(d), nil
+	default:
+		v, err := strconv.ParseUint(str, 10, 63)
+		if err != nil {
+			return 0, common.NewBasicError("Failed to parse number", err,
+				"attribute", a, "value", str)
+		}
+		return int64(v), nil
+	}
+}
+
+func (a Attribute) formatValue(v int64) string {
+	if spec, _ := a.spec(); spec.kind == kindDuration {
+		return time.Duration(v).String()
+	}
+	return strconv.FormatInt(v, 10)
+}
+
+// Operator is a comparison operator of a constraint.
+type Operator string
+
+const (
+	OpLess         Operator = "<"
+	OpLessEqual    Operator = "<="
+	OpEqual        Operator = "="
+	OpNotEqual     Operator = "!="
+	OpGreaterEqual Operator = ">="
+	OpGreater      Operator = ">"
+)
+
+func (o Operator) compare(a, b int64) bool {
+	switch o {
+	case OpLess:
+		return a < b
+	case OpLessEqual:
+		return a <= b
+	case OpEqual:
+		return a == b
+	case OpNotEqual:
+		return a != b
+	case OpGreaterEqual:
+		return a >= b
+	case OpGreater:
+		return a > b
+	}
+	return false
+}
+
+var constraintRe = regexp.MustCompile(`^\s*([a-z_]+)\s*(<=|>=|!=|=|<|>)\s*(\S+)\s*$`)
+
+// Constraint is a typed predicate on a path attribute, e.g., "mtu >= 1400",
+// "hops <= 6" or "expiry >= 30m". Paths for which the attribute is unknown
+// do not satisfy the constraint.
+type Constraint struct {
+	Attribute Attribute
+	Op        Operator
+	// Value is the bound of the constraint. Durations are stored in
+	// nanoseconds.
+	Value int64
+}
+
+// ConstraintFromString parses a constraint of the form "attribute op value".
+func ConstraintFromString(str string) (*Constraint, error) {
+	m := constraintRe.FindStringSubmatch(str)
+	if m == nil {
+		return nil, common.NewBasicError("Failed to parse constraint", nil, "value", str)
+	}
+	c := &Constraint{Attribute: Attribute(m[1]), Op: Operator(m[2])}
+	var err error
+	if c.Value, err = c.Attribute.parseValue(m[3]); err != nil {
+		return nil, err
+	}
+	return c, nil
+}
+
+// Match returns true if the path satisfies the constraint at time now.
+func (c *Constraint) Match(path *spathmeta.AppPath, now time.Time) bool {
+	spec, err := c.Attribute.spec()
+	if err != nil {
+		return false
+	}
+	v, ok := spec.value(path, now)
+	if !ok {
+		return false
+	}
+	return c.Op.compare(v, c.Value)
+}
+
+func (c *Constraint) String() string {
+	return fmt.Sprintf("%s %s %s", c.Attribute, c.Op, c.Attribute.formatValue(c.Value))
+}
+
+func (c *Constraint) MarshalJSON() ([]byte, error) {
+	return json.Marshal(c.String())
+}
+
+func (c *Constraint) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	nc, err := ConstraintFromString(str)
+	if err != nil {
+		return err
+	}
+	*c = *nc
+	return nil
+}
+
+func (c *Constraint) MarshalYAML() (interface{}, error) {
+	return c.String(), nil
+}
+
+func (c *Constraint) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	nc, err := ConstraintFromString(str)
+	if err != nil {
+		return err
+	}
+	*c = *nc
+	return nil
+}
+
+// Constraints is a list of constraints that all have to be satisfied.
+type Constraints []*Constraint
+
+// Eval returns the set of paths that satisfy all constraints.
+func (cs Constraints) Eval(inputSet spathmeta.AppPathSet) spathmeta.AppPathSet {
+	return cs.eval(inputSet, time.Now())
+}
+
+func (cs Constraints) eval(inputSet spathmeta.AppPathSet,
+	now time.Time) spathmeta.AppPathSet {
+
+	if len(cs) == 0 {
+		return inputSet
+	}
+	resultSet := make(spathmeta.AppPathSet)
+	for key, path := range inputSet {
+		if cs.match(path, now) {
+			resultSet[key] = path
+		}
+	}
+	return resultSet
+}
+
+func (cs Constraints) match(path *spathmeta.AppPath, now time.Time) bool {
+	for _, c := range cs {
+		if !c.Match(path, now) {
+			return false
+		}
+	}
+	return true
+}
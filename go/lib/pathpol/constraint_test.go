@@ -0,0 +1,173 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathpol
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+	"github.com/scionproto/scion/go/lib/util"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+var testNow = time.Now()
+
+type testPathMeta struct {
+	Mtu     uint16
+	Expiry  time.Duration
+	Latency time.Duration
+	Ifaces  []string
+}
+
+// newTestPathSet creates a path set with a path for each meta.
+func newTestPathSet(t *testing.T, metas ...testPathMeta) spathmeta.AppPathSet {
+	t.Helper()
+	aps := make(spathmeta.AppPathSet)
+	for _, m := range metas {
+		entry := &sciond.PathReplyEntry{
+			Path: &sciond.FwdPathMeta{
+				Mtu:     m.Mtu,
+				ExpTime: util.TimeToSecs(testNow.Add(m.Expiry)),
+			},
+		}
+		if m.Latency != 0 {
+			entry.Path.StaticInfo = &sciond.PathStaticInfo{
+				Latency: uint32(m.Latency / time.Microsecond),
+			}
+		}
+		for _, str := range m.Ifaces {
+			iface, err := sciond.NewPathInterface(str)
+			xtest.FailOnErr(t, err)
+			entry.Path.Interfaces = append(entry.Path.Interfaces, iface)
+		}
+		aps.Add(entry)
+	}
+	return aps
+}
+
+func TestConstraintFromString(t *testing.T) {
+	testCases := []struct {
+		Name       string
+		String     string
+		Constraint *Constraint
+		Error      bool
+	}{
+		{
+			Name:       "mtu",
+			String:     "mtu >= 1400",
+			Constraint: &Constraint{Attribute: AttrMTU, Op: OpGreaterEqual, Value: 1400},
+		},
+		{
+			Name:       "hops without spaces",
+			String:     "hops<=6",
+			Constraint: &Constraint{Attribute: AttrHops, Op: OpLessEqual, Value: 6},
+		},
+		{
+			Name:   "expiry",
+			String: "expiry >= 30m",
+			Constraint: &Constraint{Attribute: AttrExpiry, Op: OpGreaterEqual,
+				Value: int64(30 * time.Minute)},
+		},
+		{
+			Name:   "latency",
+			String: "latency < 100ms",
+			Constraint: &Constraint{Attribute: AttrLatency, Op: OpLess,
+				Value: int64(100 * time.Millisecond)},
+		},
+		{
+			Name:   "unknown attribute",
+			String: "cost < 5",
+			Error:  true,
+		},
+		{
+			Name:   "missing value",
+			String: "mtu >=",
+			Error:  true,
+		},
+		{
+			Name:   "duration for count",
+			String: "hops < 5s",
+			Error:  true,
+		},
+		{
+			Name:   "negative count",
+			String: "mtu > -1",
+			Error:  true,
+		},
+	}
+	Convey("TestConstraintFromString", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				c, err := ConstraintFromString(tc.String)
+				xtest.SoMsgError("err", err, tc.Error)
+				SoMsg("constraint", c, ShouldResemble, tc.Constraint)
+			})
+		}
+	})
+}
+
+func TestConstraintString(t *testing.T) {
+	Convey("String output can be parsed again", t, func() {
+		for _, str := range []string{"mtu >= 1400", "expiry > 30m0s", "isd_crossings = 0"} {
+			c, err := ConstraintFromString(str)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("string", c.String(), ShouldEqual, str)
+		}
+	})
+}
+
+func TestConstraintsEval(t *testing.T) {
+	aps := newTestPathSet(t,
+		testPathMeta{Mtu: 1472, Expiry: time.Hour, Latency: 20 * time.Millisecond,
+			Ifaces: []string{"1-ff00:0:110#1", "1-ff00:0:111#2"}},
+		testPathMeta{Mtu: 1280, Expiry: 10 * time.Minute,
+			Ifaces: []string{"1-ff00:0:110#2", "2-ff00:0:210#1", "2-ff00:0:210#3",
+				"2-ff00:0:211#1"}},
+	)
+	testCases := []struct {
+		Name        string
+		Constraints []string
+		ExpPathNum  int
+	}{
+		{Name: "no constraints", ExpPathNum: 2},
+		{Name: "mtu", Constraints: []string{"mtu >= 1400"}, ExpPathNum: 1},
+		{Name: "hops", Constraints: []string{"hops <= 1"}, ExpPathNum: 1},
+		{Name: "expiry", Constraints: []string{"expiry >= 30m"}, ExpPathNum: 1},
+		{Name: "unknown latency", Constraints: []string{"latency < 1s"}, ExpPathNum: 1},
+		{Name: "isd crossings", Constraints: []string{"isd_crossings = 1"}, ExpPathNum: 1},
+		{
+			Name:        "all constraints must match",
+			Constraints: []string{"mtu >= 1400", "isd_crossings = 1"},
+			ExpPathNum:  0,
+		},
+	}
+	Convey("TestConstraintsEval", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				var cs Constraints
+				for _, str := range tc.Constraints {
+					c, err := ConstraintFromString(str)
+					xtest.FailOnErr(t, err)
+					cs = append(cs, c)
+				}
+				SoMsg("paths", len(cs.eval(aps, testNow)), ShouldEqual, tc.ExpPathNum)
+			})
+		}
+	})
+}
@@ -0,0 +1,181 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathpol
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+)
+
+const (
+	ascSymbol  = "asc"
+	descSymbol = "desc"
+)
+
+// OrderKey sorts paths by an attribute, e.g., "isd_crossings asc" prefers
+// paths with the fewest ISD crossings. The direction defaults to ascending.
+// Paths for which the attribute is unknown are sorted last.
+type OrderKey struct {
+	Attribute  Attribute
+	Descending bool
+}
+
+// OrderKeyFromString parses an order key of the form "attribute [asc|desc]".
+func OrderKeyFromString(str string) (*OrderKey, error) {
+	parts := strings.Fields(str)
+	if len(parts) == 0 || len(parts) > 2 {
+		return nil, common.NewBasicError("Failed to parse order key", nil, "value", str)
+	}
+	k := &OrderKey{Attribute: Attribute(parts[0])}
+	if _, err := k.Attribute.spec(); err != nil {
+		return nil, err
+	}
+	if len(parts) == 2 {
+		switch parts[1] {
+		case ascSymbol:
+		case descSymbol:
+			k.Descending = true
+		default:
+			return nil, common.NewBasicError("Bad order direction", nil, "value", str)
+		}
+	}
+	return k, nil
+}
+
+func (k *OrderKey) String() string {
+	dir := ascSymbol
+	if k.Descending {
+		dir = descSymbol
+	}
+	return fmt.Sprintf("%s %s", k.Attribute, dir)
+}
+
+func (k *OrderKey) MarshalJSON() ([]byte, error) {
+	return json.Marshal(k.String())
+}
+
+func (k *OrderKey) UnmarshalJSON(b []byte) error {
+	var str string
+	if err := json.Unmarshal(b, &str); err != nil {
+		return err
+	}
+	nk, err := OrderKeyFromString(str)
+	if err != nil {
+		return err
+	}
+	*k = *nk
+	return nil
+}
+
+func (k *OrderKey) MarshalYAML() (interface{}, error) {
+	return k.String(), nil
+}
+
+func (k *OrderKey) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var str string
+	if err := unmarshal(&str); err != nil {
+		return err
+	}
+	nk, err := OrderKeyFromString(str)
+	if err != nil {
+		return err
+	}
+	*k = *nk
+	return nil
+}
+
+// Ordering ranks paths. Later keys break ties of earlier keys, remaining ties
+// are broken by the path key, such that the ranking is deterministic. If Limit
+// is set, only the Limit best paths are kept.
+type Ordering struct {
+	Keys  []*OrderKey `json:",omitempty" yaml:",omitempty"`
+	Limit int         `json:",omitempty" yaml:",omitempty"`
+}
+
+// Sort returns the paths of the set in ranked order, the best path first.
+// Limit is not applied.
+func (o *Ordering) Sort(inputSet spathmeta.AppPathSet) []*spathmeta.AppPath {
+	return o.sort(inputSet, time.Now())
+}
+
+func (o *Ordering) sort(inputSet spathmeta.AppPathSet, now time.Time) []*spathmeta.AppPath {
+	type rankedPath struct {
+		path   *spathmeta.AppPath
+		key    string
+		values []int64
+		known  []bool
+	}
+	var keys []*OrderKey
+	if o != nil {
+		keys = o.Keys
+	}
+	ranked := make([]rankedPath, 0, len(inputSet))
+	for key, path := range inputSet {
+		r := rankedPath{
+			path:   path,
+			key:    string(key),
+			values: make([]int64, len(keys)),
+			known:  make([]bool, len(keys)),
+		}
+		for i, k := range keys {
+			// The attributes are validated when parsing the key.
+			if spec, err := k.Attribute.spec(); err == nil {
+				r.values[i], r.known[i] = spec.value(path, now)
+			}
+		}
+		ranked = append(ranked, r)
+	}
+	sort.Slice(ranked, func(i, j int) bool {
+		a, b := ranked[i], ranked[j]
+		for k := range keys {
+			if a.known[k] != b.known[k] {
+				return a.known[k]
+			}
+			if a.values[k] == b.values[k] {
+				continue
+			}
+			if keys[k].Descending {
+				return a.values[k] > b.values[k]
+			}
+			return a.values[k] < b.values[k]
+		}
+		return a.key < b.key
+	})
+	paths := make([]*spathmeta.AppPath, 0, len(ranked))
+	for _, r := range ranked {
+		paths = append(paths, r.path)
+	}
+	return paths
+}
+
+// Eval returns the set of the Limit best paths. If Limit is not set, the input
+// set is returned, as a set does not retain the ranking. Use Sort to obtain the
+// ranked paths.
+func (o *Ordering) Eval(inputSet spathmeta.AppPathSet) spathmeta.AppPathSet {
+	if o == nil || o.Limit <= 0 || len(inputSet) <= o.Limit {
+		return inputSet
+	}
+	resultSet := make(spathmeta.AppPathSet)
+	for _, path := range o.Sort(inputSet)[:o.Limit] {
+		resultSet[path.Key()] = path
+	}
+	return resultSet
+}
@@ -0,0 +1,125 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package pathpol
+
+import (
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+func TestOrderKeyFromString(t *testing.T) {
+	testCases := []struct {
+		Name     string
+		String   string
+		OrderKey *OrderKey
+		Error    bool
+	}{
+		{
+			Name:     "default direction",
+			String:   "hops",
+			OrderKey: &OrderKey{Attribute: AttrHops},
+		},
+		{
+			Name:     "descending",
+			String:   "mtu desc",
+			OrderKey: &OrderKey{Attribute: AttrMTU, Descending: true},
+		},
+		{
+			Name:   "unknown attribute",
+			String: "cost asc",
+			Error:  true,
+		},
+		{
+			Name:   "bad direction",
+			String: "mtu up",
+			Error:  true,
+		},
+	}
+	Convey("TestOrderKeyFromString", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				k, err := OrderKeyFromString(tc.String)
+				xtest.SoMsgError("err", err, tc.Error)
+				SoMsg("key", k, ShouldResemble, tc.OrderKey)
+			})
+		}
+	})
+}
+
+func TestOrderingSort(t *testing.T) {
+	short := testPathMeta{Mtu: 1280, Expiry: time.Hour, Latency: 50 * time.Millisecond,
+		Ifaces: []string{"1-ff00:0:110#1", "2-ff00:0:210#1"}}
+	long := testPathMeta{Mtu: 1472, Expiry: time.Hour, Latency: 20 * time.Millisecond,
+		Ifaces: []string{"1-ff00:0:110#2", "1-ff00:0:111#1", "1-ff00:0:111#2",
+			"2-ff00:0:210#2"}}
+	noInfo := testPathMeta{Mtu: 1500, Expiry: time.Hour,
+		Ifaces: []string{"1-ff00:0:110#3", "1-ff00:0:112#1", "1-ff00:0:112#2",
+			"1-ff00:0:113#1", "1-ff00:0:113#2", "2-ff00:0:210#3"}}
+	aps := newTestPathSet(t, short, long, noInfo)
+	key := func(meta testPathMeta) spathmeta.PathKey {
+		return newTestPathSet(t, meta).GetAppPath("").Key()
+	}
+	testCases := []struct {
+		Name     string
+		Keys     []*OrderKey
+		Expected []spathmeta.PathKey
+	}{
+		{
+			Name:     "fewest hops",
+			Keys:     []*OrderKey{{Attribute: AttrHops}},
+			Expected: []spathmeta.PathKey{key(short), key(long), key(noInfo)},
+		},
+		{
+			Name:     "largest mtu",
+			Keys:     []*OrderKey{{Attribute: AttrMTU, Descending: true}},
+			Expected: []spathmeta.PathKey{key(noInfo), key(long), key(short)},
+		},
+		{
+			Name:     "unknown latency last",
+			Keys:     []*OrderKey{{Attribute: AttrLatency, Descending: true}},
+			Expected: []spathmeta.PathKey{key(short), key(long), key(noInfo)},
+		},
+		{
+			Name:     "ties are broken by later keys",
+			Keys:     []*OrderKey{{Attribute: AttrISDCrossings}, {Attribute: AttrLatency}},
+			Expected: []spathmeta.PathKey{key(long), key(short), key(noInfo)},
+		},
+	}
+	Convey("TestOrderingSort", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				o := &Ordering{Keys: tc.Keys}
+				paths := o.sort(aps, testNow)
+				var keys []spathmeta.PathKey
+				for _, path := range paths {
+					keys = append(keys, path.Key())
+				}
+				SoMsg("order", keys, ShouldResemble, tc.Expected)
+			})
+		}
+	})
+	Convey("Eval keeps the best paths", t, func() {
+		o := &Ordering{Keys: []*OrderKey{{Attribute: AttrHops}}, Limit: 2}
+		res := o.Eval(aps)
+		SoMsg("len", len(res), ShouldEqual, 2)
+		SoMsg("short", res, ShouldContainKey, key(short))
+		SoMsg("long", res, ShouldContainKey, key(long))
+	})
+}
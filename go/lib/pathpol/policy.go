@@ -13,9 +13,10 @@
 // limitations under the License.
 
 // Package pathpol implements path policies, documentation in doc/PathPolicy.md
-// Currently implemented: ACL, Sequence, Extends and Options.
+// Currently implemented: ACL, Sequence, Constraints, Ordering, Extends and Options.
 //
-// A policy has an Act() method that takes an AppPathSet and returns a filtered AppPathSet
+// A policy has an Act() method that takes an AppPathSet and returns a filtered AppPathSet.
+// Policies can be parsed from JSON and YAML.
 package pathpol
 
 import (
@@ -32,6 +33,31 @@ type ExtPolicy struct {
 	*Policy
 }
 
+// extPolicyYAML is the YAML representation of ExtPolicy. The YAML library does
+// not support inlining embedded pointers.
+type extPolicyYAML struct {
+	Extends []string `yaml:",omitempty"`
+	Policy  `yaml:",inline"`
+}
+
+func (p *ExtPolicy) MarshalYAML() (interface{}, error) {
+	y := extPolicyYAML{Extends: p.Extends}
+	if p.Policy != nil {
+		y.Policy = *p.Policy
+	}
+	return y, nil
+}
+
+func (p *ExtPolicy) UnmarshalYAML(unmarshal func(interface{}) error) error {
+	var y extPolicyYAML
+	if err := unmarshal(&y); err != nil {
+		return err
+	}
+	p.Extends = y.Extends
+	p.Policy = &y.Policy
+	return nil
+}
+
 // PolicyMap is a container for Policies, keyed by their unique name. PolicyMap
 // can be used to marshal Policies to JSON. Unmarshaling back to PolicyMap is
 // guaranteed to yield an object that is identical to the initial one.
@@ -39,10 +65,12 @@ type PolicyMap map[string]*ExtPolicy
 
 // Policy is a compiled path policy object, all extended policies have been merged.
 type Policy struct {
-	Name     string    `json:"-"`
-	ACL      *ACL      `json:",omitempty"`
-	Sequence *Sequence `json:",omitempty"`
-	Options  []Option  `json:",omitempty"`
+	Name        string      `json:"-" yaml:"-"`
+	ACL         *ACL        `json:",omitempty" yaml:",omitempty"`
+	Sequence    *Sequence   `json:",omitempty" yaml:",omitempty"`
+	Constraints Constraints `json:",omitempty" yaml:",omitempty"`
+	Ordering    *Ordering   `json:",omitempty" yaml:",omitempty"`
+	Options     []Option    `json:",omitempty" yaml:",omitempty"`
 }
 
 // NewPolicy creates a Policy and sorts its Options
@@ -64,11 +92,21 @@ func (p *Policy) Act(values interface{}) interface{} {
 	if p.Sequence != nil {
 		resultSet = p.Sequence.Eval(resultSet)
 	}
+	// Filter on Constraints
+	resultSet = p.Constraints.Eval(resultSet)
 	// Filter on sub policies
 	if len(p.Options) > 0 {
 		resultSet = p.evalOptions(resultSet)
 	}
-	return resultSet
+	// Keep the best paths according to the Ordering
+	return p.Ordering.Eval(resultSet)
+}
+
+// Rank filters the path set according to the policy and returns the remaining
+// paths ranked by the Ordering, the best path first. Without an Ordering, the
+// paths are sorted by their key.
+func (p *Policy) Rank(inputSet spathmeta.AppPathSet) []*spathmeta.AppPath {
+	return p.Ordering.Sort(p.Act(inputSet).(spathmeta.AppPathSet))
 }
 
 // PolicyFromExtPolicy creates a Policy from an extending Policy and the extended policies
@@ -116,6 +154,14 @@ func (p *Policy) applyExtended(extends []string, exPolicies []*ExtPolicy) error
 		if p.Sequence == nil {
 			p.Sequence = policy.Sequence
 		}
+		// Replace Constraints
+		if len(p.Constraints) == 0 {
+			p.Constraints = policy.Constraints
+		}
+		// Replace Ordering
+		if p.Ordering == nil {
+			p.Ordering = policy.Ordering
+		}
 	}
 	return nil
 }
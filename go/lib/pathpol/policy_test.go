@@ -16,10 +16,13 @@ package pathpol
 
 import (
 	"context"
+	"encoding/json"
 	"testing"
+	"time"
 
 	"github.com/golang/mock/gomock"
 	. "github.com/smartystreets/goconvey/convey"
+	"gopkg.in/yaml.v2"
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
@@ -868,6 +871,88 @@ func TestSequenceConstructor(t *testing.T) {
 	})
 }
 
+func TestConstraintsAndOrdering(t *testing.T) {
+	fast := testPathMeta{Mtu: 1472, Expiry: time.Hour, Latency: 20 * time.Millisecond,
+		Ifaces: []string{"1-ff00:0:110#1", "1-ff00:0:111#1", "1-ff00:0:111#2",
+			"1-ff00:0:112#1"}}
+	slow := testPathMeta{Mtu: 1472, Expiry: time.Hour, Latency: 80 * time.Millisecond,
+		Ifaces: []string{"1-ff00:0:110#2", "1-ff00:0:112#2"}}
+	smallMTU := testPathMeta{Mtu: 1280, Expiry: time.Hour, Latency: 10 * time.Millisecond,
+		Ifaces: []string{"1-ff00:0:110#3", "1-ff00:0:112#3"}}
+	aps := newTestPathSet(t, fast, slow, smallMTU)
+	policy := &Policy{
+		Constraints: Constraints{
+			{Attribute: AttrMTU, Op: OpGreaterEqual, Value: 1400},
+		},
+		Ordering: &Ordering{
+			Keys:  []*OrderKey{{Attribute: AttrLatency}},
+			Limit: 1,
+		},
+	}
+	Convey("Act applies constraints and limit", t, func() {
+		res := policy.Act(aps).(spathmeta.AppPathSet)
+		SoMsg("len", len(res), ShouldEqual, 1)
+		SoMsg("path", res, ShouldContainKey, newTestPathSet(t, fast).GetAppPath("").Key())
+	})
+	Convey("Rank returns the ranked paths", t, func() {
+		policy := &Policy{Ordering: &Ordering{Keys: []*OrderKey{{Attribute: AttrLatency}}}}
+		paths := policy.Rank(aps)
+		SoMsg("len", len(paths), ShouldEqual, 3)
+		SoMsg("first", paths[0].Entry.Path.Mtu, ShouldEqual, 1280)
+	})
+}
+
+func TestPolicyMapJSONYAML(t *testing.T) {
+	yamlPolicies := `
+pol_1:
+  extends:
+  - pol_2
+  acl:
+  - "- 1-ff00:0:133#0"
+  - "+"
+  constraints:
+  - "mtu >= 1400"
+  - "hops <= 6"
+  - "expiry >= 30m"
+  ordering:
+    keys:
+    - "isd_crossings asc"
+    - "latency"
+    limit: 3
+pol_2:
+  sequence: "1-ff00:0:133#0 0*"
+`
+	jsonPolicies := `{
+  "pol_1": {
+    "Extends": ["pol_2"],
+    "ACL": ["- 1-ff00:0:133#0", "+"],
+    "Constraints": ["mtu >= 1400", "hops <= 6", "expiry >= 30m"],
+    "Ordering": {"Keys": ["isd_crossings asc", "latency asc"], "Limit": 3}
+  },
+  "pol_2": {"Sequence": "1-ff00:0:133#0 0*"}
+}`
+	Convey("YAML and JSON policies are equivalent", t, func() {
+		var fromYAML, fromJSON PolicyMap
+		SoMsg("yaml err", yaml.Unmarshal([]byte(yamlPolicies), &fromYAML), ShouldBeNil)
+		SoMsg("json err", json.Unmarshal([]byte(jsonPolicies), &fromJSON), ShouldBeNil)
+		rawYAML, err := json.Marshal(fromYAML)
+		SoMsg("err", err, ShouldBeNil)
+		rawJSON, err := json.Marshal(fromJSON)
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("policies", string(rawYAML), ShouldEqual, string(rawJSON))
+		SoMsg("constraint", fromYAML["pol_1"].Constraints[2].Value, ShouldEqual,
+			int64(30*time.Minute))
+	})
+	Convey("YAML round trip", t, func() {
+		var policies, roundTrip PolicyMap
+		SoMsg("err", yaml.Unmarshal([]byte(yamlPolicies), &policies), ShouldBeNil)
+		raw, err := yaml.Marshal(policies)
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("err", yaml.Unmarshal(raw, &roundTrip), ShouldBeNil)
+		SoMsg("policies", roundTrip, ShouldResemble, policies)
+	})
+}
+
 func newSequence(t *testing.T, str string) *Sequence {
 	seq, err := NewSequence(str)
 	xtest.FailOnErr(t, err)
@@ -23,18 +23,21 @@ import (
 	"github.com/scionproto/scion/go/lib/infra/modules/db"
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/pathdb"
+	postgrespathdb "github.com/scionproto/scion/go/lib/pathdb/postgres"
 	sqlitepathdb "github.com/scionproto/scion/go/lib/pathdb/sqlite"
 	"github.com/scionproto/scion/go/lib/revcache"
 	"github.com/scionproto/scion/go/lib/revcache/memrevcache"
+	sqliterevcache "github.com/scionproto/scion/go/lib/revcache/sqlite"
 	"github.com/scionproto/scion/go/lib/util"
 )
 
 type Backend string
 
 const (
-	BackendNone   Backend = ""
-	BackendSqlite Backend = "sqlite"
-	BackendMem    Backend = "mem"
+	BackendNone     Backend = ""
+	BackendSqlite   Backend = "sqlite"
+	BackendPostgres Backend = "postgres"
+	BackendMem      Backend = "mem"
 )
 
 const (
@@ -99,7 +102,7 @@ func (cfg *PathDBConf) Validate() error {
 
 func (cfg *PathDBConf) validateBackend() error {
 	switch cfg.Backend() {
-	case BackendSqlite:
+	case BackendSqlite, BackendPostgres:
 		return nil
 	case BackendNone:
 		return common.NewBasicError("No backend set", nil)
@@ -195,6 +198,12 @@ func NewPathStorage(pdbConf PathDBConf,
 	if sameBackend(pdbConf, rcConf) {
 		return newCombinedBackend(pdbConf, rcConf)
 	}
+	return newSeparateBackends(pdbConf, rcConf)
+}
+
+func newSeparateBackends(pdbConf PathDBConf,
+	rcConf RevCacheConf) (pathdb.PathDB, revcache.RevCache, error) {
+
 	if err := pdbConf.Validate(); err != nil {
 		return nil, nil, common.NewBasicError("Invalid pathdb config", err)
 	}
@@ -219,7 +228,23 @@ func sameBackend(pdbConf PathDBConf, rcConf RevCacheConf) bool {
 func newCombinedBackend(pdbConf PathDBConf,
 	rcConf RevCacheConf) (pathdb.PathDB, revcache.RevCache, error) {
 
-	panic("Combined backend not supported")
+	switch pdbConf.Backend() {
+	case BackendSqlite:
+		// The sqlite backends are not combined, each uses its own database
+		// file. Sharing a file is not possible since the schema version is
+		// stored per file.
+		if pdbConf.Connection() == rcConf.Connection() {
+			return nil, nil, common.NewBasicError("PathDB and RevCache must not share "+
+				"the sqlite database file", nil, "connection", pdbConf.Connection())
+		}
+		return newSeparateBackends(pdbConf, rcConf)
+	case BackendPostgres:
+		// There is no PostgreSQL revocation cache, the config validation
+		// reports the unsupported backend.
+		return newSeparateBackends(pdbConf, rcConf)
+	default:
+		panic("Combined backend not supported")
+	}
 }
 
 func newPathDB(conf PathDBConf) (pathdb.PathDB, error) {
@@ -230,6 +255,8 @@ func newPathDB(conf PathDBConf) (pathdb.PathDB, error) {
 	switch conf.Backend() {
 	case BackendSqlite:
 		pdb, err = sqlitepathdb.New(conf.Connection())
+	case BackendPostgres:
+		pdb, err = postgrespathdb.New(conf.Connection())
 	case BackendNone:
 		return nil, nil
 	default:
@@ -245,12 +272,23 @@ func newPathDB(conf PathDBConf) (pathdb.PathDB, error) {
 
 func newRevCache(conf RevCacheConf) (revcache.RevCache, error) {
 	log.Info("Connecting RevCache", "backend", conf.Backend(), "connection", conf.Connection())
+	var err error
+	var rc revcache.RevCache
+
 	switch conf.Backend() {
 	case BackendMem:
 		return memrevcache.New(), nil
+	case BackendSqlite:
+		rc, err = sqliterevcache.New(conf.Connection())
 	case BackendNone:
 		return nil, nil
 	default:
 		return nil, common.NewBasicError("Unsupported backend", nil, "backend", conf.Backend())
 	}
+
+	if err != nil {
+		return nil, err
+	}
+	db.SetConnLimits(&conf, rc)
+	return rc, nil
 }
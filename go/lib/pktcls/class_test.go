@@ -72,6 +72,37 @@ func TestClassMap(t *testing.T) {
 				),
 			},
 		},
+		{
+			Name:     "IPv6",
+			FileName: "class_3",
+			Classes: ClassMap{
+				"voip": NewClass(
+					"voip",
+					NewCondAnyOf(
+						NewCondIPv4(&IPv4MatchDSCP{0x2e}),
+						NewCondIPv6(&IPv6MatchDSCP{0x2e}),
+					),
+				),
+				"branch": NewClass(
+					"branch",
+					NewCondAllOf(
+						NewCondIPv6(&IPv6MatchTrafficClass{0x0}),
+						NewCondIPv6(&IPv6MatchSource{
+							&net.IPNet{
+								IP:   net.ParseIP("2001:db8:1::"),
+								Mask: net.CIDRMask(48, 8*net.IPv6len),
+							},
+						}),
+						NewCondIPv6(&IPv6MatchDestination{
+							&net.IPNet{
+								IP:   net.ParseIP("2001:db8:2::"),
+								Mask: net.CIDRMask(48, 8*net.IPv6len),
+							},
+						}),
+					),
+				),
+			},
+		},
 		{
 			Name:     "nil ClassMap stays nil",
 			FileName: "class_2",
@@ -222,6 +253,40 @@ func TestBadJSON(t *testing.T) {
 			},
 			"Name": "Unable to parse source operand string"
 		}
+		`, `
+		{
+			"CondIPv6": {
+				"MatchSource": {
+					"Net": "192.0.2.0/24"
+				}
+			},
+			"Name": "IPv4 source operand in IPv6 condition"
+		}
+		`, `
+		{
+			"CondIPv6": {
+				"MatchToS": {
+					"TOS": "0x80"
+				}
+			},
+			"Name": "IPv4 predicate in IPv6 condition"
+		}
+		`, `
+		{
+			"CondIPv6": {
+				"MatchTrafficClass": {
+					"TrafficClass": "0x1ff"
+				}
+			},
+			"Name": "Unable to parse traffic class operand"
+		}
+		`, `
+		{
+			"CondIPv6": {
+				"MatchDSCP": null
+			},
+			"Name": "No DSCP operand"
+		}
 	`}
 	Convey("Marshaling bad JSON should return errors", t, func() {
 		for i, tc := range testCases {
@@ -206,3 +206,44 @@ func (c *CondIPv4) UnmarshalJSON(b []byte) error {
 	c.Predicate, err = unmarshalPredicate(b)
 	return err
 }
+
+var _ Cond = (*CondIPv6)(nil)
+
+// CondIPv6 conditions return true if the embedded IPv6 predicate returns true.
+type CondIPv6 struct {
+	Predicate IPv6Predicate
+}
+
+func NewCondIPv6(p IPv6Predicate) *CondIPv6 {
+	return &CondIPv6{Predicate: p}
+}
+
+func (c *CondIPv6) Eval(v interface{}) bool {
+	if v == nil {
+		return false
+	}
+	pkt := v.(*Packet)
+	// Protect against typed nils
+	if pkt == nil {
+		return false
+	}
+	parsedPkt, ok := pkt.parsedPkt.Layer(layers.LayerTypeIPv6).(*layers.IPv6)
+	if !ok || parsedPkt == nil {
+		return false
+	}
+	return c.Predicate.Eval(parsedPkt)
+}
+
+func (c *CondIPv6) Type() string {
+	return TypeCondIPv6
+}
+
+func (c *CondIPv6) MarshalJSON() ([]byte, error) {
+	return marshalInterface(c.Predicate)
+}
+
+func (c *CondIPv6) UnmarshalJSON(b []byte) error {
+	var err error
+	c.Predicate, err = unmarshalIPv6Predicate(b)
+	return err
+}
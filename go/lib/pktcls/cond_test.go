@@ -144,6 +144,100 @@ func TestIPCond(t *testing.T) {
 			),
 			ExpEval: false,
 		},
+		{
+			Name: "Match IPv6 destination and DSCP",
+			Cond: NewCondAllOf(
+				NewCondIPv6(
+					&IPv6MatchDestination{
+						&net.IPNet{
+							IP:   net.ParseIP("2001:db8:1::"),
+							Mask: net.CIDRMask(48, 8*net.IPv6len),
+						},
+					},
+				),
+				NewCondIPv6(
+					&IPv6MatchDSCP{
+						DSCP: 0x2e,
+					},
+				),
+			),
+			Packet: newTestPacket6(
+				&layers.IPv6{
+					Version:      6,
+					TrafficClass: 0x2e << 2,
+					SrcIP:        net.ParseIP("2001:db8:2::1"),
+					DstIP:        net.ParseIP("2001:db8:1::1"),
+				},
+				[]byte{3, 3, 3, 3},
+			),
+			ExpEval: true,
+		},
+		{
+			Name: "Match IPv6 source but not traffic class",
+			Cond: NewCondAllOf(
+				NewCondIPv6(
+					&IPv6MatchTrafficClass{
+						TrafficClass: 0x80,
+					},
+				),
+				NewCondIPv6(
+					&IPv6MatchSource{
+						&net.IPNet{
+							IP:   net.ParseIP("2001:db8:2::"),
+							Mask: net.CIDRMask(48, 8*net.IPv6len),
+						},
+					},
+				),
+			),
+			Packet: newTestPacket6(
+				&layers.IPv6{
+					Version: 6,
+					SrcIP:   net.ParseIP("2001:db8:2::1"),
+					DstIP:   net.ParseIP("2001:db8:1::1"),
+				},
+				[]byte{4, 4, 4, 4},
+			),
+			ExpEval: false,
+		},
+		{
+			Name: "IPv4 condition does not match IPv6 packet",
+			Cond: NewCondIPv4(
+				&IPv4MatchDestination{
+					&net.IPNet{
+						IP:   net.IPv4zero,
+						Mask: net.CIDRMask(0, 8*net.IPv4len),
+					},
+				},
+			),
+			Packet: newTestPacket6(
+				&layers.IPv6{
+					Version: 6,
+					SrcIP:   net.ParseIP("2001:db8:2::1"),
+					DstIP:   net.ParseIP("2001:db8:1::1"),
+				},
+				[]byte{5, 5, 5, 5},
+			),
+			ExpEval: false,
+		},
+		{
+			Name: "IPv6 condition does not match IPv4 packet",
+			Cond: NewCondIPv6(
+				&IPv6MatchDestination{
+					&net.IPNet{
+						IP:   net.IPv6zero,
+						Mask: net.CIDRMask(0, 8*net.IPv6len),
+					},
+				},
+			),
+			Packet: newTestPacket(
+				&layers.IPv4{
+					SrcIP: net.IP{192, 168, 1, 1},
+					DstIP: net.IP{10, 0, 0, 2},
+				},
+				[]byte{6, 6, 6, 6},
+			),
+			ExpEval: false,
+		},
 	}
 
 	Convey("TestIPCond", t, func() {
@@ -165,3 +259,16 @@ func newTestPacket(ipv4 *layers.IPv4, pld []byte) *Packet {
 	)
 	return NewPacket(buf.Bytes())
 }
+
+func newTestPacket6(ipv6 *layers.IPv6, pld []byte) *Packet {
+	// Prevent the payload from being parsed as an extension header.
+	ipv6.NextHeader = layers.IPProtocolNoNextHeader
+	buf := gopacket.NewSerializeBuffer()
+	gopacket.SerializeLayers(
+		buf,
+		gopacket.SerializeOptions{FixLengths: true},
+		ipv6,
+		gopacket.Payload(pld),
+	)
+	return NewPacket(buf.Bytes())
+}
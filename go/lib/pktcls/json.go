@@ -49,6 +49,13 @@ const (
 	TypeIPv4MatchDestination = "MatchDestination"
 	TypeIPv4MatchToS         = "MatchToS"
 	TypeIPv4MatchDSCP        = "MatchDSCP"
+	TypeCondIPv6             = "CondIPv6"
+	// The IPv6 predicates share their type names with the IPv4 predicates.
+	// They are only unmarshaled in the context of a CondIPv6.
+	TypeIPv6MatchSource       = "MatchSource"
+	TypeIPv6MatchDestination  = "MatchDestination"
+	TypeIPv6MatchTrafficClass = "MatchTrafficClass"
+	TypeIPv6MatchDSCP         = "MatchDSCP"
 )
 
 // generic container for marshaling custom data
@@ -95,6 +102,10 @@ func unmarshalInterface(b []byte) (Typer, error) {
 			var c CondIPv4
 			err := json.Unmarshal(*v, &c)
 			return &c, err
+		case TypeCondIPv6:
+			var c CondIPv6
+			err := json.Unmarshal(*v, &c)
+			return &c, err
 		case TypeIPv4MatchSource:
 			var p IPv4MatchSource
 			err := json.Unmarshal(*v, &p)
@@ -157,6 +168,37 @@ func unmarshalPredicate(b []byte) (IPv4Predicate, error) {
 	return p, nil
 }
 
+// unmarshalIPv6Predicate extracts an IPv6Predicate from a JSON encoding. It
+// is separate from unmarshalInterface, because the IPv6 predicates share their
+// type names with the IPv4 predicates.
+func unmarshalIPv6Predicate(b []byte) (IPv6Predicate, error) {
+	var container map[string]*json.RawMessage
+	if err := json.Unmarshal(b, &container); err != nil {
+		return nil, err
+	}
+	for k, v := range container {
+		if v == nil {
+			return nil, common.NewBasicError("Missing predicate operands", nil, "type", k)
+		}
+		var p IPv6Predicate
+		switch k {
+		case TypeIPv6MatchSource:
+			p = &IPv6MatchSource{}
+		case TypeIPv6MatchDestination:
+			p = &IPv6MatchDestination{}
+		case TypeIPv6MatchTrafficClass:
+			p = &IPv6MatchTrafficClass{}
+		case TypeIPv6MatchDSCP:
+			p = &IPv6MatchDSCP{}
+		default:
+			return nil, common.NewBasicError("Unknown type", nil, "type", k)
+		}
+		err := json.Unmarshal(*v, p)
+		return p, err
+	}
+	return nil, common.NewBasicError("Unable to extract IPv6Predicate from interface", nil)
+}
+
 // Special case slices because we only need them for Conds
 
 func marshalCondSlice(conds []Cond) ([]byte, error) {
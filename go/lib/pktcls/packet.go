@@ -28,9 +28,15 @@ type Packet struct {
 	parsedPkt gopacket.Packet
 }
 
+// NewPacket parses raw as an IPv6 packet if the version field of the header is
+// 6, and as an IPv4 packet otherwise.
 func NewPacket(raw common.RawBytes) *Packet {
+	first := layers.LayerTypeIPv4
+	if len(raw) > 0 && raw[0]>>4 == 6 {
+		first = layers.LayerTypeIPv6
+	}
 	return &Packet{
 		rawPkt:    raw,
-		parsedPkt: gopacket.NewPacket(raw, layers.LayerTypeIPv4, gopacket.NoCopy),
+		parsedPkt: gopacket.NewPacket(raw, first, gopacket.NoCopy),
 	}
 }
@@ -0,0 +1,198 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// This file contains an SQLite backend for the RevCache.
+
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"strings"
+	"sync"
+	"time"
+
+	_ "github.com/mattn/go-sqlite3"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/infra/modules/db"
+	"github.com/scionproto/scion/go/lib/revcache"
+)
+
+var _ revcache.RevCache = (*Backend)(nil)
+
+// Backend is an SQLite backed revocation cache. Since the revocations are
+// persisted, they survive restarts and the cache can be shared between
+// processes that use the same database file.
+type Backend struct {
+	sync.RWMutex
+	db *sql.DB
+}
+
+// New returns a new SQLite backend opening a database at the given path. If
+// no database exists a new database is be created. If the schema version of the
+// stored database is different from the one in schema.go, an error is returned.
+func New(path string) (*Backend, error) {
+	db, err := db.NewSqlite(path, Schema, SchemaVersion)
+	if err != nil {
+		return nil, err
+	}
+	return &Backend{
+		db: db,
+	}, nil
+}
+
+func (b *Backend) Get(ctx context.Context, keys revcache.KeySet) (revcache.Revocations, error) {
+	b.RLock()
+	defer b.RUnlock()
+	if len(keys) == 0 {
+		return revcache.Revocations{}, nil
+	}
+	conds := make([]string, 0, len(keys))
+	args := make([]interface{}, 0, 3*len(keys)+1)
+	for k := range keys {
+		conds = append(conds, "(IsdID=? AND AsID=? AND IfID=?)")
+		args = append(args, k.IA.I, k.IA.A, k.IfId)
+	}
+	args = append(args, time.Now().UnixNano())
+	query := "SELECT RawSignedRev FROM Revocations WHERE (" + strings.Join(conds, " OR ") +
+		") AND Expiration>?"
+	rows, err := b.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, common.NewBasicError("Error looking up revocations", err)
+	}
+	defer rows.Close()
+	revs := make(revcache.Revocations, len(keys))
+	for rows.Next() {
+		rev, err := scanRev(rows)
+		if err != nil {
+			return nil, err
+		}
+		info, _ := rev.RevInfo()
+		revs[*revcache.NewKey(info.IA(), info.IfID)] = rev
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewBasicError("Error reading DB response", err)
+	}
+	return revs, nil
+}
+
+func (b *Backend) GetAll(ctx context.Context) (revcache.ResultChan, error) {
+	b.RLock()
+	defer b.RUnlock()
+	query := "SELECT RawSignedRev FROM Revocations WHERE Expiration>?"
+	rows, err := b.db.QueryContext(ctx, query, time.Now().UnixNano())
+	if err != nil {
+		return nil, common.NewBasicError("Error looking up revocations", err)
+	}
+	defer rows.Close()
+	// Read all results upfront, so that no goroutine is needed to fill the channel and the
+	// database is not blocked by a slow consumer.
+	var results []revcache.RevOrErr
+	for rows.Next() {
+		rev, err := scanRev(rows)
+		results = append(results, revcache.RevOrErr{Rev: rev, Err: err})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, common.NewBasicError("Error reading DB response", err)
+	}
+	resCh := make(chan revcache.RevOrErr, len(results))
+	for _, res := range results {
+		resCh <- res
+	}
+	close(resCh)
+	return resCh, nil
+}
+
+func (b *Backend) Insert(ctx context.Context, rev *path_mgmt.SignedRevInfo) (bool, error) {
+	b.Lock()
+	defer b.Unlock()
+	newInfo, err := rev.RevInfo()
+	if err != nil {
+		panic(err)
+	}
+	now := time.Now()
+	if !newInfo.Expiration().After(now) {
+		return false, nil
+	}
+	packed, err := rev.Pack()
+	if err != nil {
+		return false, common.NewBasicError("Failed to pack revocation", err)
+	}
+	ia := newInfo.IA()
+	// Only replace an existing entry if it is expired or the new revocation is newer.
+	query := `
+		INSERT OR REPLACE INTO Revocations
+			(IsdID, AsID, IfID, LinkType, IssuingTime, Expiration, RawSignedRev)
+		SELECT ?, ?, ?, ?, ?, ?, ?
+		WHERE NOT EXISTS (
+			SELECT 1 FROM Revocations
+			WHERE IsdID=? AND AsID=? AND IfID=? AND IssuingTime>=? AND Expiration>?
+		)
+	`
+	res, err := b.db.ExecContext(ctx, query,
+		ia.I, ia.A, newInfo.IfID, newInfo.LinkType, newInfo.Timestamp().UnixNano(),
+		newInfo.Expiration().UnixNano(), packed,
+		ia.I, ia.A, newInfo.IfID, newInfo.Timestamp().UnixNano(), now.UnixNano())
+	if err != nil {
+		return false, common.NewBasicError("Failed to insert revocation", err)
+	}
+	n, err := res.RowsAffected()
+	if err != nil {
+		return false, common.NewBasicError("Failed to determine inserted rows", err)
+	}
+	return n > 0, nil
+}
+
+func (b *Backend) DeleteExpired(ctx context.Context) (int64, error) {
+	b.Lock()
+	defer b.Unlock()
+	query := "DELETE FROM Revocations WHERE Expiration<=?"
+	res, err := b.db.ExecContext(ctx, query, time.Now().UnixNano())
+	if err != nil {
+		return 0, common.NewBasicError("Failed to delete expired revocations", err)
+	}
+	return res.RowsAffected()
+}
+
+func (b *Backend) Close() error {
+	return b.db.Close()
+}
+
+func (b *Backend) SetMaxOpenConns(maxOpenConns int) {
+	b.db.SetMaxOpenConns(maxOpenConns)
+}
+
+func (b *Backend) SetMaxIdleConns(maxIdleConns int) {
+	b.db.SetMaxIdleConns(maxIdleConns)
+}
+
+// scanRev reads the revocation in the current row and parses its info.
+func scanRev(rows *sql.Rows) (*path_mgmt.SignedRevInfo, error) {
+	// Scan into a byte slice, so that the data is copied. The parsed revocation
+	// references the raw bytes.
+	var rawRev []byte
+	if err := rows.Scan(&rawRev); err != nil {
+		return nil, common.NewBasicError("Error reading DB response", err)
+	}
+	rev, err := path_mgmt.NewSignedRevInfoFromRaw(rawRev)
+	if err != nil {
+		return nil, common.NewBasicError("Error unmarshalling revocation", err)
+	}
+	if _, err := rev.RevInfo(); err != nil {
+		return nil, common.NewBasicError("Error parsing revocation info", err)
+	}
+	return rev, nil
+}
@@ -123,6 +123,13 @@ func (m *MockConn) Paths(ctx context.Context, dst, src addr.IA, max uint16,
 	}, nil
 }
 
+// SubscribePaths is not implemented, and always returns an error.
+func (m *MockConn) SubscribePaths(ctx context.Context, dst, src addr.IA,
+	max uint16) (*PathSubscription, error) {
+
+	return nil, common.NewBasicError("Path subscriptions not supported by mock", nil)
+}
+
 // ASInfo is not implemented.
 func (m *MockConn) ASInfo(ctx context.Context, ia addr.IA) (*ASInfoReply, error) {
 	panic("not implemented")
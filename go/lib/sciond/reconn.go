@@ -76,6 +76,29 @@ func (c *reconnector) Paths(ctx context.Context, dst, src addr.IA, max uint16,
 	return conn.Paths(ctx, dst, src, max, f)
 }
 
+// SubscribePaths uses a dedicated connection for the subscription, which is
+// closed together with the subscription. The subscription ends if SCIOND
+// goes down.
+func (c *reconnector) SubscribePaths(ctx context.Context, dst, src addr.IA,
+	max uint16) (*PathSubscription, error) {
+
+	conn, err := c.ctxAwareConnect(ctx)
+	if err != nil {
+		return nil, err
+	}
+	sub, err := conn.SubscribePaths(ctx, dst, src, max)
+	if err != nil {
+		conn.Close(ctx)
+		return nil, err
+	}
+	closeF := sub.closeF
+	sub.closeF = func(ctx context.Context) error {
+		defer conn.Close(ctx)
+		return closeF(ctx)
+	}
+	return sub, nil
+}
+
 func (c *reconnector) ASInfo(ctx context.Context, ia addr.IA) (*ASInfoReply, error) {
 	conn, err := c.ctxAwareConnect(ctx)
 	if err != nil {
@@ -110,6 +110,11 @@ type Connector interface {
 	// Paths requests from SCIOND a set of end to end paths between src and
 	// dst. max specifies the maximum number of paths returned.
 	Paths(ctx context.Context, dst, src addr.IA, max uint16, f PathReqFlags) (*PathReply, error)
+	// SubscribePaths subscribes to the paths between src and dst. The
+	// returned subscription contains the current paths, and SCIOND pushes
+	// the changes to the paths until the subscription is closed. max
+	// specifies the maximum number of paths.
+	SubscribePaths(ctx context.Context, dst, src addr.IA, max uint16) (*PathSubscription, error)
 	// ASInfo requests from SCIOND information about AS ia.
 	ASInfo(ctx context.Context, ia addr.IA) (*ASInfoReply, error)
 	// IFInfo requests from SCIOND addresses and ports of interfaces.  Slice
@@ -139,6 +144,13 @@ type connector struct {
 	asInfos  *cache.Cache
 	ifInfos  *cache.Cache
 	svcInfos *cache.Cache
+
+	// subsMtx protects subs, which contains the path subscriptions keyed by
+	// the ID of the subscribe request.
+	subsMtx sync.Mutex
+	subs    map[uint64]*PathSubscription
+	// recvOnce starts the goroutine that delivers path updates.
+	recvOnce sync.Once
 }
 
 func connect(socketName string) (*connector, error) {
@@ -159,6 +171,7 @@ func connectTimeout(socketName string, timeout time.Duration) (*connector, error
 		asInfos:  cache.New(ASInfoTTL, time.Minute),
 		ifInfos:  cache.New(IFInfoTTL, time.Minute),
 		svcInfos: cache.New(SVCInfoTTL, time.Minute),
+		subs:     make(map[uint64]*PathSubscription),
 	}, nil
 }
 
@@ -192,6 +205,100 @@ func (c *connector) Paths(ctx context.Context, dst, src addr.IA, max uint16,
 	return reply.(*Pld).PathReply, nil
 }
 
+func (c *connector) SubscribePaths(ctx context.Context, dst, src addr.IA,
+	max uint16) (*PathSubscription, error) {
+
+	c.recvOnce.Do(func() {
+		go func() {
+			defer log.LogPanicAndExit()
+			c.recvUpdates()
+		}()
+	})
+	id := c.nextID()
+	sub := NewPathSubscription(nil, func(ctx context.Context) error {
+		return c.unsubscribe(ctx, id)
+	})
+	// Register the subscription before sending the request, such that no
+	// update is lost.
+	c.subsMtx.Lock()
+	c.subs[id] = sub
+	c.subsMtx.Unlock()
+	c.Lock()
+	defer c.Unlock()
+	reply, err := c.dispatcher.Request(
+		ctx,
+		&Pld{
+			Id:    id,
+			Which: proto.SCIONDMsg_Which_pathSubscribeReq,
+			PathSubscribeReq: &PathSubscribeReq{
+				Dst:      dst.IAInt(),
+				Src:      src.IAInt(),
+				MaxPaths: max,
+			},
+		},
+		nil,
+	)
+	if err != nil {
+		c.removeSub(id)
+		return nil, common.NewBasicError("[sciond-API] Failed to subscribe to Paths", err)
+	}
+	sub.Reply = reply.(*Pld).PathSubscribeReply
+	return sub, nil
+}
+
+func (c *connector) unsubscribe(ctx context.Context, id uint64) error {
+	c.removeSub(id)
+	c.Lock()
+	defer c.Unlock()
+	err := c.dispatcher.Notify(
+		ctx,
+		&Pld{
+			Id:                 c.nextID(),
+			Which:              proto.SCIONDMsg_Which_pathUnsubscribeReq,
+			PathUnsubscribeReq: &PathUnsubscribeReq{Subscription: id},
+		},
+		nil,
+	)
+	if err != nil {
+		return common.NewBasicError("[sciond-API] Failed to unsubscribe from Paths", err)
+	}
+	return nil
+}
+
+func (c *connector) removeSub(id uint64) {
+	c.subsMtx.Lock()
+	defer c.subsMtx.Unlock()
+	delete(c.subs, id)
+}
+
+// recvUpdates delivers the path updates pushed by SCIOND to the subscriptions.
+// Once the connection is closed, all subscriptions end.
+func (c *connector) recvUpdates() {
+	for {
+		msg, _, err := c.dispatcher.RecvFrom(context.Background())
+		if err != nil {
+			c.subsMtx.Lock()
+			for id, sub := range c.subs {
+				sub.End(common.NewBasicError("Connection to SCIOND closed", err))
+				delete(c.subs, id)
+			}
+			c.subsMtx.Unlock()
+			return
+		}
+		pld, ok := msg.(*Pld)
+		if !ok || pld.Which != proto.SCIONDMsg_Which_pathUpdate {
+			log.Warn("[sciond-API] Received unexpected message", "msg", msg)
+			continue
+		}
+		c.subsMtx.Lock()
+		sub, ok := c.subs[pld.Id]
+		if ok && !sub.Push(pld.PathUpdate) {
+			delete(c.subs, pld.Id)
+		}
+		c.subsMtx.Unlock()
+	}
+}
+
 func (c *connector) ASInfo(ctx context.Context, ia addr.IA) (*ASInfoReply, error) {
 	c.Lock()
 	defer c.Unlock()
@@ -0,0 +1,112 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sciond
+
+import (
+	"context"
+	"sync"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// SubscriptionQueueLen is the number of updates that are buffered for a path
+// subscription. If the subscriber falls further behind, the subscription ends.
+const SubscriptionQueueLen = 16
+
+// PathSubscription is a subscription to the paths between two ASes. The
+// changes to the paths are delivered on the Updates channel. The channel is
+// closed when the subscription ends, e.g., because the connection to SCIOND
+// was closed, or because the updates were not consumed in time. Err then
+// returns the reason.
+type PathSubscription struct {
+	// Reply contains the paths at the time of subscribing.
+	Reply *PathSubscribeReply
+
+	closeF  func(ctx context.Context) error
+	updates chan *PathUpdate
+
+	mtx   sync.Mutex
+	ended bool
+	err   error
+}
+
+// NewPathSubscription creates a subscription with the initial paths in reply.
+// Function closeF is called when the subscriber closes the subscription.
+// Connector implementations deliver the updates with Push.
+func NewPathSubscription(reply *PathSubscribeReply,
+	closeF func(ctx context.Context) error) *PathSubscription {
+
+	return &PathSubscription{
+		Reply:   reply,
+		closeF:  closeF,
+		updates: make(chan *PathUpdate, SubscriptionQueueLen),
+	}
+}
+
+// Updates returns the channel on which the path updates are delivered.
+func (s *PathSubscription) Updates() <-chan *PathUpdate {
+	return s.updates
+}
+
+// Err returns the reason why the subscription ended, or nil if it is still
+// running or was closed by the subscriber.
+func (s *PathSubscription) Err() error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.err
+}
+
+// Push delivers u to the subscriber without blocking. If the queue of the
+// subscription is full, the subscription ends, and false is returned.
+func (s *PathSubscription) Push(u *PathUpdate) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	if s.ended {
+		return false
+	}
+	select {
+	case s.updates <- u:
+		return true
+	default:
+		s.end(common.NewBasicError("Path subscription queue full", nil))
+		return false
+	}
+}
+
+// End ends the subscription with err. Calling End on a subscription that
+// already ended is a no-op.
+func (s *PathSubscription) End(err error) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.end(err)
+}
+
+func (s *PathSubscription) end(err error) {
+	if s.ended {
+		return
+	}
+	s.ended = true
+	s.err = err
+	close(s.updates)
+}
+
+// Close cancels the subscription.
+func (s *PathSubscription) Close(ctx context.Context) error {
+	s.End(nil)
+	if s.closeF == nil {
+		return nil
+	}
+	return s.closeF(ctx)
+}
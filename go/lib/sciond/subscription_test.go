@@ -0,0 +1,61 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package sciond
+
+import (
+	"context"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+)
+
+func TestPathSubscription(t *testing.T) {
+	Convey("Updates are delivered in order", t, func() {
+		sub := NewPathSubscription(&PathSubscribeReply{}, nil)
+		u1, u2 := &PathUpdate{}, &PathUpdate{}
+		So(sub.Push(u1), ShouldBeTrue)
+		So(sub.Push(u2), ShouldBeTrue)
+		So(<-sub.Updates(), ShouldEqual, u1)
+		So(<-sub.Updates(), ShouldEqual, u2)
+	})
+	Convey("A full queue ends the subscription", t, func() {
+		sub := NewPathSubscription(&PathSubscribeReply{}, nil)
+		for i := 0; i < SubscriptionQueueLen; i++ {
+			So(sub.Push(&PathUpdate{}), ShouldBeTrue)
+		}
+		So(sub.Push(&PathUpdate{}), ShouldBeFalse)
+		So(sub.Err(), ShouldNotBeNil)
+		// The queued updates are still delivered before the channel is closed.
+		n := 0
+		for range sub.Updates() {
+			n++
+		}
+		So(n, ShouldEqual, SubscriptionQueueLen)
+		So(sub.Push(&PathUpdate{}), ShouldBeFalse)
+	})
+	Convey("Close cancels the subscription", t, func() {
+		closed := 0
+		sub := NewPathSubscription(&PathSubscribeReply{}, func(_ context.Context) error {
+			closed++
+			return nil
+		})
+		So(sub.Close(context.Background()), ShouldBeNil)
+		So(closed, ShouldEqual, 1)
+		_, ok := <-sub.Updates()
+		So(ok, ShouldBeFalse)
+		So(sub.Err(), ShouldBeNil)
+		So(sub.Push(&PathUpdate{}), ShouldBeFalse)
+	})
+}
@@ -74,6 +74,10 @@ type Pld struct {
 	IfInfoReply        *IFInfoReply
 	ServiceInfoRequest *ServiceInfoRequest
 	ServiceInfoReply   *ServiceInfoReply
+	PathSubscribeReq   *PathSubscribeReq
+	PathSubscribeReply *PathSubscribeReply
+	PathUpdate         *PathUpdate
+	PathUnsubscribeReq *PathUnsubscribeReq
 }
 
 func NewPldFromRaw(b common.RawBytes) (*Pld, error) {
@@ -118,6 +122,14 @@ func (p *Pld) union() (interface{}, error) {
 		return p.ServiceInfoRequest, nil
 	case proto.SCIONDMsg_Which_serviceInfoReply:
 		return p.ServiceInfoReply, nil
+	case proto.SCIONDMsg_Which_pathSubscribeReq:
+		return p.PathSubscribeReq, nil
+	case proto.SCIONDMsg_Which_pathSubscribeReply:
+		return p.PathSubscribeReply, nil
+	case proto.SCIONDMsg_Which_pathUpdate:
+		return p.PathUpdate, nil
+	case proto.SCIONDMsg_Which_pathUnsubscribeReq:
+		return p.PathUnsubscribeReq, nil
 	}
 	return nil, common.NewBasicError("Unsupported SCIOND union type", nil, "type", p.Which)
 }
@@ -160,6 +172,52 @@ func (r *PathReply) String() string {
 	return fmt.Sprintf("ErrorCode=%v\n  %v", r.ErrorCode, strings.Join(strEntries, "\n  "))
 }
 
+// PathSubscribeReq subscribes to the paths between Src and Dst.
+type PathSubscribeReq struct {
+	Dst      addr.IAInt
+	Src      addr.IAInt
+	MaxPaths uint16
+}
+
+func (r *PathSubscribeReq) String() string {
+	return fmt.Sprintf("%v -> %v, maxPaths=%d", r.Src, r.Dst, r.MaxPaths)
+}
+
+// PathSubscribeReply contains the paths at the time of subscribing.
+type PathSubscribeReply struct {
+	ErrorCode PathErrorCode
+	Entries   []PathReplyEntry
+}
+
+func (r *PathSubscribeReply) String() string {
+	return (&PathReply{ErrorCode: r.ErrorCode, Entries: r.Entries}).String()
+}
+
+// PathUpdate is pushed by SCIOND when the paths of a subscription change. Its
+// message ID is the ID of the PathSubscribeReq.
+type PathUpdate struct {
+	// Added contains the new paths, and the paths whose metadata changed.
+	Added []PathReplyEntry
+	// Removed contains the paths that are no longer available.
+	Removed []PathReplyEntry
+	// Revocations contains the revocations that caused paths to be removed.
+	Revocations []*path_mgmt.SignedRevInfo
+}
+
+func (u *PathUpdate) String() string {
+	return fmt.Sprintf("Added=%d Removed=%d Revocations=%d",
+		len(u.Added), len(u.Removed), len(u.Revocations))
+}
+
+// PathUnsubscribeReq cancels the subscription with the given ID.
+type PathUnsubscribeReq struct {
+	Subscription uint64
+}
+
+func (r *PathUnsubscribeReq) String() string {
+	return fmt.Sprintf("Subscription: %d", r.Subscription)
+}
+
 type PathReplyEntry struct {
 	Path     *FwdPathMeta
 	HostInfo hostinfo.HostInfo
@@ -174,6 +232,9 @@ type FwdPathMeta struct {
 	Mtu        uint16
 	Interfaces []PathInterface
 	ExpTime    uint32
+	// StaticInfo is the static metadata of the path. It is nil if none of the
+	// ASes on the path provides static metadata.
+	StaticInfo *PathStaticInfo
 }
 
 func (fpm *FwdPathMeta) SrcIA() addr.IA {
@@ -258,6 +319,53 @@ func (iface PathInterface) String() string {
 	return fmt.Sprintf("%s#%d", iface.ISD_AS(), iface.IfID)
 }
 
+// PathStaticInfo contains the static metadata of a path, aggregated from the
+// static info extensions of the AS entries of the path segments. Values that
+// are not provided by an AS are ignored in the aggregation.
+type PathStaticInfo struct {
+	// Latency is the sum of the known latencies along the path in
+	// microseconds.
+	Latency uint32
+	// Bandwidth is the minimum of the known link bandwidths along the path in
+	// kbit/s.
+	Bandwidth uint64
+	// InternalHops is the sum of the known AS internal hops along the path.
+	InternalHops uint32
+	// Ifaces contains the static info of the interfaces on the path. The
+	// entries are aligned with the interfaces of the FwdPathMeta.
+	Ifaces []PathIfaceStaticInfo
+	// Notes contains the notes of the ASes on the path.
+	Notes []PathASNote
+}
+
+// LatencyDuration returns the sum of the known latencies as duration.
+func (i *PathStaticInfo) LatencyDuration() time.Duration {
+	return time.Duration(i.Latency) * time.Microsecond
+}
+
+func (i *PathStaticInfo) String() string {
+	return fmt.Sprintf("Latency: %s Bandwidth: %dkbit/s InternalHops: %d",
+		i.LatencyDuration(), i.Bandwidth, i.InternalHops)
+}
+
+// PathIfaceStaticInfo contains the static info of an interface on a path.
+type PathIfaceStaticInfo struct {
+	Latitude  float32
+	Longitude float32
+	Address   string
+	LinkType  proto.StaticInfoIface_LinkType
+}
+
+// PathASNote is the note of an AS on a path.
+type PathASNote struct {
+	RawIsdas addr.IAInt `capnp:"isdas"`
+	Note     string
+}
+
+func (n *PathASNote) ISD_AS() addr.IA {
+	return n.RawIsdas.IA()
+}
+
 type ASInfoReq struct {
 	Isdas addr.IAInt
 }
@@ -0,0 +1,215 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"encoding/json"
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/scrypto"
+	"github.com/scionproto/scion/go/lib/util"
+)
+
+const (
+	CRLEarlyUsage    = "Revocation list IssuingTime in the future"
+	CRLInvalidIssuer = "Revocation list not issued by certificate subject"
+	CRLInvalidPeriod = "Revocation list NextUpdate not after IssuingTime"
+	CRLInvalidIssVer = "Revocation list issuer certificate version mismatch"
+	CRLStale         = "Revocation list stale"
+	CRLUnableSigPack = "RevocationList: Unable to create signature input"
+)
+
+const (
+	issuerVersion = "IssuerVersion"
+	nextUpdate    = "NextUpdate"
+	revoked       = "Revoked"
+)
+
+// Revocation identifies a single revoked certificate.
+type Revocation struct {
+	// Subject is the subject of the revoked certificate.
+	Subject addr.IA
+	// Version is the version of the revoked certificate.
+	Version uint64
+	// RevocationTime is the unix timestamp in seconds at which the certificate
+	// was revoked.
+	RevocationTime uint32
+}
+
+func (r Revocation) String() string {
+	return fmt.Sprintf("%sv%d", r.Subject, r.Version)
+}
+
+// RevocationList is a list of certificates that have been revoked by an
+// issuer before their expiration time. It is signed with the signing key of
+// the issuer certificate.
+//
+// A revocation list is valid between IssuingTime and NextUpdate. A newer
+// version of the list is expected to be published before NextUpdate.
+// Revocations are never removed from a newer version before the revoked
+// certificate has expired.
+type RevocationList struct {
+	// Issuer is the issuing AS that revoked the certificates.
+	Issuer addr.IA
+	// IssuerVersion is the version of the issuer certificate that is used to
+	// verify the signature.
+	IssuerVersion uint64
+	// IssuingTime is the unix timestamp in seconds at which the list was created.
+	IssuingTime uint32
+	// NextUpdate is the unix timestamp in seconds at which the list becomes stale.
+	NextUpdate uint32
+	// Revoked contains the revoked certificates.
+	Revoked []Revocation
+	// Signature is the signature of the issuer. It is computed over the rest
+	// of the revocation list.
+	Signature common.RawBytes `json:",omitempty"`
+	// Version is the revocation list version. It is strictly increasing.
+	// The value scrypto.LatestVer is reserved and shall not be used.
+	Version uint64
+}
+
+// RevocationListFromRaw parses the revocation list.
+func RevocationListFromRaw(raw common.RawBytes) (*RevocationList, error) {
+	crl := &RevocationList{}
+	if err := json.Unmarshal(raw, crl); err != nil {
+		return nil, common.NewBasicError("Unable to parse RevocationList", err)
+	}
+	if crl.Version == scrypto.LatestVer {
+		return nil, common.NewBasicError(ReservedVersion, nil)
+	}
+	return crl, nil
+}
+
+// Verify checks that the revocation list has been issued by the subject of
+// the issuer certificate, and that it is correctly signed. It does not check
+// whether the revocation list is stale.
+func (l *RevocationList) Verify(issCert *Certificate) error {
+	if !l.Issuer.Equal(issCert.Subject) {
+		return common.NewBasicError(CRLInvalidIssuer, nil,
+			"expected", issCert.Subject, "actual", l.Issuer)
+	}
+	if l.IssuerVersion != issCert.Version {
+		return common.NewBasicError(CRLInvalidIssVer, nil,
+			"expected", l.IssuerVersion, "actual", issCert.Version)
+	}
+	if l.NextUpdate <= l.IssuingTime {
+		return common.NewBasicError(CRLInvalidPeriod, nil,
+			"IssuingTime", util.SecsToCompact(l.IssuingTime),
+			"NextUpdate", util.SecsToCompact(l.NextUpdate))
+	}
+	if now := util.TimeToSecs(time.Now()); now < l.IssuingTime {
+		return common.NewBasicError(CRLEarlyUsage, nil,
+			"IssuingTime", util.SecsToCompact(l.IssuingTime),
+			"current", util.SecsToCompact(now))
+	}
+	return l.VerifySignature(issCert.SubjectSignKey, issCert.SignAlgorithm)
+}
+
+// VerifyTime checks that the time ts is between issuing time and next update.
+// This function does not check the validity of the signature.
+func (l *RevocationList) VerifyTime(ts uint32) error {
+	if ts < l.IssuingTime {
+		return common.NewBasicError(CRLEarlyUsage, nil,
+			"IssuingTime", util.SecsToCompact(l.IssuingTime),
+			"current", util.SecsToCompact(ts))
+	}
+	if ts >= l.NextUpdate {
+		return common.NewBasicError(CRLStale, nil,
+			"NextUpdate", util.SecsToCompact(l.NextUpdate),
+			"current", util.SecsToCompact(ts))
+	}
+	return nil
+}
+
+// VerifySignature checks the signature of the revocation list based on a
+// trusted verifying key and the associated signature algorithm.
+func (l *RevocationList) VerifySignature(verifyKey common.RawBytes, signAlgo string) error {
+	sigInput, err := l.sigPack()
+	if err != nil {
+		return common.NewBasicError(CRLUnableSigPack, err)
+	}
+	return scrypto.Verify(sigInput, l.Signature, verifyKey, signAlgo)
+}
+
+// Sign adds the signature to the revocation list. The signature is computed
+// over the revocation list without the signature field.
+func (l *RevocationList) Sign(signKey common.RawBytes, signAlgo string) error {
+	sigInput, err := l.sigPack()
+	if err != nil {
+		return err
+	}
+	sig, err := scrypto.Sign(sigInput, signKey, signAlgo)
+	if err != nil {
+		return err
+	}
+	l.Signature = sig
+	return nil
+}
+
+// sigPack creates a sorted json object of all fields, except for the signature field.
+func (l *RevocationList) sigPack() (common.RawBytes, error) {
+	if l.Version == scrypto.LatestVer {
+		return nil, common.NewBasicError(ReservedVersion, nil)
+	}
+	m := make(map[string]interface{})
+	m[issuer] = l.Issuer
+	m[issuerVersion] = l.IssuerVersion
+	m[issuingTime] = l.IssuingTime
+	m[nextUpdate] = l.NextUpdate
+	m[revoked] = l.Revoked
+	m[version] = l.Version
+	return json.Marshal(m)
+}
+
+// Revokes returns true if the certificate of the subject with the given
+// version is revoked.
+func (l *RevocationList) Revokes(subject addr.IA, version uint64) bool {
+	for _, r := range l.Revoked {
+		if r.Subject.Equal(subject) && r.Version == version {
+			return true
+		}
+	}
+	return false
+}
+
+func (l *RevocationList) String() string {
+	return fmt.Sprintf("RevocationList %sv%d", l.Issuer, l.Version)
+}
+
+func (l *RevocationList) JSON(indent bool) ([]byte, error) {
+	if indent {
+		return json.MarshalIndent(l, "", strings.Repeat(" ", 4))
+	}
+	return json.Marshal(l)
+}
+
+func (l *RevocationList) UnmarshalJSON(b []byte) error {
+	type Alias RevocationList
+	var m map[string]interface{}
+	err := json.Unmarshal(b, &m)
+	if err != nil {
+		return err
+	}
+	// The signature is omitted for unsigned revocation lists.
+	delete(m, signature)
+	if err = validateFields(m, crlFields); err != nil {
+		return common.NewBasicError(UnableValidateFields, err)
+	}
+	return json.Unmarshal(b, (*Alias)(l))
+}
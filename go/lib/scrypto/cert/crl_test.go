@@ -0,0 +1,146 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package cert
+
+import (
+	"encoding/json"
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+	"golang.org/x/crypto/ed25519"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/scrypto"
+	"github.com/scionproto/scion/go/lib/util"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+// Interface assertions
+var _ fmt.Stringer = (*RevocationList)(nil)
+
+func Test_RevocationList_Verify(t *testing.T) {
+	Convey("Create signed revocation list", t, func() {
+		pub, priv, _ := ed25519.GenerateKey(nil)
+		issCert := &Certificate{
+			Subject:        addr.IA{I: 1, A: 0xff0000000310},
+			Version:        2,
+			SignAlgorithm:  scrypto.Ed25519,
+			SubjectSignKey: common.RawBytes(pub),
+		}
+		now := util.TimeToSecs(time.Now())
+		crl := &RevocationList{
+			Issuer:        issCert.Subject,
+			IssuerVersion: issCert.Version,
+			IssuingTime:   now,
+			NextUpdate:    now + 1<<10,
+			Revoked: []Revocation{
+				{Subject: addr.IA{I: 1, A: 0xff0000000311}, Version: 1, RevocationTime: now},
+			},
+			Version: 1,
+		}
+		xtest.FailOnErr(t, crl.Sign(common.RawBytes(priv), scrypto.Ed25519))
+
+		Convey("Revocation list is verifiable", func() {
+			SoMsg("err", crl.Verify(issCert), ShouldBeNil)
+		})
+
+		Convey("Wrong issuer throws error", func() {
+			crl.Issuer = addr.IA{I: 1, A: 0xff0000000311}
+			SoMsg("err", crl.Verify(issCert), ShouldNotBeNil)
+		})
+
+		Convey("Wrong issuer certificate version throws error", func() {
+			issCert.Version = 1
+			SoMsg("err", crl.Verify(issCert), ShouldNotBeNil)
+		})
+
+		Convey("Modified revocations throw error", func() {
+			crl.Revoked = nil
+			SoMsg("err", crl.Verify(issCert), ShouldNotBeNil)
+		})
+
+		Convey("Early usage throws error", func() {
+			crl.IssuingTime = now + 1<<20
+			crl.NextUpdate = crl.IssuingTime + 1<<10
+			xtest.FailOnErr(t, crl.Sign(common.RawBytes(priv), scrypto.Ed25519))
+			SoMsg("err", crl.Verify(issCert), ShouldNotBeNil)
+		})
+
+		Convey("Stale revocation list is verifiable", func() {
+			crl.IssuingTime = now - 1<<20
+			crl.NextUpdate = now - 1
+			xtest.FailOnErr(t, crl.Sign(common.RawBytes(priv), scrypto.Ed25519))
+			SoMsg("err", crl.Verify(issCert), ShouldBeNil)
+			err := crl.VerifyTime(now)
+			SoMsg("stale", common.GetErrorMsg(err), ShouldEqual, CRLStale)
+		})
+	})
+}
+
+func Test_RevocationList_Revokes(t *testing.T) {
+	Convey("Revokes only matches subject and version", t, func() {
+		crl := &RevocationList{
+			Revoked: []Revocation{
+				{Subject: addr.IA{I: 1, A: 0xff0000000311}, Version: 2},
+			},
+		}
+		SoMsg("revoked", crl.Revokes(addr.IA{I: 1, A: 0xff0000000311}, 2), ShouldBeTrue)
+		SoMsg("version", crl.Revokes(addr.IA{I: 1, A: 0xff0000000311}, 1), ShouldBeFalse)
+		SoMsg("subject", crl.Revokes(addr.IA{I: 1, A: 0xff0000000312}, 2), ShouldBeFalse)
+	})
+}
+
+func Test_RevocationList_JSON(t *testing.T) {
+	Convey("Revocation list is parsed from its JSON encoding", t, func() {
+		crl := &RevocationList{
+			Issuer:        addr.IA{I: 1, A: 0xff0000000310},
+			IssuerVersion: 1,
+			IssuingTime:   1,
+			NextUpdate:    2,
+			Revoked: []Revocation{
+				{Subject: addr.IA{I: 1, A: 0xff0000000311}, Version: 1, RevocationTime: 1},
+			},
+			Signature: common.RawBytes{0x01, 0x02},
+			Version:   1,
+		}
+		raw, err := crl.JSON(false)
+		SoMsg("err", err, ShouldBeNil)
+		parsed, err := RevocationListFromRaw(raw)
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("crl", parsed, ShouldResemble, crl)
+	})
+
+	Convey("RevocationListFromRaw should fail for unknown fields", t, func() {
+		raw, err := (&RevocationList{Version: 1}).JSON(false)
+		xtest.FailOnErr(t, err)
+		var m map[string]interface{}
+		xtest.FailOnErr(t, json.Unmarshal(raw, &m))
+		m["xeno"] = "UNKNOWN"
+		b, err := json.Marshal(m)
+		xtest.FailOnErr(t, err)
+		_, err = RevocationListFromRaw(b)
+		SoMsg("err", err, ShouldNotBeNil)
+	})
+
+	Convey("RevocationListFromRaw should fail for reserved version", t, func() {
+		raw, err := (&RevocationList{}).JSON(false)
+		xtest.FailOnErr(t, err)
+		_, err = RevocationListFromRaw(raw)
+		SoMsg("err", err, ShouldNotBeNil)
+	})
+}
@@ -28,6 +28,8 @@ var certFields = []string{canIssue, comment, encAlgorithm, expirationTime,
 
 var chainFields = []string{"0", "1"}
 
+var crlFields = []string{issuer, issuerVersion, issuingTime, nextUpdate, revoked, version}
+
 func validateFields(m map[string]interface{}, fields []string) error {
 	for _, field := range fields {
 		if _, ok := m[field]; !ok {
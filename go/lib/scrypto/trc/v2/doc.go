@@ -15,8 +15,16 @@
 // Package trc contains the TRC implementation according to the new
 // control-plane PKI design.
 //
+// The TRC payload is distributed in a signed container (see Signed) that
+// follows the JWS JSON serialization. Each signature is either a vote cast
+// with a key of the previous TRC, or a proof of possession of a key in the
+// TRC. Base TRCs are verified with BaseVerifier, TRC updates with
+// UpdateVerifier.
+//
 // This package will replace the parent package, once the package is stable
 // and all code interacting with the trust material has been adapated.
 //
-// WARNING: Do not use this package other than for testing.
+// WARNING: Do not use this package other than for testing. The trust store
+// and scion-pki only support it alongside the TRC format of the parent
+// package.
 package trc
@@ -71,7 +71,7 @@ func (v *popValidator) popForModType(changes map[KeyType]ASToKeyMeta) error {
 
 func (v *popValidator) popForKeyType(keyType KeyType, m map[addr.AS]KeyMeta) error {
 	for as := range m {
-		if !v.hasPop(v.TRC.ProofOfPossession[as], keyType) {
+		if !hasPop(v.TRC.ProofOfPossession[as], keyType) {
 			return common.NewBasicError(MissingProofOfPossession, nil, "AS", as, "keyType", keyType)
 		}
 		delete(v.pops[as], keyType)
@@ -79,7 +79,7 @@ func (v *popValidator) popForKeyType(keyType KeyType, m map[addr.AS]KeyMeta) err
 	return nil
 }
 
-func (v *popValidator) hasPop(allPops []KeyType, keyType KeyType) bool {
+func hasPop(allPops []KeyType, keyType KeyType) bool {
 	for _, t := range allPops {
 		if t == keyType {
 			return true
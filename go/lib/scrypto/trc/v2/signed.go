@@ -0,0 +1,366 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trc
+
+import (
+	"bytes"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"strings"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/scrypto"
+)
+
+// Parsing errors with context.
+const (
+	// InvalidSignatureType indicates an inexistent signature type.
+	InvalidSignatureType = "invalid signature type"
+	// UnableToDecode indicates an invalid base64url encoding.
+	UnableToDecode = "unable to decode"
+)
+
+// Parsing errors.
+var (
+	// ErrASNotSet indicates the AS in the protected meta data is not set.
+	ErrASNotSet = errors.New("AS not set")
+	// ErrSignatureTypeNotSet indicates the signature type in the protected
+	// meta data is not set.
+	ErrSignatureTypeNotSet = errors.New("signature type not set")
+	// ErrPayloadNotSet indicates the payload of the signed TRC is not set.
+	ErrPayloadNotSet = errors.New("payload not set")
+	// ErrSignaturesNotSet indicates the signatures of the signed TRC are not
+	// set.
+	ErrSignaturesNotSet = errors.New("signatures not set")
+	// ErrProtectedNotSet indicates the protected meta data of a signature is
+	// not set.
+	ErrProtectedNotSet = errors.New("protected not set")
+	// ErrSignatureNotSet indicates the signature value is not set.
+	ErrSignatureNotSet = errors.New("signature not set")
+)
+
+// Signed contains the encoded TRC payload and the signatures over it. It
+// follows the JWS JSON serialization (RFC 7515), where each signature covers
+// its own protected meta data and the encoded payload.
+type Signed struct {
+	// EncodedTRC is the base64url encoded JSON TRC payload.
+	EncodedTRC Encoded `json:"payload"`
+	// Signatures contains the votes and proofs of possession.
+	Signatures []Signature `json:"signatures"`
+}
+
+// ParseSigned parses the raw signed TRC.
+func ParseSigned(raw []byte) (Signed, error) {
+	var signed Signed
+	if err := json.Unmarshal(raw, &signed); err != nil {
+		return Signed{}, err
+	}
+	return signed, nil
+}
+
+// Encode encodes the signed TRC in the JSON serialization.
+func (s Signed) Encode() ([]byte, error) {
+	return json.Marshal(s)
+}
+
+// UnmarshalJSON checks that all fields are set.
+func (s *Signed) UnmarshalJSON(b []byte) error {
+	var alias signedAlias
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&alias); err != nil {
+		return err
+	}
+	if err := alias.checkAllSet(); err != nil {
+		return err
+	}
+	*s = Signed{
+		EncodedTRC: *alias.EncodedTRC,
+		Signatures: *alias.Signatures,
+	}
+	return nil
+}
+
+type signedAlias struct {
+	EncodedTRC *Encoded     `json:"payload"`
+	Signatures *[]Signature `json:"signatures"`
+}
+
+func (s *signedAlias) checkAllSet() error {
+	switch {
+	case s.EncodedTRC == nil:
+		return ErrPayloadNotSet
+	case s.Signatures == nil:
+		return ErrSignaturesNotSet
+	}
+	return nil
+}
+
+// Encoded is the base64url encoded JSON TRC payload.
+type Encoded []byte
+
+// Encode encodes the TRC payload.
+func Encode(trc *TRC) (Encoded, error) {
+	raw, err := json.Marshal(trc)
+	if err != nil {
+		return nil, err
+	}
+	return Encoded(encode(raw)), nil
+}
+
+// Decode decodes the TRC payload.
+func (e Encoded) Decode() (*TRC, error) {
+	raw, err := decode(e)
+	if err != nil {
+		return nil, err
+	}
+	var trc TRC
+	if err := json.Unmarshal(raw, &trc); err != nil {
+		return nil, err
+	}
+	return &trc, nil
+}
+
+// MarshalJSON marshals the encoded payload as a JSON string.
+func (e Encoded) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(e))
+}
+
+// UnmarshalJSON unmarshals the encoded payload from a JSON string.
+func (e *Encoded) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*e = Encoded(s)
+	return nil
+}
+
+// Signature contains the signature of one key over the TRC payload.
+type Signature struct {
+	// EncodedProtected is the base64url encoded protected meta data.
+	EncodedProtected EncodedProtected `json:"protected"`
+	// Signature is the raw signature.
+	Signature common.RawBytes `json:"signature"`
+}
+
+// MarshalJSON encodes the signature value with base64url.
+func (s Signature) MarshalJSON() ([]byte, error) {
+	sig := string(encode(s.Signature))
+	return json.Marshal(signatureAlias{EncodedProtected: &s.EncodedProtected, Signature: &sig})
+}
+
+// UnmarshalJSON checks that all fields are set and decodes the signature
+// value.
+func (s *Signature) UnmarshalJSON(b []byte) error {
+	var alias signatureAlias
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&alias); err != nil {
+		return err
+	}
+	if err := alias.checkAllSet(); err != nil {
+		return err
+	}
+	sig, err := decode([]byte(*alias.Signature))
+	if err != nil {
+		return err
+	}
+	*s = Signature{
+		EncodedProtected: *alias.EncodedProtected,
+		Signature:        sig,
+	}
+	return nil
+}
+
+type signatureAlias struct {
+	EncodedProtected *EncodedProtected `json:"protected"`
+	Signature        *string           `json:"signature"`
+}
+
+func (s *signatureAlias) checkAllSet() error {
+	switch {
+	case s.EncodedProtected == nil:
+		return ErrProtectedNotSet
+	case s.Signature == nil:
+		return ErrSignatureNotSet
+	}
+	return nil
+}
+
+// Sign creates a signature over the encoded TRC payload with the provided
+// protected meta data.
+func Sign(protected Protected, trc Encoded, key common.RawBytes) (Signature, error) {
+	encProtected, err := EncodeProtected(protected)
+	if err != nil {
+		return Signature{}, err
+	}
+	sig, err := scrypto.Sign(SigInput(encProtected, trc), key, protected.Algorithm)
+	if err != nil {
+		return Signature{}, err
+	}
+	return Signature{EncodedProtected: encProtected, Signature: sig}, nil
+}
+
+// SigInput computes the signature input according to RFC 7515.
+func SigInput(protected EncodedProtected, trc Encoded) common.RawBytes {
+	input := make(common.RawBytes, len(protected)+len(trc)+1)
+	n := copy(input, protected)
+	input[n] = '.'
+	copy(input[n+1:], trc)
+	return input
+}
+
+// EncodedProtected is the base64url encoded protected meta data.
+type EncodedProtected []byte
+
+// EncodeProtected encodes the protected meta data.
+func EncodeProtected(p Protected) (EncodedProtected, error) {
+	raw, err := json.Marshal(p)
+	if err != nil {
+		return nil, err
+	}
+	return EncodedProtected(encode(raw)), nil
+}
+
+// Decode decodes the protected meta data.
+func (e EncodedProtected) Decode() (Protected, error) {
+	raw, err := decode(e)
+	if err != nil {
+		return Protected{}, err
+	}
+	var p Protected
+	if err := json.Unmarshal(raw, &p); err != nil {
+		return Protected{}, err
+	}
+	return p, nil
+}
+
+// MarshalJSON marshals the encoded meta data as a JSON string.
+func (e EncodedProtected) MarshalJSON() ([]byte, error) {
+	return json.Marshal(string(e))
+}
+
+// UnmarshalJSON unmarshals the encoded meta data from a JSON string.
+func (e *EncodedProtected) UnmarshalJSON(b []byte) error {
+	var s string
+	if err := json.Unmarshal(b, &s); err != nil {
+		return err
+	}
+	*e = EncodedProtected(s)
+	return nil
+}
+
+// Protected is the signature meta data that is covered by the signature.
+type Protected struct {
+	// Algorithm is the signing algorithm.
+	Algorithm string `json:"alg"`
+	// Type indicates whether the signature is a vote or a proof of possession.
+	Type SignatureType `json:"Type"`
+	// KeyType is the type of the key that issues the signature.
+	KeyType KeyType `json:"KeyType"`
+	// KeyVersion is the version of the key that issues the signature.
+	KeyVersion KeyVersion `json:"KeyVersion"`
+	// AS is the primary AS that issues the signature.
+	AS addr.AS `json:"AS"`
+}
+
+// UnmarshalJSON checks that all fields are set.
+func (p *Protected) UnmarshalJSON(b []byte) error {
+	var alias protectedAlias
+	dec := json.NewDecoder(bytes.NewReader(b))
+	dec.DisallowUnknownFields()
+	if err := dec.Decode(&alias); err != nil {
+		return err
+	}
+	if err := alias.checkAllSet(); err != nil {
+		return err
+	}
+	*p = Protected{
+		Algorithm:  *alias.Algorithm,
+		Type:       *alias.Type,
+		KeyType:    *alias.KeyType,
+		KeyVersion: *alias.KeyVersion,
+		AS:         *alias.AS,
+	}
+	return nil
+}
+
+type protectedAlias struct {
+	Algorithm  *string        `json:"alg"`
+	Type       *SignatureType `json:"Type"`
+	KeyType    *KeyType       `json:"KeyType"`
+	KeyVersion *KeyVersion    `json:"KeyVersion"`
+	AS         *addr.AS       `json:"AS"`
+}
+
+func (p *protectedAlias) checkAllSet() error {
+	switch {
+	case p.Algorithm == nil:
+		return ErrAlgorithmNotSet
+	case p.Type == nil:
+		return ErrSignatureTypeNotSet
+	case p.KeyType == nil:
+		return ErrTypeNotSet
+	case p.KeyVersion == nil:
+		return ErrKeyVersionNotSet
+	case p.AS == nil:
+		return ErrASNotSet
+	}
+	return nil
+}
+
+const (
+	// VoteSignature indicates a vote cast by a voting AS.
+	VoteSignature SignatureType = "Vote"
+	// POPSignature indicates a proof of possession of a fresh or modified key.
+	POPSignature SignatureType = "ProofOfPossession"
+)
+
+var _ json.Unmarshaler = (*SignatureType)(nil)
+
+// SignatureType indicates the purpose of a signature. It can either be "Vote"
+// or "ProofOfPossession".
+type SignatureType string
+
+// UnmarshalJSON checks that the signature type is valid.
+func (t *SignatureType) UnmarshalJSON(b []byte) error {
+	switch SignatureType(strings.Trim(string(b), `"`)) {
+	case VoteSignature:
+		*t = VoteSignature
+	case POPSignature:
+		*t = POPSignature
+	default:
+		return common.NewBasicError(InvalidSignatureType, nil, "input", string(b))
+	}
+	return nil
+}
+
+func encode(raw []byte) []byte {
+	enc := make([]byte, base64.RawURLEncoding.EncodedLen(len(raw)))
+	base64.RawURLEncoding.Encode(enc, raw)
+	return enc
+}
+
+func decode(enc []byte) ([]byte, error) {
+	raw := make([]byte, base64.RawURLEncoding.DecodedLen(len(enc)))
+	n, err := base64.RawURLEncoding.Decode(raw, enc)
+	if err != nil {
+		return nil, common.NewBasicError(UnableToDecode, err)
+	}
+	return raw[:n], nil
+}
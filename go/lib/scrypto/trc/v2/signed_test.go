@@ -0,0 +1,162 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trc_test
+
+import (
+	"encoding/base64"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/scionproto/scion/go/lib/scrypto"
+	trc "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
+)
+
+func TestSignedEncode(t *testing.T) {
+	base := newBaseTRC()
+	encoded, err := trc.Encode(base)
+	require.NoError(t, err)
+	signed := trc.Signed{
+		EncodedTRC: encoded,
+		Signatures: []trc.Signature{
+			{
+				EncodedProtected: trc.EncodedProtected("protected"),
+				Signature:        []byte{0xff, 0x00, 0x01},
+			},
+		},
+	}
+	raw, err := signed.Encode()
+	require.NoError(t, err)
+	parsed, err := trc.ParseSigned(raw)
+	require.NoError(t, err)
+	assert.Equal(t, signed, parsed)
+	decoded, err := parsed.EncodedTRC.Decode()
+	require.NoError(t, err)
+	assert.Equal(t, base, decoded)
+}
+
+func TestParseSigned(t *testing.T) {
+	tests := map[string]struct {
+		Input          string
+		ExpectedErrMsg string
+	}{
+		"valid": {
+			Input: `{"payload": "dHJj", "signatures": [{"protected": "cA", "signature": "c2ln"}]}`,
+		},
+		"payload not set": {
+			Input:          `{"signatures": []}`,
+			ExpectedErrMsg: trc.ErrPayloadNotSet.Error(),
+		},
+		"signatures not set": {
+			Input:          `{"payload": "dHJj"}`,
+			ExpectedErrMsg: trc.ErrSignaturesNotSet.Error(),
+		},
+		"protected not set": {
+			Input:          `{"payload": "dHJj", "signatures": [{"signature": "c2ln"}]}`,
+			ExpectedErrMsg: trc.ErrProtectedNotSet.Error(),
+		},
+		"signature not set": {
+			Input:          `{"payload": "dHJj", "signatures": [{"protected": "cA"}]}`,
+			ExpectedErrMsg: trc.ErrSignatureNotSet.Error(),
+		},
+		"signature not base64url": {
+			Input: `{"payload": "dHJj",
+				"signatures": [{"protected": "cA", "signature": "+"}]}`,
+			ExpectedErrMsg: trc.UnableToDecode,
+		},
+		"unknown field": {
+			Input:          `{"payload": "dHJj", "signatures": [], "UnknownField": "UNKNOWN"}`,
+			ExpectedErrMsg: `json: unknown field "UnknownField"`,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			_, err := trc.ParseSigned([]byte(test.Input))
+			if test.ExpectedErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedErrMsg)
+			}
+		})
+	}
+}
+
+func TestEncodedProtectedDecode(t *testing.T) {
+	tests := map[string]struct {
+		Input          string
+		Protected      trc.Protected
+		ExpectedErrMsg string
+	}{
+		"valid": {
+			Input: `{"alg": "ed25519", "Type": "Vote", "KeyType": "Online", "KeyVersion": 1,
+				"AS": "ff00:0:110"}`,
+			Protected: trc.Protected{
+				Algorithm:  scrypto.Ed25519,
+				Type:       trc.VoteSignature,
+				KeyType:    trc.OnlineKey,
+				KeyVersion: 1,
+				AS:         a110,
+			},
+		},
+		"algorithm not set": {
+			Input: `{"Type": "Vote", "KeyType": "Online", "KeyVersion": 1,
+				"AS": "ff00:0:110"}`,
+			ExpectedErrMsg: trc.ErrAlgorithmNotSet.Error(),
+		},
+		"type not set": {
+			Input: `{"alg": "ed25519", "KeyType": "Online", "KeyVersion": 1,
+				"AS": "ff00:0:110"}`,
+			ExpectedErrMsg: trc.ErrSignatureTypeNotSet.Error(),
+		},
+		"invalid type": {
+			Input: `{"alg": "ed25519", "Type": "Unknown", "KeyType": "Online", "KeyVersion": 1,
+				"AS": "ff00:0:110"}`,
+			ExpectedErrMsg: trc.InvalidSignatureType,
+		},
+		"key type not set": {
+			Input: `{"alg": "ed25519", "Type": "ProofOfPossession", "KeyVersion": 1,
+				"AS": "ff00:0:110"}`,
+			ExpectedErrMsg: trc.ErrTypeNotSet.Error(),
+		},
+		"key version not set": {
+			Input: `{"alg": "ed25519", "Type": "ProofOfPossession", "KeyType": "Online",
+				"AS": "ff00:0:110"}`,
+			ExpectedErrMsg: trc.ErrKeyVersionNotSet.Error(),
+		},
+		"AS not set": {
+			Input: `{"alg": "ed25519", "Type": "ProofOfPossession", "KeyType": "Online",
+				"KeyVersion": 1}`,
+			ExpectedErrMsg: trc.ErrASNotSet.Error(),
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			p, err := trc.EncodedProtected(base64URL(test.Input)).Decode()
+			if test.ExpectedErrMsg == "" {
+				require.NoError(t, err)
+				assert.Equal(t, test.Protected, p)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedErrMsg)
+			}
+		})
+	}
+}
+
+func base64URL(s string) string {
+	return base64.RawURLEncoding.EncodeToString([]byte(s))
+}
@@ -0,0 +1,215 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trc
+
+import (
+	"errors"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/scrypto"
+)
+
+// Verification errors with context.
+const (
+	// DuplicateSignature indicates that a signature is attached twice.
+	DuplicateSignature = "duplicate signature"
+	// InvalidProtected indicates invalid protected meta data.
+	InvalidProtected = "invalid protected meta data"
+	// InvalidSignature indicates a signature that does not verify.
+	InvalidSignature = "invalid signature"
+	// MissingSignature indicates that a vote or proof of possession is not
+	// backed by a signature.
+	MissingSignature = "missing signature"
+	// UnexpectedSignature indicates a signature that is neither backed by a
+	// vote nor a proof of possession.
+	UnexpectedSignature = "unexpected signature"
+	// WrongKeyVersion indicates that the signature is issued with the wrong
+	// key version.
+	WrongKeyVersion = "signature with wrong key version"
+	// WrongSignatureAlgorithm indicates that the signature algorithm does not
+	// match the algorithm of the key.
+	WrongSignatureAlgorithm = "signature algorithm does not match key"
+)
+
+// Verification errors.
+var (
+	// ErrNotBase indicates that the TRC is not a base TRC.
+	ErrNotBase = errors.New("not a base TRC")
+	// ErrUnknownKey indicates that the signing key is not part of the TRC.
+	ErrUnknownKey = errors.New("unknown key")
+)
+
+// BaseVerifier verifies a base TRC. A base TRC does not carry any votes, all
+// its keys must show proof of possession.
+type BaseVerifier struct {
+	// TRC is the decoded base TRC.
+	TRC *TRC
+	// Encoded is the encoded payload that has been decoded into TRC.
+	Encoded Encoded
+	// Signatures are the signatures attached to the payload.
+	Signatures []Signature
+}
+
+// Verify checks that the TRC invariant holds and that all proofs of
+// possession are backed by a valid signature.
+func (v BaseVerifier) Verify() error {
+	if !v.TRC.Base() {
+		return ErrNotBase
+	}
+	if err := v.TRC.ValidateInvariant(); err != nil {
+		return common.NewBasicError(InvariantViolation, err)
+	}
+	sv := sigVerifier{
+		Next:       v.TRC,
+		Encoded:    v.Encoded,
+		Signatures: v.Signatures,
+	}
+	return sv.verify()
+}
+
+// UpdateVerifier verifies a TRC update. It validates the update against the
+// previous TRC and verifies the votes with the keys of the previous TRC, and
+// the proofs of possession with the keys of the updated TRC.
+type UpdateVerifier struct {
+	// Prev is the previous TRC. Its version must be Next.Version - 1.
+	Prev *TRC
+	// Next is the decoded updated TRC.
+	Next *TRC
+	// NextEncoded is the encoded payload that has been decoded into Next.
+	NextEncoded Encoded
+	// Signatures are the signatures attached to the updated payload.
+	Signatures []Signature
+}
+
+// Verify verifies the TRC update. In case it is valid, the update
+// information is returned.
+func (v UpdateVerifier) Verify() (UpdateInfo, error) {
+	validator := UpdateValidator{
+		Prev: v.Prev,
+		Next: v.Next,
+	}
+	info, err := validator.Validate()
+	if err != nil {
+		return info, err
+	}
+	sv := sigVerifier{
+		Prev:       v.Prev,
+		Next:       v.Next,
+		Encoded:    v.NextEncoded,
+		Signatures: v.Signatures,
+	}
+	return info, sv.verify()
+}
+
+type sigVerifier struct {
+	Prev       *TRC
+	Next       *TRC
+	Encoded    Encoded
+	Signatures []Signature
+}
+
+func (v *sigVerifier) verify() error {
+	votes := make(map[addr.AS]struct{}, len(v.Next.Votes))
+	pops := make(map[addr.AS]keyTypeSet, len(v.Next.ProofOfPossession))
+	for _, sig := range v.Signatures {
+		p, err := sig.EncodedProtected.Decode()
+		if err != nil {
+			return common.NewBasicError(InvalidProtected, err)
+		}
+		switch p.Type {
+		case VoteSignature:
+			if _, ok := votes[p.AS]; ok {
+				return common.NewBasicError(DuplicateSignature, nil, "AS", p.AS, "type", p.Type)
+			}
+			votes[p.AS] = struct{}{}
+		case POPSignature:
+			if _, ok := pops[p.AS][p.KeyType]; ok {
+				return common.NewBasicError(DuplicateSignature, nil, "AS", p.AS, "type", p.Type,
+					"keyType", p.KeyType)
+			}
+			if pops[p.AS] == nil {
+				pops[p.AS] = make(keyTypeSet)
+			}
+			pops[p.AS][p.KeyType] = struct{}{}
+		}
+		if err := v.verifySignature(p, sig); err != nil {
+			return common.NewBasicError(InvalidSignature, err, "AS", p.AS, "type", p.Type,
+				"keyType", p.KeyType)
+		}
+	}
+	for as := range v.Next.Votes {
+		if _, ok := votes[as]; !ok {
+			return common.NewBasicError(MissingSignature, nil, "AS", as, "type", VoteSignature)
+		}
+	}
+	for as, keyTypes := range v.Next.ProofOfPossession {
+		for _, keyType := range keyTypes {
+			if _, ok := pops[as][keyType]; !ok {
+				return common.NewBasicError(MissingSignature, nil, "AS", as,
+					"type", POPSignature, "keyType", keyType)
+			}
+		}
+	}
+	return nil
+}
+
+func (v *sigVerifier) verifySignature(p Protected, sig Signature) error {
+	key, err := v.key(p)
+	if err != nil {
+		return err
+	}
+	if key.KeyVersion != p.KeyVersion {
+		return common.NewBasicError(WrongKeyVersion, nil,
+			"expected", key.KeyVersion, "actual", p.KeyVersion)
+	}
+	if key.Algorithm != p.Algorithm {
+		return common.NewBasicError(WrongSignatureAlgorithm, nil,
+			"expected", key.Algorithm, "actual", p.Algorithm)
+	}
+	return scrypto.Verify(SigInput(sig.EncodedProtected, v.Encoded), sig.Signature,
+		key.Key, key.Algorithm)
+}
+
+// key returns the key that is expected to issue the signature. Votes are cast
+// with keys of the previous TRC, proofs of possession are shown for keys of
+// the updated TRC.
+func (v *sigVerifier) key(p Protected) (KeyMeta, error) {
+	var primaries PrimaryASes
+	switch p.Type {
+	case VoteSignature:
+		vote, ok := v.Next.Votes[p.AS]
+		if !ok || v.Prev == nil {
+			return KeyMeta{}, common.NewBasicError(UnexpectedSignature, nil)
+		}
+		if vote.Type != p.KeyType {
+			return KeyMeta{}, common.NewBasicError(WrongVotingKeyType, nil,
+				"expected", vote.Type, "actual", p.KeyType)
+		}
+		primaries = v.Prev.PrimaryASes
+	case POPSignature:
+		if !hasPop(v.Next.ProofOfPossession[p.AS], p.KeyType) {
+			return KeyMeta{}, common.NewBasicError(UnexpectedSignature, nil)
+		}
+		primaries = v.Next.PrimaryASes
+	default:
+		return KeyMeta{}, common.NewBasicError(InvalidSignatureType, nil, "type", p.Type)
+	}
+	key, ok := primaries[p.AS].Keys[p.KeyType]
+	if !ok {
+		return KeyMeta{}, ErrUnknownKey
+	}
+	return key, nil
+}
@@ -0,0 +1,321 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trc_test
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/stretchr/testify/require"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/scrypto"
+	trc "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
+)
+
+func TestBaseVerifierVerify(t *testing.T) {
+	tests := map[string]struct {
+		Modify         func(t *testing.T, base *trc.TRC, keys privKeys)
+		Signatures     func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature
+		ExpectedErrMsg string
+	}{
+		"valid": {
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.pops(t, base)
+			},
+		},
+		"not base": {
+			Modify: func(_ *testing.T, base *trc.TRC, _ privKeys) {
+				base.Version = 2
+			},
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.pops(t, base)
+			},
+			ExpectedErrMsg: trc.ErrNotBase.Error(),
+		},
+		"invariant violation": {
+			Modify: func(_ *testing.T, base *trc.TRC, _ privKeys) {
+				*base.VotingQuorumPtr = 0
+			},
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.pops(t, base)
+			},
+			ExpectedErrMsg: trc.InvariantViolation,
+		},
+		"missing proof of possession": {
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.pops(t, base)[1:]
+			},
+			ExpectedErrMsg: trc.MissingSignature,
+		},
+		"duplicate proof of possession": {
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.pops(t, base)
+				return append(sigs, sigs[0])
+			},
+			ExpectedErrMsg: trc.DuplicateSignature,
+		},
+		"signed with wrong key": {
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				keys[a110][trc.OnlineKey] = keys[a120][trc.OnlineKey]
+				return keys.pops(t, base)
+			},
+			ExpectedErrMsg: trc.InvalidSignature,
+		},
+		"wrong key version": {
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.pops(t, base)
+				sigs[0] = keys.sign(t, base, trc.Protected{
+					Algorithm:  scrypto.Ed25519,
+					Type:       trc.POPSignature,
+					KeyType:    trc.OnlineKey,
+					KeyVersion: 2,
+					AS:         a110,
+				})
+				return sigs
+			},
+			ExpectedErrMsg: trc.WrongKeyVersion,
+		},
+		"unexpected vote": {
+			Signatures: func(t *testing.T, base *trc.TRC, keys privKeys) []trc.Signature {
+				return append(keys.pops(t, base), keys.votes(t, base, trc.OnlineKey, a110)...)
+			},
+			ExpectedErrMsg: trc.UnexpectedSignature,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			base := newBaseTRC()
+			keys := newPrivKeys(t, base)
+			if test.Modify != nil {
+				test.Modify(t, base, keys)
+			}
+			encoded, err := trc.Encode(base)
+			require.NoError(t, err)
+			v := trc.BaseVerifier{
+				TRC:        base,
+				Encoded:    encoded,
+				Signatures: test.Signatures(t, base, keys),
+			}
+			err = v.Verify()
+			if test.ExpectedErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedErrMsg)
+			}
+		})
+	}
+}
+
+func TestUpdateVerifierVerify(t *testing.T) {
+	tests := map[string]struct {
+		Modify         func(t *testing.T, next *trc.TRC, keys privKeys)
+		Signatures     func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature
+		ExpectedErrMsg string
+	}{
+		"regular update": {
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.votes(t, next, trc.OnlineKey, a110, a120, a140)
+			},
+		},
+		"regular update with modified issuing key": {
+			Modify: func(t *testing.T, next *trc.TRC, keys privKeys) {
+				keys.replace(t, next, a110, trc.IssuingKey)
+				next.ProofOfPossession = map[addr.AS][]trc.KeyType{
+					a110: {trc.IssuingKey},
+				}
+			},
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.votes(t, next, trc.OnlineKey, a110, a120, a140)
+				return append(sigs, keys.pops(t, next)...)
+			},
+		},
+		"proof of possession with previous key": {
+			Modify: func(t *testing.T, next *trc.TRC, keys privKeys) {
+				prevKey := keys[a110][trc.IssuingKey]
+				keys.replace(t, next, a110, trc.IssuingKey)
+				keys[a110][trc.IssuingKey] = prevKey
+				next.ProofOfPossession = map[addr.AS][]trc.KeyType{
+					a110: {trc.IssuingKey},
+				}
+			},
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.votes(t, next, trc.OnlineKey, a110, a120, a140)
+				return append(sigs, keys.pops(t, next)...)
+			},
+			ExpectedErrMsg: trc.InvalidSignature,
+		},
+		"invalid update": {
+			Modify: func(_ *testing.T, next *trc.TRC, _ privKeys) {
+				next.Version = 3
+			},
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.votes(t, next, trc.OnlineKey, a110, a120, a140)
+			},
+			ExpectedErrMsg: trc.InvalidVersionIncrement,
+		},
+		"missing vote": {
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.votes(t, next, trc.OnlineKey, a110, a120)
+			},
+			ExpectedErrMsg: trc.MissingSignature,
+		},
+		"duplicate vote": {
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				return keys.votes(t, next, trc.OnlineKey, a110, a120, a140, a140)
+			},
+			ExpectedErrMsg: trc.DuplicateSignature,
+		},
+		"vote with wrong key type": {
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.votes(t, next, trc.OnlineKey, a110, a120)
+				return append(sigs, keys.votes(t, next, trc.OfflineKey, a140)...)
+			},
+			ExpectedErrMsg: trc.WrongVotingKeyType,
+		},
+		"vote from AS without vote entry": {
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.votes(t, next, trc.OnlineKey, a110, a120, a140)
+				return append(sigs, keys.votes(t, next, trc.IssuingKey, a130)...)
+			},
+			ExpectedErrMsg: trc.UnexpectedSignature,
+		},
+		"vote over different payload": {
+			Signatures: func(t *testing.T, next *trc.TRC, keys privKeys) []trc.Signature {
+				sigs := keys.votes(t, next, trc.OnlineKey, a110, a120, a140)
+				other := *next
+				other.Description = "other"
+				sigs[0] = keys.votes(t, &other, trc.OnlineKey, a110)[0]
+				return sigs
+			},
+			ExpectedErrMsg: trc.InvalidSignature,
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			next, prev := newRegularUpdate()
+			keys := newPrivKeys(t, prev)
+			keys.setPubKeys(next)
+			if test.Modify != nil {
+				test.Modify(t, next, keys)
+			}
+			encoded, err := trc.Encode(next)
+			require.NoError(t, err)
+			v := trc.UpdateVerifier{
+				Prev:        prev,
+				Next:        next,
+				NextEncoded: encoded,
+				Signatures:  test.Signatures(t, next, keys),
+			}
+			_, err = v.Verify()
+			if test.ExpectedErrMsg == "" {
+				assert.NoError(t, err)
+			} else {
+				require.Error(t, err)
+				assert.Contains(t, err.Error(), test.ExpectedErrMsg)
+			}
+		})
+	}
+}
+
+// privKeys maps primary ASes to their private keys.
+type privKeys map[addr.AS]map[trc.KeyType]common.RawBytes
+
+// newPrivKeys generates a private key for every key in the TRC, and sets the
+// public keys in the TRC.
+func newPrivKeys(t *testing.T, trcObj *trc.TRC) privKeys {
+	keys := make(privKeys)
+	for as, primary := range trcObj.PrimaryASes {
+		keys[as] = make(map[trc.KeyType]common.RawBytes)
+		for keyType, meta := range primary.Keys {
+			meta.Key, keys[as][keyType] = genKey(t)
+			primary.Keys[keyType] = meta
+		}
+	}
+	return keys
+}
+
+// replace generates a fresh key and sets it in the TRC with an incremented
+// key version.
+func (k privKeys) replace(t *testing.T, trcObj *trc.TRC, as addr.AS, keyType trc.KeyType) {
+	meta := trcObj.PrimaryASes[as].Keys[keyType]
+	meta.Key, k[as][keyType] = genKey(t)
+	meta.KeyVersion++
+	trcObj.PrimaryASes[as].Keys[keyType] = meta
+}
+
+// setPubKeys sets the public keys in the TRC. The key versions are not
+// modified.
+func (k privKeys) setPubKeys(trcObj *trc.TRC) {
+	for as, keys := range k {
+		for keyType, priv := range keys {
+			meta := trcObj.PrimaryASes[as].Keys[keyType]
+			// The ed25519 private key contains the public key.
+			meta.Key = common.RawBytes(priv[32:])
+			trcObj.PrimaryASes[as].Keys[keyType] = meta
+		}
+	}
+}
+
+// pops returns the proofs of possession required by the TRC.
+func (k privKeys) pops(t *testing.T, trcObj *trc.TRC) []trc.Signature {
+	var sigs []trc.Signature
+	for _, as := range []addr.AS{a110, a120, a130, a140} {
+		for _, keyType := range trcObj.ProofOfPossession[as] {
+			sigs = append(sigs, k.sign(t, trcObj, trc.Protected{
+				Algorithm:  scrypto.Ed25519,
+				Type:       trc.POPSignature,
+				KeyType:    keyType,
+				KeyVersion: trcObj.PrimaryASes[as].Keys[keyType].KeyVersion,
+				AS:         as,
+			}))
+		}
+	}
+	return sigs
+}
+
+// votes returns votes cast with the provided key type. The key version is
+// always 1.
+func (k privKeys) votes(t *testing.T, trcObj *trc.TRC, keyType trc.KeyType,
+	ases ...addr.AS) []trc.Signature {
+
+	var sigs []trc.Signature
+	for _, as := range ases {
+		sigs = append(sigs, k.sign(t, trcObj, trc.Protected{
+			Algorithm:  scrypto.Ed25519,
+			Type:       trc.VoteSignature,
+			KeyType:    keyType,
+			KeyVersion: 1,
+			AS:         as,
+		}))
+	}
+	return sigs
+}
+
+func (k privKeys) sign(t *testing.T, trcObj *trc.TRC, p trc.Protected) trc.Signature {
+	encoded, err := trc.Encode(trcObj)
+	require.NoError(t, err)
+	sig, err := trc.Sign(p, encoded, k[p.AS][p.KeyType])
+	require.NoError(t, err)
+	return sig
+}
+
+func genKey(t *testing.T) (common.RawBytes, common.RawBytes) {
+	pub, priv, err := scrypto.GenKeyPair(scrypto.Ed25519)
+	require.NoError(t, err)
+	return pub, priv
+}
@@ -0,0 +1,168 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snet
+
+import (
+	"math/rand"
+	"net"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/overlay/conn"
+)
+
+var _ PacketDispatcherService = (*DirectPacketDispatcherService)(nil)
+
+// DirectPacketDispatcherService creates SCION sockets that bypass the
+// dispatcher. Each socket is an overlay UDP socket that is bound to the public
+// address of the application, with the SCION/UDP port also used as overlay
+// port. The port must be in Ports, the range of ports for which the border
+// routers of the local AS deliver packets directly to the port instead of to
+// the dispatcher.
+//
+// Routers deliver SCMP errors caused by packets sent from a direct socket to
+// the socket, based on the quoted UDP source port. Replies to SCMP General
+// requests are delivered to the socket if the request ID was created with
+// overlay.DirectSCMPID for the port of the socket. SCMP General requests to
+// the host are still answered by the dispatcher.
+//
+// Hosts inside the local AS send their packets to the dispatcher port, so
+// direct sockets only receive traffic from remote ASes.
+type DirectPacketDispatcherService struct {
+	// Ports is the range of ports that sockets can bind to.
+	Ports overlay.PortRange
+	// SCMPHandler is invoked for packets that contain an SCMP L4. If the
+	// handler is nil, errors are returned back to applications every time an
+	// SCMP message is received.
+	SCMPHandler SCMPHandler
+	// Config customizes the overlay sockets. If nil, the defaults are used.
+	Config *conn.Config
+}
+
+// RegisterTimeout opens an overlay socket on the public address. If the port
+// of the public address is 0, a free port in Ports is chosen. Bind and SVC
+// addresses are not supported, and the timeout is ignored.
+func (s *DirectPacketDispatcherService) RegisterTimeout(ia addr.IA, public *addr.AppAddr,
+	bind *overlay.OverlayAddr, svc addr.HostSVC,
+	timeout time.Duration) (PacketConn, uint16, error) {
+
+	if public == nil || public.L3 == nil || public.L4 == nil {
+		return nil, 0, common.NewBasicError("Incomplete public address", nil, "public", public)
+	}
+	if bind != nil {
+		return nil, 0, common.NewBasicError("Bind addresses require the dispatcher", nil,
+			"bind", bind)
+	}
+	if svc != addr.SvcNone {
+		return nil, 0, common.NewBasicError("SVC addresses require the dispatcher", nil,
+			"svc", svc)
+	}
+	if err := s.Ports.ValidateDirect(); err != nil {
+		return nil, 0, err
+	}
+	if s.Ports.IsEmpty() {
+		return nil, 0, common.NewBasicError("No direct ports configured", nil)
+	}
+	var ports []uint16
+	if port := public.L4.Port(); port != 0 {
+		if !s.Ports.Contains(port) {
+			return nil, 0, common.NewBasicError("Port outside of direct port range", nil,
+				"port", port, "range", s.Ports)
+		}
+		ports = []uint16{port}
+	} else {
+		ports = s.candidatePorts()
+	}
+	var err error
+	for _, port := range ports {
+		var ov *overlay.OverlayAddr
+		ov, err = overlay.NewOverlayAddr(public.L3, addr.NewL4UDPInfo(port))
+		if err != nil {
+			return nil, 0, common.NewBasicError(ErrBadOverlay, err)
+		}
+		var c conn.Conn
+		if c, err = conn.New(ov, nil, s.Config); err == nil {
+			pconn := &SCIONPacketConn{
+				conn:        &overlayPacketConn{conn: c},
+				scmpHandler: s.SCMPHandler,
+			}
+			return pconn, port, nil
+		}
+	}
+	return nil, 0, common.NewBasicError("Unable to open direct socket", err,
+		"public", public, "range", s.Ports)
+}
+
+// candidatePorts returns all ports in the range, starting at a random port.
+func (s *DirectPacketDispatcherService) candidatePorts() []uint16 {
+	n := int(s.Ports.Max) - int(s.Ports.Min) + 1
+	start := rand.Intn(n)
+	ports := make([]uint16, n)
+	for i := range ports {
+		ports[i] = s.Ports.Min + uint16((start+i)%n)
+	}
+	return ports
+}
+
+var _ net.PacketConn = (*overlayPacketConn)(nil)
+
+// overlayPacketConn adapts an overlay socket to the net.PacketConn interface.
+// Addresses are overlay addresses.
+type overlayPacketConn struct {
+	conn conn.Conn
+}
+
+func (c *overlayPacketConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	n, meta, err := c.conn.Read(b)
+	if err != nil {
+		return 0, nil, err
+	}
+	if meta.Src == nil {
+		return 0, nil, common.NewBasicError("Unable to determine overlay source", nil)
+	}
+	// The overlay socket allocates a new source address for every read.
+	return n, meta.Src, nil
+}
+
+func (c *overlayPacketConn) WriteTo(b []byte, a net.Addr) (int, error) {
+	ov, ok := a.(*overlay.OverlayAddr)
+	if !ok {
+		return 0, common.NewBasicError("Unsupported address type", nil,
+			"type", common.TypeOf(a))
+	}
+	return c.conn.WriteTo(b, ov)
+}
+
+func (c *overlayPacketConn) Close() error {
+	return c.conn.Close()
+}
+
+func (c *overlayPacketConn) LocalAddr() net.Addr {
+	return c.conn.LocalAddr()
+}
+
+func (c *overlayPacketConn) SetDeadline(t time.Time) error {
+	return c.conn.SetDeadline(t)
+}
+
+func (c *overlayPacketConn) SetReadDeadline(t time.Time) error {
+	return c.conn.SetReadDeadline(t)
+}
+
+func (c *overlayPacketConn) SetWriteDeadline(t time.Time) error {
+	return c.conn.SetWriteDeadline(t)
+}
@@ -0,0 +1,521 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snet
+
+import (
+	"context"
+	"math/rand"
+	"net"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathmgr"
+	"github.com/scionproto/scion/go/lib/pathpol"
+	"github.com/scionproto/scion/go/lib/scmp"
+	"github.com/scionproto/scion/go/lib/spath"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+)
+
+const (
+	// DefaultProbeInterval is the default interval in which the paths of a
+	// MultipathConn are probed.
+	DefaultProbeInterval = time.Second
+	// DefaultProbeTimeout is the default time after which an unanswered probe
+	// counts as timed out.
+	DefaultProbeTimeout = time.Second
+	// DefaultMaxTimeouts is the default number of consecutive probe timeouts
+	// after which a path is no longer used.
+	DefaultMaxTimeouts = 3
+)
+
+// Possible multipath errors
+const (
+	ErrNoUsablePath = "no usable path"
+	ErrNoPathChosen = "no path selected"
+)
+
+// MultipathConfig configures a MultipathConn. Zero values are replaced by
+// the defaults.
+type MultipathConfig struct {
+	// Selector selects the path for each write. The default selects the path
+	// with the lowest RTT.
+	Selector PathSelector
+	// Filter, if set, restricts the paths that are watched.
+	Filter *pathpol.Policy
+	// ProbeInterval is the interval in which SCMP echo probes are sent on
+	// each path.
+	ProbeInterval time.Duration
+	// ProbeTimeout is the time after which an unanswered probe counts as
+	// timed out.
+	ProbeTimeout time.Duration
+	// MaxTimeouts is the number of consecutive probe timeouts after which a
+	// path is no longer used, until one of its probes is answered again.
+	MaxTimeouts int
+}
+
+func (cfg *MultipathConfig) initDefaults() {
+	if cfg.Selector == nil {
+		cfg.Selector = LowestRTTSelector{}
+	}
+	if cfg.ProbeInterval == 0 {
+		cfg.ProbeInterval = DefaultProbeInterval
+	}
+	if cfg.ProbeTimeout == 0 {
+		cfg.ProbeTimeout = DefaultProbeTimeout
+	}
+	if cfg.MaxTimeouts == 0 {
+		cfg.MaxTimeouts = DefaultMaxTimeouts
+	}
+}
+
+var _ net.Conn = (*MultipathConn)(nil)
+
+// MultipathConn is a connection to a fixed remote address that uses all the
+// paths to the remote AS. The paths are kept up to date by a path resolver
+// watch, and are probed with SCMP echo requests. For each write, the path is
+// chosen by the configured PathSelector among the usable paths. A path is not
+// usable once one of its interfaces is revoked via SCMP, or once its probes
+// timed out repeatedly. If no path is usable, all paths that are not revoked
+// are considered.
+//
+// Probe replies and SCMP revocations are processed by Read. Applications that
+// only write must therefore read from the connection in a separate goroutine.
+type MultipathConn struct {
+	scionConnBase
+	scionConnReader
+	conn   PacketConn
+	writer *scionConnWriter
+	cfg    MultipathConfig
+	sp     *pathmgr.SyncPaths
+	// id is the SCMP echo ID of the probes.
+	id uint64
+
+	mtx   sync.Mutex
+	state *multipathState
+
+	closeOnce sync.Once
+	closeC    chan struct{}
+}
+
+// DialMultipath returns a multipath connection from laddr to raddr. The
+// network must have a path resolver, and must use the default packet
+// dispatcher service. Parameter cfg can be nil, in which case the defaults
+// are used.
+func (n *SCIONNetwork) DialMultipath(laddr, raddr *Addr, cfg *MultipathConfig,
+	timeout time.Duration) (*MultipathConn, error) {
+
+	if raddr == nil {
+		return nil, common.NewBasicError("Unable to dial to nil remote", nil)
+	}
+	if raddr.Host == nil {
+		return nil, common.NewBasicError(ErrNoApplicationAddress, nil)
+	}
+	if raddr.IA.Equal(n.localIA) {
+		return nil, common.NewBasicError("Multipath is not supported in the local AS", nil)
+	}
+	if n.pathResolver == nil {
+		return nil, common.NewBasicError("Multipath requires a path resolver", nil)
+	}
+	dispatcher, ok := n.dispatcher.(*DefaultPacketDispatcherService)
+	if !ok {
+		return nil, common.NewBasicError("Multipath requires the default dispatcher service",
+			nil, "type", common.TypeOf(n.dispatcher))
+	}
+	c := &MultipathConn{
+		id:     rand.Uint64(),
+		closeC: make(chan struct{}),
+	}
+	if cfg != nil {
+		c.cfg = *cfg
+	}
+	c.cfg.initDefaults()
+	c.state = newMultipathState(c.cfg.MaxTimeouts)
+	// Intercept the probe replies and the revocations.
+	mpDispatcher := &DefaultPacketDispatcherService{
+		Dispatcher:  dispatcher.Dispatcher,
+		SCMPHandler: &multipathSCMPHandler{conn: c, next: dispatcher.SCMPHandler},
+	}
+	base, packetConn, err := n.listen(mpDispatcher, "udp4", laddr, nil, addr.SvcNone, timeout)
+	if err != nil {
+		return nil, err
+	}
+	base.raddr = raddr.Copy()
+	ctx := context.Background()
+	if timeout != 0 {
+		var cancelF context.CancelFunc
+		ctx, cancelF = context.WithTimeout(ctx, timeout)
+		defer cancelF()
+	}
+	sp, err := n.pathResolver.WatchFilter(ctx, base.laddr.IA, raddr.IA, c.cfg.Filter)
+	if err != nil {
+		packetConn.Close()
+		return nil, common.NewBasicError("Unable to watch paths", err)
+	}
+	c.scionConnBase = *base
+	c.scionConnReader = *newScionConnReader(&c.scionConnBase, packetConn)
+	c.conn = packetConn
+	c.writer = newScionConnWriter(&c.scionConnBase, n.pathResolver, packetConn)
+	c.sp = sp
+	go func() {
+		defer log.LogPanicAndExit()
+		c.probe()
+	}()
+	return c, nil
+}
+
+// Write sends b on the path chosen by the path selector.
+func (c *MultipathConn) Write(b []byte) (int, error) {
+	raddr, key, err := c.selectPath()
+	if err != nil {
+		return 0, err
+	}
+	n, err := c.writer.writeWithLock(b, raddr)
+	if err != nil {
+		return n, err
+	}
+	c.mtx.Lock()
+	c.state.written(key, n)
+	c.mtx.Unlock()
+	return n, nil
+}
+
+// Stats returns the statistics of the current paths, sorted by path key.
+func (c *MultipathConn) Stats() []PathStats {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.state.update(c.sp.Load())
+	return c.state.stats()
+}
+
+func (c *MultipathConn) SetDeadline(t time.Time) error {
+	if err := c.scionConnReader.SetReadDeadline(t); err != nil {
+		return err
+	}
+	return c.SetWriteDeadline(t)
+}
+
+func (c *MultipathConn) SetWriteDeadline(t time.Time) error {
+	return c.writer.SetWriteDeadline(t)
+}
+
+// Close stops probing and watching the paths, and closes the connection.
+func (c *MultipathConn) Close() error {
+	c.closeOnce.Do(func() {
+		close(c.closeC)
+		c.sp.Destroy()
+	})
+	return c.conn.Close()
+}
+
+func (c *MultipathConn) selectPath() (*Addr, spathmeta.PathKey, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.state.update(c.sp.Load())
+	paths := c.state.usable()
+	if len(paths) == 0 {
+		return nil, "", common.NewBasicError(ErrNoUsablePath, nil, "dst", c.raddr.IA)
+	}
+	i := c.cfg.Selector.Select(paths)
+	if i < 0 || i >= len(paths) {
+		return nil, "", common.NewBasicError(ErrNoPathChosen, nil, "dst", c.raddr.IA)
+	}
+	raddr, err := c.remoteAddr(paths[i].Path)
+	if err != nil {
+		return nil, "", err
+	}
+	return raddr, paths[i].Key(), nil
+}
+
+// remoteAddr returns the remote address with the path and next hop of ap.
+func (c *MultipathConn) remoteAddr(ap *spathmeta.AppPath) (*Addr, error) {
+	raddr := c.raddr.Copy()
+	raddr.Path = spath.New(ap.Entry.Path.FwdPath)
+	if err := raddr.Path.InitOffsets(); err != nil {
+		return nil, common.NewBasicError("Unable to initialize path", err)
+	}
+	var err error
+	if raddr.NextHop, err = ap.Entry.HostInfo.Overlay(); err != nil {
+		return nil, common.NewBasicError(ErrBadOverlay, err)
+	}
+	return raddr, nil
+}
+
+// probe periodically sends SCMP echo requests on all paths, until the
+// connection is closed.
+func (c *MultipathConn) probe() {
+	ticker := time.NewTicker(c.cfg.ProbeInterval)
+	defer ticker.Stop()
+	for {
+		select {
+		case <-c.closeC:
+			return
+		case now := <-ticker.C:
+			c.mtx.Lock()
+			c.state.update(c.sp.Load())
+			c.state.expireProbes(now.Add(-c.cfg.ProbeTimeout))
+			probes := c.state.newProbes(now)
+			c.mtx.Unlock()
+			for seq, ap := range probes {
+				if err := c.sendProbe(ap, seq); err != nil {
+					log.Debug("Unable to send path probe", "dst", c.raddr.IA, "err", err)
+				}
+			}
+		}
+	}
+}
+
+func (c *MultipathConn) sendProbe(ap *spathmeta.AppPath, seq uint16) error {
+	raddr, err := c.remoteAddr(ap)
+	if err != nil {
+		return err
+	}
+	info := &scmp.InfoEcho{Id: c.id, Seq: seq}
+	meta := scmp.Meta{InfoLen: uint8(info.Len() / common.LineLen)}
+	pld := make(common.RawBytes, scmp.MetaLen+info.Len())
+	meta.Write(pld)
+	info.Write(pld[scmp.MetaLen:])
+	pkt := &SCIONPacket{
+		SCIONPacketInfo: SCIONPacketInfo{
+			Destination: SCIONAddress{IA: raddr.IA, Host: raddr.Host.L3},
+			Source:      SCIONAddress{IA: c.laddr.IA, Host: c.laddr.Host.L3},
+			Path:        raddr.Path,
+			L4Header: scmp.NewHdr(
+				scmp.ClassType{Class: scmp.C_General, Type: scmp.T_G_EchoRequest}, len(pld)),
+			Payload: pld,
+		},
+	}
+	return c.conn.WriteTo(pkt, raddr.NextHop)
+}
+
+func (c *MultipathConn) probeReply(seq uint16) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.state.probeReply(seq, time.Now())
+}
+
+func (c *MultipathConn) revoke(ia addr.IA, ifid common.IFIDType) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if n := c.state.revoke(ia, ifid); n > 0 {
+		log.Info("Failing over, paths revoked", "dst", c.raddr.IA, "paths", n,
+			"ia", ia, "ifid", ifid)
+	}
+}
+
+// multipathSCMPHandler consumes the replies to the probes of conn, and
+// informs conn about revocations. All other SCMP messages, and the
+// revocations, are passed to next.
+type multipathSCMPHandler struct {
+	conn *MultipathConn
+	next SCMPHandler
+}
+
+func (h *multipathSCMPHandler) Handle(pkt *SCIONPacket) error {
+	hdr, ok := pkt.L4Header.(*scmp.Hdr)
+	if !ok {
+		return common.NewBasicError("scmp handler invoked with non-scmp packet", nil, "pkt", pkt)
+	}
+	scmpPayload, ok := pkt.Payload.(*scmp.Payload)
+	switch {
+	case !ok:
+	case hdr.Class == scmp.C_General && hdr.Type == scmp.T_G_EchoReply:
+		if info, ok := scmpPayload.Info.(*scmp.InfoEcho); ok && info.Id == h.conn.id {
+			h.conn.probeReply(info.Seq)
+			return nil
+		}
+	case hdr.Class == scmp.C_Path && hdr.Type == scmp.T_P_RevokedIF:
+		if info, ok := scmpPayload.Info.(*scmp.InfoRevocation); ok {
+			h.revoke(info.RawSRev)
+		}
+	}
+	if h.next == nil {
+		return nil
+	}
+	return h.next.Handle(pkt)
+}
+
+func (h *multipathSCMPHandler) revoke(rawSRev common.RawBytes) {
+	sRevInfo, err := path_mgmt.NewSignedRevInfoFromRaw(rawSRev)
+	if err != nil {
+		log.Warn("Unable to parse signed revocation", "err", err)
+		return
+	}
+	revInfo, err := sRevInfo.RevInfo()
+	if err != nil {
+		log.Warn("Unable to parse revocation info", "err", err)
+		return
+	}
+	h.conn.revoke(revInfo.IA(), revInfo.IfID)
+}
+
+// multipathState keeps the statistics of the paths of a MultipathConn. It is
+// not safe for concurrent use.
+type multipathState struct {
+	maxTimeouts int
+	paths       map[spathmeta.PathKey]*PathStats
+	modifyTime  time.Time
+	// probes maps the sequence numbers of the outstanding probes to the
+	// probed paths.
+	probes  map[uint16]probe
+	nextSeq uint16
+}
+
+type probe struct {
+	key  spathmeta.PathKey
+	sent time.Time
+}
+
+func newMultipathState(maxTimeouts int) *multipathState {
+	return &multipathState{
+		maxTimeouts: maxTimeouts,
+		paths:       make(map[spathmeta.PathKey]*PathStats),
+		probes:      make(map[uint16]probe),
+	}
+}
+
+// update adds and removes paths to match the watched paths. The statistics of
+// the remaining paths are kept.
+func (s *multipathState) update(data *pathmgr.SyncPathsData) {
+	if !s.modifyTime.IsZero() && s.modifyTime.Equal(data.ModifyTime) {
+		return
+	}
+	s.modifyTime = data.ModifyTime
+	for key := range s.paths {
+		if _, ok := data.APS[key]; !ok {
+			delete(s.paths, key)
+		}
+	}
+	for key, ap := range data.APS {
+		if stats, ok := s.paths[key]; ok {
+			stats.Path = ap
+		} else {
+			s.paths[key] = &PathStats{Path: ap}
+		}
+	}
+}
+
+// usable returns the paths that are neither revoked nor timed out. If there is
+// no such path, all paths that are not revoked are returned.
+func (s *multipathState) usable() []PathStats {
+	var usable, notRevoked []PathStats
+	for _, stats := range s.paths {
+		if stats.Revoked {
+			continue
+		}
+		notRevoked = append(notRevoked, *stats)
+		if stats.Timeouts < s.maxTimeouts {
+			usable = append(usable, *stats)
+		}
+	}
+	if len(usable) == 0 {
+		usable = notRevoked
+	}
+	sortPathStats(usable)
+	return usable
+}
+
+func (s *multipathState) stats() []PathStats {
+	stats := make([]PathStats, 0, len(s.paths))
+	for _, ps := range s.paths {
+		stats = append(stats, *ps)
+	}
+	sortPathStats(stats)
+	return stats
+}
+
+func (s *multipathState) written(key spathmeta.PathKey, n int) {
+	if stats, ok := s.paths[key]; ok {
+		stats.PacketsSent++
+		stats.BytesSent += uint64(n)
+	}
+}
+
+// newProbes registers a probe for every path, and returns the paths to probe
+// keyed by the sequence number of the probe.
+func (s *multipathState) newProbes(now time.Time) map[uint16]*spathmeta.AppPath {
+	probes := make(map[uint16]*spathmeta.AppPath, len(s.paths))
+	for key, stats := range s.paths {
+		seq := s.nextSeq
+		s.nextSeq++
+		s.probes[seq] = probe{key: key, sent: now}
+		stats.ProbesSent++
+		probes[seq] = stats.Path
+	}
+	return probes
+}
+
+// expireProbes counts the probes sent before deadline as timed out.
+func (s *multipathState) expireProbes(deadline time.Time) {
+	for seq, p := range s.probes {
+		if !p.sent.Before(deadline) {
+			continue
+		}
+		delete(s.probes, seq)
+		if stats, ok := s.paths[p.key]; ok {
+			stats.Timeouts++
+		}
+	}
+}
+
+func (s *multipathState) probeReply(seq uint16, now time.Time) {
+	p, ok := s.probes[seq]
+	if !ok {
+		return
+	}
+	delete(s.probes, seq)
+	stats, ok := s.paths[p.key]
+	if !ok {
+		return
+	}
+	rtt := now.Sub(p.sent)
+	if stats.RTT == 0 {
+		stats.RTT = rtt
+	} else {
+		stats.RTT = (7*stats.RTT + rtt) / 8
+	}
+	stats.ProbesAnswered++
+	stats.Timeouts = 0
+	stats.Revoked = false
+}
+
+// revoke marks the paths that contain the interface as revoked, and returns
+// the number of newly revoked paths.
+func (s *multipathState) revoke(ia addr.IA, ifid common.IFIDType) int {
+	revoked := 0
+	for _, stats := range s.paths {
+		if stats.Revoked {
+			continue
+		}
+		for _, iface := range stats.Path.Entry.Path.Interfaces {
+			if iface.IfID == ifid && iface.ISD_AS().Equal(ia) {
+				stats.Revoked = true
+				revoked++
+				break
+			}
+		}
+	}
+	return revoked
+}
+
+func sortPathStats(stats []PathStats) {
+	sort.Slice(stats, func(i, j int) bool {
+		return stats[i].Key() < stats[j].Key()
+	})
+}
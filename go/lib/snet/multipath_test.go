@@ -0,0 +1,149 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snet
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/pathmgr"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+func mustAppPath(path string) *spathmeta.AppPath {
+	var ifaces []sciond.PathInterface
+	for _, str := range strings.Split(path, " ") {
+		pi, err := sciond.NewPathInterface(str)
+		if err != nil {
+			panic(err)
+		}
+		ifaces = append(ifaces, pi)
+	}
+	return &spathmeta.AppPath{
+		Entry: &sciond.PathReplyEntry{Path: &sciond.FwdPathMeta{Interfaces: ifaces}},
+	}
+}
+
+func syncPathsData(modTime time.Time, paths ...*spathmeta.AppPath) *pathmgr.SyncPathsData {
+	aps := make(spathmeta.AppPathSet)
+	for _, ap := range paths {
+		aps[ap.Key()] = ap
+	}
+	return &pathmgr.SyncPathsData{APS: aps, ModifyTime: modTime}
+}
+
+func TestSelectors(t *testing.T) {
+	Convey("Given path statistics", t, func() {
+		paths := []PathStats{
+			{Path: mustAppPath("1-ff00:0:110#1 1-ff00:0:111#2")},
+			{Path: mustAppPath("1-ff00:0:110#3 1-ff00:0:111#4")},
+			{Path: mustAppPath("1-ff00:0:110#5 1-ff00:0:112#6 1-ff00:0:112#7 1-ff00:0:111#8")},
+		}
+		Convey("the lowest RTT selector prefers measured paths", func() {
+			So(LowestRTTSelector{}.Select(paths), ShouldEqual, 0)
+			paths[1].RTT = 20 * time.Millisecond
+			So(LowestRTTSelector{}.Select(paths), ShouldEqual, 1)
+			paths[2].RTT = 10 * time.Millisecond
+			So(LowestRTTSelector{}.Select(paths), ShouldEqual, 2)
+		})
+		Convey("the round robin selector selects the paths in turn", func() {
+			s := &RoundRobinSelector{}
+			var selected []int
+			for i := 0; i < 4; i++ {
+				selected = append(selected, s.Select(paths))
+			}
+			So(selected, ShouldResemble, []int{0, 1, 2, 0})
+		})
+	})
+}
+
+func TestMultipathState(t *testing.T) {
+	Convey("Given a multipath state with two paths", t, func() {
+		now := time.Now()
+		ap1 := mustAppPath("1-ff00:0:110#1 1-ff00:0:111#2")
+		ap2 := mustAppPath("1-ff00:0:110#3 1-ff00:0:111#4")
+		s := newMultipathState(2)
+		s.update(syncPathsData(now, ap1, ap2))
+		So(s.usable(), ShouldHaveLength, 2)
+		Convey("statistics survive path updates", func() {
+			s.written(ap1.Key(), 10)
+			ap3 := mustAppPath("1-ff00:0:110#5 1-ff00:0:111#6")
+			s.update(syncPathsData(now.Add(time.Second), ap1, ap3))
+			stats := s.stats()
+			So(stats, ShouldHaveLength, 2)
+			for _, ps := range stats {
+				So(ps.Key(), ShouldNotEqual, ap2.Key())
+				if ps.Key() == ap1.Key() {
+					So(ps.PacketsSent, ShouldEqual, 1)
+					So(ps.BytesSent, ShouldEqual, 10)
+				}
+			}
+		})
+		Convey("answered probes update the RTT", func() {
+			probes := s.newProbes(now)
+			So(probes, ShouldHaveLength, 2)
+			for seq, ap := range probes {
+				if ap.Key() == ap1.Key() {
+					s.probeReply(seq, now.Add(80*time.Millisecond))
+				}
+			}
+			s.expireProbes(now.Add(time.Millisecond))
+			for _, ps := range s.stats() {
+				So(ps.ProbesSent, ShouldEqual, 1)
+				if ps.Key() == ap1.Key() {
+					So(ps.RTT, ShouldEqual, 80*time.Millisecond)
+					So(ps.ProbesAnswered, ShouldEqual, 1)
+					So(ps.Timeouts, ShouldEqual, 0)
+				} else {
+					So(ps.RTT, ShouldEqual, 0)
+					So(ps.Timeouts, ShouldEqual, 1)
+				}
+			}
+		})
+		Convey("paths with consecutive timeouts are not used", func() {
+			for i := 0; i < 2; i++ {
+				for seq, ap := range s.newProbes(now) {
+					if ap.Key() == ap1.Key() {
+						s.probeReply(seq, now)
+					}
+				}
+				s.expireProbes(now.Add(time.Millisecond))
+			}
+			usable := s.usable()
+			So(usable, ShouldHaveLength, 1)
+			So(usable[0].Key(), ShouldEqual, ap1.Key())
+		})
+		Convey("revoked paths are not used", func() {
+			So(s.revoke(xtest.MustParseIA("1-ff00:0:111"), 2), ShouldEqual, 1)
+			usable := s.usable()
+			So(usable, ShouldHaveLength, 1)
+			So(usable[0].Key(), ShouldEqual, ap2.Key())
+			So(s.revoke(xtest.MustParseIA("1-ff00:0:111"), 4), ShouldEqual, 1)
+			So(s.usable(), ShouldBeEmpty)
+		})
+		Convey("timed out paths are used if no other path is usable", func() {
+			for i := 0; i < 2; i++ {
+				s.newProbes(now)
+				s.expireProbes(now.Add(time.Millisecond))
+			}
+			So(s.usable(), ShouldHaveLength, 2)
+		})
+	})
+}
@@ -0,0 +1,110 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package snet
+
+import (
+	"sync/atomic"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/pathpol"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+)
+
+// PathStats contains the statistics of a path of a MultipathConn.
+type PathStats struct {
+	Path *spathmeta.AppPath
+	// RTT is the smoothed round trip time of the SCMP echo probes sent on the
+	// path. It is zero if no probe was answered yet.
+	RTT time.Duration
+	// ProbesSent and ProbesAnswered count the SCMP echo probes.
+	ProbesSent     uint64
+	ProbesAnswered uint64
+	// Timeouts is the number of consecutive unanswered probes.
+	Timeouts int
+	// PacketsSent and BytesSent count the data written on the path.
+	PacketsSent uint64
+	BytesSent   uint64
+	// Revoked is set if an interface on the path was revoked. It is reset
+	// once a probe on the path is answered.
+	Revoked bool
+}
+
+// Key returns the key of the path.
+func (s *PathStats) Key() spathmeta.PathKey {
+	return s.Path.Key()
+}
+
+// PathSelector selects the path of a MultipathConn that is used for a write.
+// Implementations must be safe for concurrent use.
+type PathSelector interface {
+	// Select returns the index of the path in paths that is used for the next
+	// write, or -1 if none of the paths is acceptable. Paths contains the
+	// usable paths sorted by their key, and is never empty.
+	Select(paths []PathStats) int
+}
+
+var _ PathSelector = LowestRTTSelector{}
+
+// LowestRTTSelector selects the path with the lowest RTT. Paths without RTT
+// measurement are only selected if no path has one.
+type LowestRTTSelector struct{}
+
+func (LowestRTTSelector) Select(paths []PathStats) int {
+	best := 0
+	for i := range paths {
+		rtt, bestRTT := paths[i].RTT, paths[best].RTT
+		if rtt != 0 && (bestRTT == 0 || rtt < bestRTT) {
+			best = i
+		}
+	}
+	return best
+}
+
+var _ PathSelector = (*RoundRobinSelector)(nil)
+
+// RoundRobinSelector selects the paths in turn.
+type RoundRobinSelector struct {
+	next uint64
+}
+
+func (s *RoundRobinSelector) Select(paths []PathStats) int {
+	return int((atomic.AddUint64(&s.next, 1) - 1) % uint64(len(paths)))
+}
+
+var _ PathSelector = (*PolicySelector)(nil)
+
+// PolicySelector selects the best path according to the ordering of Policy.
+// Paths that are rejected by the policy are never selected.
+type PolicySelector struct {
+	Policy *pathpol.Policy
+}
+
+func (s *PolicySelector) Select(paths []PathStats) int {
+	aps := make(spathmeta.AppPathSet)
+	for i := range paths {
+		aps[paths[i].Key()] = paths[i].Path
+	}
+	ranked := s.Policy.Rank(aps)
+	if len(ranked) == 0 {
+		return -1
+	}
+	best := ranked[0].Key()
+	for i := range paths {
+		if paths[i].Key() == best {
+			return i
+		}
+	}
+	return -1
+}
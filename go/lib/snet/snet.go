@@ -232,6 +232,18 @@ func (n *SCIONNetwork) ListenSCION(network string, laddr *Addr,
 func (n *SCIONNetwork) ListenSCIONWithBindSVC(network string, laddr, baddr *Addr,
 	svc addr.HostSVC, timeout time.Duration) (Conn, error) {
 
+	base, packetConn, err := n.listen(n.dispatcher, network, laddr, baddr, svc, timeout)
+	if err != nil {
+		return nil, err
+	}
+	return newSCIONConn(base, n.pathResolver, packetConn), nil
+}
+
+// listen validates the addresses and registers laddr with dispatcher.
+func (n *SCIONNetwork) listen(dispatcher PacketDispatcherService, network string,
+	laddr, baddr *Addr, svc addr.HostSVC,
+	timeout time.Duration) (*scionConnBase, PacketConn, error) {
+
 	// FIXME(scrye): If no local address is specified, we want to
 	// bind to the address of the outbound interface on a random
 	// free port. However, the current dispatcher version cannot
@@ -248,30 +260,30 @@ func (n *SCIONNetwork) ListenSCIONWithBindSVC(network string, laddr, baddr *Addr
 		l4Type = common.L4UDP
 		defL4 = addr.NewL4UDPInfo(0)
 	default:
-		return nil, common.NewBasicError("Network not implemented", nil, "net", network)
+		return nil, nil, common.NewBasicError("Network not implemented", nil, "net", network)
 	}
 	if laddr == nil {
-		return nil, common.NewBasicError("Nil laddr not supported", nil)
+		return nil, nil, common.NewBasicError("Nil laddr not supported", nil)
 	}
 	if laddr.Host == nil {
-		return nil, common.NewBasicError("Nil Host laddr not supported", nil)
+		return nil, nil, common.NewBasicError("Nil Host laddr not supported", nil)
 	}
 	if laddr.Host.L3 == nil {
-		return nil, common.NewBasicError("Nil Host L3 laddr not supported", nil)
+		return nil, nil, common.NewBasicError("Nil Host L3 laddr not supported", nil)
 	}
 	if laddr.Host.L3.Type() != l3Type {
-		return nil, common.NewBasicError("Supplied local address does not match network", nil,
+		return nil, nil, common.NewBasicError("Supplied local address does not match network", nil,
 			"expected L3", l3Type, "actual L3", laddr.Host.L3.Type())
 	}
 	if laddr.Host.L3.IP().IsUnspecified() {
-		return nil, common.NewBasicError("Binding to unspecified address not supported", nil)
+		return nil, nil, common.NewBasicError("Binding to unspecified address not supported", nil)
 	}
 	if laddr.Host.L4 == nil {
 		// If no port has been specified, default to 0 to get a random port from the dispatcher
 		laddr.Host.L4 = defL4
 	}
 	if laddr.Host.L4.Type() != l4Type {
-		return nil, common.NewBasicError("Supplied local address does not match network", nil,
+		return nil, nil, common.NewBasicError("Supplied local address does not match network", nil,
 			"expected L4", l4Type, "actual L4", laddr.Host.L4.Type())
 	}
 	conn := &scionConnBase{
@@ -285,7 +297,7 @@ func (n *SCIONNetwork) ListenSCIONWithBindSVC(network string, laddr, baddr *Addr
 		conn.laddr.IA = n.IA()
 	}
 	if !conn.laddr.IA.Equal(conn.scionNet.localIA) {
-		return nil, common.NewBasicError("Unable to listen on non-local IA", nil,
+		return nil, nil, common.NewBasicError("Unable to listen on non-local IA", nil,
 			"expected", conn.scionNet.localIA, "actual", conn.laddr.IA, "type", "public")
 	}
 	var bindAddr *overlay.OverlayAddr
@@ -294,24 +306,24 @@ func (n *SCIONNetwork) ListenSCIONWithBindSVC(network string, laddr, baddr *Addr
 		conn.baddr = baddr.Copy()
 		bindAddr, err = overlay.NewOverlayAddr(baddr.Host.L3, baddr.Host.L4)
 		if err != nil {
-			return nil, common.NewBasicError("Unable to construct overlay bind address", err)
+			return nil, nil, common.NewBasicError("Unable to construct overlay bind address", err)
 		}
 		if !conn.baddr.IA.Equal(conn.scionNet.localIA) {
-			return nil, common.NewBasicError("Unable to listen on non-local IA", nil,
+			return nil, nil, common.NewBasicError("Unable to listen on non-local IA", nil,
 				"expected", conn.scionNet.localIA, "actual", conn.baddr.IA, "type", "bind")
 		}
 	}
-	packetConn, port, err := conn.scionNet.dispatcher.RegisterTimeout(conn.laddr.IA,
+	packetConn, port, err := dispatcher.RegisterTimeout(conn.laddr.IA,
 		conn.laddr.Host, bindAddr, svc, timeout)
 	if err != nil {
-		return nil, err
+		return nil, nil, err
 	}
 	if port != conn.laddr.Host.L4.Port() {
 		// Update port
 		conn.laddr.Host.L4 = addr.NewL4UDPInfo(port)
 	}
 	log.Debug("Registered with dispatcher", "addr", conn.laddr)
-	return newSCIONConn(conn, n.pathResolver, packetConn), nil
+	return conn, packetConn, nil
 }
 
 // PathResolver returns the pathmgr.PR that the network is using.
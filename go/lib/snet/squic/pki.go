@@ -0,0 +1,338 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package squic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/asn1"
+	"encoding/binary"
+	"math/big"
+	"sync"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/proto"
+)
+
+const (
+	// DefaultCertValidity is the default validity of the TLS certificates
+	// created by PKI.
+	DefaultCertValidity = 24 * time.Hour
+	// DefaultVerifyTimeout is the default time PKI waits for the crypto
+	// material required to verify a peer.
+	DefaultVerifyTimeout = 2 * time.Second
+)
+
+// Errors
+const (
+	ErrNoSigner       = "No control-plane signer available"
+	ErrNoSCIONSign    = "Certificate does not contain a SCION signature"
+	ErrCertNotCurrent = "Certificate is not valid at the current time"
+	ErrStaleSign      = "Certificate signature is stale"
+)
+
+// SignerFunc returns the signer that is used to sign the public key of a newly
+// created certificate.
+type SignerFunc func() (infra.Signer, error)
+
+// oidSCIONSign identifies the certificate extension that contains the
+// control-plane signature of the certificate's public key.
+var oidSCIONSign = asn1.ObjectIdentifier{1, 3, 6, 1, 4, 1, 55324, 1, 3, 1}
+
+// PKI authenticates QUIC peers with the SCION control-plane PKI.
+//
+// The TLS certificates presented by PKI are self-signed certificates for
+// ephemeral keys. The public key and the validity period of each certificate
+// are signed with the AS signing key of the local AS, and the signature is
+// embedded in the certificate. Peers verify the signature with the AS
+// certificate chain, which the verifier obtains from the trust store, and
+// thereby authenticate the AS of the endpoint. The standard TLS PKI is not
+// used.
+//
+// Servers always present a certificate. Clients without a certificate, e.g.,
+// clients with a verify-only PKI, are accepted unauthenticated, while the
+// certificates that clients present are verified.
+type PKI struct {
+	// Verifier verifies the signatures embedded in the certificates of peers.
+	Verifier infra.Verifier
+	// CertValidity is the validity of the created certificates. Certificates
+	// of peers are rejected once their signature is older than CertValidity,
+	// regardless of the validity period they claim. If zero,
+	// DefaultCertValidity is used.
+	CertValidity time.Duration
+	// VerifyTimeout bounds the time to fetch crypto material from the trust
+	// store. If zero, DefaultVerifyTimeout is used.
+	VerifyTimeout time.Duration
+
+	mtx     sync.Mutex
+	signerF SignerFunc
+	cert    *tls.Certificate
+	// verifyOnly indicates that the PKI never presents a certificate.
+	verifyOnly bool
+}
+
+// NewPKI returns a PKI that signs its certificates with signer and verifies
+// peers with verifier. The signer can be nil, in which case handshakes fail
+// until a signer is set with SetSigner.
+func NewPKI(signer infra.Signer, verifier infra.Verifier) *PKI {
+	p := &PKI{Verifier: verifier}
+	p.SetSigner(signer)
+	return p
+}
+
+// NewPKIWithSignerFunc returns a PKI that obtains the signer from signerF
+// every time it creates a certificate. This allows the signer to follow
+// updates of the local certificate chain.
+func NewPKIWithSignerFunc(signerF SignerFunc, verifier infra.Verifier) *PKI {
+	return &PKI{Verifier: verifier, signerF: signerF}
+}
+
+// NewVerifyOnlyPKI returns a PKI for clients that only verify servers and do
+// not authenticate themselves. It requires no signing key, and it cannot be
+// used for servers.
+func NewVerifyOnlyPKI(verifier infra.Verifier) *PKI {
+	return &PKI{Verifier: verifier, verifyOnly: true}
+}
+
+// VerifyOnly returns whether the PKI only verifies peers, see
+// NewVerifyOnlyPKI.
+func (p *PKI) VerifyOnly() bool {
+	return p.verifyOnly
+}
+
+// SetSigner replaces the signer. Certificates created with the previous
+// signer are no longer used.
+func (p *PKI) SetSigner(signer infra.Signer) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	p.signerF = func() (infra.Signer, error) { return signer, nil }
+	p.cert = nil
+}
+
+// TLSConfig returns a TLS configuration that presents the certificate of the
+// local AS, and verifies the certificate of the peer. Peers from any AS are
+// accepted. It can be used for both clients and servers.
+func (p *PKI) TLSConfig() *tls.Config {
+	return p.TLSConfigFor(addr.IA{})
+}
+
+// TLSConfigFor is similar to TLSConfig, but only accepts peers from AS ia.
+// Zero values in ia are wildcards.
+func (p *PKI) TLSConfigFor(ia addr.IA) *tls.Config {
+	return &tls.Config{
+		GetCertificate: func(*tls.ClientHelloInfo) (*tls.Certificate, error) {
+			return p.certificate()
+		},
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			if p.verifyOnly {
+				// An empty certificate makes the client not send any.
+				return &tls.Certificate{}, nil
+			}
+			return p.certificate()
+		},
+		ClientAuth: tls.RequestClientCert,
+		// The standard TLS PKI is not used, verification is done in
+		// VerifyPeerCertificate.
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			return p.verify(ia, rawCerts)
+		},
+	}
+}
+
+// certificate returns the current certificate. A new certificate is created
+// if there is none, if it expired, or if half of its validity has passed.
+func (p *PKI) certificate() (*tls.Certificate, error) {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	if p.cert != nil && time.Since(p.cert.Leaf.NotBefore) < p.certValidity()/2 &&
+		time.Now().Before(p.cert.Leaf.NotAfter) {
+
+		return p.cert, nil
+	}
+	if p.signerF == nil {
+		return nil, common.NewBasicError(ErrNoSigner, nil)
+	}
+	signer, err := p.signerF()
+	if err != nil {
+		return nil, common.NewBasicError(ErrNoSigner, err)
+	}
+	if signer == nil {
+		return nil, common.NewBasicError(ErrNoSigner, nil)
+	}
+	cert, err := newCertificate(signer, p.certValidity())
+	if err != nil {
+		return nil, err
+	}
+	p.cert = cert
+	return cert, nil
+}
+
+func (p *PKI) certValidity() time.Duration {
+	if p.CertValidity == 0 {
+		return DefaultCertValidity
+	}
+	return p.CertValidity
+}
+
+func (p *PKI) verify(ia addr.IA, rawCerts [][]byte) error {
+	if len(rawCerts) == 0 {
+		// Only clients can omit the certificate, the TLS handshake fails for
+		// servers without certificate.
+		return nil
+	}
+	cert, err := x509.ParseCertificate(rawCerts[0])
+	if err != nil {
+		return common.NewBasicError("Unable to parse peer certificate", err)
+	}
+	now := time.Now()
+	if now.Before(cert.NotBefore) || now.After(cert.NotAfter) {
+		return common.NewBasicError(ErrCertNotCurrent, nil,
+			"notBefore", cert.NotBefore, "notAfter", cert.NotAfter)
+	}
+	sign, err := certSign(cert)
+	if err != nil {
+		return err
+	}
+	if exp := sign.Time().Add(p.certValidity()); now.After(exp) {
+		return common.NewBasicError(ErrStaleSign, nil, "signed", sign.Time(),
+			"validity", p.certValidity())
+	}
+	timeout := p.VerifyTimeout
+	if timeout == 0 {
+		timeout = DefaultVerifyTimeout
+	}
+	ctx, cancelF := context.WithTimeout(context.Background(), timeout)
+	defer cancelF()
+	input := certSigInput(cert.RawSubjectPublicKeyInfo, cert.NotBefore, cert.NotAfter)
+	err = p.Verifier.WithIA(ia).Verify(ctx, input, sign)
+	if err != nil {
+		return common.NewBasicError("Unable to verify peer certificate", err, "ia", ia)
+	}
+	return nil
+}
+
+// PeerIA returns the AS that signed the certificate. The result is only
+// authentic if the certificate was verified by PKI, e.g., if it was presented
+// by the peer during a handshake with a PKI TLS configuration.
+func PeerIA(cert *x509.Certificate) (addr.IA, error) {
+	sign, err := certSign(cert)
+	if err != nil {
+		return addr.IA{}, err
+	}
+	src, err := ctrl.NewSignSrcDefFromRaw(sign.Src)
+	if err != nil {
+		return addr.IA{}, common.NewBasicError("Unable to parse signature source", err)
+	}
+	return src.IA, nil
+}
+
+// newCertificate creates a self-signed certificate for a new key. The public
+// key and the validity period are signed by signer, and the signature is added
+// as certificate extension.
+func newCertificate(signer infra.Signer, validity time.Duration) (*tls.Certificate, error) {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to generate key", err)
+	}
+	spki, err := x509.MarshalPKIXPublicKey(&key.PublicKey)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to marshal public key", err)
+	}
+	meta := signer.Meta()
+	// The certificate encodes the validity period with second precision.
+	now := time.Now().Truncate(time.Second)
+	// Allow for some clock skew.
+	notBefore := now.Add(-time.Minute)
+	notAfter := now.Add(validity)
+	if !meta.ExpTime.IsZero() && meta.ExpTime.Before(notAfter) {
+		notAfter = meta.ExpTime.Truncate(time.Second)
+	}
+	sign, err := signer.Sign(certSigInput(spki, notBefore, notAfter))
+	if err != nil {
+		return nil, common.NewBasicError("Unable to sign public key", err)
+	}
+	packed, err := proto.PackRoot(sign)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to pack signature", err)
+	}
+	rawSign, err := asn1.Marshal([]byte(packed))
+	if err != nil {
+		return nil, common.NewBasicError("Unable to encode signature", err)
+	}
+	serial, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	if err != nil {
+		return nil, common.NewBasicError("Unable to generate serial number", err)
+	}
+	tmpl := &x509.Certificate{
+		SerialNumber: serial,
+		Subject:      pkix.Name{CommonName: meta.Src.IA.String()},
+		NotBefore:    notBefore,
+		NotAfter:     notAfter,
+		KeyUsage:     x509.KeyUsageDigitalSignature,
+		ExtKeyUsage:  []x509.ExtKeyUsage{x509.ExtKeyUsageServerAuth, x509.ExtKeyUsageClientAuth},
+		ExtraExtensions: []pkix.Extension{
+			{Id: oidSCIONSign, Value: rawSign},
+		},
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to create certificate", err)
+	}
+	leaf, err := x509.ParseCertificate(raw)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to parse created certificate", err)
+	}
+	return &tls.Certificate{Certificate: [][]byte{raw}, PrivateKey: key, Leaf: leaf}, nil
+}
+
+// certSigInput returns the input of the control-plane signature of a
+// certificate. It covers the validity period, such that the lifetime of the
+// certificate cannot be extended without the signing key.
+func certSigInput(spki []byte, notBefore, notAfter time.Time) common.RawBytes {
+	input := make(common.RawBytes, len(spki)+16)
+	copy(input, spki)
+	binary.BigEndian.PutUint64(input[len(spki):], uint64(notBefore.Unix()))
+	binary.BigEndian.PutUint64(input[len(spki)+8:], uint64(notAfter.Unix()))
+	return input
+}
+
+// certSign extracts the control-plane signature from the certificate.
+func certSign(cert *x509.Certificate) (*proto.SignS, error) {
+	for _, ext := range cert.Extensions {
+		if !ext.Id.Equal(oidSCIONSign) {
+			continue
+		}
+		var packed []byte
+		if rest, err := asn1.Unmarshal(ext.Value, &packed); err != nil || len(rest) != 0 {
+			return nil, common.NewBasicError("Unable to decode SCION signature", err)
+		}
+		sign := &proto.SignS{}
+		if err := proto.ParseFromRaw(sign, packed); err != nil {
+			return nil, common.NewBasicError("Unable to parse SCION signature", err)
+		}
+		return sign, nil
+	}
+	return nil, common.NewBasicError(ErrNoSCIONSign, nil)
+}
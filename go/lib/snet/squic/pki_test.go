@@ -0,0 +1,227 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package squic
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/tls"
+	"crypto/x509"
+	"math/big"
+	"net"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/lib/scrypto"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// testSigner signs with a static key on behalf of an AS.
+type testSigner struct {
+	meta infra.SignerMeta
+	key  common.RawBytes
+}
+
+func (s *testSigner) Sign(msg common.RawBytes) (*proto.SignS, error) {
+	var err error
+	sign := proto.NewSignS(proto.SignType_ed25519, s.meta.Src.Pack())
+	sign.Signature, err = scrypto.Sign(sign.SigInput(msg, true), s.key, s.meta.Algo)
+	return sign, err
+}
+
+func (s *testSigner) Meta() infra.SignerMeta {
+	return s.meta
+}
+
+// testVerifier verifies signatures with the static keys of the ASes in keys.
+type testVerifier struct {
+	infra.Verifier
+	keys map[addr.IA]common.RawBytes
+	ia   addr.IA
+}
+
+func (v *testVerifier) WithIA(ia addr.IA) infra.Verifier {
+	return &testVerifier{keys: v.keys, ia: ia}
+}
+
+func (v *testVerifier) Verify(_ context.Context, msg common.RawBytes,
+	sign *proto.SignS) error {
+
+	src, err := ctrl.NewSignSrcDefFromRaw(sign.Src)
+	if err != nil {
+		return err
+	}
+	if !v.ia.IsZero() && !v.ia.Equal(src.IA) {
+		return common.NewBasicError("AS does not match bound source", nil, "ia", src.IA)
+	}
+	key, ok := v.keys[src.IA]
+	if !ok {
+		return common.NewBasicError("Unknown AS", nil, "ia", src.IA)
+	}
+	return scrypto.Verify(sign.SigInput(msg, false), sign.Signature, key, scrypto.Ed25519)
+}
+
+func newTestSigner(t *testing.T, ia addr.IA, keys map[addr.IA]common.RawBytes) *testSigner {
+	pub, priv, err := scrypto.GenKeyPair(scrypto.Ed25519)
+	xtest.FailOnErr(t, err)
+	keys[ia] = pub
+	return &testSigner{
+		meta: infra.SignerMeta{
+			Src:     ctrl.SignSrcDef{IA: ia, ChainVer: 1, TRCVer: 1},
+			ExpTime: time.Now().Add(time.Hour),
+			Algo:    scrypto.Ed25519,
+		},
+		key: priv,
+	}
+}
+
+// handshake runs a TLS handshake between a client and a server with the
+// respective configurations, and returns the client and server errors.
+func handshake(t *testing.T, clientCfg, serverCfg *tls.Config) (error, error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	xtest.FailOnErr(t, err)
+	defer ln.Close()
+	serverErrC := make(chan error, 1)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			serverErrC <- err
+			return
+		}
+		defer conn.Close()
+		conn.SetDeadline(time.Now().Add(time.Second))
+		serverErrC <- tls.Server(conn, serverCfg).Handshake()
+	}()
+	conn, err := net.Dial("tcp", ln.Addr().String())
+	xtest.FailOnErr(t, err)
+	defer conn.Close()
+	conn.SetDeadline(time.Now().Add(time.Second))
+	clientErr := tls.Client(conn, clientCfg).Handshake()
+	return clientErr, <-serverErrC
+}
+
+// selfSignedCert creates a self-signed certificate without SCION signature.
+func selfSignedCert(t *testing.T) tls.Certificate {
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	xtest.FailOnErr(t, err)
+	tmpl := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		NotBefore:    time.Now().Add(-time.Minute),
+		NotAfter:     time.Now().Add(time.Hour),
+	}
+	raw, err := x509.CreateCertificate(rand.Reader, tmpl, tmpl, &key.PublicKey, key)
+	xtest.FailOnErr(t, err)
+	return tls.Certificate{Certificate: [][]byte{raw}, PrivateKey: key}
+}
+
+func TestPKI(t *testing.T) {
+	Convey("Given PKIs for two ASes", t, func() {
+		keys := make(map[addr.IA]common.RawBytes)
+		clientIA := xtest.MustParseIA("1-ff00:0:110")
+		serverIA := xtest.MustParseIA("1-ff00:0:111")
+		verifier := &testVerifier{keys: keys}
+		clientPKI := NewPKI(newTestSigner(t, clientIA, keys), verifier)
+		serverPKI := NewPKI(newTestSigner(t, serverIA, keys), verifier)
+		Convey("peers are mutually authenticated", func() {
+			clientErr, serverErr := handshake(t, clientPKI.TLSConfigFor(serverIA),
+				serverPKI.TLSConfig())
+			So(clientErr, ShouldBeNil)
+			So(serverErr, ShouldBeNil)
+		})
+		Convey("the certificate contains the AS", func() {
+			cert, err := serverPKI.certificate()
+			So(err, ShouldBeNil)
+			ia, err := PeerIA(cert.Leaf)
+			So(err, ShouldBeNil)
+			So(ia, ShouldResemble, serverIA)
+		})
+		Convey("clients reject servers of other ASes", func() {
+			clientErr, _ := handshake(t, clientPKI.TLSConfigFor(clientIA), serverPKI.TLSConfig())
+			So(clientErr, ShouldNotBeNil)
+		})
+		Convey("servers reject clients with unknown keys", func() {
+			otherPKI := NewPKI(newTestSigner(t, clientIA, make(map[addr.IA]common.RawBytes)),
+				verifier)
+			_, serverErr := handshake(t, otherPKI.TLSConfigFor(serverIA), serverPKI.TLSConfig())
+			So(serverErr, ShouldNotBeNil)
+		})
+		Convey("servers accept clients without certificate", func() {
+			clientErr, serverErr := handshake(t, &tls.Config{InsecureSkipVerify: true},
+				serverPKI.TLSConfig())
+			So(clientErr, ShouldBeNil)
+			So(serverErr, ShouldBeNil)
+		})
+		Convey("servers reject clients with certificates without signature", func() {
+			clientCfg := &tls.Config{
+				Certificates:       []tls.Certificate{selfSignedCert(t)},
+				InsecureSkipVerify: true,
+			}
+			_, serverErr := handshake(t, clientCfg, serverPKI.TLSConfig())
+			So(serverErr, ShouldNotBeNil)
+		})
+		Convey("verify-only clients authenticate servers", func() {
+			verifyOnly := NewVerifyOnlyPKI(verifier)
+			clientErr, serverErr := handshake(t, verifyOnly.TLSConfigFor(serverIA),
+				serverPKI.TLSConfig())
+			So(clientErr, ShouldBeNil)
+			So(serverErr, ShouldBeNil)
+			clientErr, _ = handshake(t, verifyOnly.TLSConfigFor(clientIA),
+				serverPKI.TLSConfig())
+			So(clientErr, ShouldNotBeNil)
+		})
+		Convey("verify-only PKIs cannot serve", func() {
+			verifyOnly := NewVerifyOnlyPKI(verifier)
+			_, serverErr := handshake(t, clientPKI.TLSConfigFor(serverIA),
+				verifyOnly.TLSConfig())
+			So(serverErr, ShouldNotBeNil)
+		})
+		Convey("clients reject servers with stale signatures", func() {
+			verifyOnly := NewVerifyOnlyPKI(verifier)
+			verifyOnly.CertValidity = time.Nanosecond
+			clientErr, _ := handshake(t, verifyOnly.TLSConfigFor(serverIA),
+				serverPKI.TLSConfig())
+			So(clientErr, ShouldNotBeNil)
+		})
+		Convey("the signature covers the validity period", func() {
+			cert, err := serverPKI.certificate()
+			So(err, ShouldBeNil)
+			leaf := *cert.Leaf
+			leaf.NotAfter = leaf.NotAfter.Add(time.Hour)
+			sign, err := certSign(&leaf)
+			So(err, ShouldBeNil)
+			input := certSigInput(leaf.RawSubjectPublicKeyInfo, leaf.NotBefore, leaf.NotAfter)
+			So(verifier.Verify(context.Background(), input, sign), ShouldNotBeNil)
+			input = certSigInput(leaf.RawSubjectPublicKeyInfo, cert.Leaf.NotBefore,
+				cert.Leaf.NotAfter)
+			So(verifier.Verify(context.Background(), input, sign), ShouldBeNil)
+		})
+		Convey("handshakes fail without signer", func() {
+			serverPKI.SetSigner(nil)
+			clientErr, serverErr := handshake(t, clientPKI.TLSConfigFor(serverIA),
+				serverPKI.TLSConfig())
+			So(clientErr, ShouldNotBeNil)
+			So(serverErr, ShouldNotBeNil)
+		})
+	})
+}
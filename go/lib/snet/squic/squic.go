@@ -34,6 +34,8 @@ var (
 	// Don't verify the server's cert, as we are not using the TLS PKI.
 	cliTlsCfg = &tls.Config{InsecureSkipVerify: true}
 	srvTlsCfg = &tls.Config{}
+	// pki, if set, authenticates peers with the control-plane PKI.
+	pki *PKI
 )
 
 func Init(keyPath, pemPath string) error {
@@ -51,6 +53,13 @@ func Init(keyPath, pemPath string) error {
 	return nil
 }
 
+// InitPKI configures squic to authenticate peers with the control-plane PKI,
+// instead of using the static certificate loaded by Init. Dialed sessions
+// only accept servers from the AS of the remote address.
+func InitPKI(p *PKI) {
+	pki = p
+}
+
 func DialSCION(network *snet.SCIONNetwork, laddr, raddr *snet.Addr,
 	quicConfig *quic.Config) (quic.Session, error) {
 
@@ -64,8 +73,13 @@ func DialSCIONWithBindSVC(network *snet.SCIONNetwork, laddr, raddr, baddr *snet.
 	if err != nil {
 		return nil, err
 	}
-	// Use dummy hostname, as it's used for SNI, and we're not doing cert verification.
-	return quic.Dial(sconn, raddr, "host:0", cliTlsCfg, quicConfig)
+	tlsCfg := cliTlsCfg
+	if pki != nil {
+		tlsCfg = pki.TLSConfigFor(raddr.IA)
+	}
+	// Use dummy hostname, as it's used for SNI, and we're not doing TLS PKI
+	// verification.
+	return quic.Dial(sconn, raddr, "host:0", tlsCfg, quicConfig)
 }
 
 func ListenSCION(network *snet.SCIONNetwork, laddr *snet.Addr,
@@ -77,14 +91,17 @@ func ListenSCION(network *snet.SCIONNetwork, laddr *snet.Addr,
 func ListenSCIONWithBindSVC(network *snet.SCIONNetwork, laddr, baddr *snet.Addr,
 	svc addr.HostSVC, quicConfig *quic.Config) (quic.Listener, error) {
 
-	if len(srvTlsCfg.Certificates) == 0 {
+	tlsCfg := srvTlsCfg
+	if pki != nil {
+		tlsCfg = pki.TLSConfig()
+	} else if len(srvTlsCfg.Certificates) == 0 {
 		return nil, common.NewBasicError("squic: No server TLS certificate configured", nil)
 	}
 	sconn, err := sListen(network, laddr, baddr, svc)
 	if err != nil {
 		return nil, err
 	}
-	return quic.Listen(sconn, srvTlsCfg, quicConfig)
+	return quic.Listen(sconn, tlsCfg, quicConfig)
 }
 
 func sListen(network *snet.SCIONNetwork, laddr, baddr *snet.Addr,
@@ -0,0 +1,138 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reliable
+
+import (
+	"net"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+const (
+	// maxBatchSize is the maximum size of a SEQPACKET message. It is the size
+	// of the ReadPacketizer buffer, which receives a message at once.
+	maxBatchSize = 1 << 16
+	// maxBatchBuffers is the maximum number of buffers in a SEQPACKET
+	// message, s.t. the message is written with a single writev.
+	maxBatchBuffers = 512
+	// maxHeaderLength is the length of a frame header with an IPv6 address.
+	maxHeaderLength = 8 + 1 + 4 + 16 + 2
+)
+
+// Message is a packet that is read or written as part of a batch.
+type Message struct {
+	// Buffer contains the payload. On writes, the payload is all of Buffer.
+	// On reads, the payload is copied to the beginning of Buffer, and N is
+	// set to its length.
+	Buffer []byte
+	N      int
+	// Addr is the overlay address of the packet, i.e., the next hop on writes
+	// and the last hop on reads. It can be nil.
+	Addr net.Addr
+}
+
+// ReadBatch reads up to len(msgs) packets. It blocks until at least one
+// packet is available, and returns the number of packets read. On a
+// SEQPACKET connection, all packets of a message are read with a single
+// system call; on a stream connection, all packets that are available after
+// the first read are returned.
+//
+// If an error is returned, the first n messages still contain valid packets.
+func (conn *Conn) ReadBatch(msgs []Message) (int, error) {
+	conn.readMutex.Lock()
+	defer conn.readMutex.Unlock()
+
+	for i := range msgs {
+		if i > 0 && !conn.readPacketizer.buffered() {
+			return i, nil
+		}
+		n, address, err := conn.readFrom(msgs[i].Buffer)
+		if err != nil {
+			return i, err
+		}
+		msgs[i].N, msgs[i].Addr = n, address
+	}
+	return len(msgs), nil
+}
+
+// WriteBatch writes the packets in msgs, and returns the number of packets
+// written. The payloads are not copied; only the frame headers are
+// serialized, and the headers and payloads are written with writev. On a
+// SEQPACKET connection, the packets are packed into as few messages as
+// possible.
+func (conn *Conn) WriteBatch(msgs []Message) (int, error) {
+	conn.writeMutex.Lock()
+	defer conn.writeMutex.Unlock()
+
+	var bufs net.Buffers
+	// written is the number of packets written, batchSize the size of the
+	// packets in bufs, and offset the space used in the header buffer.
+	written, batchSize, offset := 0, 0, 0
+	flush := func(next int) error {
+		if len(bufs) > 0 {
+			if _, err := bufs.WriteTo(conn.UnixConn); err != nil {
+				return err
+			}
+		}
+		bufs, batchSize, offset, written = bufs[:0], 0, 0, next
+		return nil
+	}
+	var header [maxHeaderLength]byte
+	for i := range msgs {
+		n, err := serializeHeader(header[:], &msgs[i])
+		if err != nil {
+			return written, err
+		}
+		size := n + len(msgs[i].Buffer)
+		full := offset+n > len(conn.writeBuffer)
+		if conn.packet {
+			if size > maxBatchSize {
+				return written, common.NewBasicError(ErrPayloadTooLong, nil,
+					"have", size, "max", maxBatchSize)
+			}
+			full = full || batchSize+size > maxBatchSize || len(bufs)+2 > maxBatchBuffers
+		}
+		if full {
+			if err := flush(i); err != nil {
+				return written, err
+			}
+		}
+		copy(conn.writeBuffer[offset:], header[:n])
+		bufs = append(bufs, conn.writeBuffer[offset:offset+n], msgs[i].Buffer)
+		batchSize += size
+		offset += n
+	}
+	if err := flush(len(msgs)); err != nil {
+		return written, err
+	}
+	return written, nil
+}
+
+// serializeHeader writes the frame header of msg to b, and returns the length
+// of the header.
+func serializeHeader(b []byte, msg *Message) (int, error) {
+	address := toUDPAddr(msg.Addr)
+	f := frame{
+		Cookie:      expectedCookie,
+		AddressType: byte(getAddressType(address)),
+		Length:      uint32(len(msg.Buffer)),
+	}
+	if address != nil {
+		if err := f.insertAddress(address); err != nil {
+			return 0, err
+		}
+	}
+	return f.SerializeTo(b)
+}
@@ -0,0 +1,236 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reliable
+
+import (
+	"net"
+	"path/filepath"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/overlay"
+	"github.com/scionproto/scion/go/lib/xtest"
+)
+
+func TestRegisterV2(t *testing.T) {
+	Convey("Given a listening server", t, func() {
+		dir, cleanF := xtest.MustTempDir("", "reliable")
+		defer cleanF()
+		socket := filepath.Join(dir, "test.sock")
+		l, err := Listen(socket)
+		xtest.FailOnErr(t, err)
+		defer l.Close()
+		ia := xtest.MustParseIA("1-ff00:0:1")
+		public := &addr.AppAddr{
+			L3: addr.HostFromIP(net.IP{127, 0, 0, 1}),
+			L4: addr.NewL4UDPInfo(40000),
+		}
+
+		Convey("a v2 client gets a SEQPACKET socket from a v2 server", func() {
+			served := serve(l, true)
+			client, port, err := RegisterV2(socket, ia, public, nil, addr.SvcNone,
+				time.Second, V2SeqPacket)
+			SoMsg("err", err, ShouldBeNil)
+			defer client.Close()
+			result := <-served
+			SoMsg("server err", result.err, ShouldBeNil)
+			defer result.conn.Close()
+			SoMsg("port", port, ShouldEqual, 40000)
+			SoMsg("registration version", result.reg.Version, ShouldEqual, ProtocolV2)
+			SoMsg("client version", client.Version(), ShouldEqual, ProtocolV2)
+			SoMsg("client seqpacket", client.SeqPacket(), ShouldBeTrue)
+			SoMsg("server seqpacket", result.conn.SeqPacket(), ShouldBeTrue)
+
+			Convey("batches are read with a single read", func() {
+				sent := testMessages(3)
+				n, err := client.WriteBatch(sent)
+				SoMsg("write err", err, ShouldBeNil)
+				SoMsg("written", n, ShouldEqual, 3)
+				received := make([]Message, 8)
+				for i := range received {
+					received[i].Buffer = make([]byte, 1500)
+				}
+				n, err = result.conn.ReadBatch(received)
+				SoMsg("read err", err, ShouldBeNil)
+				SoMsg("read", n, ShouldEqual, 3)
+				shouldMatchMessages(received[:n], sent)
+			})
+			Convey("single packets are exchanged in both directions", func() {
+				shouldExchange(client, result.conn)
+				shouldExchange(result.conn, client)
+			})
+		})
+		Convey("a v2 client falls back to v1 with a v1 server", func() {
+			served := serve(l, false)
+			client, _, err := RegisterV2(socket, ia, public, nil, addr.SvcNone,
+				time.Second, V2SeqPacket)
+			SoMsg("err", err, ShouldBeNil)
+			defer client.Close()
+			result := <-served
+			SoMsg("server err", result.err, ShouldBeNil)
+			defer result.conn.Close()
+			SoMsg("client version", client.Version(), ShouldEqual, ProtocolV1)
+			SoMsg("client seqpacket", client.SeqPacket(), ShouldBeFalse)
+			shouldExchange(client, result.conn)
+			shouldExchange(result.conn, client)
+		})
+		Convey("a v2 client does not fall back to v1 after a bad confirmation", func() {
+			accepted := make(chan int, 1)
+			go func() {
+				n := 0
+				defer func() { accepted <- n }()
+				for {
+					c, err := l.Accept()
+					if err != nil {
+						return
+					}
+					n++
+					conn := c.(*Conn)
+					b := make([]byte, 1500)
+					if _, _, err := conn.ReadFrom(b); err == nil && n == 1 {
+						// Reply with a malformed confirmation.
+						conn.WriteTo([]byte{0}, nil)
+					}
+					conn.Close()
+				}
+			}()
+			_, _, err := RegisterV2(socket, ia, public, nil, addr.SvcNone,
+				time.Second, V2SeqPacket)
+			SoMsg("err", err, ShouldNotBeNil)
+			SoMsg("no confirmation", common.GetErrorMsg(err), ShouldNotEqual, ErrNoConfirmation)
+			l.Close()
+			SoMsg("connections", <-accepted, ShouldEqual, 1)
+		})
+		Convey("a v1 client registers with a v2 server", func() {
+			served := serve(l, true)
+			client, _, err := RegisterTimeout(socket, ia, public, nil, addr.SvcNone,
+				time.Second)
+			SoMsg("err", err, ShouldBeNil)
+			defer client.Close()
+			result := <-served
+			SoMsg("server err", result.err, ShouldBeNil)
+			defer result.conn.Close()
+			SoMsg("registration version", result.reg.Version, ShouldEqual, uint8(0))
+			SoMsg("client version", client.Version(), ShouldEqual, ProtocolV1)
+			SoMsg("server seqpacket", result.conn.SeqPacket(), ShouldBeFalse)
+
+			Convey("batches are written on stream sockets", func() {
+				sent := testMessages(3)
+				n, err := result.conn.WriteBatch(sent)
+				SoMsg("write err", err, ShouldBeNil)
+				SoMsg("written", n, ShouldEqual, 3)
+				var received []Message
+				for len(received) < len(sent) {
+					batch := make([]Message, len(sent)-len(received))
+					for i := range batch {
+						batch[i].Buffer = make([]byte, 1500)
+					}
+					n, err := client.ReadBatch(batch)
+					SoMsg("read err", err, ShouldBeNil)
+					received = append(received, batch[:n]...)
+				}
+				shouldMatchMessages(received, sent)
+			})
+		})
+	})
+}
+
+type serveResult struct {
+	conn *Conn
+	reg  Registration
+	err  error
+}
+
+// serve accepts a single registration on l. If v2 is false, the server
+// behaves like a server that only supports ProtocolV1, i.e., it closes the
+// connection of ProtocolV2 registrations.
+func serve(l *Listener, v2 bool) <-chan serveResult {
+	ch := make(chan serveResult, 1)
+	go func() {
+		var result serveResult
+		defer func() { ch <- result }()
+		var conn *Conn
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				result.err = err
+				return
+			}
+			conn = c.(*Conn)
+			b := make([]byte, 1500)
+			n, _, err := conn.ReadFrom(b)
+			if err != nil {
+				result.err = err
+				return
+			}
+			if result.err = result.reg.DecodeFromBytes(b[:n]); result.err != nil {
+				return
+			}
+			if v2 || result.reg.Version < ProtocolV2 {
+				break
+			}
+			conn.Close()
+		}
+		confirmation := &Confirmation{Port: uint16(result.reg.PublicAddress.Port)}
+		if v2 && result.reg.Version >= ProtocolV2 {
+			confirmation.Version = ProtocolV2
+			confirmation.Flags = result.reg.Flags & V2SupportedFlags
+		}
+		result.conn, result.err = conn.Confirm(confirmation)
+		if result.conn != conn {
+			conn.Close()
+		}
+	}()
+	return ch
+}
+
+func testMessages(n int) []Message {
+	msgs := make([]Message, n)
+	for i := range msgs {
+		ov, err := overlay.NewOverlayAddr(addr.HostFromIP(net.IP{192, 0, 2, byte(i + 1)}),
+			addr.NewL4UDPInfo(uint16(30041+i)))
+		if err != nil {
+			panic(err)
+		}
+		msgs[i] = Message{Buffer: make([]byte, 100*(i+1)), Addr: ov}
+		for j := range msgs[i].Buffer {
+			msgs[i].Buffer[j] = byte(i + j)
+		}
+	}
+	return msgs
+}
+
+func shouldMatchMessages(received, sent []Message) {
+	SoMsg("count", len(received), ShouldEqual, len(sent))
+	for i := range received {
+		SoMsg("payload", received[i].Buffer[:received[i].N], ShouldResemble, sent[i].Buffer)
+		SoMsg("address", received[i].Addr.String(), ShouldEqual, sent[i].Addr.String())
+	}
+}
+
+func shouldExchange(src, dst *Conn) {
+	sent := testMessages(1)[0]
+	_, err := src.WriteTo(sent.Buffer, sent.Addr)
+	SoMsg("write err", err, ShouldBeNil)
+	b := make([]byte, 1500)
+	n, address, err := dst.ReadFrom(b)
+	SoMsg("read err", err, ShouldBeNil)
+	SoMsg("payload", b[:n], ShouldResemble, sent.Buffer)
+	SoMsg("address", address.String(), ShouldEqual, sent.Addr.String())
+}
@@ -36,6 +36,9 @@ const (
 	ErrIncompleteMessage     = "incomplete message"
 	ErrBadLength             = "bad length"
 	ErrBufferTooSmall        = "buffer too small"
+	ErrBadVersion            = "bad protocol version"
+	ErrNoSocket              = "missing data socket"
+	ErrNoConfirmation        = "connection closed without confirmation"
 )
 
 func IsDispatcherError(err error) bool {
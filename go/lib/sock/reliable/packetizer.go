@@ -39,24 +39,41 @@ func NewReadPacketizer(conn net.Conn) *ReadPacketizer {
 }
 
 func (r *ReadPacketizer) Read(b []byte) (int, error) {
+	packet, err := r.next()
+	if err != nil {
+		return 0, err
+	}
+	if len(packet) > len(b) {
+		return 0, common.NewBasicError(ErrBufferTooSmall, nil,
+			"have", len(b), "want", len(packet))
+	}
+	copy(b, packet)
+	r.deleteData(len(packet))
+	return len(packet), nil
+}
+
+// next returns the next packet, reading from the connection if no packet is
+// buffered. The packet references the internal buffer; it is valid until it
+// is deleted by calling deleteData with its length.
+func (r *ReadPacketizer) next() ([]byte, error) {
 	for {
 		if packet := r.haveNextPacket(r.data); packet != nil {
-			if len(packet) > len(b) {
-				return 0, common.NewBasicError(ErrBufferTooSmall, nil,
-					"have", len(b), "want", len(packet))
-			}
-			copy(b, packet)
-			r.deleteData(len(packet))
-			return len(packet), nil
+			return packet, nil
 		}
 		n, err := r.conn.Read(r.freeSpace)
 		if err != nil {
-			return 0, err
+			return nil, err
 		}
 		r.addData(n)
 	}
 }
 
+// buffered returns whether a complete packet is buffered, i.e., whether the
+// next call to next does not read from the connection.
+func (r *ReadPacketizer) buffered() bool {
+	return r.haveNextPacket(r.data) != nil
+}
+
 func (r *ReadPacketizer) deleteData(count int) {
 	copy(r.buffer[:], r.buffer[count:r.availableData()])
 	r.updateSlices(r.availableData() - count)
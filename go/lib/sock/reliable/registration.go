@@ -24,17 +24,45 @@ import (
 type CommandBitField uint8
 
 const (
+	CmdProtocolV2  CommandBitField = 0x08
 	CmdBindAddress CommandBitField = 0x04
 	CmdEnableSCMP  CommandBitField = 0x02
 	CmdAlwaysOn    CommandBitField = 0x01
 )
 
 // Registration contains metadata for a SCION Dispatcher registration message.
+const (
+	// ProtocolV1 is the original protocol, in which each packet is framed on
+	// the UNIX stream socket.
+	ProtocolV1 uint8 = 1
+	// ProtocolV2 extends ProtocolV1 with the options in V2Flags.
+	ProtocolV2 uint8 = 2
+)
+
+// V2Flags are the options of protocol version 2. Clients request options in
+// the registration, and the server grants a subset of them in the
+// confirmation.
+type V2Flags uint8
+
+const (
+	// V2SeqPacket moves the traffic of the registration to a SOCK_SEQPACKET
+	// socket that is passed to the client with the confirmation. Each
+	// SEQPACKET message contains a batch of one or more frames.
+	V2SeqPacket V2Flags = 0x01
+	// V2SupportedFlags contains all options supported by this implementation.
+	V2SupportedFlags = V2SeqPacket
+)
+
 type Registration struct {
 	IA            addr.IA
 	PublicAddress *net.UDPAddr
 	BindAddress   *net.UDPAddr
 	SVCAddress    addr.HostSVC
+	// Version is the protocol version requested by the client. Zero means
+	// ProtocolV1.
+	Version uint8
+	// Flags are the requested options if Version is ProtocolV2.
+	Flags V2Flags
 }
 
 func (r *Registration) SerializeTo(b []byte) (int, error) {
@@ -53,6 +81,10 @@ func (r *Registration) SerializeTo(b []byte) (int, error) {
 		msg.BindData = &bindAddress
 		bindAddress.SetFromUDPAddr(r.BindAddress)
 	}
+	if r.Version >= ProtocolV2 {
+		msg.Command |= CmdProtocolV2
+		msg.Flags = r.Flags
+	}
 	if r.SVCAddress != addr.SvcNone {
 		buffer := make([]byte, 2)
 		common.Order.PutUint16(buffer, uint16(r.SVCAddress))
@@ -85,6 +117,11 @@ func (r *Registration) DecodeFromBytes(b []byte) error {
 			Port: int(msg.BindData.Port),
 		}
 	}
+	r.Version, r.Flags = 0, 0
+	if (msg.Command & CmdProtocolV2) != 0 {
+		r.Version = ProtocolV2
+		r.Flags = msg.Flags
+	}
 	return nil
 }
 
@@ -96,7 +133,9 @@ type registrationMessage struct {
 	IA         uint64
 	PublicData registrationAddressField
 	BindData   *registrationAddressField
-	SVC        []byte
+	// Flags is only present on the wire if CmdProtocolV2 is set.
+	Flags V2Flags
+	SVC   []byte
 }
 
 func (m *registrationMessage) SerializeTo(b []byte) (int, error) {
@@ -117,6 +156,13 @@ func (m *registrationMessage) SerializeTo(b []byte) (int, error) {
 		}
 		offset += m.BindData.length()
 	}
+	if (m.Command & CmdProtocolV2) != 0 {
+		if len(b) < offset+1 {
+			return 0, common.NewBasicError(ErrBufferTooSmall, nil)
+		}
+		b[offset] = byte(m.Flags)
+		offset++
+	}
 	copy(b[offset:], m.SVC)
 	offset += len(m.SVC)
 	return offset, nil
@@ -141,6 +187,13 @@ func (l *registrationMessage) DecodeFromBytes(b []byte) error {
 		}
 		offset += l.BindData.length()
 	}
+	if (l.Command & CmdProtocolV2) != 0 {
+		if len(b) < offset+1 {
+			return common.NewBasicError(ErrIncompleteMessage, nil)
+		}
+		l.Flags = V2Flags(b[offset])
+		offset++
+	}
 	switch len(b[offset:]) {
 	case 0:
 		return nil
@@ -198,16 +251,28 @@ func (l *registrationAddressField) length() int {
 	return 2 + 1 + len(l.Address)
 }
 
+// Confirmation is the reply of the server to a registration. It contains the
+// 2-byte L4 port. If the server accepted a ProtocolV2 registration, the port is
+// followed by the 1-byte protocol version and the 1-byte granted V2 flags.
 type Confirmation struct {
 	Port uint16
+	// Version is the protocol version accepted by the server. Zero means
+	// ProtocolV1.
+	Version uint8
+	// Flags are the granted options if Version is ProtocolV2.
+	Flags V2Flags
 }
 
 func (c *Confirmation) SerializeTo(b []byte) (int, error) {
-	if len(b) < 2 {
+	if len(b) < c.length() {
 		return 0, common.NewBasicError(ErrBufferTooSmall, nil)
 	}
 	common.Order.PutUint16(b, c.Port)
-	return 2, nil
+	if c.Version >= ProtocolV2 {
+		b[2] = c.Version
+		b[3] = byte(c.Flags)
+	}
+	return c.length(), nil
 }
 
 func (c *Confirmation) DecodeFromBytes(b []byte) error {
@@ -215,5 +280,21 @@ func (c *Confirmation) DecodeFromBytes(b []byte) error {
 		return common.NewBasicError(ErrIncompletePort, nil)
 	}
 	c.Port = common.Order.Uint16(b)
+	c.Version, c.Flags = 0, 0
+	if len(b) < 4 {
+		return nil
+	}
+	if b[2] != ProtocolV2 {
+		return common.NewBasicError(ErrBadVersion, nil, "version", b[2])
+	}
+	c.Version = b[2]
+	c.Flags = V2Flags(b[3])
 	return nil
 }
+
+func (c *Confirmation) length() int {
+	if c.Version >= ProtocolV2 {
+		return 4
+	}
+	return 2
+}
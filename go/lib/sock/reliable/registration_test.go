@@ -104,6 +104,18 @@ func TestRegistrationMessageSerializeTo(t *testing.T) {
 				0, 80, 1, 10, 2, 3, 4,
 				0, 81, 1, 10, 5, 6, 7, 0, 2},
 		},
+		{
+			Name: "protocol v2 with SVC",
+			Registration: &Registration{
+				IA:            xtest.MustParseIA("1-ff00:0:1"),
+				PublicAddress: &net.UDPAddr{IP: net.IP{10, 2, 3, 4}, Port: 80},
+				SVCAddress:    addr.SvcCS,
+				Version:       ProtocolV2,
+				Flags:         V2SeqPacket,
+			},
+			ExpectedData: []byte{0x0b, 17, 0, 1, 0xff, 0, 0, 0, 0, 0x01,
+				0, 80, 1, 10, 2, 3, 4, 0x01, 0, 2},
+		},
 	}
 	Convey("", t, func() {
 		for _, tc := range testCases {
@@ -237,6 +249,26 @@ func TestRegistrationMessageDecodeFromBytes(t *testing.T) {
 				SVCAddress:    addr.SvcPS,
 			},
 		},
+		{
+			Name: "protocol v2 with bind and SVC",
+			Data: []byte{0x0f, 17, 0, 1, 0xff, 0, 0, 0, 0, 0x01,
+				0, 80, 1, 10, 2, 3, 4,
+				0, 81, 1, 10, 5, 6, 7,
+				0x01, 0x00, 0x01},
+			ExpectedRegistration: Registration{
+				IA:            xtest.MustParseIA("1-ff00:0:1"),
+				PublicAddress: &net.UDPAddr{IP: net.IP{10, 2, 3, 4}, Port: 80},
+				BindAddress:   &net.UDPAddr{IP: net.IP{10, 5, 6, 7}, Port: 81},
+				SVCAddress:    addr.SvcPS,
+				Version:       ProtocolV2,
+				Flags:         V2SeqPacket,
+			},
+		},
+		{
+			Name:          "protocol v2 without flags",
+			Data:          []byte{0x0b, 17, 0, 1, 0xff, 0, 0, 0, 0, 0x01, 0, 80, 1, 10, 2, 3, 4},
+			ExpectedError: ErrIncompleteMessage,
+		},
 	}
 	Convey("", t, func() {
 		for _, tc := range testCases {
@@ -265,6 +297,14 @@ func TestConfirmationMessageSerializeTo(t *testing.T) {
 			SoMsg("err", err, ShouldBeNil)
 			SoMsg("data", b[:n], ShouldResemble, []byte{0xaa, 0xbb})
 		})
+		Convey("protocol v2", func() {
+			confirmation.Version = ProtocolV2
+			confirmation.Flags = V2SeqPacket
+			b := make([]byte, 1500)
+			n, err := confirmation.SerializeTo(b)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("data", b[:n], ShouldResemble, []byte{0xaa, 0xbb, 2, 0x01})
+		})
 	})
 }
 
@@ -283,5 +323,17 @@ func TestConfirmationDecodeFromBytes(t *testing.T) {
 			SoMsg("err", err, ShouldBeNil)
 			SoMsg("data", confirmation, ShouldResemble, Confirmation{Port: 0xaabb})
 		})
+		Convey("protocol v2", func() {
+			b := []byte{0xaa, 0xbb, 2, 0x01}
+			err := confirmation.DecodeFromBytes(b)
+			SoMsg("err", err, ShouldBeNil)
+			SoMsg("data", confirmation, ShouldResemble,
+				Confirmation{Port: 0xaabb, Version: ProtocolV2, Flags: V2SeqPacket})
+		})
+		Convey("bad version", func() {
+			b := []byte{0xaa, 0xbb, 3, 0x01}
+			err := confirmation.DecodeFromBytes(b)
+			xtest.SoMsgErrorStr("err", err, ErrBadVersion)
+		})
 	})
 }
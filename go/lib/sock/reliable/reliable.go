@@ -42,8 +42,32 @@
 //  +2-bytes: L4 bind port  \
 //  +1-byte: Address type    ) (optional bind address)
 //  +var-byte: Bind Address /
+//  +1-byte: V2 flags (only if Command has 0x08=Protocol v2 set)
 //  +2-bytes: SVC (optional SVC type)
 //
+// ReliableSocket protocol version 2:
+//
+// Clients request version 2 by setting the Protocol v2 bit in the
+// registration command, followed by the requested options (see V2Flags). A
+// server that supports version 2 appends the accepted version and the granted
+// options to the confirmation (see Confirmation). Servers that only support
+// version 1 do not expect the V2 flags byte, so they reject the registration
+// and close the connection. RegisterV2 then repeats the registration with
+// version 1, and the connection continues with version 1.
+//
+// If the V2SeqPacket option is granted, the server creates a SOCK_SEQPACKET
+// socket pair and passes one end to the client (SCM_RIGHTS) together with the
+// confirmation. Both ends then close the stream socket, and the traffic of the
+// registration uses the SEQPACKET socket. Each SEQPACKET message contains a
+// batch of one or more frames in the common header format, so a batch of
+// packets is read or written with a single system call.
+//
+// Independently of the version, ReadBatch and WriteBatch read and write
+// multiple packets at once. Writes do not copy the payloads.
+//
+// Shared-memory rings between applications and the dispatcher are not
+// supported; SEQPACKET batching is the only transport option of version 2.
+//
 // To communicate with SCIOND, clients must first connect to SCIOND's UNIX socket. Messages
 // for SCIOND must set the ADDR TYPE field in the common header to NONE. The payload contains
 // the query for SCIOND (e.g., a request for paths to a SCION destination). The reply header
@@ -63,8 +87,10 @@ package reliable
 
 import (
 	"fmt"
+	"io"
 	"net"
 	"sync"
+	"syscall"
 	"time"
 
 	"github.com/scionproto/scion/go/lib/addr"
@@ -100,20 +126,34 @@ func NewDispatcherService(name string) DispatcherService {
 	return &dispatcherService{Address: name}
 }
 
+// NewDispatcherServiceV2 acts like NewDispatcherService, but the returned
+// service requests ProtocolV2 with the options in flags. See RegisterV2.
+func NewDispatcherServiceV2(name string, flags V2Flags) DispatcherService {
+	if name == "" {
+		name = DefaultDispPath
+	}
+	return &dispatcherService{Address: name, Version: ProtocolV2, Flags: flags}
+}
+
 type dispatcherService struct {
 	Address string
+	Version uint8
+	Flags   V2Flags
 }
 
 func (d *dispatcherService) Register(ia addr.IA, public *addr.AppAddr, bind *overlay.OverlayAddr,
 	svc addr.HostSVC) (net.PacketConn, uint16, error) {
 
-	return Register(d.Address, ia, public, bind, svc)
+	return d.RegisterTimeout(ia, public, bind, svc, 0)
 }
 
 func (d *dispatcherService) RegisterTimeout(ia addr.IA, public *addr.AppAddr,
 	bind *overlay.OverlayAddr, svc addr.HostSVC,
 	timeout time.Duration) (net.PacketConn, uint16, error) {
 
+	if d.Version >= ProtocolV2 {
+		return RegisterV2(d.Address, ia, public, bind, svc, timeout, d.Flags)
+	}
 	return RegisterTimeout(d.Address, ia, public, bind, svc, timeout)
 }
 
@@ -125,12 +165,16 @@ type Conn struct {
 	*net.UnixConn
 
 	readMutex      sync.Mutex
-	readBuffer     []byte
 	readPacketizer *ReadPacketizer
 
 	writeMutex    sync.Mutex
 	writeBuffer   []byte
 	writeStreamer *WriteStreamer
+
+	// version is the negotiated protocol version.
+	version uint8
+	// packet is set if conn is a SOCK_SEQPACKET socket (see V2SeqPacket).
+	packet bool
 }
 
 func newConn(c net.Conn) *Conn {
@@ -139,8 +183,8 @@ func newConn(c net.Conn) *Conn {
 		UnixConn:       c.(*net.UnixConn),
 		writeBuffer:    make([]byte, defBufSize),
 		writeStreamer:  NewWriteStreamer(conn),
-		readBuffer:     make([]byte, defBufSize),
 		readPacketizer: NewReadPacketizer(conn),
+		version:        ProtocolV1,
 	}
 }
 
@@ -183,6 +227,27 @@ func Register(dispatcher string, ia addr.IA, public *addr.AppAddr, bind *overlay
 func RegisterTimeout(dispatcher string, ia addr.IA, public *addr.AppAddr,
 	bind *overlay.OverlayAddr, svc addr.HostSVC, timeout time.Duration) (*Conn, uint16, error) {
 
+	return register(dispatcher, ia, public, bind, svc, timeout, ProtocolV1, 0)
+}
+
+// RegisterV2 acts like RegisterTimeout, but requests ProtocolV2 with the
+// options in flags. If the dispatcher closes the connection without
+// confirming the ProtocolV2 registration, as dispatchers that only support
+// ProtocolV1 do, it is repeated with ProtocolV1 within the same timeout. If the dispatcher does
+// not grant some of the options, the registration still succeeds. The
+// negotiated protocol is available via the Version and SeqPacket methods of
+// the returned Conn.
+func RegisterV2(dispatcher string, ia addr.IA, public *addr.AppAddr,
+	bind *overlay.OverlayAddr, svc addr.HostSVC, timeout time.Duration,
+	flags V2Flags) (*Conn, uint16, error) {
+
+	return register(dispatcher, ia, public, bind, svc, timeout, ProtocolV2, flags)
+}
+
+func register(dispatcher string, ia addr.IA, public *addr.AppAddr,
+	bind *overlay.OverlayAddr, svc addr.HostSVC, timeout time.Duration,
+	version uint8, flags V2Flags) (*Conn, uint16, error) {
+
 	publicUDP, err := createUDPAddrFromAppAddr(public)
 	if err != nil {
 		return nil, 0, err
@@ -197,10 +262,35 @@ func RegisterTimeout(dispatcher string, ia addr.IA, public *addr.AppAddr,
 		PublicAddress: publicUDP,
 		BindAddress:   bindUDP,
 		SVCAddress:    svc,
+		Version:       version,
+		Flags:         flags,
 	}
 
 	// Compute deadline prior to Dial, because timeout is relative to current time.
 	deadline := time.Now().Add(timeout)
+	conn, port, err := registerWithDeadline(dispatcher, reg, timeout, deadline)
+	if err == nil || version < ProtocolV2 || common.GetErrorMsg(err) != ErrNoConfirmation {
+		return conn, port, err
+	}
+	// Servers that only support ProtocolV1 do not expect the V2 flags byte,
+	// and close the connection without a confirmation. Retry with a
+	// ProtocolV1 registration.
+	if timeout != 0 {
+		if timeout = time.Until(deadline); timeout <= 0 {
+			return nil, 0, err
+		}
+	}
+	reg.Version, reg.Flags = ProtocolV1, 0
+	return registerWithDeadline(dispatcher, reg, timeout, deadline)
+}
+
+// registerWithDeadline sends the registration to the dispatcher and waits for
+// the confirmation. If timeout is not 0, the registration must complete
+// before deadline. If the dispatcher closes the connection without a
+// confirmation, the returned error is ErrNoConfirmation.
+func registerWithDeadline(dispatcher string, reg *Registration, timeout time.Duration,
+	deadline time.Time) (*Conn, uint16, error) {
+
 	conn, err := DialTimeout(dispatcher, timeout)
 	if err != nil {
 		return nil, 0, err
@@ -222,23 +312,48 @@ func RegisterTimeout(dispatcher string, ia addr.IA, public *addr.AppAddr,
 		return nil, 0, err
 	}
 
-	n, _, err = conn.ReadFrom(b)
+	// The data socket of V2SeqPacket is passed together with the
+	// confirmation.
+	var fds []int
+	if reg.Version >= ProtocolV2 && (reg.Flags&V2SeqPacket) != 0 {
+		n, fds, err = conn.readWithRights(b)
+	} else {
+		n, _, err = conn.ReadFrom(b)
+	}
 	if err != nil {
 		conn.Close()
+		if err == io.EOF || IsSpecificSysError(err, syscall.ECONNRESET) {
+			return nil, 0, common.NewBasicError(ErrNoConfirmation, err)
+		}
 		return nil, 0, err
 	}
 
 	var c Confirmation
 	err = c.DecodeFromBytes(b[:n])
 	if err != nil {
+		closeFDs(fds)
 		conn.Close()
 		return nil, 0, err
 	}
-	if publicUDP.Port != 0 && publicUDP.Port != int(c.Port) {
+	if reg.PublicAddress.Port != 0 && reg.PublicAddress.Port != int(c.Port) {
+		closeFDs(fds)
 		conn.Close()
-		return nil, 0, common.NewBasicError("port mismatch", nil, "requested", publicUDP.Port,
-			"received", c.Port)
+		return nil, 0, common.NewBasicError("port mismatch", nil,
+			"requested", reg.PublicAddress.Port, "received", c.Port)
 	}
+	if c.Version >= ProtocolV2 {
+		conn.version = c.Version
+	}
+	if c.Version >= ProtocolV2 && (c.Flags&V2SeqPacket) != 0 {
+		dataConn, err := newSeqPacketConn(fds)
+		conn.Close()
+		if err != nil {
+			return nil, 0, err
+		}
+		dataConn.version = c.Version
+		return dataConn, c.Port, nil
+	}
+	closeFDs(fds)
 	// Disable deadline to not affect calling code
 	conn.SetDeadline(time.Time{})
 	return conn, c.Port, nil
@@ -250,17 +365,26 @@ func (conn *Conn) ReadFrom(buf []byte) (int, net.Addr, error) {
 	conn.readMutex.Lock()
 	defer conn.readMutex.Unlock()
 
-	n, err := conn.readPacketizer.Read(conn.readBuffer)
+	return conn.readFrom(buf)
+}
+
+// readFrom decodes the next packet directly from the buffer of the
+// packetizer. The caller must hold the read lock.
+func (conn *Conn) readFrom(buf []byte) (int, net.Addr, error) {
+	packet, err := conn.readPacketizer.next()
 	if err != nil {
 		return 0, nil, err
 	}
+	defer conn.readPacketizer.deleteData(len(packet))
 	var p OverlayPacket
-	p.DecodeFromBytes(conn.readBuffer[:n])
+	p.DecodeFromBytes(packet)
 	var overlayAddr *overlay.OverlayAddr
 	if p.Address != nil {
 		var err error
 		overlayAddr, err = overlay.NewOverlayAddr(
-			addr.HostFromIP(p.Address.IP),
+			// The address references the buffer of the packetizer, which is
+			// reused by the next read.
+			addr.HostFromIP(append(net.IP(nil), p.Address.IP...)),
 			addr.NewL4UDPInfo(uint16(p.Address.Port)),
 		)
 		if err != nil {
@@ -279,18 +403,17 @@ func (conn *Conn) ReadFrom(buf []byte) (int, net.Addr, error) {
 // On error, the number of bytes returned is meaningless. On success, the number of bytes
 // is always len(buf).
 func (conn *Conn) WriteTo(buf []byte, dst net.Addr) (int, error) {
+	if conn.packet {
+		if _, err := conn.WriteBatch([]Message{{Buffer: buf, Addr: dst}}); err != nil {
+			return 0, err
+		}
+		return len(buf), nil
+	}
 	conn.writeMutex.Lock()
 	defer conn.writeMutex.Unlock()
 
-	var publicAddress *net.UDPAddr
-	if dst != nil {
-		overlayAddr := dst.(*overlay.OverlayAddr)
-		if overlayAddr != nil {
-			publicAddress = overlayAddr.ToUDPAddr()
-		}
-	}
 	p := &OverlayPacket{
-		Address: publicAddress,
+		Address: toUDPAddr(dst),
 		Payload: buf,
 	}
 	n, err := p.SerializeTo(conn.writeBuffer)
@@ -313,6 +436,17 @@ func (conn *Conn) Read(buf []byte) (int, error) {
 	return n, err
 }
 
+// Version returns the negotiated protocol version.
+func (conn *Conn) Version() uint8 {
+	return conn.version
+}
+
+// SeqPacket returns whether conn is a SOCK_SEQPACKET socket, i.e., whether
+// V2SeqPacket was granted.
+func (conn *Conn) SeqPacket() bool {
+	return conn.packet
+}
+
 // Listener listens on Unix sockets and returns Conn sockets on Accept().
 type Listener struct {
 	*net.UnixListener
@@ -341,6 +475,19 @@ func (listener *Listener) String() string {
 	return fmt.Sprintf("&{addr: %v}", listener.UnixListener.Addr())
 }
 
+// toUDPAddr returns the UDP address of the overlay address dst, or nil if
+// dst is nil.
+func toUDPAddr(dst net.Addr) *net.UDPAddr {
+	if dst == nil {
+		return nil
+	}
+	overlayAddr := dst.(*overlay.OverlayAddr)
+	if overlayAddr == nil {
+		return nil
+	}
+	return overlayAddr.ToUDPAddr()
+}
+
 func createUDPAddrFromAppAddr(address *addr.AppAddr) (*net.UDPAddr, error) {
 	if address == nil || address.L3 == nil {
 		return nil, common.NewBasicError("nil application address", nil)
@@ -0,0 +1,170 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package reliable
+
+import (
+	"net"
+	"os"
+	"syscall"
+
+	"github.com/scionproto/scion/go/lib/common"
+)
+
+// Confirm sends the confirmation c to the client at the other end of conn.
+//
+// If c grants V2SeqPacket, Confirm creates a SOCK_SEQPACKET socket pair,
+// passes one end to the client together with the confirmation, and returns a
+// Conn for the other end. The caller should then close conn and use the
+// returned Conn for the traffic of the registration. Otherwise, conn is
+// returned.
+func (conn *Conn) Confirm(c *Confirmation) (*Conn, error) {
+	b := make([]byte, 16)
+	n, err := c.SerializeTo(b)
+	if err != nil {
+		return nil, err
+	}
+	if c.Version < ProtocolV2 || (c.Flags&V2SeqPacket) == 0 {
+		if _, err := conn.WriteTo(b[:n], nil); err != nil {
+			return nil, err
+		}
+		return conn, nil
+	}
+
+	frame := make([]byte, 32)
+	p := &OverlayPacket{Payload: b[:n]}
+	n, err = p.SerializeTo(frame)
+	if err != nil {
+		return nil, err
+	}
+	fds, err := socketpair()
+	if err != nil {
+		return nil, err
+	}
+	dataConn, err := newSeqPacketConn(fds[:1])
+	if err != nil {
+		syscall.Close(fds[1])
+		return nil, err
+	}
+	dataConn.version = c.Version
+	conn.writeMutex.Lock()
+	written, _, err := conn.UnixConn.WriteMsgUnix(frame[:n], syscall.UnixRights(fds[1]), nil)
+	conn.writeMutex.Unlock()
+	// The socket is duplicated into the client when the message is sent.
+	syscall.Close(fds[1])
+	if err == nil && written != n {
+		err = common.NewBasicError("Incomplete confirmation", nil, "written", written,
+			"length", n)
+	}
+	if err != nil {
+		dataConn.Close()
+		return nil, err
+	}
+	return dataConn, nil
+}
+
+// socketpair returns a pair of connected SOCK_SEQPACKET sockets.
+func socketpair() ([]int, error) {
+	syscall.ForkLock.RLock()
+	defer syscall.ForkLock.RUnlock()
+	fds, err := syscall.Socketpair(syscall.AF_UNIX, syscall.SOCK_SEQPACKET, 0)
+	if err != nil {
+		return nil, os.NewSyscallError("socketpair", err)
+	}
+	syscall.CloseOnExec(fds[0])
+	syscall.CloseOnExec(fds[1])
+	return fds[:], nil
+}
+
+// newSeqPacketConn returns a Conn for the SOCK_SEQPACKET socket in fds. The
+// socket is closed if an error is returned, and fds must contain exactly one
+// socket.
+func newSeqPacketConn(fds []int) (*Conn, error) {
+	if len(fds) != 1 {
+		closeFDs(fds)
+		return nil, common.NewBasicError(ErrNoSocket, nil, "sockets", len(fds))
+	}
+	f := os.NewFile(uintptr(fds[0]), "reliable")
+	// FileConn duplicates the socket, so f is always closed.
+	defer f.Close()
+	typ, err := syscall.GetsockoptInt(fds[0], syscall.SOL_SOCKET, syscall.SO_TYPE)
+	if err != nil {
+		return nil, os.NewSyscallError("getsockopt", err)
+	}
+	if typ != syscall.SOCK_SEQPACKET {
+		return nil, common.NewBasicError(ErrNoSocket, nil, "type", typ)
+	}
+	c, err := net.FileConn(f)
+	if err != nil {
+		return nil, err
+	}
+	conn := newConn(c)
+	conn.packet = true
+	return conn, nil
+}
+
+// readWithRights acts like ReadFrom, but also returns the file descriptors
+// that were passed with the first message read from the socket.
+func (conn *Conn) readWithRights(buf []byte) (int, []int, error) {
+	conn.readMutex.Lock()
+	defer conn.readMutex.Unlock()
+
+	r := conn.readPacketizer
+	oob := make([]byte, syscall.CmsgSpace(4))
+	n, oobn, _, _, err := conn.UnixConn.ReadMsgUnix(r.freeSpace, oob)
+	if err != nil {
+		return 0, nil, err
+	}
+	// If the message contains more than the first frame, the rest stays in
+	// the buffer of the packetizer.
+	r.addData(n)
+	fds, err := parseRights(oob[:oobn])
+	if err != nil {
+		return 0, nil, err
+	}
+	n, _, err = conn.readFrom(buf)
+	if err != nil {
+		closeFDs(fds)
+		return 0, nil, err
+	}
+	return n, fds, nil
+}
+
+// parseRights returns the file descriptors in the control messages in oob.
+func parseRights(oob []byte) ([]int, error) {
+	msgs, err := syscall.ParseSocketControlMessage(oob)
+	if err != nil {
+		return nil, os.NewSyscallError("parse control message", err)
+	}
+	var fds []int
+	for i := range msgs {
+		if msgs[i].Header.Level != syscall.SOL_SOCKET ||
+			msgs[i].Header.Type != syscall.SCM_RIGHTS {
+			continue
+		}
+		rights, err := syscall.ParseUnixRights(&msgs[i])
+		if err != nil {
+			closeFDs(fds)
+			return nil, os.NewSyscallError("parse rights", err)
+		}
+		fds = append(fds, rights...)
+	}
+	return fds, nil
+}
+
+func closeFDs(fds []int) {
+	for _, fd := range fds {
+		syscall.Close(fd)
+	}
+}
@@ -0,0 +1,76 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+// Package tokenbucket implements a token bucket rate limiter for byte rates.
+package tokenbucket
+
+import (
+	"sync"
+	"time"
+)
+
+// Bucket is a token bucket that limits a byte rate. Tokens are bytes, which
+// are added at the configured rate up to the burst size. The bucket starts
+// full.
+//
+// Bucket is safe for concurrent use from multiple goroutines. A nil Bucket
+// allows everything.
+type Bucket struct {
+	mtx    sync.Mutex
+	rate   float64
+	burst  float64
+	tokens float64
+	last   time.Time
+}
+
+// New returns a bucket that is refilled with rate bytes per second up to
+// burst bytes. If burst is zero, it is set to rate. If rate is zero, nil is
+// returned.
+func New(rate, burst uint64) *Bucket {
+	if rate == 0 {
+		return nil
+	}
+	if burst == 0 {
+		burst = rate
+	}
+	return &Bucket{
+		rate:   float64(rate),
+		burst:  float64(burst),
+		tokens: float64(burst),
+		last:   time.Now(),
+	}
+}
+
+// Allow takes n tokens from the bucket at time now, and returns true. If the
+// bucket contains less than n tokens, no tokens are taken and false is
+// returned.
+func (b *Bucket) Allow(now time.Time, n int) bool {
+	if b == nil {
+		return true
+	}
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	if elapsed := now.Sub(b.last); elapsed > 0 {
+		b.tokens += elapsed.Seconds() * b.rate
+		if b.tokens > b.burst {
+			b.tokens = b.burst
+		}
+		b.last = now
+	}
+	if b.tokens < float64(n) {
+		return false
+	}
+	b.tokens -= float64(n)
+	return true
+}
@@ -0,0 +1,56 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package tokenbucket
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBucket(t *testing.T) {
+	t.Run("nil bucket allows everything", func(t *testing.T) {
+		b := New(0, 100)
+		assert.Nil(t, b)
+		assert.True(t, b.Allow(time.Now(), 1<<20))
+	})
+	t.Run("bucket starts full", func(t *testing.T) {
+		b := New(100, 300)
+		now := b.last
+		assert.True(t, b.Allow(now, 300))
+		assert.False(t, b.Allow(now, 1))
+	})
+	t.Run("burst defaults to rate", func(t *testing.T) {
+		b := New(100, 0)
+		now := b.last
+		assert.False(t, b.Allow(now, 101))
+		assert.True(t, b.Allow(now, 100))
+	})
+	t.Run("bucket is refilled with rate", func(t *testing.T) {
+		b := New(100, 300)
+		now := b.last
+		assert.True(t, b.Allow(now, 300))
+		now = now.Add(time.Second)
+		assert.False(t, b.Allow(now, 101))
+		assert.True(t, b.Allow(now, 100))
+	})
+	t.Run("bucket is refilled up to burst", func(t *testing.T) {
+		b := New(100, 300)
+		now := b.last.Add(time.Minute)
+		assert.False(t, b.Allow(now, 301))
+		assert.True(t, b.Allow(now, 300))
+	})
+}
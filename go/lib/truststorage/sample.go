@@ -15,10 +15,11 @@
 package truststorage
 
 const trustDbSample = `
-# The type of trustdb backend. (default sqlite)
+# The type of trustdb backend, either "sqlite" or "postgres". (default sqlite)
 Backend = "sqlite"
 
-# Connection for the trust database.
+# Connection for the trust database. This is the path to the database file for
+# the "sqlite" backend, or the connection string for the "postgres" backend.
 Connection = "/var/lib/scion/spki/%s.trust.db"
 
 # The maximum number of open connections to the database. In case of the
@@ -25,6 +25,7 @@ import (
 	"github.com/scionproto/scion/go/lib/config"
 	"github.com/scionproto/scion/go/lib/infra/modules/db"
 	"github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb"
+	"github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb/trustdbpostgres"
 	"github.com/scionproto/scion/go/lib/infra/modules/trust/trustdb/trustdbsqlite"
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/util"
@@ -33,8 +34,9 @@ import (
 type Backend string
 
 const (
-	BackendNone   Backend = ""
-	BackendSqlite Backend = "sqlite"
+	BackendNone     Backend = ""
+	BackendSqlite   Backend = "sqlite"
+	BackendPostgres Backend = "postgres"
 )
 
 const (
@@ -95,7 +97,7 @@ func (cfg *TrustDBConf) Validate() error {
 
 func (cfg *TrustDBConf) validateBackend() error {
 	switch cfg.Backend() {
-	case BackendSqlite:
+	case BackendSqlite, BackendPostgres:
 		return nil
 	case BackendNone:
 		return common.NewBasicError("No backend set", nil)
@@ -112,6 +114,8 @@ func (cfg *TrustDBConf) New() (trustdb.TrustDB, error) {
 	switch cfg.Backend() {
 	case BackendSqlite:
 		tdb, err = trustdbsqlite.New(cfg.Connection())
+	case BackendPostgres:
+		tdb, err = trustdbpostgres.New(cfg.Connection())
 	default:
 		return nil, common.NewBasicError("Unsupported backend", nil, "backend", cfg.Backend())
 	}
@@ -41,6 +41,7 @@ type Config struct {
 	Metrics   env.Metrics
 	Tracing   env.Tracing
 	QUIC      env.QUIC `toml:"quic"`
+	Trust     env.Trust
 	TrustDB   truststorage.TrustDBConf
 	Discovery idiscovery.Config
 	PS        PSConfig
@@ -63,6 +64,7 @@ func (cfg *Config) Validate() error {
 		&cfg.General,
 		&cfg.Logging,
 		&cfg.Metrics,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.Discovery,
 		&cfg.PS,
@@ -76,6 +78,7 @@ func (cfg *Config) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
 		&cfg.Metrics,
 		&cfg.Tracing,
 		&cfg.QUIC,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.Discovery,
 		&cfg.PS,
@@ -100,6 +103,10 @@ type PSConfig struct {
 	// CryptoSyncInterval specifies the interval of crypto pushes towards
 	// the local CS.
 	CryptoSyncInterval util.DurWrap
+	// HiddenPathGroups contains the file path for the hidden path groups
+	// configuration. If this is the empty string, the path server does not
+	// act as a hidden path registry.
+	HiddenPathGroups string
 }
 
 func (cfg *PSConfig) InitDefaults() {
@@ -43,6 +43,7 @@ func TestConfigSample(t *testing.T) {
 
 func InitTestConfig(cfg *Config) {
 	envtest.InitTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, nil)
+	envtest.InitTestTrust(&cfg.Trust)
 	truststoragetest.InitTestConfig(&cfg.TrustDB)
 	idiscoverytest.InitTestConfig(&cfg.Discovery)
 	InitTestPSConfig(&cfg.PS)
@@ -50,12 +51,14 @@ func InitTestConfig(cfg *Config) {
 
 func InitTestPSConfig(cfg *PSConfig) {
 	cfg.SegSync = true
+	cfg.HiddenPathGroups = "test"
 	pathstoragetest.InitTestPathDBConf(&cfg.PathDB)
 	pathstoragetest.InitTestRevCacheConf(&cfg.RevCache)
 }
 
 func CheckTestConfig(cfg *Config, id string) {
 	envtest.CheckTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, nil, id)
+	envtest.CheckTestTrust(&cfg.Trust)
 	truststoragetest.CheckTestConfig(&cfg.TrustDB, id)
 	idiscoverytest.CheckTestConfig(&cfg.Discovery)
 	CheckTestPSConfig(&cfg.PS, id)
@@ -68,4 +71,5 @@ func CheckTestPSConfig(cfg *PSConfig, id string) {
 	SoMsg("QueryInterval correct", cfg.QueryInterval.Duration, ShouldEqual, DefaultQueryInterval)
 	SoMsg("CryptoSyncInterval correct", cfg.CryptoSyncInterval.Duration,
 		ShouldEqual, DefaultCryptoSyncInterval)
+	SoMsg("HiddenPathGroups correct", cfg.HiddenPathGroups, ShouldEqual, "")
 }
@@ -26,4 +26,8 @@ QueryInterval = "5m"
 
 # The interval of crypto pushes towards the local CS. (default 30s)
 CryptoSyncInterval = "30s"
+
+# The file path for the hidden path groups configuration. In case of the empty
+# string, the path server does not act as a hidden path registry. (default "")
+HiddenPathGroups = ""
 `
@@ -27,6 +27,7 @@ import (
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
 	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/modules/segverifier"
 	"github.com/scionproto/scion/go/lib/log"
@@ -51,6 +52,8 @@ type HandlerArgs struct {
 	QueryInterval time.Duration
 	IA            addr.IA
 	TopoProvider  topology.Provider
+	// HiddenPathGroups are the hidden path groups known to the path server.
+	HiddenPathGroups hiddenpath.Groups
 }
 
 type baseHandler struct {
@@ -136,6 +139,15 @@ func (h *baseHandler) sleepOrTimeout(ctx context.Context) error {
 
 func (h *baseHandler) verifyAndStore(ctx context.Context, src net.Addr,
 	recs []*seg.Meta, revInfos []*path_mgmt.SignedRevInfo) error {
+
+	return h.verifyAndStoreWithHPCfgIDs(ctx, src, recs, revInfos,
+		[]*query.HPCfgID{&query.NullHpCfgID})
+}
+
+// verifyAndStoreWithHPCfgIDs verifies the segments and revocations and stores
+// the verified segments with the given hidden path config ids.
+func (h *baseHandler) verifyAndStoreWithHPCfgIDs(ctx context.Context, src net.Addr,
+	recs []*seg.Meta, revInfos []*path_mgmt.SignedRevInfo, hpCfgIDs []*query.HPCfgID) error {
 	// TODO(lukedirtwalker): collect the verified segs/revoc and return them.
 
 	logger := log.FromCtx(ctx)
@@ -175,7 +187,7 @@ func (h *baseHandler) verifyAndStore(ctx context.Context, src net.Addr,
 		return verifiedSegs[i].Segment.GetLoggingID() < verifiedSegs[j].Segment.GetLoggingID()
 	})
 	for _, s := range verifiedSegs {
-		n, err := tx.Insert(ctx, s)
+		n, err := tx.InsertWithHPCfgIDs(ctx, s, hpCfgIDs)
 		if err != nil {
 			if errRollback := tx.Rollback(); errRollback != nil {
 				err = common.NewBasicError("Unable to rollback", err, "rollbackErr", errRollback)
@@ -0,0 +1,158 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/lib/infra/messenger"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+	"github.com/scionproto/scion/go/lib/snet"
+	"github.com/scionproto/scion/go/proto"
+)
+
+const (
+	HPUnknownGroupErr    = "Unknown hidden path group"
+	HPNotRegistryErr     = "Not a registry of the hidden path group"
+	HPNotAuthorizedErr   = "Not authorized for the hidden path group"
+	HPInvalidSegmentsErr = "Invalid hidden segments"
+)
+
+type hpSegRegHandler struct {
+	*baseHandler
+	localIA addr.IA
+	groups  hiddenpath.Groups
+}
+
+// NewHPSegRegHandler creates a handler for hidden segment registrations. Only
+// down segments of writers of a group, for which the local AS is a registry,
+// are accepted.
+func NewHPSegRegHandler(args HandlerArgs) infra.Handler {
+	f := func(r *infra.Request) *infra.HandlerResult {
+		handler := &hpSegRegHandler{
+			baseHandler: newBaseHandler(r, args),
+			localIA:     args.IA,
+			groups:      args.HiddenPathGroups,
+		}
+		return handler.Handle()
+	}
+	return infra.HandlerFunc(f)
+}
+
+func (h *hpSegRegHandler) Handle() *infra.HandlerResult {
+	logger := log.FromCtx(h.request.Context())
+	hpSegReg, ok := h.request.Message.(*path_mgmt.HPSegReg)
+	if !ok {
+		logger.Error("[hpSegRegHandler] wrong message type, expected path_mgmt.HPSegReg",
+			"msg", h.request.Message, "type", common.TypeOf(h.request.Message))
+		return infra.MetricsErrInternal
+	}
+	rw, ok := infra.ResponseWriterFromContext(h.request.Context())
+	if !ok {
+		logger.Error("[hpSegRegHandler] Unable to service request, no Messenger found")
+		return infra.MetricsErrInternal
+	}
+	subCtx, cancelF := context.WithTimeout(h.request.Context(), HandlerTimeout)
+	defer cancelF()
+	sendAck := messenger.SendAckHelper(subCtx, rw)
+	if hpSegReg.HPSegRecs == nil || hpSegReg.GroupId == nil {
+		logger.Error("[hpSegRegHandler] Missing hidden segments or group id")
+		sendAck(proto.Ack_ErrCode_reject, messenger.AckRejectFailedToParse)
+		return infra.MetricsErrInvalid
+	}
+	if err := hpSegReg.ParseRaw(); err != nil {
+		logger.Error("[hpSegRegHandler] Failed to parse message", "err", err)
+		sendAck(proto.Ack_ErrCode_reject, messenger.AckRejectFailedToParse)
+		return infra.MetricsErrInvalid
+	}
+	logger.Debug("[hpSegRegHandler] Received HPSegRecs", "src", h.request.Peer,
+		"data", hpSegReg.HPSegRecs)
+
+	snetPeer := h.request.Peer.(*snet.Addr)
+	group, err := h.authorize(snetPeer.IA, hiddenpath.IdFromMsg(hpSegReg.GroupId))
+	if err != nil {
+		logger.Warn("[hpSegRegHandler] Rejecting registration", "peer", snetPeer.IA,
+			"err", err)
+		sendAck(proto.Ack_ErrCode_reject, common.GetErrorMsg(err))
+		return infra.MetricsErrInvalid
+	}
+	if err := validateHPSegs(snetPeer.IA, hpSegReg.HPSegRecs); err != nil {
+		logger.Warn("[hpSegRegHandler] Invalid hidden segments", "peer", snetPeer.IA,
+			"err", err)
+		sendAck(proto.Ack_ErrCode_reject, common.GetErrorMsg(err))
+		return infra.MetricsErrInvalid
+	}
+	peerPath, err := snetPeer.GetPath()
+	if err != nil {
+		logger.Error("[hpSegRegHandler] Failed to initialize path", "err", err)
+		sendAck(proto.Ack_ErrCode_reject, messenger.AckRejectFailedToParse)
+		return infra.MetricsErrInvalid
+	}
+	svcToQuery := &snet.Addr{
+		IA:      snetPeer.IA,
+		Path:    peerPath.Path(),
+		NextHop: peerPath.OverlayNextHop(),
+		Host:    addr.NewSVCUDPAppAddr(addr.SvcBS),
+	}
+	err = h.verifyAndStoreWithHPCfgIDs(subCtx, svcToQuery, hpSegReg.Recs, nil,
+		[]*query.HPCfgID{group.Id.HPCfgID()})
+	if err != nil {
+		sendAck(proto.Ack_ErrCode_reject, err.Error())
+		return infra.MetricsErrInvalid
+	}
+	sendAck(proto.Ack_ErrCode_ok, "")
+	return infra.MetricsResultOk
+}
+
+// authorize returns the group if the local AS is a registry of the group and
+// the peer is a writer of the group.
+func (h *hpSegRegHandler) authorize(peer addr.IA,
+	id hiddenpath.GroupId) (*hiddenpath.Group, error) {
+
+	group, ok := h.groups[id]
+	if !ok {
+		return nil, common.NewBasicError(HPUnknownGroupErr, nil, "id", id)
+	}
+	if !group.HasRegistry(h.localIA) {
+		return nil, common.NewBasicError(HPNotRegistryErr, nil, "id", id)
+	}
+	if !group.HasWriter(peer) {
+		return nil, common.NewBasicError(HPNotAuthorizedErr, nil, "id", id, "peer", peer)
+	}
+	return group, nil
+}
+
+// validateHPSegs checks that all segments are down segments that terminate in
+// the writer AS.
+func validateHPSegs(writer addr.IA, recs *path_mgmt.HPSegRecs) error {
+	for _, rec := range recs.Recs {
+		if rec.Type != proto.PathSegType_down {
+			return common.NewBasicError(HPInvalidSegmentsErr, nil,
+				"reason", "not a down segment", "type", rec.Type)
+		}
+		if !rec.Segment.LastIA().Equal(writer) {
+			return common.NewBasicError(HPInvalidSegmentsErr, nil,
+				"reason", "segment does not end at writer", "lastIA", rec.Segment.LastIA(),
+				"writer", writer)
+		}
+	}
+	return nil
+}
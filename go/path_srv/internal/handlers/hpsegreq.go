@@ -0,0 +1,135 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/lib/infra/messenger"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+	"github.com/scionproto/scion/go/lib/snet"
+	"github.com/scionproto/scion/go/proto"
+)
+
+type hpSegReqHandler struct {
+	*baseHandler
+	localIA addr.IA
+	groups  hiddenpath.Groups
+}
+
+// NewHPSegReqHandler creates a handler for hidden segment requests. The
+// request is only answered if the requester is a reader of all requested
+// groups, and the local AS is a registry of all requested groups.
+func NewHPSegReqHandler(args HandlerArgs) infra.Handler {
+	f := func(r *infra.Request) *infra.HandlerResult {
+		handler := &hpSegReqHandler{
+			baseHandler: newBaseHandler(r, args),
+			localIA:     args.IA,
+			groups:      args.HiddenPathGroups,
+		}
+		return handler.Handle()
+	}
+	return infra.HandlerFunc(f)
+}
+
+func (h *hpSegReqHandler) Handle() *infra.HandlerResult {
+	ctx := h.request.Context()
+	logger := log.FromCtx(ctx)
+	hpSegReq, ok := h.request.Message.(*path_mgmt.HPSegReq)
+	if !ok {
+		logger.Error("[hpSegReqHandler] wrong message type, expected path_mgmt.HPSegReq",
+			"msg", h.request.Message, "type", common.TypeOf(h.request.Message))
+		return infra.MetricsErrInternal
+	}
+	logger.Debug("[hpSegReqHandler] Received", "hpSegReq", hpSegReq)
+	rw, ok := infra.ResponseWriterFromContext(ctx)
+	if !ok {
+		logger.Error("[hpSegReqHandler] Unable to service request, no ResponseWriter found")
+		return infra.MetricsErrInternal
+	}
+	subCtx, cancelF := context.WithTimeout(ctx, HandlerTimeout)
+	defer cancelF()
+	sendAck := messenger.SendAckHelper(subCtx, rw)
+	if hpSegReq.DstIA().IsZero() || len(hpSegReq.GroupIds) == 0 {
+		logger.Warn("[hpSegReqHandler] Drop, invalid request", "req", hpSegReq)
+		sendAck(proto.Ack_ErrCode_reject, messenger.AckRejectFailedToParse)
+		return infra.MetricsErrInvalid
+	}
+	peer := h.request.Peer.(*snet.Addr).IA
+	groups, err := h.authorize(peer, hpSegReq.GroupIds)
+	if err != nil {
+		logger.Warn("[hpSegReqHandler] Rejecting request", "peer", peer, "err", err)
+		sendAck(proto.Ack_ErrCode_reject, common.GetErrorMsg(err))
+		return infra.MetricsErrInvalid
+	}
+	reply := &path_mgmt.HPSegReply{}
+	for _, group := range groups {
+		segs, err := h.fetchSegsFromDB(subCtx, &query.Params{
+			SegTypes: []proto.PathSegType{proto.PathSegType_down},
+			EndsAt:   []addr.IA{hpSegReq.DstIA()},
+			HpCfgIDs: []*query.HPCfgID{group.Id.HPCfgID()},
+		})
+		if err != nil {
+			logger.Error("[hpSegReqHandler] Failed to get hidden segments from DB",
+				"group", group.Id, "err", err)
+			sendAck(proto.Ack_ErrCode_retry, messenger.AckRetryDBError)
+			return infra.MetricsErrInternal
+		}
+		recs := &path_mgmt.HPSegRecs{
+			GroupId: group.Id.ToMsg(),
+			Recs:    make([]*seg.Meta, 0, len(segs)),
+		}
+		for _, s := range segs {
+			recs.Recs = append(recs.Recs, seg.NewMeta(s, proto.PathSegType_down))
+		}
+		reply.Recs = append(reply.Recs, recs)
+	}
+	if err := rw.SendHPSegReply(subCtx, reply); err != nil {
+		logger.Error("[hpSegReqHandler] Failed to send reply", "err", err)
+		return infra.MetricsErrInternal
+	}
+	logger.Debug("[hpSegReqHandler] reply sent", "id", h.request.ID, "groups", len(groups))
+	return infra.MetricsResultOk
+}
+
+// authorize returns the requested groups, if the local AS is a registry and
+// the peer is a reader of all of them.
+func (h *hpSegReqHandler) authorize(peer addr.IA,
+	ids []*path_mgmt.HPGroupId) ([]*hiddenpath.Group, error) {
+
+	groups := make([]*hiddenpath.Group, 0, len(ids))
+	for _, rawId := range ids {
+		id := hiddenpath.IdFromMsg(rawId)
+		group, ok := h.groups[id]
+		if !ok {
+			return nil, common.NewBasicError(HPUnknownGroupErr, nil, "id", id)
+		}
+		if !group.HasRegistry(h.localIA) {
+			return nil, common.NewBasicError(HPNotRegistryErr, nil, "id", id)
+		}
+		if !group.HasReader(peer) {
+			return nil, common.NewBasicError(HPNotAuthorizedErr, nil, "id", id, "peer", peer)
+		}
+		groups = append(groups, group)
+	}
+	return groups, nil
+}
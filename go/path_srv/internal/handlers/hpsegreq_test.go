@@ -0,0 +1,187 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package handlers
+
+import (
+	"context"
+	"fmt"
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/golang/mock/gomock"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/ctrl/ack"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
+	"github.com/scionproto/scion/go/lib/infra"
+	"github.com/scionproto/scion/go/lib/infra/mock_infra"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+	pathdbbe "github.com/scionproto/scion/go/lib/pathdb/sqlite"
+	"github.com/scionproto/scion/go/lib/revcache/memrevcache"
+	"github.com/scionproto/scion/go/lib/scrypto"
+	"github.com/scionproto/scion/go/lib/snet"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/path_srv/internal/config"
+	"github.com/scionproto/scion/go/proto"
+)
+
+var (
+	hpGroupId = hiddenpath.GroupId{OwnerAS: core2_210.A, Suffix: 0x1}
+	hpGroups  = hiddenpath.Groups{
+		hpGroupId: {
+			Id:         hpGroupId,
+			Version:    1,
+			Owner:      core2_210,
+			Writers:    []addr.IA{as2_211},
+			Readers:    []addr.IA{as2_222},
+			Registries: []addr.IA{core2_210},
+		},
+	}
+)
+
+var _ gomock.Matcher = (*hpReplyMatcher)(nil)
+
+type hpReplyMatcher struct {
+	reply *path_mgmt.HPSegReply
+}
+
+func (r *hpReplyMatcher) Matches(o interface{}) bool {
+	reply, ok := o.(*path_mgmt.HPSegReply)
+	if !ok {
+		return false
+	}
+	for _, replies := range []*path_mgmt.HPSegReply{reply, r.reply} {
+		for _, recs := range replies.Recs {
+			// Init the id field, so that deep equal works.
+			for _, sm := range recs.Recs {
+				sm.Segment.ID()
+				sm.Segment.FullId()
+			}
+		}
+	}
+	return reflect.DeepEqual(r.reply, reply)
+}
+
+func (r *hpReplyMatcher) String() string {
+	return fmt.Sprintf("Matches Reply: %v", r.reply)
+}
+
+func TestHPSegReq(t *testing.T) {
+	ctrl := gomock.NewController(t)
+	defer ctrl.Finish()
+	g := newTestGraph(ctrl)
+
+	db, err := pathdbbe.New(":memory:")
+	xtest.FailOnErr(t, err)
+	ctx, cancelF := context.WithTimeout(context.Background(), time.Second)
+	defer cancelF()
+	_, err = db.InsertWithHPCfgIDs(ctx, seg.NewMeta(g.seg210_211, proto.PathSegType_down),
+		[]*query.HPCfgID{hpGroupId.HPCfgID()})
+	xtest.FailOnErr(t, err)
+	args := HandlerArgs{
+		PathDB:           db,
+		RevCache:         memrevcache.New(),
+		QueryInterval:    config.DefaultQueryInterval,
+		IA:               core2_210,
+		HiddenPathGroups: hpGroups,
+	}
+
+	t.Run("hidden segments are not public", func(t *testing.T) {
+		h := newBaseHandler(nil, args)
+		segs, err := h.fetchSegsFromDB(ctx, &query.Params{
+			SegTypes: []proto.PathSegType{proto.PathSegType_down},
+			EndsAt:   []addr.IA{as2_211},
+			HpCfgIDs: []*query.HPCfgID{&query.NullHpCfgID},
+		})
+		xtest.FailOnErr(t, err)
+		if len(segs) != 0 {
+			t.Errorf("Expected no public segments, actual %v", segs)
+		}
+	})
+
+	tests := map[string]struct {
+		Peer     addr.IA
+		GroupId  hiddenpath.GroupId
+		Expected *path_mgmt.HPSegReply
+	}{
+		"authorized reader": {
+			Peer:    as2_222,
+			GroupId: hpGroupId,
+			Expected: &path_mgmt.HPSegReply{
+				Recs: []*path_mgmt.HPSegRecs{
+					{
+						GroupId: hpGroupId.ToMsg(),
+						Recs:    []*seg.Meta{seg.NewMeta(g.seg210_211, proto.PathSegType_down)},
+					},
+				},
+			},
+		},
+		"writer is not a reader": {
+			Peer:    as2_211,
+			GroupId: hpGroupId,
+		},
+		"unknown group": {
+			Peer:    as2_222,
+			GroupId: hiddenpath.GroupId{OwnerAS: core2_210.A, Suffix: 0x2},
+		},
+	}
+	for name, test := range tests {
+		t.Run(name, func(t *testing.T) {
+			ctrl := gomock.NewController(t)
+			defer ctrl.Finish()
+			rw := mock_infra.NewMockResponseWriter(ctrl)
+			req := infra.NewRequest(
+				infra.NewContextWithResponseWriter(context.Background(), rw),
+				&path_mgmt.HPSegReq{
+					RawDstIA: as2_211.IAInt(),
+					GroupIds: []*path_mgmt.HPGroupId{test.GroupId.ToMsg()},
+				},
+				nil,
+				&snet.Addr{IA: test.Peer},
+				scrypto.RandUint64(),
+			)
+			if test.Expected != nil {
+				rw.EXPECT().SendHPSegReply(gomock.Any(), &hpReplyMatcher{reply: test.Expected})
+			} else {
+				rw.EXPECT().SendAckReply(gomock.Any(), gomock.Any()).DoAndReturn(
+					func(_ context.Context, msg *ack.Ack) error {
+						if msg.Err != proto.Ack_ErrCode_reject {
+							t.Errorf("Expected reject, actual %v", msg.Err)
+						}
+						return nil
+					},
+				)
+			}
+			NewHPSegReqHandler(args).Handle(req)
+		})
+	}
+}
+
+func TestHPSegRegAuthorize(t *testing.T) {
+	h := &hpSegRegHandler{localIA: core2_210, groups: hpGroups}
+	if _, err := h.authorize(as2_211, hpGroupId); err != nil {
+		t.Errorf("Expected writer to be authorized, err %v", err)
+	}
+	if _, err := h.authorize(as2_222, hpGroupId); err == nil {
+		t.Errorf("Expected reader not to be authorized to write")
+	}
+	h.localIA = as2_222
+	if _, err := h.authorize(as2_211, hpGroupId); err == nil {
+		t.Errorf("Expected non-registry to reject registration")
+	}
+}
@@ -96,6 +96,7 @@ func (h *segReqHandler) fetchDownSegs(ctx context.Context, dst addr.IA,
 	q := &query.Params{
 		SegTypes: []proto.PathSegType{proto.PathSegType_down},
 		EndsAt:   []addr.IA{dst},
+		HpCfgIDs: []*query.HPCfgID{&query.NullHpCfgID},
 	}
 	segs, err := h.fetchSegsFromDB(ctx, q)
 	if err != nil {
@@ -177,6 +177,7 @@ func (h *segReqCoreHandler) fetchDownSegsFromDB(ctx context.Context,
 	q := &query.Params{
 		SegTypes: []proto.PathSegType{proto.PathSegType_down},
 		EndsAt:   []addr.IA{dstIA},
+		HpCfgIDs: []*query.HPCfgID{&query.NullHpCfgID},
 	}
 	return h.fetchSegsFromDB(ctx, q)
 }
@@ -147,6 +147,7 @@ func (s *SegSyncer) runInternal(ctx context.Context, cPs net.Addr) (int, error)
 	q := &query.Params{
 		SegTypes:      []proto.PathSegType{proto.PathSegType_down},
 		StartsAt:      []addr.IA{s.localIA},
+		HpCfgIDs:      []*query.HPCfgID{&query.NullHpCfgID},
 		MinLastUpdate: s.latestUpdate,
 	}
 	queryResult, err := s.pathDB.Get(ctx, q)
@@ -31,6 +31,7 @@ import (
 	"github.com/scionproto/scion/go/lib/discovery"
 	"github.com/scionproto/scion/go/lib/env"
 	"github.com/scionproto/scion/go/lib/fatal"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/infraenv"
 	"github.com/scionproto/scion/go/lib/infra/messenger"
@@ -103,6 +104,9 @@ func realMain() int {
 	trustConf := &trust.Config{
 		MustHaveLocalChain: true,
 		ServiceType:        proto.ServiceType_ps,
+		CheckRevocation:    !cfg.Trust.DisableRevocationCheck,
+		RequireFreshCRL:    cfg.Trust.RequireFreshCRL,
+		MaxLeafLifetime:    cfg.Trust.MaxLeafLifetime.Duration,
 	}
 	trustStore := trust.NewStore(trustDB, topo.ISD_AS, trustConf, log.Root())
 	err = trustStore.LoadAuthoritativeCrypto(filepath.Join(cfg.General.ConfigDir, "certs"))
@@ -137,6 +141,14 @@ func realMain() int {
 		TrustStore:            trustStore,
 		SVCRouter:             messenger.NewSVCRouter(itopo.Provider()),
 	}
+	if cfg.QUIC.Address != "" && cfg.QUIC.PKI {
+		nc.QUICPKI, err = infraenv.NewQUICPKI(filepath.Join(cfg.General.ConfigDir, "keys"),
+			topo.ISD_AS, trustStore, trustDB)
+		if err != nil {
+			log.Crit("Unable to initialize QUIC PKI", "err", err)
+			return 1
+		}
+	}
 	msger, err := nc.Messenger()
 	if err != nil {
 		log.Crit(infraenv.ErrAppUnableToInitMessenger, "err", err)
@@ -147,13 +159,19 @@ func realMain() int {
 	// TODO(lukedirtwalker): with the new CP-PKI design the PS should no longer need to handle TRC
 	// and cert requests.
 	msger.AddHandler(infra.TRCRequest, trustStore.NewTRCReqHandler(false))
+	hpGroups, err := loadHiddenPathGroups()
+	if err != nil {
+		log.Crit("Unable to load hidden path groups", "err", err)
+		return 1
+	}
 	args := handlers.HandlerArgs{
-		PathDB:        pathDB,
-		RevCache:      revCache,
-		TrustStore:    trustStore,
-		QueryInterval: cfg.PS.QueryInterval.Duration,
-		IA:            topo.ISD_AS,
-		TopoProvider:  itopo.Provider(),
+		PathDB:           pathDB,
+		RevCache:         revCache,
+		TrustStore:       trustStore,
+		QueryInterval:    cfg.PS.QueryInterval.Duration,
+		IA:               topo.ISD_AS,
+		TopoProvider:     itopo.Provider(),
+		HiddenPathGroups: hpGroups,
 	}
 	core := topo.Core
 	var segReqHandler infra.Handler
@@ -171,6 +189,11 @@ func realMain() int {
 		msger.AddHandler(infra.SegSync, handlers.NewSyncHandler(args))
 	}
 	msger.AddHandler(infra.SignedRev, handlers.NewRevocHandler(args))
+	if registered := hpGroups.Registered(topo.ISD_AS); len(registered) > 0 {
+		log.Info("Acting as hidden path registry", "groups", len(registered))
+		msger.AddHandler(infra.HPSegReg, handlers.NewHPSegRegHandler(args))
+		msger.AddHandler(infra.HPSegRequest, handlers.NewHPSegReqHandler(args))
+	}
 	cfg.Metrics.StartPrometheus()
 	// Start handling requests/messages
 	go func() {
@@ -259,6 +282,15 @@ func (t *periodicTasks) Kill() {
 	t.running = false
 }
 
+// loadHiddenPathGroups loads the hidden path groups. If no groups are
+// configured, nil is returned.
+func loadHiddenPathGroups() (hiddenpath.Groups, error) {
+	if cfg.PS.HiddenPathGroups == "" {
+		return nil, nil
+	}
+	return hiddenpath.LoadGroups(cfg.PS.HiddenPathGroups)
+}
+
 func setupBasic() error {
 	if _, err := toml.DecodeFile(env.ConfigFile(), &cfg); err != nil {
 		return err
@@ -42,6 +42,7 @@ type Config struct {
 	Metrics   env.Metrics
 	Tracing   env.Tracing
 	QUIC      env.QUIC `toml:"quic"`
+	Trust     env.Trust
 	TrustDB   truststorage.TrustDBConf
 	Discovery idiscovery.Config
 	SD        SDConfig
@@ -64,6 +65,7 @@ func (cfg *Config) Validate() error {
 		&cfg.General,
 		&cfg.Logging,
 		&cfg.Metrics,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.Discovery,
 		&cfg.SD,
@@ -77,6 +79,7 @@ func (cfg *Config) Sample(dst io.Writer, path config.Path, _ config.CtxMap) {
 		&cfg.Metrics,
 		&cfg.Tracing,
 		&cfg.QUIC,
+		&cfg.Trust,
 		&cfg.TrustDB,
 		&cfg.Discovery,
 		&cfg.SD,
@@ -98,6 +101,9 @@ type SDConfig struct {
 	Unix string
 	// If set to True, the socket is removed before being created
 	DeleteSocket bool
+	// HTTP is the TCP address to listen on for the HTTP/JSON API. If empty,
+	// the HTTP/JSON API is disabled.
+	HTTP string
 	// Public is the local address to listen on for SCION messages (if Bind is
 	// not set), and to send out messages to other nodes.
 	Public *snet.Addr
@@ -111,6 +117,11 @@ type SDConfig struct {
 	// QueryInterval specifies after how much time segments
 	// for a destination should be refetched.
 	QueryInterval util.DurWrap
+	// HiddenPathGroups contains the file path for the hidden path groups
+	// configuration. Hidden down segments are requested from the registries
+	// of the groups the local AS is a reader of. If this is the empty string,
+	// no hidden down segments are requested.
+	HiddenPathGroups string
 }
 
 func (cfg *SDConfig) InitDefaults() {
@@ -44,6 +44,7 @@ func TestConfigSample(t *testing.T) {
 
 func InitTestConfig(cfg *Config) {
 	envtest.InitTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, nil)
+	envtest.InitTestTrust(&cfg.Trust)
 	truststoragetest.InitTestConfig(&cfg.TrustDB)
 	idiscoverytest.InitTestConfig(&cfg.Discovery)
 	InitTestSDConfig(&cfg.SD)
@@ -51,12 +52,15 @@ func InitTestConfig(cfg *Config) {
 
 func InitTestSDConfig(cfg *SDConfig) {
 	cfg.DeleteSocket = true
+	cfg.HTTP = "test"
+	cfg.HiddenPathGroups = "test"
 	pathstoragetest.InitTestPathDBConf(&cfg.PathDB)
 	pathstoragetest.InitTestRevCacheConf(&cfg.RevCache)
 }
 
 func CheckTestConfig(cfg *Config, id string) {
 	envtest.CheckTest(&cfg.General, &cfg.Logging, &cfg.Metrics, &cfg.Tracing, nil, id)
+	envtest.CheckTestTrust(&cfg.Trust)
 	truststoragetest.CheckTestConfig(&cfg.TrustDB, id)
 	idiscoverytest.CheckTestConfig(&cfg.Discovery)
 	CheckTestSDConfig(&cfg.SD, id)
@@ -71,4 +75,6 @@ func CheckTestSDConfig(cfg *SDConfig, id string) {
 		"1-ff00:0:110,[127.0.0.1]:0 (UDP)")
 	SoMsg("QueryInterval correct", cfg.QueryInterval.Duration, ShouldEqual, DefaultQueryInterval)
 	SoMsg("DeleteSocket set", cfg.DeleteSocket, ShouldBeFalse)
+	SoMsg("HTTP correct", cfg.HTTP, ShouldEqual, "")
+	SoMsg("HiddenPathGroups correct", cfg.HiddenPathGroups, ShouldEqual, "")
 }
@@ -28,6 +28,10 @@ Unix = "/run/shm/sciond/default-unix.sock"
 # If set to True, the socket is removed before being created. (default false)
 DeleteSocket = false
 
+# TCP address to listen on for the HTTP/JSON API, e.g., "127.0.0.1:30256". In
+# case of the empty string, the HTTP/JSON API is disabled. (default "")
+HTTP = ""
+
 # Local address to listen on for SCION messages (if Bind is not set),
 # and to send out messages to other nodes. (required)
 Public = "1-ff00:0:110,[127.0.0.1]:0"
@@ -38,4 +42,10 @@ Public = "1-ff00:0:110,[127.0.0.1]:0"
 
 # The time after which segments for a destination are refetched. (default 5m)
 QueryInterval = "5m"
+
+# The file path for the hidden path groups configuration. Hidden down segments
+# are requested from the registries of the groups the local AS is a reader of.
+# In case of the empty string, no hidden down segments are requested.
+# (default "")
+HiddenPathGroups = ""
 `
@@ -27,6 +27,7 @@ import (
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
 	"github.com/scionproto/scion/go/lib/ctrl/seg"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/hostinfo"
 	"github.com/scionproto/scion/go/lib/infra"
 	"github.com/scionproto/scion/go/lib/infra/messenger"
@@ -58,10 +59,12 @@ type Fetcher struct {
 	revocationCache revcache.RevCache
 	config          config.SDConfig
 	replyHandler    *segfetcher.SegReplyHandler
+	hpGroups        hiddenpath.Groups
 }
 
 func NewFetcher(messenger infra.Messenger, pathDB pathdb.PathDB, trustStore infra.TrustStore,
-	revCache revcache.RevCache, cfg config.SDConfig, logger log.Logger) *Fetcher {
+	revCache revcache.RevCache, cfg config.SDConfig, hpGroups hiddenpath.Groups,
+	logger log.Logger) *Fetcher {
 
 	return &Fetcher{
 		messenger:       messenger,
@@ -69,6 +72,7 @@ func NewFetcher(messenger infra.Messenger, pathDB pathdb.PathDB, trustStore infr
 		trustStore:      trustStore,
 		revocationCache: revCache,
 		config:          cfg,
+		hpGroups:        hpGroups,
 		replyHandler: &segfetcher.SegReplyHandler{
 			Verifier: &segfetcher.SegVerifier{Verifier: trustStore.NewVerifier()},
 			Storage: &segfetcher.DefaultStorage{
@@ -152,6 +156,10 @@ func (f *fetcherHandler) GetPaths(ctx context.Context, req *sciond.PathReq,
 			// continue anyway, things might still work out for the client.
 		}
 	}
+	// Hidden segments are only served by the hidden path registries. They are
+	// fetched concurrently with the public segments, and are considered when
+	// building the paths below.
+	hiddenDone := f.startFetchHiddenSegs(ctx, req.Dst.IA())
 	// We don't have enough local information, grab fresh segments from the
 	// network. The spawned goroutine (in fetchAndVerify) takes care of
 	// updating the path database and revocation cache.
@@ -167,6 +175,11 @@ func (f *fetcherHandler) GetPaths(ctx context.Context, req *sciond.PathReq,
 	case <-ctx.Done():
 	case storedSegs = <-processedResult.EarlyTriggerProcessed():
 	}
+	// The hidden segment fetch is bounded by hiddenSegsTimeout.
+	select {
+	case <-ctx.Done():
+	case <-hiddenDone:
+	}
 	if storedSegs > 0 {
 		if reply, err := f.buildReplyFromDB(ctx, req, true); reply != nil {
 			return reply, err
@@ -282,6 +295,7 @@ func (f *fetcherHandler) buildSCIONDReplyEntries(paths []*combinator.Path,
 				Mtu:        path.Mtu,
 				Interfaces: path.Interfaces,
 				ExpTime:    uint32(path.ComputeExpTime().Unix()),
+				StaticInfo: path.StaticInfo,
 			},
 			HostInfo: hostinfo.FromTopoBRAddr(*ifInfo.InternalAddrs),
 		})
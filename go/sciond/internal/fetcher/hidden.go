@@ -0,0 +1,180 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package fetcher
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
+	"github.com/scionproto/scion/go/lib/infra/messenger"
+	"github.com/scionproto/scion/go/lib/infra/modules/segfetcher"
+	"github.com/scionproto/scion/go/lib/infra/modules/segverifier"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathdb/query"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/snet"
+	"github.com/scionproto/scion/go/lib/spath"
+)
+
+// hiddenSegsTimeout is the maximum time spent fetching hidden segments. It is
+// shorter than the timeout of path requests, such that unreachable registries
+// do not delay the reply with the public paths.
+const hiddenSegsTimeout = 2 * time.Second
+
+// startFetchHiddenSegs fetches the hidden segments to dst in the background
+// (see fetchHiddenSegs). The returned channel is closed when the fetch is done.
+func (f *fetcherHandler) startFetchHiddenSegs(ctx context.Context, dst addr.IA) <-chan struct{} {
+	done := make(chan struct{})
+	go func() {
+		defer log.LogPanicAndExit()
+		defer close(done)
+		subCtx, cancelF := context.WithTimeout(ctx, hiddenSegsTimeout)
+		defer cancelF()
+		f.fetchHiddenSegs(subCtx, dst)
+	}()
+	return done
+}
+
+// fetchHiddenSegs requests the hidden down segments to dst from the registries
+// of the hidden path groups the local AS is a reader of. The registries are
+// queried concurrently. Verified segments are stored in the path database with
+// the id of the group they belong to. Errors are only logged, such that the
+// public segments can still be fetched.
+func (f *fetcherHandler) fetchHiddenSegs(ctx context.Context, dst addr.IA) {
+	readable := f.hpGroups.Readable(f.topology.ISD_AS)
+	if len(readable) == 0 {
+		return
+	}
+	var wg sync.WaitGroup
+	for registry, ids := range hiddenpath.RegistryMapping(readable) {
+		wg.Add(1)
+		go func(registry addr.IA, ids []hiddenpath.GroupId) {
+			defer log.LogPanicAndExit()
+			defer wg.Done()
+			f.fetchHiddenSegsFrom(ctx, dst, registry, ids)
+		}(registry, ids)
+	}
+	wg.Wait()
+}
+
+// fetchHiddenSegsFrom requests the hidden down segments to dst of the groups
+// ids from the registry.
+func (f *fetcherHandler) fetchHiddenSegsFrom(ctx context.Context, dst addr.IA,
+	registry addr.IA, ids []hiddenpath.GroupId) {
+
+	a, err := f.registryAddr(ctx, registry)
+	if err != nil {
+		f.logger.Warn("Unable to reach hidden path registry", "registry", registry,
+			"err", err)
+		return
+	}
+	req := &path_mgmt.HPSegReq{
+		RawDstIA: dst.IAInt(),
+		GroupIds: make([]*path_mgmt.HPGroupId, 0, len(ids)),
+	}
+	for _, id := range ids {
+		req.GroupIds = append(req.GroupIds, id.ToMsg())
+	}
+	f.logger.Debug("Requesting hidden segments", "registry", a, "groups", ids)
+	reply, err := f.messenger.GetHPSegs(ctx, req, a, messenger.NextId())
+	if err != nil {
+		f.logger.Warn("Unable to retrieve hidden segments", "registry", registry,
+			"err", err)
+		return
+	}
+	if err := f.verifyAndStoreHPSegs(ctx, reply, a); err != nil {
+		f.logger.Warn("Failed to store hidden segments", "registry", registry,
+			"err", err)
+	}
+}
+
+// registryAddr returns the address of the path server in the registry AS. The
+// path to a remote registry is built from the segments in the path database.
+func (f *fetcherHandler) registryAddr(ctx context.Context, registry addr.IA) (net.Addr, error) {
+	if registry.Equal(f.topology.ISD_AS) {
+		return &snet.Addr{IA: registry, Host: addr.NewSVCUDPAppAddr(addr.SvcPS)}, nil
+	}
+	paths, err := f.buildPathsFromDB(ctx, &sciond.PathReq{
+		Src: f.topology.ISD_AS.IAInt(),
+		Dst: registry.IAInt(),
+	})
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		ifInfo, ok := f.topology.IFInfoMap[path.Interfaces[0].IfID]
+		if !ok {
+			continue
+		}
+		x := &bytes.Buffer{}
+		if _, err := path.WriteTo(x); err != nil {
+			return nil, common.NewBasicError("Failed to write path", err)
+		}
+		p := spath.New(x.Bytes())
+		if err := p.InitOffsets(); err != nil {
+			return nil, common.NewBasicError("Failed to init offsets", err)
+		}
+		return &snet.Addr{
+			IA:      registry,
+			Host:    addr.NewSVCUDPAppAddr(addr.SvcPS),
+			Path:    p,
+			NextHop: ifInfo.InternalAddrs.PublicOverlay(f.topology.Overlay),
+		}, nil
+	}
+	return nil, common.NewBasicError("No path to registry", nil, "registry", registry)
+}
+
+// verifyAndStoreHPSegs verifies the hidden segments in the reply and stores
+// the verified ones with the id of their group.
+func (f *fetcherHandler) verifyAndStoreHPSegs(ctx context.Context,
+	reply *path_mgmt.HPSegReply, server net.Addr) error {
+
+	var segs []*segfetcher.SegWithHP
+	for _, recs := range reply.Recs {
+		if recs.GroupId == nil {
+			continue
+		}
+		hpCfgIDs := []*query.HPCfgID{hiddenpath.IdFromMsg(recs.GroupId).HPCfgID()}
+		verifiedCh, units := segverifier.StartVerification(ctx,
+			f.trustStore.NewVerifier(), server, recs.Recs, nil)
+		for i := 0; i < units; i++ {
+			select {
+			case result := <-verifiedCh:
+				if err := result.SegError(); err != nil {
+					f.logger.Warn("Failed to verify hidden segment", "err", err)
+					continue
+				}
+				segs = append(segs, &segfetcher.SegWithHP{
+					Seg:      result.Unit.SegMeta,
+					HPCfgIds: hpCfgIDs,
+				})
+			case <-ctx.Done():
+				return ctx.Err()
+			}
+		}
+	}
+	if len(segs) == 0 {
+		return nil
+	}
+	storage := &segfetcher.DefaultStorage{PathDB: f.pathDB, RevCache: f.revocationCache}
+	return storage.StoreSegs(ctx, segs)
+}
@@ -51,7 +51,14 @@ func NewConnHandler(conn net.PacketConn,
 	}
 }
 
+// ConnCloser is implemented by handlers that keep state for the connections
+// of the clients. ConnClosed is called after the connection was closed.
+type ConnCloser interface {
+	ConnClosed(conn net.PacketConn)
+}
+
 func (srv *ConnHandler) Serve() error {
+	defer srv.connClosed()
 	for {
 		b := make(common.RawBytes, common.MaxMTU)
 		n, address, err := srv.Conn.ReadFrom(b)
@@ -65,6 +72,14 @@ func (srv *ConnHandler) Serve() error {
 	}
 }
 
+func (srv *ConnHandler) connClosed() {
+	for _, handler := range srv.Handlers {
+		if closer, ok := handler.(ConnCloser); ok {
+			closer.ConnClosed(srv.Conn)
+		}
+	}
+}
+
 func (srv *ConnHandler) Handle(b common.RawBytes, address net.Addr) {
 	p := &sciond.Pld{}
 	if err := proto.ParseFromReader(p, bytes.NewReader(b)); err != nil {
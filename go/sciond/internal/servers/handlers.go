@@ -82,6 +82,51 @@ func (h *PathRequestHandler) Handle(ctx context.Context, conn net.PacketConn, sr
 	}
 }
 
+// PathSubscribeHandler handles the path subscriptions of the clients. It
+// replies with the current paths, after which the changes are pushed by
+// Subscriptions.
+type PathSubscribeHandler struct {
+	Subscriptions *Subscriptions
+}
+
+func (h *PathSubscribeHandler) Handle(ctx context.Context, conn net.PacketConn, src net.Addr,
+	pld *sciond.Pld) {
+
+	logger := log.FromCtx(ctx)
+	logger.Debug("[PathSubscribeHandler] Received request", "req", pld.PathSubscribeReq)
+	workCtx, workCancelF := context.WithTimeout(ctx, DefaultWorkTimeout)
+	defer workCancelF()
+	req := &sciond.PathReq{
+		Dst:      pld.PathSubscribeReq.Dst,
+		Src:      pld.PathSubscribeReq.Src,
+		MaxPaths: pld.PathSubscribeReq.MaxPaths,
+	}
+	if err := h.Subscriptions.subscribe(workCtx, conn, src, pld.Id, req); err != nil {
+		logger.Warn("Unable to reply to client", "client", src, "err", err)
+	} else {
+		logger.Debug("Added path subscription", "client", src, "id", pld.Id)
+	}
+}
+
+// ConnClosed removes the subscriptions of the closed connection.
+func (h *PathSubscribeHandler) ConnClosed(conn net.PacketConn) {
+	h.Subscriptions.ConnClosed(conn)
+}
+
+// PathUnsubscribeHandler cancels path subscriptions. The client does not
+// receive a reply.
+type PathUnsubscribeHandler struct {
+	Subscriptions *Subscriptions
+}
+
+func (h *PathUnsubscribeHandler) Handle(ctx context.Context, conn net.PacketConn,
+	src net.Addr, pld *sciond.Pld) {
+
+	logger := log.FromCtx(ctx)
+	logger.Debug("[PathUnsubscribeHandler] Received request", "req", pld.PathUnsubscribeReq)
+	h.Subscriptions.remove(conn, pld.PathUnsubscribeReq.Subscription)
+}
+
 // ASInfoRequestHandler represents the shared global state for the handling of all
 // ASInfoRequest queries. The SCIOND API spawns a goroutine with method Handle
 // for each ASInfoRequest it receives.
@@ -244,6 +289,8 @@ func makeHostInfos(topo *topology.Topo, t proto.ServiceType) []hostinfo.HostInfo
 type RevNotificationHandler struct {
 	RevCache   revcache.RevCache
 	TrustStore infra.TrustStore
+	// Subscriptions, if set, are informed about valid revocations.
+	Subscriptions *Subscriptions
 }
 
 func (h *RevNotificationHandler) Handle(ctx context.Context, conn net.PacketConn,
@@ -266,6 +313,9 @@ func (h *RevNotificationHandler) Handle(ctx context.Context, conn net.PacketConn
 	switch {
 	case isValid(err):
 		revReply.Result = sciond.RevValid
+		if h.Subscriptions != nil {
+			h.Subscriptions.Revoked(revNotification.SRevInfo)
+		}
 	case isStale(err):
 		revReply.Result = sciond.RevStale
 	case isInvalid(err):
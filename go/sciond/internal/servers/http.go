@@ -0,0 +1,615 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathdb"
+	"github.com/scionproto/scion/go/lib/revcache"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/tracing"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// HTTPAPIPrefix is the path prefix of version 1 of the HTTP API.
+const HTTPAPIPrefix = "/api/v1"
+
+// Diagnostics contains the databases that are exposed by the diagnostics
+// endpoints of the HTTP API. Nil databases are not exposed.
+type Diagnostics struct {
+	PathDB   pathdb.Read
+	RevCache revcache.RevCache
+}
+
+// HTTPServer serves the SCIOND API as HTTP/JSON. The requests are answered by
+// the same handlers as the SCIONDMsg requests. The following endpoints are
+// supported:
+//
+//   GET  /api/v1/paths?dst=<IA>[&src=<IA>][&max=<n>][&refresh=true]
+//   GET  /api/v1/as[?ia=<IA>]
+//   GET  /api/v1/interfaces[?ifid=<IFID>...]
+//   GET  /api/v1/services?type=<bs|ps|cs|...>...
+//   POST /api/v1/revocations with body {"SignedRevInfo": <base64 packed revocation>}
+//   GET  /api/v1/revocations (diagnostics, the cached revocations)
+//   GET  /api/v1/segments[?start=<IA>][&end=<IA>] (diagnostics, the cached segments)
+//
+// Errors are returned as {"Error": <message>} with a 4xx or 5xx status code.
+type HTTPServer struct {
+	address  string
+	handlers HandlerMap
+	diag     Diagnostics
+	log      log.Logger
+
+	mu          sync.Mutex
+	server      *http.Server
+	closeCalled bool
+}
+
+// NewHTTPServer initializes a new HTTP server at the TCP address. To start
+// listening on the address, call ListenAndServe.
+func NewHTTPServer(address string, handlers HandlerMap, diag Diagnostics,
+	logger log.Logger) *HTTPServer {
+
+	return &HTTPServer{
+		address:  address,
+		handlers: handlers,
+		diag:     diag,
+		log:      logger,
+	}
+}
+
+// ListenAndServe starts listening on srv's address, and serves the HTTP API
+// until the server is closed. After the server is closed, nil is returned.
+func (srv *HTTPServer) ListenAndServe() error {
+	srv.mu.Lock()
+	if srv.closeCalled {
+		srv.mu.Unlock()
+		return common.NewBasicError("attempted to listen on server that was shut down", nil)
+	}
+	listener, err := net.Listen("tcp", srv.address)
+	if err != nil {
+		srv.mu.Unlock()
+		return common.NewBasicError("unable to listen on socket", nil,
+			"address", srv.address, "err", err)
+	}
+	srv.server = &http.Server{Handler: srv.Handler()}
+	srv.mu.Unlock()
+
+	if err := srv.server.Serve(listener); err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// Handler returns the HTTP handler of the API.
+func (srv *HTTPServer) Handler() http.Handler {
+	mux := http.NewServeMux()
+	mux.HandleFunc(HTTPAPIPrefix+"/paths", srv.handlePaths)
+	mux.HandleFunc(HTTPAPIPrefix+"/as", srv.handleASInfo)
+	mux.HandleFunc(HTTPAPIPrefix+"/interfaces", srv.handleIFInfo)
+	mux.HandleFunc(HTTPAPIPrefix+"/services", srv.handleSVCInfo)
+	mux.HandleFunc(HTTPAPIPrefix+"/revocations", srv.handleRevocations)
+	mux.HandleFunc(HTTPAPIPrefix+"/segments", srv.handleSegments)
+	return mux
+}
+
+// Close immediately closes the listener and all active connections.
+func (srv *HTTPServer) Close() error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.server == nil {
+		return common.NewBasicError("uninitialized server", nil)
+	}
+	srv.closeCalled = true
+	return srv.server.Close()
+}
+
+// Shutdown stops listening for new connections, and waits for the active
+// requests to complete, or until ctx is done.
+func (srv *HTTPServer) Shutdown(ctx context.Context) error {
+	srv.mu.Lock()
+	defer srv.mu.Unlock()
+	if srv.server == nil {
+		return common.NewBasicError("uninitialized server", nil)
+	}
+	srv.closeCalled = true
+	return srv.server.Shutdown(ctx)
+}
+
+func (srv *HTTPServer) handlePaths(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	q := r.URL.Query()
+	dst, err := addr.IAFromString(q.Get("dst"))
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "invalid dst: %v", err)
+		return
+	}
+	var src addr.IA
+	if s := q.Get("src"); s != "" {
+		if src, err = addr.IAFromString(s); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid src: %v", err)
+			return
+		}
+	}
+	var maxPaths uint64
+	if s := q.Get("max"); s != "" {
+		if maxPaths, err = strconv.ParseUint(s, 10, 16); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid max: %v", err)
+			return
+		}
+	}
+	pld := &sciond.Pld{
+		Which: proto.SCIONDMsg_Which_pathReq,
+		PathReq: &sciond.PathReq{
+			Dst:      dst.IAInt(),
+			Src:      src.IAInt(),
+			MaxPaths: uint16(maxPaths),
+			Flags:    sciond.PathReqFlags{Refresh: q.Get("refresh") == "true"},
+		},
+	}
+	reply, ok := srv.query(w, r, pld, proto.SCIONDMsg_Which_pathReply)
+	if !ok {
+		return
+	}
+	res := httpPathReply{
+		ErrorCode: reply.PathReply.ErrorCode.String(),
+		Paths:     make([]httpPath, 0, len(reply.PathReply.Entries)),
+	}
+	for _, entry := range reply.PathReply.Entries {
+		path := httpPath{
+			NextHop: entry.HostInfo.String(),
+			MTU:     entry.Path.Mtu,
+			Expiry:  entry.Path.Expiry(),
+			FwdPath: entry.Path.FwdPath,
+		}
+		for _, iface := range entry.Path.Interfaces {
+			path.Interfaces = append(path.Interfaces, iface.String())
+		}
+		res.Paths = append(res.Paths, path)
+	}
+	writeJSON(w, res)
+}
+
+func (srv *HTTPServer) handleASInfo(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	var ia addr.IA
+	if s := r.URL.Query().Get("ia"); s != "" {
+		var err error
+		if ia, err = addr.IAFromString(s); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid ia: %v", err)
+			return
+		}
+	}
+	pld := &sciond.Pld{
+		Which:     proto.SCIONDMsg_Which_asInfoReq,
+		AsInfoReq: &sciond.ASInfoReq{Isdas: ia.IAInt()},
+	}
+	reply, ok := srv.query(w, r, pld, proto.SCIONDMsg_Which_asInfoReply)
+	if !ok {
+		return
+	}
+	res := make([]httpASInfo, 0, len(reply.AsInfoReply.Entries))
+	for _, entry := range reply.AsInfoReply.Entries {
+		res = append(res, httpASInfo{
+			IA:   entry.ISD_AS().String(),
+			MTU:  entry.Mtu,
+			Core: entry.IsCore,
+		})
+	}
+	writeJSON(w, res)
+}
+
+func (srv *HTTPServer) handleIFInfo(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	req := &sciond.IFInfoRequest{}
+	for _, s := range r.URL.Query()["ifid"] {
+		ifid, err := strconv.ParseUint(s, 10, 64)
+		if err != nil {
+			httpError(w, http.StatusBadRequest, "invalid ifid: %v", err)
+			return
+		}
+		req.IfIDs = append(req.IfIDs, common.IFIDType(ifid))
+	}
+	pld := &sciond.Pld{
+		Which:         proto.SCIONDMsg_Which_ifInfoRequest,
+		IfInfoRequest: req,
+	}
+	reply, ok := srv.query(w, r, pld, proto.SCIONDMsg_Which_ifInfoReply)
+	if !ok {
+		return
+	}
+	res := make([]httpInterface, 0, len(reply.IfInfoReply.RawEntries))
+	for _, entry := range reply.IfInfoReply.RawEntries {
+		res = append(res, httpInterface{
+			IfID:    entry.IfID,
+			Address: entry.HostInfo.String(),
+		})
+	}
+	writeJSON(w, res)
+}
+
+func (srv *HTTPServer) handleSVCInfo(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	req := &sciond.ServiceInfoRequest{}
+	for _, s := range r.URL.Query()["type"] {
+		t := proto.ServiceTypeFromString(s)
+		if t == proto.ServiceType_unset {
+			httpError(w, http.StatusBadRequest, "invalid service type: %s", s)
+			return
+		}
+		req.ServiceTypes = append(req.ServiceTypes, t)
+	}
+	pld := &sciond.Pld{
+		Which:              proto.SCIONDMsg_Which_serviceInfoRequest,
+		ServiceInfoRequest: req,
+	}
+	reply, ok := srv.query(w, r, pld, proto.SCIONDMsg_Which_serviceInfoReply)
+	if !ok {
+		return
+	}
+	res := make([]httpService, 0, len(reply.ServiceInfoReply.Entries))
+	for _, entry := range reply.ServiceInfoReply.Entries {
+		svc := httpService{
+			Type:      entry.ServiceType.String(),
+			TTL:       entry.Ttl,
+			Addresses: make([]string, 0, len(entry.HostInfos)),
+		}
+		for i := range entry.HostInfos {
+			svc.Addresses = append(svc.Addresses, entry.HostInfos[i].String())
+		}
+		res = append(res, svc)
+	}
+	writeJSON(w, res)
+}
+
+func (srv *HTTPServer) handleRevocations(w http.ResponseWriter, r *http.Request) {
+	switch r.Method {
+	case http.MethodGet:
+		srv.listRevocations(w, r)
+	case http.MethodPost:
+		srv.notifyRevocation(w, r)
+	default:
+		httpError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+	}
+}
+
+func (srv *HTTPServer) notifyRevocation(w http.ResponseWriter, r *http.Request) {
+	var req httpRevNotification
+	if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+		httpError(w, http.StatusBadRequest, "invalid body: %v", err)
+		return
+	}
+	sRevInfo, err := path_mgmt.NewSignedRevInfoFromRaw(req.SignedRevInfo)
+	if err != nil {
+		httpError(w, http.StatusBadRequest, "invalid revocation: %v", err)
+		return
+	}
+	pld := &sciond.Pld{
+		Which:           proto.SCIONDMsg_Which_revNotification,
+		RevNotification: &sciond.RevNotification{SRevInfo: sRevInfo},
+	}
+	reply, ok := srv.query(w, r, pld, proto.SCIONDMsg_Which_revReply)
+	if !ok {
+		return
+	}
+	writeJSON(w, httpRevReply{Result: reply.RevReply.Result.String()})
+}
+
+func (srv *HTTPServer) listRevocations(w http.ResponseWriter, r *http.Request) {
+	if srv.diag.RevCache == nil {
+		httpError(w, http.StatusNotFound, "revocation diagnostics not available")
+		return
+	}
+	ctx, cancelF := context.WithTimeout(r.Context(), DefaultWorkTimeout)
+	defer cancelF()
+	revs, err := srv.diag.RevCache.GetAll(ctx)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "unable to read revocations: %v", err)
+		return
+	}
+	res := []httpRevocation{}
+	for rev := range revs {
+		// The channel must be drained completely, so errors are only
+		// reported afterwards.
+		if err != nil {
+			continue
+		}
+		if err = rev.Err; err != nil {
+			continue
+		}
+		var entry httpRevocation
+		if entry, err = newHTTPRevocation(rev.Rev); err == nil {
+			res = append(res, entry)
+		}
+	}
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "unable to read revocations: %v", err)
+		return
+	}
+	writeJSON(w, res)
+}
+
+func (srv *HTTPServer) handleSegments(w http.ResponseWriter, r *http.Request) {
+	if !checkMethod(w, r, http.MethodGet) {
+		return
+	}
+	if srv.diag.PathDB == nil {
+		httpError(w, http.StatusNotFound, "segment diagnostics not available")
+		return
+	}
+	var start, end addr.IA
+	q := r.URL.Query()
+	var err error
+	if s := q.Get("start"); s != "" {
+		if start, err = addr.IAFromString(s); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid start: %v", err)
+			return
+		}
+	}
+	if s := q.Get("end"); s != "" {
+		if end, err = addr.IAFromString(s); err != nil {
+			httpError(w, http.StatusBadRequest, "invalid end: %v", err)
+			return
+		}
+	}
+	ctx, cancelF := context.WithTimeout(r.Context(), DefaultWorkTimeout)
+	defer cancelF()
+	results, err := srv.diag.PathDB.GetAll(ctx)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "unable to read segments: %v", err)
+		return
+	}
+	res := []httpSegment{}
+	for result := range results {
+		// The channel must be drained completely, so errors are only
+		// reported afterwards.
+		if err != nil {
+			continue
+		}
+		if err = result.Err; err != nil {
+			continue
+		}
+		ps := result.Result.Seg
+		if (!start.IsZero() && !start.Equal(ps.FirstIA())) ||
+			(!end.IsZero() && !end.Equal(ps.LastIA())) {
+			continue
+		}
+		var id common.RawBytes
+		if id, err = ps.ID(); err != nil {
+			continue
+		}
+		segment := httpSegment{
+			ID:         id.String(),
+			LastUpdate: result.Result.LastUpdate,
+			Expiry:     ps.MaxExpiry(),
+		}
+		for _, ase := range ps.ASEntries {
+			segment.ASes = append(segment.ASes, ase.IA().String())
+		}
+		res = append(res, segment)
+	}
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "unable to read segments: %v", err)
+		return
+	}
+	writeJSON(w, res)
+}
+
+// query passes pld to the handler of the request type, and returns the
+// reply. If the request cannot be answered, or the reply is not of type
+// replyType, an error is written to w and false is returned.
+func (srv *HTTPServer) query(w http.ResponseWriter, r *http.Request, pld *sciond.Pld,
+	replyType proto.SCIONDMsg_Which) (*sciond.Pld, bool) {
+
+	handler, ok := srv.handlers[pld.Which]
+	if !ok {
+		httpError(w, http.StatusNotImplemented, "%s not supported", pld.Which)
+		return nil, false
+	}
+	ctx, span := tracing.CtxWith(r.Context(), srv.log, fmt.Sprintf("%s.http", pld.Which))
+	defer span.Finish()
+	conn := &replyConn{}
+	handler.Handle(ctx, conn, httpAddr(r.RemoteAddr), pld)
+	if conn.reply == nil {
+		httpError(w, http.StatusInternalServerError, "no reply")
+		return nil, false
+	}
+	reply, err := sciond.NewPldFromRaw(conn.reply)
+	if err != nil {
+		httpError(w, http.StatusInternalServerError, "unable to parse reply: %v", err)
+		return nil, false
+	}
+	if reply.Which != replyType {
+		httpError(w, http.StatusInternalServerError, "unexpected reply: %s", reply.Which)
+		return nil, false
+	}
+	return reply, true
+}
+
+func checkMethod(w http.ResponseWriter, r *http.Request, method string) bool {
+	if r.Method != method {
+		httpError(w, http.StatusMethodNotAllowed, "method %s not allowed", r.Method)
+		return false
+	}
+	return true
+}
+
+func writeJSON(w http.ResponseWriter, v interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "    ")
+	if err := enc.Encode(v); err != nil {
+		log.Warn("Unable to write HTTP reply", "err", err)
+	}
+}
+
+func httpError(w http.ResponseWriter, code int, format string, args ...interface{}) {
+	w.Header().Set("Content-Type", "application/json")
+	w.WriteHeader(code)
+	json.NewEncoder(w).Encode(httpErrorReply{Error: fmt.Sprintf(format, args...)})
+}
+
+// The JSON representations of the HTTP API.
+
+type httpErrorReply struct {
+	Error string
+}
+
+type httpPathReply struct {
+	ErrorCode string
+	Paths     []httpPath
+}
+
+type httpPath struct {
+	Interfaces []string
+	NextHop    string
+	MTU        uint16
+	Expiry     time.Time
+	FwdPath    []byte
+}
+
+type httpASInfo struct {
+	IA   string
+	MTU  uint16
+	Core bool
+}
+
+type httpInterface struct {
+	IfID    common.IFIDType
+	Address string
+}
+
+type httpService struct {
+	Type      string
+	TTL       uint32
+	Addresses []string
+}
+
+type httpRevNotification struct {
+	SignedRevInfo []byte
+}
+
+type httpRevReply struct {
+	Result string
+}
+
+type httpRevocation struct {
+	IA            string
+	IfID          common.IFIDType
+	LinkType      string
+	Timestamp     time.Time
+	Expiry        time.Time
+	SignedRevInfo []byte
+}
+
+func newHTTPRevocation(sRevInfo *path_mgmt.SignedRevInfo) (httpRevocation, error) {
+	revInfo, err := sRevInfo.RevInfo()
+	if err != nil {
+		return httpRevocation{}, err
+	}
+	raw, err := sRevInfo.Pack()
+	if err != nil {
+		return httpRevocation{}, err
+	}
+	return httpRevocation{
+		IA:            revInfo.IA().String(),
+		IfID:          revInfo.IfID,
+		LinkType:      revInfo.LinkType.String(),
+		Timestamp:     revInfo.Timestamp(),
+		Expiry:        revInfo.Expiration(),
+		SignedRevInfo: raw,
+	}, nil
+}
+
+type httpSegment struct {
+	ID         string
+	ASes       []string
+	LastUpdate time.Time
+	Expiry     time.Time
+}
+
+// httpAddr is the address of an HTTP client.
+type httpAddr string
+
+func (a httpAddr) Network() string {
+	return "http"
+}
+
+func (a httpAddr) String() string {
+	return string(a)
+}
+
+var _ net.PacketConn = (*replyConn)(nil)
+
+// replyConn is passed to the handlers to capture their reply to HTTP
+// requests. Only the first reply is kept.
+type replyConn struct {
+	mtx   sync.Mutex
+	reply common.RawBytes
+}
+
+func (c *replyConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if c.reply == nil {
+		c.reply = append(common.RawBytes(nil), b...)
+	}
+	return len(b), nil
+}
+
+func (c *replyConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	return 0, nil, io.EOF
+}
+
+func (c *replyConn) Close() error {
+	return nil
+}
+
+func (c *replyConn) LocalAddr() net.Addr {
+	return httpAddr("")
+}
+
+func (c *replyConn) SetDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *replyConn) SetReadDeadline(t time.Time) error {
+	return nil
+}
+
+func (c *replyConn) SetWriteDeadline(t time.Time) error {
+	return nil
+}
@@ -0,0 +1,111 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"encoding/json"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/xtest"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// asInfoHandler replies to AS info requests with the requested AS.
+type asInfoHandler struct {
+	req *sciond.ASInfoReq
+}
+
+func (h *asInfoHandler) Handle(ctx context.Context, conn net.PacketConn, src net.Addr,
+	pld *sciond.Pld) {
+
+	h.req = pld.AsInfoReq
+	reply := &sciond.Pld{
+		Id:    pld.Id,
+		Which: proto.SCIONDMsg_Which_asInfoReply,
+		AsInfoReply: &sciond.ASInfoReply{
+			Entries: []sciond.ASInfoReplyEntry{
+				{RawIsdas: pld.AsInfoReq.Isdas, Mtu: 1472, IsCore: true},
+			},
+		},
+	}
+	sendReply(reply, conn, src)
+}
+
+// silentHandler does not reply.
+type silentHandler struct{}
+
+func (h silentHandler) Handle(ctx context.Context, conn net.PacketConn, src net.Addr,
+	pld *sciond.Pld) {
+}
+
+func TestHTTPServer(t *testing.T) {
+	Convey("Given an HTTP server", t, func() {
+		asInfo := &asInfoHandler{}
+		handlers := HandlerMap{
+			proto.SCIONDMsg_Which_asInfoReq: asInfo,
+			proto.SCIONDMsg_Which_pathReq:   silentHandler{},
+		}
+		srv := NewHTTPServer("", handlers, Diagnostics{}, log.Root())
+		get := func(url string) *httptest.ResponseRecorder {
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, url, nil))
+			return rec
+		}
+		Convey("requests are answered by the handlers", func() {
+			rec := get(HTTPAPIPrefix + "/as?ia=1-ff00:0:110")
+			So(rec.Code, ShouldEqual, http.StatusOK)
+			So(asInfo.req.Isdas.IA(), ShouldResemble, xtest.MustParseIA("1-ff00:0:110"))
+			var res []httpASInfo
+			So(json.Unmarshal(rec.Body.Bytes(), &res), ShouldBeNil)
+			So(res, ShouldResemble, []httpASInfo{{IA: "1-ff00:0:110", MTU: 1472, Core: true}})
+		})
+		Convey("omitted parameters are zero", func() {
+			So(get(HTTPAPIPrefix+"/as").Code, ShouldEqual, http.StatusOK)
+			So(asInfo.req.Isdas.IA(), ShouldResemble, addr.IA{})
+		})
+		Convey("invalid parameters are rejected", func() {
+			So(get(HTTPAPIPrefix+"/as?ia=foo").Code, ShouldEqual, http.StatusBadRequest)
+			So(get(HTTPAPIPrefix+"/paths").Code, ShouldEqual, http.StatusBadRequest)
+			So(get(HTTPAPIPrefix+"/interfaces?ifid=-1").Code, ShouldEqual,
+				http.StatusBadRequest)
+		})
+		Convey("requests without handler are not implemented", func() {
+			So(get(HTTPAPIPrefix+"/interfaces").Code, ShouldEqual, http.StatusNotImplemented)
+		})
+		Convey("requests without reply fail", func() {
+			So(get(HTTPAPIPrefix+"/paths?dst=1-ff00:0:110").Code, ShouldEqual,
+				http.StatusInternalServerError)
+		})
+		Convey("diagnostics without databases are not found", func() {
+			So(get(HTTPAPIPrefix+"/segments").Code, ShouldEqual, http.StatusNotFound)
+			So(get(HTTPAPIPrefix+"/revocations").Code, ShouldEqual, http.StatusNotFound)
+		})
+		Convey("wrong methods are not allowed", func() {
+			rec := httptest.NewRecorder()
+			srv.Handler().ServeHTTP(rec,
+				httptest.NewRequest(http.MethodPost, HTTPAPIPrefix+"/as", nil))
+			So(rec.Code, ShouldEqual, http.StatusMethodNotAllowed)
+		})
+	})
+}
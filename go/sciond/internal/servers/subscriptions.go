@@ -0,0 +1,293 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"bytes"
+	"context"
+	"net"
+	"sync"
+	"time"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/ctrl/path_mgmt"
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/periodic"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+	"github.com/scionproto/scion/go/proto"
+)
+
+// DefaultSubscriptionRefresh is the interval in which the paths of all path
+// subscriptions are fetched again.
+const DefaultSubscriptionRefresh = 10 * time.Second
+
+// MaxConcurrentRefreshes is the maximum number of subscriptions whose paths
+// are fetched concurrently by Run.
+const MaxConcurrentRefreshes = 16
+
+// PathFetcher fetches the paths for a path request. It is implemented by
+// fetcher.Fetcher.
+type PathFetcher interface {
+	GetPaths(ctx context.Context, req *sciond.PathReq, earlyReplyInterval time.Duration,
+		logger log.Logger) (*sciond.PathReply, error)
+}
+
+var _ periodic.Task = (*Subscriptions)(nil)
+
+// Subscriptions keeps track of the path subscriptions of the SCIOND API
+// clients, and pushes the changes of the subscribed paths to the clients.
+// Revocations are pushed as soon as they are received. Other changes are
+// detected by Run, which fetches the paths of all subscriptions and should be
+// run periodically.
+type Subscriptions struct {
+	fetcher PathFetcher
+
+	mtx  sync.Mutex
+	subs map[subKey]*subscription
+}
+
+func NewSubscriptions(fetcher PathFetcher) *Subscriptions {
+	return &Subscriptions{
+		fetcher: fetcher,
+		subs:    make(map[subKey]*subscription),
+	}
+}
+
+type subKey struct {
+	conn net.PacketConn
+	id   uint64
+}
+
+type subscription struct {
+	conn net.PacketConn
+	src  net.Addr
+	id   uint64
+	req  *sciond.PathReq
+	// mtx serializes the updates of the subscription, such that the client
+	// receives them in order.
+	mtx sync.Mutex
+	// paths are the paths the client knows about.
+	paths spathmeta.AppPathSet
+}
+
+// Run fetches the paths of all subscriptions, and pushes the changes. Up to
+// MaxConcurrentRefreshes subscriptions are refreshed concurrently, such that a
+// slow path lookup does not delay the updates of the other subscriptions.
+func (s *Subscriptions) Run(ctx context.Context) {
+	sem := make(chan struct{}, MaxConcurrentRefreshes)
+	var wg sync.WaitGroup
+	for _, sub := range s.list() {
+		select {
+		case sem <- struct{}{}:
+		case <-ctx.Done():
+			// The remaining subscriptions are refreshed in the next run.
+			wg.Wait()
+			return
+		}
+		wg.Add(1)
+		go func(sub *subscription) {
+			defer log.LogPanicAndExit()
+			defer wg.Done()
+			defer func() { <-sem }()
+			s.refresh(ctx, sub)
+		}(sub)
+	}
+	wg.Wait()
+}
+
+// Revoked removes the paths that contain the interface revoked by sRevInfo
+// from the subscriptions, and pushes the removal together with the
+// revocation. Afterwards, the paths of the affected subscriptions are fetched
+// again, to push the alternative paths.
+func (s *Subscriptions) Revoked(sRevInfo *path_mgmt.SignedRevInfo) {
+	revInfo, err := sRevInfo.RevInfo()
+	if err != nil {
+		log.Error("Unable to parse revocation info", "err", err)
+		return
+	}
+	pi := sciond.PathInterface{
+		RawIsdas: revInfo.IA().IAInt(),
+		IfID:     common.IFIDType(revInfo.IfID),
+	}
+	for _, sub := range s.list() {
+		sub.mtx.Lock()
+		var removed []sciond.PathReplyEntry
+		for key, path := range sub.paths {
+			if containsIface(path.Entry, pi) {
+				removed = append(removed, *path.Entry)
+				delete(sub.paths, key)
+			}
+		}
+		if len(removed) > 0 {
+			s.push(sub, &sciond.PathUpdate{
+				Removed:     removed,
+				Revocations: []*path_mgmt.SignedRevInfo{sRevInfo},
+			})
+		}
+		sub.mtx.Unlock()
+		if len(removed) > 0 {
+			go func(sub *subscription) {
+				defer log.LogPanicAndExit()
+				ctx, cancelF := context.WithTimeout(context.Background(), DefaultWorkTimeout)
+				defer cancelF()
+				s.refresh(ctx, sub)
+			}(sub)
+		}
+	}
+}
+
+// ConnClosed removes the subscriptions of the client connection conn.
+func (s *Subscriptions) ConnClosed(conn net.PacketConn) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	for key := range s.subs {
+		if key.conn == conn {
+			delete(s.subs, key)
+		}
+	}
+}
+
+// subscribe fetches the current paths for req, replies to the client and
+// registers the subscription.
+func (s *Subscriptions) subscribe(ctx context.Context, conn net.PacketConn, src net.Addr,
+	id uint64, req *sciond.PathReq) error {
+
+	sub := &subscription{conn: conn, src: src, id: id, req: req}
+	// Hold the lock until the reply is sent, such that updates are only
+	// pushed afterwards.
+	sub.mtx.Lock()
+	defer sub.mtx.Unlock()
+	reply, err := s.fetcher.GetPaths(ctx, req, DefaultEarlyReply, log.FromCtx(ctx))
+	if err != nil {
+		log.FromCtx(ctx).Error("Unable to get paths", "err", err)
+	}
+	if reply == nil {
+		reply = &sciond.PathReply{ErrorCode: sciond.ErrorInternal}
+	}
+	sub.paths = spathmeta.NewAppPathSet(reply)
+	s.add(sub)
+	pld := &sciond.Pld{
+		Id:    id,
+		Which: proto.SCIONDMsg_Which_pathSubscribeReply,
+		PathSubscribeReply: &sciond.PathSubscribeReply{
+			ErrorCode: reply.ErrorCode,
+			Entries:   reply.Entries,
+		},
+	}
+	if err := sendReply(pld, conn, src); err != nil {
+		s.remove(conn, id)
+		return err
+	}
+	return nil
+}
+
+// refresh fetches the paths of sub, and pushes the changes compared to the
+// paths the client knows about.
+func (s *Subscriptions) refresh(ctx context.Context, sub *subscription) {
+	sub.mtx.Lock()
+	defer sub.mtx.Unlock()
+	if !s.contains(sub) {
+		return
+	}
+	reply, err := s.fetcher.GetPaths(ctx, sub.req, DefaultEarlyReply, log.Root())
+	if err != nil || reply == nil {
+		// Keep the current paths, they are fetched again in the next run.
+		log.Warn("Unable to refresh subscribed paths", "req", sub.req, "err", err)
+		return
+	}
+	paths := spathmeta.NewAppPathSet(reply)
+	update := diffPaths(sub.paths, paths)
+	sub.paths = paths
+	if len(update.Added) == 0 && len(update.Removed) == 0 {
+		return
+	}
+	s.push(sub, update)
+}
+
+// push sends update to the client of sub. If it cannot be sent, the
+// subscription is removed. The caller must hold the lock of sub.
+func (s *Subscriptions) push(sub *subscription, update *sciond.PathUpdate) {
+	pld := &sciond.Pld{
+		Id:         sub.id,
+		Which:      proto.SCIONDMsg_Which_pathUpdate,
+		PathUpdate: update,
+	}
+	if err := sendReply(pld, sub.conn, sub.src); err != nil {
+		log.Warn("Unable to push path update, removing subscription",
+			"client", sub.src, "id", sub.id, "err", err)
+		s.remove(sub.conn, sub.id)
+	}
+}
+
+func (s *Subscriptions) add(sub *subscription) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.subs[subKey{conn: sub.conn, id: sub.id}] = sub
+}
+
+func (s *Subscriptions) remove(conn net.PacketConn, id uint64) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	delete(s.subs, subKey{conn: conn, id: id})
+}
+
+func (s *Subscriptions) contains(sub *subscription) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return s.subs[subKey{conn: sub.conn, id: sub.id}] == sub
+}
+
+func (s *Subscriptions) list() []*subscription {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	subs := make([]*subscription, 0, len(s.subs))
+	for _, sub := range s.subs {
+		subs = append(subs, sub)
+	}
+	return subs
+}
+
+// diffPaths returns the update that turns the path set old into new. Paths
+// with the same interfaces but different forwarding path, MTU or expiration
+// time are added again.
+func diffPaths(old, new spathmeta.AppPathSet) *sciond.PathUpdate {
+	update := &sciond.PathUpdate{}
+	for key, path := range new {
+		oldPath, ok := old[key]
+		if !ok || !samePathMeta(oldPath.Entry.Path, path.Entry.Path) {
+			update.Added = append(update.Added, *path.Entry)
+		}
+	}
+	for key, path := range old {
+		if _, ok := new[key]; !ok {
+			update.Removed = append(update.Removed, *path.Entry)
+		}
+	}
+	return update
+}
+
+func samePathMeta(a, b *sciond.FwdPathMeta) bool {
+	return a.Mtu == b.Mtu && a.ExpTime == b.ExpTime && bytes.Equal(a.FwdPath, b.FwdPath)
+}
+
+func containsIface(entry *sciond.PathReplyEntry, pi sciond.PathInterface) bool {
+	for _, iface := range entry.Path.Interfaces {
+		if iface.Equal(&pi) {
+			return true
+		}
+	}
+	return false
+}
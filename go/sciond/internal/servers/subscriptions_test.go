@@ -0,0 +1,98 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package servers
+
+import (
+	"context"
+	"sync"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+)
+
+// blockingFetcher blocks all path requests until release is closed, and
+// records how many requests are pending at once.
+type blockingFetcher struct {
+	release chan struct{}
+
+	mtx       sync.Mutex
+	calls     int
+	active    int
+	maxActive int
+}
+
+func (f *blockingFetcher) GetPaths(ctx context.Context, req *sciond.PathReq,
+	earlyReplyInterval time.Duration, logger log.Logger) (*sciond.PathReply, error) {
+
+	f.mtx.Lock()
+	f.calls++
+	f.active++
+	if f.active > f.maxActive {
+		f.maxActive = f.active
+	}
+	f.mtx.Unlock()
+	<-f.release
+	f.mtx.Lock()
+	f.active--
+	f.mtx.Unlock()
+	return &sciond.PathReply{}, nil
+}
+
+func (f *blockingFetcher) counts() (calls, active, maxActive int) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.calls, f.active, f.maxActive
+}
+
+func TestSubscriptionsRun(t *testing.T) {
+	Convey("Run refreshes the subscriptions concurrently up to the bound", t, func() {
+		f := &blockingFetcher{release: make(chan struct{})}
+		s := NewSubscriptions(f)
+		n := 2 * MaxConcurrentRefreshes
+		for i := 0; i < n; i++ {
+			s.add(&subscription{
+				id:    uint64(i),
+				req:   &sciond.PathReq{},
+				paths: spathmeta.NewAppPathSet(nil),
+			})
+		}
+		done := make(chan struct{})
+		go func() {
+			s.Run(context.Background())
+			close(done)
+		}()
+		deadline := time.Now().Add(time.Second)
+		for time.Now().Before(deadline) {
+			if _, active, _ := f.counts(); active >= MaxConcurrentRefreshes {
+				break
+			}
+			time.Sleep(time.Millisecond)
+		}
+		// Give Run the chance to exceed the bound.
+		time.Sleep(10 * time.Millisecond)
+		_, active, _ := f.counts()
+		So(active, ShouldEqual, MaxConcurrentRefreshes)
+		close(f.release)
+		<-done
+		calls, _, maxActive := f.counts()
+		So(calls, ShouldEqual, n)
+		So(maxActive, ShouldEqual, MaxConcurrentRefreshes)
+	})
+}
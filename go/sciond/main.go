@@ -31,6 +31,7 @@ import (
 	"github.com/scionproto/scion/go/lib/discovery"
 	"github.com/scionproto/scion/go/lib/env"
 	"github.com/scionproto/scion/go/lib/fatal"
+	"github.com/scionproto/scion/go/lib/hiddenpath"
 	"github.com/scionproto/scion/go/lib/infra/infraenv"
 	"github.com/scionproto/scion/go/lib/infra/messenger"
 	"github.com/scionproto/scion/go/lib/infra/modules/idiscovery"
@@ -101,12 +102,22 @@ func realMain() int {
 		return 1
 	}
 	defer trustDB.Close()
-	trustStore := trust.NewStore(trustDB, itopo.Get().ISD_AS, nil, log.Root())
+	trustConf := &trust.Config{
+		CheckRevocation: !cfg.Trust.DisableRevocationCheck,
+		RequireFreshCRL: cfg.Trust.RequireFreshCRL,
+		MaxLeafLifetime: cfg.Trust.MaxLeafLifetime.Duration,
+	}
+	trustStore := trust.NewStore(trustDB, itopo.Get().ISD_AS, trustConf, log.Root())
 	err = trustStore.LoadAuthoritativeTRC(filepath.Join(cfg.General.ConfigDir, "certs"))
 	if err != nil {
 		log.Crit("Unable to load local TRC", "err", err)
 		return 1
 	}
+	err = trustStore.LoadAuthoritativeTRCv2(filepath.Join(cfg.General.ConfigDir, "certs"))
+	if err != nil {
+		log.Crit("Unable to load local v2 TRCs", "err", err)
+		return 1
+	}
 	tracer, trCloser, err := cfg.Tracing.NewTracer(cfg.General.ID)
 	if err != nil {
 		log.Crit("Unable to create tracer", "err", err)
@@ -129,22 +140,41 @@ func realMain() int {
 		TrustStore:            trustStore,
 		SVCRouter:             messenger.NewSVCRouter(itopo.Provider()),
 	}
+	if cfg.QUIC.PKI {
+		// SCIOND only connects to control-plane servers, it does not need to
+		// authenticate itself.
+		nc.QUICPKI = infraenv.NewVerifyOnlyQUICPKI(trustStore)
+	}
 	msger, err := nc.Messenger()
 	if err != nil {
 		log.Crit(infraenv.ErrAppUnableToInitMessenger, "err", err)
 		return 1
 	}
+	hpGroups, err := loadHiddenPathGroups()
+	if err != nil {
+		log.Crit("Unable to load hidden path groups", "err", err)
+		return 1
+	}
+	pathFetcher := fetcher.NewFetcher(
+		msger,
+		pathDB,
+		trustStore,
+		revCache,
+		cfg.SD,
+		hpGroups,
+		log.Root(),
+	)
+	subscriptions := servers.NewSubscriptions(pathFetcher)
 	// Route messages to their correct handlers
 	handlers := servers.HandlerMap{
 		proto.SCIONDMsg_Which_pathReq: &servers.PathRequestHandler{
-			Fetcher: fetcher.NewFetcher(
-				msger,
-				pathDB,
-				trustStore,
-				revCache,
-				cfg.SD,
-				log.Root(),
-			),
+			Fetcher: pathFetcher,
+		},
+		proto.SCIONDMsg_Which_pathSubscribeReq: &servers.PathSubscribeHandler{
+			Subscriptions: subscriptions,
+		},
+		proto.SCIONDMsg_Which_pathUnsubscribeReq: &servers.PathUnsubscribeHandler{
+			Subscriptions: subscriptions,
 		},
 		proto.SCIONDMsg_Which_asInfoReq: &servers.ASInfoRequestHandler{
 			TrustStore: trustStore,
@@ -152,8 +182,9 @@ func realMain() int {
 		proto.SCIONDMsg_Which_ifInfoRequest:      &servers.IFInfoRequestHandler{},
 		proto.SCIONDMsg_Which_serviceInfoRequest: &servers.SVCInfoRequestHandler{},
 		proto.SCIONDMsg_Which_revNotification: &servers.RevNotificationHandler{
-			RevCache:   revCache,
-			TrustStore: trustStore,
+			RevCache:      revCache,
+			TrustStore:    trustStore,
+			Subscriptions: subscriptions,
 		},
 	}
 	cleaner := periodic.StartPeriodicTask(pathdb.NewCleaner(pathDB),
@@ -162,6 +193,9 @@ func realMain() int {
 	rcCleaner := periodic.StartPeriodicTask(revcache.NewCleaner(revCache),
 		periodic.NewTicker(10*time.Second), 10*time.Second)
 	defer rcCleaner.Stop()
+	subsRefresher := periodic.StartPeriodicTask(subscriptions,
+		periodic.NewTicker(servers.DefaultSubscriptionRefresh), servers.DefaultSubscriptionRefresh)
+	defer subsRefresher.Stop()
 	// Start servers
 	rsockServer, shutdownF := NewServer("rsock", cfg.SD.Reliable, handlers, log.Root())
 	defer shutdownF()
@@ -169,6 +203,21 @@ func realMain() int {
 	unixpacketServer, shutdownF := NewServer("unixpacket", cfg.SD.Unix, handlers, log.Root())
 	defer shutdownF()
 	StartServer("UnixServer", cfg.SD.Unix, unixpacketServer)
+	if cfg.SD.HTTP != "" {
+		httpServer := servers.NewHTTPServer(cfg.SD.HTTP, handlers,
+			servers.Diagnostics{PathDB: pathDB, RevCache: revCache}, log.Root())
+		defer func() {
+			ctx, cancelF := context.WithTimeout(context.Background(), ShutdownWaitTimeout)
+			httpServer.Shutdown(ctx)
+			cancelF()
+		}()
+		go func() {
+			defer log.LogPanicAndExit()
+			if err := httpServer.ListenAndServe(); err != nil {
+				fatal.Fatal(common.NewBasicError("HTTPServer ListenAndServe error", err))
+			}
+		}()
+	}
 	cfg.Metrics.StartPrometheus()
 	select {
 	case <-fatal.ShutdownChan():
@@ -180,6 +229,15 @@ func realMain() int {
 	}
 }
 
+// loadHiddenPathGroups loads the hidden path groups. If no groups are
+// configured, nil is returned.
+func loadHiddenPathGroups() (hiddenpath.Groups, error) {
+	if cfg.SD.HiddenPathGroups == "" {
+		return nil, nil
+	}
+	return hiddenpath.LoadGroups(cfg.SD.HiddenPathGroups)
+}
+
 func setupBasic() error {
 	if _, err := toml.DecodeFile(env.ConfigFile(), &cfg); err != nil {
 		return err
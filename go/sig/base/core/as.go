@@ -16,6 +16,7 @@
 package core
 
 import (
+	"encoding/json"
 	"net"
 	"sync"
 	"time"
@@ -25,6 +26,7 @@ import (
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/log"
+	"github.com/scionproto/scion/go/lib/pathpol"
 	"github.com/scionproto/scion/go/lib/ringbuf"
 	"github.com/scionproto/scion/go/sig/base"
 	"github.com/scionproto/scion/go/sig/config"
@@ -33,6 +35,7 @@ import (
 	"github.com/scionproto/scion/go/sig/egress/router"
 	"github.com/scionproto/scion/go/sig/egress/session"
 	"github.com/scionproto/scion/go/sig/egress/worker"
+	"github.com/scionproto/scion/go/sig/mgmt"
 )
 
 const (
@@ -50,7 +53,18 @@ type ASEntry struct {
 	version           uint64 // used to track certain changes made to ASEntry
 	log.Logger
 
-	Session *session.Session
+	// sessions contains the sessions to the remote AS, keyed by session ID.
+	// The default session always exists.
+	sessions map[mgmt.SessionType]*sessEntry
+	selector *base.ClassSelector
+}
+
+// sessEntry is a session together with the path policy of its path pool and
+// the number of paths it spreads flows over.
+type sessEntry struct {
+	*session.Session
+	policy    *pathpol.Policy
+	multipath int
 }
 
 func newASEntry(ia addr.IA) (*ASEntry, error) {
@@ -61,26 +75,115 @@ func newASEntry(ia addr.IA) (*ASEntry, error) {
 		Nets:              make(map[string]*net.IPNet),
 		healthMonitorStop: make(chan struct{}),
 	}
-	var err error
-	pool, err := session.NewPathPool(ia)
+	def, err := ae.newSession(config.DefaultSession, nil, 0)
 	if err != nil {
 		return nil, err
 	}
-	ae.Session, err = session.NewSession(ia, 0, ae.Logger, pool, worker.DefaultFactory)
+	ae.sessions = map[mgmt.SessionType]*sessEntry{config.DefaultSession: def}
+	ae.selector = base.NewClassSelector(def.Session, nil)
+	return ae, nil
+}
+
+func (ae *ASEntry) newSession(id mgmt.SessionType, policy *pathpol.Policy,
+	multipath int) (*sessEntry, error) {
+
+	pool, err := session.NewPathPool(ae.IA, policy)
 	if err != nil {
 		return nil, err
 	}
-	return ae, nil
+	sess, err := session.NewSession(ae.IA, id, multipath, ae.Logger, pool, worker.DefaultFactory)
+	if err != nil {
+		return nil, err
+	}
+	return &sessEntry{Session: sess, policy: policy, multipath: multipath}, nil
 }
 
 func (ae *ASEntry) ReloadConfig(cfg *config.ASEntry) bool {
 	ae.Lock()
 	defer ae.Unlock()
 	// Method calls first to prevent skips due to logical short-circuit
-	s := ae.addNewNets(cfg.Nets)
+	s := ae.reloadSessions(cfg)
+	s = ae.addNewNets(cfg.Nets) && s
 	return ae.delOldNets(cfg.Nets) && s
 }
 
+// reloadSessions creates the sessions in cfg that do not exist yet or whose
+// path policy or multipath setting changed, steers the traffic classes to the sessions, and cleans
+// up the sessions that are no longer used. If a session cannot be reloaded,
+// the current session with the same ID is kept.
+func (ae *ASEntry) reloadSessions(cfg *config.ASEntry) bool {
+	s := true
+	sessCfgs := cfg.Sessions
+	if cfg.Session(config.DefaultSession) == nil {
+		// The default session exists even if it is not configured.
+		sessCfgs = append([]*config.SessionEntry{{ID: config.DefaultSession}}, sessCfgs...)
+	}
+	sessions := make(map[mgmt.SessionType]*sessEntry)
+	for _, sessCfg := range sessCfgs {
+		sess, err := ae.reloadSession(cfg, sessCfg)
+		if err != nil {
+			ae.Error("Unable to reload session", "id", sessCfg.ID, "err", err)
+			s = false
+			if sess = ae.sessions[sessCfg.ID]; sess == nil {
+				continue
+			}
+		}
+		sessions[sessCfg.ID] = sess
+	}
+	var classes []base.ClassSession
+	for _, sessCfg := range cfg.Sessions {
+		sess, ok := sessions[sessCfg.ID]
+		class, classOk := cfg.Classes[sessCfg.Class]
+		if !ok || !classOk || sessCfg.ID == config.DefaultSession {
+			continue
+		}
+		classes = append(classes, base.ClassSession{Class: class, Session: sess.Session})
+	}
+	ae.selector.Update(sessions[config.DefaultSession].Session, classes)
+	// Clean up the sessions that have been replaced or removed.
+	for id, sess := range ae.sessions {
+		if sessions[id] != sess {
+			ae.cleanSession(sess)
+		}
+	}
+	ae.sessions = sessions
+	return s
+}
+
+// reloadSession returns the current session for sessCfg if its path policy
+// and multipath setting did not change. Otherwise, a new session is created, and started if the
+// network setup is done.
+func (ae *ASEntry) reloadSession(cfg *config.ASEntry,
+	sessCfg *config.SessionEntry) (*sessEntry, error) {
+
+	policy, err := cfg.PathPolicy(sessCfg.PathPolicy)
+	if err != nil {
+		return nil, err
+	}
+	curr, ok := ae.sessions[sessCfg.ID]
+	if ok && samePolicy(curr.policy, policy) && curr.multipath == sessCfg.Multipath {
+		return curr, nil
+	}
+	sess, err := ae.newSession(sessCfg.ID, policy, sessCfg.Multipath)
+	if err != nil {
+		return nil, err
+	}
+	if ae.egressRing != nil {
+		sess.Start()
+	}
+	ae.Info("Created session", "id", sessCfg.ID, "class", sessCfg.Class,
+		"policy", sessCfg.PathPolicy, "multipath", sessCfg.Multipath)
+	return sess, nil
+}
+
+// samePolicy compares the JSON encodings of the policies, which ignore the
+// policy names.
+func samePolicy(a, b *pathpol.Policy) bool {
+	rawA, errA := json.Marshal(a)
+	rawB, errB := json.Marshal(b)
+	return errA == nil && errB == nil && string(rawA) == string(rawB)
+}
+
 // addNewNets adds the networks in ipnets that are not currently configured.
 func (ae *ASEntry) addNewNets(ipnets []*config.IPNet) bool {
 	s := true
@@ -218,8 +321,11 @@ func (ae *ASEntry) performHealthCheck(prevHealth *bool, prevVersion *uint64) {
 	*prevVersion = ae.version
 }
 
+// checkHealth returns the health of the default session. The sessions of the
+// traffic classes do not affect the health of the remote AS, such that a
+// restrictive path policy does not withdraw the networks of the remote AS.
 func (ae *ASEntry) checkHealth() bool {
-	return ae.Session.Healthy()
+	return ae.sessions[config.DefaultSession].Healthy()
 }
 
 func (ae *ASEntry) Cleanup() error {
@@ -240,8 +346,19 @@ func (ae *ASEntry) Cleanup() error {
 }
 
 func (ae *ASEntry) cleanSessions() {
-	if err := ae.Session.Cleanup(); err != nil {
-		ae.Session.Error("Error cleaning up session", "err", err)
+	for _, sess := range ae.sessions {
+		ae.cleanSession(sess)
+	}
+}
+
+func (ae *ASEntry) cleanSession(sess *sessEntry) {
+	if ae.egressRing == nil {
+		// Sessions are only started on network setup, but Cleanup waits for
+		// the goroutines of the session to stop.
+		sess.Start()
+	}
+	if err := sess.Cleanup(); err != nil {
+		sess.Error("Error cleaning up session", "err", err)
 	}
 }
 
@@ -250,13 +367,14 @@ func (ae *ASEntry) setupNet() {
 		prometheus.Labels{"ringId": ae.IAString, "sessId": ""})
 	go func() {
 		defer log.LogPanicAndExit()
-		dispatcher.NewDispatcher(ae.IA, ae.egressRing,
-			&base.SingleSession{Session: ae.Session}).Run()
+		dispatcher.NewDispatcher(ae.IA, ae.egressRing, ae.selector).Run()
 	}()
 	go func() {
 		defer log.LogPanicAndExit()
 		ae.monitorHealth()
 	}()
-	ae.Session.Start()
+	for _, sess := range ae.sessions {
+		sess.Start()
+	}
 	ae.Info("Network setup done")
 }
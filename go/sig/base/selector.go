@@ -15,7 +15,10 @@
 package base
 
 import (
+	"sync/atomic"
+
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/pktcls"
 	"github.com/scionproto/scion/go/sig/egress"
 )
 
@@ -30,3 +33,52 @@ type SingleSession struct {
 func (ss *SingleSession) ChooseSess(b common.RawBytes) egress.Session {
 	return ss.Session
 }
+
+var _ egress.SessionSelector = (*ClassSelector)(nil)
+
+// ClassSession maps a traffic class to the session that carries its traffic.
+type ClassSession struct {
+	Class   *pktcls.Class
+	Session egress.Session
+}
+
+// ClassSelector implements egress.SessionSelector. On ChooseSess, the packet
+// is matched against the classes in order, and the session of the first
+// matching class is returned. Packets that do not match any class are sent
+// over the default session. The sessions can be replaced concurrently to
+// ChooseSess using Update.
+type ClassSelector struct {
+	// *classSelection
+	selection atomic.Value
+}
+
+type classSelection struct {
+	def     egress.Session
+	classes []ClassSession
+}
+
+func NewClassSelector(def egress.Session, classes []ClassSession) *ClassSelector {
+	cs := &ClassSelector{}
+	cs.Update(def, classes)
+	return cs
+}
+
+// Update replaces the default session and the class sessions.
+func (cs *ClassSelector) Update(def egress.Session, classes []ClassSession) {
+	cs.selection.Store(&classSelection{def: def, classes: classes})
+}
+
+func (cs *ClassSelector) ChooseSess(b common.RawBytes) egress.Session {
+	sel := cs.selection.Load().(*classSelection)
+	if len(sel.classes) == 0 {
+		// Skip parsing the packet if there is nothing to classify.
+		return sel.def
+	}
+	pkt := pktcls.NewPacket(b)
+	for _, c := range sel.classes {
+		if c.Class.Eval(pkt) {
+			return c.Session
+		}
+	}
+	return sel.def
+}
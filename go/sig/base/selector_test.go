@@ -0,0 +1,116 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package base
+
+import (
+	"net"
+	"testing"
+
+	"github.com/google/gopacket"
+	"github.com/google/gopacket/layers"
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/pktcls"
+	"github.com/scionproto/scion/go/sig/egress"
+	"github.com/scionproto/scion/go/sig/mgmt"
+)
+
+// testSession is a session that is only compared by identity.
+type testSession struct {
+	egress.Session
+	id mgmt.SessionType
+}
+
+func TestClassSelector(t *testing.T) {
+	def, voip, bulk := &testSession{id: 0}, &testSession{id: 1}, &testSession{id: 2}
+	classes := []ClassSession{
+		{
+			Class: pktcls.NewClass("voip",
+				pktcls.NewCondIPv4(&pktcls.IPv4MatchDSCP{DSCP: 0x2e})),
+			Session: voip,
+		},
+		{
+			Class: pktcls.NewClass("bulk",
+				pktcls.NewCondIPv4(&pktcls.IPv4MatchDestination{
+					Net: &net.IPNet{
+						IP:   net.IP{198, 51, 100, 0},
+						Mask: net.CIDRMask(24, 8*net.IPv4len),
+					},
+				})),
+			Session: bulk,
+		},
+	}
+	testCases := []struct {
+		Name    string
+		Packet  common.RawBytes
+		Session egress.Session
+	}{
+		{
+			Name:    "First matching class",
+			Packet:  newTestPacket(0x2e<<2, net.IP{198, 51, 100, 1}),
+			Session: voip,
+		},
+		{
+			Name:    "Second class",
+			Packet:  newTestPacket(0, net.IP{198, 51, 100, 1}),
+			Session: bulk,
+		},
+		{
+			Name:    "No matching class",
+			Packet:  newTestPacket(0, net.IP{192, 0, 2, 1}),
+			Session: def,
+		},
+		{
+			Name:    "Unparsable packet",
+			Packet:  common.RawBytes{0x00, 0x01},
+			Session: def,
+		},
+	}
+
+	Convey("Packets are steered to the session of their class", t, func() {
+		cs := NewClassSelector(def, classes)
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				SoMsg("session", cs.ChooseSess(tc.Packet), ShouldEqual, tc.Session)
+			})
+		}
+	})
+	Convey("Update replaces the sessions", t, func() {
+		cs := NewClassSelector(def, classes)
+		newDef := &testSession{id: 0}
+		cs.Update(newDef, nil)
+		SoMsg("session", cs.ChooseSess(newTestPacket(0x2e<<2, net.IP{192, 0, 2, 1})),
+			ShouldEqual, newDef)
+	})
+}
+
+func newTestPacket(tos uint8, dst net.IP) common.RawBytes {
+	buf := gopacket.NewSerializeBuffer()
+	gopacket.SerializeLayers(
+		buf,
+		gopacket.SerializeOptions{},
+		&layers.IPv4{
+			Version: 4,
+			IHL:     5,
+			TOS:     tos,
+			TTL:     64,
+			SrcIP:   net.IP{192, 0, 2, 100},
+			DstIP:   dst,
+		},
+		gopacket.Payload([]byte{1, 2, 3, 4}),
+	)
+	return buf.Bytes()
+}
@@ -23,6 +23,9 @@ import (
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/pathpol"
+	"github.com/scionproto/scion/go/lib/pktcls"
+	"github.com/scionproto/scion/go/sig/mgmt"
 )
 
 // Cfg is a direct Go representation of the JSON file format.
@@ -41,11 +44,135 @@ func LoadFromFile(path string) (*Cfg, error) {
 	if err := json.Unmarshal(b, cfg); err != nil {
 		return nil, common.NewBasicError("Unable to parse SIG config", err)
 	}
+	if err := cfg.Validate(); err != nil {
+		return nil, common.NewBasicError("Invalid SIG config", err)
+	}
 	return cfg, nil
 }
 
+// Validate checks that the configuration of all remote ASes is consistent.
+func (cfg *Cfg) Validate() error {
+	for ia, entry := range cfg.ASes {
+		if err := entry.Validate(); err != nil {
+			return common.NewBasicError("Invalid AS entry", err, "ia", ia)
+		}
+	}
+	return nil
+}
+
+// DefaultSession is the ID of the session that carries all traffic that does
+// not match any of the traffic classes of a remote AS.
+const DefaultSession mgmt.SessionType = 0
+
 type ASEntry struct {
 	Nets []*IPNet
+	// Classes are the traffic classes of the packets sent to the remote AS,
+	// keyed by class name.
+	Classes pktcls.ClassMap `json:",omitempty"`
+	// PathPolicies are the path policies that can be used by the sessions,
+	// keyed by policy name.
+	PathPolicies pathpol.PolicyMap `json:",omitempty"`
+	// Sessions maps traffic classes to sessions. Packets are matched against
+	// the classes in order, and are sent over the session of the first
+	// matching class. The default session must not have a class, but may set
+	// a path policy.
+	Sessions []*SessionEntry `json:",omitempty"`
+}
+
+// Validate checks that the sessions only refer to existing classes and path
+// policies, and that all path policies can be compiled.
+func (ae *ASEntry) Validate() error {
+	ids := make(map[mgmt.SessionType]struct{})
+	for _, sess := range ae.Sessions {
+		if _, ok := ids[sess.ID]; ok {
+			return common.NewBasicError("Duplicate session", nil, "id", sess.ID)
+		}
+		ids[sess.ID] = struct{}{}
+		switch {
+		case sess.ID == DefaultSession && sess.Class != "":
+			return common.NewBasicError("Default session must not have a class", nil,
+				"class", sess.Class)
+		case sess.ID != DefaultSession && sess.Class == "":
+			return common.NewBasicError("No class set", nil, "id", sess.ID)
+		}
+		if _, ok := ae.Classes[sess.Class]; sess.Class != "" && !ok {
+			return common.NewBasicError("Unknown class", nil,
+				"id", sess.ID, "class", sess.Class)
+		}
+		if _, ok := ae.PathPolicies[sess.PathPolicy]; sess.PathPolicy != "" && !ok {
+			return common.NewBasicError("Unknown path policy", nil,
+				"id", sess.ID, "policy", sess.PathPolicy)
+		}
+		if sess.Multipath < 0 {
+			return common.NewBasicError("Negative number of paths", nil,
+				"id", sess.ID, "multipath", sess.Multipath)
+		}
+	}
+	for name := range ae.PathPolicies {
+		if _, err := ae.PathPolicy(name); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// Session returns the entry of the session with the given ID, or nil if the
+// session is not configured.
+func (ae *ASEntry) Session(id mgmt.SessionType) *SessionEntry {
+	for _, sess := range ae.Sessions {
+		if sess.ID == id {
+			return sess
+		}
+	}
+	return nil
+}
+
+// PathPolicy compiles the path policy with the given name. Extended policies
+// are resolved by their name in PathPolicies. The empty name yields a nil
+// policy, which does not filter any paths.
+func (ae *ASEntry) PathPolicy(name string) (*pathpol.Policy, error) {
+	if name == "" {
+		return nil, nil
+	}
+	extPolicy, ok := ae.PathPolicies[name]
+	if !ok {
+		return nil, common.NewBasicError("Unknown path policy", nil, "policy", name)
+	}
+	extended := make([]*pathpol.ExtPolicy, 0, len(ae.PathPolicies))
+	for n, p := range ae.PathPolicies {
+		extended = append(extended, namedPolicy(n, p))
+	}
+	policy, err := pathpol.PolicyFromExtPolicy(namedPolicy(name, extPolicy), extended)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to compile path policy", err, "policy", name)
+	}
+	return policy, nil
+}
+
+// namedPolicy returns a copy of the policy with the name set. The names of
+// the policies are not part of the JSON encoding, and the copy prevents
+// PolicyFromExtPolicy from modifying the configured policy.
+func namedPolicy(name string, extPolicy *pathpol.ExtPolicy) *pathpol.ExtPolicy {
+	policy := &pathpol.Policy{}
+	if extPolicy.Policy != nil {
+		*policy = *extPolicy.Policy
+	}
+	policy.Name = name
+	return &pathpol.ExtPolicy{Extends: extPolicy.Extends, Policy: policy}
+}
+
+// SessionEntry configures a session to the remote AS.
+type SessionEntry struct {
+	ID mgmt.SessionType
+	// Class is the name of the traffic class carried by the session.
+	Class string `json:",omitempty"`
+	// PathPolicy is the name of the path policy that filters the paths of the
+	// session. If it is not set, all paths to the remote AS are used.
+	PathPolicy string `json:",omitempty"`
+	// Multipath is the number of healthy paths that the session spreads its
+	// flows over. All packets of a flow are sent over the same path. If it
+	// is less than 2, the session sends all traffic over a single path.
+	Multipath int `json:",omitempty"`
 }
 
 // IPNet is custom type of net.IPNet, to allow custom unmarshalling.
@@ -64,6 +191,12 @@ func (in *IPNet) UnmarshalJSON(b []byte) error {
 		return common.NewBasicError("Network is not canonical (should not be host address).",
 			nil, "raw", s)
 	}
+	if ip.To4() != nil && len(ipnet.Mask) == net.IPv6len {
+		// IPv4 packets would never match the network, and it would not be
+		// detected to overlap with IPv4 networks.
+		return common.NewBasicError("IPv4-mapped IPv6 networks are not supported", nil,
+			"raw", s)
+	}
 	*in = IPNet(*ipnet)
 	return nil
 }
@@ -23,6 +23,8 @@ import (
 	. "github.com/smartystreets/goconvey/convey"
 
 	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/pathpol"
+	"github.com/scionproto/scion/go/lib/pktcls"
 	"github.com/scionproto/scion/go/lib/xtest"
 )
 
@@ -71,6 +73,66 @@ func TestLoadFromFile(t *testing.T) {
 				ConfigVersion: 9001,
 			},
 		},
+		{
+			Name:     "classes",
+			FileName: "02-classes",
+			Config: Cfg{
+				ASes: map[addr.IA]*ASEntry{
+					xtest.MustParseIA("1-ff00:0:1"): {
+						Nets: []*IPNet{
+							{
+								IP:   net.IP{192, 0, 2, 0},
+								Mask: net.CIDRMask(24, 8*net.IPv4len),
+							},
+						},
+						Classes: pktcls.ClassMap{
+							"bulk": pktcls.NewClass(
+								"bulk",
+								pktcls.NewCondIPv4(&pktcls.IPv4MatchDestination{
+									Net: &net.IPNet{
+										IP:   net.IP{198, 51, 100, 0},
+										Mask: net.CIDRMask(24, 8*net.IPv4len),
+									},
+								}),
+							),
+							"voip": pktcls.NewClass(
+								"voip",
+								pktcls.NewCondIPv4(&pktcls.IPv4MatchDSCP{DSCP: 0x2e}),
+							),
+						},
+						PathPolicies: pathpol.PolicyMap{
+							"high-mtu": {
+								Policy: &pathpol.Policy{
+									Constraints: pathpol.Constraints{
+										{
+											Attribute: pathpol.AttrMTU,
+											Op:        pathpol.OpGreaterEqual,
+											Value:     1400,
+										},
+									},
+								},
+							},
+							"low-latency": {
+								Policy: &pathpol.Policy{
+									Ordering: &pathpol.Ordering{
+										Keys: []*pathpol.OrderKey{
+											{Attribute: pathpol.AttrLatency},
+										},
+										Limit: 1,
+									},
+								},
+							},
+						},
+						Sessions: []*SessionEntry{
+							{ID: 0, PathPolicy: "high-mtu"},
+							{ID: 1, Class: "voip", PathPolicy: "low-latency"},
+							{ID: 2, Class: "bulk", Multipath: 4},
+						},
+					},
+				},
+				ConfigVersion: 9002,
+			},
+		},
 	}
 
 	Convey("Test SIG config marshal/unmarshal", t, func() {
@@ -134,6 +196,16 @@ func TestIPNetUnmarshalJSON(t *testing.T) {
 			Error: true,
 			JSON:  `"2001::f1/24"`,
 		},
+		{
+			Name:  "Invalid IPv4-mapped IPv6 Network",
+			Error: true,
+			JSON:  `"::ffff:192.0.2.0/120"`,
+		},
+		{
+			Name:  "Correct Network IPv6 Addr with embedded IPv4 Addr",
+			Error: false,
+			JSON:  `"64:ff9b::c000:200/120"`,
+		},
 	}
 
 	Convey("Test verify network addr in sig.json", t, func() {
@@ -146,3 +218,112 @@ func TestIPNetUnmarshalJSON(t *testing.T) {
 		}
 	})
 }
+
+func TestASEntryValidate(t *testing.T) {
+	newEntry := func(sessions ...*SessionEntry) *ASEntry {
+		return &ASEntry{
+			Classes: pktcls.ClassMap{
+				"voip": pktcls.NewClass("voip",
+					pktcls.NewCondIPv4(&pktcls.IPv4MatchDSCP{DSCP: 0x2e})),
+			},
+			PathPolicies: pathpol.PolicyMap{
+				"base": {Policy: &pathpol.Policy{}},
+				"ext":  {Extends: []string{"base"}},
+			},
+			Sessions: sessions,
+		}
+	}
+	testCases := []struct {
+		Name  string
+		Entry *ASEntry
+		Error bool
+	}{
+		{
+			Name:  "No sessions",
+			Entry: newEntry(),
+		},
+		{
+			Name: "Valid sessions",
+			Entry: newEntry(
+				&SessionEntry{ID: 0, PathPolicy: "base"},
+				&SessionEntry{ID: 1, Class: "voip", PathPolicy: "ext", Multipath: 2},
+			),
+		},
+		{
+			Name: "Duplicate session",
+			Entry: newEntry(
+				&SessionEntry{ID: 1, Class: "voip"},
+				&SessionEntry{ID: 1, Class: "voip"},
+			),
+			Error: true,
+		},
+		{
+			Name:  "Default session with class",
+			Entry: newEntry(&SessionEntry{ID: 0, Class: "voip"}),
+			Error: true,
+		},
+		{
+			Name:  "Session without class",
+			Entry: newEntry(&SessionEntry{ID: 1}),
+			Error: true,
+		},
+		{
+			Name:  "Unknown class",
+			Entry: newEntry(&SessionEntry{ID: 1, Class: "bulk"}),
+			Error: true,
+		},
+		{
+			Name:  "Unknown path policy",
+			Entry: newEntry(&SessionEntry{ID: 1, Class: "voip", PathPolicy: "foo"}),
+			Error: true,
+		},
+		{
+			Name:  "Negative number of paths",
+			Entry: newEntry(&SessionEntry{ID: 1, Class: "voip", Multipath: -1}),
+			Error: true,
+		},
+		{
+			Name: "Unknown extended path policy",
+			Entry: &ASEntry{
+				PathPolicies: pathpol.PolicyMap{"ext": {Extends: []string{"foo"}}},
+			},
+			Error: true,
+		},
+	}
+
+	Convey("Test AS entry validation", t, func() {
+		for _, tc := range testCases {
+			Convey(tc.Name, func() {
+				xtest.SoMsgError("err", tc.Entry.Validate(), tc.Error)
+			})
+		}
+	})
+}
+
+func TestASEntryPathPolicy(t *testing.T) {
+	mtu := &pathpol.Constraint{Attribute: pathpol.AttrMTU, Op: pathpol.OpGreaterEqual,
+		Value: 1400}
+	entry := &ASEntry{
+		PathPolicies: pathpol.PolicyMap{
+			"base": {Policy: &pathpol.Policy{Constraints: pathpol.Constraints{mtu}}},
+			"ext":  {Extends: []string{"base"}},
+		},
+	}
+
+	Convey("Empty name yields nil policy", t, func() {
+		policy, err := entry.PathPolicy("")
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("policy", policy, ShouldBeNil)
+	})
+	Convey("Extended policies are resolved by name", t, func() {
+		policy, err := entry.PathPolicy("ext")
+		SoMsg("err", err, ShouldBeNil)
+		SoMsg("name", policy.Name, ShouldEqual, "ext")
+		SoMsg("constraints", policy.Constraints, ShouldResemble, pathpol.Constraints{mtu})
+		SoMsg("configured policy", entry.PathPolicies["ext"].Policy, ShouldBeNil)
+	})
+	Convey("Unknown policy", t, func() {
+		_, err := entry.PathPolicy("foo")
+		SoMsg("err", err, ShouldNotBeNil)
+	})
+}
@@ -64,7 +64,11 @@ func (ed *egressDispatcher) Run() {
 				ed.Debug("EgressDispatcher: unable to find session")
 				continue
 			}
-			sess.Ring().Write(ringbuf.EntryList{buf}, true)
+			if n, _ := sess.Ring().Write(ringbuf.EntryList{buf}, true); n < 0 {
+				// The session has been cleaned up after a config reload.
+				egress.EgressFreePkts.Write(ringbuf.EntryList{buf}, true)
+				continue
+			}
 			ed.updateMetrics(sess.IA().IAInt(), sess.ID(), len(buf))
 		}
 	}
@@ -82,9 +82,15 @@ type WorkerFactory func(Session, log.Logger) Runner
 type RemoteInfo struct {
 	Sig      *siginfo.Sig
 	SessPath *SessPath
+	// Paths are the paths that the flows of a multipath session are spread
+	// over. If it is empty, all traffic is sent over SessPath.
+	Paths []*WeightedPath
 }
 
 func (r *RemoteInfo) String() string {
+	if len(r.Paths) > 0 {
+		return fmt.Sprintf("Sig: %s Path: %s Paths: %s", r.Sig, r.SessPath, r.Paths)
+	}
 	return fmt.Sprintf("Sig: %s Path: %s", r.Sig, r.SessPath)
 }
 
@@ -0,0 +1,123 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"math"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/util"
+)
+
+// MaxPathWeight is the weight of a path without any failures.
+const MaxPathWeight = 1 << 10
+
+const (
+	fnvOffset64 = 14695981039346656037
+	fnvPrime64  = 1099511628211
+
+	ip4HdrLen = 20
+	ip6HdrLen = 40
+
+	protoTCP  = 6
+	protoUDP  = 17
+	protoSCTP = 132
+)
+
+// WeightedPath is one of the paths of a multipath session. Flows are spread
+// over the paths in proportion to the path weights.
+type WeightedPath struct {
+	*SessPath
+	Weight  uint32
+	keyHash uint64
+}
+
+func NewWeightedPath(path *SessPath, weight uint32) *WeightedPath {
+	return &WeightedPath{
+		SessPath: path,
+		Weight:   weight,
+		keyHash:  fnvAdd(fnvOffset64, common.RawBytes(path.Key())),
+	}
+}
+
+func (wp *WeightedPath) String() string {
+	return fmt.Sprintf("%s Weight: %d", wp.SessPath, wp.Weight)
+}
+
+// ChoosePath returns the index of the path that the flow with the given hash
+// is sent over. It uses weighted rendezvous hashing, such that a flow stays
+// on its path as long as that path is in the set, and only the flows of a
+// removed path are moved. If no path has a positive weight, -1 is returned.
+func ChoosePath(paths []*WeightedPath, flow uint64) int {
+	best := -1
+	var bestScore float64
+	for i, path := range paths {
+		if path.Weight == 0 {
+			continue
+		}
+		// u is uniformly distributed in (0, 1) for each (flow, path) pair.
+		u := (float64(util.Mix64(flow^path.keyHash)>>11) + 0.5) / (1 << 53)
+		score := float64(path.Weight) / -math.Log(u)
+		if best == -1 || score > bestScore {
+			best, bestScore = i, score
+		}
+	}
+	return best
+}
+
+// FlowHash returns a hash of the 5-tuple of the IPv4 or IPv6 packet pkt. The
+// ports are only included for TCP, UDP and SCTP packets that are not
+// fragmented, such that all fragments of a packet have the same hash.
+// Packets that cannot be parsed all have the same hash.
+func FlowHash(pkt common.RawBytes) uint64 {
+	var h uint64 = fnvOffset64
+	var proto uint8
+	var l4 common.RawBytes
+	switch {
+	case len(pkt) >= ip4HdrLen && pkt[0]>>4 == 4:
+		proto = pkt[9]
+		h = fnvAdd(h, pkt[12:20])
+		// Ignore the ports unless both the MF flag and the fragment offset are unset.
+		hdrLen := int(pkt[0]&0x0F) * 4
+		if common.Order.Uint16(pkt[6:8])&0x3FFF == 0 && hdrLen <= len(pkt) {
+			l4 = pkt[hdrLen:]
+		}
+	case len(pkt) >= ip6HdrLen && pkt[0]>>4 == 6:
+		// Packets with extension headers are hashed without ports.
+		proto = pkt[6]
+		h = fnvAdd(h, pkt[8:40])
+		l4 = pkt[ip6HdrLen:]
+	default:
+		return h
+	}
+	h = (h ^ uint64(proto)) * fnvPrime64
+	switch proto {
+	case protoTCP, protoUDP, protoSCTP:
+		if len(l4) >= 4 {
+			h = fnvAdd(h, l4[:4])
+		}
+	}
+	return h
+}
+
+// fnvAdd adds b to the FNV-1a hash h.
+func fnvAdd(h uint64, b common.RawBytes) uint64 {
+	for _, c := range b {
+		h ^= uint64(c)
+		h *= fnvPrime64
+	}
+	return h
+}
@@ -0,0 +1,180 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package egress
+
+import (
+	"fmt"
+	"testing"
+	"time"
+
+	. "github.com/smartystreets/goconvey/convey"
+
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/sciond"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
+	"github.com/scionproto/scion/go/lib/util"
+)
+
+// newUDP4 returns a UDP/IPv4 packet header. If frag is set, the packet is the
+// first fragment of a larger packet.
+func newUDP4(src, dst byte, srcPort, dstPort uint16, frag bool) common.RawBytes {
+	pkt := make(common.RawBytes, ip4HdrLen+8)
+	pkt[0] = 0x45
+	if frag {
+		// Set the MF flag.
+		pkt[6] = 0x20
+	}
+	pkt[9] = protoUDP
+	copy(pkt[12:16], []byte{192, 0, 2, src})
+	copy(pkt[16:20], []byte{198, 51, 100, dst})
+	common.Order.PutUint16(pkt[20:22], srcPort)
+	common.Order.PutUint16(pkt[22:24], dstPort)
+	return pkt
+}
+
+func newUDP6(src, dst byte, srcPort, dstPort uint16) common.RawBytes {
+	pkt := make(common.RawBytes, ip6HdrLen+8)
+	pkt[0] = 0x60
+	pkt[6] = protoUDP
+	pkt[8], pkt[23] = 0x20, src
+	pkt[24], pkt[39] = 0x20, dst
+	common.Order.PutUint16(pkt[40:42], srcPort)
+	common.Order.PutUint16(pkt[42:44], dstPort)
+	return pkt
+}
+
+func newTestPaths(weights ...uint32) []*WeightedPath {
+	var paths []*WeightedPath
+	for i, weight := range weights {
+		path := NewSessPath(spathmeta.PathKey(fmt.Sprintf("path%d", i)), nil)
+		paths = append(paths, NewWeightedPath(path, weight))
+	}
+	return paths
+}
+
+func TestFlowHash(t *testing.T) {
+	Convey("Packets of the same flow have the same hash", t, func() {
+		So(FlowHash(newUDP4(1, 2, 1000, 53, false)), ShouldEqual,
+			FlowHash(newUDP4(1, 2, 1000, 53, false)))
+		So(FlowHash(newUDP6(1, 2, 1000, 53)), ShouldEqual, FlowHash(newUDP6(1, 2, 1000, 53)))
+	})
+	Convey("Packets of different flows have different hashes", t, func() {
+		h := FlowHash(newUDP4(1, 2, 1000, 53, false))
+		So(FlowHash(newUDP4(3, 2, 1000, 53, false)), ShouldNotEqual, h)
+		So(FlowHash(newUDP4(1, 3, 1000, 53, false)), ShouldNotEqual, h)
+		So(FlowHash(newUDP4(1, 2, 1001, 53, false)), ShouldNotEqual, h)
+		So(FlowHash(newUDP4(1, 2, 1000, 54, false)), ShouldNotEqual, h)
+		h6 := FlowHash(newUDP6(1, 2, 1000, 53))
+		So(FlowHash(newUDP6(3, 2, 1000, 53)), ShouldNotEqual, h6)
+		So(FlowHash(newUDP6(1, 2, 1001, 53)), ShouldNotEqual, h6)
+	})
+	Convey("Ports of fragmented packets are ignored", t, func() {
+		So(FlowHash(newUDP4(1, 2, 1000, 53, true)), ShouldEqual,
+			FlowHash(newUDP4(1, 2, 1001, 54, true)))
+	})
+	Convey("Ports of non-port protocols are ignored", t, func() {
+		a, b := newUDP4(1, 2, 1000, 53, false), newUDP4(1, 2, 1001, 54, false)
+		a[9], b[9] = 1, 1
+		So(FlowHash(a), ShouldEqual, FlowHash(b))
+	})
+	Convey("Truncated packets do not panic", t, func() {
+		for _, pkt := range []common.RawBytes{nil, {0x45}, {0x60, 0, 0}} {
+			So(func() { FlowHash(pkt) }, ShouldNotPanic)
+		}
+		So(func() { FlowHash(newUDP4(1, 2, 1000, 53, false)[:ip4HdrLen+2]) }, ShouldNotPanic)
+	})
+}
+
+func TestChoosePath(t *testing.T) {
+	const flows = 10000
+	Convey("No paths", t, func() {
+		So(ChoosePath(nil, 1), ShouldEqual, -1)
+		So(ChoosePath(newTestPaths(0, 0), 1), ShouldEqual, -1)
+	})
+	Convey("Flows are spread according to the weights", t, func() {
+		paths := newTestPaths(MaxPathWeight, MaxPathWeight, MaxPathWeight/2, 0)
+		counts := make([]int, len(paths))
+		for i := 0; i < flows; i++ {
+			counts[ChoosePath(paths, FlowHash(newUDP4(1, 2, uint16(i), 53, false)))]++
+		}
+		So(counts[0], ShouldAlmostEqual, flows*2/5, flows/50)
+		So(counts[1], ShouldAlmostEqual, flows*2/5, flows/50)
+		So(counts[2], ShouldAlmostEqual, flows/5, flows/50)
+		So(counts[3], ShouldEqual, 0)
+	})
+	Convey("Only the flows of a removed path move", t, func() {
+		paths := newTestPaths(MaxPathWeight, MaxPathWeight, MaxPathWeight)
+		for i := 0; i < flows; i++ {
+			flow := FlowHash(newUDP4(1, 2, uint16(i), 53, false))
+			before := paths[ChoosePath(paths, flow)]
+			after := paths[1+ChoosePath(paths[1:], flow)]
+			if before != paths[0] {
+				So(after, ShouldEqual, before)
+			}
+		}
+	})
+}
+
+func TestSessPathPoolGetMultiple(t *testing.T) {
+	newEntry := func(exp time.Duration) *sciond.PathReplyEntry {
+		return &sciond.PathReplyEntry{
+			Path: &sciond.FwdPathMeta{ExpTime: util.TimeToSecs(time.Now().Add(exp))},
+		}
+	}
+	newPool := func() SessPathPool {
+		return SessPathPool{
+			"a": NewSessPathStats("a", newEntry(time.Hour)),
+			"b": NewSessPathStats("b", newEntry(time.Hour)),
+			"c": NewSessPathStats("c", newEntry(time.Hour)),
+			"d": NewSessPathStats("d", newEntry(time.Second)),
+		}
+	}
+	keys := func(paths []*WeightedPath) []spathmeta.PathKey {
+		var keys []spathmeta.PathKey
+		for _, path := range paths {
+			keys = append(keys, path.Key())
+		}
+		return keys
+	}
+	Convey("Paths close to expiry are skipped", t, func() {
+		paths := newPool().GetMultiple(5)
+		So(keys(paths), ShouldResemble, []spathmeta.PathKey{"a", "b", "c"})
+		for _, path := range paths {
+			So(path.Weight, ShouldEqual, MaxPathWeight)
+		}
+	})
+	Convey("The number of paths is limited", t, func() {
+		So(keys(newPool().GetMultiple(2)), ShouldResemble, []spathmeta.PathKey{"a", "b"})
+	})
+	Convey("Failed paths are skipped until they reply", t, func() {
+		spp := newPool()
+		spp.Timeout(spp["a"].SessPath, time.Now())
+		So(keys(spp.GetMultiple(5)), ShouldResemble, []spathmeta.PathKey{"b", "c"})
+		spp.Reply(spp["a"].SessPath, time.Now())
+		paths := spp.GetMultiple(5)
+		So(keys(paths), ShouldResemble, []spathmeta.PathKey{"b", "c", "a"})
+		So(paths[2].Weight, ShouldEqual, MaxPathWeight/2)
+	})
+	Convey("Without healthy paths, the best path is returned", t, func() {
+		spp := newPool()
+		for _, key := range []spathmeta.PathKey{"a", "b", "c"} {
+			spp.Timeout(spp[key].SessPath, time.Now())
+		}
+		paths := spp.GetMultiple(5)
+		So(len(paths), ShouldEqual, 1)
+		So(paths[0].Key(), ShouldBeIn, []spathmeta.PathKey{"a", "b", "c"})
+		So(paths[0].Weight, ShouldEqual, MaxPathWeight)
+	})
+}
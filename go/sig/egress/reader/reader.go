@@ -106,11 +106,22 @@ BatchLoop:
 }
 
 func (r *Reader) getDestIP(b common.RawBytes) (net.IP, error) {
+	if len(b) == 0 {
+		return nil, common.NewBasicError("Empty egress packet", nil)
+	}
 	ver := (b[0] >> 4)
 	switch ver {
 	case ip4Ver:
+		if len(b) < ip4DstOff+net.IPv4len {
+			return nil, common.NewBasicError("Truncated IPv4 egress packet", nil,
+				"len", len(b))
+		}
 		return net.IP(b[ip4DstOff : ip4DstOff+net.IPv4len]), nil
 	case ip6Ver:
+		if len(b) < ip6DstOff+net.IPv6len {
+			return nil, common.NewBasicError("Truncated IPv6 egress packet", nil,
+				"len", len(b))
+		}
 		return net.IP(b[ip6DstOff : ip6DstOff+net.IPv6len]), nil
 	default:
 		return nil, common.NewBasicError("Unsupported IP protocol version in egress packet", nil,
@@ -0,0 +1,140 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"fmt"
+	"math/rand"
+	"net"
+	"testing"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/ringbuf"
+)
+
+// linearNetworks is the former implementation of NetMapI, which scans all
+// non-overlapping networks on lookup. It is kept as a baseline for the
+// benchmarks.
+type linearNetworks struct {
+	nets []*network
+}
+
+func (ns *linearNetworks) Add(ipnet *net.IPNet, ia addr.IA, ring *ringbuf.Ring) error {
+	cnet := newCanonNet(ipnet)
+	for _, exnet := range ns.nets {
+		if exnet.net.Contains(cnet.IP) || cnet.Contains(exnet.net.IP) {
+			return fmt.Errorf("networks overlap: %s %s", cnet, exnet.net)
+		}
+	}
+	ns.nets = append(ns.nets, &network{cnet, ia, ring})
+	return nil
+}
+
+func (ns *linearNetworks) Delete(ipnet *net.IPNet) error {
+	return fmt.Errorf("not implemented")
+}
+
+func (ns *linearNetworks) Lookup(ip net.IP) (addr.IA, *ringbuf.Ring) {
+	for _, n := range ns.nets {
+		if n.net.Contains(ip) {
+			return n.ia, n.ring
+		}
+	}
+	return addr.IA{}, nil
+}
+
+// benchNets returns count non-overlapping networks. IPv4 networks are /24s in
+// 10.0.0.0/8, IPv6 networks are /48s in 2001:db8::/32.
+func benchNets(count int, v6 bool) []*net.IPNet {
+	nets := make([]*net.IPNet, 0, count)
+	for i := 0; i < count; i++ {
+		if v6 {
+			ip := net.ParseIP("2001:db8::")
+			ip[4], ip[5] = byte(i>>8), byte(i)
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(48, 8*net.IPv6len)})
+		} else {
+			ip := net.IP{10, byte(i >> 8), byte(i), 0}
+			nets = append(nets, &net.IPNet{IP: ip, Mask: net.CIDRMask(24, 8*net.IPv4len)})
+		}
+	}
+	return nets
+}
+
+// benchIPs returns count addresses, each contained in one of nets.
+func benchIPs(count int, nets []*net.IPNet) []net.IP {
+	r := rand.New(rand.NewSource(1))
+	ips := make([]net.IP, 0, count)
+	for i := 0; i < count; i++ {
+		n := nets[r.Intn(len(nets))]
+		ip := append(net.IP(nil), n.IP...)
+		ip[len(ip)-1] = byte(r.Intn(256))
+		ips = append(ips, ip)
+	}
+	return ips
+}
+
+func benchmarkLookup(b *testing.B, nm NetMapI, count int, v6 bool) {
+	nets := benchNets(count, v6)
+	for _, n := range nets {
+		if err := nm.Add(n, addr.IA{I: 1, A: 1}, &ringbuf.Ring{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	ips := benchIPs(1024, nets)
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if _, ring := nm.Lookup(ips[n%len(ips)]); ring == nil {
+			b.Fatal("Lookup failed")
+		}
+	}
+}
+
+func BenchmarkLookup(b *testing.B) {
+	for _, count := range []int{10, 1000, 10000} {
+		for _, v6 := range []bool{false, true} {
+			version := "IPv4"
+			if v6 {
+				version = "IPv6"
+			}
+			b.Run(fmt.Sprintf("Trie/%s/%d", version, count), func(b *testing.B) {
+				benchmarkLookup(b, &Networks{}, count, v6)
+			})
+			b.Run(fmt.Sprintf("Linear/%s/%d", version, count), func(b *testing.B) {
+				benchmarkLookup(b, &linearNetworks{}, count, v6)
+			})
+		}
+	}
+}
+
+// BenchmarkAddDelete measures modifications of a table with 10000 networks,
+// which copy the modified paths of the trie.
+func BenchmarkAddDelete(b *testing.B) {
+	nets := benchNets(10001, false)
+	ns := &Networks{}
+	for _, n := range nets[1:] {
+		if err := ns.Add(n, addr.IA{I: 1, A: 1}, &ringbuf.Ring{}); err != nil {
+			b.Fatal(err)
+		}
+	}
+	b.ResetTimer()
+	for n := 0; n < b.N; n++ {
+		if err := ns.Add(nets[0], addr.IA{I: 1, A: 1}, &ringbuf.Ring{}); err != nil {
+			b.Fatal(err)
+		}
+		if err := ns.Delete(nets[0]); err != nil {
+			b.Fatal(err)
+		}
+	}
+}
@@ -19,6 +19,7 @@ package router
 import (
 	"net"
 	"sync"
+	"sync/atomic"
 
 	"github.com/scionproto/scion/go/lib/addr"
 	"github.com/scionproto/scion/go/lib/common"
@@ -33,12 +34,24 @@ type NetMapI interface {
 	Lookup(net.IP) (addr.IA, *ringbuf.Ring)
 }
 
-// Networks is an unordered mapping of non-overlapping IP allocations to ASes. It is
-// concurrency safe. It is intended to be a stand-in until we have a a proper
-// mapping type, as the lookup is O(n) for the number of networks it contains.
+// Networks is a longest-prefix-match mapping of IP allocations to ASes.
+// Networks may overlap, Lookup returns the most specific network that contains
+// the address. It is concurrency safe. Lookups are lock-free: modifications
+// copy the modified paths of the tries and then atomically replace the tables,
+// such that config reloads do not block the egress path.
 type Networks struct {
-	m    sync.RWMutex
-	nets []*network
+	// m serializes modifications.
+	m sync.Mutex
+	// *tables
+	tables atomic.Value
+}
+
+// tables contains the immutable tries for both IP versions.
+type tables struct {
+	v4 *node
+	v6 *node
+	// count is the number of networks in both tries.
+	count int
 }
 
 func (ns *Networks) Add(ipnet *net.IPNet, ia addr.IA, ring *ringbuf.Ring) error {
@@ -49,54 +62,85 @@ func (ns *Networks) Add(ipnet *net.IPNet, ia addr.IA, ring *ringbuf.Ring) error
 		return common.NewBasicError("Networks.Add(): ringBuf.Ring must not be nil", nil, "ia", ia)
 	}
 	cnet := newCanonNet(ipnet)
+	prefixLen, ok := cnet.prefixLen()
+	if !ok {
+		return common.NewBasicError("Networks.Add(): Invalid network mask", nil, "net", ipnet)
+	}
 	ns.m.Lock()
 	defer ns.m.Unlock()
 	newNet := &network{cnet, ia, ring}
-	for _, exnet := range ns.nets {
-		if exnet.net.Contains(cnet.IP) || cnet.Contains(exnet.net.IP) {
-			return common.NewBasicError("Networks.Add(): Networks overlap", nil,
-				"new", newNet, "existing", exnet)
-		}
+	t := ns.load()
+	root := t.root(cnet)
+	newRoot, existing := root.insert(cnet.IP, 0, prefixLen, newNet)
+	if existing != nil {
+		return common.NewBasicError("Networks.Add(): Network already present", nil,
+			"new", newNet, "existing", existing)
 	}
-	ns.nets = append(ns.nets, newNet)
+	ns.tables.Store(t.with(cnet, newRoot, t.count+1))
 	return nil
 }
 
 func (ns *Networks) Delete(ipnet *net.IPNet) error {
 	cnet := newCanonNet(ipnet)
+	prefixLen, ok := cnet.prefixLen()
+	if !ok {
+		return common.NewBasicError("Networks.Delete(): Invalid network mask", nil, "net", ipnet)
+	}
 	ns.m.Lock()
 	defer ns.m.Unlock()
-	idx := ns.getIdxL(cnet)
-	if idx < 0 {
+	t := ns.load()
+	newRoot, ok := t.root(cnet).remove(cnet.IP, 0, prefixLen)
+	if !ok {
 		return common.NewBasicError("Networks.Delete(): IPNet entry not present", nil, "net", ipnet)
 	}
-	// Fast delete, as it doesn't preserve order.
-	// https://github.com/golang/go/wiki/SliceTricks#delete-without-preserving-order
-	l := len(ns.nets)
-	ns.nets[idx] = ns.nets[l-1] // Copy last element to index i
-	ns.nets[l-1] = nil          // Zero last element, to allow it to be garbage collected.
-	ns.nets = ns.nets[:l-1]
+	ns.tables.Store(t.with(cnet, newRoot, t.count-1))
 	return nil
 }
 
 func (ns *Networks) Lookup(ip net.IP) (addr.IA, *ringbuf.Ring) {
-	ns.m.RLock()
-	defer ns.m.RUnlock()
-	for _, n := range ns.nets {
-		if n.net.Contains(ip) {
-			return n.ia, n.ring
-		}
+	t := ns.load()
+	var n *network
+	if ip4 := ip.To4(); ip4 != nil {
+		n = t.v4.lookup(ip4)
+	} else if ip6 := ip.To16(); ip6 != nil {
+		n = t.v6.lookup(ip6)
+	}
+	if n == nil {
+		return addr.IA{}, nil
+	}
+	return n.ia, n.ring
+}
+
+// Len returns the number of networks.
+func (ns *Networks) Len() int {
+	return ns.load().count
+}
+
+func (ns *Networks) load() *tables {
+	if t, ok := ns.tables.Load().(*tables); ok {
+		return t
 	}
-	return addr.IA{}, nil
+	return &tables{}
 }
 
-func (ns *Networks) getIdxL(cnet *canonNet) int {
-	for i, n := range ns.nets {
-		if n.net.Equal(cnet) {
-			return i
-		}
+// root returns the root of the trie for the IP version of cnet.
+func (t *tables) root(cnet *canonNet) *node {
+	if cnet.isV4() {
+		return t.v4
 	}
-	return -1
+	return t.v6
+}
+
+// with returns a copy of the tables, with the trie for the IP version of cnet
+// replaced by root.
+func (t *tables) with(cnet *canonNet, root *node, count int) *tables {
+	c := &tables{v4: t.v4, v6: t.v6, count: count}
+	if cnet.isV4() {
+		c.v4 = root
+	} else {
+		c.v6 = root
+	}
+	return c
 }
 
 type network struct {
@@ -115,10 +159,31 @@ func newCanonNet(ipnet *net.IPNet) *canonNet {
 	cn := &canonNet{&net.IPNet{}}
 	// Canonicalize the IP
 	cn.IP = ipnet.IP.Mask(ipnet.Mask)
-	cn.Mask = append([]byte(nil), ipnet.Mask...)
+	mask := ipnet.Mask
+	if len(cn.IP) == net.IPv4len && len(mask) == net.IPv6len {
+		// IPv4 address with an IPv6 mask, see net.IP.Mask.
+		mask = mask[net.IPv6len-net.IPv4len:]
+	}
+	cn.Mask = append([]byte(nil), mask...)
 	return cn
 }
 
+// isV4 returns true for IPv4 networks. The IP of a canonicalized IPv4 network
+// has the same length as its mask.
+func (cn *canonNet) isV4() bool {
+	return len(cn.Mask) == net.IPv4len
+}
+
+// prefixLen returns the length of the network prefix. It returns false if the
+// mask is not in canonical form or does not match the IP.
+func (cn *canonNet) prefixLen() (int, bool) {
+	ones, bits := cn.Mask.Size()
+	if bits == 0 || len(cn.IP) != len(cn.Mask) {
+		return 0, false
+	}
+	return ones, true
+}
+
 func (cn *canonNet) Equal(other *canonNet) bool {
 	if cn == nil || other == nil {
 		return cn == other
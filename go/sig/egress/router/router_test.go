@@ -74,11 +74,14 @@ func Test_Networks_Add(t *testing.T) {
 		{[]string{"192.0.2.0/24", "192.0.2.1/24"}, 1, false},
 		{[]string{"2001:db8::/48", "2001:db8::1/48"}, 1, false},
 		// Test adding supernet
-		{[]string{"192.0.2.0/25", "192.0.2.0/24"}, 1, false},
-		{[]string{"2001:db8::/49", "2001:db8::/48"}, 1, false},
+		{[]string{"192.0.2.0/25", "192.0.2.0/24"}, 2, true},
+		{[]string{"2001:db8::/49", "2001:db8::/48"}, 2, true},
 		// Test adding subnet
-		{[]string{"192.0.2.0/24", "192.0.2.0/25"}, 1, false},
-		{[]string{"2001:db8::/48", "2001:db8::/49"}, 1, false},
+		{[]string{"192.0.2.0/24", "192.0.2.0/25"}, 2, true},
+		{[]string{"2001:db8::/48", "2001:db8::/49"}, 2, true},
+		// Test default routes
+		{[]string{"0.0.0.0/0", "::/0"}, 2, true},
+		{[]string{"0.0.0.0/0", "0.0.0.0/0"}, 1, false},
 	}
 	Convey("Networks.Add()", t, func() {
 		nets := &Networks{}
@@ -101,7 +104,7 @@ func Test_Networks_Add(t *testing.T) {
 					SoMsg("Errors should be thrown", ok, ShouldBeFalse)
 				}
 				SoMsg("There should be the correct number of networks",
-					len(nets.nets), ShouldEqual, tc.count)
+					nets.Len(), ShouldEqual, tc.count)
 			})
 		}
 	})
@@ -129,7 +132,7 @@ func Test_Networks_Delete(t *testing.T) {
 	}
 	Convey("Networks.Delete()", t, func() {
 		nets := defNetworks(t)
-		numNets := len(nets.nets)
+		numNets := nets.Len()
 		for _, tc := range testCases {
 			Convey(tc.net, func() {
 				delNet := parseNet(t, tc.net)
@@ -138,12 +141,9 @@ func Test_Networks_Delete(t *testing.T) {
 				if tc.ok {
 					SoMsg("Delete should succeed", err, ShouldBeNil)
 					SoMsg("Number of nets should have reduced",
-						len(nets.nets), ShouldEqual, numNets-1)
-					for _, n := range nets.nets {
-						if cdelNet.Equal(n.net) {
-							SoMsg("Network should not be present anymore", true, ShouldBeFalse)
-						}
-					}
+						nets.Len(), ShouldEqual, numNets-1)
+					_, ring := nets.Lookup(cdelNet.IP)
+					SoMsg("Network should not be present anymore", ring, ShouldBeNil)
 				} else {
 					SoMsg("Delete should fail", err, ShouldNotBeNil)
 				}
@@ -189,6 +189,93 @@ func Test_Networks_Lookup(t *testing.T) {
 	})
 }
 
+func Test_Networks_LongestPrefixMatch(t *testing.T) {
+	iaDef := addr.IA{I: 1, A: 0xff0000000010}
+	iaC := addr.IA{I: 1, A: 0xff0000000011}
+	nested := map[string]addr.IA{
+		"0.0.0.0/0":       iaDef,
+		"192.0.2.0/24":    iaA,
+		"192.0.2.128/25":  iaB,
+		"192.0.2.130/32":  iaC,
+		"::/0":            iaDef,
+		"2001:db8::/32":   iaA,
+		"2001:db8:1::/48": iaB,
+	}
+	var testCases = []struct {
+		ip string
+		ia addr.IA
+	}{
+		{"198.51.100.1", iaDef},
+		{"192.0.2.1", iaA},
+		{"192.0.2.127", iaA},
+		{"192.0.2.128", iaB},
+		{"192.0.2.130", iaC},
+		{"192.0.2.131", iaB},
+		{"2001:db9::1", iaDef},
+		{"2001:db8::1", iaA},
+		{"2001:db8:1::1", iaB},
+		{"2001:db8:2::1", iaA},
+	}
+	Convey("Networks.Lookup() returns the most specific network", t, func() {
+		nets := &Networks{}
+		for n, ia := range nested {
+			SoMsg("add "+n, nets.Add(parseNet(t, n), ia, &ringbuf.Ring{}), ShouldBeNil)
+		}
+		for _, tc := range testCases {
+			Convey(tc.ip, func() {
+				ia, ring := nets.Lookup(net.ParseIP(tc.ip))
+				SoMsg("Lookup should succeed", ring, ShouldNotBeNil)
+				SoMsg("IA should match", ia, ShouldResemble, tc.ia)
+			})
+		}
+		Convey("Deleting a subnet falls back to the supernet", func() {
+			SoMsg("err", nets.Delete(parseNet(t, "192.0.2.128/25")), ShouldBeNil)
+			ia, _ := nets.Lookup(net.ParseIP("192.0.2.131"))
+			SoMsg("IA should match", ia, ShouldResemble, iaA)
+			ia, _ = nets.Lookup(net.ParseIP("192.0.2.130"))
+			SoMsg("More specific IA should match", ia, ShouldResemble, iaC)
+		})
+		Convey("Deleting a supernet keeps the subnets", func() {
+			SoMsg("err", nets.Delete(parseNet(t, "0.0.0.0/0")), ShouldBeNil)
+			_, ring := nets.Lookup(net.ParseIP("198.51.100.1"))
+			SoMsg("Lookup should fail", ring, ShouldBeNil)
+			ia, _ := nets.Lookup(net.ParseIP("192.0.2.1"))
+			SoMsg("IA should match", ia, ShouldResemble, iaA)
+			ia, _ = nets.Lookup(net.ParseIP("2001:db9::1"))
+			SoMsg("IPv6 default should match", ia, ShouldResemble, iaDef)
+		})
+	})
+}
+
+func Test_Networks_ConcurrentLookup(t *testing.T) {
+	Convey("Lookups see a consistent table while networks are modified", t, func() {
+		nets := defNetworks(t)
+		numNets := nets.Len()
+		changing := parseNet(t, "198.51.100.0/24")
+		done := make(chan struct{})
+		go func() {
+			defer close(done)
+			for i := 0; i < 1000; i++ {
+				nets.Add(changing, iaB, &ringbuf.Ring{})
+				nets.Delete(changing)
+			}
+		}()
+		for {
+			select {
+			case <-done:
+				SoMsg("count", nets.Len(), ShouldEqual, numNets)
+				return
+			default:
+				ia, _ := nets.Lookup(net.ParseIP("192.0.2.5"))
+				if ia != iaB {
+					SoMsg("IA should match", ia, ShouldResemble, iaB)
+					return
+				}
+			}
+		}
+	})
+}
+
 func Test_ipNet_Equal(t *testing.T) {
 	var testCases = []struct {
 		netA string
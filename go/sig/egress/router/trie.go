@@ -0,0 +1,106 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package router
+
+import (
+	"net"
+)
+
+// node is a node of an immutable binary trie. The path from the root to a node
+// is the prefix of the node, the children extend the prefix by a 0 and a 1
+// bit, respectively. Modifications copy the nodes on the path to the modified
+// node and return a new root, such that concurrent lookups on the old root are
+// not affected.
+type node struct {
+	children [2]*node
+	// entry is the network with the prefix of the node, or nil if there is
+	// none.
+	entry *network
+}
+
+// insert returns the root of a copy of the trie with entry added at the
+// prefix of length prefixLen of ip. depth is the prefix length of n. If the
+// prefix already has an entry, the trie is not modified and the existing entry
+// is returned.
+func (n *node) insert(ip net.IP, depth, prefixLen int, entry *network) (*node, *network) {
+	c := &node{}
+	if n != nil {
+		*c = *n
+	}
+	if depth == prefixLen {
+		if c.entry != nil {
+			return nil, c.entry
+		}
+		c.entry = entry
+		return c, nil
+	}
+	b := bit(ip, depth)
+	child, existing := c.children[b].insert(ip, depth+1, prefixLen, entry)
+	if existing != nil {
+		return nil, existing
+	}
+	c.children[b] = child
+	return c, nil
+}
+
+// remove returns the root of a copy of the trie with the entry at the prefix
+// of length prefixLen of ip removed. Nodes without entry and children are
+// pruned. depth is the prefix length of n. It returns false if there is no
+// entry with the prefix.
+func (n *node) remove(ip net.IP, depth, prefixLen int) (*node, bool) {
+	if n == nil {
+		return nil, false
+	}
+	c := *n
+	if depth == prefixLen {
+		if c.entry == nil {
+			return nil, false
+		}
+		c.entry = nil
+	} else {
+		b := bit(ip, depth)
+		child, ok := c.children[b].remove(ip, depth+1, prefixLen)
+		if !ok {
+			return nil, false
+		}
+		c.children[b] = child
+	}
+	if c.entry == nil && c.children[0] == nil && c.children[1] == nil {
+		return nil, true
+	}
+	return &c, true
+}
+
+// lookup returns the entry with the longest prefix that contains ip, or nil
+// if there is none.
+func (n *node) lookup(ip net.IP) *network {
+	var best *network
+	maxDepth := len(ip) * 8
+	for depth := 0; n != nil; depth++ {
+		if n.entry != nil {
+			best = n.entry
+		}
+		if depth == maxDepth {
+			break
+		}
+		n = n.children[bit(ip, depth)]
+	}
+	return best
+}
+
+// bit returns the i-th most significant bit of ip.
+func bit(ip net.IP, i int) int {
+	return int(ip[i/8]>>(7-uint(i%8))) & 1
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/scionproto/scion/go/lib/common"
 	"github.com/scionproto/scion/go/lib/log"
 	"github.com/scionproto/scion/go/lib/pathmgr"
+	"github.com/scionproto/scion/go/lib/pathpol"
 	"github.com/scionproto/scion/go/lib/pktdisp"
 	"github.com/scionproto/scion/go/lib/ringbuf"
 	"github.com/scionproto/scion/go/lib/snet"
@@ -45,6 +46,9 @@ type Session struct {
 	log.Logger
 	ia     addr.IA
 	SessId mgmt.SessionType
+	// number of paths that flows are spread over, multipath is disabled if
+	// it is less than 2.
+	paths int
 
 	// pool of paths, managed by pathmgr
 	pool egress.PathPool
@@ -62,7 +66,9 @@ type Session struct {
 	factory        egress.WorkerFactory
 }
 
-func NewSession(dstIA addr.IA, sessId mgmt.SessionType, logger log.Logger,
+// NewSession creates a session to dstIA. If paths is at least 2, the flows are
+// spread over up to paths healthy paths from pool.
+func NewSession(dstIA addr.IA, sessId mgmt.SessionType, paths int, logger log.Logger,
 	pool egress.PathPool, factory egress.WorkerFactory) (*Session, error) {
 
 	var err error
@@ -70,6 +76,7 @@ func NewSession(dstIA addr.IA, sessId mgmt.SessionType, logger log.Logger,
 		Logger:  logger.New("sessId", sessId),
 		ia:      dstIA,
 		SessId:  sessId,
+		paths:   paths,
 		pool:    pool,
 		factory: factory,
 	}
@@ -166,8 +173,10 @@ type PathPool struct {
 
 var _ egress.PathPool = (*PathPool)(nil)
 
-func NewPathPool(dst addr.IA) (*PathPool, error) {
-	pool, err := sigcmn.PathMgr.Watch(context.TODO(), sigcmn.IA, dst)
+// NewPathPool creates a pool of the paths to dst that satisfy policy. A nil
+// policy does not filter any paths.
+func NewPathPool(dst addr.IA, policy *pathpol.Policy) (*PathPool, error) {
+	pool, err := sigcmn.PathMgr.WatchFilter(context.TODO(), sigcmn.IA, dst, policy)
 	if err != nil {
 		return nil, common.NewBasicError("Unable to register watch", err)
 	}
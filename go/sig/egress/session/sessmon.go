@@ -57,6 +57,11 @@ type sessMonitor struct {
 	// last PollReq sent, so that sessMonitor can correlate replies to the
 	// remoteInfo used for the request.
 	updateMsgId mgmt.MsgIdType
+	// the id of the last PollReq sent, used to keep the ids unique.
+	lastMsgId mgmt.MsgIdType
+	// the paths polled in multipath mode in addition to the path in smRemote,
+	// keyed by the id of the outstanding PollReq.
+	probes map[mgmt.MsgIdType]*egress.SessPath
 	// the last time a PollRep was received.
 	lastReply time.Time
 }
@@ -66,6 +71,7 @@ func newSessMonitor(sess *Session) *sessMonitor {
 		Logger: sess.Logger,
 		sess:   sess, pool: sess.pool,
 		sessPathPool: egress.NewSessPathPool(),
+		probes:       make(map[mgmt.MsgIdType]*egress.SessPath),
 	}
 }
 
@@ -98,6 +104,9 @@ Top:
 			sm.sessPathPool.Update(sm.pool.Paths())
 			sm.updateRemote()
 			sm.sendReq()
+			if sm.sess.paths > 1 {
+				sm.updatePaths()
+			}
 		case rpld := <-regc:
 			sm.handleRep(rpld)
 		case <-pathExpiryTick.C:
@@ -197,6 +206,47 @@ func (sm *sessMonitor) updateSessSnap() {
 	sm.sess.currRemote.Store(&remote)
 }
 
+// updatePaths updates the set of paths that the flows of a multipath session
+// are spread over, and polls the remote SIG over each of them to keep the
+// health statistics of the paths up to date.
+func (sm *sessMonitor) updatePaths() {
+	for id, path := range sm.probes {
+		if time.Since(id.Time()) > tout {
+			sm.sessPathPool.Timeout(path, id.Time())
+			delete(sm.probes, id)
+		}
+	}
+	paths := sm.sessPathPool.GetMultiple(sm.sess.paths)
+	if !samePaths(paths, sm.smRemote.Paths) {
+		sm.smRemote.Paths = paths
+		sm.updateSessSnap()
+		sm.Info("sessMonitor: New paths", "remote", sm.smRemote)
+	}
+	for _, path := range paths {
+		if sm.smRemote.SessPath != nil && path.Key() == sm.smRemote.SessPath.Key() {
+			// This path is already polled by sendReq.
+			continue
+		}
+		id := sm.newMsgId()
+		sm.probes[id] = path.SessPath
+		sm.sendPoll(path.SessPath, id)
+	}
+}
+
+// samePaths returns true if a and b contain the same paths with the same
+// weights in the same order.
+func samePaths(a, b []*egress.WeightedPath) bool {
+	if len(a) != len(b) {
+		return false
+	}
+	for i := range a {
+		if a[i].Key() != b[i].Key() || a[i].Weight != b[i].Weight {
+			return false
+		}
+	}
+	return true
+}
+
 func (sm *sessMonitor) getNewPath(old *egress.SessPath) *egress.SessPath {
 	if old == nil {
 		return sm.sessPathPool.Get("")
@@ -208,8 +258,24 @@ func (sm *sessMonitor) sendReq() {
 	if sm.smRemote == nil || sm.smRemote.SessPath == nil {
 		return
 	}
-	sm.updateMsgId = mgmt.MsgIdType(time.Now().UnixNano())
-	spld, err := mgmt.NewPld(sm.updateMsgId, mgmt.NewPollReq(sigcmn.MgmtAddr, sm.sess.SessId))
+	sm.updateMsgId = sm.newMsgId()
+	sm.sendPoll(sm.smRemote.SessPath, sm.updateMsgId)
+}
+
+// newMsgId returns a PollReq id based on the current time. The ids are
+// strictly increasing, even if several polls are sent at once.
+func (sm *sessMonitor) newMsgId() mgmt.MsgIdType {
+	id := mgmt.MsgIdType(time.Now().UnixNano())
+	if id <= sm.lastMsgId {
+		id = sm.lastMsgId + 1
+	}
+	sm.lastMsgId = id
+	return id
+}
+
+// sendPoll sends a PollReq with the given id to the remote SIG over path.
+func (sm *sessMonitor) sendPoll(path *egress.SessPath, id mgmt.MsgIdType) {
+	spld, err := mgmt.NewPld(id, mgmt.NewPollReq(sigcmn.MgmtAddr, sm.sess.SessId))
 	if err != nil {
 		sm.Error("sessMonitor: Error creating SIGCtrl payload", "err", err)
 		return
@@ -230,11 +296,11 @@ func (sm *sessMonitor) sendReq() {
 		return
 	}
 	raddr := sm.smRemote.Sig.CtrlSnetAddr()
-	raddr.Path = spath.New(sm.smRemote.SessPath.PathEntry().Path.FwdPath)
+	raddr.Path = spath.New(path.PathEntry().Path.FwdPath)
 	if err := raddr.Path.InitOffsets(); err != nil {
 		sm.Error("sessMonitor: Error initializing path offsets", "err", err)
 	}
-	nh, err := sm.smRemote.SessPath.PathEntry().HostInfo.Overlay()
+	nh, err := path.PathEntry().HostInfo.Overlay()
 	if err != nil {
 		sm.Error("sessMonitor: Unsupported NextHop", "err", err)
 	}
@@ -261,6 +327,13 @@ func (sm *sessMonitor) handleRep(rpld *disp.RegPld) {
 		return
 	}
 
+	if path, ok := sm.probes[rpld.Id]; ok {
+		// Reply to a poll over one of the paths of a multipath session.
+		delete(sm.probes, rpld.Id)
+		sm.sessPathPool.Reply(path, rpld.Id.Time())
+		return
+	}
+
 	// Inform SessPathPool that a reply has arrived.
 	if sm.smRemote.SessPath != nil {
 		sm.sessPathPool.Reply(sm.smRemote.SessPath, rpld.Id.Time())
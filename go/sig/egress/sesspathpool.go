@@ -16,6 +16,7 @@ package egress
 
 import (
 	"math"
+	"sort"
 	"time"
 
 	"github.com/scionproto/scion/go/lib/sciond"
@@ -66,6 +67,38 @@ func (spp SessPathPool) Get(exclude spathmeta.PathKey) *SessPath {
 	return res.SessPath
 }
 
+// GetMultiple returns up to n paths to spread traffic over, weighted by their
+// number of recent failures. Only healthy paths that are not close to expiry
+// are returned, paths with fewer failures first. If there is no such path, the
+// result contains the path returned by Get, if any.
+func (spp SessPathPool) GetMultiple(n int) []*WeightedPath {
+	var stats []*SessPathStats
+	for _, v := range spp {
+		if v.healthy() && !v.SessPath.IsCloseToExpiry() {
+			stats = append(stats, v)
+		}
+	}
+	sort.Slice(stats, func(i, j int) bool {
+		if stats[i].failCount != stats[j].failCount {
+			return stats[i].failCount < stats[j].failCount
+		}
+		return stats[i].SessPath.Key() < stats[j].SessPath.Key()
+	})
+	if len(stats) > n {
+		stats = stats[:n]
+	}
+	paths := make([]*WeightedPath, 0, len(stats))
+	for _, v := range stats {
+		paths = append(paths, NewWeightedPath(v.SessPath, v.weight()))
+	}
+	if len(paths) == 0 {
+		if path := spp.Get(""); path != nil {
+			paths = append(paths, NewWeightedPath(path, MaxPathWeight))
+		}
+	}
+	return paths
+}
+
 func (spp SessPathPool) GetByKey(key spathmeta.PathKey) *SessPath {
 	res := spp[key]
 	if res == nil {
@@ -96,6 +129,11 @@ func (spp SessPathPool) Update(aps spathmeta.AppPathSet) {
 // Reply is called when a probe reply arrives.
 // 'sent' is the time when the original probe was sent.
 func (spp SessPathPool) Reply(path *SessPath, sent time.Time) {
+	sp := spp[path.Key()]
+	if sp == nil {
+		return
+	}
+	sp.lastReply = time.Now()
 }
 
 // Timeout is called when a reply to a probe is not received in time.
@@ -122,6 +160,7 @@ func (spp SessPathPool) ExpireFails() {
 type SessPathStats struct {
 	SessPath  *SessPath
 	lastFail  time.Time
+	lastReply time.Time
 	failCount uint16
 }
 
@@ -131,3 +170,15 @@ func NewSessPathStats(key spathmeta.PathKey, pathEntry *sciond.PathReplyEntry) *
 		lastFail: time.Now(),
 	}
 }
+
+// healthy returns true if the path has no recent failures, or if a reply
+// arrived over the path after the last failure.
+func (sps *SessPathStats) healthy() bool {
+	return sps.failCount == 0 || sps.lastReply.After(sps.lastFail)
+}
+
+// weight returns the weight of the path in a multipath session. Every recent
+// failure reduces the share of the traffic sent over the path.
+func (sps *SessPathStats) weight() uint32 {
+	return MaxPathWeight / (1 + uint32(sps.failCount))
+}
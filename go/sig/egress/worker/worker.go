@@ -25,6 +25,7 @@ import (
 	"github.com/scionproto/scion/go/lib/ringbuf"
 	"github.com/scionproto/scion/go/lib/sciond"
 	"github.com/scionproto/scion/go/lib/spath"
+	"github.com/scionproto/scion/go/lib/spath/spathmeta"
 	"github.com/scionproto/scion/go/lib/spkt"
 	"github.com/scionproto/scion/go/lib/util"
 	"github.com/scionproto/scion/go/sig/egress"
@@ -66,13 +67,29 @@ type worker struct {
 	log.Logger
 	iaString      string
 	sess          egress.Session
+	frameSentCtrs metrics.CtrPair
+
+	// the session's remote that the streams were last synchronized with.
+	remote *egress.RemoteInfo
+	// the streams of the paths of a multipath session, keyed by path, or a
+	// single stream with the empty key if multipath is disabled.
+	streams map[spathmeta.PathKey]*stream
+	// the streams in the order of remote.Paths.
+	active    []*stream
+	lastEpoch uint16
+	pkts      ringbuf.EntryList
+}
+
+// stream is a sequence of frames sent over the same path. Every stream has its
+// own epoch, such that the remote SIG reassembles the streams independently.
+type stream struct {
+	f             *frame
+	path          *egress.SessPath
 	currSig       *siginfo.Sig
 	currPathEntry *sciond.PathReplyEntry
-	frameSentCtrs metrics.CtrPair
 
 	epoch uint16
 	seq   uint32
-	pkts  ringbuf.EntryList
 }
 
 func NewWorker(sess egress.Session, logger log.Logger) *worker {
@@ -84,37 +101,34 @@ func NewWorker(sess egress.Session, logger log.Logger) *worker {
 			Pkts:  metrics.FramesSent.WithLabelValues(sess.IA().String(), sess.ID().String()),
 			Bytes: metrics.FrameBytesSent.WithLabelValues(sess.IA().String(), sess.ID().String()),
 		},
-		pkts: make(ringbuf.EntryList, 0, egress.EgressBufPkts),
+		lastEpoch: uint16(time.Now().Unix()&0xFFFF) - 1,
+		pkts:      make(ringbuf.EntryList, 0, egress.EgressBufPkts),
 	}
 }
 
 func (w *worker) Run() {
 	defer log.LogPanicAndExit()
 	w.Info("EgressWorker: starting")
-	f := newFrame()
 
 TopLoop:
 	for {
-		// If the frame is empty, block indefinitely for more packets.
-		fEmpty := f.offset == sigcmn.SIGHdrSize
-		if !w.read(fEmpty) {
+		// If all frames are empty, block indefinitely for more packets.
+		empty := w.empty()
+		if !w.read(empty) {
 			break TopLoop
 		}
-		if fEmpty {
-			// Cover the case where no packets have arrived in a while, and the
-			// current path is stale.
-			w.resetFrame(f)
-		} else if len(w.pkts) == 0 {
-			// Didn't read any new packets, send partial frame.
-			if err := w.write(f); err != nil {
-				w.Error("Error sending frame", "err", err)
-			}
+		if !empty && len(w.pkts) == 0 {
+			// Didn't read any new packets, send partial frames.
+			w.flush()
 			continue TopLoop
 		}
+		// Cover the case where no packets have arrived in a while, and the
+		// current paths are stale.
+		w.sync()
 		// Process buffered packets.
 		for i := range w.pkts {
 			pkt := w.pkts[i].(common.RawBytes)
-			if err := w.processPkt(f, pkt); err != nil {
+			if err := w.processPkt(w.choose(pkt), pkt); err != nil {
 				w.Error("Error sending frame", "err", err)
 			}
 		}
@@ -128,7 +142,8 @@ TopLoop:
 	w.sess.AnnounceWorkerStopped()
 }
 
-func (w *worker) processPkt(f *frame, pkt common.RawBytes) error {
+func (w *worker) processPkt(s *stream, pkt common.RawBytes) error {
+	f := s.f
 	f.startPkt(uint16(len(pkt)))
 	pktOff := 0
 	// Write chunks of the packet to frames, sending off frames as they fill up.
@@ -136,7 +151,7 @@ func (w *worker) processPkt(f *frame, pkt common.RawBytes) error {
 		pktOff += f.readFrom(pkt[pktOff:])
 		if f.isFull() {
 			// There's no point in trying to fit another packet into this frame.
-			if err := w.write(f); err != nil {
+			if err := w.write(s); err != nil {
 				// Skip the rest of this packet.
 				return err
 			}
@@ -161,38 +176,116 @@ func (w *worker) read(block bool) bool {
 	return true
 }
 
-func (w *worker) write(f *frame) error {
+// sync updates the streams to the current remote of the session. The partial
+// frames of paths that are no longer used are sent before their streams are
+// removed.
+func (w *worker) sync() {
+	remote := w.sess.Remote()
+	if remote == w.remote && w.active != nil {
+		return
+	}
+	w.remote = remote
+	keys := []spathmeta.PathKey{""}
+	paths := []*egress.SessPath{nil}
+	if remote != nil && len(remote.Paths) > 0 {
+		keys, paths = keys[:0], paths[:0]
+		for _, path := range remote.Paths {
+			keys = append(keys, path.Key())
+			paths = append(paths, path.SessPath)
+		}
+	} else if remote != nil {
+		paths[0] = remote.SessPath
+	}
+	streams := make(map[spathmeta.PathKey]*stream, len(keys))
+	active := make([]*stream, 0, len(keys))
+	for i, key := range keys {
+		s, ok := w.streams[key]
+		if !ok {
+			s = &stream{f: newFrame()}
+		}
+		s.path = paths[i]
+		if s.f.isEmpty() {
+			w.resetFrame(s)
+		}
+		streams[key] = s
+		active = append(active, s)
+	}
+	for key, s := range w.streams {
+		if _, ok := streams[key]; !ok && !s.f.isEmpty() {
+			if err := w.write(s); err != nil {
+				w.Error("Error sending frame", "err", err)
+			}
+		}
+	}
+	w.streams, w.active = streams, active
+}
+
+// choose returns the stream of the path that pkt is sent over.
+func (w *worker) choose(pkt common.RawBytes) *stream {
+	if len(w.active) == 1 {
+		return w.active[0]
+	}
+	i := egress.ChoosePath(w.remote.Paths, egress.FlowHash(pkt))
+	if i < 0 {
+		i = 0
+	}
+	return w.active[i]
+}
+
+// empty returns true if none of the streams has a partial frame.
+func (w *worker) empty() bool {
+	for _, s := range w.active {
+		if !s.f.isEmpty() {
+			return false
+		}
+	}
+	return true
+}
+
+// flush sends the partial frames of all streams.
+func (w *worker) flush() {
+	for _, s := range w.active {
+		if s.f.isEmpty() {
+			continue
+		}
+		if err := w.write(s); err != nil {
+			w.Error("Error sending frame", "err", err)
+		}
+	}
+}
+
+func (w *worker) write(s *stream) error {
 	// TODO(kormat): consider looking for an updated path here, and switching
 	// to it if the mtu isn't smaller than the current one.
-	defer w.resetFrame(f)
-	if w.currPathEntry == nil {
+	defer w.resetFrame(s)
+	if s.currPathEntry == nil {
 		// FIXME(kormat): add some metrics to track this.
 		return nil
 	}
-	if w.currSig == nil {
+	if s.currSig == nil {
 		// FIXME(kormat): add some metrics to track this.
 		return nil
 	}
-	snetAddr := w.currSig.EncapSnetAddr()
-	snetAddr.Path = spath.New(w.currPathEntry.Path.FwdPath)
+	snetAddr := s.currSig.EncapSnetAddr()
+	snetAddr.Path = spath.New(s.currPathEntry.Path.FwdPath)
 	if err := snetAddr.Path.InitOffsets(); err != nil {
 		return common.NewBasicError("Error initializing path offsets", err)
 	}
-	nh, err := w.currPathEntry.HostInfo.Overlay()
+	nh, err := s.currPathEntry.HostInfo.Overlay()
 	if err != nil {
 		return common.NewBasicError("Egress unsupported NextHop", err)
 	}
 	snetAddr.NextHop = nh
-	if w.seq == 0 {
-		w.epoch = uint16(time.Now().Unix() & 0xFFFF)
+	if s.seq == 0 {
+		s.epoch = w.newEpoch()
 	}
-	f.writeHdr(w.sess.ID(), w.epoch, w.seq)
+	s.f.writeHdr(w.sess.ID(), s.epoch, s.seq)
 	// Update sequence number for next packet
-	w.seq += 1
-	if w.seq > MaxSeq {
-		w.seq = 0
+	s.seq += 1
+	if s.seq > MaxSeq {
+		s.seq = 0
 	}
-	bytesWritten, err := w.sess.Conn().WriteToSCION(f.raw(), snetAddr)
+	bytesWritten, err := w.sess.Conn().WriteToSCION(s.f.raw(), snetAddr)
 	if err != nil {
 		return common.NewBasicError("Egress write error", err)
 	}
@@ -201,26 +294,37 @@ func (w *worker) write(f *frame) error {
 	return nil
 }
 
-func (w *worker) resetFrame(f *frame) {
+// newEpoch returns the epoch of a stream that starts at sequence number 0. The
+// epochs of the streams of a worker are distinct, even if they start within
+// the same second.
+func (w *worker) newEpoch() uint16 {
+	epoch := uint16(time.Now().Unix() & 0xFFFF)
+	if int16(epoch-w.lastEpoch) <= 0 {
+		epoch = w.lastEpoch + 1
+	}
+	w.lastEpoch = epoch
+	return epoch
+}
+
+func (w *worker) resetFrame(s *stream) {
 	var mtu uint16 = common.MinMTU
 	var addrLen, pathLen uint16
-	remote := w.sess.Remote()
-	if remote != nil {
-		w.currSig = remote.Sig
-		if w.currSig != nil {
-			addrLen = uint16(spkt.AddrHdrLen(w.currSig.Host, sigcmn.Host))
+	if w.remote != nil {
+		s.currSig = w.remote.Sig
+		if s.currSig != nil {
+			addrLen = uint16(spkt.AddrHdrLen(s.currSig.Host, sigcmn.Host))
 		}
-		w.currPathEntry = nil
-		if remote.SessPath != nil {
-			w.currPathEntry = remote.SessPath.PathEntry()
+		s.currPathEntry = nil
+		if s.path != nil {
+			s.currPathEntry = s.path.PathEntry()
 		}
-		if w.currPathEntry != nil {
-			mtu = w.currPathEntry.Path.Mtu
-			pathLen = uint16(len(w.currPathEntry.Path.FwdPath))
+		if s.currPathEntry != nil {
+			mtu = s.currPathEntry.Path.Mtu
+			pathLen = uint16(len(s.currPathEntry.Path.FwdPath))
 		}
 	}
 	// FIXME(kormat): to do this properly, need to account for any ext headers.
-	f.reset(mtu - spkt.CmnHdrLen - addrLen - pathLen - l4.UDPLen)
+	s.f.reset(mtu - spkt.CmnHdrLen - addrLen - pathLen - l4.UDPLen)
 }
 
 type frame struct {
@@ -249,6 +353,10 @@ func (f *frame) readFrom(b common.RawBytes) int {
 	return copied
 }
 
+func (f *frame) isEmpty() bool {
+	return f.offset == sigcmn.SIGHdrSize
+}
+
 func (f *frame) isFull() bool {
 	return (len(f.b) - f.offset) < MinSpace
 }
@@ -35,6 +35,15 @@ const (
 	rlistCleanUpInterval = 1 * time.Second
 )
 
+const (
+	ip4Ver       = 0x4
+	ip6Ver       = 0x6
+	ip4HdrLen    = 20
+	ip4LenOff    = 2
+	ip6HdrLen    = 40
+	ip6PldLenOff = 4
+)
+
 type sender interface {
 	send(common.RawBytes) error
 }
@@ -156,6 +165,9 @@ func (w *Worker) cleanup() {
 }
 
 func (w *Worker) send(packet common.RawBytes) error {
+	if err := checkPkt(packet); err != nil {
+		return err
+	}
 	bytesWritten, err := tunIO.Write(packet)
 	if err != nil {
 		return common.NewBasicError("Unable to write to internal ingress", err,
@@ -165,3 +177,35 @@ func (w *Worker) send(packet common.RawBytes) error {
 	w.sentCtrs.Bytes.Add(float64(bytesWritten))
 	return nil
 }
+
+// checkPkt verifies that packet is an IPv4 or IPv6 packet whose length matches
+// the length in its header. This prevents writing the garbage of a broken
+// reassembly to the internal network.
+func checkPkt(packet common.RawBytes) error {
+	if len(packet) == 0 {
+		return common.NewBasicError("Empty ingress packet", nil)
+	}
+	var expLen int
+	switch ver := packet[0] >> 4; ver {
+	case ip4Ver:
+		if len(packet) < ip4HdrLen {
+			return common.NewBasicError("Truncated IPv4 ingress packet", nil,
+				"length", len(packet))
+		}
+		expLen = int(common.Order.Uint16(packet[ip4LenOff:]))
+	case ip6Ver:
+		if len(packet) < ip6HdrLen {
+			return common.NewBasicError("Truncated IPv6 ingress packet", nil,
+				"length", len(packet))
+		}
+		expLen = ip6HdrLen + int(common.Order.Uint16(packet[ip6PldLenOff:]))
+	default:
+		return common.NewBasicError("Unsupported IP protocol version in ingress packet", nil,
+			"type", ver)
+	}
+	if expLen != len(packet) {
+		return common.NewBasicError("Ingress packet length does not match header", nil,
+			"expected", expLen, "length", len(packet))
+	}
+	return nil
+}
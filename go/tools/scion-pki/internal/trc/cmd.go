@@ -62,6 +62,10 @@ and a section 'TRC' with the following values:
 		integer reprensenting the time the previous TRC is still valid in seconds
 	QuorumTRC [required]
 		integer reprensenting the number of core ASes needed to sign a new TRC.
+
+'trc' also provides the commands 'create', 'sign', 'combine' and 'verify' to handle
+signed TRCs in the new format (go/lib/scrypto/trc/v2). They operate on the files
+passed as arguments and do not use the root directory.
 `,
 }
 
@@ -74,6 +78,71 @@ var gen = &cobra.Command{
 	},
 }
 
+var createTRC = &cobra.Command{
+	Use:   "create <payload.json> <signed.trc>",
+	Short: "Create an unsigned TRC from the TRC payload",
+	Long: `
+'create' validates the TRC payload and writes it encoded in a signed TRC container
+without any signatures. The signatures are added with 'sign'.
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runCreate(args)
+	},
+}
+
+var signTRC = &cobra.Command{
+	Use:   "sign <signed.trc> <out.trc>",
+	Short: "Sign the TRC with the key of a primary AS",
+	Long: `
+'sign' adds the vote and/or proof of possession that the TRC expects from the primary
+AS for the given key type. The algorithm and key version are taken from the TRC. Votes
+are cast with the key of the previous TRC, which must be passed with --prev if the
+voting key is modified in the update. The private key file contains the base64
+encoded key as stored by 'keys gen'.
+`,
+	Args: cobra.ExactArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runSign(args)
+	},
+}
+
+var combineTRC = &cobra.Command{
+	Use:   "combine <out.trc> <signed.trc>...",
+	Short: "Combine the signatures of multiple signed TRCs",
+	Long: `
+'combine' merges the signatures of signed TRCs with an identical payload into one
+signed TRC.
+`,
+	Args: cobra.MinimumNArgs(2),
+	Run: func(cmd *cobra.Command, args []string) {
+		runCombine(args)
+	},
+}
+
+var verifyTRC = &cobra.Command{
+	Use:   "verify <signed.trc>",
+	Short: "Verify the signed TRC",
+	Long: `
+'verify' verifies all signatures of the signed TRC. A TRC update is verified against the
+previous TRC, which must be passed with --prev.
+`,
+	Args: cobra.ExactArgs(1),
+	Run: func(cmd *cobra.Command, args []string) {
+		runVerify(args)
+	},
+}
+
 func init() {
 	Cmd.AddCommand(gen)
+	Cmd.AddCommand(createTRC)
+	Cmd.AddCommand(signTRC)
+	Cmd.AddCommand(combineTRC)
+	Cmd.AddCommand(verifyTRC)
+	signTRC.Flags().StringVar(&signAS, "as", "", "primary AS that signs the TRC, e.g., ff00:0:110")
+	signTRC.Flags().StringVar(&signKeyType, "key-type", "",
+		"key type of the signing key (Online, Offline or Issuing)")
+	signTRC.Flags().StringVar(&signKey, "key", "", "file containing the private signing key")
+	signTRC.Flags().StringVar(&prevTRC, "prev", "", "previous signed TRC")
+	verifyTRC.Flags().StringVar(&prevTRC, "prev", "", "previous signed TRC")
 }
@@ -0,0 +1,274 @@
+// Copyright 2019 Anapaya Systems
+//
+// Licensed under the Apache License, Version 2.0 (the "License");
+// you may not use this file except in compliance with the License.
+// You may obtain a copy of the License at
+//
+//   http://www.apache.org/licenses/LICENSE-2.0
+//
+// Unless required by applicable law or agreed to in writing, software
+// distributed under the License is distributed on an "AS IS" BASIS,
+// WITHOUT WARRANTIES OR CONDITIONS OF ANY KIND, either express or implied.
+// See the License for the specific language governing permissions and
+// limitations under the License.
+
+package trc
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"os"
+
+	"github.com/scionproto/scion/go/lib/addr"
+	"github.com/scionproto/scion/go/lib/common"
+	"github.com/scionproto/scion/go/lib/keyconf"
+	"github.com/scionproto/scion/go/lib/scrypto"
+	trcv2 "github.com/scionproto/scion/go/lib/scrypto/trc/v2"
+	"github.com/scionproto/scion/go/tools/scion-pki/internal/pkicmn"
+)
+
+var (
+	signAS      string
+	signKeyType string
+	signKey     string
+	prevTRC     string
+)
+
+func runCreate(args []string) {
+	raw, err := ioutil.ReadFile(args[0])
+	if err != nil {
+		pkicmn.ErrorAndExit("Error reading payload: %s\n", err)
+	}
+	var t trcv2.TRC
+	if err := json.Unmarshal(raw, &t); err != nil {
+		pkicmn.ErrorAndExit("Error parsing payload: %s\n", err)
+	}
+	if err := t.ValidateInvariant(); err != nil {
+		pkicmn.ErrorAndExit("Error validating payload: %s\n", err)
+	}
+	encoded, err := trcv2.Encode(&t)
+	if err != nil {
+		pkicmn.ErrorAndExit("Error encoding payload: %s\n", err)
+	}
+	signed := &trcv2.Signed{EncodedTRC: encoded, Signatures: []trcv2.Signature{}}
+	if err := writeSigned(signed, args[1]); err != nil {
+		pkicmn.ErrorAndExit("Error writing signed TRC: %s\n", err)
+	}
+	os.Exit(0)
+}
+
+func runSign(args []string) {
+	signed, err := loadSigned(args[0])
+	if err != nil {
+		pkicmn.ErrorAndExit("Error loading signed TRC: %s\n", err)
+	}
+	if err := sign(signed); err != nil {
+		pkicmn.ErrorAndExit("Error signing TRC: %s\n", err)
+	}
+	if err := writeSigned(signed, args[1]); err != nil {
+		pkicmn.ErrorAndExit("Error writing signed TRC: %s\n", err)
+	}
+	os.Exit(0)
+}
+
+// sign adds the vote and proof of possession that are expected from the
+// configured AS and key type. Existing signatures with the same meta data are
+// replaced.
+func sign(signed *trcv2.Signed) error {
+	as, err := addr.ASFromString(signAS)
+	if err != nil {
+		return common.NewBasicError("Unable to parse AS", err)
+	}
+	var keyType trcv2.KeyType
+	if err := json.Unmarshal([]byte(fmt.Sprintf("%q", signKeyType)), &keyType); err != nil {
+		return common.NewBasicError("Unable to parse key type", err)
+	}
+	t, err := signed.EncodedTRC.Decode()
+	if err != nil {
+		return common.NewBasicError("Unable to decode payload", err)
+	}
+	var metas []trcv2.Protected
+	var keys []trcv2.KeyMeta
+	if vote, ok := t.Votes[as]; ok && vote.Type == keyType {
+		key, err := voteKey(t, as, vote)
+		if err != nil {
+			return err
+		}
+		metas = append(metas, trcv2.Protected{Type: trcv2.VoteSignature})
+		keys = append(keys, key)
+	}
+	for _, popType := range t.ProofOfPossession[as] {
+		if popType == keyType {
+			metas = append(metas, trcv2.Protected{Type: trcv2.POPSignature})
+			keys = append(keys, t.PrimaryASes[as].Keys[keyType])
+		}
+	}
+	if len(metas) == 0 {
+		return common.NewBasicError("Neither vote nor proof of possession expected", nil,
+			"as", as, "keyType", keyType)
+	}
+	for i, p := range metas {
+		p.Algorithm = keys[i].Algorithm
+		p.KeyType = keyType
+		p.KeyVersion = keys[i].KeyVersion
+		p.AS = as
+		priv, err := keyconf.LoadKey(signKey, p.Algorithm)
+		if err != nil {
+			return common.NewBasicError("Unable to load private key", err, "file", signKey)
+		}
+		sig, err := trcv2.Sign(p, signed.EncodedTRC, priv)
+		if err != nil {
+			return err
+		}
+		err = scrypto.Verify(trcv2.SigInput(sig.EncodedProtected, signed.EncodedTRC),
+			sig.Signature, keys[i].Key, p.Algorithm)
+		if err != nil {
+			return common.NewBasicError("Private key does not match public key", err,
+				"type", p.Type, "keyVersion", p.KeyVersion)
+		}
+		if signed.Signatures, err = replaceSignature(signed.Signatures, sig); err != nil {
+			return err
+		}
+		pkicmn.QuietPrint("Added %s signature of %s with %s key version %d\n", p.Type, as,
+			keyType, p.KeyVersion)
+	}
+	return nil
+}
+
+// voteKey returns the key that is used to cast the vote. The vote is cast
+// with a key of the previous TRC, which is only needed if the key has been
+// modified in the update.
+func voteKey(t *trcv2.TRC, as addr.AS, vote trcv2.Vote) (trcv2.KeyMeta, error) {
+	if prevTRC != "" {
+		prev, err := loadSigned(prevTRC)
+		if err != nil {
+			return trcv2.KeyMeta{}, common.NewBasicError("Unable to load previous TRC", err)
+		}
+		p, err := prev.EncodedTRC.Decode()
+		if err != nil {
+			return trcv2.KeyMeta{}, common.NewBasicError("Unable to decode previous TRC", err)
+		}
+		t = p
+	}
+	key, ok := t.PrimaryASes[as].Keys[vote.Type]
+	if !ok || key.KeyVersion != vote.KeyVersion {
+		return trcv2.KeyMeta{}, common.NewBasicError("Voting key not found, "+
+			"previous TRC required", nil, "as", as, "keyType", vote.Type,
+			"keyVersion", vote.KeyVersion)
+	}
+	return key, nil
+}
+
+func runCombine(args []string) {
+	var combined *trcv2.Signed
+	for _, file := range args[1:] {
+		signed, err := loadSigned(file)
+		if err != nil {
+			pkicmn.ErrorAndExit("Error loading signed TRC: %s\n", err)
+		}
+		if combined == nil {
+			combined = &trcv2.Signed{EncodedTRC: signed.EncodedTRC}
+		}
+		if !bytes.Equal(combined.EncodedTRC, signed.EncodedTRC) {
+			pkicmn.ErrorAndExit("Error combining signed TRCs: payload of %s differs\n", file)
+		}
+		for _, sig := range signed.Signatures {
+			if combined.Signatures, err = replaceSignature(combined.Signatures, sig); err != nil {
+				pkicmn.ErrorAndExit("Error combining signed TRCs: %s\n", err)
+			}
+		}
+	}
+	if err := writeSigned(combined, args[0]); err != nil {
+		pkicmn.ErrorAndExit("Error writing signed TRC: %s\n", err)
+	}
+	os.Exit(0)
+}
+
+func runVerify(args []string) {
+	signed, err := loadSigned(args[0])
+	if err != nil {
+		pkicmn.ErrorAndExit("Error loading signed TRC: %s\n", err)
+	}
+	if err := verify(signed); err != nil {
+		pkicmn.QuietPrint("Verification of %s FAILED. Reason: %s\n", args[0], err)
+		os.Exit(2)
+	}
+	pkicmn.QuietPrint("Verification of %s SUCCEEDED.\n", args[0])
+	os.Exit(0)
+}
+
+func verify(signed *trcv2.Signed) error {
+	t, err := signed.EncodedTRC.Decode()
+	if err != nil {
+		return common.NewBasicError("Unable to decode payload", err)
+	}
+	if t.Base() {
+		v := trcv2.BaseVerifier{
+			TRC:        t,
+			Encoded:    signed.EncodedTRC,
+			Signatures: signed.Signatures,
+		}
+		return v.Verify()
+	}
+	if prevTRC == "" {
+		return common.NewBasicError("Previous TRC required to verify update", nil)
+	}
+	prevSigned, err := loadSigned(prevTRC)
+	if err != nil {
+		return common.NewBasicError("Unable to load previous TRC", err)
+	}
+	prev, err := prevSigned.EncodedTRC.Decode()
+	if err != nil {
+		return common.NewBasicError("Unable to decode previous TRC", err)
+	}
+	v := trcv2.UpdateVerifier{
+		Prev:        prev,
+		Next:        t,
+		NextEncoded: signed.EncodedTRC,
+		Signatures:  signed.Signatures,
+	}
+	_, err = v.Verify()
+	return err
+}
+
+// replaceSignature adds sig to sigs. An existing signature with the same type,
+// AS and key type is replaced.
+func replaceSignature(sigs []trcv2.Signature, sig trcv2.Signature) ([]trcv2.Signature, error) {
+	p, err := sig.EncodedProtected.Decode()
+	if err != nil {
+		return nil, common.NewBasicError("Unable to decode protected meta data", err)
+	}
+	for i, other := range sigs {
+		o, err := other.EncodedProtected.Decode()
+		if err != nil {
+			return nil, common.NewBasicError("Unable to decode protected meta data", err)
+		}
+		if o.Type == p.Type && o.AS == p.AS && o.KeyType == p.KeyType {
+			sigs[i] = sig
+			return sigs, nil
+		}
+	}
+	return append(sigs, sig), nil
+}
+
+func loadSigned(file string) (*trcv2.Signed, error) {
+	raw, err := ioutil.ReadFile(file)
+	if err != nil {
+		return nil, err
+	}
+	signed, err := trcv2.ParseSigned(raw)
+	if err != nil {
+		return nil, common.NewBasicError("Unable to parse signed TRC", err, "file", file)
+	}
+	return &signed, nil
+}
+
+func writeSigned(signed *trcv2.Signed, file string) error {
+	raw, err := json.MarshalIndent(signed, "", "    ")
+	if err != nil {
+		return common.NewBasicError("Unable to encode signed TRC", err)
+	}
+	return pkicmn.WriteToFile(raw, file, 0644)
+}